@@ -17,10 +17,7 @@ limitations under the License.
 package e2e_test
 
 import (
-	"encoding/json"
 	"fmt"
-	"io"
-	"strings"
 	"time"
 
 	. "github.com/onsi/ginkgo"
@@ -118,11 +115,21 @@ var _ = Describe("Test addon rest api", func() {
 			}
 			res := post("/addons/mock-addon/enable", req)
 			defer res.Body.Close()
-			var addon apisv1.AddonStatusResponse
-			Expect(decodeResponseBody(res, &addon)).Should(Succeed())
-			Expect(addon.Name).Should(BeEquivalentTo("mock-addon"))
-			Expect(len(addon.Args)).Should(BeEquivalentTo(1))
-			Expect(addon.Args["testkey"]).Should(BeEquivalentTo("testvalue"))
+			var op apisv1.AddonOperationBase
+			Expect(decodeResponseBody(res, &op)).Should(Succeed())
+			Expect(op.Addon).Should(BeEquivalentTo("mock-addon"))
+			Expect(op.Type).Should(BeEquivalentTo("enable"))
+			waitAddonOperationSucceeded("mock-addon", op.ID)
+		})
+
+		It("list addon operations", func() {
+			res := get("/addons/mock-addon/operations")
+			defer res.Body.Close()
+			var operations apisv1.ListAddonOperationResponse
+			Expect(decodeResponseBody(res, &operations)).Should(Succeed())
+			Expect(operations.Total).Should(BeEquivalentTo(1))
+			Expect(operations.Operations[0].Args["testkey"]).Should(BeEquivalentTo("testvalue"))
+			Expect(len(operations.Operations[0].Logs)).ShouldNot(BeZero())
 		})
 
 		It("addon status", func() {
@@ -153,11 +160,10 @@ var _ = Describe("Test addon rest api", func() {
 			Eventually(func(g Gomega) {
 				res := put("/addons/mock-addon/update", req)
 				defer res.Body.Close()
-				var addonStatus apisv1.AddonStatusResponse
-				g.Expect(decodeResponseBody(res, &addonStatus)).Should(Succeed())
-				g.Expect(addonStatus.Name).Should(BeEquivalentTo("mock-addon"))
-				g.Expect(len(addonStatus.Args)).Should(BeEquivalentTo(1))
-				g.Expect(addonStatus.Args["testkey"]).Should(BeEquivalentTo("new-testvalue"))
+				var op apisv1.AddonOperationBase
+				g.Expect(decodeResponseBody(res, &op)).Should(Succeed())
+				g.Expect(op.Addon).Should(BeEquivalentTo("mock-addon"))
+				waitAddonOperationSucceeded("mock-addon", op.ID)
 
 				status := get("/addons/mock-addon/status")
 				var newaddonStatus apisv1.AddonStatusResponse
@@ -188,9 +194,10 @@ var _ = Describe("Test addon rest api", func() {
 		It("disable addon ", func() {
 			res := post("/addons/mock-addon/disable", nil)
 			defer res.Body.Close()
-			var addonStatus apisv1.AddonStatusResponse
-			Expect(decodeResponseBody(res, &addonStatus)).Should(Succeed())
-			Expect(addonStatus.Name).Should(BeEquivalentTo("mock-addon"))
+			var op apisv1.AddonOperationBase
+			Expect(decodeResponseBody(res, &op)).Should(Succeed())
+			Expect(op.Addon).Should(BeEquivalentTo("mock-addon"))
+			waitAddonOperationSucceeded("mock-addon", op.ID)
 		})
 
 		It("enable addon with not match system version requirement", func() {
@@ -201,17 +208,14 @@ var _ = Describe("Test addon rest api", func() {
 			}
 			res := post("/addons/not-match-addon/enable", req)
 			defer res.Body.Close()
-			type errResp struct {
-				BusinessCode int    `json:"BusinessCode"`
-				Message      string `json:"Message"`
-			}
-			var errResponse errResp
-			body, err := io.ReadAll(res.Body)
-			Expect(err).Should(BeNil())
-			err = json.Unmarshal(body, &errResponse)
-			Expect(err).Should(BeNil())
-			Expect(errResponse.BusinessCode).Should(BeEquivalentTo(50018))
-			Expect(strings.Contains(errResponse.Message, "fail to install"))
+			var op apisv1.AddonOperationBase
+			Expect(decodeResponseBody(res, &op)).Should(Succeed())
+			Eventually(func(g Gomega) {
+				detail := get(fmt.Sprintf("/addons/not-match-addon/operations/%s", op.ID))
+				g.Expect(decodeResponseBody(detail, &op)).Should(Succeed())
+				g.Expect(op.Phase).Should(BeEquivalentTo("Failed"))
+			}, 30*time.Second, 300*time.Millisecond).Should(Succeed())
+			Expect(op.Error).Should(ContainSubstring("system version requirement mismatch"))
 		})
 	})
 
@@ -220,9 +224,9 @@ var _ = Describe("Test addon rest api", func() {
 			req := apisv1.EnableAddonRequest{}
 			res := post("/addons/foo/enable", req)
 			defer res.Body.Close()
-			var addon apisv1.AddonStatusResponse
-			Expect(decodeResponseBody(res, &addon)).Should(Succeed())
-			Expect(addon.Name).Should(BeEquivalentTo("foo"))
+			var op apisv1.AddonOperationBase
+			Expect(decodeResponseBody(res, &op)).Should(Succeed())
+			Expect(op.Addon).Should(BeEquivalentTo("foo"))
 
 			Eventually(func(g Gomega) {
 				status := get("/addons/bar/status")
@@ -239,9 +243,9 @@ var _ = Describe("Test addon rest api", func() {
 			req := apisv1.EnableAddonRequest{}
 			res := post("/addons/mock-dep-addon/enable", req)
 			defer res.Body.Close()
-			var addon apisv1.AddonStatusResponse
-			Expect(decodeResponseBody(res, &addon)).Should(Succeed())
-			Expect(addon.Name).Should(BeEquivalentTo("mock-dep-addon"))
+			var op apisv1.AddonOperationBase
+			Expect(decodeResponseBody(res, &op)).Should(Succeed())
+			Expect(op.Addon).Should(BeEquivalentTo("mock-dep-addon"))
 
 			Eventually(func(g Gomega) {
 				status := get("/addons/mock-dep-addon/status")
@@ -265,3 +269,13 @@ var _ = Describe("Test addon rest api", func() {
 		})
 	})
 })
+
+func waitAddonOperationSucceeded(addonName, operationID string) {
+	Eventually(func(g Gomega) {
+		res := get(fmt.Sprintf("/addons/%s/operations/%s", addonName, operationID))
+		var op apisv1.AddonOperationBase
+		g.Expect(decodeResponseBody(res, &op)).Should(Succeed())
+		g.Expect(op.Error).Should(BeEmpty())
+		g.Expect(op.Phase).Should(BeEquivalentTo("Succeeded"))
+	}, 60*time.Second, 300*time.Millisecond).Should(Succeed())
+}
@@ -0,0 +1,237 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package limiter
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+
+	"github.com/kubevela/velaux/pkg/plugin/types"
+)
+
+// UserLimiterIdleTime the per-user limiter is removed after it is idle for this duration.
+var UserLimiterIdleTime = time.Minute * 10
+
+// State the current state of the limiters of one route, the empty route means the plugin level.
+type State struct {
+	Route   string       `json:"route,omitempty"`
+	Global  *BucketState `json:"global,omitempty"`
+	PerUser *BucketState `json:"perUser,omitempty"`
+	// Users the number of the active users that have the per-user limiters.
+	Users    int   `json:"users"`
+	Allowed  int64 `json:"allowed"`
+	Rejected int64 `json:"rejected"`
+}
+
+// BucketState the state of one token bucket
+type BucketState struct {
+	Rate  float64 `json:"rate"`
+	Burst int     `json:"burst"`
+	// Tokens the available tokens now, it is only available for the global bucket.
+	Tokens float64 `json:"tokens,omitempty"`
+}
+
+type userLimiter struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+type bucket struct {
+	route    string
+	config   types.RateLimit
+	global   *rate.Limiter
+	perUser  map[string]*userLimiter
+	allowed  int64
+	rejected int64
+}
+
+type pluginLimiter struct {
+	buckets     map[string]*bucket
+	lastCleanup time.Time
+}
+
+var cachePluginLimiter = map[string]*pluginLimiter{}
+var cacheLock sync.Mutex
+
+// InvalidateCache remove the limiters of the plugin, it should be called after the plugin reloaded.
+func InvalidateCache(pluginID string) {
+	cacheLock.Lock()
+	defer cacheLock.Unlock()
+	delete(cachePluginLimiter, pluginID)
+}
+
+// burstOf the bucket size is the rate rounded up by default
+func burstOf(r float64, burst int) int {
+	if burst > 0 {
+		return burst
+	}
+	if burst = int(math.Ceil(r)); burst < 1 {
+		return 1
+	}
+	return burst
+}
+
+func newLimiter(r float64, burst int) *rate.Limiter {
+	return rate.NewLimiter(rate.Limit(r), burstOf(r, burst))
+}
+
+func newBucket(route string, config types.RateLimit) *bucket {
+	b := &bucket{route: route, config: config, perUser: map[string]*userLimiter{}}
+	if config.Global > 0 {
+		b.global = newLimiter(config.Global, config.Burst)
+	}
+	return b
+}
+
+func (b *bucket) reserve(now time.Time, user string) []*rate.Reservation {
+	var reservations []*rate.Reservation
+	if b.global != nil {
+		reservations = append(reservations, b.global.ReserveN(now, 1))
+	}
+	if b.config.PerUser > 0 {
+		u, ok := b.perUser[user]
+		if !ok {
+			u = &userLimiter{limiter: newLimiter(b.config.PerUser, b.config.Burst)}
+			b.perUser[user] = u
+		}
+		u.lastSeen = now
+		reservations = append(reservations, u.limiter.ReserveN(now, 1))
+	}
+	return reservations
+}
+
+func (b *bucket) state() State {
+	s := State{Route: b.route, Users: len(b.perUser), Allowed: b.allowed, Rejected: b.rejected}
+	if b.global != nil {
+		s.Global = &BucketState{Rate: float64(b.global.Limit()), Burst: b.global.Burst(), Tokens: b.global.Tokens()}
+	}
+	if b.config.PerUser > 0 {
+		s.PerUser = &BucketState{Rate: b.config.PerUser, Burst: burstOf(b.config.PerUser, b.config.Burst)}
+	}
+	return s
+}
+
+func routeKey(route *types.Route) string {
+	method := route.Method
+	if method == "" {
+		method = "GET"
+	}
+	return strings.ToUpper(method) + " " + route.Path
+}
+
+func (p *pluginLimiter) bucket(key string, config *types.RateLimit) *bucket {
+	if config == nil || (config.Global <= 0 && config.PerUser <= 0) {
+		return nil
+	}
+	b, ok := p.buckets[key]
+	if !ok {
+		b = newBucket(key, *config)
+		p.buckets[key] = b
+	}
+	return b
+}
+
+func (p *pluginLimiter) cleanup(now time.Time) {
+	if now.Sub(p.lastCleanup) < time.Minute {
+		return
+	}
+	p.lastCleanup = now
+	for _, b := range p.buckets {
+		for user, u := range b.perUser {
+			if now.Sub(u.lastSeen) > UserLimiterIdleTime {
+				delete(b.perUser, user)
+			}
+		}
+	}
+}
+
+// Allow checking whether the request of the user is allowed by the limiters of the plugin and the route.
+// If not allowed, return the duration the client should wait before retrying.
+func Allow(plugin *types.Plugin, route *types.Route, user string) (bool, time.Duration) {
+	if plugin.RateLimit == nil && (route == nil || route.RateLimit == nil) {
+		return true, 0
+	}
+	cacheLock.Lock()
+	defer cacheLock.Unlock()
+	p, ok := cachePluginLimiter[plugin.PluginID()]
+	if !ok {
+		p = &pluginLimiter{buckets: map[string]*bucket{}}
+		cachePluginLimiter[plugin.PluginID()] = p
+	}
+	now := time.Now()
+	p.cleanup(now)
+
+	var buckets []*bucket
+	if b := p.bucket("", plugin.RateLimit); b != nil {
+		buckets = append(buckets, b)
+	}
+	if route != nil {
+		if b := p.bucket(routeKey(route), route.RateLimit); b != nil {
+			buckets = append(buckets, b)
+		}
+	}
+
+	var reservations []*rate.Reservation
+	var delay time.Duration
+	for _, b := range buckets {
+		for _, r := range b.reserve(now, user) {
+			reservations = append(reservations, r)
+			if !r.OK() {
+				delay = time.Second
+			} else if d := r.DelayFrom(now); d > delay {
+				delay = d
+			}
+		}
+	}
+	if delay == 0 {
+		for _, b := range buckets {
+			b.allowed++
+		}
+		return true, 0
+	}
+	// Give back the tokens, the request is not sent.
+	for _, r := range reservations {
+		r.CancelAt(now)
+	}
+	for _, b := range buckets {
+		b.rejected++
+	}
+	return false, delay
+}
+
+// GetState return the state of the limiters of the plugin, sorted by the route.
+func GetState(pluginID string) []State {
+	cacheLock.Lock()
+	defer cacheLock.Unlock()
+	p, ok := cachePluginLimiter[pluginID]
+	if !ok {
+		return nil
+	}
+	var states []State
+	for _, b := range p.buckets {
+		states = append(states, b.state())
+	}
+	sort.Slice(states, func(i, j int) bool {
+		return states[i].Route < states[j].Route
+	})
+	return states
+}
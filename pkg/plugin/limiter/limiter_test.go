@@ -0,0 +1,58 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package limiter
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+
+	"github.com/kubevela/velaux/pkg/plugin/types"
+)
+
+func TestAllow(t *testing.T) {
+	plugin := &types.Plugin{JSONData: types.JSONData{ID: "limiter-test", RateLimit: &types.RateLimit{Global: 0.001, Burst: 3}}}
+	route := &types.Route{Path: "/nodes", RateLimit: &types.RateLimit{PerUser: 0.001, Burst: 2}}
+	defer InvalidateCache(plugin.PluginID())
+
+	allowed, _ := Allow(plugin, route, "alice")
+	assert.Equal(t, allowed, true)
+	allowed, _ = Allow(plugin, route, "alice")
+	assert.Equal(t, allowed, true)
+	// The per-user bucket is empty
+	allowed, retryAfter := Allow(plugin, route, "alice")
+	assert.Equal(t, allowed, false)
+	assert.Equal(t, retryAfter > 0, true)
+	// The rejected request does not consume the global tokens
+	allowed, _ = Allow(plugin, route, "bob")
+	assert.Equal(t, allowed, true)
+	// The global bucket is empty
+	allowed, _ = Allow(plugin, &types.Route{Path: "/pods"}, "bob")
+	assert.Equal(t, allowed, false)
+
+	states := GetState(plugin.PluginID())
+	assert.Equal(t, len(states), 2)
+	assert.Equal(t, states[0].Route, "")
+	assert.Equal(t, states[0].Allowed, int64(3))
+	assert.Equal(t, states[0].Rejected, int64(2))
+	assert.Equal(t, states[1].Route, "GET /nodes")
+	assert.Equal(t, states[1].Users, 2)
+	assert.Equal(t, states[1].PerUser.Burst, 2)
+
+	InvalidateCache(plugin.PluginID())
+	assert.Equal(t, len(GetState(plugin.PluginID())), 0)
+}
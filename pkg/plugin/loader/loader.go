@@ -57,6 +57,12 @@ var (
 
 	// ErrInvalidBackendAuthEmptySecret -
 	ErrInvalidBackendAuthEmptySecret = errors.New("the authSecret field is required when the auth type is defined")
+
+	// ErrInvalidLimit -
+	ErrInvalidLimit = errors.New("the rateLimit or maxBodyBytes is invalid in plugin.json, the values must not be negative")
+
+	// ErrInvalidHook -
+	ErrInvalidHook = errors.New("the hook is invalid in plugin.json, the name, events and url fields are required, and only the application.created and application.deployed events support the validating hook")
 )
 
 var (
@@ -265,6 +271,54 @@ func validatePluginJSON(data types.JSONData) error {
 		return ErrInvalidBackendAuth
 	}
 
+	if !validLimit(data.RateLimit, data.MaxBodyBytes) {
+		return ErrInvalidLimit
+	}
+	for _, r := range data.Routes {
+		if r != nil && !validLimit(r.RateLimit, r.MaxBodyBytes) {
+			return ErrInvalidLimit
+		}
+	}
+
+	for _, h := range data.Hooks {
+		if err := validateHook(data, h); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func validLimit(rateLimit *types.RateLimit, maxBodyBytes int64) bool {
+	if maxBodyBytes < 0 {
+		return false
+	}
+	return rateLimit == nil || (rateLimit.Global >= 0 && rateLimit.PerUser >= 0 && rateLimit.Burst >= 0)
+}
+
+func validateHook(data types.JSONData, h *types.Hook) error {
+	if h == nil || h.Name == "" || h.URL == "" || len(h.Events) == 0 {
+		return ErrInvalidHook
+	}
+	if h.GetType() != types.HookTypeValidating && h.GetType() != types.HookTypeNotifying {
+		return ErrInvalidHook
+	}
+	if h.GetFailurePolicy() != types.HookFailurePolicyFail && h.GetFailurePolicy() != types.HookFailurePolicyIgnore {
+		return ErrInvalidHook
+	}
+	for _, e := range h.Events {
+		if !e.IsValid() || (h.GetType() == types.HookTypeValidating && !e.IsValidatable()) {
+			return ErrInvalidHook
+		}
+	}
+	u, err := url.Parse(h.URL)
+	if err != nil {
+		return ErrInvalidHook
+	}
+	// The relative path must be served by the backend service.
+	if !u.IsAbs() && data.BackendType != types.KubeService {
+		return ErrInvalidHook
+	}
 	return nil
 }
 
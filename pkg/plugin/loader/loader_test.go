@@ -31,3 +31,18 @@ func TestLoad(t *testing.T) {
 	assert.Equal(t, len(plugins), 1)
 	assert.Equal(t, plugins[0].ID, "plugin-test")
 }
+
+func TestValidateHook(t *testing.T) {
+	data := types.JSONData{ID: "plugin-test", Type: types.PageApp}
+	data.Hooks = []*types.Hook{{Name: "notify", URL: "http://127.0.0.1/notify", Events: []types.HookEvent{types.HookEventApplicationDeleted}}}
+	assert.Equal(t, validatePluginJSON(data), nil)
+
+	data.Hooks = []*types.Hook{{Name: "validate", Type: types.HookTypeValidating, URL: "http://127.0.0.1/validate", Events: []types.HookEvent{types.HookEventApplicationDeleted}}}
+	assert.Equal(t, validatePluginJSON(data), ErrInvalidHook)
+
+	data.Hooks = []*types.Hook{{Name: "notify", URL: "/notify", Events: []types.HookEvent{types.HookEventProjectCreated}}}
+	assert.Equal(t, validatePluginJSON(data), ErrInvalidHook)
+	data.BackendType = types.KubeService
+	data.BackendService = &types.KubernetesService{Name: "plugin-backend"}
+	assert.Equal(t, validatePluginJSON(data), nil)
+}
@@ -0,0 +1,122 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package loader
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"k8s.io/klog/v2"
+)
+
+// DefaultWatchDebounce the default duration to wait for more changes before calling the handler.
+// The plugin build tools usually write many files at once.
+var DefaultWatchDebounce = time.Millisecond * 500
+
+// Watcher watches the plugin paths, and calls the handler after the files changed.
+// It is used by the plugin development mode.
+type Watcher struct {
+	paths    []string
+	handler  func()
+	debounce time.Duration
+	watcher  *fsnotify.Watcher
+}
+
+// NewWatcher create a watcher for the plugin paths
+func NewWatcher(paths []string, handler func()) (*Watcher, error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	w := &Watcher{
+		paths:    paths,
+		handler:  handler,
+		debounce: DefaultWatchDebounce,
+		watcher:  watcher,
+	}
+	for _, p := range paths {
+		w.addDir(p)
+	}
+	return w, nil
+}
+
+// addDir watch the directory and all sub directories, fsnotify does not support watching recursively.
+func (w *Watcher) addDir(root string) {
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if path != root && (d.Name() == "node_modules" || strings.HasPrefix(d.Name(), ".")) {
+			return filepath.SkipDir
+		}
+		if err := w.watcher.Add(path); err != nil {
+			klog.Warningf("failed to watch the plugin path %s: %s", path, err.Error())
+		}
+		return nil
+	})
+	if err != nil {
+		klog.Warningf("failed to walk the plugin path %s: %s", root, err.Error())
+	}
+}
+
+// Start watching the changes until the context is done.
+func (w *Watcher) Start(ctx context.Context) {
+	defer func() {
+		if err := w.watcher.Close(); err != nil {
+			klog.Warningf("failed to close the plugin watcher: %s", err.Error())
+		}
+	}()
+	klog.Infof("Watching the plugin paths %v for changes", w.paths)
+	timer := time.NewTimer(w.debounce)
+	timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case event, ok := <-w.watcher.Events:
+			if !ok {
+				return
+			}
+			if event.Op&fsnotify.Create != 0 {
+				if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+					w.addDir(event.Name)
+				}
+			}
+			if event.Op == fsnotify.Chmod {
+				continue
+			}
+			klog.V(4).Infof("the plugin file %s changed: %s", event.Name, event.Op.String())
+			timer.Reset(w.debounce)
+		case err, ok := <-w.watcher.Errors:
+			if !ok {
+				return
+			}
+			klog.Errorf("failed to watch the plugin paths: %s", err.Error())
+		case <-timer.C:
+			w.handler()
+		}
+	}
+}
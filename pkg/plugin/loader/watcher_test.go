@@ -0,0 +1,56 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package loader
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+)
+
+func TestWatcher(t *testing.T) {
+	dir := t.TempDir()
+	changed := make(chan struct{}, 10)
+	w, err := NewWatcher([]string{dir}, func() {
+		changed <- struct{}{}
+	})
+	assert.NilError(t, err)
+	w.debounce = time.Millisecond * 50
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go w.Start(ctx)
+
+	// The files in the new sub directory should be watched too.
+	pluginDir := filepath.Join(dir, "plugin-test")
+	assert.NilError(t, os.Mkdir(pluginDir, 0750))
+	select {
+	case <-changed:
+	case <-time.After(time.Second * 5):
+		t.Fatal("the handler is not called after the directory created")
+	}
+	time.Sleep(time.Millisecond * 100)
+	assert.NilError(t, os.WriteFile(filepath.Join(pluginDir, "plugin.json"), []byte("{}"), 0600))
+	select {
+	case <-changed:
+	case <-time.After(time.Second * 5):
+		t.Fatal("the handler is not called after the file changed")
+	}
+}
@@ -26,10 +26,9 @@ import (
 	"k8s.io/apiserver/pkg/authentication/user"
 	"k8s.io/apiserver/pkg/endpoints/request"
 	"k8s.io/client-go/rest"
-	"k8s.io/client-go/transport"
 
+	"github.com/julienschmidt/httprouter"
 	pkgmulticluster "github.com/kubevela/pkg/multicluster"
-	"github.com/oam-dev/kubevela/pkg/multicluster"
 
 	"github.com/kubevela/velaux/pkg/plugin/types"
 	"github.com/kubevela/velaux/pkg/server/domain/service"
@@ -44,30 +43,30 @@ type kubeAPIProxy struct {
 	baseURL    *url.URL
 }
 
-type disableImpersonate struct {
-	rt http.RoundTripper
-}
+// ClusterParamKey the path parameter or the query parameter key to specify the cluster
+const ClusterParamKey = "cluster"
 
-// By the default, NewImpersonatingRoundTripper is called, the impersonate set to true.
-func (rt *disableImpersonate) RoundTrip(req *http.Request) (*http.Response, error) {
-	query := req.URL.Query()
-	query.Set("impersonate", "false")
-	req.URL.RawQuery = query.Encode()
-	return rt.rt.RoundTrip(req)
-}
+// ClusterHeader the request header to specify the cluster
+const ClusterHeader = "X-Vela-Cluster"
 
-// DisableClusterGatewayAuthTransportWrapper disable impersonate feature in Cluster Gateway
-func DisableClusterGatewayAuthTransportWrapper() transport.WrapperFunc {
-	return func(rt http.RoundTripper) (ret http.RoundTripper) {
-		return &disableImpersonate{rt: rt}
+// GetRequestCluster return the cluster that the request wants to access, the priority is: path parameter, header, query parameter.
+// The empty value means the hub cluster.
+func GetRequestCluster(req *http.Request, params httprouter.Params) string {
+	if cluster := params.ByName(ClusterParamKey); cluster != "" {
+		return cluster
 	}
+	if cluster := req.Header.Get(ClusterHeader); cluster != "" {
+		return cluster
+	}
+	return req.URL.Query().Get(ClusterParamKey)
 }
 
 // NewKubeAPIProxy create a proxy for the Kubernetes API
+// The requests to the managed clusters are sent through the cluster-gateway, and the cluster-gateway impersonates
+// the user and the plugin group, so the plugin role must be granted in the managed clusters.
 func NewKubeAPIProxy(kubeConfig *rest.Config, plugin *types.Plugin) (BackendProxy, error) {
 	configShallowCopy := rest.CopyConfig(kubeConfig)
 	configShallowCopy.Wrap(pkgmulticluster.NewTransportWrapper())
-	configShallowCopy.Wrap(DisableClusterGatewayAuthTransportWrapper())
 	httpClient, err := rest.HTTPClientFor(configShallowCopy)
 	if err != nil {
 		return nil, err
@@ -105,16 +104,16 @@ func (k *kubeAPIProxy) Handler(req *http.Request, res http.ResponseWriter) {
 	userName, ok := req.Context().Value(&apis.CtxKeyUser).(string)
 	if !ok {
 		bcode.ReturnHTTPError(req, res, bcode.ErrUnauthorized)
+		return
 	}
 	director := func(req *http.Request) {
 		var base = *k.baseURL
 		base.Path = req.URL.Path
-		for k, v := range req.URL.Query() {
-			for _, v1 := range v {
-				base.Query().Add(k, v1)
-			}
-		}
+		query := req.URL.Query()
+		query.Del(ClusterParamKey)
+		base.RawQuery = query.Encode()
 		req.URL = &base
+		req.Header.Del(ClusterHeader)
 	}
 	rp := &httputil.ReverseProxy{Director: director, Transport: k.httpClient.Transport, ErrorLog: log.Default()}
 	req = req.WithContext(
@@ -122,9 +121,5 @@ func (k *kubeAPIProxy) Handler(req *http.Request, res http.ResponseWriter) {
 			Name:   userName,
 			Groups: []string{service.GeneratePluginSubjectName(k.plugin)},
 		}))
-	if req.URL.Query().Get("cluster") != "" {
-		req = req.WithContext(
-			multicluster.ContextWithClusterName(req.Context(), req.URL.Query().Get("cluster")))
-	}
 	rp.ServeHTTP(res, req)
 }
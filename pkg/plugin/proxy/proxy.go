@@ -19,6 +19,7 @@ package proxy
 import (
 	"fmt"
 	"net/http"
+	"sync"
 
 	"k8s.io/client-go/rest"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -38,10 +39,24 @@ type BackendProxy interface {
 }
 
 var proxyCache = make(map[*types.Plugin]BackendProxy)
+var proxyCacheLock sync.RWMutex
+
+// InvalidateCache remove the cached proxies of the plugin, it should be called after the plugin reloaded.
+func InvalidateCache(pluginID string) {
+	proxyCacheLock.Lock()
+	defer proxyCacheLock.Unlock()
+	for plugin := range proxyCache {
+		if plugin.PluginID() == pluginID {
+			delete(proxyCache, plugin)
+		}
+	}
+}
 
 // NewBackendPluginProxy create or return a proxy tool for a plugin
 func NewBackendPluginProxy(plugin *types.Plugin, kubeClient client.Client, kubeConfig *rest.Config) (BackendProxy, error) {
+	proxyCacheLock.RLock()
 	p, ok := proxyCache[plugin]
+	proxyCacheLock.RUnlock()
 	if ok {
 		return p, nil
 	}
@@ -59,6 +74,8 @@ func NewBackendPluginProxy(plugin *types.Plugin, kubeClient client.Client, kubeC
 	default:
 		return nil, ErrAvailablePlugin
 	}
+	proxyCacheLock.Lock()
 	proxyCache[plugin] = p
+	proxyCacheLock.Unlock()
 	return p, nil
 }
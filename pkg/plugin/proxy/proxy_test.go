@@ -22,6 +22,7 @@ import (
 	"net/http/httptest"
 	"net/url"
 
+	"github.com/julienschmidt/httprouter"
 	kubevelatypes "github.com/oam-dev/kubevela/apis/types"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -64,6 +65,15 @@ var _ = Describe("Test proxy", func() {
 		Expect(res.Code).To(Equal(200))
 	})
 
+	It("Test getting the request cluster", func() {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/nodes?cluster=query", nil)
+		Expect(GetRequestCluster(req, nil)).Should(Equal("query"))
+		req.Header.Set(ClusterHeader, "header")
+		Expect(GetRequestCluster(req, nil)).Should(Equal("header"))
+		Expect(GetRequestCluster(req, httprouter.Params{{Key: ClusterParamKey, Value: "path"}})).Should(Equal("path"))
+		Expect(GetRequestCluster(httptest.NewRequest(http.MethodGet, "/api/v1/nodes", nil), nil)).Should(Equal(""))
+	})
+
 	It("Test kube-service proxy", func() {
 		plugin := &types.Plugin{
 			JSONData: types.JSONData{
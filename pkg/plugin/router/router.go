@@ -20,6 +20,7 @@ import (
 	"net/http"
 	"path"
 	"strings"
+	"sync"
 
 	"github.com/julienschmidt/httprouter"
 	"k8s.io/klog/v2"
@@ -34,6 +35,14 @@ var DefaultPluginResourceKey = "pluginName"
 type Handle func(http.ResponseWriter, *http.Request, httprouter.Params, *types.Plugin, *types.Route)
 
 var cachePluginRouter = map[string]http.Handler{}
+var cacheLock sync.RWMutex
+
+// InvalidateCache remove the cached router of the plugin, it should be called after the plugin reloaded.
+func InvalidateCache(pluginID string) {
+	cacheLock.Lock()
+	defer cacheLock.Unlock()
+	delete(cachePluginRouter, pluginID)
+}
 
 type defaultRouter struct {
 	h      Handle
@@ -50,7 +59,10 @@ func newDefaultRouter(h Handle, plugin *types.Plugin) *defaultRouter {
 
 // GenerateHTTPRouter create and return the plugin backend router
 func GenerateHTTPRouter(plugin *types.Plugin, pathPrefix string, h Handle) http.Handler {
-	if r, e := cachePluginRouter[plugin.PluginID()]; e {
+	cacheLock.RLock()
+	r, e := cachePluginRouter[plugin.PluginID()]
+	cacheLock.RUnlock()
+	if e {
 		return r
 	}
 	var router http.Handler
@@ -72,6 +84,8 @@ func GenerateHTTPRouter(plugin *types.Plugin, pathPrefix string, h Handle) http.
 		}
 		router = r
 	}
+	cacheLock.Lock()
 	cachePluginRouter[plugin.PluginID()] = router
+	cacheLock.Unlock()
 	return router
 }
@@ -97,6 +97,14 @@ type JSONData struct {
 	// Routes define the route to proxy the backend server.
 	Routes      []*Route     `json:"routes,omitempty"`
 	Requirement *Requirement `json:"requirement,omitempty"`
+	// Storage define the persistent storage for the plugin, it is disabled if not specified.
+	Storage *Storage `json:"storage,omitempty"`
+	// Hooks define the webhooks called by VelaUX when the lifecycle events happen.
+	Hooks []*Hook `json:"hooks,omitempty"`
+	// RateLimit limits the rate of all proxy requests of the plugin.
+	RateLimit *RateLimit `json:"rateLimit,omitempty"`
+	// MaxBodyBytes the max bytes of the proxy request body, it could be overridden by the route.
+	MaxBodyBytes int64 `json:"maxBodyBytes,omitempty"`
 }
 
 // Includes means the menus that this plugin include.
@@ -137,6 +145,213 @@ type Route struct {
 
 	// Proxy parameters
 	ProxyHeaders []Header `json:"headers,omitempty"`
+
+	// Limit parameters
+	// RateLimit limits the rate of the requests matched this route, it works with the limit of the plugin level.
+	RateLimit *RateLimit `json:"rateLimit,omitempty"`
+	// MaxBodyBytes the max bytes of the request body, it overrides the limit of the plugin level.
+	MaxBodyBytes int64 `json:"maxBodyBytes,omitempty"`
+}
+
+// RateLimit describes the token bucket limiters of the proxy requests.
+type RateLimit struct {
+	// PerUser the tokens per second for each user, zero means no limit.
+	PerUser float64 `json:"perUser,omitempty"`
+	// Global the tokens per second for all users, zero means no limit.
+	Global float64 `json:"global,omitempty"`
+	// Burst the bucket size, default is the rate rounded up.
+	Burst int `json:"burst,omitempty"`
+}
+
+// GetMaxBodyBytes return the max bytes of the request body, zero means no limit.
+func (p *Plugin) GetMaxBodyBytes(route *Route) int64 {
+	if route != nil && route.MaxBodyBytes > 0 {
+		return route.MaxBodyBytes
+	}
+	return p.MaxBodyBytes
+}
+
+// Storage describes the persistent storage that the plugin requests.
+// The data is saved to the VelaUX datastore, so the plugin doesn't need to deploy the database.
+type Storage struct {
+	// Resource is the RBAC resource to check the permission, the actions include: list, detail, update and delete.
+	// Default is `storage`, means the resource path is plugin:{pluginName}/storage:*
+	Resource string `json:"resource,omitempty"`
+	// Scopes the scopes of the storage allowed to use, includes: platform, project and user.
+	// Only the platform scope is allowed by default.
+	Scopes []StorageScope `json:"scopes,omitempty"`
+	// Quota the max bytes of all values in one scope.
+	Quota int64 `json:"quota,omitempty"`
+	// MaxValueBytes the max bytes of one value.
+	MaxValueBytes int64 `json:"maxValueBytes,omitempty"`
+}
+
+// StorageScope the scope of the plugin storage
+type StorageScope string
+
+const (
+	// StorageScopePlatform the data is shared by the whole platform.
+	StorageScopePlatform StorageScope = "platform"
+	// StorageScopeProject the data belongs to one project.
+	StorageScopeProject StorageScope = "project"
+	// StorageScopeUser the data belongs to the current user, only the user self could access it.
+	StorageScopeUser StorageScope = "user"
+)
+
+const (
+	// DefaultStorageResource the default RBAC resource of the plugin storage
+	DefaultStorageResource = "storage"
+	// DefaultStorageQuota the default max bytes of all values in one scope, 1MiB
+	DefaultStorageQuota int64 = 1 << 20
+	// DefaultStorageMaxValueBytes the default max bytes of one value, 64KiB
+	DefaultStorageMaxValueBytes int64 = 64 << 10
+)
+
+// GetResource return the RBAC resource of the storage
+func (s *Storage) GetResource() string {
+	if s.Resource == "" {
+		return DefaultStorageResource
+	}
+	return s.Resource
+}
+
+// GetQuota return the max bytes of all values in one scope
+func (s *Storage) GetQuota() int64 {
+	if s.Quota <= 0 {
+		return DefaultStorageQuota
+	}
+	return s.Quota
+}
+
+// GetMaxValueBytes return the max bytes of one value
+func (s *Storage) GetMaxValueBytes() int64 {
+	if s.MaxValueBytes <= 0 {
+		return DefaultStorageMaxValueBytes
+	}
+	return s.MaxValueBytes
+}
+
+// AllowScope checking whether the scope is allowed to use
+func (s *Storage) AllowScope(scope StorageScope) bool {
+	if len(s.Scopes) == 0 {
+		return scope == StorageScopePlatform
+	}
+	for _, allowed := range s.Scopes {
+		if allowed == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// Hook describes a webhook that VelaUX calls with a signed JSON payload when the lifecycle events happen.
+type Hook struct {
+	Name   string      `json:"name"`
+	Events []HookEvent `json:"events"`
+	// URL the absolute URL of the webhook, or the path of the backend service if the backend type is kube-service.
+	URL string `json:"url"`
+	// Type the hook type, includes: validating and notifying. Default is notifying.
+	Type HookType `json:"type,omitempty"`
+	// TimeoutSeconds the timeout of calling the webhook, default is 10 seconds.
+	TimeoutSeconds int `json:"timeoutSeconds,omitempty"`
+	// FailurePolicy defines how to handle the error of calling a validating webhook, includes: Fail and Ignore.
+	// Default is Fail.
+	FailurePolicy HookFailurePolicy `json:"failurePolicy,omitempty"`
+}
+
+// HookEvent the lifecycle event of VelaUX
+type HookEvent string
+
+const (
+	// HookEventApplicationCreated the application is created, supports the validating hook.
+	HookEventApplicationCreated HookEvent = "application.created"
+	// HookEventApplicationDeployed the application is deployed, supports the validating hook.
+	HookEventApplicationDeployed HookEvent = "application.deployed"
+	// HookEventApplicationDeleted the application is deleted
+	HookEventApplicationDeleted HookEvent = "application.deleted"
+	// HookEventWorkflowRecordFinished the workflow record is finished
+	HookEventWorkflowRecordFinished HookEvent = "workflowRecord.finished"
+	// HookEventProjectCreated the project is created
+	HookEventProjectCreated HookEvent = "project.created"
+	// HookEventClusterUnreachable the cluster becomes unreachable
+	HookEventClusterUnreachable HookEvent = "cluster.unreachable"
+	// HookEventClusterRecovered the unreachable cluster becomes reachable again
+	HookEventClusterRecovered HookEvent = "cluster.recovered"
+	// HookEventClusterOverloaded the CPU or memory usage of the cluster exceeds the threshold
+	HookEventClusterOverloaded HookEvent = "cluster.overloaded"
+)
+
+// IsValid checking the hook event
+func (e HookEvent) IsValid() bool {
+	switch e {
+	case HookEventApplicationCreated, HookEventApplicationDeployed, HookEventApplicationDeleted,
+		HookEventWorkflowRecordFinished, HookEventProjectCreated,
+		HookEventClusterUnreachable, HookEventClusterRecovered, HookEventClusterOverloaded:
+		return true
+	}
+	return false
+}
+
+// IsValidatable checking whether the event could be vetoed by the validating hooks
+func (e HookEvent) IsValidatable() bool {
+	return e == HookEventApplicationCreated || e == HookEventApplicationDeployed
+}
+
+// HookType the hook type
+type HookType string
+
+const (
+	// HookTypeValidating the hook is called before the action, and the action is refused if the hook does not allow it.
+	HookTypeValidating HookType = "validating"
+	// HookTypeNotifying the hook is called asynchronously after the action is done.
+	HookTypeNotifying HookType = "notifying"
+)
+
+// HookFailurePolicy the policy of handling the error of calling the webhook
+type HookFailurePolicy string
+
+const (
+	// HookFailurePolicyFail refuse the action if calling the webhook failed.
+	HookFailurePolicyFail HookFailurePolicy = "Fail"
+	// HookFailurePolicyIgnore ignore the error of calling the webhook.
+	HookFailurePolicyIgnore HookFailurePolicy = "Ignore"
+)
+
+// DefaultHookTimeoutSeconds the default timeout of calling the webhook
+const DefaultHookTimeoutSeconds = 10
+
+// GetType return the hook type
+func (h *Hook) GetType() HookType {
+	if h.Type == "" {
+		return HookTypeNotifying
+	}
+	return h.Type
+}
+
+// GetTimeoutSeconds return the timeout of calling the webhook
+func (h *Hook) GetTimeoutSeconds() int {
+	if h.TimeoutSeconds <= 0 {
+		return DefaultHookTimeoutSeconds
+	}
+	return h.TimeoutSeconds
+}
+
+// GetFailurePolicy return the failure policy
+func (h *Hook) GetFailurePolicy() HookFailurePolicy {
+	if h.FailurePolicy == "" {
+		return HookFailurePolicyFail
+	}
+	return h.FailurePolicy
+}
+
+// Subscribe checking whether the hook subscribes the event
+func (h *Hook) Subscribe(event HookEvent) bool {
+	for _, e := range h.Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
 }
 
 // KubernetesService define one kubernetes service
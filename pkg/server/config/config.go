@@ -18,6 +18,8 @@ package config
 
 import (
 	"fmt"
+	"net"
+	"strings"
 	"time"
 
 	"github.com/spf13/pflag"
@@ -25,6 +27,7 @@ import (
 	"github.com/google/uuid"
 
 	"github.com/kubevela/velaux/pkg/server/infrastructure/datastore"
+	"github.com/kubevela/velaux/pkg/server/infrastructure/secretstore"
 )
 
 // Config config for server
@@ -61,12 +64,102 @@ type Config struct {
 	PluginConfig PluginConfig
 
 	DexServerURL string
+
+	// AccountSecurity the security policy of the local accounts
+	AccountSecurity AccountSecurityConfig
+
+	// ProjectArchiveRetention the archived projects could be restored in this period, they are purged after it
+	ProjectArchiveRetention time.Duration
+
+	// NamespaceReconcileInterval the interval of verifying the namespaces and the privileges of the targets and the envs
+	NamespaceReconcileInterval time.Duration
+
+	// NamespaceRepair repair the drifted namespaces and privileges found by the reconciliation
+	NamespaceRepair bool
+
+	// ClusterHealth the config of probing the health of the clusters
+	ClusterHealth ClusterHealthConfig
+
+	// OfflineAddon the config of the offline addon registries
+	OfflineAddon OfflineAddonConfig
+
+	// CloudShell the config of the lifecycle of the cloud shells
+	CloudShell CloudShellConfig
+
+	// ConfigRevisionLimit the max number of the revisions kept for every config, 0 means the history is disabled
+	ConfigRevisionLimit int
+
+	// ConfigDistributionCheckInterval the interval of checking the configs delivered by the distributions, 0 means disabling the checker
+	ConfigDistributionCheckInterval time.Duration
+
+	// SecretStore the external store of the sensitive properties of the configs
+	SecretStore secretstore.Config
+
+	// DefinitionRevisionLimit the max number of the revisions kept for every definition applied by VelaUX, 0 means the history is disabled
+	DefinitionRevisionLimit int
+}
+
+// CloudShellConfig the config of the lifecycle of the cloud shells
+type CloudShellConfig struct {
+	// IdleTimeout the cloud shell is deleted after it is not accessed in this period, 0 means never
+	IdleTimeout time.Duration
+	// MaxLifetime the cloud shell is deleted after it is created for this period, 0 means never
+	MaxLifetime time.Duration
+	// MaxSessions the max number of the active cloud shells of the platform, 0 means no limit
+	MaxSessions int
+	// ReapInterval the interval of deleting the expired cloud shells and revoking the privileges of the expired kubeconfigs
+	ReapInterval time.Duration
+	// KubeConfigExpiration the default expiration of the kubeconfig issued to the users
+	KubeConfigExpiration time.Duration
+	// KubeConfigMaxExpiration the max expiration of the kubeconfig issued to the users
+	KubeConfigMaxExpiration time.Duration
+	// KubeConfigServer the address of the kubernetes API server in the issued kubeconfig, the address in the kubeconfig of the apiserver is used if it is empty
+	KubeConfigServer string
+}
+
+// OfflineAddonConfig the config of the offline addon registries, their addons are stored and served by the apiserver
+type OfflineAddonConfig struct {
+	// StorageDir the directory to store the addon packages of the offline registries
+	StorageDir string
+	// ServerURL the URL to reach this apiserver when loading the addons from the offline registries,
+	// the loopback address of the bind address is used if it is empty
+	ServerURL string
+}
+
+// ClusterHealthConfig the config of probing the health of the clusters
+type ClusterHealthConfig struct {
+	// ProbeInterval the interval of probing the clusters, 0 means disabling the prober
+	ProbeInterval time.Duration
+	// Retention the snapshots of the cluster health are kept in this period
+	Retention time.Duration
+	// CPUThreshold the cluster is overloaded if the percentage of the CPU usage exceeds it
+	CPUThreshold int
+	// MemoryThreshold the cluster is overloaded if the percentage of the memory usage exceeds it
+	MemoryThreshold int
+}
+
+// AccountSecurityConfig the password policy and the lockout policy of the local accounts
+type AccountSecurityConfig struct {
+	// PasswordMinLength the minimum length of the password, 0 means no limit
+	PasswordMinLength int
+	// PasswordMinClasses the minimum number of the character classes (lowercase, uppercase, digit and symbol) of the password
+	PasswordMinClasses int
+	// PasswordHistory the number of the previous passwords that can not be reused
+	PasswordHistory int
+	// PasswordMaxAge the password must be changed after this duration, 0 means the password never expires
+	PasswordMaxAge time.Duration
+	// LockoutThreshold lock the account after this number of the consecutive failed login attempts, 0 means never lock
+	LockoutThreshold int
+	// LockoutDuration the locked account is unlocked automatically after this duration, 0 means locking until an administrator unlocks it
+	LockoutDuration time.Duration
 }
 
 // PluginConfig the plugin directory config
 type PluginConfig struct {
 	CorePluginPath   string
 	CustomPluginPath []string
+	// DevMode watch the plugin paths and reload the plugins after the files changed.
+	DevMode bool
 }
 
 type leaderConfig struct {
@@ -100,9 +193,56 @@ func NewConfig() *Config {
 			CustomPluginPath: []string{"plugins"},
 		},
 		DexServerURL: "http://dex.vela-system:5556",
+		AccountSecurity: AccountSecurityConfig{
+			PasswordMinLength:  8,
+			PasswordMinClasses: 2,
+			LockoutThreshold:   5,
+			LockoutDuration:    time.Minute * 15,
+		},
+		ProjectArchiveRetention:    time.Hour * 24 * 7,
+		NamespaceReconcileInterval: time.Minute * 10,
+		ClusterHealth: ClusterHealthConfig{
+			ProbeInterval:   time.Minute * 5,
+			Retention:       time.Hour * 24 * 3,
+			CPUThreshold:    90,
+			MemoryThreshold: 90,
+		},
+		OfflineAddon: OfflineAddonConfig{
+			StorageDir: "offline-addons",
+		},
+		CloudShell: CloudShellConfig{
+			IdleTimeout:  time.Minute * 30,
+			MaxLifetime:  time.Hour,
+			ReapInterval: time.Minute,
+
+			KubeConfigExpiration:    time.Hour,
+			KubeConfigMaxExpiration: time.Hour * 24,
+		},
+		ConfigRevisionLimit:             10,
+		ConfigDistributionCheckInterval: time.Minute * 5,
+		SecretStore: secretstore.Config{
+			VaultMount:      "secret",
+			VaultPathPrefix: "velaux",
+		},
+		DefinitionRevisionLimit: 10,
 	}
 }
 
+// OfflineAddonServerURL returns the URL to reach this apiserver when loading the addons from the offline registries
+func (s *Config) OfflineAddonServerURL() string {
+	if s.OfflineAddon.ServerURL != "" {
+		return strings.TrimSuffix(s.OfflineAddon.ServerURL, "/")
+	}
+	host, port, err := net.SplitHostPort(s.BindAddr)
+	if err != nil {
+		return "http://127.0.0.1:8000"
+	}
+	if ip := net.ParseIP(host); host == "" || ip != nil && ip.IsUnspecified() {
+		host = "127.0.0.1"
+	}
+	return "http://" + net.JoinHostPort(host, port)
+}
+
 // Validate validate generic server run options
 func (s *Config) Validate() []error {
 	var errs []error
@@ -110,6 +250,9 @@ func (s *Config) Validate() []error {
 	if s.Datastore.Type != "mongodb" && s.Datastore.Type != "kubeapi" {
 		errs = append(errs, fmt.Errorf("not support datastore type %s", s.Datastore.Type))
 	}
+	if err := s.SecretStore.Validate(); err != nil {
+		errs = append(errs, err)
+	}
 
 	return errs
 }
@@ -132,4 +275,37 @@ func (s *Config) AddFlags(fs *pflag.FlagSet, c *Config) {
 	fs.StringVar(&s.WorkflowVersion, "workflow-version", c.WorkflowVersion, "the version of workflow to meet controller requirement.")
 	fs.StringVar(&s.DexServerURL, "dex-server", c.DexServerURL, "the URL of the dex server.")
 	fs.StringArrayVar(&s.PluginConfig.CustomPluginPath, "plugin-path", c.PluginConfig.CustomPluginPath, "the path of the plugin directory")
+	fs.BoolVar(&s.PluginConfig.DevMode, "plugin-dev-mode", c.PluginConfig.DevMode, "watch the plugin directories and reload the plugins after the files changed, only for the plugin development.")
+	fs.IntVar(&s.AccountSecurity.PasswordMinLength, "password-min-length", c.AccountSecurity.PasswordMinLength, "the minimum length of the password of the local users.")
+	fs.IntVar(&s.AccountSecurity.PasswordMinClasses, "password-min-classes", c.AccountSecurity.PasswordMinClasses, "the minimum number of the character classes (lowercase, uppercase, digit and symbol) of the password of the local users.")
+	fs.IntVar(&s.AccountSecurity.PasswordHistory, "password-history", c.AccountSecurity.PasswordHistory, "the number of the previous passwords that can not be reused.")
+	fs.DurationVar(&s.AccountSecurity.PasswordMaxAge, "password-max-age", c.AccountSecurity.PasswordMaxAge, "the password must be changed after this duration, 0 means the password never expires.")
+	fs.IntVar(&s.AccountSecurity.LockoutThreshold, "lockout-threshold", c.AccountSecurity.LockoutThreshold, "lock the local user after this number of the consecutive failed login attempts, 0 means never lock.")
+	fs.DurationVar(&s.AccountSecurity.LockoutDuration, "lockout-duration", c.AccountSecurity.LockoutDuration, "the locked user is unlocked automatically after this duration, 0 means locking until an administrator unlocks it.")
+	fs.DurationVar(&s.ProjectArchiveRetention, "project-archive-retention", c.ProjectArchiveRetention, "the archived projects could be restored in this period, they are purged after it.")
+	fs.DurationVar(&s.NamespaceReconcileInterval, "namespace-reconcile-interval", c.NamespaceReconcileInterval, "the interval of verifying the namespaces and the privileges of the targets and the envs.")
+	fs.BoolVar(&s.NamespaceRepair, "namespace-repair", c.NamespaceRepair, "repair the drifted namespaces and privileges of the targets and the envs found by the reconciliation.")
+	fs.DurationVar(&s.ClusterHealth.ProbeInterval, "cluster-probe-interval", c.ClusterHealth.ProbeInterval, "the interval of probing the health of the clusters, 0 means disabling the prober.")
+	fs.DurationVar(&s.ClusterHealth.Retention, "cluster-health-retention", c.ClusterHealth.Retention, "the snapshots of the cluster health are kept in this period.")
+	fs.IntVar(&s.ClusterHealth.CPUThreshold, "cluster-cpu-threshold", c.ClusterHealth.CPUThreshold, "the cluster is overloaded if the percentage of the CPU usage exceeds it.")
+	fs.IntVar(&s.ClusterHealth.MemoryThreshold, "cluster-memory-threshold", c.ClusterHealth.MemoryThreshold, "the cluster is overloaded if the percentage of the memory usage exceeds it.")
+	fs.StringVar(&s.OfflineAddon.StorageDir, "offline-addon-dir", c.OfflineAddon.StorageDir, "the directory to store the addon packages of the offline addon registries.")
+	fs.DurationVar(&s.CloudShell.IdleTimeout, "cloudshell-idle-timeout", c.CloudShell.IdleTimeout, "the cloud shell is deleted after it is not accessed in this period, 0 means never.")
+	fs.DurationVar(&s.CloudShell.MaxLifetime, "cloudshell-max-lifetime", c.CloudShell.MaxLifetime, "the cloud shell is deleted after it is created for this period, 0 means never.")
+	fs.IntVar(&s.CloudShell.MaxSessions, "cloudshell-max-sessions", c.CloudShell.MaxSessions, "the max number of the active cloud shells of the platform, 0 means no limit.")
+	fs.DurationVar(&s.CloudShell.ReapInterval, "cloudshell-reap-interval", c.CloudShell.ReapInterval, "the interval of deleting the expired cloud shells and revoking the privileges of the expired kubeconfigs.")
+	fs.IntVar(&s.ConfigRevisionLimit, "config-revision-limit", c.ConfigRevisionLimit, "the max number of the revisions kept for every config, 0 means the history is disabled.")
+	fs.DurationVar(&s.ConfigDistributionCheckInterval, "config-distribution-check-interval", c.ConfigDistributionCheckInterval, "the interval of checking the configs delivered by the distributions, 0 means disabling the checker.")
+	fs.DurationVar(&s.CloudShell.KubeConfigExpiration, "kubeconfig-expiration", c.CloudShell.KubeConfigExpiration, "the default expiration of the kubeconfig issued to the users.")
+	fs.DurationVar(&s.CloudShell.KubeConfigMaxExpiration, "kubeconfig-max-expiration", c.CloudShell.KubeConfigMaxExpiration, "the max expiration of the kubeconfig issued to the users.")
+	fs.StringVar(&s.CloudShell.KubeConfigServer, "kubeconfig-server", c.CloudShell.KubeConfigServer, "the address of the kubernetes API server in the kubeconfig issued to the users, the address of the apiserver's kubeconfig is used if it is empty.")
+	fs.IntVar(&s.DefinitionRevisionLimit, "definition-revision-limit", c.DefinitionRevisionLimit, "the max number of the revisions kept for every definition applied by VelaUX, 0 means the history is disabled.")
+	fs.StringVar(&s.SecretStore.Type, "secret-store", c.SecretStore.Type, "the external store of the sensitive properties of the configs, support vault and file, empty means they are kept in the config secrets.")
+	fs.StringVar(&s.SecretStore.VaultAddress, "secret-store-vault-address", c.SecretStore.VaultAddress, "the address of the vault server, takes effect when the secret store is vault.")
+	fs.StringVar(&s.SecretStore.VaultToken, "secret-store-vault-token", c.SecretStore.VaultToken, "the token to access the vault server, the VAULT_TOKEN environment variable is used if it is empty.")
+	fs.StringVar(&s.SecretStore.VaultMount, "secret-store-vault-mount", c.SecretStore.VaultMount, "the mount path of the KV version 2 secrets engine of the vault server.")
+	fs.StringVar(&s.SecretStore.VaultPathPrefix, "secret-store-vault-path-prefix", c.SecretStore.VaultPathPrefix, "the secrets are stored under this path of the secrets engine.")
+	fs.StringVar(&s.SecretStore.FilePath, "secret-store-file", c.SecretStore.FilePath, "the path of the encrypted file, takes effect when the secret store is file.")
+	fs.StringVar(&s.SecretStore.FileKeyPath, "secret-store-file-key", c.SecretStore.FileKeyPath, "the path of the file that contains the key to encrypt the secret store file.")
+	fs.StringVar(&s.OfflineAddon.ServerURL, "offline-addon-server-url", c.OfflineAddon.ServerURL, "the URL to reach this server when loading the addons from the offline addon registries, the loopback address of the bind address is used if it is empty.")
 }
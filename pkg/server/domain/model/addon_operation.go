@@ -0,0 +1,148 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import "time"
+
+func init() {
+	RegisterModel(&AddonOperation{}, &AddonOperationLock{})
+}
+
+const (
+	// AddonOperationEnable enable the addon
+	AddonOperationEnable = "enable"
+	// AddonOperationUpdate update the version or the args of the enabled addon
+	AddonOperationUpdate = "update"
+	// AddonOperationDisable disable the addon
+	AddonOperationDisable = "disable"
+	// AddonOperationPromote promote the staged rollout of the addon to all target clusters
+	AddonOperationPromote = "promote"
+)
+
+const (
+	// AddonOperationPending means the operation is waiting for running
+	AddonOperationPending = "Pending"
+	// AddonOperationRunning means the operation is running
+	AddonOperationRunning = "Running"
+	// AddonOperationSucceeded means the operation is succeeded
+	AddonOperationSucceeded = "Succeeded"
+	// AddonOperationFailed means the operation is failed
+	AddonOperationFailed = "Failed"
+)
+
+// AddonOperation the record of enabling, updating or disabling an addon, the operation runs in the background
+type AddonOperation struct {
+	BaseModel
+	ID       string `json:"id"`
+	Addon    string `json:"addon"`
+	Type     string `json:"type"`
+	Operator string `json:"operator,omitempty"`
+	// Owner the ID of the replica running the operation
+	Owner string `json:"owner,omitempty"`
+	// Args the args to enable or update the addon, they are not persisted because they may contain the credentials
+	Args map[string]interface{} `json:"-"`
+	// RedactedArgs the args with the values of the sensitive parameters redacted, they are persisted to show the operation
+	RedactedArgs map[string]interface{} `json:"redactedArgs,omitempty"`
+	Version      string                 `json:"version,omitempty"`
+	RegistryName string                 `json:"registryName,omitempty"`
+	// Force whether to disable the addon even if it is used by the applications
+	Force bool   `json:"force,omitempty"`
+	Phase string `json:"phase"`
+	// Logs the logs of the steps of the operation
+	Logs []AddonOperationLog `json:"logs,omitempty"`
+	// Warnings the additional info reported by enabling the addon
+	Warnings  []string   `json:"warnings,omitempty"`
+	Error     string     `json:"error,omitempty"`
+	StartTime *time.Time `json:"startTime,omitempty"`
+	EndTime   *time.Time `json:"endTime,omitempty"`
+}
+
+// AddonOperationLog the log of a step of the addon operation
+type AddonOperationLog struct {
+	Time    time.Time `json:"time"`
+	Message string    `json:"message"`
+}
+
+// Finished whether the operation is succeeded or failed
+func (a *AddonOperation) Finished() bool {
+	return a.Phase == AddonOperationSucceeded || a.Phase == AddonOperationFailed
+}
+
+// TableName return custom table name
+func (a *AddonOperation) TableName() string {
+	return tableNamePrefix + "addon_operation"
+}
+
+// ShortTableName is the compressed version of table name for kubeapi storage and others
+func (a *AddonOperation) ShortTableName() string {
+	return "addon_op"
+}
+
+// PrimaryKey return custom primary key
+func (a *AddonOperation) PrimaryKey() string {
+	return a.ID
+}
+
+// Index return custom index
+func (a *AddonOperation) Index() map[string]interface{} {
+	index := make(map[string]interface{})
+	if a.ID != "" {
+		index["id"] = a.ID
+	}
+	if a.Addon != "" {
+		index["addon"] = a.Addon
+	}
+	if a.Phase != "" {
+		index["phase"] = a.Phase
+	}
+	return index
+}
+
+// AddonOperationLock the lock of the addon held by the running operation, only one operation of an addon could be running
+// across the replicas. The owner updates the heartbeat time while the operation is running, the lock whose heartbeat stops
+// is stale, it means the owner is stopped and the operation is interrupted.
+type AddonOperationLock struct {
+	BaseModel
+	Addon         string    `json:"addon"`
+	OperationID   string    `json:"operationID"`
+	Owner         string    `json:"owner"`
+	HeartbeatTime time.Time `json:"heartbeatTime"`
+}
+
+// TableName return custom table name
+func (a *AddonOperationLock) TableName() string {
+	return tableNamePrefix + "addon_operation_lock"
+}
+
+// ShortTableName is the compressed version of table name for kubeapi storage and others
+func (a *AddonOperationLock) ShortTableName() string {
+	return "addon_lock"
+}
+
+// PrimaryKey return custom primary key
+func (a *AddonOperationLock) PrimaryKey() string {
+	return a.Addon
+}
+
+// Index return custom index
+func (a *AddonOperationLock) Index() map[string]interface{} {
+	index := make(map[string]interface{})
+	if a.Addon != "" {
+		index["addon"] = a.Addon
+	}
+	return index
+}
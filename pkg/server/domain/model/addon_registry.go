@@ -0,0 +1,56 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+func init() {
+	RegisterModel(&OfflineAddonRegistry{})
+}
+
+// OfflineAddonRegistry the addon registry served by VelaUX from the local storage, the addon packages are imported
+// from a server-local directory or uploaded through the API, so the addons could be managed without any network access
+type OfflineAddonRegistry struct {
+	BaseModel
+	Name string `json:"name"`
+	// Dir the server-local directory to import the addons from, it is optional
+	Dir string `json:"dir,omitempty"`
+	// Token the password for the KubeVela addon client to pull the addon packages
+	Token string `json:"token"`
+}
+
+// TableName return custom table name
+func (o *OfflineAddonRegistry) TableName() string {
+	return tableNamePrefix + "offline_addon_registry"
+}
+
+// ShortTableName is the compressed version of table name for kubeapi storage and others
+func (o *OfflineAddonRegistry) ShortTableName() string {
+	return "addon_offreg"
+}
+
+// PrimaryKey return custom primary key
+func (o *OfflineAddonRegistry) PrimaryKey() string {
+	return o.Name
+}
+
+// Index return custom index
+func (o *OfflineAddonRegistry) Index() map[string]interface{} {
+	index := make(map[string]interface{})
+	if o.Name != "" {
+		index["name"] = o.Name
+	}
+	return index
+}
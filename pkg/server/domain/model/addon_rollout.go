@@ -0,0 +1,73 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import "time"
+
+func init() {
+	RegisterModel(&AddonRollout{})
+}
+
+const (
+	// AddonRolloutStaged means the addon is only enabled on the staged clusters
+	AddonRolloutStaged = "Staged"
+	// AddonRolloutPromoted means the addon is promoted to all target clusters
+	AddonRolloutPromoted = "Promoted"
+)
+
+// AddonRollout the staged rollout of an addon, the addon is enabled on the staged clusters first,
+// and then promoted to all target clusters
+type AddonRollout struct {
+	BaseModel
+	Addon        string `json:"addon"`
+	Version      string `json:"version,omitempty"`
+	RegistryName string `json:"registryName,omitempty"`
+	// Clusters the target clusters of the addon, empty means all clusters
+	Clusters []string `json:"clusters,omitempty"`
+	// StagedClusters the clusters to enable the addon first
+	StagedClusters []string   `json:"stagedClusters"`
+	Phase          string     `json:"phase"`
+	Operator       string     `json:"operator,omitempty"`
+	PromoteTime    *time.Time `json:"promoteTime,omitempty"`
+}
+
+// TableName return custom table name
+func (a *AddonRollout) TableName() string {
+	return tableNamePrefix + "addon_rollout"
+}
+
+// ShortTableName is the compressed version of table name for kubeapi storage and others
+func (a *AddonRollout) ShortTableName() string {
+	return "addon_ro"
+}
+
+// PrimaryKey return custom primary key
+func (a *AddonRollout) PrimaryKey() string {
+	return a.Addon
+}
+
+// Index return custom index
+func (a *AddonRollout) Index() map[string]interface{} {
+	index := make(map[string]interface{})
+	if a.Addon != "" {
+		index["addon"] = a.Addon
+	}
+	if a.Phase != "" {
+		index["phase"] = a.Phase
+	}
+	return index
+}
@@ -0,0 +1,57 @@
+/*
+Copyright 2021 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+func init() {
+	RegisterModel(&ClusterGroup{})
+}
+
+// ClusterGroup groups the clusters by the names and the labels
+type ClusterGroup struct {
+	BaseModel
+	Name        string `json:"name"`
+	Alias       string `json:"alias,omitempty"`
+	Description string `json:"description,omitempty"`
+	// Clusters the names of the clusters in the group
+	Clusters []string `json:"clusters,omitempty"`
+	// LabelSelector the clusters matching the labels are in the group, includes the clusters joined later
+	LabelSelector map[string]string `json:"labelSelector,omitempty"`
+}
+
+// TableName return custom table name
+func (c *ClusterGroup) TableName() string {
+	return tableNamePrefix + "cluster_group"
+}
+
+// ShortTableName is the compressed version of table name for kubeapi storage and others
+func (c *ClusterGroup) ShortTableName() string {
+	return "cg"
+}
+
+// PrimaryKey return custom primary key
+func (c *ClusterGroup) PrimaryKey() string {
+	return c.Name
+}
+
+// Index return custom index
+func (c *ClusterGroup) Index() map[string]interface{} {
+	index := make(map[string]interface{})
+	if c.Name != "" {
+		index["name"] = c.Name
+	}
+	return index
+}
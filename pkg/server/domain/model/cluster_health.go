@@ -0,0 +1,111 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import (
+	"time"
+)
+
+func init() {
+	RegisterModel(&ClusterHealthHistory{})
+}
+
+// ClusterHealthHistory the recent snapshots of the health of a cluster, they are kept in a bounded ring ordered by the probe time
+type ClusterHealthHistory struct {
+	BaseModel
+	Cluster   string                  `json:"cluster"`
+	Snapshots []ClusterHealthSnapshot `json:"snapshots"`
+}
+
+// Record append the snapshot to the ring, the snapshots older than the retention or beyond the capacity are dropped
+func (c *ClusterHealthHistory) Record(snapshot ClusterHealthSnapshot, retention time.Duration, capacity int) {
+	c.Snapshots = append(c.Snapshots, snapshot)
+	drop := 0
+	if len(c.Snapshots) > capacity {
+		drop = len(c.Snapshots) - capacity
+	}
+	if retention > 0 {
+		for drop < len(c.Snapshots) && snapshot.ProbeTime.Sub(c.Snapshots[drop].ProbeTime) > retention {
+			drop++
+		}
+	}
+	c.Snapshots = append([]ClusterHealthSnapshot{}, c.Snapshots[drop:]...)
+}
+
+// Latest return the latest snapshot, nil means the cluster has not been probed
+func (c *ClusterHealthHistory) Latest() *ClusterHealthSnapshot {
+	if len(c.Snapshots) == 0 {
+		return nil
+	}
+	return &c.Snapshots[len(c.Snapshots)-1]
+}
+
+// TableName return custom table name
+func (c *ClusterHealthHistory) TableName() string {
+	return tableNamePrefix + "cluster_health_history"
+}
+
+// ShortTableName is the compressed version of table name for kubeapi storage and others
+func (c *ClusterHealthHistory) ShortTableName() string {
+	return "chh"
+}
+
+// PrimaryKey return custom primary key
+func (c *ClusterHealthHistory) PrimaryKey() string {
+	return c.Cluster
+}
+
+// Index return custom index
+func (c *ClusterHealthHistory) Index() map[string]interface{} {
+	index := make(map[string]interface{})
+	if c.Cluster != "" {
+		index["cluster"] = c.Cluster
+	}
+	return index
+}
+
+// ClusterHealthSnapshot the reachability and the resource info of a cluster probed at a time
+type ClusterHealthSnapshot struct {
+	Cluster   string `json:"cluster"`
+	Reachable bool   `json:"reachable"`
+	Reason    string `json:"reason,omitempty"`
+
+	WorkerNumber   int   `json:"workerNumber,omitempty"`
+	MasterNumber   int   `json:"masterNumber,omitempty"`
+	CPUCapacity    int64 `json:"cpuCapacity,omitempty"`
+	CPUUsed        int64 `json:"cpuUsed,omitempty"`
+	MemoryCapacity int64 `json:"memoryCapacity,omitempty"`
+	MemoryUsed     int64 `json:"memoryUsed,omitempty"`
+	GPUCapacity    int64 `json:"gpuCapacity,omitempty"`
+	GPUUsed        int64 `json:"gpuUsed,omitempty"`
+	PodCapacity    int64 `json:"podCapacity,omitempty"`
+	PodUsed        int64 `json:"podUsed,omitempty"`
+	// CPUPercent the percentage of the CPU usage, it comes from the metrics API if it is available
+	CPUPercent int `json:"cpuPercent,omitempty"`
+	// MemoryPercent the percentage of the memory usage, it comes from the metrics API if it is available
+	MemoryPercent int `json:"memoryPercent,omitempty"`
+
+	ProbeTime time.Time `json:"probeTime"`
+}
+
+// NewClusterHealthSnapshot new the snapshot of the cluster at the probe time
+func NewClusterHealthSnapshot(cluster string, probeTime time.Time) *ClusterHealthSnapshot {
+	return &ClusterHealthSnapshot{
+		Cluster:   cluster,
+		ProbeTime: probeTime,
+	}
+}
@@ -0,0 +1,131 @@
+/*
+Copyright 2021 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import "time"
+
+func init() {
+	RegisterModel(&ConfigDistributionStatus{}, &ExternalConfigDistribution{})
+}
+
+const (
+	// ConfigTargetSynced means the config in the target is the same as the source
+	ConfigTargetSynced = "Synced"
+	// ConfigTargetFailed means the config is not delivered to the target
+	ConfigTargetFailed = "Failed"
+	// ConfigTargetDrifted means the config in the target is modified after it is delivered
+	ConfigTargetDrifted = "Drifted"
+)
+
+// ConfigTargetStatus the delivery status of a config in a target of the distribution
+type ConfigTargetStatus struct {
+	Config      string `json:"config"`
+	ClusterName string `json:"clusterName"`
+	Namespace   string `json:"namespace"`
+	Status      string `json:"status"`
+	Reason      string `json:"reason,omitempty"`
+	// SourceHash the hash of the content of the source config
+	SourceHash string `json:"sourceHash,omitempty"`
+	// TargetHash the hash of the content of the config in the target
+	TargetHash string `json:"targetHash,omitempty"`
+}
+
+// ConfigDistributionStatus the delivery status of the configs in all targets of a distribution
+type ConfigDistributionStatus struct {
+	BaseModel
+	Project       string               `json:"project"`
+	Distribution  string               `json:"distribution"`
+	Targets       []ConfigTargetStatus `json:"targets"`
+	LastCheckTime time.Time            `json:"lastCheckTime"`
+}
+
+// TableName return custom table name
+func (c *ConfigDistributionStatus) TableName() string {
+	return tableNamePrefix + "config_distribution_status"
+}
+
+// ShortTableName is the compressed version of table name for kubeapi storage and others
+func (c *ConfigDistributionStatus) ShortTableName() string {
+	return "cds"
+}
+
+// PrimaryKey return custom primary key
+func (c *ConfigDistributionStatus) PrimaryKey() string {
+	return compositeKey(c.Project, c.Distribution)
+}
+
+// Index return custom index
+func (c *ConfigDistributionStatus) Index() map[string]interface{} {
+	index := make(map[string]interface{})
+	if c.Project != "" {
+		index["project"] = c.Project
+	}
+	if c.Distribution != "" {
+		index["distribution"] = c.Distribution
+	}
+	return index
+}
+
+// ConfigReference the namespaced name of a config
+type ConfigReference struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+}
+
+// ConfigDistributionTarget the namespace of a cluster that the configs are distributed to
+type ConfigDistributionTarget struct {
+	ClusterName string `json:"clusterName"`
+	Namespace   string `json:"namespace"`
+}
+
+// ExternalConfigDistribution the configs whose sensitive properties are kept in the secret store, they are resolved and
+// delivered by the apiserver instead of the application of the distribution, so their values are never stored in the control plane.
+type ExternalConfigDistribution struct {
+	BaseModel
+	Project      string                     `json:"project"`
+	Distribution string                     `json:"distribution"`
+	Namespace    string                     `json:"namespace"`
+	Configs      []ConfigReference          `json:"configs"`
+	Targets      []ConfigDistributionTarget `json:"targets"`
+}
+
+// TableName return custom table name
+func (e *ExternalConfigDistribution) TableName() string {
+	return tableNamePrefix + "external_config_distribution"
+}
+
+// ShortTableName is the compressed version of table name for kubeapi storage and others
+func (e *ExternalConfigDistribution) ShortTableName() string {
+	return "ecd"
+}
+
+// PrimaryKey return custom primary key
+func (e *ExternalConfigDistribution) PrimaryKey() string {
+	return compositeKey(e.Project, e.Distribution)
+}
+
+// Index return custom index
+func (e *ExternalConfigDistribution) Index() map[string]interface{} {
+	index := make(map[string]interface{})
+	if e.Project != "" {
+		index["project"] = e.Project
+	}
+	if e.Distribution != "" {
+		index["distribution"] = e.Distribution
+	}
+	return index
+}
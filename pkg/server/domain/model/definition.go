@@ -0,0 +1,63 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import "fmt"
+
+func init() {
+	RegisterModel(&DefinitionRevision{})
+}
+
+// DefinitionRevision the CUE source of a definition applied by VelaUX, the definition could be rolled back to it
+type DefinitionRevision struct {
+	BaseModel
+	Name string `json:"name"`
+	// Type the type of the definition, such as component, trait, workflowstep and policy
+	Type     string `json:"type"`
+	Revision int64  `json:"revision"`
+	CUE      string `json:"cue"`
+	Operator string `json:"operator,omitempty"`
+	// RollbackFrom the revision restored by this revision, it is zero if the revision is not created by a rollback
+	RollbackFrom int64 `json:"rollbackFrom,omitempty"`
+}
+
+// TableName return custom table name
+func (d *DefinitionRevision) TableName() string {
+	return tableNamePrefix + "definition_revision"
+}
+
+// ShortTableName is the compressed version of table name for kubeapi storage and others
+func (d *DefinitionRevision) ShortTableName() string {
+	return "def_rev"
+}
+
+// PrimaryKey return custom primary key
+func (d *DefinitionRevision) PrimaryKey() string {
+	return fmt.Sprintf("%s-%s-%d", d.Type, d.Name, d.Revision)
+}
+
+// Index return custom index
+func (d *DefinitionRevision) Index() map[string]interface{} {
+	index := make(map[string]interface{})
+	if d.Name != "" {
+		index["name"] = d.Name
+	}
+	if d.Type != "" {
+		index["type"] = d.Type
+	}
+	return index
+}
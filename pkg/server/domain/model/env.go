@@ -35,6 +35,9 @@ type Env struct {
 	// Targets defines the name of delivery target that belongs to this env
 	// In one project, a delivery target can only belong to one env.
 	Targets []string `json:"targets,omitempty"`
+
+	// Health the result of the latest namespace reconciliation, nil means the env is not checked yet
+	Health *NamespaceHealth `json:"health,omitempty"`
 }
 
 // TableName return custom table name
@@ -0,0 +1,82 @@
+/*
+Copyright 2021 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import (
+	"time"
+)
+
+func init() {
+	RegisterModel(&IssuedKubeConfig{})
+}
+
+const (
+	// KubeConfigPrivilegeScoped the privilege of all resources in the namespace, or the cluster if the namespace is empty
+	KubeConfigPrivilegeScoped = "scoped"
+	// KubeConfigPrivilegeApplication the privilege of the applications in the namespace
+	KubeConfigPrivilegeApplication = "application"
+)
+
+// KubeConfigPrivilege the kubernetes privilege granted to the issued kubeconfig
+type KubeConfigPrivilege struct {
+	Type      string `json:"type"`
+	Cluster   string `json:"cluster"`
+	Namespace string `json:"namespace,omitempty"`
+	ReadOnly  bool   `json:"readOnly"`
+}
+
+// IssuedKubeConfig the record of the kubeconfig issued to a user, the privileges are bound to the group of the credential,
+// so they could be revoked before the certificate expires.
+type IssuedKubeConfig struct {
+	BaseModel
+	ID         string                `json:"id"`
+	Username   string                `json:"username"`
+	Group      string                `json:"group"`
+	Privileges []KubeConfigPrivilege `json:"privileges,omitempty"`
+	ExpireTime time.Time             `json:"expireTime"`
+	Revoked    bool                  `json:"revoked"`
+	RevokeTime *time.Time            `json:"revokeTime,omitempty"`
+	// Revoker the user revoking the kubeconfig, it is empty if the privileges are cleaned up after the expiration
+	Revoker string `json:"revoker,omitempty"`
+}
+
+// TableName return custom table name
+func (k *IssuedKubeConfig) TableName() string {
+	return tableNamePrefix + "issued_kubeconfig"
+}
+
+// ShortTableName is the compressed version of table name for kubeapi storage and others
+func (k *IssuedKubeConfig) ShortTableName() string {
+	return "ikc"
+}
+
+// PrimaryKey return custom primary key
+func (k *IssuedKubeConfig) PrimaryKey() string {
+	return k.ID
+}
+
+// Index return custom index
+func (k *IssuedKubeConfig) Index() map[string]interface{} {
+	index := make(map[string]interface{})
+	if k.ID != "" {
+		index["id"] = k.ID
+	}
+	if k.Username != "" {
+		index["username"] = k.Username
+	}
+	return index
+}
@@ -17,6 +17,8 @@ limitations under the License.
 package model
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"reflect"
@@ -145,6 +147,16 @@ func (m *BaseModel) SetUpdateTime(time time.Time) {
 	m.UpdateTime = time
 }
 
+// compositeKey returns the primary key composed of the parts. The parts are length prefixed and hashed,
+// so the different tuples never produce the same key even if the parts contain the separators.
+func compositeKey(parts ...string) string {
+	hash := sha256.New()
+	for _, part := range parts {
+		_, _ = fmt.Fprintf(hash, "%d:%s", len(part), part)
+	}
+	return hex.EncodeToString(hash.Sum(nil))[:32]
+}
+
 func deepCopy(src interface{}) interface{} {
 	dst := reflect.New(reflect.TypeOf(src).Elem())
 
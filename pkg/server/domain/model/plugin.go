@@ -18,6 +18,7 @@ package model
 
 func init() {
 	RegisterModel(&PluginSetting{})
+	RegisterModel(&PluginStorage{})
 }
 
 // PluginSetting save the setting data of the plugin
@@ -52,3 +53,51 @@ func (p PluginSetting) Index() map[string]interface{} {
 	}
 	return index
 }
+
+// PluginStorage is the document saved by the plugin to the plugin storage
+type PluginStorage struct {
+	BaseModel
+	PluginID string `json:"pluginID"`
+	// Scope includes: platform, project and user
+	Scope string `json:"scope"`
+	// ScopeName is the project name for the project scope and the username for the user scope.
+	ScopeName string      `json:"scopeName,omitempty"`
+	Key       string      `json:"key"`
+	Value     *JSONStruct `json:"value,omitempty"`
+	// Size the bytes of the value
+	Size    int64  `json:"size"`
+	Creator string `json:"creator,omitempty"`
+}
+
+// PrimaryKey return custom primary key, the names of the projects and the users and the keys may contain any separator
+func (p *PluginStorage) PrimaryKey() string {
+	return compositeKey(p.PluginID, p.Scope, p.ScopeName, p.Key)
+}
+
+// TableName return custom table name
+func (p *PluginStorage) TableName() string {
+	return tableNamePrefix + "plugin_storage"
+}
+
+// ShortTableName is the compressed version of table name for kubeapi storage and others
+func (p *PluginStorage) ShortTableName() string {
+	return "pstore"
+}
+
+// Index return custom index
+func (p *PluginStorage) Index() map[string]interface{} {
+	index := make(map[string]interface{})
+	if p.PluginID != "" {
+		index["pluginID"] = p.PluginID
+	}
+	if p.Scope != "" {
+		index["scope"] = p.Scope
+	}
+	if p.ScopeName != "" {
+		index["scopeName"] = p.ScopeName
+	}
+	if p.Key != "" {
+		index["key"] = p.Key
+	}
+	return index
+}
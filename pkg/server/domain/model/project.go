@@ -16,6 +16,8 @@ limitations under the License.
 
 package model
 
+import "time"
+
 func init() {
 	RegisterModel(&Project{})
 }
@@ -28,6 +30,52 @@ type Project struct {
 	Owner       string `json:"owner"`
 	Description string `json:"description,omitempty"`
 	Namespace   string `json:"namespace"`
+	// Quota the quota of the project, nil means unlimited
+	Quota *ProjectQuota `json:"quota,omitempty"`
+	// Archived the archived project is read-only and hidden from the members, it is purged after the retention period
+	Archived     bool       `json:"archived,omitempty"`
+	ArchivedTime *time.Time `json:"archivedTime,omitempty"`
+	// Deletion the progress of the cascading deletion, nil means the project is not being deleted
+	Deletion *ProjectDeletion `json:"deletion,omitempty"`
+}
+
+const (
+	// ProjectDeletionPhaseApplications means the applications of the project are being deleted
+	ProjectDeletionPhaseApplications = "DeletingApplications"
+	// ProjectDeletionPhaseEnvs means the envs of the project are being deleted
+	ProjectDeletionPhaseEnvs = "DeletingEnvs"
+	// ProjectDeletionPhaseTargets means the targets of the project are being deleted
+	ProjectDeletionPhaseTargets = "DeletingTargets"
+	// ProjectDeletionPhaseProject means the members, the roles and the permissions of the project are being deleted
+	ProjectDeletionPhaseProject = "DeletingProject"
+	// ProjectDeletionPhaseFailed means the deletion is failed, it could be retried
+	ProjectDeletionPhaseFailed = "Failed"
+)
+
+// ProjectDeletion the progress of the cascading deletion of the project
+type ProjectDeletion struct {
+	Phase     string    `json:"phase"`
+	StartTime time.Time `json:"startTime"`
+	// Deleted the number of the deleted resources, keyed by the kind, such as applications, envs and targets
+	Deleted map[string]int `json:"deleted,omitempty"`
+	// Remaining the number of the resources waiting for deleting, keyed by the kind
+	Remaining map[string]int `json:"remaining,omitempty"`
+	Message   string         `json:"message,omitempty"`
+}
+
+// ProjectQuota the quota of the project, the zero value of the limits means unlimited
+type ProjectQuota struct {
+	MaxApplications           int `json:"maxApplications,omitempty"`
+	MaxEnvs                   int `json:"maxEnvs,omitempty"`
+	MaxTargets                int `json:"maxTargets,omitempty"`
+	MaxComponentsPerApp       int `json:"maxComponentsPerApp,omitempty"`
+	MaxConcurrentPipelineRuns int `json:"maxConcurrentPipelineRuns,omitempty"`
+	// ResourceQuota the hard limits of the ResourceQuota provisioned into the namespaces of the targets, such as {"requests.cpu": "10"}
+	ResourceQuota map[string]string `json:"resourceQuota,omitempty"`
+	// DefaultLimits the default limits of the containers, set by the LimitRange provisioned into the namespaces of the targets
+	DefaultLimits map[string]string `json:"defaultLimits,omitempty"`
+	// DefaultRequests the default requests of the containers, set by the LimitRange provisioned into the namespaces of the targets
+	DefaultRequests map[string]string `json:"defaultRequests,omitempty"`
 }
 
 // GetNamespace get the namespace name of this project.
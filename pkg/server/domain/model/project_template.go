@@ -0,0 +1,139 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+func init() {
+	RegisterModel(&ProjectTemplate{})
+}
+
+// ProjectTemplate declares the resources created with the project, such as the envs, the targets, the roles and the members.
+// The string fields of the spec could refer the variables by the placeholders like ${name}, the built-in variables
+// are project, namespace and owner.
+type ProjectTemplate struct {
+	BaseModel
+	Name        string                    `json:"name"`
+	Alias       string                    `json:"alias"`
+	Description string                    `json:"description,omitempty"`
+	Variables   []ProjectTemplateVariable `json:"variables,omitempty"`
+	Spec        ProjectTemplateSpec       `json:"spec"`
+}
+
+// ProjectTemplateVariable the variable of the template
+type ProjectTemplateVariable struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Default     string `json:"default,omitempty"`
+	Required    bool   `json:"required,omitempty"`
+}
+
+// ProjectTemplateSpec the resources created with the project, they are created in the order of the fields.
+type ProjectTemplateSpec struct {
+	Targets             []ProjectTemplateTarget       `json:"targets,omitempty"`
+	Envs                []ProjectTemplateEnv          `json:"envs,omitempty"`
+	Permissions         []ProjectTemplatePermission   `json:"permissions,omitempty"`
+	Roles               []ProjectTemplateRole         `json:"roles,omitempty"`
+	Members             []ProjectTemplateMember       `json:"members,omitempty"`
+	ConfigDistributions []ProjectTemplateDistribution `json:"configDistributions,omitempty"`
+	Pipelines           []ProjectTemplatePipeline     `json:"pipelines,omitempty"`
+}
+
+// ProjectTemplateTarget the target created in the project, the name is unique in the platform
+// so it usually refers the project variable, such as ${project}-dev.
+type ProjectTemplateTarget struct {
+	Name        string        `json:"name"`
+	Alias       string        `json:"alias,omitempty"`
+	Description string        `json:"description,omitempty"`
+	Cluster     ClusterTarget `json:"cluster"`
+}
+
+// ProjectTemplateEnv the env created in the project
+type ProjectTemplateEnv struct {
+	Name        string `json:"name"`
+	Alias       string `json:"alias,omitempty"`
+	Description string `json:"description,omitempty"`
+	Namespace   string `json:"namespace"`
+	// Targets the names of the targets, they are usually declared by the same template
+	Targets []string `json:"targets,omitempty"`
+}
+
+// ProjectTemplatePermission the custom permission of the project
+type ProjectTemplatePermission struct {
+	Name      string   `json:"name"`
+	Alias     string   `json:"alias,omitempty"`
+	Resources []string `json:"resources"`
+	Actions   []string `json:"actions"`
+	Effect    string   `json:"effect"`
+}
+
+// ProjectTemplateRole the custom role of the project
+type ProjectTemplateRole struct {
+	Name        string   `json:"name"`
+	Alias       string   `json:"alias,omitempty"`
+	Permissions []string `json:"permissions"`
+}
+
+// ProjectTemplateMember the member of the project
+type ProjectTemplateMember struct {
+	// User the name of the user, such as ${owner}
+	User  string   `json:"user"`
+	Roles []string `json:"roles"`
+}
+
+// ProjectTemplateDistribution the config distribution of the project
+type ProjectTemplateDistribution struct {
+	Name    string                  `json:"name"`
+	Configs []ProjectTemplateConfig `json:"configs"`
+	Targets []ClusterTarget         `json:"targets"`
+}
+
+// ProjectTemplateConfig the config to distribute
+type ProjectTemplateConfig struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace,omitempty"`
+}
+
+// ProjectTemplatePipeline the default pipeline of the project
+type ProjectTemplatePipeline struct {
+	Name        string       `json:"name"`
+	Alias       string       `json:"alias,omitempty"`
+	Description string       `json:"description,omitempty"`
+	Spec        WorkflowSpec `json:"spec"`
+}
+
+// TableName return custom table name
+func (p *ProjectTemplate) TableName() string {
+	return tableNamePrefix + "project_template"
+}
+
+// ShortTableName is the compressed version of table name for kubeapi storage and others
+func (p *ProjectTemplate) ShortTableName() string {
+	return "pj_tpl"
+}
+
+// PrimaryKey return custom primary key
+func (p *ProjectTemplate) PrimaryKey() string {
+	return p.Name
+}
+
+// Index return custom index
+func (p *ProjectTemplate) Index() map[string]interface{} {
+	index := make(map[string]interface{})
+	if p.Name != "" {
+		index["name"] = p.Name
+	}
+	return index
+}
@@ -0,0 +1,119 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import "path"
+
+func init() {
+	RegisterModel(&SCIMConfig{})
+	RegisterModel(&SCIMGroup{})
+}
+
+// DefaultSCIMConfigName the name of the only SCIM config
+const DefaultSCIMConfigName = "default"
+
+// SCIMConfig the config of the SCIM provisioning endpoint
+type SCIMConfig struct {
+	BaseModel
+	Name    string `json:"name"`
+	Enabled bool   `json:"enabled"`
+	// TokenHash the sha256 hash of the bearer token, the token is only shown once after generated
+	TokenHash  string          `json:"tokenHash,omitempty"`
+	GroupRules []SCIMGroupRule `json:"groupRules,omitempty"`
+}
+
+// SCIMGroupRule maps the members of the SCIM groups onto the project users
+type SCIMGroupRule struct {
+	// Group the display name of the group, the shell pattern is supported, such as "team-*"
+	Group   string   `json:"group"`
+	Project string   `json:"project"`
+	Roles   []string `json:"roles"`
+}
+
+// Match return whether the rule matches the group
+func (r SCIMGroupRule) Match(displayName string) bool {
+	matched, err := path.Match(r.Group, displayName)
+	return err == nil && matched
+}
+
+// TableName return custom table name
+func (s *SCIMConfig) TableName() string {
+	return tableNamePrefix + "scim_config"
+}
+
+// ShortTableName return custom table name
+func (s *SCIMConfig) ShortTableName() string {
+	return "scimc"
+}
+
+// PrimaryKey return custom primary key
+func (s *SCIMConfig) PrimaryKey() string {
+	return s.Name
+}
+
+// Index return custom index
+func (s *SCIMConfig) Index() map[string]interface{} {
+	index := make(map[string]interface{})
+	if s.Name != "" {
+		index["name"] = s.Name
+	}
+	return index
+}
+
+// SCIMGroup is the group provisioned by the SCIM client
+type SCIMGroup struct {
+	BaseModel
+	ID          string `json:"id"`
+	DisplayName string `json:"displayName"`
+	ExternalID  string `json:"externalID,omitempty"`
+	// Members the names of the member users
+	Members []string `json:"members,omitempty"`
+}
+
+// TableName return custom table name
+func (s *SCIMGroup) TableName() string {
+	return tableNamePrefix + "scim_group"
+}
+
+// ShortTableName return custom table name
+func (s *SCIMGroup) ShortTableName() string {
+	return "scimg"
+}
+
+// PrimaryKey return custom primary key
+func (s *SCIMGroup) PrimaryKey() string {
+	return s.ID
+}
+
+// Index return custom index
+func (s *SCIMGroup) Index() map[string]interface{} {
+	index := make(map[string]interface{})
+	if s.ID != "" {
+		index["id"] = s.ID
+	}
+	return index
+}
+
+// HasMember return whether the user is the member of the group
+func (s *SCIMGroup) HasMember(username string) bool {
+	for _, m := range s.Members {
+		if m == username {
+			return true
+		}
+	}
+	return false
+}
@@ -39,6 +39,17 @@ type SystemInfo struct {
 	LoginType                   string        `json:"loginType"`
 	DexUserDefaultProjects      []ProjectRef  `json:"projects"`
 	DexUserDefaultPlatformRoles []string      `json:"dexUserDefaultPlatformRoles"`
+	// SigningKeys the keys to sign the JWT tokens, the first one signs the new tokens,
+	// the retired ones are kept to verify the issued tokens until they expire.
+	SigningKeys []SigningKey `json:"signingKeys,omitempty"`
+}
+
+// SigningKey the key to sign the JWT tokens, the empty ID means the legacy key (SignedKey).
+type SigningKey struct {
+	ID         string    `json:"id"`
+	Key        string    `json:"key"`
+	CreateTime time.Time `json:"createTime"`
+	RetireTime time.Time `json:"retireTime,omitempty"`
 }
 
 // ProjectRef set the project name and roles
@@ -16,6 +16,8 @@ limitations under the License.
 
 package model
 
+import "time"
+
 func init() {
 	RegisterModel(&Target{})
 }
@@ -30,6 +32,11 @@ type Target struct {
 	Description string                 `json:"description,omitempty"`
 	Cluster     *ClusterTarget         `json:"cluster,omitempty"`
 	Variable    map[string]interface{} `json:"variable,omitempty"`
+	// Health the result of the latest namespace reconciliation, nil means the target is not checked yet
+	Health *NamespaceHealth `json:"health,omitempty"`
+	// ProvisionedClusters the clusters the namespace, the quota and the privileges of the target are provisioned in,
+	// the clusters not matched by the target anymore are released according to it
+	ProvisionedClusters []string `json:"provisionedClusters,omitempty"`
 }
 
 // TableName return custom table name
@@ -59,8 +66,37 @@ func (d *Target) Index() map[string]interface{} {
 	return index
 }
 
-// ClusterTarget one kubernetes cluster delivery target
+// ClusterTarget the kubernetes clusters delivery target, it selects one cluster by the name,
+// or selects the clusters dynamically by the labels or the cluster group
 type ClusterTarget struct {
-	ClusterName string `json:"clusterName" validate:"checkname"`
+	ClusterName string `json:"clusterName" validate:"omitempty,checkname"`
 	Namespace   string `json:"namespace" optional:"true"`
+	// LabelSelector selects all clusters matching the labels, includes the clusters joined later
+	LabelSelector map[string]string `json:"labelSelector,omitempty"`
+	// ClusterGroup selects all clusters in the cluster group
+	ClusterGroup string `json:"clusterGroup,omitempty"`
+}
+
+// IsDynamic whether the clusters of the target are resolved dynamically
+func (c *ClusterTarget) IsDynamic() bool {
+	return c != nil && (len(c.LabelSelector) > 0 || c.ClusterGroup != "")
+}
+
+const (
+	// NamespaceHealthy means the namespace and the privileges match the expectation
+	NamespaceHealthy = "Healthy"
+	// NamespaceDrifted means the namespace or the privileges are changed out-of-band
+	NamespaceDrifted = "Drifted"
+	// NamespaceUnreachable means the cluster of the namespace could not be reached
+	NamespaceUnreachable = "Unreachable"
+)
+
+// NamespaceHealth the health status of the namespace of the target or the env
+type NamespaceHealth struct {
+	Status string `json:"status"`
+	// Findings the drifts found by the latest check
+	Findings []string `json:"findings,omitempty"`
+	// Repaired the drifts repaired by the latest check
+	Repaired      []string  `json:"repaired,omitempty"`
+	LastCheckTime time.Time `json:"lastCheckTime"`
 }
@@ -29,6 +29,7 @@ func init() {
 	RegisterModel(&Role{})
 	RegisterModel(&Permission{})
 	RegisterModel(&PermissionTemplate{})
+	RegisterModel(&Session{})
 }
 
 // DefaultAdminUserAlias default admin user alias
@@ -49,8 +50,31 @@ type User struct {
 	// UserRoles binding the platform level roles
 	UserRoles []string `json:"userRoles"`
 	DexSub    string   `json:"dexSub,omitempty"`
+	// PasswordHistory the hashes of the previous passwords, the newest is the first one
+	PasswordHistory    []string  `json:"passwordHistory,omitempty"`
+	PasswordUpdateTime time.Time `json:"passwordUpdateTime,omitempty"`
+	// FailedLoginAttempts the number of the consecutive failed login attempts
+	FailedLoginAttempts int       `json:"failedLoginAttempts,omitempty"`
+	LockReason          string    `json:"lockReason,omitempty"`
+	LockTime            time.Time `json:"lockTime,omitempty"`
+	// LockedUntil the zero value means the user is locked until an administrator unlocks it
+	LockedUntil time.Time `json:"lockedUntil,omitempty"`
+	// TOTPSecret the base32 encoded secret of the TOTP authenticator, it is enrolled but not enabled until the first code is verified
+	TOTPSecret  string `json:"totpSecret,omitempty"`
+	TOTPEnabled bool   `json:"totpEnabled,omitempty"`
+	// TOTPLastStep the time step of the last accepted TOTP code, the codes of this step and before are rejected to prevent the replay
+	TOTPLastStep int64 `json:"totpLastStep,omitempty"`
+	// RecoveryCodes the hashes of the unused recovery codes
+	RecoveryCodes []string `json:"recoveryCodes,omitempty"`
+	// ExternalID the identifier of the user in the SCIM client
+	ExternalID string `json:"externalID,omitempty"`
+	// SCIMUserName the user name from the SCIM client, the name of the user is derived from it
+	SCIMUserName string `json:"scimUserName,omitempty"`
 }
 
+// LockReasonTooManyFailedAttempts the lock reason of the user who failed to log in too many times
+const LockReasonTooManyFailedAttempts = "too many failed login attempts"
+
 // TableName return custom table name
 func (u *User) TableName() string {
 	return tableNamePrefix + "user"
@@ -81,6 +105,19 @@ func (u *User) Index() map[string]interface{} {
 	return index
 }
 
+// IsLocked return if the user is locked at the time
+func (u *User) IsLocked(now time.Time) bool {
+	if u.LockReason == "" {
+		return false
+	}
+	return u.LockedUntil.IsZero() || now.Before(u.LockedUntil)
+}
+
+// IsSCIMProvisioned return if the user is provisioned by the SCIM client, only these users are managed by SCIM
+func (u *User) IsSCIMProvisioned() bool {
+	return u.SCIMUserName != ""
+}
+
 // IsAdmin return if the user have admin role
 func (u *User) IsAdmin() bool {
 	for _, role := range u.UserRoles {
@@ -131,9 +168,53 @@ func (u *ProjectUser) Index() map[string]interface{} {
 type CustomClaims struct {
 	Username  string `json:"username"`
 	GrantType string `json:"grantType"`
+	// SessionID the ID of the server-side session, the token is invalid after the session is revoked
+	SessionID string `json:"sid,omitempty"`
 	jwt.StandardClaims
 }
 
+// Session is the model of the login session, the access tokens and the refresh tokens are bound to it
+type Session struct {
+	BaseModel
+	ID       string `json:"id"`
+	Username string `json:"username"`
+	// UserAgent the device of the client
+	UserAgent    string    `json:"userAgent,omitempty"`
+	IP           string    `json:"ip,omitempty"`
+	LastSeenTime time.Time `json:"lastSeenTime"`
+	// ExpireTime the session expires with the latest refresh token
+	ExpireTime time.Time `json:"expireTime"`
+	// RefreshTokenID the ID of the latest refresh token, the refresh tokens issued before are invalid
+	RefreshTokenID string `json:"refreshTokenID,omitempty"`
+}
+
+// TableName return custom table name
+func (s *Session) TableName() string {
+	return tableNamePrefix + "session"
+}
+
+// ShortTableName return custom table name
+func (s *Session) ShortTableName() string {
+	return "sess"
+}
+
+// PrimaryKey return custom primary key
+func (s *Session) PrimaryKey() string {
+	return s.ID
+}
+
+// Index return custom index
+func (s *Session) Index() map[string]interface{} {
+	index := make(map[string]interface{})
+	if s.ID != "" {
+		index["id"] = s.ID
+	}
+	if s.Username != "" {
+		index["username"] = s.Username
+	}
+	return index
+}
+
 // Role is a model for a new RBAC mode.
 type Role struct {
 	BaseModel
@@ -0,0 +1,93 @@
+/*
+ Copyright 2021 The KubeVela Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+ 	http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	prismclusterv1alpha1 "github.com/kubevela/prism/pkg/apis/cluster/v1alpha1"
+
+	"github.com/oam-dev/kubevela/pkg/utils"
+
+	"github.com/kubevela/velaux/pkg/server/domain/model"
+	"github.com/kubevela/velaux/pkg/server/infrastructure/datastore"
+	"github.com/kubevela/velaux/pkg/server/utils/bcode"
+)
+
+// GetClusterGroup get the cluster group
+func GetClusterGroup(ctx context.Context, ds datastore.DataStore, name string) (*model.ClusterGroup, error) {
+	group := &model.ClusterGroup{Name: name}
+	if err := ds.Get(ctx, group); err != nil {
+		if errors.Is(err, datastore.ErrRecordNotExist) {
+			return nil, bcode.ErrClusterGroupNotFound
+		}
+		return nil, err
+	}
+	return group, nil
+}
+
+// ResolveClusterGroup list the names of the existing clusters in the group, includes the clusters matching the label selector
+func ResolveClusterGroup(ctx context.Context, k8sClient client.Client, group *model.ClusterGroup) ([]string, error) {
+	clusters, err := prismclusterv1alpha1.NewClusterClient(k8sClient).List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	selector := labels.SelectorFromSet(group.LabelSelector)
+	var names []string
+	for _, cluster := range clusters.Items {
+		if utils.StringsContain(group.Clusters, cluster.Name) || (len(group.LabelSelector) > 0 && selector.Matches(labels.Set(cluster.Labels))) {
+			names = append(names, cluster.Name)
+		}
+	}
+	return names, nil
+}
+
+// ListClustersByLabels list the names of the clusters matching the labels
+func ListClustersByLabels(ctx context.Context, k8sClient client.Client, matchLabels map[string]string) ([]string, error) {
+	clusters, err := prismclusterv1alpha1.NewClusterClient(k8sClient).List(ctx, client.MatchingLabels(matchLabels))
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, cluster := range clusters.Items {
+		names = append(names, cluster.Name)
+	}
+	return names, nil
+}
+
+// ResolveTargetClusters resolve the clusters of the target. The dynamic target is resolved to the clusters
+// matching the labels or in the cluster group at present, so the clusters joined later are included.
+func ResolveTargetClusters(ctx context.Context, ds datastore.DataStore, k8sClient client.Client, target *model.Target) ([]string, error) {
+	if target.Cluster == nil {
+		return nil, nil
+	}
+	if !target.Cluster.IsDynamic() {
+		return []string{target.Cluster.ClusterName}, nil
+	}
+	if target.Cluster.ClusterGroup != "" {
+		group, err := GetClusterGroup(ctx, ds, target.Cluster.ClusterGroup)
+		if err != nil {
+			return nil, err
+		}
+		return ResolveClusterGroup(ctx, k8sClient, group)
+	}
+	return ListClustersByLabels(ctx, k8sClient, target.Cluster.LabelSelector)
+}
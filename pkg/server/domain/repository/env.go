@@ -50,7 +50,18 @@ func CreateEnv(ctx context.Context, kubeClient client.Client, ds datastore.DataS
 	}
 
 	// Creating the namespace at first.
-	err = util.CreateOrUpdateNamespace(ctx, kubeClient, env.Namespace,
+	if err := CreateEnvNamespace(ctx, kubeClient, env); err != nil {
+		return err
+	}
+	if err = ds.Add(ctx, env); err != nil {
+		return err
+	}
+	return nil
+}
+
+// CreateEnvNamespace create the namespace of the env in the control plane, or label the existing one
+func CreateEnvNamespace(ctx context.Context, kubeClient client.Client, env *model.Env) error {
+	err := util.CreateOrUpdateNamespace(ctx, kubeClient, env.Namespace,
 		util.MergeOverrideLabels(map[string]string{
 			oam.LabelControlPlaneNamespaceUsage: oam.VelaNamespaceUsageEnv,
 		}), util.MergeNoConflictLabels(map[string]string{
@@ -63,9 +74,6 @@ func CreateEnv(ctx context.Context, kubeClient client.Client, ds datastore.DataS
 		klog.Errorf("update namespace label failure %s", err.Error())
 		return bcode.ErrEnvNamespaceFail
 	}
-	if err = ds.Add(ctx, env); err != nil {
-		return err
-	}
 	return nil
 }
 
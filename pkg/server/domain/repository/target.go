@@ -18,8 +18,12 @@ package repository
 
 import (
 	"context"
+	"fmt"
 
+	corev1 "k8s.io/api/core/v1"
 	apierror "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/klog/v2"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
@@ -53,6 +57,86 @@ func CreateTargetNamespace(ctx context.Context, k8sClient client.Client, cluster
 	return nil
 }
 
+const (
+	// TargetResourceQuotaName the name of the ResourceQuota provisioned into the namespace of the target
+	TargetResourceQuotaName = "vela-project-quota"
+	// TargetLimitRangeName the name of the LimitRange provisioned into the namespace of the target
+	TargetLimitRangeName = "vela-project-limits"
+)
+
+// ParseResourceList parse the resource list, such as {"requests.cpu": "10"}
+func ParseResourceList(resources map[string]string) (corev1.ResourceList, error) {
+	if len(resources) == 0 {
+		return nil, nil
+	}
+	list := corev1.ResourceList{}
+	for name, value := range resources {
+		quantity, err := resource.ParseQuantity(value)
+		if err != nil || quantity.Sign() < 0 {
+			return nil, bcode.ErrProjectQuotaInvalid.SetMessage(fmt.Sprintf("the quantity %s of %s is invalid", value, name))
+		}
+		list[corev1.ResourceName(name)] = quantity
+	}
+	return list, nil
+}
+
+// ApplyTargetQuota provision the ResourceQuota and the LimitRange of the project quota into the namespace of the target,
+// they are removed if the quota does not declare the resources.
+func ApplyTargetQuota(ctx context.Context, k8sClient client.Client, clusterName, namespace string, quota *model.ProjectQuota) error {
+	if clusterName == "" || namespace == "" {
+		return bcode.ErrTargetInvalidWithEmptyClusterOrNamespace
+	}
+	if quota == nil {
+		quota = &model.ProjectQuota{}
+	}
+	ctx = multicluster.ContextWithClusterName(ctx, clusterName)
+	hard, err := ParseResourceList(quota.ResourceQuota)
+	if err != nil {
+		return err
+	}
+	resourceQuota := &corev1.ResourceQuota{ObjectMeta: metav1.ObjectMeta{Name: TargetResourceQuotaName, Namespace: namespace}}
+	if err := applyQuotaObject(ctx, k8sClient, resourceQuota, len(hard) == 0, func() {
+		resourceQuota.Spec = corev1.ResourceQuotaSpec{Hard: hard}
+	}); err != nil {
+		return err
+	}
+
+	limits, err := ParseResourceList(quota.DefaultLimits)
+	if err != nil {
+		return err
+	}
+	requests, err := ParseResourceList(quota.DefaultRequests)
+	if err != nil {
+		return err
+	}
+	limitRange := &corev1.LimitRange{ObjectMeta: metav1.ObjectMeta{Name: TargetLimitRangeName, Namespace: namespace}}
+	return applyQuotaObject(ctx, k8sClient, limitRange, len(limits) == 0 && len(requests) == 0, func() {
+		limitRange.Spec = corev1.LimitRangeSpec{Limits: []corev1.LimitRangeItem{{
+			Type:           corev1.LimitTypeContainer,
+			Default:        limits,
+			DefaultRequest: requests,
+		}}}
+	})
+}
+
+func applyQuotaObject(ctx context.Context, k8sClient client.Client, obj client.Object, remove bool, mutate func()) error {
+	if remove {
+		if err := k8sClient.Delete(ctx, obj); err != nil && !apierror.IsNotFound(err) {
+			return err
+		}
+		return nil
+	}
+	if err := k8sClient.Get(ctx, client.ObjectKeyFromObject(obj), obj); err != nil {
+		if !apierror.IsNotFound(err) {
+			return err
+		}
+		mutate()
+		return k8sClient.Create(ctx, obj)
+	}
+	mutate()
+	return k8sClient.Update(ctx, obj)
+}
+
 // DeleteTargetNamespace delete the namespace of the target
 func DeleteTargetNamespace(ctx context.Context, k8sClient client.Client, clusterName, namespace, targetName string) error {
 	err := utils.UpdateNamespace(multicluster.ContextWithClusterName(ctx, clusterName), k8sClient, namespace,
@@ -463,7 +463,7 @@ func HaveTerraformWorkload(ctx context.Context, kubeClient client.Client, compon
 func createOverrideConfigForTerraformComponent(env *model.Env, target *model.Target, terraformComponents []*model.ApplicationComponent) v1alpha1.EnvConfig {
 	placement := v1alpha1.EnvPlacement{}
 	if target.Cluster != nil {
-		placement.ClusterSelector = &common.ClusterSelector{Name: target.Cluster.ClusterName}
+		placement.ClusterSelector = &common.ClusterSelector{Name: target.Cluster.ClusterName, Labels: target.Cluster.LabelSelector}
 		placement.NamespaceSelector = &v1alpha1.NamespaceSelector{Name: target.Cluster.Namespace}
 	}
 	var componentPatches []v1alpha1.EnvComponentPatch
@@ -585,12 +585,7 @@ func GenEnvWorkflowStepsAndPolicies(ctx context.Context, kubeClient client.Clien
 				Creator:       userName,
 				EnvName:       env.Name,
 			}
-			properties, err := model.NewJSONStructByStruct(v1alpha1.TopologyPolicySpec{
-				Placement: v1alpha1.Placement{
-					Clusters: []string{target.Cluster.ClusterName},
-				},
-				Namespace: target.Cluster.Namespace,
-			})
+			properties, err := model.NewJSONStructByStruct(TopologyPolicySpecForTarget(target, nil))
 			if err != nil {
 				klog.Errorf("fail to create the properties of the topology policy, %s", err.Error())
 				continue
@@ -619,6 +614,21 @@ func GenEnvWorkflowStepsAndPolicies(ctx context.Context, kubeClient client.Clien
 	return steps, policies
 }
 
+// TopologyPolicySpecForTarget generate the topology policy of the target. The clusters of the dynamic target are
+// resolved when rendering the application, the resolved clusters override the placement of the policy.
+func TopologyPolicySpecForTarget(target *model.Target, resolvedClusters []string) v1alpha1.TopologyPolicySpec {
+	spec := v1alpha1.TopologyPolicySpec{Namespace: target.Cluster.Namespace}
+	switch {
+	case resolvedClusters != nil:
+		spec.Placement.Clusters = resolvedClusters
+	case len(target.Cluster.LabelSelector) > 0:
+		spec.Placement.ClusterLabelSelector = target.Cluster.LabelSelector
+	case target.Cluster.ClusterName != "":
+		spec.Placement.Clusters = []string{target.Cluster.ClusterName}
+	}
+	return spec
+}
+
 // UpdateWorkflowSteps will update workflow with new steps
 func UpdateWorkflowSteps(ctx context.Context, ds datastore.DataStore, workflow *model.Workflow, steps []model.WorkflowStep) error {
 	workflow.Steps = steps
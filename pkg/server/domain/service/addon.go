@@ -21,6 +21,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"path/filepath"
 	"sort"
 	"strings"
 	"sync"
@@ -49,7 +50,10 @@ import (
 	velaerr "github.com/oam-dev/kubevela/pkg/utils/errors"
 	"github.com/oam-dev/kubevela/pkg/utils/schema"
 
+	"github.com/kubevela/velaux/pkg/server/config"
+	"github.com/kubevela/velaux/pkg/server/domain/model"
 	"github.com/kubevela/velaux/pkg/server/infrastructure/clients"
+	"github.com/kubevela/velaux/pkg/server/infrastructure/datastore"
 	apis "github.com/kubevela/velaux/pkg/server/interfaces/api/dto/v1"
 	"github.com/kubevela/velaux/pkg/server/utils/bcode"
 )
@@ -64,10 +68,20 @@ type AddonService interface {
 	ListAddons(ctx context.Context, registry, query string) ([]*apis.DetailAddonResponse, error)
 	StatusAddon(ctx context.Context, name string) (*apis.AddonStatusResponse, error)
 	GetAddon(ctx context.Context, name string, registry string, version string) (*apis.DetailAddonResponse, error)
-	EnableAddon(ctx context.Context, name string, args apis.EnableAddonRequest) error
-	DisableAddon(ctx context.Context, name string, force bool) error
+	EnableAddon(ctx context.Context, name string, args apis.EnableAddonRequest) (*apis.AddonOperationBase, error)
+	DisableAddon(ctx context.Context, name string, force bool) (*apis.AddonOperationBase, error)
 	ListEnabledAddon(ctx context.Context) ([]*apis.AddonBaseStatus, error)
-	UpdateAddon(ctx context.Context, name string, args apis.EnableAddonRequest) error
+	UpdateAddon(ctx context.Context, name string, args apis.EnableAddonRequest) (*apis.AddonOperationBase, error)
+	ListAddonOperations(ctx context.Context, name string, page, pageSize int) (*apis.ListAddonOperationResponse, error)
+	GetAddonOperation(ctx context.Context, name string, operationID string) (*apis.AddonOperationBase, error)
+	PreviewAddonUpgrade(ctx context.Context, name string, args apis.EnableAddonRequest) (*apis.AddonUpgradePreviewResponse, error)
+	PromoteAddonRollout(ctx context.Context, name string) (*apis.AddonOperationBase, error)
+	UploadOfflineAddon(ctx context.Context, registry string, data []byte, checksum string) (*apis.OfflineAddonVersion, error)
+	SyncOfflineAddonRegistry(ctx context.Context, registry string) (*apis.SyncOfflineAddonRegistryResponse, error)
+	ListOfflineAddons(ctx context.Context, registry string) (*apis.ListOfflineAddonResponse, error)
+	DeleteOfflineAddon(ctx context.Context, registry, name, version string) error
+	CheckOfflineAddonRegistryToken(ctx context.Context, registry, username, password string) error
+	GetOfflineAddonRegistryFile(ctx context.Context, registry, file string) ([]byte, error)
 	Init(ctx context.Context) error
 }
 
@@ -117,28 +131,39 @@ func AddonImpl2AddonRes(impl *pkgaddon.UIData, config *rest.Config) (*apis.Detai
 }
 
 // NewAddonService returns an addon service
-func NewAddonService(cacheTime time.Duration) AddonService {
+func NewAddonService(cacheTime time.Duration, offline config.OfflineAddonConfig) AddonService {
 	dc, err := clients.GetDiscoveryClient()
 	if err != nil {
 		panic(err)
 	}
+	// the storage directory is relative to the working directory by default, resolve it once at the startup
+	if dir, err := filepath.Abs(offline.StorageDir); err == nil {
+		offline.StorageDir = dir
+	}
 
 	return &addonServiceImpl{
 		cacheTime:       cacheTime,
+		offline:         offline,
 		mutex:           new(sync.RWMutex),
 		discoveryClient: dc,
+		replicaID:       newReplicaID(),
 	}
 }
 
 type addonServiceImpl struct {
 	cacheTime          time.Duration
 	addonRegistryCache *pkgaddon.Cache
+	Store              datastore.DataStore        `inject:"datastore"`
 	RegistryDS         pkgaddon.RegistryDataStore `inject:"registryDatastore"`
 	KubeClient         client.Client              `inject:"kubeClient"`
 	KubeConfig         *rest.Config               `inject:"kubeConfig"`
 	Apply              apply.Applicator           `inject:"apply"`
 	discoveryClient    *discovery.DiscoveryClient
-	mutex              *sync.RWMutex
+	offline            config.OfflineAddonConfig
+	// mutex guards the local storage of the offline addon registries
+	mutex *sync.RWMutex
+	// replicaID the owner of the addon operations run by this replica
+	replicaID string
 }
 
 func (u *addonServiceImpl) Init(ctx context.Context) error {
@@ -146,7 +171,10 @@ func (u *addonServiceImpl) Init(ctx context.Context) error {
 	// TODO(@wonderflow): it's better to add a close channel here, but it should be fine as it's only invoke once in APIServer.
 	go cache.DiscoverAndRefreshLoop(ctx, u.cacheTime)
 	u.addonRegistryCache = cache
-	return nil
+	if err := u.syncOfflineAddonRegistryURL(ctx); err != nil {
+		klog.Errorf("failed to sync the url of the offline addon registries: %s", err.Error())
+	}
+	return u.failInterruptedAddonOperations(ctx)
 }
 
 // GetAddon will get addon information
@@ -159,7 +187,7 @@ func (u *addonServiceImpl) GetAddon(ctx context.Context, name string, registry s
 			return nil, err
 		}
 		for _, r := range registries {
-			addon, err = u.addonRegistryCache.GetUIData(r, name, version)
+			addon, err = u.getAddonUIData(r, name, version)
 			if err != nil && !errors.Is(err, pkgaddon.ErrNotExist) {
 				return nil, err
 			}
@@ -172,7 +200,7 @@ func (u *addonServiceImpl) GetAddon(ctx context.Context, name string, registry s
 		if err != nil {
 			return nil, err
 		}
-		addon, err = u.addonRegistryCache.GetUIData(addonRegistry, name, version)
+		addon, err = u.getAddonUIData(addonRegistry, name, version)
 		if err != nil && !errors.Is(err, pkgaddon.ErrNotExist) {
 			return nil, err
 		}
@@ -191,6 +219,14 @@ func (u *addonServiceImpl) GetAddon(ctx context.Context, name string, registry s
 	return a, nil
 }
 
+// getAddonUIData get the addon from the local storage for the offline addon registry, or from the cache for the others
+func (u *addonServiceImpl) getAddonUIData(r pkgaddon.Registry, name, version string) (*pkgaddon.UIData, error) {
+	if u.isOfflineAddonRegistry(r) {
+		return u.getOfflineAddonUIData(r.Name, name, version)
+	}
+	return u.addonRegistryCache.GetUIData(r, name, version)
+}
+
 func (u *addonServiceImpl) StatusAddon(ctx context.Context, name string) (*apis.AddonStatusResponse, error) {
 	status, err := pkgaddon.GetAddonStatus(ctx, u.KubeClient, name)
 	if err != nil {
@@ -227,6 +263,19 @@ func (u *addonServiceImpl) StatusAddon(ctx context.Context, name string) (*apis.
 		AllClusters:      allClusters,
 	}
 
+	rollout, err := u.getAddonRollout(ctx, name)
+	if err != nil && !errors.Is(err, bcode.ErrAddonRolloutNotExist) {
+		return nil, err
+	}
+	if rollout != nil {
+		res.Rollout = convertAddonRolloutModel(rollout)
+	}
+	var clusterNames []string
+	for _, c := range clusters {
+		clusterNames = append(clusterNames, c.Name)
+	}
+	res.ClusterStatus = buildAddonClusterStatus(status, rollout, clusterNames)
+
 	var sec v1.Secret
 	err = u.KubeClient.Get(ctx, client.ObjectKey{
 		Namespace: types.DefaultKubeVelaNS,
@@ -259,7 +308,12 @@ func (u *addonServiceImpl) ListAddons(ctx context.Context, registry, query strin
 		if registry != "" && r.Name != registry {
 			continue
 		}
-		listAddons, err := u.addonRegistryCache.ListUIData(r)
+		var listAddons []*pkgaddon.UIData
+		if u.isOfflineAddonRegistry(r) {
+			listAddons, err = u.listOfflineAddonUIData(r.Name)
+		} else {
+			listAddons, err = u.addonRegistryCache.ListUIData(r)
+		}
 		if err != nil {
 			gatherErr = append(gatherErr, err)
 			continue
@@ -307,10 +361,19 @@ func (u *addonServiceImpl) ListAddons(ctx context.Context, registry, query strin
 }
 
 func (u *addonServiceImpl) DeleteAddonRegistry(ctx context.Context, name string) error {
-	return u.RegistryDS.DeleteRegistry(ctx, name)
+	if err := u.RegistryDS.DeleteRegistry(ctx, name); err != nil {
+		return err
+	}
+	return u.deleteOfflineAddonRegistry(ctx, name)
 }
 
 func (u *addonServiceImpl) CreateAddonRegistry(ctx context.Context, req apis.CreateAddonRegistryRequest) (*apis.AddonRegistry, error) {
+	if req.Offline != nil {
+		if req.Helm != nil || req.Git != nil || req.Oss != nil || req.Gitee != nil || req.Gitlab != nil {
+			return nil, bcode.ErrAddonRegistryInvalid.SetMessage("the offline addon registry can't have another source")
+		}
+		return u.createOfflineAddonRegistry(ctx, req)
+	}
 	r := addonRegistryModelFromCreateAddonRegistryRequest(req)
 
 	err := u.RegistryDS.AddRegistry(ctx, r)
@@ -337,7 +400,19 @@ func (u *addonServiceImpl) GetAddonRegistry(ctx context.Context, name string) (*
 	if err != nil {
 		return nil, err
 	}
-	return convertAddonRegistry(r), nil
+	return u.convertAddonRegistry(ctx, r)
+}
+
+// convertAddonRegistry convert the registry, the offline addon registry is shown with its source directory instead of the internal helm repository
+func (u *addonServiceImpl) convertAddonRegistry(ctx context.Context, r pkgaddon.Registry) (*apis.AddonRegistry, error) {
+	if !u.isOfflineAddonRegistry(r) {
+		return convertAddonRegistry(r), nil
+	}
+	offline, err := u.getOfflineAddonRegistry(ctx, r.Name)
+	if err != nil {
+		return nil, err
+	}
+	return u.convertOfflineAddonRegistry(offline), nil
 }
 
 func (u addonServiceImpl) UpdateAddonRegistry(ctx context.Context, name string, req apis.UpdateAddonRegistryRequest) (*apis.AddonRegistry, error) {
@@ -345,6 +420,16 @@ func (u addonServiceImpl) UpdateAddonRegistry(ctx context.Context, name string,
 	if err != nil {
 		return nil, bcode.ErrAddonRegistryNotExist
 	}
+	if u.isOfflineAddonRegistry(r) {
+		offline, err := u.getOfflineAddonRegistry(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		return u.updateOfflineAddonRegistry(ctx, offline, req)
+	}
+	if req.Offline != nil {
+		return nil, bcode.ErrAddonRegistryInvalid.SetMessage("the addon registry can't be changed to an offline addon registry")
+	}
 	switch {
 	case req.Git != nil:
 		r.Git = req.Git
@@ -378,7 +463,10 @@ func (u *addonServiceImpl) ListAddonRegistries(ctx context.Context) ([]*apis.Add
 		return nil, err
 	}
 	for _, registry := range registries {
-		r := convertAddonRegistry(registry)
+		r, err := u.convertAddonRegistry(ctx, registry)
+		if err != nil {
+			return nil, err
+		}
 		list = append(list, r)
 	}
 	sort.Slice(list, func(i, j int) bool {
@@ -387,11 +475,11 @@ func (u *addonServiceImpl) ListAddonRegistries(ctx context.Context) ([]*apis.Add
 	return list, nil
 }
 
-func (u *addonServiceImpl) EnableAddon(ctx context.Context, name string, args apis.EnableAddonRequest) error {
-	var err error
+// EnableAddon start an operation to enable the addon in the background
+func (u *addonServiceImpl) EnableAddon(ctx context.Context, name string, args apis.EnableAddonRequest) (*apis.AddonOperationBase, error) {
 	registries, err := u.RegistryDS.ListRegistries(ctx)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	if len(args.RegistryName) != 0 {
 		foundRegistry := false
@@ -401,22 +489,49 @@ func (u *addonServiceImpl) EnableAddon(ctx context.Context, name string, args ap
 			}
 		}
 		if !foundRegistry {
-			return bcode.ErrAddonRegistryNotExist.SetMessage(fmt.Sprintf("specified registry %s not exist", args.RegistryName))
+			return nil, bcode.ErrAddonRegistryNotExist.SetMessage(fmt.Sprintf("specified registry %s not exist", args.RegistryName))
 		}
 	}
+	rollout, stagedArgs, err := u.stageAddonRollout(ctx, name, args, false)
+	if err != nil {
+		return nil, err
+	}
+	op := newAddonOperation(ctx, name, model.AddonOperationEnable)
+	op.Args, op.Version, op.RegistryName = stagedArgs, args.Version, args.RegistryName
+	return u.startAddonOperation(ctx, op, func(ctx context.Context) error {
+		if err := u.enableAddon(ctx, op, registries); err != nil {
+			return err
+		}
+		return u.recordAddonRollout(ctx, name, rollout)
+	})
+}
+
+// enableAddon enable the addon from the first registry which has the addon
+func (u *addonServiceImpl) enableAddon(ctx context.Context, op *model.AddonOperation, registries []pkgaddon.Registry) error {
 	for i, r := range registries {
-		if len(args.RegistryName) != 0 && args.RegistryName != r.Name {
+		if len(op.RegistryName) != 0 && op.RegistryName != r.Name {
 			continue
 		}
-		// TODO: response the additional info to velaux users
-		_, err = pkgaddon.EnableAddon(ctx, name, args.Version, u.KubeClient, u.discoveryClient, u.Apply, u.KubeConfig, r, args.Args, u.addonRegistryCache, pkgaddon.FilterDependencyRegistries(i, registries))
+		// Record the args with the sensitive values redacted, the schema is read from the same registry to apply the addon
+		if ui, err := u.getAddonUIData(r, op.Addon, op.Version); err == nil {
+			op.RedactedArgs = redactAddonArgs(op.Args, ui.APISchema)
+		} else {
+			op.RedactedArgs = redactAllAddonArgs(op.Args)
+		}
+		u.logAddonOperation(ctx, op, "rendering and applying the addon %s from the registry %s", op.Addon, r.Name)
+		info, err := pkgaddon.EnableAddon(ctx, op.Addon, op.Version, u.KubeClient, u.discoveryClient, u.Apply, u.KubeConfig, r, op.Args, u.addonRegistryCache, pkgaddon.FilterDependencyRegistries(i, registries))
 		if err == nil {
+			if info = strings.TrimSpace(info); info != "" {
+				op.Warnings = append(op.Warnings, info)
+			}
+			u.logAddonOperation(ctx, op, "the addon application is applied")
 			return nil
 		}
 
 		// if reach this line error must is not nil
 		if errors.Is(err, pkgaddon.ErrNotExist) {
 			// one registry return addon not exist error, should not break other registry func
+			u.logAddonOperation(ctx, op, "the addon is not found in the registry %s", r.Name)
 			continue
 		}
 		if strings.Contains(err.Error(), "specified version") {
@@ -433,13 +548,18 @@ func (u *addonServiceImpl) EnableAddon(ctx context.Context, name string, args ap
 	return bcode.ErrAddonNotExist
 }
 
-func (u *addonServiceImpl) DisableAddon(ctx context.Context, name string, force bool) error {
-	err := pkgaddon.DisableAddon(ctx, u.KubeClient, name, u.KubeConfig, force)
-	if err != nil {
-		klog.Errorf("delete application fail: %s", err.Error())
-		return err
-	}
-	return nil
+// DisableAddon start an operation to disable the addon in the background
+func (u *addonServiceImpl) DisableAddon(ctx context.Context, name string, force bool) (*apis.AddonOperationBase, error) {
+	op := newAddonOperation(ctx, name, model.AddonOperationDisable)
+	op.Force = force
+	return u.startAddonOperation(ctx, op, func(ctx context.Context) error {
+		u.logAddonOperation(ctx, op, "deleting the addon application")
+		if err := pkgaddon.DisableAddon(ctx, u.KubeClient, name, u.KubeConfig, force); err != nil {
+			klog.Errorf("delete application fail: %s", err.Error())
+			return err
+		}
+		return u.recordAddonRollout(ctx, name, nil)
+	})
 }
 
 func (u *addonServiceImpl) ListEnabledAddon(ctx context.Context) ([]*apis.AddonBaseStatus, error) {
@@ -462,7 +582,8 @@ func (u *addonServiceImpl) ListEnabledAddon(ctx context.Context) ([]*apis.AddonB
 	return response, nil
 }
 
-func (u *addonServiceImpl) UpdateAddon(ctx context.Context, name string, args apis.EnableAddonRequest) error {
+// UpdateAddon start an operation to update the enabled addon in the background
+func (u *addonServiceImpl) UpdateAddon(ctx context.Context, name string, args apis.EnableAddonRequest) (*apis.AddonOperationBase, error) {
 	var app v1beta1.Application
 	// check addon application whether exist
 	err := u.KubeClient.Get(ctx, client.ObjectKey{
@@ -470,31 +591,25 @@ func (u *addonServiceImpl) UpdateAddon(ctx context.Context, name string, args ap
 		Name:      addonutil.Addon2AppName(name),
 	}, &app)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	registries, err := u.RegistryDS.ListRegistries(ctx)
 	if err != nil {
-		return err
+		return nil, err
 	}
-
-	for i, r := range registries {
-		// TODO: response the additional info to velaux users
-		_, err = pkgaddon.EnableAddon(ctx, name, args.Version, u.KubeClient, u.discoveryClient, u.Apply, u.KubeConfig, r, args.Args, u.addonRegistryCache, pkgaddon.FilterDependencyRegistries(i, registries))
-		if err == nil {
-			return nil
-		}
-		if errors.Is(err, pkgaddon.ErrNotExist) {
-			continue
-		}
-		// wrap this error with special bcode
-		if errors.As(err, &pkgaddon.VersionUnMatchError{}) {
-			return bcode.ErrAddonSystemVersionMismatch
-		}
-		// except `addon not found`, other errors should return directly
-		return err
+	rollout, stagedArgs, err := u.stageAddonRollout(ctx, name, args, true)
+	if err != nil {
+		return nil, err
 	}
-	return bcode.ErrAddonNotExist
+	op := newAddonOperation(ctx, name, model.AddonOperationUpdate)
+	op.Args, op.Version, op.RegistryName = stagedArgs, args.Version, args.RegistryName
+	return u.startAddonOperation(ctx, op, func(ctx context.Context) error {
+		if err := u.enableAddon(ctx, op, registries); err != nil {
+			return err
+		}
+		return u.recordAddonRollout(ctx, name, rollout)
+	})
 }
 
 func addonRegistryModelFromCreateAddonRegistryRequest(req apis.CreateAddonRegistryRequest) pkgaddon.Registry {
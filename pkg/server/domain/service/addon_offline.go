@@ -0,0 +1,654 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/repo"
+	"k8s.io/apimachinery/pkg/util/rand"
+	"k8s.io/klog/v2"
+
+	pkgaddon "github.com/oam-dev/kubevela/pkg/addon"
+
+	"github.com/kubevela/velaux/pkg/server/domain/model"
+	"github.com/kubevela/velaux/pkg/server/infrastructure/datastore"
+	apis "github.com/kubevela/velaux/pkg/server/interfaces/api/dto/v1"
+	"github.com/kubevela/velaux/pkg/server/utils/bcode"
+)
+
+// OfflineAddonRegistryBasePath the path prefix of the endpoint serving the offline addon registries as the helm repositories
+const OfflineAddonRegistryBasePath = "/api/v1/offline_addon_registries"
+
+// OfflineAddonRegistryUser the username for the KubeVela addon client to pull the addons from the offline registries
+const OfflineAddonRegistryUser = "velaux"
+
+const offlineAddonIndexFile = "index.yaml"
+
+var (
+	// offlineAddonNameRegexp the name of the chart, it is lowercase letters, digits and dashes
+	offlineAddonNameRegexp = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`)
+	// offlineAddonVersionRegexp the semantic version accepted by helm, the minor and the patch are optional
+	offlineAddonVersionRegexp = regexp.MustCompile(`^v?(0|[1-9]\d*)(\.(0|[1-9]\d*)){0,2}(-[0-9A-Za-z-]+(\.[0-9A-Za-z-]+)*)?(\+[0-9A-Za-z-]+(\.[0-9A-Za-z-]+)*)?$`)
+)
+
+// offlineAddonRegistryURL the url of the helm repository serving the offline addon registry
+func (u *addonServiceImpl) offlineAddonRegistryURL(name string) string {
+	return strings.TrimSuffix(u.offline.ServerURL, "/") + OfflineAddonRegistryBasePath + "/" + name
+}
+
+// isOfflineAddonRegistry check whether the addon registry is served by this server
+func (u *addonServiceImpl) isOfflineAddonRegistry(r pkgaddon.Registry) bool {
+	return r.Helm != nil && r.Helm.Username == OfflineAddonRegistryUser && r.Helm.URL == u.offlineAddonRegistryURL(r.Name)
+}
+
+func (u *addonServiceImpl) offlineAddonRegistryDir(name string) string {
+	return filepath.Join(u.offline.StorageDir, name)
+}
+
+func (u *addonServiceImpl) getOfflineAddonRegistry(ctx context.Context, name string) (*model.OfflineAddonRegistry, error) {
+	registry := &model.OfflineAddonRegistry{Name: name}
+	if err := u.Store.Get(ctx, registry); err != nil {
+		if errors.Is(err, datastore.ErrRecordNotExist) {
+			return nil, bcode.ErrOfflineAddonRegistryNotExist
+		}
+		return nil, err
+	}
+	return registry, nil
+}
+
+// createOfflineAddonRegistry create the storage of the offline addon registry, and register it as a helm repository served by this server
+func (u *addonServiceImpl) createOfflineAddonRegistry(ctx context.Context, req apis.CreateAddonRegistryRequest) (*apis.AddonRegistry, error) {
+	if _, err := u.RegistryDS.GetRegistry(ctx, req.Name); err == nil {
+		return nil, bcode.ErrAddonRegistryExist
+	}
+	registry := &model.OfflineAddonRegistry{Name: req.Name, Dir: req.Offline.Dir, Token: rand.String(32)}
+	if err := u.Store.Add(ctx, registry); err != nil {
+		if errors.Is(err, datastore.ErrRecordExist) {
+			return nil, bcode.ErrAddonRegistryExist
+		}
+		return nil, err
+	}
+	u.mutex.Lock()
+	err := writeOfflineAddonIndex(u.offlineAddonRegistryDir(req.Name), repo.NewIndexFile())
+	u.mutex.Unlock()
+	if err == nil {
+		err = u.RegistryDS.AddRegistry(ctx, u.offlineAddonRegistryModel(registry))
+	}
+	if err != nil {
+		if err := u.Store.Delete(ctx, registry); err != nil {
+			klog.Errorf("failed to clean up the offline addon registry %s: %s", registry.Name, err.Error())
+		}
+		return nil, err
+	}
+	if registry.Dir != "" {
+		res, err := u.SyncOfflineAddonRegistry(ctx, registry.Name)
+		if err != nil {
+			klog.Errorf("failed to import the addons of the offline addon registry %s: %s", registry.Name, err.Error())
+		} else {
+			for _, failure := range res.Failed {
+				klog.Warningf("failed to import the addon %s to the offline addon registry %s: %s", failure.Source, registry.Name, failure.Reason)
+			}
+		}
+	}
+	return u.convertOfflineAddonRegistry(registry), nil
+}
+
+func (u *addonServiceImpl) offlineAddonRegistryModel(registry *model.OfflineAddonRegistry) pkgaddon.Registry {
+	return pkgaddon.Registry{
+		Name: registry.Name,
+		Helm: &pkgaddon.HelmSource{
+			URL:      u.offlineAddonRegistryURL(registry.Name),
+			Username: OfflineAddonRegistryUser,
+			Password: registry.Token,
+		},
+	}
+}
+
+func (u *addonServiceImpl) convertOfflineAddonRegistry(registry *model.OfflineAddonRegistry) *apis.AddonRegistry {
+	return &apis.AddonRegistry{Name: registry.Name, Offline: &apis.OfflineAddonSource{Dir: registry.Dir}}
+}
+
+// updateOfflineAddonRegistry update the source directory of the offline addon registry
+func (u *addonServiceImpl) updateOfflineAddonRegistry(ctx context.Context, registry *model.OfflineAddonRegistry, req apis.UpdateAddonRegistryRequest) (*apis.AddonRegistry, error) {
+	if req.Offline == nil || req.Helm != nil || req.Git != nil || req.Oss != nil || req.Gitee != nil || req.Gitlab != nil {
+		return nil, bcode.ErrAddonRegistryInvalid.SetMessage("the offline addon registry can't be changed to another type")
+	}
+	registry.Dir = req.Offline.Dir
+	if err := u.Store.Put(ctx, registry); err != nil {
+		return nil, err
+	}
+	return u.convertOfflineAddonRegistry(registry), nil
+}
+
+// deleteOfflineAddonRegistry remove the stored addons of the offline addon registry
+func (u *addonServiceImpl) deleteOfflineAddonRegistry(ctx context.Context, name string) error {
+	if err := u.Store.Delete(ctx, &model.OfflineAddonRegistry{Name: name}); err != nil {
+		if errors.Is(err, datastore.ErrRecordNotExist) {
+			return nil
+		}
+		return err
+	}
+	u.mutex.Lock()
+	defer u.mutex.Unlock()
+	return os.RemoveAll(u.offlineAddonRegistryDir(name))
+}
+
+// syncOfflineAddonRegistryURL keep the url of the offline addon registries consistent with the address of this server
+func (u *addonServiceImpl) syncOfflineAddonRegistryURL(ctx context.Context) error {
+	var registry model.OfflineAddonRegistry
+	entities, err := u.Store.List(ctx, &registry, nil)
+	if err != nil {
+		return err
+	}
+	for _, entity := range entities {
+		offline := entity.(*model.OfflineAddonRegistry)
+		r, err := u.RegistryDS.GetRegistry(ctx, offline.Name)
+		if err != nil {
+			klog.Errorf("failed to get the offline addon registry %s: %s", offline.Name, err.Error())
+			continue
+		}
+		if u.isOfflineAddonRegistry(r) {
+			continue
+		}
+		if err := u.RegistryDS.UpdateRegistry(ctx, u.offlineAddonRegistryModel(offline)); err != nil {
+			klog.Errorf("failed to update the url of the offline addon registry %s: %s", offline.Name, err.Error())
+		}
+	}
+	return nil
+}
+
+// CheckOfflineAddonRegistryToken check the basic auth of the client pulling the addons from the offline addon registry
+func (u *addonServiceImpl) CheckOfflineAddonRegistryToken(ctx context.Context, name, username, password string) error {
+	registry, err := u.getOfflineAddonRegistry(ctx, name)
+	if err != nil {
+		if errors.Is(err, bcode.ErrOfflineAddonRegistryNotExist) {
+			return bcode.ErrOfflineAddonRegistryUnauthorized
+		}
+		return err
+	}
+	if username != OfflineAddonRegistryUser || subtle.ConstantTimeCompare([]byte(password), []byte(registry.Token)) != 1 {
+		return bcode.ErrOfflineAddonRegistryUnauthorized
+	}
+	return nil
+}
+
+// GetOfflineAddonRegistryFile returns the index or the addon package of the offline addon registry,
+// the checksum of the addon package is verified before serving it
+func (u *addonServiceImpl) GetOfflineAddonRegistryFile(ctx context.Context, name, file string) ([]byte, error) {
+	if _, err := u.getOfflineAddonRegistry(ctx, name); err != nil {
+		return nil, err
+	}
+	dir := u.offlineAddonRegistryDir(name)
+	u.mutex.RLock()
+	defer u.mutex.RUnlock()
+	if file == offlineAddonIndexFile {
+		return os.ReadFile(filepath.Clean(filepath.Join(dir, offlineAddonIndexFile)))
+	}
+	index, err := loadOfflineAddonIndex(dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, versions := range index.Entries {
+		for _, version := range versions {
+			if len(version.URLs) == 0 || version.URLs[0] != file {
+				continue
+			}
+			data, err := readOfflineAddonFile(dir, file)
+			if err != nil {
+				return nil, err
+			}
+			if digest := addonPackageDigest(data); digest != version.Digest {
+				klog.Errorf("the checksum of the addon package %s in the offline addon registry %s is %s, expected %s", file, name, digest, version.Digest)
+				return nil, bcode.ErrOfflineAddonChecksumMismatch
+			}
+			return data, nil
+		}
+	}
+	return nil, bcode.ErrOfflineAddonNotExist
+}
+
+// UploadOfflineAddon store the packaged addon in the offline addon registry, the checksum is verified if it is specified
+func (u *addonServiceImpl) UploadOfflineAddon(ctx context.Context, name string, data []byte, checksum string) (*apis.OfflineAddonVersion, error) {
+	if _, err := u.getOfflineAddonRegistry(ctx, name); err != nil {
+		return nil, err
+	}
+	if checksum != "" && !strings.EqualFold(strings.TrimPrefix(checksum, "sha256:"), addonPackageDigest(data)) {
+		return nil, bcode.ErrOfflineAddonChecksumMismatch
+	}
+	u.mutex.Lock()
+	defer u.mutex.Unlock()
+	version, _, err := storeOfflineAddon(u.offlineAddonRegistryDir(name), data)
+	if err != nil {
+		return nil, err
+	}
+	return convertOfflineAddonVersion(version), nil
+}
+
+// SyncOfflineAddonRegistry import the packaged addons(*.tgz) and the addon directories from the source directory of the offline addon registry
+func (u *addonServiceImpl) SyncOfflineAddonRegistry(ctx context.Context, name string) (*apis.SyncOfflineAddonRegistryResponse, error) {
+	registry, err := u.getOfflineAddonRegistry(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if registry.Dir == "" {
+		return nil, bcode.ErrAddonRegistryInvalid.SetMessage("the source directory of the offline addon registry is not specified")
+	}
+	entries, err := os.ReadDir(registry.Dir)
+	if err != nil {
+		return nil, bcode.ErrAddonRegistryInvalid.SetMessage(fmt.Sprintf("fail to read the source directory: %s", err.Error()))
+	}
+	res := &apis.SyncOfflineAddonRegistryResponse{
+		Imported: []*apis.OfflineAddonVersion{},
+		Skipped:  []*apis.OfflineAddonVersion{},
+		Failed:   []apis.OfflineAddonImportFailure{},
+	}
+	u.mutex.Lock()
+	defer u.mutex.Unlock()
+	for _, entry := range entries {
+		source := filepath.Join(registry.Dir, entry.Name())
+		var data []byte
+		switch {
+		case entry.IsDir():
+			if isAddon, _ := pkgaddon.IsAddonDir(source); !isAddon {
+				continue
+			}
+			data, err = packageAddonDir(source)
+		case strings.HasSuffix(entry.Name(), ".tgz"):
+			data, err = os.ReadFile(filepath.Clean(source))
+		default:
+			continue
+		}
+		if err != nil {
+			res.Failed = append(res.Failed, apis.OfflineAddonImportFailure{Source: entry.Name(), Reason: err.Error()})
+			continue
+		}
+		version, stored, err := storeOfflineAddon(u.offlineAddonRegistryDir(name), data)
+		if err != nil {
+			res.Failed = append(res.Failed, apis.OfflineAddonImportFailure{Source: entry.Name(), Reason: err.Error()})
+			continue
+		}
+		if stored {
+			res.Imported = append(res.Imported, convertOfflineAddonVersion(version))
+		} else {
+			res.Skipped = append(res.Skipped, convertOfflineAddonVersion(version))
+		}
+	}
+	return res, nil
+}
+
+// ListOfflineAddons list the addon versions stored in the offline addon registry
+func (u *addonServiceImpl) ListOfflineAddons(ctx context.Context, name string) (*apis.ListOfflineAddonResponse, error) {
+	if _, err := u.getOfflineAddonRegistry(ctx, name); err != nil {
+		return nil, err
+	}
+	u.mutex.RLock()
+	index, err := loadOfflineAddonIndex(u.offlineAddonRegistryDir(name))
+	u.mutex.RUnlock()
+	if err != nil {
+		return nil, err
+	}
+	res := &apis.ListOfflineAddonResponse{Addons: []*apis.OfflineAddonVersion{}}
+	for _, versions := range index.Entries {
+		for _, version := range versions {
+			res.Addons = append(res.Addons, convertOfflineAddonVersion(version))
+		}
+	}
+	sort.SliceStable(res.Addons, func(i, j int) bool {
+		return res.Addons[i].Name < res.Addons[j].Name
+	})
+	return res, nil
+}
+
+// DeleteOfflineAddon remove the addon version from the offline addon registry
+func (u *addonServiceImpl) DeleteOfflineAddon(ctx context.Context, name, addonName, version string) error {
+	if _, err := u.getOfflineAddonRegistry(ctx, name); err != nil {
+		return err
+	}
+	dir := u.offlineAddonRegistryDir(name)
+	u.mutex.Lock()
+	defer u.mutex.Unlock()
+	index, err := loadOfflineAddonIndex(dir)
+	if err != nil {
+		return err
+	}
+	versions := index.Entries[addonName]
+	for i, v := range versions {
+		if v.Version != version {
+			continue
+		}
+		index.Entries[addonName] = append(versions[:i], versions[i+1:]...)
+		if len(index.Entries[addonName]) == 0 {
+			delete(index.Entries, addonName)
+		}
+		if err := writeOfflineAddonIndex(dir, index); err != nil {
+			return err
+		}
+		for _, file := range v.URLs {
+			if err := os.Remove(filepath.Join(dir, filepath.Base(file))); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+		return nil
+	}
+	return bcode.ErrOfflineAddonNotExist
+}
+
+// listOfflineAddonUIData list the latest version of the addons in the offline addon registry from the local storage,
+// it is always up-to-date unlike the addon registry cache
+func (u *addonServiceImpl) listOfflineAddonUIData(name string) ([]*pkgaddon.UIData, error) {
+	u.mutex.RLock()
+	index, err := loadOfflineAddonIndex(u.offlineAddonRegistryDir(name))
+	u.mutex.RUnlock()
+	if err != nil {
+		return nil, err
+	}
+	var addons []*pkgaddon.UIData
+	for addonName, versions := range index.Entries {
+		if len(versions) == 0 {
+			continue
+		}
+		latest := versions[0]
+		var availableVersions []string
+		for _, version := range versions {
+			availableVersions = append(availableVersions, version.Version)
+		}
+		addons = append(addons, &pkgaddon.UIData{Meta: pkgaddon.Meta{
+			Name:        addonName,
+			Icon:        latest.Icon,
+			Tags:        latest.Keywords,
+			Description: latest.Description,
+			Version:     latest.Version,
+		}, RegistryName: name, AvailableVersions: availableVersions})
+	}
+	return addons, nil
+}
+
+// getOfflineAddonUIData load the addon from the local storage of the offline addon registry,
+// the latest stable version is chosen if the version is not specified
+func (u *addonServiceImpl) getOfflineAddonUIData(name, addonName, version string) (*pkgaddon.UIData, error) {
+	dir := u.offlineAddonRegistryDir(name)
+	u.mutex.RLock()
+	defer u.mutex.RUnlock()
+	index, err := loadOfflineAddonIndex(dir)
+	if err != nil {
+		return nil, err
+	}
+	versions := index.Entries[addonName]
+	if len(versions) == 0 {
+		return nil, pkgaddon.ErrNotExist
+	}
+	var target *repo.ChartVersion
+	var availableVersions []string
+	for _, v := range versions {
+		availableVersions = append(availableVersions, v.Version)
+		if version != "" && v.Version == version {
+			target = v
+		}
+	}
+	if version == "" {
+		target, _ = index.Get(addonName, "")
+	}
+	if target == nil || len(target.URLs) == 0 {
+		return nil, fmt.Errorf("specified version %s not exist", version)
+	}
+	data, err := readOfflineAddonFile(dir, target.URLs[0])
+	if err != nil {
+		return nil, err
+	}
+	files, err := loader.LoadArchiveFiles(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	uiData, err := loadAddonUIDataFromFiles(addonName, files)
+	if err != nil {
+		return nil, err
+	}
+	uiData.AvailableVersions = availableVersions
+	uiData.RegistryName = name
+	uiData.Meta.SystemRequirements = pkgaddon.LoadSystemRequirements(target.Annotations)
+	return uiData, nil
+}
+
+func convertOfflineAddonVersion(version *repo.ChartVersion) *apis.OfflineAddonVersion {
+	return &apis.OfflineAddonVersion{
+		Name:        version.Name,
+		Version:     version.Version,
+		Description: version.Description,
+		Digest:      version.Digest,
+		Created:     version.Created,
+	}
+}
+
+// addonPackageDigest the sha256 checksum of the addon package, it is the same as the digest in the index of the helm repository
+func addonPackageDigest(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// addonContentDigest the checksum of the files in the addon package, the packages of the same files are treated as the same
+// even if they are packaged at different times
+func addonContentDigest(files []*loader.BufferedFile) string {
+	sorted := make([]*loader.BufferedFile, len(files))
+	copy(sorted, files)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Name < sorted[j].Name
+	})
+	hash := sha256.New()
+	for _, f := range sorted {
+		hash.Write([]byte(f.Name))
+		hash.Write([]byte{0})
+		hash.Write(f.Data)
+		hash.Write([]byte{0})
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+func loadAddonUIDataFromFiles(name string, files []*loader.BufferedFile) (*pkgaddon.UIData, error) {
+	reader := pkgaddon.MemoryReader{Name: name, Files: files}
+	metas, err := reader.ListAddonMeta()
+	if err != nil {
+		return nil, err
+	}
+	meta := metas[name]
+	return pkgaddon.GetUIDataFromReader(&reader, &meta, pkgaddon.UIMetaOptions)
+}
+
+// loadOfflineAddonPackage validate the packaged addon, it must be a helm chart with the addon metadata of the same name and version
+func loadOfflineAddonPackage(data []byte) (*chart.Metadata, []*loader.BufferedFile, error) {
+	files, err := loader.LoadArchiveFiles(bytes.NewReader(data))
+	if err != nil {
+		return nil, nil, bcode.ErrOfflineAddonInvalidPackage.SetMessage(fmt.Sprintf("fail to extract the addon package: %s", err.Error()))
+	}
+	ch, err := loader.LoadFiles(files)
+	if err != nil {
+		return nil, nil, bcode.ErrOfflineAddonInvalidPackage.SetMessage(fmt.Sprintf("the addon package is not a valid chart: %s", err.Error()))
+	}
+	// the name and the version make up the file name of the stored package
+	if !offlineAddonNameRegexp.MatchString(ch.Name()) {
+		return nil, nil, bcode.ErrOfflineAddonInvalidPackage.SetMessage(fmt.Sprintf("the addon name %q is invalid", ch.Name()))
+	}
+	if !offlineAddonVersionRegexp.MatchString(ch.Metadata.Version) {
+		return nil, nil, bcode.ErrOfflineAddonInvalidPackage.SetMessage(fmt.Sprintf("the addon version %q is not a semantic version", ch.Metadata.Version))
+	}
+	uiData, err := loadAddonUIDataFromFiles(ch.Name(), files)
+	if err != nil {
+		return nil, nil, bcode.ErrOfflineAddonInvalidPackage.SetMessage(fmt.Sprintf("fail to load the addon: %s", err.Error()))
+	}
+	if uiData.Name != ch.Name() || uiData.Version != ch.Metadata.Version {
+		return nil, nil, bcode.ErrOfflineAddonInvalidPackage.SetMessage(fmt.Sprintf("the addon %s:%s is inconsistent with the chart %s:%s",
+			uiData.Name, uiData.Version, ch.Name(), ch.Metadata.Version))
+	}
+	return ch.Metadata, files, nil
+}
+
+// storeOfflineAddon store the packaged addon in the directory and add it to the index, it returns false if the same package is already stored.
+// The caller must hold the lock of the offline addon registries.
+func storeOfflineAddon(dir string, data []byte) (*repo.ChartVersion, bool, error) {
+	metadata, files, err := loadOfflineAddonPackage(data)
+	if err != nil {
+		return nil, false, err
+	}
+	index, err := loadOfflineAddonIndex(dir)
+	if err != nil {
+		return nil, false, err
+	}
+	for _, existing := range index.Entries[metadata.Name] {
+		if existing.Version != metadata.Version {
+			continue
+		}
+		if existing.Digest == addonPackageDigest(data) {
+			return existing, false, nil
+		}
+		if len(existing.URLs) != 0 {
+			stored, err := readOfflineAddonFile(dir, existing.URLs[0])
+			if err == nil {
+				if storedFiles, err := loader.LoadArchiveFiles(bytes.NewReader(stored)); err == nil && addonContentDigest(storedFiles) == addonContentDigest(files) {
+					return existing, false, nil
+				}
+			}
+		}
+		return nil, false, bcode.ErrOfflineAddonVersionExist
+	}
+	filename := filepath.Base(fmt.Sprintf("%s-%s.tgz", metadata.Name, metadata.Version))
+	target, err := offlineAddonFilePath(dir, filename)
+	if err != nil {
+		return nil, false, err
+	}
+	if err := os.MkdirAll(dir, 0750); err != nil {
+		return nil, false, err
+	}
+	if err := os.WriteFile(target, data, 0600); err != nil {
+		return nil, false, err
+	}
+	if err := index.MustAdd(metadata, filename, "", addonPackageDigest(data)); err != nil {
+		_ = os.Remove(target)
+		return nil, false, bcode.ErrOfflineAddonInvalidPackage.SetMessage(err.Error())
+	}
+	if err := writeOfflineAddonIndex(dir, index); err != nil {
+		return nil, false, err
+	}
+	version, err := index.Get(metadata.Name, metadata.Version)
+	if err != nil {
+		return nil, false, err
+	}
+	return version, true, nil
+}
+
+// offlineAddonFilePath returns the path of the file in the directory of the offline addon registry,
+// the file must not escape the directory.
+func offlineAddonFilePath(dir, file string) (string, error) {
+	target := filepath.Join(dir, file)
+	rel, err := filepath.Rel(dir, target)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) || filepath.IsAbs(rel) {
+		return "", bcode.ErrOfflineAddonInvalidPackage.SetMessage(fmt.Sprintf("the file %q is out of the offline addon registry", file))
+	}
+	return target, nil
+}
+
+func readOfflineAddonFile(dir, file string) ([]byte, error) {
+	target, err := offlineAddonFilePath(dir, file)
+	if err != nil {
+		return nil, err
+	}
+	return os.ReadFile(filepath.Clean(target))
+}
+
+func loadOfflineAddonIndex(dir string) (*repo.IndexFile, error) {
+	index, err := repo.LoadIndexFile(filepath.Join(dir, offlineAddonIndexFile))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return repo.NewIndexFile(), nil
+		}
+		return nil, err
+	}
+	return index, nil
+}
+
+func writeOfflineAddonIndex(dir string, index *repo.IndexFile) error {
+	if err := os.MkdirAll(dir, 0750); err != nil {
+		return err
+	}
+	index.SortEntries()
+	return index.WriteFile(filepath.Join(dir, offlineAddonIndexFile), 0600)
+}
+
+// packageAddonDir package the addon directory as a helm chart like `vela addon package`, the directory is not modified
+func packageAddonDir(source string) ([]byte, error) {
+	tmp, err := os.MkdirTemp("", "offline-addon-")
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = os.RemoveAll(tmp)
+	}()
+	addonDir := filepath.Join(tmp, filepath.Base(source))
+	if err := copyAddonDir(source, addonDir); err != nil {
+		return nil, err
+	}
+	if err := pkgaddon.MakeChartCompatible(addonDir, false); err != nil {
+		return nil, err
+	}
+	ch, err := loader.LoadDir(addonDir)
+	if err != nil {
+		return nil, err
+	}
+	archive, err := chartutil.Save(ch, tmp)
+	if err != nil {
+		return nil, err
+	}
+	return os.ReadFile(filepath.Clean(archive))
+}
+
+func copyAddonDir(source, target string) error {
+	return filepath.WalkDir(source, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(source, path)
+		if err != nil {
+			return err
+		}
+		dest := filepath.Join(target, rel)
+		if d.IsDir() {
+			return os.MkdirAll(dest, 0750)
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		data, err := os.ReadFile(filepath.Clean(path))
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(dest, data, 0600)
+	})
+}
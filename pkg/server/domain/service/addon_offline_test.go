@@ -0,0 +1,142 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"sync"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	pkgaddon "github.com/oam-dev/kubevela/pkg/addon"
+
+	"github.com/kubevela/velaux/pkg/server/config"
+	"github.com/kubevela/velaux/pkg/server/utils/bcode"
+)
+
+var _ = Describe("Test offline addon registry functions", func() {
+	var service *addonServiceImpl
+	var addonDir string
+
+	writeAddon := func(version, description string) []byte {
+		Expect(os.WriteFile(filepath.Join(addonDir, "metadata.yaml"), []byte("name: fluxcd\nversion: "+version+"\ndescription: "+description+"\n"), 0600)).Should(Succeed())
+		Expect(os.WriteFile(filepath.Join(addonDir, "template.cue"), []byte("output: {}\n"), 0600)).Should(Succeed())
+		data, err := packageAddonDir(addonDir)
+		Expect(err).Should(BeNil())
+		return data
+	}
+
+	BeforeEach(func() {
+		service = &addonServiceImpl{
+			mutex:   new(sync.RWMutex),
+			offline: config.OfflineAddonConfig{StorageDir: GinkgoT().TempDir(), ServerURL: "http://127.0.0.1:8000"},
+		}
+		addonDir = filepath.Join(GinkgoT().TempDir(), "fluxcd")
+		Expect(os.MkdirAll(addonDir, 0750)).Should(Succeed())
+	})
+
+	It("Test storing the addon packages", func() {
+		dir := service.offlineAddonRegistryDir("offline")
+		data := writeAddon("1.0.0", "flux")
+		_, err := os.Stat(filepath.Join(addonDir, "Chart.yaml"))
+		Expect(os.IsNotExist(err)).Should(BeTrue())
+
+		version, stored, err := storeOfflineAddon(dir, data)
+		Expect(err).Should(BeNil())
+		Expect(stored).Should(BeTrue())
+		Expect(version.Version).Should(Equal("1.0.0"))
+		Expect(version.Digest).Should(Equal(addonPackageDigest(data)))
+
+		_, stored, err = storeOfflineAddon(dir, writeAddon("1.0.0", "flux"))
+		Expect(err).Should(BeNil())
+		Expect(stored).Should(BeFalse())
+		_, _, err = storeOfflineAddon(dir, writeAddon("1.0.0", "another flux"))
+		Expect(err).Should(Equal(bcode.ErrOfflineAddonVersionExist))
+		_, _, err = storeOfflineAddon(dir, []byte("invalid"))
+		Expect(err).ShouldNot(BeNil())
+		Expect(err.(*bcode.Bcode).BusinessCode).Should(Equal(bcode.ErrOfflineAddonInvalidPackage.BusinessCode))
+
+		_, stored, err = storeOfflineAddon(dir, writeAddon("1.1.0-beta.1", "flux beta"))
+		Expect(err).Should(BeNil())
+		Expect(stored).Should(BeTrue())
+	})
+
+	It("Test rejecting the packages escaping the storage directory", func() {
+		dir := service.offlineAddonRegistryDir("offline")
+		for _, metadata := range [][2]string{{"../../../tmp/pwned", "1.0.0"}, {"fluxcd", "1.0.0/../../pwned"}} {
+			var buf bytes.Buffer
+			gz := gzip.NewWriter(&buf)
+			tw := tar.NewWriter(gz)
+			for name, content := range map[string]string{
+				"fluxcd/Chart.yaml":    "apiVersion: v2\nname: " + metadata[0] + "\nversion: " + metadata[1] + "\n",
+				"fluxcd/metadata.yaml": "name: " + metadata[0] + "\nversion: " + metadata[1] + "\n",
+			} {
+				Expect(tw.WriteHeader(&tar.Header{Name: name, Mode: 0600, Size: int64(len(content))})).Should(Succeed())
+				_, err := tw.Write([]byte(content))
+				Expect(err).Should(BeNil())
+			}
+			Expect(tw.Close()).Should(Succeed())
+			Expect(gz.Close()).Should(Succeed())
+			_, _, err := storeOfflineAddon(dir, buf.Bytes())
+			Expect(err).ShouldNot(BeNil())
+			Expect(err.(*bcode.Bcode).BusinessCode).Should(Equal(bcode.ErrOfflineAddonInvalidPackage.BusinessCode))
+		}
+		_, err := offlineAddonFilePath(dir, "../index.yaml")
+		Expect(err).ShouldNot(BeNil())
+		_, err = readOfflineAddonFile(dir, "../../etc/passwd")
+		Expect(err).ShouldNot(BeNil())
+	})
+
+	It("Test loading the addons from the local storage", func() {
+		dir := service.offlineAddonRegistryDir("offline")
+		_, _, err := storeOfflineAddon(dir, writeAddon("1.0.0", "flux"))
+		Expect(err).Should(BeNil())
+		_, _, err = storeOfflineAddon(dir, writeAddon("1.1.0-beta.1", "flux beta"))
+		Expect(err).Should(BeNil())
+
+		addons, err := service.listOfflineAddonUIData("offline")
+		Expect(err).Should(BeNil())
+		Expect(len(addons)).Should(Equal(1))
+		Expect(addons[0].AvailableVersions).Should(Equal([]string{"1.1.0-beta.1", "1.0.0"}))
+
+		addon, err := service.getOfflineAddonUIData("offline", "fluxcd", "")
+		Expect(err).Should(BeNil())
+		Expect(addon.Version).Should(Equal("1.0.0"))
+		Expect(addon.RegistryName).Should(Equal("offline"))
+		addon, err = service.getOfflineAddonUIData("offline", "fluxcd", "1.1.0-beta.1")
+		Expect(err).Should(BeNil())
+		Expect(addon.Description).Should(Equal("flux beta"))
+		_, err = service.getOfflineAddonUIData("offline", "fluxcd", "2.0.0")
+		Expect(err).ShouldNot(BeNil())
+		_, err = service.getOfflineAddonUIData("offline", "terraform", "")
+		Expect(err).Should(Equal(pkgaddon.ErrNotExist))
+	})
+
+	It("Test recognizing the offline addon registry", func() {
+		Expect(service.offlineAddonRegistryURL("offline")).Should(Equal("http://127.0.0.1:8000/api/v1/offline_addon_registries/offline"))
+		Expect(service.isOfflineAddonRegistry(pkgaddon.Registry{Name: "offline", Helm: &pkgaddon.HelmSource{
+			URL: "http://127.0.0.1:8000/api/v1/offline_addon_registries/offline", Username: OfflineAddonRegistryUser,
+		}})).Should(BeTrue())
+		Expect(service.isOfflineAddonRegistry(pkgaddon.Registry{Name: "offline", Helm: &pkgaddon.HelmSource{URL: "https://addons.kubevela.net"}})).Should(BeFalse())
+		Expect(service.isOfflineAddonRegistry(pkgaddon.Registry{Name: "offline", Git: &pkgaddon.GitAddonSource{URL: "https://github.com/kubevela/catalog"}})).Should(BeFalse())
+	})
+})
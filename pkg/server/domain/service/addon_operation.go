@@ -0,0 +1,366 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"k8s.io/klog/v2"
+
+	"github.com/kubevela/pkg/util/rand"
+
+	"github.com/kubevela/velaux/pkg/server/domain/model"
+	"github.com/kubevela/velaux/pkg/server/infrastructure/datastore"
+	apis "github.com/kubevela/velaux/pkg/server/interfaces/api/dto/v1"
+	"github.com/kubevela/velaux/pkg/server/utils"
+	"github.com/kubevela/velaux/pkg/server/utils/bcode"
+)
+
+const (
+	// addonOperationHeartbeatInterval the interval the owner updates the heartbeat of the addon lock
+	addonOperationHeartbeatInterval = time.Second * 10
+	// addonOperationStaleTimeout the lock without the heartbeat in this duration is stale, the operation is interrupted
+	addonOperationStaleTimeout = time.Minute
+)
+
+// newReplicaID generate the ID of the apiserver replica, it is recorded as the owner of the addon operations
+func newReplicaID() string {
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		hostname = "velaux"
+	}
+	return fmt.Sprintf("%s-%s", hostname, rand.RandomString(6))
+}
+
+func newAddonOperation(ctx context.Context, addonName, operationType string) *model.AddonOperation {
+	operator, _ := utils.UsernameFrom(ctx)
+	return &model.AddonOperation{
+		ID:       fmt.Sprintf("%s-%s", addonName, rand.RandomString(8)),
+		Addon:    addonName,
+		Type:     operationType,
+		Operator: operator,
+		Phase:    model.AddonOperationPending,
+	}
+}
+
+// startAddonOperation record the operation and run it in the background, only one operation of an addon could be running
+func (u *addonServiceImpl) startAddonOperation(ctx context.Context, op *model.AddonOperation, run func(ctx context.Context) error) (*apis.AddonOperationBase, error) {
+	op.Owner = u.replicaID
+	if err := u.acquireAddonLock(ctx, op); err != nil {
+		return nil, err
+	}
+	if err := u.Store.Add(ctx, op); err != nil {
+		u.releaseAddonLock(context.Background(), op)
+		return nil, err
+	}
+	base := convertAddonOperationModel(op)
+	go func() {
+		// the operation outlives the request, so it does not inherit the request context
+		ctx, cancel := context.WithCancel(context.Background())
+		defer u.releaseAddonLock(context.Background(), op)
+		defer cancel()
+		go u.heartbeatAddonLock(ctx, op)
+		u.runAddonOperation(ctx, op, run)
+	}()
+	return base, nil
+}
+
+// acquireAddonLock lock the addon in the datastore for the operation. The stale lock is taken over, and its operation is failed.
+func (u *addonServiceImpl) acquireAddonLock(ctx context.Context, op *model.AddonOperation) error {
+	lock := &model.AddonOperationLock{Addon: op.Addon, OperationID: op.ID, Owner: op.Owner, HeartbeatTime: time.Now()}
+	add := func() error {
+		if err := u.Store.Add(ctx, lock); err != nil {
+			if errors.Is(err, datastore.ErrRecordExist) {
+				return bcode.ErrAddonOperationInProgress
+			}
+			return err
+		}
+		return nil
+	}
+	err := add()
+	if !errors.Is(err, bcode.ErrAddonOperationInProgress) {
+		return err
+	}
+	held := &model.AddonOperationLock{Addon: op.Addon}
+	if err := u.Store.Get(ctx, held); err != nil {
+		if errors.Is(err, datastore.ErrRecordNotExist) {
+			return add()
+		}
+		return err
+	}
+	if time.Since(held.HeartbeatTime) < addonOperationStaleTimeout {
+		return bcode.ErrAddonOperationInProgress
+	}
+	u.failInterruptedAddonOperation(ctx, held.OperationID, held.Owner)
+	// The datastore could not compare and swap, delete the stale lock and only one replica could add it again
+	if err := u.Store.Delete(ctx, held); err != nil && !errors.Is(err, datastore.ErrRecordNotExist) {
+		return err
+	}
+	return add()
+}
+
+// releaseAddonLock delete the lock of the addon if it is held by the operation
+func (u *addonServiceImpl) releaseAddonLock(ctx context.Context, op *model.AddonOperation) {
+	lock := &model.AddonOperationLock{Addon: op.Addon}
+	if err := u.Store.Get(ctx, lock); err != nil {
+		if !errors.Is(err, datastore.ErrRecordNotExist) {
+			klog.Errorf("failed to get the lock of the addon %s: %s", op.Addon, err.Error())
+		}
+		return
+	}
+	if lock.OperationID != op.ID {
+		return
+	}
+	if err := u.Store.Delete(ctx, lock); err != nil && !errors.Is(err, datastore.ErrRecordNotExist) {
+		klog.Errorf("failed to release the lock of the addon %s: %s", op.Addon, err.Error())
+	}
+}
+
+// heartbeatAddonLock update the heartbeat of the lock until the operation is finished, so the other replicas know it is running
+func (u *addonServiceImpl) heartbeatAddonLock(ctx context.Context, op *model.AddonOperation) {
+	ticker := time.NewTicker(addonOperationHeartbeatInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			lock := &model.AddonOperationLock{Addon: op.Addon}
+			if err := u.Store.Get(ctx, lock); err != nil {
+				klog.Errorf("failed to get the lock of the addon %s: %s", op.Addon, err.Error())
+				continue
+			}
+			if lock.OperationID != op.ID {
+				klog.Errorf("the lock of the addon %s is taken over by the operation %s", op.Addon, lock.OperationID)
+				return
+			}
+			lock.HeartbeatTime = time.Now()
+			if err := u.Store.Put(ctx, lock); err != nil {
+				klog.Errorf("failed to update the heartbeat of the addon %s: %s", op.Addon, err.Error())
+			}
+		}
+	}
+}
+
+func (u *addonServiceImpl) runAddonOperation(ctx context.Context, op *model.AddonOperation, run func(ctx context.Context) error) {
+	startTime := time.Now()
+	op.Phase = model.AddonOperationRunning
+	op.StartTime = &startTime
+	u.logAddonOperation(ctx, op, "start to %s the addon %s", op.Type, op.Addon)
+
+	err := run(ctx)
+	endTime := time.Now()
+	op.EndTime = &endTime
+	if err != nil {
+		klog.Errorf("failed to %s the addon %s: %s", op.Type, op.Addon, err.Error())
+		op.Phase = model.AddonOperationFailed
+		op.Error = err.Error()
+		u.logAddonOperation(ctx, op, "failed to %s the addon: %s", op.Type, err.Error())
+		return
+	}
+	op.Phase = model.AddonOperationSucceeded
+	u.logAddonOperation(ctx, op, "the addon is %s successfully", pastTenseOfAddonOperation(op.Type))
+}
+
+func pastTenseOfAddonOperation(operationType string) string {
+	switch operationType {
+	case model.AddonOperationEnable:
+		return "enabled"
+	case model.AddonOperationUpdate:
+		return "updated"
+	case model.AddonOperationPromote:
+		return "promoted"
+	default:
+		return "disabled"
+	}
+}
+
+// logAddonOperation append the log of the step and persist the progress of the operation
+func (u *addonServiceImpl) logAddonOperation(ctx context.Context, op *model.AddonOperation, format string, args ...interface{}) {
+	op.Logs = append(op.Logs, model.AddonOperationLog{Time: time.Now(), Message: fmt.Sprintf(format, args...)})
+	if err := u.Store.Put(ctx, op); err != nil {
+		klog.Errorf("failed to update the addon operation %s: %s", op.ID, err.Error())
+	}
+}
+
+// failInterruptedAddonOperations the operations could not be resumed after the owner stops, mark them as failed.
+// The operations run by the other live replicas are kept, their locks are still refreshed by the heartbeat.
+func (u *addonServiceImpl) failInterruptedAddonOperations(ctx context.Context) error {
+	entities, err := u.Store.List(ctx, &model.AddonOperation{}, &datastore.ListOptions{FilterOptions: datastore.FilterOptions{
+		In: []datastore.InQueryOption{{Key: "phase", Values: []string{model.AddonOperationPending, model.AddonOperationRunning}}},
+	}})
+	if err != nil {
+		return fmt.Errorf("list the addon operations failure %w", err)
+	}
+	for _, entity := range entities {
+		op := entity.(*model.AddonOperation)
+		lock := &model.AddonOperationLock{Addon: op.Addon}
+		err := u.Store.Get(ctx, lock)
+		if err != nil && !errors.Is(err, datastore.ErrRecordNotExist) {
+			return err
+		}
+		if err == nil && lock.OperationID == op.ID {
+			if time.Since(lock.HeartbeatTime) < addonOperationStaleTimeout {
+				continue
+			}
+			if err := u.Store.Delete(ctx, lock); err != nil && !errors.Is(err, datastore.ErrRecordNotExist) {
+				return err
+			}
+		}
+		u.failInterruptedAddonOperation(ctx, op.ID, op.Owner)
+	}
+	return nil
+}
+
+// failInterruptedAddonOperation mark the operation as failed if it is not finished
+func (u *addonServiceImpl) failInterruptedAddonOperation(ctx context.Context, id, owner string) {
+	op := &model.AddonOperation{ID: id}
+	if err := u.Store.Get(ctx, op); err != nil {
+		if !errors.Is(err, datastore.ErrRecordNotExist) {
+			klog.Errorf("failed to get the addon operation %s: %s", id, err.Error())
+		}
+		return
+	}
+	if op.Finished() {
+		return
+	}
+	endTime := time.Now()
+	op.Phase = model.AddonOperationFailed
+	op.Error = fmt.Sprintf("the operation is interrupted, the replica %s running it is stopped", owner)
+	op.EndTime = &endTime
+	if err := u.Store.Put(ctx, op); err != nil {
+		klog.Errorf("failed to update the addon operation %s: %s", id, err.Error())
+	}
+}
+
+// ListAddonOperations list the operations of the addon, the latest first
+func (u *addonServiceImpl) ListAddonOperations(ctx context.Context, name string, page, pageSize int) (*apis.ListAddonOperationResponse, error) {
+	entities, err := u.Store.List(ctx, &model.AddonOperation{Addon: name}, &datastore.ListOptions{
+		Page:     page,
+		PageSize: pageSize,
+		SortBy:   []datastore.SortOption{{Key: "createTime", Order: datastore.SortOrderDescending}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	resp := &apis.ListAddonOperationResponse{Operations: []apis.AddonOperationBase{}}
+	for _, entity := range entities {
+		resp.Operations = append(resp.Operations, *convertAddonOperationModel(entity.(*model.AddonOperation)))
+	}
+	count, err := u.Store.Count(ctx, &model.AddonOperation{Addon: name}, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp.Total = count
+	return resp, nil
+}
+
+// GetAddonOperation get the operation of the addon, includes the logs of the steps
+func (u *addonServiceImpl) GetAddonOperation(ctx context.Context, name string, operationID string) (*apis.AddonOperationBase, error) {
+	op := &model.AddonOperation{ID: operationID}
+	if err := u.Store.Get(ctx, op); err != nil {
+		if errors.Is(err, datastore.ErrRecordNotExist) {
+			return nil, bcode.ErrAddonOperationNotExist
+		}
+		return nil, err
+	}
+	if op.Addon != name {
+		return nil, bcode.ErrAddonOperationNotExist
+	}
+	return convertAddonOperationModel(op), nil
+}
+
+func convertAddonOperationModel(op *model.AddonOperation) *apis.AddonOperationBase {
+	return &apis.AddonOperationBase{
+		ID:           op.ID,
+		Addon:        op.Addon,
+		Type:         op.Type,
+		Operator:     op.Operator,
+		Args:         op.RedactedArgs,
+		Version:      op.Version,
+		RegistryName: op.RegistryName,
+		Force:        op.Force,
+		Phase:        op.Phase,
+		Logs:         append([]model.AddonOperationLog{}, op.Logs...),
+		Warnings:     append([]string{}, op.Warnings...),
+		Error:        op.Error,
+		CreateTime:   op.CreateTime,
+		StartTime:    op.StartTime,
+		EndTime:      op.EndTime,
+	}
+}
+
+// redactAddonArgs copy the args with the values of the sensitive parameters redacted, such as the password fields in the
+// parameter schema of the addon.
+func redactAddonArgs(args map[string]interface{}, schema *openapi3.Schema) map[string]interface{} {
+	if len(args) == 0 {
+		return nil
+	}
+	redacted, _ := redactAddonArg(args, schema).(map[string]interface{})
+	return redacted
+}
+
+func redactAddonArg(value interface{}, schema *openapi3.Schema) interface{} {
+	if isSensitiveSchema(schema) {
+		return configRedactedValue
+	}
+	switch v := value.(type) {
+	case map[string]interface{}:
+		res := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			var property *openapi3.Schema
+			if schema != nil {
+				if ref := schema.Properties[key]; ref != nil {
+					property = ref.Value
+				} else if schema.AdditionalProperties != nil {
+					property = schema.AdditionalProperties.Value
+				}
+			}
+			res[key] = redactAddonArg(item, property)
+		}
+		return res
+	case []interface{}:
+		var items *openapi3.Schema
+		if schema != nil && schema.Items != nil {
+			items = schema.Items.Value
+		}
+		res := make([]interface{}, 0, len(v))
+		for _, item := range v {
+			res = append(res, redactAddonArg(item, items))
+		}
+		return res
+	default:
+		return value
+	}
+}
+
+// redactAllAddonArgs redact the values of all args, it is used when the parameter schema of the addon is unknown
+func redactAllAddonArgs(args map[string]interface{}) map[string]interface{} {
+	if len(args) == 0 {
+		return nil
+	}
+	redacted := make(map[string]interface{}, len(args))
+	for key := range args {
+		redacted[key] = configRedactedValue
+	}
+	return redacted
+}
@@ -0,0 +1,182 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/kubevela/pkg/util/rand"
+
+	"github.com/kubevela/velaux/pkg/server/domain/model"
+	"github.com/kubevela/velaux/pkg/server/infrastructure/datastore"
+	"github.com/kubevela/velaux/pkg/server/utils"
+	"github.com/kubevela/velaux/pkg/server/utils/bcode"
+)
+
+var _ = Describe("Test addon operation functions", func() {
+	var service *addonServiceImpl
+
+	BeforeEach(func() {
+		InitTestEnv("addon-operation-test-" + rand.RandomString(4))
+		service = &addonServiceImpl{Store: ds, replicaID: "replica-a"}
+	})
+
+	waitFinished := func(name, id string) string {
+		var phase string
+		Eventually(func() bool {
+			op, err := service.GetAddonOperation(context.TODO(), name, id)
+			Expect(err).Should(BeNil())
+			phase = op.Phase
+			return phase == model.AddonOperationSucceeded || phase == model.AddonOperationFailed
+		}, 10*time.Second, 100*time.Millisecond).Should(BeTrue())
+		return phase
+	}
+
+	It("Test running the addon operations in the background", func() {
+		ctx := utils.WithUsername(context.TODO(), "admin")
+		release := make(chan struct{})
+		op := newAddonOperation(ctx, "fluxcd", model.AddonOperationEnable)
+		op.Args = map[string]interface{}{"token": "s3cret"}
+		base, err := service.startAddonOperation(ctx, op, func(ctx context.Context) error {
+			<-release
+			return nil
+		})
+		Expect(err).Should(BeNil())
+		Expect(base.Phase).Should(Equal(model.AddonOperationPending))
+		Expect(base.Operator).Should(Equal("admin"))
+
+		_, err = service.startAddonOperation(ctx, newAddonOperation(ctx, "fluxcd", model.AddonOperationDisable), func(ctx context.Context) error {
+			return nil
+		})
+		Expect(err).Should(Equal(bcode.ErrAddonOperationInProgress))
+		close(release)
+		Expect(waitFinished("fluxcd", base.ID)).Should(Equal(model.AddonOperationSucceeded))
+		stored := &model.AddonOperation{ID: base.ID}
+		Expect(ds.Get(context.TODO(), stored)).Should(Succeed())
+		Expect(stored.Args).Should(BeNil())
+
+		failed := newAddonOperation(ctx, "fluxcd", model.AddonOperationDisable)
+		base, err = service.startAddonOperation(ctx, failed, func(ctx context.Context) error {
+			return errors.New("the addon is used by the applications")
+		})
+		Expect(err).Should(BeNil())
+		Expect(waitFinished("fluxcd", base.ID)).Should(Equal(model.AddonOperationFailed))
+		detail, err := service.GetAddonOperation(context.TODO(), "fluxcd", base.ID)
+		Expect(err).Should(BeNil())
+		Expect(detail.Error).Should(Equal("the addon is used by the applications"))
+		Expect(len(detail.Logs)).Should(Equal(2))
+
+		operations, err := service.ListAddonOperations(context.TODO(), "fluxcd", 0, 0)
+		Expect(err).Should(BeNil())
+		Expect(operations.Total).Should(BeEquivalentTo(2))
+		_, err = service.GetAddonOperation(context.TODO(), "velaux", base.ID)
+		Expect(err).Should(Equal(bcode.ErrAddonOperationNotExist))
+	})
+
+	It("Test failing the interrupted addon operations", func() {
+		Expect(ds.Add(context.TODO(), &model.AddonOperation{ID: "velaux-interrupted", Addon: "velaux", Owner: "replica-b", Phase: model.AddonOperationRunning})).Should(Succeed())
+		Expect(ds.Add(context.TODO(), &model.AddonOperation{ID: "fluxcd-running", Addon: "fluxcd", Owner: "replica-b", Phase: model.AddonOperationRunning})).Should(Succeed())
+		Expect(ds.Add(context.TODO(), &model.AddonOperationLock{Addon: "fluxcd", OperationID: "fluxcd-running", Owner: "replica-b", HeartbeatTime: time.Now()})).Should(Succeed())
+		Expect(ds.Add(context.TODO(), &model.AddonOperation{ID: "kruise-stale", Addon: "kruise", Owner: "replica-b", Phase: model.AddonOperationRunning})).Should(Succeed())
+		Expect(ds.Add(context.TODO(), &model.AddonOperationLock{Addon: "kruise", OperationID: "kruise-stale", Owner: "replica-b", HeartbeatTime: time.Now().Add(-addonOperationStaleTimeout)})).Should(Succeed())
+		Expect(service.failInterruptedAddonOperations(context.TODO())).Should(Succeed())
+
+		op, err := service.GetAddonOperation(context.TODO(), "velaux", "velaux-interrupted")
+		Expect(err).Should(BeNil())
+		Expect(op.Phase).Should(Equal(model.AddonOperationFailed))
+		Expect(op.EndTime).ShouldNot(BeNil())
+		Expect(op.Error).Should(ContainSubstring("replica-b"))
+		// the operation run by another live replica is kept
+		op, err = service.GetAddonOperation(context.TODO(), "fluxcd", "fluxcd-running")
+		Expect(err).Should(BeNil())
+		Expect(op.Phase).Should(Equal(model.AddonOperationRunning))
+		op, err = service.GetAddonOperation(context.TODO(), "kruise", "kruise-stale")
+		Expect(err).Should(BeNil())
+		Expect(op.Phase).Should(Equal(model.AddonOperationFailed))
+		Expect(ds.Get(context.TODO(), &model.AddonOperationLock{Addon: "kruise"})).Should(Equal(datastore.ErrRecordNotExist))
+	})
+
+	It("Test taking over the stale lock of the addon", func() {
+		ctx := utils.WithUsername(context.TODO(), "admin")
+		Expect(ds.Add(context.TODO(), &model.AddonOperation{ID: "fluxcd-running", Addon: "fluxcd", Owner: "replica-b", Phase: model.AddonOperationRunning})).Should(Succeed())
+		lock := &model.AddonOperationLock{Addon: "fluxcd", OperationID: "fluxcd-running", Owner: "replica-b", HeartbeatTime: time.Now()}
+		Expect(ds.Add(context.TODO(), lock)).Should(Succeed())
+		_, err := service.startAddonOperation(ctx, newAddonOperation(ctx, "fluxcd", model.AddonOperationDisable), func(ctx context.Context) error {
+			return nil
+		})
+		Expect(err).Should(Equal(bcode.ErrAddonOperationInProgress))
+
+		lock.HeartbeatTime = time.Now().Add(-addonOperationStaleTimeout)
+		Expect(ds.Put(context.TODO(), lock)).Should(Succeed())
+		base, err := service.startAddonOperation(ctx, newAddonOperation(ctx, "fluxcd", model.AddonOperationDisable), func(ctx context.Context) error {
+			return nil
+		})
+		Expect(err).Should(BeNil())
+		Expect(waitFinished("fluxcd", base.ID)).Should(Equal(model.AddonOperationSucceeded))
+		op, err := service.GetAddonOperation(context.TODO(), "fluxcd", "fluxcd-running")
+		Expect(err).Should(BeNil())
+		Expect(op.Phase).Should(Equal(model.AddonOperationFailed))
+		stored := &model.AddonOperation{ID: base.ID}
+		Expect(ds.Get(context.TODO(), stored)).Should(Succeed())
+		Expect(stored.Owner).Should(Equal("replica-a"))
+		// the lock is released after the operation is finished
+		Eventually(func() error {
+			return ds.Get(context.TODO(), &model.AddonOperationLock{Addon: "fluxcd"})
+		}, 10*time.Second, 100*time.Millisecond).Should(Equal(datastore.ErrRecordNotExist))
+	})
+
+	It("Test redacting the args of the addon operations", func() {
+		schema := &openapi3.Schema{Properties: openapi3.Schemas{
+			"token": &openapi3.SchemaRef{Value: &openapi3.Schema{Type: openapi3.TypeString, Format: "password"}},
+			"registry": &openapi3.SchemaRef{Value: &openapi3.Schema{Type: openapi3.TypeObject, Properties: openapi3.Schemas{
+				"password": &openapi3.SchemaRef{Value: &openapi3.Schema{Type: openapi3.TypeString, WriteOnly: true}},
+			}}},
+		}}
+		args := map[string]interface{}{
+			"testkey":  "testvalue",
+			"token":    "s3cret",
+			"registry": map[string]interface{}{"url": "https://registry.example.com", "password": "s3cret"},
+			"clusters": []interface{}{"local"},
+		}
+		Expect(redactAddonArgs(args, schema)).Should(Equal(map[string]interface{}{
+			"testkey":  "testvalue",
+			"token":    "******",
+			"registry": map[string]interface{}{"url": "https://registry.example.com", "password": "******"},
+			"clusters": []interface{}{"local"},
+		}))
+		Expect(args["token"]).Should(Equal("s3cret"))
+		Expect(redactAllAddonArgs(args)).Should(Equal(map[string]interface{}{
+			"testkey": "******", "token": "******", "registry": "******", "clusters": "******",
+		}))
+		Expect(redactAddonArgs(nil, schema)).Should(BeNil())
+
+		op := newAddonOperation(context.TODO(), "fluxcd", model.AddonOperationEnable)
+		op.Args = args
+		op.RedactedArgs = redactAddonArgs(args, schema)
+		Expect(ds.Add(context.TODO(), op)).Should(Succeed())
+		detail, err := service.GetAddonOperation(context.TODO(), "fluxcd", op.ID)
+		Expect(err).Should(BeNil())
+		Expect(detail.Args["testkey"]).Should(Equal("testvalue"))
+		Expect(detail.Args["token"]).Should(Equal("******"))
+	})
+})
@@ -0,0 +1,366 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	errors2 "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+
+	"github.com/oam-dev/kubevela/apis/core.oam.dev/v1beta1"
+	"github.com/oam-dev/kubevela/apis/types"
+	pkgaddon "github.com/oam-dev/kubevela/pkg/addon"
+	"github.com/oam-dev/kubevela/pkg/oam"
+	pkgUtils "github.com/oam-dev/kubevela/pkg/utils"
+	commonutil "github.com/oam-dev/kubevela/pkg/utils/common"
+
+	apis "github.com/kubevela/velaux/pkg/server/interfaces/api/dto/v1"
+	"github.com/kubevela/velaux/pkg/server/utils/bcode"
+)
+
+var addonDefinitionKinds = []string{
+	v1beta1.ComponentDefinitionKind,
+	v1beta1.TraitDefinitionKind,
+	v1beta1.PolicyDefinitionKind,
+	v1beta1.WorkflowStepDefinitionKind,
+}
+
+// PreviewAddonUpgrade render the target version of the enabled addon with the args, and diff it against the installed addon.
+// Nothing is applied.
+func (u *addonServiceImpl) PreviewAddonUpgrade(ctx context.Context, name string, args apis.EnableAddonRequest) (*apis.AddonUpgradePreviewResponse, error) {
+	installedApp, err := pkgaddon.FetchAddonRelatedApp(ctx, u.KubeClient, name)
+	if err != nil {
+		if errors2.IsNotFound(err) {
+			return nil, bcode.ErrAddonNotEnabled
+		}
+		return nil, err
+	}
+	registries, err := u.RegistryDS.ListRegistries(ctx)
+	if err != nil {
+		return nil, err
+	}
+	registry, target, pkg, err := u.loadAddonInstallPackage(ctx, name, args.Version, args.RegistryName, registries)
+	if err != nil {
+		return nil, err
+	}
+	mergedArgs, err := pkgaddon.MergeAddonInstallArgs(ctx, u.KubeClient, name, args.Args)
+	if err != nil {
+		return nil, err
+	}
+	resp := &apis.AddonUpgradePreviewResponse{
+		Name:             name,
+		RegistryName:     registry.Name,
+		InstalledVersion: installedApp.GetLabels()[oam.LabelAddonVersion],
+		TargetVersion:    pkg.Version,
+		Args:             mergedArgs,
+	}
+
+	renderedApp, _, err := pkgaddon.RenderApp(ctx, pkg, u.KubeClient, mergedArgs)
+	if err != nil {
+		return nil, bcode.ErrAddonRender.SetMessage(err.Error())
+	}
+	renderedApp.Name, renderedApp.Namespace = installedApp.Name, installedApp.Namespace
+	appDiff, err := u.diffAddonApplication(ctx, installedApp.DeepCopy(), renderedApp)
+	if err != nil {
+		return nil, err
+	}
+	resp.AppDiff = *appDiff
+
+	renderedDefs, err := pkgaddon.RenderDefinitions(pkg, u.KubeConfig)
+	if err != nil {
+		return nil, bcode.ErrAddonRender.SetMessage(err.Error())
+	}
+	installedDefs, err := u.listAddonDefinitions(ctx, installedApp.Name)
+	if err != nil {
+		return nil, err
+	}
+	resp.Definitions = diffAddonDefinitions(installedDefs, renderedDefs)
+
+	// the parameters and the dependencies of the installed version are read from the registry it is installed from
+	installed, err := u.loadInstalledAddonUIData(name, installedApp.GetLabels()[oam.LabelAddonRegistry], resp.InstalledVersion, registries)
+	if err != nil {
+		klog.Warningf("fail to load the installed version of the addon %s: %s", name, err.Error())
+		resp.Warnings = append(resp.Warnings, fmt.Sprintf("can't load the installed version %s of the addon from the registry, the parameter and dependency changes are not reported", resp.InstalledVersion))
+		resp.Parameters, resp.Dependencies = []apis.AddonChangeItem{}, []apis.AddonChangeItem{}
+		return resp, nil
+	}
+	resp.Parameters = diffAddonParameters(installed.APISchema, target.APISchema)
+	resp.Dependencies = diffAddonDependencies(installed.Dependencies, pkg.Dependencies)
+	return resp, nil
+}
+
+// loadAddonInstallPackage load the install package from the first registry which has the addon, same as enabling the addon
+func (u *addonServiceImpl) loadAddonInstallPackage(ctx context.Context, name, version, registryName string, registries []pkgaddon.Registry) (*pkgaddon.Registry, *pkgaddon.UIData, *pkgaddon.InstallPackage, error) {
+	for i := range registries {
+		r := registries[i]
+		if len(registryName) != 0 && registryName != r.Name {
+			continue
+		}
+		uiData, err := u.getAddonUIData(r, name, version)
+		if err == nil {
+			var pkg *pkgaddon.InstallPackage
+			if !pkgaddon.IsVersionRegistry(r) {
+				var metas map[string]pkgaddon.SourceMeta
+				metas, err = u.addonRegistryCache.ListAddonMeta(r)
+				if err != nil {
+					return nil, nil, nil, err
+				}
+				meta, ok := metas[name]
+				if !ok {
+					continue
+				}
+				pkg, err = r.GetInstallPackage(&meta, uiData)
+			} else {
+				pkg, err = pkgaddon.BuildVersionedRegistry(r.Name, r.Helm.URL, &commonutil.HTTPOption{
+					Username:        r.Helm.Username,
+					Password:        r.Helm.Password,
+					InsecureSkipTLS: r.Helm.InsecureSkipTLS,
+				}).GetAddonInstallPackage(ctx, name, version)
+			}
+			if err == nil {
+				return &r, uiData, pkg, nil
+			}
+		}
+		if errors.Is(err, pkgaddon.ErrNotExist) {
+			continue
+		}
+		if strings.Contains(err.Error(), "specified version") {
+			return nil, nil, nil, bcode.ErrAddonInvalidVersion.SetMessage(err.Error())
+		}
+		return nil, nil, nil, err
+	}
+	return nil, nil, nil, bcode.ErrAddonNotExist
+}
+
+func (u *addonServiceImpl) loadInstalledAddonUIData(name, registryName, version string, registries []pkgaddon.Registry) (*pkgaddon.UIData, error) {
+	if version == "" {
+		return nil, errors.New("the installed version is unknown")
+	}
+	for _, r := range registries {
+		if registryName != "" && r.Name != registryName {
+			continue
+		}
+		uiData, err := u.getAddonUIData(r, name, version)
+		if err == nil {
+			return uiData, nil
+		}
+		if !errors.Is(err, pkgaddon.ErrNotExist) {
+			return nil, err
+		}
+	}
+	return nil, pkgaddon.ErrNotExist
+}
+
+// diffAddonApplication diff the application like comparing the applications, the status and the metadata are ignored
+func (u *addonServiceImpl) diffAddonApplication(ctx context.Context, installed, rendered *v1beta1.Application) (*apis.AppCompareResponse, error) {
+	ignoreSomeParams(installed)
+	ignoreSomeParams(rendered)
+	baseAppBytes, err := yaml.Marshal(installed)
+	if err != nil {
+		return nil, err
+	}
+	targetAppBytes, err := yaml.Marshal(rendered)
+	if err != nil {
+		return nil, err
+	}
+	resp := &apis.AppCompareResponse{
+		IsDiff:        string(baseAppBytes) != string(targetAppBytes),
+		BaseAppYAML:   string(baseAppBytes),
+		TargetAppYAML: string(targetAppBytes),
+	}
+	args := commonutil.Args{
+		Schema: commonutil.Scheme,
+	}
+	_ = args.SetConfig(u.KubeConfig)
+	args.SetClient(u.KubeClient)
+	diffResult, buff, err := compare(ctx, args, rendered, installed)
+	if err != nil {
+		// the yaml of both applications is still returned, so the users could compare them by themselves
+		klog.Errorf("fail to compare the addon application %s", err.Error())
+		return resp, nil
+	}
+	resp.IsDiff = diffResult.DiffType != ""
+	resp.DiffReport = buff.String()
+	return resp, nil
+}
+
+// listAddonDefinitions list the definitions owned by the addon application
+func (u *addonServiceImpl) listAddonDefinitions(ctx context.Context, appName string) ([]*unstructured.Unstructured, error) {
+	var defs []*unstructured.Unstructured
+	for _, kind := range addonDefinitionKinds {
+		list := &unstructured.UnstructuredList{}
+		list.SetGroupVersionKind(v1beta1.SchemeGroupVersion.WithKind(kind + "List"))
+		if err := u.KubeClient.List(ctx, list, client.InNamespace(types.DefaultKubeVelaNS)); err != nil {
+			return nil, err
+		}
+		for i := range list.Items {
+			for _, owner := range list.Items[i].GetOwnerReferences() {
+				if owner.Kind == v1beta1.ApplicationKind && owner.Name == appName {
+					defs = append(defs, &list.Items[i])
+					break
+				}
+			}
+		}
+	}
+	return defs, nil
+}
+
+func diffAddonDefinitions(installed, rendered []*unstructured.Unstructured) []apis.AddonChangeItem {
+	key := func(def *unstructured.Unstructured) string {
+		return def.GetKind() + "/" + def.GetName()
+	}
+	installedDefs := map[string]*unstructured.Unstructured{}
+	for _, def := range installed {
+		installedDefs[key(def)] = def
+	}
+	changes := []apis.AddonChangeItem{}
+	for _, def := range rendered {
+		existing, ok := installedDefs[key(def)]
+		if !ok {
+			changes = append(changes, apis.AddonChangeItem{Name: def.GetName(), Kind: def.GetKind(), Change: apis.AddonChangeAdded})
+			continue
+		}
+		delete(installedDefs, key(def))
+		if !reflect.DeepEqual(normalizeJSON(existing.Object["spec"]), normalizeJSON(def.Object["spec"])) {
+			changes = append(changes, apis.AddonChangeItem{Name: def.GetName(), Kind: def.GetKind(), Change: apis.AddonChangeModified})
+		}
+	}
+	for _, def := range installedDefs {
+		changes = append(changes, apis.AddonChangeItem{Name: def.GetName(), Kind: def.GetKind(), Change: apis.AddonChangeRemoved})
+	}
+	sortAddonChanges(changes)
+	return changes
+}
+
+// normalizeJSON make the values decoded from the different formats comparable, e.g. the numbers
+func normalizeJSON(in interface{}) interface{} {
+	data, err := json.Marshal(in)
+	if err != nil {
+		return in
+	}
+	var out interface{}
+	if err := json.Unmarshal(data, &out); err != nil {
+		return in
+	}
+	return out
+}
+
+type addonParameter struct {
+	Type     string
+	Default  interface{}
+	Required bool
+}
+
+func (p addonParameter) String() string {
+	desc := "type=" + p.Type
+	if p.Default != nil {
+		defaultValue, _ := json.Marshal(p.Default)
+		desc += ", default=" + string(defaultValue)
+	}
+	if p.Required {
+		desc += ", required"
+	}
+	return desc
+}
+
+// flattenAddonParameters flatten the properties of the schema, the name of a nested property is joined by dots
+func flattenAddonParameters(prefix string, schema *openapi3.Schema, parameters map[string]addonParameter) {
+	if schema == nil {
+		return
+	}
+	for name, ref := range schema.Properties {
+		if ref == nil || ref.Value == nil {
+			continue
+		}
+		fullName := name
+		if prefix != "" {
+			fullName = prefix + "." + name
+		}
+		parameters[fullName] = addonParameter{
+			Type:     ref.Value.Type,
+			Default:  normalizeJSON(ref.Value.Default),
+			Required: pkgUtils.StringsContain(schema.Required, name),
+		}
+		flattenAddonParameters(fullName, ref.Value, parameters)
+	}
+}
+
+func diffAddonParameters(installed, target *openapi3.Schema) []apis.AddonChangeItem {
+	before, after := map[string]addonParameter{}, map[string]addonParameter{}
+	flattenAddonParameters("", installed, before)
+	flattenAddonParameters("", target, after)
+	changes := []apis.AddonChangeItem{}
+	for name, p := range after {
+		old, ok := before[name]
+		switch {
+		case !ok:
+			changes = append(changes, apis.AddonChangeItem{Name: name, Kind: p.Type, Change: apis.AddonChangeAdded, After: p.String()})
+		case !reflect.DeepEqual(old, p):
+			changes = append(changes, apis.AddonChangeItem{Name: name, Kind: p.Type, Change: apis.AddonChangeModified, Before: old.String(), After: p.String()})
+		}
+	}
+	for name, p := range before {
+		if _, ok := after[name]; !ok {
+			changes = append(changes, apis.AddonChangeItem{Name: name, Kind: p.Type, Change: apis.AddonChangeRemoved, Before: p.String()})
+		}
+	}
+	sortAddonChanges(changes)
+	return changes
+}
+
+func diffAddonDependencies(installed, target []*pkgaddon.Dependency) []apis.AddonChangeItem {
+	before := map[string]string{}
+	for _, dep := range installed {
+		before[dep.Name] = dep.Version
+	}
+	changes := []apis.AddonChangeItem{}
+	for _, dep := range target {
+		version, ok := before[dep.Name]
+		switch {
+		case !ok:
+			changes = append(changes, apis.AddonChangeItem{Name: dep.Name, Change: apis.AddonChangeAdded, After: dep.Version})
+		case version != dep.Version:
+			changes = append(changes, apis.AddonChangeItem{Name: dep.Name, Change: apis.AddonChangeModified, Before: version, After: dep.Version})
+		}
+		delete(before, dep.Name)
+	}
+	for name, version := range before {
+		changes = append(changes, apis.AddonChangeItem{Name: name, Change: apis.AddonChangeRemoved, Before: version})
+	}
+	sortAddonChanges(changes)
+	return changes
+}
+
+func sortAddonChanges(changes []apis.AddonChangeItem) {
+	sort.Slice(changes, func(i, j int) bool {
+		if changes[i].Name != changes[j].Name {
+			return changes[i].Name < changes[j].Name
+		}
+		return changes[i].Kind < changes[j].Kind
+	})
+}
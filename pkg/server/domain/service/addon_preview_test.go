@@ -0,0 +1,85 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"github.com/getkin/kin-openapi/openapi3"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	pkgaddon "github.com/oam-dev/kubevela/pkg/addon"
+
+	apis "github.com/kubevela/velaux/pkg/server/interfaces/api/dto/v1"
+)
+
+var _ = Describe("Test addon upgrade preview functions", func() {
+	newDef := func(kind, name string, spec map[string]interface{}) *unstructured.Unstructured {
+		def := &unstructured.Unstructured{Object: map[string]interface{}{"spec": spec}}
+		def.SetKind(kind)
+		def.SetName(name)
+		return def
+	}
+
+	It("Test diffing the definitions", func() {
+		installed := []*unstructured.Unstructured{
+			newDef("TraitDefinition", "kustomize-patch", map[string]interface{}{"podDisruptive": false}),
+			newDef("ComponentDefinition", "helm", map[string]interface{}{"workload": map[string]interface{}{"type": "autodetects.core.oam.dev"}}),
+			newDef("ComponentDefinition", "kustomize", map[string]interface{}{"replicas": int64(1)}),
+		}
+		rendered := []*unstructured.Unstructured{
+			newDef("ComponentDefinition", "helm", map[string]interface{}{"workload": map[string]interface{}{"type": "autodetects.core.oam.dev"}}),
+			newDef("ComponentDefinition", "kustomize", map[string]interface{}{"replicas": float64(2)}),
+			newDef("TraitDefinition", "kustomize-json-patch", map[string]interface{}{"podDisruptive": false}),
+		}
+		Expect(diffAddonDefinitions(installed, rendered)).Should(Equal([]apis.AddonChangeItem{
+			{Name: "kustomize", Kind: "ComponentDefinition", Change: apis.AddonChangeModified},
+			{Name: "kustomize-json-patch", Kind: "TraitDefinition", Change: apis.AddonChangeAdded},
+			{Name: "kustomize-patch", Kind: "TraitDefinition", Change: apis.AddonChangeRemoved},
+		}))
+		Expect(diffAddonDefinitions(installed, installed)).Should(BeEmpty())
+	})
+
+	It("Test diffing the parameters", func() {
+		installed := openapi3.NewObjectSchema().
+			WithProperty("clusters", openapi3.NewArraySchema()).
+			WithProperty("onlyHelmComponents", openapi3.NewBoolSchema().WithDefault(false)).
+			WithProperty("image", openapi3.NewObjectSchema().WithProperty("tag", openapi3.NewStringSchema()))
+		target := openapi3.NewObjectSchema().
+			WithProperty("clusters", openapi3.NewArraySchema()).
+			WithProperty("onlyHelmComponents", openapi3.NewBoolSchema().WithDefault(true)).
+			WithProperty("image", openapi3.NewObjectSchema().WithProperty("registry", openapi3.NewStringSchema()))
+		target.Required = []string{"clusters"}
+		Expect(diffAddonParameters(installed, target)).Should(Equal([]apis.AddonChangeItem{
+			{Name: "clusters", Kind: "array", Change: apis.AddonChangeModified, Before: "type=array", After: "type=array, required"},
+			{Name: "image.registry", Kind: "string", Change: apis.AddonChangeAdded, After: "type=string"},
+			{Name: "image.tag", Kind: "string", Change: apis.AddonChangeRemoved, Before: "type=string"},
+			{Name: "onlyHelmComponents", Kind: "boolean", Change: apis.AddonChangeModified, Before: "type=boolean, default=false", After: "type=boolean, default=true"},
+		}))
+		Expect(diffAddonParameters(nil, nil)).Should(BeEmpty())
+	})
+
+	It("Test diffing the dependencies", func() {
+		installed := []*pkgaddon.Dependency{{Name: "fluxcd"}, {Name: "vela-workflow", Version: ">=0.0.1"}}
+		target := []*pkgaddon.Dependency{{Name: "terraform", Version: ">=1.0.0"}, {Name: "vela-workflow", Version: ">=0.0.2"}}
+		Expect(diffAddonDependencies(installed, target)).Should(Equal([]apis.AddonChangeItem{
+			{Name: "fluxcd", Change: apis.AddonChangeRemoved},
+			{Name: "terraform", Change: apis.AddonChangeAdded, After: ">=1.0.0"},
+			{Name: "vela-workflow", Change: apis.AddonChangeModified, Before: ">=0.0.1", After: ">=0.0.2"},
+		}))
+	})
+})
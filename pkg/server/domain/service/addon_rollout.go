@@ -0,0 +1,261 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	errors2 "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	common2 "github.com/oam-dev/kubevela/apis/core.oam.dev/common"
+	"github.com/oam-dev/kubevela/apis/types"
+	pkgaddon "github.com/oam-dev/kubevela/pkg/addon"
+	"github.com/oam-dev/kubevela/pkg/multicluster"
+	pkgUtils "github.com/oam-dev/kubevela/pkg/utils"
+	addonutil "github.com/oam-dev/kubevela/pkg/utils/addon"
+
+	"github.com/kubevela/velaux/pkg/server/domain/model"
+	"github.com/kubevela/velaux/pkg/server/infrastructure/datastore"
+	apis "github.com/kubevela/velaux/pkg/server/interfaces/api/dto/v1"
+	"github.com/kubevela/velaux/pkg/server/utils"
+	"github.com/kubevela/velaux/pkg/server/utils/bcode"
+)
+
+// clustersFromAddonArgs returns the clusters specified in the args of the addon, empty means all clusters
+func clustersFromAddonArgs(args map[string]interface{}) []string {
+	switch clusters := args[types.ClustersArg].(type) {
+	case []string:
+		return clusters
+	case []interface{}:
+		var names []string
+		for _, c := range clusters {
+			if name, ok := c.(string); ok {
+				names = append(names, name)
+			}
+		}
+		return names
+	default:
+		return nil
+	}
+}
+
+// stageAddonRollout validate the staged clusters, and returns the rollout to record and the args to apply the addon on the staged clusters.
+// The addon is a single application, the clusters removed from its topology are cleaned up, so the addon can't keep
+// running the installed version on the clusters out of the stage. The staged rollout is only supported when enabling the addon.
+func (u *addonServiceImpl) stageAddonRollout(ctx context.Context, name string, req apis.EnableAddonRequest, enabled bool) (*model.AddonRollout, map[string]interface{}, error) {
+	if len(req.RolloutClusters) == 0 {
+		return nil, req.Args, nil
+	}
+	if enabled {
+		return nil, nil, bcode.ErrAddonRolloutEnabled
+	}
+	targets := clustersFromAddonArgs(req.Args)
+	candidates := targets
+	if len(candidates) == 0 {
+		clusters, err := multicluster.ListVirtualClusters(ctx, u.KubeClient)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, c := range clusters {
+			candidates = append(candidates, c.Name)
+		}
+	}
+	for _, cluster := range req.RolloutClusters {
+		if !pkgUtils.StringsContain(candidates, cluster) {
+			return nil, nil, bcode.ErrAddonRolloutInvalidClusters
+		}
+	}
+
+	stagedArgs := map[string]interface{}{}
+	for key, value := range req.Args {
+		stagedArgs[key] = value
+	}
+	stagedArgs[types.ClustersArg] = req.RolloutClusters
+	operator, _ := utils.UsernameFrom(ctx)
+	return &model.AddonRollout{
+		Addon:          name,
+		Version:        req.Version,
+		RegistryName:   req.RegistryName,
+		Clusters:       targets,
+		StagedClusters: req.RolloutClusters,
+		Phase:          model.AddonRolloutStaged,
+		Operator:       operator,
+	}, stagedArgs, nil
+}
+
+// recordAddonRollout record the staged rollout after the addon is applied on the staged clusters,
+// the previous rollout is removed if the addon is applied on all target clusters.
+func (u *addonServiceImpl) recordAddonRollout(ctx context.Context, name string, rollout *model.AddonRollout) error {
+	if rollout == nil {
+		if err := u.Store.Delete(ctx, &model.AddonRollout{Addon: name}); err != nil && !errors.Is(err, datastore.ErrRecordNotExist) {
+			return err
+		}
+		return nil
+	}
+	if err := u.Store.Add(ctx, rollout); err != nil {
+		if errors.Is(err, datastore.ErrRecordExist) {
+			return u.Store.Put(ctx, rollout)
+		}
+		return err
+	}
+	return nil
+}
+
+func (u *addonServiceImpl) getAddonRollout(ctx context.Context, name string) (*model.AddonRollout, error) {
+	rollout := &model.AddonRollout{Addon: name}
+	if err := u.Store.Get(ctx, rollout); err != nil {
+		if errors.Is(err, datastore.ErrRecordNotExist) {
+			return nil, bcode.ErrAddonRolloutNotExist
+		}
+		return nil, err
+	}
+	return rollout, nil
+}
+
+// PromoteAddonRollout start an operation to apply the staged addon on all target clusters
+func (u *addonServiceImpl) PromoteAddonRollout(ctx context.Context, name string) (*apis.AddonOperationBase, error) {
+	rollout, err := u.getAddonRollout(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if rollout.Phase != model.AddonRolloutStaged {
+		return nil, bcode.ErrAddonRolloutPromoted
+	}
+	registries, err := u.RegistryDS.ListRegistries(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// the args are not recorded in the rollout, the staged args are kept in the secret of the enabled addon
+	args, err := u.fetchEnabledAddonArgs(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	delete(args, types.ClustersArg)
+	if len(rollout.Clusters) != 0 {
+		args[types.ClustersArg] = rollout.Clusters
+	}
+	op := newAddonOperation(ctx, name, model.AddonOperationPromote)
+	op.Args, op.Version, op.RegistryName = args, rollout.Version, rollout.RegistryName
+	return u.startAddonOperation(ctx, op, func(ctx context.Context) error {
+		if err := u.enableAddon(ctx, op, registries); err != nil {
+			return err
+		}
+		promoteTime := time.Now()
+		rollout.Phase = model.AddonRolloutPromoted
+		rollout.PromoteTime = &promoteTime
+		return u.Store.Put(ctx, rollout)
+	})
+}
+
+// fetchEnabledAddonArgs returns the args the addon is enabled with, which are saved in the secret of the addon
+func (u *addonServiceImpl) fetchEnabledAddonArgs(ctx context.Context, name string) (map[string]interface{}, error) {
+	var sec v1.Secret
+	if err := u.KubeClient.Get(ctx, client.ObjectKey{Namespace: types.DefaultKubeVelaNS, Name: addonutil.Addon2SecName(name)}, &sec); err != nil {
+		if errors2.IsNotFound(err) {
+			return map[string]interface{}{}, nil
+		}
+		return nil, bcode.ErrAddonSecretGet
+	}
+	args, err := pkgaddon.FetchArgsFromSecret(&sec)
+	if err != nil {
+		return nil, err
+	}
+	if args == nil {
+		args = map[string]interface{}{}
+	}
+	return args, nil
+}
+
+// buildAddonClusterStatus summarize the status of the addon on each cluster, the target clusters of the staged rollout
+// which are not staged are pending the promotion.
+func buildAddonClusterStatus(status pkgaddon.Status, rollout *model.AddonRollout, allClusters []string) []apis.AddonClusterStatus {
+	clusterStatus := map[string]*apis.AddonClusterStatus{}
+	for cluster := range status.Clusters {
+		clusterStatus[cluster] = &apis.AddonClusterStatus{Cluster: cluster, Version: status.InstalledVersion, Healthy: true}
+	}
+	var services []common2.ApplicationComponentStatus
+	if status.AppStatus != nil {
+		services = status.AppStatus.Services
+	}
+	for _, service := range services {
+		cluster := service.Cluster
+		if cluster == "" {
+			cluster = multicluster.ClusterLocalName
+		}
+		cs, ok := clusterStatus[cluster]
+		if !ok {
+			cs = &apis.AddonClusterStatus{Cluster: cluster, Version: status.InstalledVersion, Healthy: true}
+			clusterStatus[cluster] = cs
+		}
+		cs.Healthy = cs.Healthy && service.Healthy
+		cs.Components = append(cs.Components, apis.AddonComponentHealth{
+			Name:      service.Name,
+			Namespace: service.Namespace,
+			Healthy:   service.Healthy,
+			Message:   service.Message,
+		})
+	}
+	for _, cs := range clusterStatus {
+		switch {
+		case status.AddonPhase != string(apis.AddonPhaseEnabled):
+			cs.Phase = apis.AddonPhase(status.AddonPhase)
+		case cs.Healthy:
+			cs.Phase = apis.AddonPhaseEnabled
+		default:
+			cs.Phase = apis.AddonPhaseEnabling
+		}
+	}
+	if rollout != nil && rollout.Phase == model.AddonRolloutStaged {
+		targets := rollout.Clusters
+		if len(targets) == 0 {
+			targets = allClusters
+		}
+		for _, cluster := range targets {
+			if _, ok := clusterStatus[cluster]; !ok {
+				clusterStatus[cluster] = &apis.AddonClusterStatus{Cluster: cluster, Phase: apis.AddonPhasePendingPromotion}
+			}
+		}
+	}
+
+	res := []apis.AddonClusterStatus{}
+	for _, cs := range clusterStatus {
+		res = append(res, *cs)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Cluster < res[j].Cluster
+	})
+	return res
+}
+
+func convertAddonRolloutModel(rollout *model.AddonRollout) *apis.AddonRolloutBase {
+	return &apis.AddonRolloutBase{
+		Addon:          rollout.Addon,
+		Version:        rollout.Version,
+		RegistryName:   rollout.RegistryName,
+		Clusters:       rollout.Clusters,
+		StagedClusters: rollout.StagedClusters,
+		Phase:          rollout.Phase,
+		Operator:       rollout.Operator,
+		CreateTime:     rollout.CreateTime,
+		PromoteTime:    rollout.PromoteTime,
+	}
+}
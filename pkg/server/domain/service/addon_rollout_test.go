@@ -0,0 +1,136 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/kubevela/pkg/util/rand"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	common2 "github.com/oam-dev/kubevela/apis/core.oam.dev/common"
+	"github.com/oam-dev/kubevela/apis/types"
+	pkgaddon "github.com/oam-dev/kubevela/pkg/addon"
+	addonutil "github.com/oam-dev/kubevela/pkg/utils/addon"
+
+	"github.com/kubevela/velaux/pkg/server/domain/model"
+	apis "github.com/kubevela/velaux/pkg/server/interfaces/api/dto/v1"
+	"github.com/kubevela/velaux/pkg/server/utils/bcode"
+)
+
+var _ = Describe("Test addon rollout functions", func() {
+	var service *addonServiceImpl
+
+	BeforeEach(func() {
+		InitTestEnv("addon-rollout-test-" + rand.RandomString(4))
+		service = &addonServiceImpl{Store: ds, KubeClient: k8sClient, replicaID: "replica-a"}
+	})
+
+	It("Test staging the rollout", func() {
+		_, _, err := service.stageAddonRollout(context.TODO(), "fluxcd", apis.EnableAddonRequest{
+			Args:            map[string]interface{}{"clusters": []string{"local"}},
+			RolloutClusters: []string{"cluster-a"},
+		}, false)
+		Expect(err).Should(Equal(bcode.ErrAddonRolloutInvalidClusters))
+
+		rollout, args, err := service.stageAddonRollout(context.TODO(), "fluxcd", apis.EnableAddonRequest{
+			Args:            map[string]interface{}{"clusters": []interface{}{"local", "cluster-a"}, "onlyHelmComponents": true},
+			Version:         "1.0.0",
+			RolloutClusters: []string{"cluster-a"},
+		}, false)
+		Expect(err).Should(BeNil())
+		Expect(args["clusters"]).Should(Equal([]string{"cluster-a"}))
+		Expect(args["onlyHelmComponents"]).Should(BeTrue())
+		Expect(rollout.Clusters).Should(Equal([]string{"local", "cluster-a"}))
+		Expect(rollout.Phase).Should(Equal(model.AddonRolloutStaged))
+
+		Expect(service.recordAddonRollout(context.TODO(), "fluxcd", rollout)).Should(Succeed())
+		rollout.Version = "1.0.1"
+		Expect(service.recordAddonRollout(context.TODO(), "fluxcd", rollout)).Should(Succeed())
+		recorded, err := service.getAddonRollout(context.TODO(), "fluxcd")
+		Expect(err).Should(BeNil())
+		Expect(recorded.Version).Should(Equal("1.0.1"))
+
+		Expect(service.recordAddonRollout(context.TODO(), "fluxcd", nil)).Should(Succeed())
+		_, err = service.getAddonRollout(context.TODO(), "fluxcd")
+		Expect(err).Should(Equal(bcode.ErrAddonRolloutNotExist))
+		_, err = service.PromoteAddonRollout(context.TODO(), "fluxcd")
+		Expect(err).Should(Equal(bcode.ErrAddonRolloutNotExist))
+	})
+
+	It("Test rejecting the staged upgrades", func() {
+		_, _, err := service.stageAddonRollout(context.TODO(), "fluxcd", apis.EnableAddonRequest{
+			Args:            map[string]interface{}{"clusters": []string{"local", "cluster-a"}},
+			Version:         "1.0.1",
+			RolloutClusters: []string{"cluster-a"},
+		}, true)
+		Expect(err).Should(Equal(bcode.ErrAddonRolloutEnabled))
+
+		rollout, args, err := service.stageAddonRollout(context.TODO(), "fluxcd", apis.EnableAddonRequest{
+			Args:    map[string]interface{}{"clusters": []string{"local", "cluster-a"}},
+			Version: "1.0.1",
+		}, true)
+		Expect(err).Should(BeNil())
+		Expect(rollout).Should(BeNil())
+		Expect(args["clusters"]).Should(Equal([]string{"local", "cluster-a"}))
+	})
+
+	It("Test fetching the args of the enabled addon", func() {
+		args, err := service.fetchEnabledAddonArgs(context.TODO(), "fluxcd")
+		Expect(err).Should(BeNil())
+		Expect(args).Should(BeEmpty())
+
+		secret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: types.DefaultKubeVelaNS, Name: addonutil.Addon2SecName("fluxcd")},
+			Data:       map[string][]byte{pkgaddon.AddonParameterDataKey: []byte(`{"clusters":["cluster-a"],"token":"s3cret"}`)},
+		}
+		Expect(k8sClient.Create(context.TODO(), secret)).Should(Succeed())
+		args, err = service.fetchEnabledAddonArgs(context.TODO(), "fluxcd")
+		Expect(err).Should(BeNil())
+		Expect(args).Should(Equal(map[string]interface{}{"clusters": []interface{}{"cluster-a"}, "token": "s3cret"}))
+		Expect(k8sClient.Delete(context.TODO(), secret)).Should(Succeed())
+	})
+
+	It("Test building the status of the clusters", func() {
+		status := pkgaddon.Status{
+			AddonPhase:       string(apis.AddonPhaseEnabled),
+			InstalledVersion: "1.0.0",
+			AppStatus: &common2.AppStatus{Services: []common2.ApplicationComponentStatus{
+				{Name: "fluxcd", Healthy: true},
+				{Name: "fluxcd", Cluster: "cluster-a", Healthy: false, Message: "the deployment is not ready"},
+			}},
+			Clusters: map[string]map[string]interface{}{"local": {}, "cluster-a": {}},
+		}
+		rollout := &model.AddonRollout{Addon: "fluxcd", StagedClusters: []string{"cluster-a"}, Phase: model.AddonRolloutStaged}
+		Expect(buildAddonClusterStatus(status, rollout, []string{"local", "cluster-a", "cluster-b"})).Should(Equal([]apis.AddonClusterStatus{
+			{Cluster: "cluster-a", Phase: apis.AddonPhaseEnabling, Version: "1.0.0", Healthy: false, Components: []apis.AddonComponentHealth{
+				{Name: "fluxcd", Healthy: false, Message: "the deployment is not ready"},
+			}},
+			{Cluster: "cluster-b", Phase: apis.AddonPhasePendingPromotion},
+			{Cluster: "local", Phase: apis.AddonPhaseEnabled, Version: "1.0.0", Healthy: true, Components: []apis.AddonComponentHealth{
+				{Name: "fluxcd", Healthy: true},
+			}},
+		}))
+
+		rollout.Phase = model.AddonRolloutPromoted
+		Expect(len(buildAddonClusterStatus(status, rollout, []string{"local", "cluster-a", "cluster-b"}))).Should(Equal(2))
+	})
+})
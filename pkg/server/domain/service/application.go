@@ -37,6 +37,7 @@ import (
 	"sigs.k8s.io/yaml"
 
 	"github.com/oam-dev/kubevela/apis/core.oam.dev/common"
+	"github.com/oam-dev/kubevela/apis/core.oam.dev/v1alpha1"
 	"github.com/oam-dev/kubevela/apis/core.oam.dev/v1beta1"
 	velatypes "github.com/oam-dev/kubevela/apis/types"
 	"github.com/oam-dev/kubevela/pkg/appfile"
@@ -48,6 +49,7 @@ import (
 	"github.com/oam-dev/kubevela/pkg/utils/apply"
 	commonutil "github.com/oam-dev/kubevela/pkg/utils/common"
 
+	pluginTypes "github.com/kubevela/velaux/pkg/plugin/types"
 	"github.com/kubevela/velaux/pkg/server/domain/model"
 	"github.com/kubevela/velaux/pkg/server/domain/repository"
 	"github.com/kubevela/velaux/pkg/server/event/sync/convert"
@@ -76,6 +78,8 @@ type ApplicationService interface {
 	CreateApplication(context.Context, apisv1.CreateApplicationRequest) (*apisv1.ApplicationBase, error)
 	UpdateApplication(context.Context, *model.Application, apisv1.UpdateApplicationRequest) (*apisv1.ApplicationBase, error)
 	DeleteApplication(ctx context.Context, app *model.Application) error
+	TransferApplication(ctx context.Context, app *model.Application, req apisv1.TransferApplicationRequest) (*apisv1.ApplicationBase, error)
+	CloneApplication(ctx context.Context, app *model.Application, req apisv1.CloneApplicationRequest) (*apisv1.ApplicationBase, error)
 	Deploy(ctx context.Context, app *model.Application, req apisv1.ApplicationDeployRequest) (*apisv1.ApplicationDeployResponse, error)
 	GetApplicationComponent(ctx context.Context, app *model.Application, componentName string) (*model.ApplicationComponent, error)
 	ListComponents(ctx context.Context, app *model.Application, op apisv1.ListApplicationComponentOptions) ([]*apisv1.ComponentBase, error)
@@ -117,6 +121,8 @@ type applicationServiceImpl struct {
 	DefinitionService DefinitionService   `inject:""`
 	ProjectService    ProjectService      `inject:""`
 	UserService       UserService         `inject:""`
+	PluginHookService PluginHookService   `inject:""`
+	RbacService       RBACService         `inject:""`
 }
 
 // NewApplicationService new application service
@@ -418,8 +424,23 @@ func (c *applicationServiceImpl) CreateApplication(ctx context.Context, req apis
 	if err != nil {
 		return nil, bcode.ErrProjectIsNotExist
 	}
+	if err := ensureProjectWritable(ctx, c.Store, project.Name); err != nil {
+		return nil, err
+	}
+	if err := checkProjectQuota(ctx, c.Store, project.Name, quotaApplications, &model.Application{Project: project.Name}); err != nil {
+		return nil, err
+	}
 	application.Project = project.Name
 
+	if c.PluginHookService != nil {
+		payload := NewPluginHookPayload(ctx, pluginTypes.HookEventApplicationCreated)
+		payload.Project = project
+		payload.Application = assembler.ConvertAppModelToBase(&application, []*apisv1.ProjectBase{project})
+		if err := c.PluginHookService.Validate(ctx, pluginTypes.HookEventApplicationCreated, payload); err != nil {
+			return nil, err
+		}
+	}
+
 	if req.Component != nil {
 		_, err = c.createComponent(ctx, &application, *req.Component, true)
 		if err != nil {
@@ -452,6 +473,12 @@ func (c *applicationServiceImpl) CreateApplication(ctx context.Context, req apis
 	}
 	// render app base info.
 	base := assembler.ConvertAppModelToBase(&application, []*apisv1.ProjectBase{project})
+	if c.PluginHookService != nil {
+		payload := NewPluginHookPayload(ctx, pluginTypes.HookEventApplicationCreated)
+		payload.Project = project
+		payload.Application = base
+		c.PluginHookService.Notify(ctx, pluginTypes.HookEventApplicationCreated, payload)
+	}
 	return base, nil
 }
 
@@ -591,6 +618,9 @@ func (c *applicationServiceImpl) UpdateApplication(ctx context.Context, app *mod
 		if err != nil {
 			return nil, bcode.ErrProjectIsNotExist
 		}
+		if err := ensureProjectWritable(ctx, c.Store, app.Project); err != nil {
+			return nil, err
+		}
 	}
 	app.Alias = req.Alias
 	app.Description = req.Description
@@ -760,6 +790,14 @@ func (c *applicationServiceImpl) Deploy(ctx context.Context, app *model.Applicat
 		return nil, err
 	}
 
+	if c.PluginHookService != nil {
+		payload := c.newAppHookPayload(ctx, pluginTypes.HookEventApplicationDeployed, app)
+		payload.DeployRequest = &req
+		if err := c.PluginHookService.Validate(ctx, pluginTypes.HookEventApplicationDeployed, payload); err != nil {
+			return nil, err
+		}
+	}
+
 	// step2: check and create application revision
 	if !req.Force {
 		var lastVersion = model.ApplicationRevision{
@@ -861,9 +899,30 @@ func (c *applicationServiceImpl) Deploy(ctx context.Context, app *model.Applicat
 		res.WorkflowRecord = assembler.ConvertFromRecordModel(record).WorkflowRecordBase
 	}
 
+	if c.PluginHookService != nil {
+		payload := c.newAppHookPayload(ctx, pluginTypes.HookEventApplicationDeployed, app)
+		payload.DeployRequest = &req
+		if record != nil {
+			payload.WorkflowRecord = &res.WorkflowRecord
+		}
+		c.PluginHookService.Notify(ctx, pluginTypes.HookEventApplicationDeployed, payload)
+	}
+
 	return res, nil
 }
 
+// newAppHookPayload create the plugin hook payload with the application and project info
+func (c *applicationServiceImpl) newAppHookPayload(ctx context.Context, event pluginTypes.HookEvent, app *model.Application) *apisv1.PluginHookPayload {
+	payload := NewPluginHookPayload(ctx, event)
+	var projects []*apisv1.ProjectBase
+	if project, err := c.ProjectService.DetailProject(ctx, app.Project); err == nil {
+		payload.Project = project
+		projects = append(projects, project)
+	}
+	payload.Application = assembler.ConvertAppModelToBase(app, projects)
+	return payload
+}
+
 func (c *applicationServiceImpl) renderOAMApplication(ctx context.Context, appModel *model.Application, reqWorkflowName, envName, version string) (*v1beta1.Application, error) {
 	// Priority 1 uses the requested workflow as release .
 	// Priority 2 uses the default workflow as release .
@@ -951,6 +1010,9 @@ func (c *applicationServiceImpl) renderOAMApplication(ctx context.Context, appMo
 	if err != nil {
 		return nil, err
 	}
+	if err := c.renderDynamicTargets(ctx, env, envPolicies); err != nil {
+		return nil, err
+	}
 	policies = append(policies, envPolicies...)
 
 	for _, entity := range components {
@@ -1013,6 +1075,53 @@ func (c *applicationServiceImpl) renderOAMApplication(ctx context.Context, appMo
 	return app, nil
 }
 
+// renderDynamicTargets resolve the clusters of the dynamic targets in the env, includes the clusters joined later,
+// prepare the namespaces in the resolved clusters, and override the placement of the topology policies of the targets.
+func (c *applicationServiceImpl) renderDynamicTargets(ctx context.Context, env *model.Env, envPolicies []*model.ApplicationPolicy) error {
+	for _, policy := range envPolicies {
+		if policy.Type != v1alpha1.TopologyPolicyType || !pkgUtils.StringsContain(env.Targets, policy.Name) {
+			continue
+		}
+		target, err := c.TargetService.GetTarget(ctx, policy.Name)
+		if err != nil {
+			if errors.Is(err, datastore.ErrRecordNotExist) {
+				continue
+			}
+			return err
+		}
+		if !target.Cluster.IsDynamic() {
+			continue
+		}
+		provisionCtx := utils.WithProject(ctx, "")
+		clusters, err := repository.ResolveTargetClusters(provisionCtx, c.Store, c.KubeClient, target)
+		if err != nil {
+			return err
+		}
+		if len(clusters) == 0 {
+			return bcode.ErrTargetNoMatchedCluster.SetMessage(fmt.Sprintf("no cluster matches the target %s", target.Name))
+		}
+		project := &model.Project{Name: target.Project}
+		if err := c.Store.Get(ctx, project); err != nil {
+			return err
+		}
+		if err := provisionTarget(provisionCtx, c.KubeClient, target, clusters, project.Quota); err != nil {
+			return err
+		}
+		if _, err := releaseStaleClusters(provisionCtx, c.Store, c.KubeClient, target, clusters); err != nil {
+			return err
+		}
+		if err := recordProvisionedClusters(ctx, c.Store, target, clusters); err != nil {
+			return err
+		}
+		properties, err := model.NewJSONStructByStruct(repository.TopologyPolicySpecForTarget(target, clusters))
+		if err != nil {
+			return err
+		}
+		policy.Properties = properties
+	}
+	return nil
+}
+
 func convertWorkflowModel2WorkflowSpec(step model.WorkflowStepBase) workflowv1alpha1.WorkflowStepBase {
 	var workflowStep = workflowv1alpha1.WorkflowStepBase{
 		Name:      step.Name,
@@ -1109,7 +1218,13 @@ func (c *applicationServiceImpl) DeleteApplication(ctx context.Context, app *mod
 		klog.Errorf("delete envbindings in app %s failure %s", app.Name, err.Error())
 	}
 
-	return c.Store.Delete(ctx, app)
+	if err := c.Store.Delete(ctx, app); err != nil {
+		return err
+	}
+	if c.PluginHookService != nil {
+		c.PluginHookService.Notify(ctx, pluginTypes.HookEventApplicationDeleted, c.newAppHookPayload(ctx, pluginTypes.HookEventApplicationDeleted, app))
+	}
+	return nil
 }
 
 func (c *applicationServiceImpl) GetApplicationComponent(ctx context.Context, app *model.Application, componentName string) (*model.ApplicationComponent, error) {
@@ -1157,6 +1272,9 @@ func (c *applicationServiceImpl) UpdateComponent(ctx context.Context, app *model
 }
 
 func (c *applicationServiceImpl) createComponent(ctx context.Context, app *model.Application, com apisv1.CreateComponentRequest, main bool) (*apisv1.ComponentBase, error) {
+	if err := checkProjectQuota(ctx, c.Store, app.Project, quotaComponents, &model.ApplicationComponent{AppPrimaryKey: app.PrimaryKey()}); err != nil {
+		return nil, err
+	}
 	var cd v1beta1.ComponentDefinition
 	loadCtx := utils.WithProject(ctx, "")
 	if err := c.KubeClient.Get(loadCtx, types.NamespacedName{Name: com.ComponentType, Namespace: velatypes.DefaultKubeVelaNS}, &cd); err != nil {
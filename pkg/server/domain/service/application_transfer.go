@@ -0,0 +1,600 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	"github.com/oam-dev/kubevela/apis/core.oam.dev/v1alpha1"
+	"github.com/oam-dev/kubevela/apis/core.oam.dev/v1beta1"
+	velatypes "github.com/oam-dev/kubevela/apis/types"
+	"github.com/oam-dev/kubevela/pkg/oam"
+	"github.com/oam-dev/kubevela/pkg/oam/util"
+	pkgUtils "github.com/oam-dev/kubevela/pkg/utils"
+
+	"github.com/kubevela/velaux/pkg/server/domain/model"
+	"github.com/kubevela/velaux/pkg/server/domain/repository"
+	"github.com/kubevela/velaux/pkg/server/infrastructure/datastore"
+	assembler "github.com/kubevela/velaux/pkg/server/interfaces/api/assembler/v1"
+	apisv1 "github.com/kubevela/velaux/pkg/server/interfaces/api/dto/v1"
+	"github.com/kubevela/velaux/pkg/server/utils/bcode"
+)
+
+// transferTakeOverPolicyName the policy to take over the resources of the application CR re-homed by the transfer
+const transferTakeOverPolicyName = "transfer-take-over"
+
+// envMapping maps an env bound by the application to an env of the destination project
+type envMapping struct {
+	binding *model.EnvBinding
+	source  *model.Env
+	dest    *model.Env
+}
+
+// TransferApplication move the application with all of its components, policies, workflows, env bindings, triggers
+// and revisions to another project. The application CRs are re-homed into the namespaces of the mapped envs, the
+// resources are orphaned by the original CRs and taken over by the new CRs, so they are not recreated.
+func (c *applicationServiceImpl) TransferApplication(ctx context.Context, app *model.Application, req apisv1.TransferApplicationRequest) (*apisv1.ApplicationBase, error) {
+	if req.Project == app.Project {
+		return nil, bcode.ErrApplicationTransferConflict.SetMessage("the application is already in the project " + req.Project)
+	}
+	project, err := c.prepareDestinationProject(ctx, req.Project)
+	if err != nil {
+		return nil, err
+	}
+	mappings, err := c.mapApplicationEnvs(ctx, app, req.Project, req.EnvMapping, true)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.checkTransferDestinations(ctx, app, mappings); err != nil {
+		return nil, err
+	}
+	var moved []envMapping
+	for _, m := range mappings {
+		moved = append(moved, m)
+		if err := c.transferEnvBinding(ctx, app, m); err != nil {
+			klog.Errorf("failed to transfer the application %s from the env %s to %s: %s", app.Name, m.source.Name, m.dest.Name, err.Error())
+			c.revertEnvBindings(ctx, app, moved)
+			return nil, err
+		}
+	}
+	sourceProject := app.Project
+	app.Project = project.Name
+	if err := c.Store.Put(ctx, app); err != nil {
+		app.Project = sourceProject
+		c.revertEnvBindings(ctx, app, moved)
+		return nil, err
+	}
+	return assembler.ConvertAppModelToBase(app, []*apisv1.ProjectBase{project}), nil
+}
+
+// checkTransferDestinations make sure no application CR is in the way in the namespaces of the destination envs,
+// so the transfer does not fail halfway.
+func (c *applicationServiceImpl) checkTransferDestinations(ctx context.Context, app *model.Application, mappings []envMapping) error {
+	for _, m := range mappings {
+		name := m.binding.AppDeployName
+		if name == "" {
+			name = app.Name
+		}
+		var exist v1beta1.Application
+		err := c.KubeClient.Get(ctx, types.NamespacedName{Namespace: m.dest.Namespace, Name: name}, &exist)
+		if err == nil {
+			return bcode.ErrApplicationTransferConflict.SetMessage(fmt.Sprintf("the application %s already exists in the namespace %s", name, m.dest.Namespace))
+		}
+		if !apierrors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// revertEnvBindings move the transferred env bindings back to the source envs, the latest first.
+// The env binding failed halfway is reverted too, the steps not done are skipped.
+func (c *applicationServiceImpl) revertEnvBindings(ctx context.Context, app *model.Application, moved []envMapping) {
+	for i := len(moved) - 1; i >= 0; i-- {
+		m := moved[i]
+		binding := *m.binding
+		binding.Name = m.dest.Name
+		if err := c.transferEnvBinding(ctx, app, envMapping{binding: &binding, source: m.dest, dest: m.source}); err != nil {
+			klog.Errorf("failed to revert the transfer of the application %s from the env %s to %s: %s", app.Name, m.source.Name, m.dest.Name, err.Error())
+		}
+	}
+}
+
+// CloneApplication copy the application with a new name, the revisions and the workflow records are not copied,
+// and the new application is not deployed.
+func (c *applicationServiceImpl) CloneApplication(ctx context.Context, app *model.Application, req apisv1.CloneApplicationRequest) (*apisv1.ApplicationBase, error) {
+	projectName := req.Project
+	if projectName == "" {
+		projectName = app.Project
+	}
+	project, err := c.prepareDestinationProject(ctx, projectName)
+	if err != nil {
+		return nil, err
+	}
+	exist, err := c.Store.IsExist(ctx, &model.Application{Name: req.Name})
+	if err != nil {
+		return nil, err
+	}
+	if exist {
+		return nil, bcode.ErrApplicationExist
+	}
+	envs := map[string]*model.Env{}
+	if projectName == app.Project && len(req.EnvMapping) == 0 {
+		bindings, err := c.Store.List(ctx, &model.EnvBinding{AppPrimaryKey: app.PrimaryKey()}, nil)
+		if err != nil {
+			return nil, err
+		}
+		for _, entity := range bindings {
+			name := entity.(*model.EnvBinding).Name
+			envs[name] = &model.Env{Name: name}
+		}
+	} else {
+		mappings, err := c.mapApplicationEnvs(ctx, app, projectName, req.EnvMapping, false)
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range mappings {
+			envs[m.source.Name] = m.dest
+		}
+	}
+
+	clone := &model.Application{
+		Name:        req.Name,
+		Alias:       req.Alias,
+		Description: req.Description,
+		Icon:        app.Icon,
+		Project:     projectName,
+		Labels:      map[string]string{},
+	}
+	if clone.Alias == "" {
+		clone.Alias = app.Alias
+	}
+	if clone.Description == "" {
+		clone.Description = app.Description
+	}
+	for k, v := range app.Labels {
+		// the clone is managed by the UX, not synced from the CR
+		if k == model.LabelSyncNamespace || k == model.LabelSyncGeneration || k == model.LabelSyncRevision || k == velatypes.LabelSourceOfTruth {
+			continue
+		}
+		clone.Labels[k] = v
+	}
+	if err := c.Store.Add(ctx, clone); err != nil {
+		if errors.Is(err, datastore.ErrRecordExist) {
+			return nil, bcode.ErrApplicationExist
+		}
+		return nil, err
+	}
+	if err := c.cloneApplicationEntities(ctx, app, clone, envs); err != nil {
+		klog.Errorf("failed to clone the application %s to %s: %s", app.Name, clone.Name, err.Error())
+		if err := c.DeleteApplication(ctx, clone); err != nil {
+			klog.Errorf("failed to clean up the cloned application %s: %s", clone.Name, err.Error())
+		}
+		return nil, err
+	}
+	return assembler.ConvertAppModelToBase(clone, []*apisv1.ProjectBase{project}), nil
+}
+
+// prepareDestinationProject check the user could create the applications in the destination project and the quota
+func (c *applicationServiceImpl) prepareDestinationProject(ctx context.Context, projectName string) (*apisv1.ProjectBase, error) {
+	project, err := c.ProjectService.DetailProject(ctx, projectName)
+	if err != nil {
+		return nil, bcode.ErrProjectIsNotExist
+	}
+	if err := ensureProjectWritable(ctx, c.Store, projectName); err != nil {
+		return nil, err
+	}
+	if c.RbacService != nil {
+		userName, _ := ctx.Value(&apisv1.CtxKeyUser).(string)
+		user := &model.User{Name: userName}
+		if err := c.Store.Get(ctx, user); err != nil {
+			return nil, bcode.ErrUnauthorized
+		}
+		permissions, err := c.RbacService.GetUserPermissions(ctx, user, projectName, true)
+		if err != nil {
+			return nil, err
+		}
+		ra := &RequestResourceAction{}
+		ra.SetResourceWithName("project:{projectName}/application:*", func(name string) string {
+			return projectName
+		})
+		ra.SetActions([]string{"create"})
+		if !ra.Match(permissions) {
+			return nil, bcode.ErrForbidden
+		}
+	}
+	if err := checkProjectQuota(ctx, c.Store, projectName, quotaApplications, &model.Application{Project: projectName}); err != nil {
+		return nil, err
+	}
+	return project, nil
+}
+
+// mapApplicationEnvs resolve the destination envs of the env bindings, the destination env must belong to the
+// project and contain all targets of the source env, so the resources are delivered to the same places.
+func (c *applicationServiceImpl) mapApplicationEnvs(ctx context.Context, app *model.Application, projectName string, mapping map[string]string, requireAll bool) ([]envMapping, error) {
+	entities, err := c.Store.List(ctx, &model.EnvBinding{AppPrimaryKey: app.PrimaryKey()}, nil)
+	if err != nil {
+		return nil, err
+	}
+	bound := map[string]bool{}
+	used := map[string]string{}
+	var mappings []envMapping
+	for _, entity := range entities {
+		binding := entity.(*model.EnvBinding)
+		bound[binding.Name] = true
+		destName, ok := mapping[binding.Name]
+		if !ok {
+			if requireAll {
+				return nil, bcode.ErrApplicationEnvMismatch.SetMessage(fmt.Sprintf("the env %s is not mapped to the destination project", binding.Name))
+			}
+			continue
+		}
+		if source, ok := used[destName]; ok {
+			return nil, bcode.ErrApplicationEnvMismatch.SetMessage(fmt.Sprintf("the envs %s and %s are mapped to the same env %s", source, binding.Name, destName))
+		}
+		used[destName] = binding.Name
+		source, err := repository.GetEnv(ctx, c.Store, binding.Name)
+		if err != nil {
+			return nil, err
+		}
+		dest, err := repository.GetEnv(ctx, c.Store, destName)
+		if err != nil {
+			return nil, bcode.ErrApplicationEnvMismatch.SetMessage(fmt.Sprintf("the env %s is not exist", destName))
+		}
+		if dest.Project != projectName {
+			return nil, bcode.ErrApplicationEnvMismatch.SetMessage(fmt.Sprintf("the env %s does not belong to the project %s", destName, projectName))
+		}
+		for _, target := range source.Targets {
+			if !pkgUtils.StringsContain(dest.Targets, target) {
+				return nil, bcode.ErrApplicationEnvMismatch.SetMessage(fmt.Sprintf("the env %s does not contain the target %s of the env %s", destName, target, source.Name))
+			}
+		}
+		mappings = append(mappings, envMapping{binding: binding, source: source, dest: dest})
+	}
+	for name := range mapping {
+		if !bound[name] {
+			return nil, bcode.ErrApplicationEnvMismatch.SetMessage(fmt.Sprintf("the application is not bound to the env %s", name))
+		}
+	}
+	sort.Slice(mappings, func(i, j int) bool { return mappings[i].source.Name < mappings[j].source.Name })
+	return mappings, nil
+}
+
+// transferEnvBinding move the env binding and the workflows, the policies and the revisions of the env to the destination env
+func (c *applicationServiceImpl) transferEnvBinding(ctx context.Context, app *model.Application, m envMapping) error {
+	if err := c.rehomeApplicationCR(ctx, app, m); err != nil {
+		return err
+	}
+	binding := *m.binding
+	if err := c.Store.Delete(ctx, m.binding); err != nil && !errors.Is(err, datastore.ErrRecordNotExist) {
+		return err
+	}
+	binding.Name = m.dest.Name
+	if err := c.Store.Add(ctx, &binding); err != nil {
+		if !errors.Is(err, datastore.ErrRecordExist) {
+			return err
+		}
+		if err := c.Store.Put(ctx, &binding); err != nil {
+			return err
+		}
+	}
+
+	workflowRenames := map[string]string{}
+	workflows, err := c.Store.List(ctx, &model.Workflow{AppPrimaryKey: app.PrimaryKey(), EnvName: m.source.Name}, nil)
+	if err != nil {
+		return err
+	}
+	for _, entity := range workflows {
+		workflow := entity.(*model.Workflow)
+		workflow.EnvName = m.dest.Name
+		if workflow.Name != repository.ConvertWorkflowName(m.source.Name) {
+			if err := c.Store.Put(ctx, workflow); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := c.Store.Delete(ctx, &model.Workflow{AppPrimaryKey: app.PrimaryKey(), Name: workflow.Name}); err != nil {
+			return err
+		}
+		workflowRenames[workflow.Name] = transferredWorkflowName(workflow.Name, m)
+		workflow.Name = transferredWorkflowName(workflow.Name, m)
+		if err := c.Store.Add(ctx, workflow); err != nil {
+			return err
+		}
+	}
+
+	policies, err := c.Store.List(ctx, &model.ApplicationPolicy{AppPrimaryKey: app.PrimaryKey(), EnvName: m.source.Name}, nil)
+	if err != nil {
+		return err
+	}
+	for _, entity := range policies {
+		policy := entity.(*model.ApplicationPolicy)
+		policy.EnvName = m.dest.Name
+		if err := c.Store.Put(ctx, policy); err != nil {
+			return err
+		}
+	}
+
+	revisions, err := c.Store.List(ctx, &model.ApplicationRevision{AppPrimaryKey: app.PrimaryKey(), EnvName: m.source.Name}, nil)
+	if err != nil {
+		return err
+	}
+	for _, entity := range revisions {
+		revision := entity.(*model.ApplicationRevision)
+		revision.EnvName = m.dest.Name
+		if name, ok := workflowRenames[revision.WorkflowName]; ok {
+			revision.WorkflowName = name
+		}
+		if err := c.Store.Put(ctx, revision); err != nil {
+			return err
+		}
+	}
+
+	for oldName, newName := range workflowRenames {
+		triggers, err := c.Store.List(ctx, &model.ApplicationTrigger{AppPrimaryKey: app.PrimaryKey()}, nil)
+		if err != nil {
+			return err
+		}
+		for _, entity := range triggers {
+			trigger := entity.(*model.ApplicationTrigger)
+			if trigger.WorkflowName != oldName {
+				continue
+			}
+			trigger.WorkflowName = newName
+			if err := c.Store.Put(ctx, trigger); err != nil {
+				return err
+			}
+		}
+		records, err := c.Store.List(ctx, &model.WorkflowRecord{AppPrimaryKey: app.PrimaryKey(), WorkflowName: oldName}, nil)
+		if err != nil {
+			return err
+		}
+		for _, entity := range records {
+			record := entity.(*model.WorkflowRecord)
+			record.WorkflowName = newName
+			if err := c.Store.Put(ctx, record); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// transferredWorkflowName the default workflow of the env is named after the env, it is renamed after the destination env
+func transferredWorkflowName(name string, m envMapping) string {
+	if name == repository.ConvertWorkflowName(m.source.Name) {
+		return repository.ConvertWorkflowName(m.dest.Name)
+	}
+	return name
+}
+
+// rehomeApplicationCR move the application CR into the namespace of the destination env. The new CR is created
+// first, the original CR orphans the resources while it is deleted, then the new CR takes them over, so the running
+// workloads are not interrupted. The new CR is removed without touching the resources if the original CR is kept.
+func (c *applicationServiceImpl) rehomeApplicationCR(ctx context.Context, app *model.Application, m envMapping) error {
+	name := m.binding.AppDeployName
+	if name == "" {
+		name = app.Name
+	}
+	var origin v1beta1.Application
+	if err := c.KubeClient.Get(ctx, types.NamespacedName{Namespace: m.source.Namespace, Name: name}, &origin); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	var exist v1beta1.Application
+	err := c.KubeClient.Get(ctx, types.NamespacedName{Namespace: m.dest.Namespace, Name: name}, &exist)
+	if err == nil {
+		return bcode.ErrApplicationTransferConflict.SetMessage(fmt.Sprintf("the application %s already exists in the namespace %s", name, m.dest.Namespace))
+	}
+	if !apierrors.IsNotFound(err) {
+		return err
+	}
+
+	labels := make(map[string]string, len(origin.Labels))
+	for key, value := range origin.Labels {
+		labels[key] = value
+	}
+	if _, ok := labels[model.LabelSyncNamespace]; ok {
+		labels[model.LabelSyncNamespace] = m.dest.Namespace
+	}
+	annotations := make(map[string]string, len(origin.Annotations))
+	for key, value := range origin.Annotations {
+		annotations[key] = value
+	}
+	// The default workflow of the env is renamed after the destination env. The deploy version and the publish version
+	// still refer to the revision and the workflow record, they are moved to the destination env with the same keys.
+	if workflowName, ok := annotations[oam.AnnotationWorkflowName]; ok {
+		annotations[oam.AnnotationWorkflowName] = transferredWorkflowName(workflowName, m)
+	}
+	rehomed := &v1beta1.Application{
+		TypeMeta: origin.TypeMeta,
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Namespace:   m.dest.Namespace,
+			Labels:      labels,
+			Annotations: annotations,
+		},
+		Spec: *origin.Spec.DeepCopy(),
+	}
+	var components []string
+	for _, component := range origin.Spec.Components {
+		components = append(components, component.Name)
+	}
+	var policies []v1beta1.AppPolicy
+	for _, policy := range rehomed.Spec.Policies {
+		if policy.Type != v1alpha1.TakeOverPolicyType || policy.Name != transferTakeOverPolicyName {
+			policies = append(policies, policy)
+		}
+	}
+	rehomed.Spec.Policies = append(policies, v1beta1.AppPolicy{
+		Name: transferTakeOverPolicyName,
+		Type: v1alpha1.TakeOverPolicyType,
+		Properties: util.Object2RawExtension(v1alpha1.TakeOverPolicySpec{
+			Rules: []v1alpha1.TakeOverPolicyRule{{Selector: v1alpha1.ResourcePolicyRuleSelector{CompNames: components}}},
+		}),
+	})
+
+	if err := c.KubeClient.Create(ctx, rehomed); err != nil {
+		if apierrors.IsAlreadyExists(err) {
+			return bcode.ErrApplicationTransferConflict.SetMessage(fmt.Sprintf("the application %s already exists in the namespace %s", name, m.dest.Namespace))
+		}
+		return err
+	}
+	if !controllerutil.ContainsFinalizer(&origin, oam.FinalizerOrphanResource) {
+		controllerutil.AddFinalizer(&origin, oam.FinalizerOrphanResource)
+		if err := c.KubeClient.Update(ctx, &origin); err != nil {
+			c.removeRehomedApplicationCR(ctx, rehomed)
+			return err
+		}
+	}
+	if err := c.KubeClient.Delete(ctx, &origin); err != nil && !apierrors.IsNotFound(err) {
+		c.removeRehomedApplicationCR(ctx, rehomed)
+		return err
+	}
+	klog.Infof("the application %s is re-homed from the namespace %s to %s", name, m.source.Namespace, m.dest.Namespace)
+	return nil
+}
+
+// removeRehomedApplicationCR delete the new CR when the original CR could not be removed, the resources are orphaned
+// because they are still managed by the original CR.
+func (c *applicationServiceImpl) removeRehomedApplicationCR(ctx context.Context, rehomed *v1beta1.Application) {
+	controllerutil.AddFinalizer(rehomed, oam.FinalizerOrphanResource)
+	if err := c.KubeClient.Update(ctx, rehomed); err != nil {
+		klog.Errorf("failed to orphan the resources of the application %s/%s: %s", rehomed.Namespace, rehomed.Name, err.Error())
+		return
+	}
+	if err := c.KubeClient.Delete(ctx, rehomed); err != nil && !apierrors.IsNotFound(err) {
+		klog.Errorf("failed to delete the application %s/%s: %s", rehomed.Namespace, rehomed.Name, err.Error())
+	}
+}
+
+// cloneApplicationEntities copy the components, the policies, the workflows, the env bindings and the triggers,
+// the envs maps the source envs to the destination envs, the entities of the unmapped envs are skipped.
+func (c *applicationServiceImpl) cloneApplicationEntities(ctx context.Context, app, clone *model.Application, envs map[string]*model.Env) error {
+	components, err := c.Store.List(ctx, &model.ApplicationComponent{AppPrimaryKey: app.PrimaryKey()}, nil)
+	if err != nil {
+		return err
+	}
+	for _, entity := range components {
+		component := entity.(*model.ApplicationComponent)
+		component.BaseModel = model.BaseModel{}
+		component.AppPrimaryKey = clone.PrimaryKey()
+		if err := c.Store.Add(ctx, component); err != nil {
+			return err
+		}
+	}
+
+	policies, err := c.Store.List(ctx, &model.ApplicationPolicy{AppPrimaryKey: app.PrimaryKey()}, nil)
+	if err != nil {
+		return err
+	}
+	for _, entity := range policies {
+		policy := entity.(*model.ApplicationPolicy)
+		if policy.EnvName != "" {
+			env, ok := envs[policy.EnvName]
+			if !ok {
+				continue
+			}
+			policy.EnvName = env.Name
+		}
+		policy.BaseModel = model.BaseModel{}
+		policy.AppPrimaryKey = clone.PrimaryKey()
+		if err := c.Store.Add(ctx, policy); err != nil {
+			return err
+		}
+	}
+
+	workflowNames := map[string]string{}
+	workflows, err := c.Store.List(ctx, &model.Workflow{AppPrimaryKey: app.PrimaryKey()}, nil)
+	if err != nil {
+		return err
+	}
+	for _, entity := range workflows {
+		workflow := entity.(*model.Workflow)
+		name := workflow.Name
+		if workflow.EnvName != "" {
+			env, ok := envs[workflow.EnvName]
+			if !ok {
+				continue
+			}
+			if workflow.Name == repository.ConvertWorkflowName(workflow.EnvName) {
+				workflow.Name = repository.ConvertWorkflowName(env.Name)
+			}
+			workflow.EnvName = env.Name
+		}
+		workflowNames[name] = workflow.Name
+		workflow.BaseModel = model.BaseModel{}
+		workflow.AppPrimaryKey = clone.PrimaryKey()
+		if err := c.Store.Add(ctx, workflow); err != nil {
+			return err
+		}
+	}
+
+	bindings, err := c.Store.List(ctx, &model.EnvBinding{AppPrimaryKey: app.PrimaryKey()}, nil)
+	if err != nil {
+		return err
+	}
+	for _, entity := range bindings {
+		binding := entity.(*model.EnvBinding)
+		env, ok := envs[binding.Name]
+		if !ok {
+			continue
+		}
+		binding.BaseModel = model.BaseModel{}
+		binding.AppPrimaryKey = clone.PrimaryKey()
+		binding.AppDeployName = ""
+		binding.Name = env.Name
+		if err := c.Store.Add(ctx, binding); err != nil {
+			return err
+		}
+	}
+
+	triggers, err := c.Store.List(ctx, &model.ApplicationTrigger{AppPrimaryKey: app.PrimaryKey()}, nil)
+	if err != nil {
+		return err
+	}
+	for _, entity := range triggers {
+		trigger := entity.(*model.ApplicationTrigger)
+		workflowName, ok := workflowNames[trigger.WorkflowName]
+		if !ok {
+			continue
+		}
+		trigger.BaseModel = model.BaseModel{}
+		trigger.AppPrimaryKey = clone.PrimaryKey()
+		trigger.WorkflowName = workflowName
+		trigger.Token = genWebhookToken()
+		if strings.HasPrefix(trigger.Name, app.Name+"-") {
+			trigger.Name = clone.Name + strings.TrimPrefix(trigger.Name, app.Name)
+		}
+		if err := c.Store.Add(ctx, trigger); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,166 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/oam-dev/kubevela/apis/core.oam.dev/v1beta1"
+	"github.com/oam-dev/kubevela/pkg/oam"
+
+	"github.com/kubevela/velaux/pkg/server/domain/model"
+	"github.com/kubevela/velaux/pkg/server/domain/repository"
+	apisv1 "github.com/kubevela/velaux/pkg/server/interfaces/api/dto/v1"
+	"github.com/kubevela/velaux/pkg/server/utils/bcode"
+)
+
+var _ = Describe("Test application transfer functions", func() {
+	var app *model.Application
+
+	BeforeEach(func() {
+		InitTestEnv("app-transfer-test-" + strconv.FormatInt(time.Now().UnixNano(), 10))
+		for _, project := range []string{"transfer-from", "transfer-to"} {
+			_, err := projectService.CreateProject(context.TODO(), apisv1.CreateProjectRequest{Name: project})
+			Expect(err).Should(BeNil())
+		}
+		_, err := targetService.CreateTarget(context.TODO(), apisv1.CreateTargetRequest{
+			Name: "transfer-target", Project: "transfer-from", Cluster: &apisv1.ClusterTarget{ClusterName: "local", Namespace: "transfer-target"},
+		})
+		Expect(err).Should(BeNil())
+		_, err = envService.CreateEnv(context.TODO(), apisv1.CreateEnvRequest{Name: "transfer-from-dev", Namespace: "transfer-from-dev", Project: "transfer-from", Targets: []string{"transfer-target"}})
+		Expect(err).Should(BeNil())
+		_, err = envService.CreateEnv(context.TODO(), apisv1.CreateEnvRequest{Name: "transfer-to-dev", Namespace: "transfer-to-dev", Project: "transfer-to", Targets: []string{"transfer-target"}})
+		Expect(err).Should(BeNil())
+		_, err = envService.CreateEnv(context.TODO(), apisv1.CreateEnvRequest{Name: "transfer-to-empty", Namespace: "transfer-to-empty", Project: "transfer-to"})
+		Expect(err).Should(BeNil())
+		_, err = appService.CreateApplication(context.TODO(), apisv1.CreateApplicationRequest{
+			Name:       "transfer-app",
+			Project:    "transfer-from",
+			EnvBinding: []*apisv1.EnvBinding{{Name: "transfer-from-dev"}},
+			Component:  &apisv1.CreateComponentRequest{Name: "transfer-component", ComponentType: "webservice"},
+		})
+		Expect(err).Should(BeNil())
+		app, err = appService.GetApplication(context.TODO(), "transfer-app")
+		Expect(err).Should(BeNil())
+	})
+
+	It("Test transferring the application", func() {
+		_, err := appService.TransferApplication(context.TODO(), app, apisv1.TransferApplicationRequest{Project: "transfer-to"})
+		Expect(err.(*bcode.Bcode).BusinessCode).Should(Equal(bcode.ErrApplicationEnvMismatch.BusinessCode))
+		_, err = appService.TransferApplication(context.TODO(), app, apisv1.TransferApplicationRequest{
+			Project: "transfer-to", EnvMapping: map[string]string{"transfer-from-dev": "transfer-to-empty"},
+		})
+		Expect(err.(*bcode.Bcode).BusinessCode).Should(Equal(bcode.ErrApplicationEnvMismatch.BusinessCode))
+
+		base, err := appService.TransferApplication(context.TODO(), app, apisv1.TransferApplicationRequest{
+			Project: "transfer-to", EnvMapping: map[string]string{"transfer-from-dev": "transfer-to-dev"},
+		})
+		Expect(err).Should(BeNil())
+		Expect(base.Project.Name).Should(Equal("transfer-to"))
+		Expect(ds.Get(context.TODO(), &model.EnvBinding{AppPrimaryKey: "transfer-app", Name: "transfer-to-dev"})).Should(BeNil())
+		workflow := &model.Workflow{AppPrimaryKey: "transfer-app", Name: repository.ConvertWorkflowName("transfer-to-dev")}
+		Expect(ds.Get(context.TODO(), workflow)).Should(BeNil())
+		Expect(workflow.EnvName).Should(Equal("transfer-to-dev"))
+		triggers, err := appService.ListApplicationTriggers(context.TODO(), app)
+		Expect(err).Should(BeNil())
+		Expect(triggers[0].WorkflowName).Should(Equal(repository.ConvertWorkflowName("transfer-to-dev")))
+	})
+
+	It("Test the transfer leaves nothing moved when it fails", func() {
+		conflict := &v1beta1.Application{ObjectMeta: metav1.ObjectMeta{Name: "transfer-app", Namespace: "transfer-to-dev"}}
+		Expect(k8sClient.Create(context.TODO(), conflict)).Should(Succeed())
+		_, err := appService.TransferApplication(context.TODO(), app, apisv1.TransferApplicationRequest{
+			Project: "transfer-to", EnvMapping: map[string]string{"transfer-from-dev": "transfer-to-dev"},
+		})
+		Expect(err.(*bcode.Bcode).BusinessCode).Should(Equal(bcode.ErrApplicationTransferConflict.BusinessCode))
+		Expect(ds.Get(context.TODO(), &model.EnvBinding{AppPrimaryKey: "transfer-app", Name: "transfer-from-dev"})).Should(BeNil())
+		Expect(app.Project).Should(Equal("transfer-from"))
+		Expect(k8sClient.Delete(context.TODO(), conflict)).Should(Succeed())
+
+		mappings, err := appService.mapApplicationEnvs(context.TODO(), app, "transfer-to", map[string]string{"transfer-from-dev": "transfer-to-dev"}, true)
+		Expect(err).Should(BeNil())
+		Expect(appService.transferEnvBinding(context.TODO(), app, mappings[0])).Should(Succeed())
+		appService.revertEnvBindings(context.TODO(), app, mappings)
+		Expect(ds.Get(context.TODO(), &model.EnvBinding{AppPrimaryKey: "transfer-app", Name: "transfer-from-dev"})).Should(BeNil())
+		exist, err := ds.IsExist(context.TODO(), &model.EnvBinding{AppPrimaryKey: "transfer-app", Name: "transfer-to-dev"})
+		Expect(err).Should(BeNil())
+		Expect(exist).Should(BeFalse())
+		workflow := &model.Workflow{AppPrimaryKey: "transfer-app", Name: repository.ConvertWorkflowName("transfer-from-dev")}
+		Expect(ds.Get(context.TODO(), workflow)).Should(BeNil())
+		Expect(workflow.EnvName).Should(Equal("transfer-from-dev"))
+		triggers, err := appService.ListApplicationTriggers(context.TODO(), app)
+		Expect(err).Should(BeNil())
+		Expect(triggers[0].WorkflowName).Should(Equal(repository.ConvertWorkflowName("transfer-from-dev")))
+	})
+
+	It("Test re-homing the application CR", func() {
+		origin := &v1beta1.Application{ObjectMeta: metav1.ObjectMeta{
+			Name:      "transfer-app",
+			Namespace: "transfer-from-dev",
+			Labels:    map[string]string{model.LabelSyncNamespace: "transfer-from-dev", oam.AnnotationAppName: "transfer-app"},
+			Annotations: map[string]string{
+				oam.AnnotationWorkflowName:   repository.ConvertWorkflowName("transfer-from-dev"),
+				oam.AnnotationDeployVersion:  "v1",
+				oam.AnnotationPublishVersion: "workflow-v1",
+			},
+		}}
+		Expect(k8sClient.Create(context.TODO(), origin)).Should(Succeed())
+		mappings, err := appService.mapApplicationEnvs(context.TODO(), app, "transfer-to", map[string]string{"transfer-from-dev": "transfer-to-dev"}, true)
+		Expect(err).Should(BeNil())
+		Expect(appService.rehomeApplicationCR(context.TODO(), app, mappings[0])).Should(Succeed())
+
+		var rehomed v1beta1.Application
+		Expect(k8sClient.Get(context.TODO(), types.NamespacedName{Namespace: "transfer-to-dev", Name: "transfer-app"}, &rehomed)).Should(Succeed())
+		Expect(rehomed.Annotations[oam.AnnotationWorkflowName]).Should(Equal(repository.ConvertWorkflowName("transfer-to-dev")))
+		Expect(rehomed.Annotations[oam.AnnotationDeployVersion]).Should(Equal("v1"))
+		Expect(rehomed.Annotations[oam.AnnotationPublishVersion]).Should(Equal("workflow-v1"))
+		Expect(rehomed.Labels[model.LabelSyncNamespace]).Should(Equal("transfer-to-dev"))
+		Expect(rehomed.Labels[oam.AnnotationAppName]).Should(Equal("transfer-app"))
+	})
+
+	It("Test cloning the application", func() {
+		_, err := appService.CloneApplication(context.TODO(), app, apisv1.CloneApplicationRequest{Name: "transfer-app"})
+		Expect(err).Should(Equal(bcode.ErrApplicationExist))
+
+		base, err := appService.CloneApplication(context.TODO(), app, apisv1.CloneApplicationRequest{Name: "transfer-app-clone"})
+		Expect(err).Should(BeNil())
+		Expect(base.Project.Name).Should(Equal("transfer-from"))
+		clone := &model.Application{Name: "transfer-app-clone"}
+		components, err := appService.ListComponents(context.TODO(), clone, apisv1.ListApplicationComponentOptions{})
+		Expect(err).Should(BeNil())
+		Expect(len(components)).Should(Equal(1))
+		Expect(ds.Get(context.TODO(), &model.EnvBinding{AppPrimaryKey: "transfer-app-clone", Name: "transfer-from-dev"})).Should(BeNil())
+		triggers, err := appService.ListApplicationTriggers(context.TODO(), clone)
+		Expect(err).Should(BeNil())
+		Expect(len(triggers)).Should(Equal(1))
+		Expect(triggers[0].Name).Should(Equal("transfer-app-clone-default"))
+
+		// the bindings of the unmapped envs are not cloned into another project
+		_, err = appService.CloneApplication(context.TODO(), app, apisv1.CloneApplicationRequest{Name: "transfer-app-other", Project: "transfer-to"})
+		Expect(err).Should(BeNil())
+		exist, err := ds.IsExist(context.TODO(), &model.EnvBinding{AppPrimaryKey: "transfer-app-other", Name: "transfer-from-dev"})
+		Expect(err).Should(BeNil())
+		Expect(exist).Should(BeFalse())
+	})
+})
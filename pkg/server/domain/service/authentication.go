@@ -23,6 +23,7 @@ import (
 	"net/http"
 	"reflect"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/coreos/go-oidc"
@@ -58,14 +59,97 @@ const (
 	GrantTypeAccess = "access"
 	// GrantTypeRefresh is the grant type for refresh token
 	GrantTypeRefresh = "refresh"
+	// GrantTypeMFA is the grant type for the token of the second login step
+	GrantTypeMFA = "mfa"
+
+	// mfaTokenExpiration the user should verify the TOTP code in this duration after the password is verified
+	mfaTokenExpiration     = time.Minute * 5
+	accessTokenExpiration  = time.Hour
+	refreshTokenExpiration = time.Hour * 24
+
+	// signingKeysReloadInterval the min interval to reload the signing keys when a token signed by an unknown key is received
+	signingKeysReloadInterval = time.Second * 10
+	// signingKeysRefreshInterval the signing keys are reloaded in this interval, so the key rotated by another replica
+	// is used to sign the new tokens and the removed keys are not accepted any more.
+	signingKeysRefreshInterval = time.Minute
 )
 
-// signedKey is the signed key of JWT
-var signedKey string
+// signingKeys the signing keys of JWT, it is loaded from the system info
+var signingKeys = struct {
+	sync.RWMutex
+	active   model.SigningKey
+	keys     map[string]string
+	loadTime time.Time
+}{keys: map[string]string{"": ""}}
+
+// signingKeysLoader loads the system info, the keys may be rotated by another replica.
+var signingKeysLoader func(ctx context.Context) (*model.SystemInfo, error)
+
+// setSigningKeys replace the signing keys in memory with the keys in the system info
+func setSigningKeys(info *model.SystemInfo) {
+	keys := info.SigningKeys
+	if len(keys) == 0 {
+		keys = []model.SigningKey{{Key: info.SignedKey, CreateTime: info.CreateTime}}
+	}
+	keyMap := make(map[string]string, len(keys))
+	for _, k := range keys {
+		keyMap[k.ID] = k.Key
+	}
+	signingKeys.Lock()
+	defer signingKeys.Unlock()
+	signingKeys.active = keys[0]
+	signingKeys.keys = keyMap
+	signingKeys.loadTime = time.Now()
+}
+
+// reloadSigningKeys reload the signing keys if they are loaded before the interval
+func reloadSigningKeys(interval time.Duration) {
+	signingKeys.RLock()
+	loadTime := signingKeys.loadTime
+	signingKeys.RUnlock()
+	if signingKeysLoader == nil || time.Since(loadTime) < interval {
+		return
+	}
+	info, err := signingKeysLoader(context.Background())
+	if err != nil {
+		klog.Errorf("failed to reload the signing keys: %s", err.Error())
+		// Retry after the interval instead of loading the keys on every request
+		signingKeys.Lock()
+		signingKeys.loadTime = time.Now()
+		signingKeys.Unlock()
+		return
+	}
+	setSigningKeys(info)
+}
+
+// getSigningKey get the key by the ID, reload the keys if the key is not found
+func getSigningKey(id string) (string, bool) {
+	reloadSigningKeys(signingKeysRefreshInterval)
+	signingKeys.RLock()
+	key, ok := signingKeys.keys[id]
+	signingKeys.RUnlock()
+	if ok {
+		return key, ok
+	}
+	reloadSigningKeys(signingKeysReloadInterval)
+	signingKeys.RLock()
+	defer signingKeys.RUnlock()
+	key, ok = signingKeys.keys[id]
+	return key, ok
+}
+
+// activeSigningKey get the key to sign the new tokens
+func activeSigningKey() model.SigningKey {
+	reloadSigningKeys(signingKeysRefreshInterval)
+	signingKeys.RLock()
+	defer signingKeys.RUnlock()
+	return signingKeys.active
+}
 
 // AuthenticationService is the service of authentication
 type AuthenticationService interface {
 	Login(ctx context.Context, loginReq apisv1.LoginRequest) (*apisv1.LoginResponse, error)
+	LoginMFA(ctx context.Context, req apisv1.LoginMFARequest) (*apisv1.LoginResponse, error)
 	RefreshToken(ctx context.Context, refreshToken string) (*apisv1.RefreshTokenResponse, error)
 	GetDexConfig(ctx context.Context) (*apisv1.DexConfigResponse, error)
 	GetLoginType(ctx context.Context) (*apisv1.GetLoginTypeResponse, error)
@@ -75,6 +159,7 @@ type authenticationServiceImpl struct {
 	SysService     SystemInfoService   `inject:""`
 	UserService    UserService         `inject:""`
 	ProjectService ProjectService      `inject:""`
+	SessionService SessionService      `inject:""`
 	Store          datastore.DataStore `inject:"datastore"`
 	KubeClient     client.Client       `inject:"kubeClient"`
 }
@@ -100,6 +185,7 @@ type localHandlerImpl struct {
 	userService UserService
 	username    string
 	password    string
+	newPassword string
 }
 
 func (a *authenticationServiceImpl) newDexHandler(ctx context.Context, req apisv1.LoginRequest) (*dexHandlerImpl, error) {
@@ -147,6 +233,7 @@ func (a *authenticationServiceImpl) newLocalHandler(req apisv1.LoginRequest) (*l
 		userService: a.UserService,
 		username:    req.Username,
 		password:    req.Password,
+		newPassword: req.NewPassword,
 	}, nil
 }
 
@@ -180,11 +267,56 @@ func (a *authenticationServiceImpl) Login(ctx context.Context, loginReq apisv1.L
 	if userBase.Disabled {
 		return nil, bcode.ErrUserAlreadyDisabled
 	}
-	accessToken, err := a.generateJWTToken(userBase.Name, GrantTypeAccess, time.Hour)
+	if userBase.TOTPEnabled {
+		mfaToken, err := a.generateJWTToken(userBase.Name, GrantTypeMFA, "", "", mfaTokenExpiration)
+		if err != nil {
+			return nil, err
+		}
+		return &apisv1.LoginResponse{
+			User:        userBase,
+			MFARequired: true,
+			MFAToken:    mfaToken,
+		}, nil
+	}
+	return a.newLoginResponse(ctx, userBase)
+}
+
+// LoginMFA verify the TOTP code or the recovery code of the user who has passed the first login step
+func (a *authenticationServiceImpl) LoginMFA(ctx context.Context, req apisv1.LoginMFARequest) (*apisv1.LoginResponse, error) {
+	claim, err := ParseToken(req.MFAToken)
 	if err != nil {
 		return nil, err
 	}
-	refreshToken, err := a.generateJWTToken(userBase.Name, GrantTypeRefresh, time.Hour*24)
+	if claim.GrantType != GrantTypeMFA {
+		return nil, bcode.ErrNotMFAToken
+	}
+	user, err := a.UserService.GetUser(ctx, claim.Username)
+	if err != nil {
+		if errors.Is(err, datastore.ErrRecordNotExist) {
+			return nil, bcode.ErrUsernameNotExist
+		}
+		return nil, err
+	}
+	if user.Disabled {
+		return nil, bcode.ErrUserAlreadyDisabled
+	}
+	if err := a.UserService.VerifyTOTP(ctx, user, req.Code); err != nil {
+		return nil, err
+	}
+	return a.newLoginResponse(ctx, convertUserBase(user))
+}
+
+// newLoginResponse create a session and issue the tokens bound to it
+func (a *authenticationServiceImpl) newLoginResponse(ctx context.Context, userBase *apisv1.UserBase) (*apisv1.LoginResponse, error) {
+	var session *model.Session
+	if a.SessionService != nil {
+		var err error
+		session, err = a.SessionService.CreateSession(ctx, userBase.Name, refreshTokenExpiration)
+		if err != nil {
+			return nil, err
+		}
+	}
+	accessToken, refreshToken, err := a.generateTokenPair(userBase.Name, session)
 	if err != nil {
 		return nil, err
 	}
@@ -195,19 +327,43 @@ func (a *authenticationServiceImpl) Login(ctx context.Context, loginReq apisv1.L
 	}, nil
 }
 
-func (a *authenticationServiceImpl) generateJWTToken(username, grantType string, expireDuration time.Duration) (string, error) {
+// generateTokenPair issue the access token and the refresh token bound to the session,
+// the refresh token carries the ID recorded in the session so that it can be used only once.
+func (a *authenticationServiceImpl) generateTokenPair(username string, session *model.Session) (string, string, error) {
+	var sessionID, refreshTokenID string
+	if session != nil {
+		sessionID, refreshTokenID = session.ID, session.RefreshTokenID
+	}
+	accessToken, err := a.generateJWTToken(username, GrantTypeAccess, sessionID, "", accessTokenExpiration)
+	if err != nil {
+		return "", "", err
+	}
+	refreshToken, err := a.generateJWTToken(username, GrantTypeRefresh, sessionID, refreshTokenID, refreshTokenExpiration)
+	if err != nil {
+		return "", "", err
+	}
+	return accessToken, refreshToken, nil
+}
+
+func (a *authenticationServiceImpl) generateJWTToken(username, grantType, sessionID, tokenID string, expireDuration time.Duration) (string, error) {
 	expire := time.Now().Add(expireDuration)
 	claims := model.CustomClaims{
 		StandardClaims: jwt.StandardClaims{
 			NotBefore: time.Now().Unix(),
 			ExpiresAt: expire.Unix(),
 			Issuer:    jwtIssuer,
+			Id:        tokenID,
 		},
 		Username:  username,
 		GrantType: grantType,
+		SessionID: sessionID,
 	}
+	key := activeSigningKey()
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(signedKey))
+	if key.ID != "" {
+		token.Header["kid"] = key.ID
+	}
+	return token.SignedString([]byte(key.Key))
 }
 
 func (a *authenticationServiceImpl) RefreshToken(ctx context.Context, refreshToken string) (*apisv1.RefreshTokenResponse, error) {
@@ -218,24 +374,49 @@ func (a *authenticationServiceImpl) RefreshToken(ctx context.Context, refreshTok
 		}
 		return nil, err
 	}
-	if claim.GrantType == GrantTypeRefresh {
-		accessToken, err := a.generateJWTToken(claim.Username, GrantTypeAccess, time.Hour)
-		if err != nil {
-			return nil, err
+	if claim.GrantType != GrantTypeRefresh {
+		return nil, bcode.ErrTokenInvalid
+	}
+	// The refresh token issued before the sessions are introduced can't be rotated, the user should login again
+	if claim.SessionID == "" {
+		return nil, bcode.ErrSessionNotFound
+	}
+	user, err := a.UserService.GetUser(ctx, claim.Username)
+	if err != nil {
+		if errors.Is(err, datastore.ErrRecordNotExist) {
+			return nil, bcode.ErrSessionNotFound
 		}
-		// TODO: generate a new refresh token
-		return &apisv1.RefreshTokenResponse{
-			AccessToken:  accessToken,
-			RefreshToken: refreshToken,
-		}, nil
+		return nil, err
 	}
-	return nil, err
+	if user.Disabled {
+		return nil, bcode.ErrUserDisabled
+	}
+	session, err := a.SessionService.RenewSession(ctx, claim, refreshTokenExpiration)
+	if err != nil {
+		return nil, err
+	}
+	accessToken, newRefreshToken, err := a.generateTokenPair(claim.Username, session)
+	if err != nil {
+		return nil, err
+	}
+	return &apisv1.RefreshTokenResponse{
+		AccessToken:  accessToken,
+		RefreshToken: newRefreshToken,
+	}, nil
 }
 
 // ParseToken parses and verifies a token
 func ParseToken(tokenString string) (*model.CustomClaims, error) {
 	token, err := jwt.ParseWithClaims(tokenString, &model.CustomClaims{}, func(token *jwt.Token) (interface{}, error) {
-		return []byte(signedKey), nil
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, bcode.ErrTokenInvalid
+		}
+		kid, _ := token.Header["kid"].(string)
+		key, ok := getSigningKey(kid)
+		if !ok {
+			return nil, bcode.ErrTokenInvalid
+		}
+		return []byte(key), nil
 	})
 	if err != nil {
 		var ve *jwt.ValidationError
@@ -509,8 +690,13 @@ func (l *localHandlerImpl) login(ctx context.Context) (*apisv1.UserBase, error)
 		}
 		return nil, err
 	}
-	if err := compareHashWithPassword(user.Password, l.password); err != nil {
-		return nil, err
+	if err := l.userService.VerifyPassword(ctx, user, l.password); err != nil {
+		if !errors.Is(err, bcode.ErrUserPasswordExpired) || l.newPassword == "" {
+			return nil, err
+		}
+		if _, err := l.userService.UpdateUser(ctx, user, apisv1.UpdateUserRequest{Password: l.newPassword}); err != nil {
+			return nil, err
+		}
 	}
 	if err := l.userService.UpdateUserLoginTime(ctx, user); err != nil {
 		return nil, err
@@ -520,6 +706,8 @@ func (l *localHandlerImpl) login(ctx context.Context) (*apisv1.UserBase, error)
 		LastLoginTime: user.LastLoginTime,
 		Name:          user.Name,
 		Email:         user.Email,
+		Disabled:      user.Disabled,
+		TOTPEnabled:   user.TOTPEnabled,
 	}, nil
 }
 
@@ -162,6 +162,45 @@ var _ = Describe("Test authentication service functions", func() {
 		Expect(resp.Name).Should(Equal("test-login"))
 	})
 
+	It("Test MFA login", func() {
+		ctx := context.Background()
+		_, err := userService.CreateUser(ctx, apisv1.CreateUserRequest{
+			Name:     "test-mfa",
+			Email:    "mfa@example.com",
+			Password: "password1",
+		})
+		Expect(err).Should(BeNil())
+		user, err := userService.GetUser(ctx, "test-mfa")
+		Expect(err).Should(BeNil())
+		enroll, err := userService.EnrollTOTP(ctx, user)
+		Expect(err).Should(BeNil())
+		code, err := utils.GenerateTOTPCode(enroll.Secret, time.Now())
+		Expect(err).Should(BeNil())
+		_, err = userService.ActivateTOTP(ctx, user, code)
+		Expect(err).Should(BeNil())
+
+		resp, err := authService.Login(ctx, apisv1.LoginRequest{Username: "test-mfa", Password: "password1"})
+		Expect(err).Should(BeNil())
+		Expect(resp.MFARequired).Should(BeTrue())
+		Expect(resp.AccessToken).Should(BeEmpty())
+		// the MFA token can not be used as the access token
+		claims, err := ParseToken(resp.MFAToken)
+		Expect(err).Should(BeNil())
+		Expect(claims.GrantType).Should(Equal(GrantTypeMFA))
+
+		_, err = authService.LoginMFA(ctx, apisv1.LoginMFARequest{MFAToken: resp.MFAToken, Code: "000000"})
+		Expect(err).ShouldNot(BeNil())
+		// the code accepted by the activation can not be replayed
+		_, err = authService.LoginMFA(ctx, apisv1.LoginMFARequest{MFAToken: resp.MFAToken, Code: code})
+		Expect(err).ShouldNot(BeNil())
+		nextCode, err := utils.GenerateTOTPCode(enroll.Secret, time.Now().Add(utils.TOTPPeriod))
+		Expect(err).Should(BeNil())
+		resp, err = authService.LoginMFA(ctx, apisv1.LoginMFARequest{MFAToken: resp.MFAToken, Code: nextCode})
+		Expect(err).Should(BeNil())
+		Expect(resp.AccessToken).ShouldNot(BeEmpty())
+		Expect(resp.RefreshToken).ShouldNot(BeEmpty())
+	})
+
 	It("Test update dex config", func() {
 		err := k8sClient.Create(context.Background(), &corev1.Namespace{
 			ObjectMeta: metav1.ObjectMeta{
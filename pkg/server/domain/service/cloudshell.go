@@ -22,6 +22,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -45,6 +46,7 @@ import (
 	"github.com/oam-dev/kubevela/pkg/auth"
 	pkgutils "github.com/oam-dev/kubevela/pkg/utils"
 
+	"github.com/kubevela/velaux/pkg/server/config"
 	"github.com/kubevela/velaux/pkg/server/domain/model"
 	apisv1 "github.com/kubevela/velaux/pkg/server/interfaces/api/dto/v1"
 	"github.com/kubevela/velaux/pkg/server/utils"
@@ -73,34 +75,54 @@ const (
 
 	// ServerAddressInCluster the kubernetes server address in cluster.
 	ServerAddressInCluster = "https://kubernetes.default:443"
+
+	// AnnotationCloudShellLastActivity the annotation records the last time the cloud shell is accessed
+	AnnotationCloudShellLastActivity = "oam.dev/cloudshell-last-activity"
 )
 
+// cloudShellActivityInterval the interval of recording the activity of the cloud shell, avoid updating it on every request
+var cloudShellActivityInterval = time.Minute
+
+// minKubeConfigExpireTime the min expiration of the certificate signing request
+const minKubeConfigExpireTime = time.Minute * 10
+
 // CloudShellService provide the cloud shell feature
 type CloudShellService interface {
 	Prepare(ctx context.Context) (*apisv1.CloudShellPrepareResponse, error)
 	GetCloudShellEndpoint(ctx context.Context) (string, error)
 	Destroy(ctx context.Context) error
+	KeepAlive(ctx context.Context) error
+	ListCloudShellSessions(ctx context.Context) (*apisv1.ListCloudShellSessionResponse, error)
+	TerminateCloudShell(ctx context.Context, userName string) error
+	ReapExpiredCloudShells(ctx context.Context) error
+	IssueKubeConfig(ctx context.Context, req apisv1.IssueKubeConfigRequest) (*apisv1.IssueKubeConfigResponse, error)
+	ListIssuedKubeConfigs(ctx context.Context, userName string) (*apisv1.ListIssuedKubeConfigResponse, error)
+	RevokeKubeConfig(ctx context.Context, id string) error
+	ReapExpiredKubeConfigs(ctx context.Context) error
 }
 
 // GenerateKubeConfig generate the kubeconfig for the cloudshell
 type GenerateKubeConfig func(ctx context.Context, cli kubernetes.Interface, cfg *api.Config, writer io.Writer, options ...auth.KubeConfigGenerateOption) (*api.Config, error)
 
 type cloudShellServiceImpl struct {
-	KubeClient         client.Client  `inject:"kubeClient"`
-	KubeConfig         *rest.Config   `inject:"kubeConfig"`
-	UserService        UserService    `inject:""`
-	ProjectService     ProjectService `inject:""`
-	RBACService        RBACService    `inject:""`
-	TargetService      TargetService  `inject:""`
-	EnvService         EnvService     `inject:""`
+	Store              datastore.DataStore `inject:"datastore"`
+	KubeClient         client.Client       `inject:"kubeClient"`
+	KubeConfig         *rest.Config        `inject:"kubeConfig"`
+	UserService        UserService         `inject:""`
+	ProjectService     ProjectService      `inject:""`
+	RBACService        RBACService         `inject:""`
+	TargetService      TargetService       `inject:""`
+	EnvService         EnvService          `inject:""`
 	GenerateKubeConfig GenerateKubeConfig
 	CACert             []byte
+	Config             config.CloudShellConfig
 }
 
 // NewCloudShellService create the instance of the cloud shell service
-func NewCloudShellService() CloudShellService {
+func NewCloudShellService(cfg config.CloudShellConfig) CloudShellService {
 	return &cloudShellServiceImpl{
 		GenerateKubeConfig: auth.GenerateKubeConfig,
+		Config:             cfg,
 	}
 }
 
@@ -131,6 +153,12 @@ func (c *cloudShellServiceImpl) Prepare(ctx context.Context) (*apisv1.CloudShell
 		}
 	}
 	if shouldCreate {
+		if err := c.checkCloudShellLimit(ctx); err != nil {
+			if meta.IsNoMatchError(err) {
+				return nil, bcode.ErrCloudShellAddonNotEnabled
+			}
+			return nil, err
+		}
 		if err := c.prepareKubeConfig(ctx); err != nil {
 			return res, fmt.Errorf("failed to prepare the kubeconfig for the user: %w", err)
 		}
@@ -147,7 +175,7 @@ func (c *cloudShellServiceImpl) Prepare(ctx context.Context) (*apisv1.CloudShell
 		res.Status = StatusPreparing
 	} else {
 		if cloudShell.Status.Phase == v1alpha1.PhaseFailed {
-			if err := c.KubeClient.Delete(ctx, &cloudShell); err != nil {
+			if err := c.deleteCloudShell(ctx, &cloudShell); err != nil {
 				klog.Errorf("failed to clear the failed cloud shell:%s", err.Error())
 			}
 			res.Status = StatusFailed
@@ -185,7 +213,7 @@ func (c *cloudShellServiceImpl) Destroy(ctx context.Context) error {
 		}
 		return err
 	}
-	return c.KubeClient.Delete(ctx, &cloudShell)
+	return c.deleteCloudShell(ctx, &cloudShell)
 }
 
 func (c *cloudShellServiceImpl) GetCloudShellEndpoint(ctx context.Context) (string, error) {
@@ -210,9 +238,182 @@ func (c *cloudShellServiceImpl) GetCloudShellEndpoint(ctx context.Context) (stri
 		}
 		return "", err
 	}
+	if err := c.recordCloudShellActivity(ctx, &cloudShell); err != nil {
+		klog.Errorf("failed to record the activity of the cloud shell %s: %s", cloudShell.Name, err.Error())
+	}
 	return cloudShell.Status.AccessURL, nil
 }
 
+// KeepAlive record the activity of the user's cloud shell, it is invoked periodically while the terminal is connected
+func (c *cloudShellServiceImpl) KeepAlive(ctx context.Context) error {
+	var userName string
+	if user := ctx.Value(&apisv1.CtxKeyUser); user != nil {
+		if u, ok := user.(string); ok {
+			userName = u
+		}
+	}
+	if userName == "" {
+		return bcode.ErrUnauthorized
+	}
+	var cloudShell v1alpha1.CloudShell
+	if err := c.KubeClient.Get(ctx, types.NamespacedName{Namespace: kubevelatypes.DefaultKubeVelaNS, Name: makeUserCloudShellName(userName)}, &cloudShell); err != nil {
+		return err
+	}
+	return c.recordCloudShellActivity(ctx, &cloudShell)
+}
+
+// recordCloudShellActivity update the last activity time of the cloud shell if it is recorded before the activity interval
+func (c *cloudShellServiceImpl) recordCloudShellActivity(ctx context.Context, cloudShell *v1alpha1.CloudShell) error {
+	now := time.Now()
+	if last := cloudShellLastActivity(cloudShell); now.Sub(last) < cloudShellActivityInterval {
+		return nil
+	}
+	patch := client.MergeFrom(cloudShell.DeepCopy())
+	if cloudShell.Annotations == nil {
+		cloudShell.Annotations = map[string]string{}
+	}
+	cloudShell.Annotations[AnnotationCloudShellLastActivity] = now.UTC().Format(time.RFC3339)
+	return c.KubeClient.Patch(ctx, cloudShell, patch)
+}
+
+// ListCloudShellSessions list the active cloud shells of all users
+func (c *cloudShellServiceImpl) ListCloudShellSessions(ctx context.Context) (*apisv1.ListCloudShellSessionResponse, error) {
+	cloudShells, err := c.listCloudShells(ctx)
+	if err != nil {
+		if meta.IsNoMatchError(err) {
+			return nil, bcode.ErrCloudShellAddonNotEnabled
+		}
+		return nil, err
+	}
+	res := &apisv1.ListCloudShellSessionResponse{Sessions: []*apisv1.CloudShellSession{}}
+	for i := range cloudShells {
+		cs := &cloudShells[i]
+		res.Sessions = append(res.Sessions, &apisv1.CloudShellSession{
+			User:             cs.Spec.RunAsUser,
+			Phase:            cs.Status.Phase,
+			CreateTime:       cs.CreationTimestamp.Time,
+			LastActivityTime: cloudShellLastActivity(cs),
+			ExpireTime:       cloudShellExpireTime(cs, c.Config),
+		})
+	}
+	sort.Slice(res.Sessions, func(i, j int) bool {
+		return res.Sessions[i].CreateTime.Before(res.Sessions[j].CreateTime)
+	})
+	return res, nil
+}
+
+// TerminateCloudShell delete the cloud shell of the user and revoke the kubeconfig
+func (c *cloudShellServiceImpl) TerminateCloudShell(ctx context.Context, userName string) error {
+	var cloudShell v1alpha1.CloudShell
+	if err := c.KubeClient.Get(ctx, types.NamespacedName{Namespace: kubevelatypes.DefaultKubeVelaNS, Name: makeUserCloudShellName(userName)}, &cloudShell); err != nil {
+		if meta.IsNoMatchError(err) {
+			return bcode.ErrCloudShellAddonNotEnabled
+		}
+		if apierrors.IsNotFound(err) {
+			return bcode.ErrCloudShellNotInit
+		}
+		return err
+	}
+	return c.deleteCloudShell(ctx, &cloudShell)
+}
+
+// ReapExpiredCloudShells delete the cloud shells which are idle or live longer than the max lifetime
+func (c *cloudShellServiceImpl) ReapExpiredCloudShells(ctx context.Context) error {
+	cloudShells, err := c.listCloudShells(ctx)
+	if err != nil {
+		if meta.IsNoMatchError(err) {
+			return nil
+		}
+		return err
+	}
+	now := time.Now()
+	for i := range cloudShells {
+		cs := &cloudShells[i]
+		expireTime := cloudShellExpireTime(cs, c.Config)
+		if expireTime == nil || now.Before(*expireTime) {
+			continue
+		}
+		klog.Infof("deleting the expired cloud shell %s of the user %s", cs.Name, cs.Spec.RunAsUser)
+		if err := c.deleteCloudShell(ctx, cs); err != nil {
+			klog.Errorf("failed to delete the expired cloud shell %s: %s", cs.Name, err.Error())
+		}
+	}
+	return nil
+}
+
+// checkCloudShellLimit check the number of the active cloud shells, the expired ones are not counted
+func (c *cloudShellServiceImpl) checkCloudShellLimit(ctx context.Context) error {
+	if c.Config.MaxSessions <= 0 {
+		return nil
+	}
+	cloudShells, err := c.listCloudShells(ctx)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	active := 0
+	for i := range cloudShells {
+		if expireTime := cloudShellExpireTime(&cloudShells[i], c.Config); expireTime == nil || now.Before(*expireTime) {
+			active++
+		}
+	}
+	if active >= c.Config.MaxSessions {
+		return bcode.ErrCloudShellLimitExceeded
+	}
+	return nil
+}
+
+func (c *cloudShellServiceImpl) listCloudShells(ctx context.Context) ([]v1alpha1.CloudShell, error) {
+	var cloudShells v1alpha1.CloudShellList
+	if err := c.KubeClient.List(ctx, &cloudShells, client.InNamespace(kubevelatypes.DefaultKubeVelaNS), client.MatchingLabels{DefaultLabelKey: "cloudshell"}); err != nil {
+		return nil, err
+	}
+	return cloudShells.Items, nil
+}
+
+// deleteCloudShell delete the cloud shell and the kubeconfig generated for the user
+func (c *cloudShellServiceImpl) deleteCloudShell(ctx context.Context, cloudShell *v1alpha1.CloudShell) error {
+	if err := c.KubeClient.Delete(ctx, cloudShell); err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	if cloudShell.Spec.ConfigmapName == "" {
+		return nil
+	}
+	var cm corev1.ConfigMap
+	cm.Name = cloudShell.Spec.ConfigmapName
+	cm.Namespace = cloudShell.Namespace
+	if err := c.KubeClient.Delete(ctx, &cm); err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+// cloudShellLastActivity returns the last time the cloud shell is accessed, it is the creation time if it is never accessed
+func cloudShellLastActivity(cloudShell *v1alpha1.CloudShell) time.Time {
+	if value, ok := cloudShell.Annotations[AnnotationCloudShellLastActivity]; ok {
+		if last, err := time.Parse(time.RFC3339, value); err == nil {
+			return last
+		}
+	}
+	return cloudShell.CreationTimestamp.Time
+}
+
+// cloudShellExpireTime returns the time the cloud shell expires by the idle timeout or the max lifetime, nil means never
+func cloudShellExpireTime(cloudShell *v1alpha1.CloudShell, cfg config.CloudShellConfig) *time.Time {
+	var expireTime *time.Time
+	if cfg.MaxLifetime > 0 {
+		t := cloudShell.CreationTimestamp.Add(cfg.MaxLifetime)
+		expireTime = &t
+	}
+	if cfg.IdleTimeout > 0 {
+		t := cloudShellLastActivity(cloudShell).Add(cfg.IdleTimeout)
+		if expireTime == nil || t.Before(*expireTime) {
+			expireTime = &t
+		}
+	}
+	return expireTime
+}
+
 // prepareKubeConfig prepare the user's kube config
 func (c *cloudShellServiceImpl) prepareKubeConfig(ctx context.Context) error {
 	var userName string
@@ -295,10 +496,15 @@ func (c *cloudShellServiceImpl) prepareKubeConfig(ctx context.Context) error {
 		cfg.Clusters[k].Server = ServerAddressInCluster
 	}
 	buffer := bytes.NewBuffer(nil)
-	cfg, err = c.GenerateKubeConfig(ctx, cli, cfg, buffer, auth.KubeConfigWithIdentityGenerateOption(auth.Identity{
+	options := []auth.KubeConfigGenerateOption{auth.KubeConfigWithIdentityGenerateOption(auth.Identity{
 		User:   userName,
 		Groups: groups,
-	}))
+	})}
+	if c.Config.MaxLifetime > 0 {
+		// the certificate can't be revoked, so it expires with the cloud shell
+		options = append(options, kubeConfigExpireTimeOption(c.Config.MaxLifetime))
+	}
+	cfg, err = c.GenerateKubeConfig(ctx, cli, cfg, buffer, options...)
 	if err != nil {
 		klog.Errorf("failed to generate the kube config:%s Message: %s", err.Error(), strings.ReplaceAll(buffer.String(), "\n", "\t"))
 		return err
@@ -340,6 +546,20 @@ func (c *cloudShellServiceImpl) prepareKubeConfig(ctx context.Context) error {
 	return c.KubeClient.Create(ctx, &cm)
 }
 
+// kubeConfigExpireTimeOption set the expiration of the X.509 certificate in the generated kubeconfig
+type kubeConfigExpireTimeOption time.Duration
+
+// ApplyToOptions .
+func (opt kubeConfigExpireTimeOption) ApplyToOptions(options *auth.KubeConfigGenerateOptions) {
+	if options.X509 == nil {
+		return
+	}
+	options.X509.ExpireTime = time.Duration(opt)
+	if options.X509.ExpireTime < minKubeConfigExpireTime {
+		options.X509.ExpireTime = minKubeConfigExpireTime
+	}
+}
+
 func makeUserConfigName(userName string) string {
 	return fmt.Sprintf("users-%s-kubeconfig", userName)
 }
@@ -364,14 +584,19 @@ func (c *cloudShellServiceImpl) newCloudShell(ctx context.Context) (*v1alpha1.Cl
 	cs.Labels = map[string]string{
 		DefaultLabelKey: "cloudshell",
 	}
+	cs.Annotations = map[string]string{
+		AnnotationCloudShellLastActivity: time.Now().UTC().Format(time.RFC3339),
+	}
 	cs.Spec.ConfigmapName = makeUserConfigName(userName)
 	cs.Spec.RunAsUser = userName
 	// only one client and exit on disconnection
 	once, _ := strconv.ParseBool(os.Getenv("CLOUDSHELL_ONCE"))
 	cs.Spec.Once = once
 	cs.Spec.Cleanup = true
-	// A cloudshell instance can live for a maximum of 60 minutes.
-	cs.Spec.Ttl = 60 * 60
+	// the reaper deletes the cloud shell after the max lifetime, the ttl makes sure the job is cleaned up as well
+	if c.Config.MaxLifetime > 0 {
+		cs.Spec.Ttl = int32(c.Config.MaxLifetime.Seconds())
+	}
 	cs.Spec.CommandAction = DefaultCloudShellCommand
 	cs.Spec.ExposeMode = v1alpha1.ExposureServiceClusterIP
 	cs.Spec.PathPrefix = DefaultCloudShellPathPrefix
@@ -389,6 +614,22 @@ func checkReadOnly(projectName string, permissions []*model.Permission) bool {
 
 // managePrivilegesForProject grant the privileges for a project
 func (c *cloudShellServiceImpl) managePrivilegesForProject(ctx context.Context, project *apisv1.ProjectBase, readOnly bool) (string, error) {
+	authPDs := c.listPrivilegesForProject(ctx, project, readOnly)
+	groupName := utils.KubeVelaProjectReadGroupPrefix + project.Name
+	if !readOnly {
+		groupName = utils.KubeVelaProjectGroupPrefix + project.Name
+	}
+	identity := &auth.Identity{Groups: []string{groupName}}
+	writer := &bytes.Buffer{}
+	if err := auth.GrantPrivileges(ctx, c.KubeClient, authPDs, identity, writer, auth.WithReplace); err != nil {
+		return "", err
+	}
+	klog.Infof("GrantPrivileges: %s", writer.String())
+	return groupName, nil
+}
+
+// listPrivilegesForProject list the privileges of the namespaces of the targets and the envs of a project
+func (c *cloudShellServiceImpl) listPrivilegesForProject(ctx context.Context, project *apisv1.ProjectBase, readOnly bool) []auth.PrivilegeDescription {
 	projectName := project.Name
 	targets, err := c.TargetService.ListTargets(ctx, 0, 0, projectName)
 	if err != nil {
@@ -396,7 +637,13 @@ func (c *cloudShellServiceImpl) managePrivilegesForProject(ctx context.Context,
 	}
 	var authPDs []auth.PrivilegeDescription
 	for _, t := range targets.Targets {
-		authPDs = append(authPDs, &auth.ScopedPrivilege{Cluster: t.Cluster.ClusterName, Namespace: t.Cluster.Namespace, ReadOnly: readOnly})
+		clusters := []string{t.Cluster.ClusterName}
+		if (*model.ClusterTarget)(t.Cluster).IsDynamic() {
+			clusters = t.ResolvedClusters
+		}
+		for _, cluster := range clusters {
+			authPDs = append(authPDs, &auth.ScopedPrivilege{Cluster: cluster, Namespace: t.Cluster.Namespace, ReadOnly: readOnly})
+		}
 	}
 	envs, err := c.EnvService.ListEnvs(ctx, 0, 0, apisv1.ListEnvOptions{Project: projectName})
 	if err != nil {
@@ -408,23 +655,13 @@ func (c *cloudShellServiceImpl) managePrivilegesForProject(ctx context.Context,
 
 	// The namespace of the environment: Application and WorkflowRun
 	authPDs = append(authPDs, &auth.ApplicationPrivilege{Cluster: kubevelatypes.ClusterLocalName, Namespace: project.Namespace, ReadOnly: readOnly})
-
-	groupName := utils.KubeVelaProjectReadGroupPrefix + projectName
-	if !readOnly {
-		groupName = utils.KubeVelaProjectGroupPrefix + projectName
-	}
-	identity := &auth.Identity{Groups: []string{groupName}}
-	writer := &bytes.Buffer{}
-	if err := auth.GrantPrivileges(ctx, c.KubeClient, authPDs, identity, writer, auth.WithReplace); err != nil {
-		return "", err
-	}
-	klog.Infof("GrantPrivileges: %s", writer.String())
-	return groupName, nil
+	return authPDs
 }
 
 // NewTestCloudShellService returns a test cloudshell service for testing
 func NewTestCloudShellService(ds datastore.DataStore, c client.Client, cfg *rest.Config) CloudShellService {
 	return &cloudShellServiceImpl{
+		Store:          ds,
 		KubeClient:     c,
 		KubeConfig:     cfg,
 		UserService:    NewTestUserService(ds, c),
@@ -0,0 +1,323 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/clientcmd/api"
+	"k8s.io/klog/v2"
+
+	"github.com/kubevela/pkg/util/rand"
+	kubevelatypes "github.com/oam-dev/kubevela/apis/types"
+	"github.com/oam-dev/kubevela/pkg/auth"
+	pkgutils "github.com/oam-dev/kubevela/pkg/utils"
+
+	"github.com/kubevela/velaux/pkg/server/config"
+	"github.com/kubevela/velaux/pkg/server/domain/model"
+	"github.com/kubevela/velaux/pkg/server/infrastructure/datastore"
+	apisv1 "github.com/kubevela/velaux/pkg/server/interfaces/api/dto/v1"
+	"github.com/kubevela/velaux/pkg/server/utils/bcode"
+)
+
+// KubeConfigGroupPrefix the prefix of the user and the group of the issued kubeconfig, the privileges of the kubeconfig are only bound to
+// its own group, so they could be revoked by removing the group from the role bindings.
+const KubeConfigGroupPrefix = "velaux:kubeconfig:"
+
+// IssueKubeConfig issue a short-lived kubeconfig to the current user, it is scoped to the namespaces of the projects the user could access
+func (c *cloudShellServiceImpl) IssueKubeConfig(ctx context.Context, req apisv1.IssueKubeConfigRequest) (*apisv1.IssueKubeConfigResponse, error) {
+	var userName string
+	if user := ctx.Value(&apisv1.CtxKeyUser); user != nil {
+		if u, ok := user.(string); ok {
+			userName = u
+		}
+	}
+	if userName == "" {
+		return nil, bcode.ErrUnauthorized
+	}
+	user, _ := c.UserService.GetUser(ctx, userName)
+	if user == nil {
+		return nil, bcode.ErrUnauthorized
+	}
+	expiration, err := kubeConfigExpiration(req.ExpirationSeconds, c.Config)
+	if err != nil {
+		return nil, err
+	}
+	privileges, err := c.listUserKubePrivileges(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+	id := rand.RandomString(8)
+	record := &model.IssuedKubeConfig{
+		ID:         id,
+		Username:   userName,
+		Group:      KubeConfigGroupPrefix + id,
+		Privileges: privileges,
+		ExpireTime: time.Now().Add(expiration),
+	}
+	if err := c.Store.Add(ctx, record); err != nil {
+		return nil, err
+	}
+
+	identity := &auth.Identity{Groups: []string{record.Group}}
+	writer := &bytes.Buffer{}
+	if err := auth.GrantPrivileges(ctx, c.KubeClient, convertKubePrivileges(privileges), identity, writer); err != nil {
+		c.cleanIssuedKubeConfig(ctx, record)
+		return nil, err
+	}
+	klog.Infof("GrantPrivileges: %s", writer.String())
+
+	kubeConfig, err := c.generateIssuedKubeConfig(ctx, record, expiration)
+	if err != nil {
+		c.cleanIssuedKubeConfig(ctx, record)
+		return nil, err
+	}
+	return &apisv1.IssueKubeConfigResponse{
+		IssuedKubeConfigBase: *convertIssuedKubeConfigModel(record),
+		KubeConfig:           kubeConfig,
+	}, nil
+}
+
+// ListIssuedKubeConfigs list the records of the issued kubeconfigs, all users are listed if the user name is empty
+func (c *cloudShellServiceImpl) ListIssuedKubeConfigs(ctx context.Context, userName string) (*apisv1.ListIssuedKubeConfigResponse, error) {
+	entities, err := c.Store.List(ctx, &model.IssuedKubeConfig{Username: userName}, &datastore.ListOptions{
+		SortBy: []datastore.SortOption{{Key: "createTime", Order: datastore.SortOrderDescending}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	res := &apisv1.ListIssuedKubeConfigResponse{KubeConfigs: []*apisv1.IssuedKubeConfigBase{}}
+	for _, entity := range entities {
+		res.KubeConfigs = append(res.KubeConfigs, convertIssuedKubeConfigModel(entity.(*model.IssuedKubeConfig)))
+	}
+	return res, nil
+}
+
+// RevokeKubeConfig revoke the privileges of the issued kubeconfig before it expires
+func (c *cloudShellServiceImpl) RevokeKubeConfig(ctx context.Context, id string) error {
+	record := &model.IssuedKubeConfig{ID: id}
+	if err := c.Store.Get(ctx, record); err != nil {
+		if errors.Is(err, datastore.ErrRecordNotExist) {
+			return bcode.ErrKubeConfigNotExist
+		}
+		return err
+	}
+	if record.Revoked {
+		return bcode.ErrKubeConfigRevoked
+	}
+	var revoker string
+	if user := ctx.Value(&apisv1.CtxKeyUser); user != nil {
+		if u, ok := user.(string); ok {
+			revoker = u
+		}
+	}
+	return c.revokeIssuedKubeConfig(ctx, record, revoker)
+}
+
+// ReapExpiredKubeConfigs revoke the privileges of the expired kubeconfigs, the certificates could not be used anymore
+// but the role bindings of their groups are left.
+func (c *cloudShellServiceImpl) ReapExpiredKubeConfigs(ctx context.Context) error {
+	entities, err := c.Store.List(ctx, &model.IssuedKubeConfig{}, nil)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	for _, entity := range entities {
+		record := entity.(*model.IssuedKubeConfig)
+		if record.Revoked || now.Before(record.ExpireTime) {
+			continue
+		}
+		if err := c.revokeIssuedKubeConfig(ctx, record, ""); err != nil {
+			klog.Errorf("failed to revoke the privileges of the expired kubeconfig %s: %s", record.ID, err.Error())
+		}
+	}
+	return nil
+}
+
+// cleanIssuedKubeConfig revoke the privileges granted to the kubeconfig failed to issue
+func (c *cloudShellServiceImpl) cleanIssuedKubeConfig(ctx context.Context, record *model.IssuedKubeConfig) {
+	if err := c.revokeIssuedKubeConfig(ctx, record, ""); err != nil {
+		klog.Errorf("failed to revoke the privileges of the kubeconfig %s failed to issue: %s", record.ID, err.Error())
+	}
+}
+
+// revokeIssuedKubeConfig remove the group of the kubeconfig from the role bindings and record the revocation
+func (c *cloudShellServiceImpl) revokeIssuedKubeConfig(ctx context.Context, record *model.IssuedKubeConfig, revoker string) error {
+	identity := &auth.Identity{Groups: []string{record.Group}}
+	writer := &bytes.Buffer{}
+	// revoke the privileges one by one, the revocation stops at the first role binding not found
+	for _, p := range convertKubePrivileges(record.Privileges) {
+		if err := auth.RevokePrivileges(ctx, c.KubeClient, []auth.PrivilegeDescription{p}, identity, writer); err != nil {
+			return err
+		}
+	}
+	klog.Infof("RevokePrivileges: %s", writer.String())
+	revokeTime := time.Now()
+	record.Revoked = true
+	record.RevokeTime = &revokeTime
+	record.Revoker = revoker
+	return c.Store.Put(ctx, record)
+}
+
+// listUserKubePrivileges list the privileges of the namespaces of the projects the user could access,
+// the privileges of a project are read-only if the user could not deploy its applications.
+func (c *cloudShellServiceImpl) listUserKubePrivileges(ctx context.Context, user *model.User) ([]model.KubeConfigPrivilege, error) {
+	projects, err := c.ProjectService.ListUserProjects(ctx, user.Name)
+	if err != nil {
+		return nil, err
+	}
+	var authPDs []auth.PrivilegeDescription
+	if pkgutils.StringsContain(user.UserRoles, model.RoleAdmin) {
+		authPDs = append(authPDs, &auth.ScopedPrivilege{Cluster: kubevelatypes.ClusterLocalName})
+	}
+	for _, p := range projects {
+		permissions, err := c.RBACService.GetUserPermissions(ctx, user, p.Name, false)
+		readOnly := true
+		if err != nil {
+			klog.Errorf("failed to get the user permissions %s", err.Error())
+		} else {
+			readOnly = checkReadOnly(p.Name, permissions)
+		}
+		authPDs = append(authPDs, c.listPrivilegesForProject(ctx, p, readOnly)...)
+	}
+	return convertKubePrivilegesModel(authPDs), nil
+}
+
+// generateIssuedKubeConfig generate the kubeconfig with the identity of the user and the group of the issued kubeconfig
+func (c *cloudShellServiceImpl) generateIssuedKubeConfig(ctx context.Context, record *model.IssuedKubeConfig, expiration time.Duration) (string, error) {
+	caData := c.KubeConfig.CAData
+	if len(caData) == 0 && c.KubeConfig.CAFile != "" {
+		ca, err := os.ReadFile(c.KubeConfig.CAFile)
+		if err != nil {
+			return "", err
+		}
+		caData = ca
+	}
+	server := c.Config.KubeConfigServer
+	if server == "" {
+		server = c.KubeConfig.Host
+	}
+	cfg := &api.Config{Clusters: map[string]*api.Cluster{
+		kubevelatypes.ClusterLocalName: {
+			Server:                   server,
+			CertificateAuthorityData: caData,
+			InsecureSkipTLSVerify:    c.KubeConfig.Insecure,
+		},
+	}}
+	cli, err := kubernetes.NewForConfig(c.KubeConfig)
+	if err != nil {
+		return "", err
+	}
+	buffer := bytes.NewBuffer(nil)
+	cfg, err = c.GenerateKubeConfig(ctx, cli, cfg, buffer, auth.KubeConfigWithIdentityGenerateOption(issuedKubeConfigIdentity(record)), kubeConfigExpireTimeOption(expiration))
+	if err != nil {
+		klog.Errorf("failed to generate the kube config:%s Message: %s", err.Error(), strings.ReplaceAll(buffer.String(), "\n", "\t"))
+		return "", err
+	}
+	bs, err := clientcmd.Write(*cfg)
+	if err != nil {
+		return "", err
+	}
+	return string(bs), nil
+}
+
+// issuedKubeConfigIdentity returns the identity in the certificate of the issued kubeconfig. The user is namespaced by the ID
+// of the kubeconfig instead of the VelaUX user name, so it can't collide with the users of the cluster and their role bindings.
+func issuedKubeConfigIdentity(record *model.IssuedKubeConfig) auth.Identity {
+	return auth.Identity{
+		User:   KubeConfigGroupPrefix + record.ID,
+		Groups: []string{record.Group},
+	}
+}
+
+// kubeConfigExpiration returns the expiration of the issued kubeconfig, the default expiration is used if it is not specified
+func kubeConfigExpiration(seconds int64, cfg config.CloudShellConfig) (time.Duration, error) {
+	expiration := cfg.KubeConfigExpiration
+	if seconds > 0 {
+		expiration = time.Duration(seconds) * time.Second
+	}
+	if expiration < minKubeConfigExpireTime || (cfg.KubeConfigMaxExpiration > 0 && expiration > cfg.KubeConfigMaxExpiration) {
+		return 0, bcode.ErrKubeConfigInvalidExpiration.SetMessage(fmt.Sprintf("the expiration must be between %s and %s", minKubeConfigExpireTime, cfg.KubeConfigMaxExpiration))
+	}
+	return expiration, nil
+}
+
+// convertKubePrivilegesModel convert the privileges to record, the duplicated ones are removed
+func convertKubePrivilegesModel(authPDs []auth.PrivilegeDescription) []model.KubeConfigPrivilege {
+	var privileges []model.KubeConfigPrivilege
+	for _, pd := range authPDs {
+		var privilege model.KubeConfigPrivilege
+		switch p := pd.(type) {
+		case *auth.ScopedPrivilege:
+			privilege = model.KubeConfigPrivilege{Type: model.KubeConfigPrivilegeScoped, Cluster: p.Cluster, Namespace: p.Namespace, ReadOnly: p.ReadOnly}
+		case *auth.ApplicationPrivilege:
+			privilege = model.KubeConfigPrivilege{Type: model.KubeConfigPrivilegeApplication, Cluster: p.Cluster, Namespace: p.Namespace, ReadOnly: p.ReadOnly}
+		default:
+			continue
+		}
+		var exist bool
+		for _, existing := range privileges {
+			if existing == privilege {
+				exist = true
+				break
+			}
+		}
+		if !exist {
+			privileges = append(privileges, privilege)
+		}
+	}
+	return privileges
+}
+
+func convertKubePrivileges(privileges []model.KubeConfigPrivilege) []auth.PrivilegeDescription {
+	var authPDs []auth.PrivilegeDescription
+	for _, p := range privileges {
+		switch p.Type {
+		case model.KubeConfigPrivilegeScoped:
+			authPDs = append(authPDs, &auth.ScopedPrivilege{Cluster: p.Cluster, Namespace: p.Namespace, ReadOnly: p.ReadOnly})
+		case model.KubeConfigPrivilegeApplication:
+			authPDs = append(authPDs, &auth.ApplicationPrivilege{Cluster: p.Cluster, Namespace: p.Namespace, ReadOnly: p.ReadOnly})
+		}
+	}
+	return authPDs
+}
+
+func convertIssuedKubeConfigModel(record *model.IssuedKubeConfig) *apisv1.IssuedKubeConfigBase {
+	base := &apisv1.IssuedKubeConfigBase{
+		ID:         record.ID,
+		Username:   record.Username,
+		Group:      record.Group,
+		Privileges: []apisv1.KubeConfigPrivilege{},
+		CreateTime: record.CreateTime,
+		ExpireTime: record.ExpireTime,
+		Revoked:    record.Revoked,
+		RevokeTime: record.RevokeTime,
+		Revoker:    record.Revoker,
+	}
+	for _, p := range record.Privileges {
+		base.Privileges = append(base.Privileges, apisv1.KubeConfigPrivilege{Type: p.Type, Cluster: p.Cluster, Namespace: p.Namespace, ReadOnly: p.ReadOnly})
+	}
+	return base
+}
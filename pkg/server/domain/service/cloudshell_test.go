@@ -31,6 +31,8 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
 	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/yaml"
 
@@ -38,6 +40,7 @@ import (
 	"github.com/oam-dev/kubevela/pkg/auth"
 	pkgutils "github.com/oam-dev/kubevela/pkg/utils"
 
+	"github.com/kubevela/velaux/pkg/server/config"
 	"github.com/kubevela/velaux/pkg/server/domain/model"
 	apisv1 "github.com/kubevela/velaux/pkg/server/interfaces/api/dto/v1"
 	"github.com/kubevela/velaux/pkg/server/utils"
@@ -192,7 +195,85 @@ var _ = Describe("Test cloudshell service function", func() {
 		Expect(err).Should(BeNil())
 		Expect(endpoint).Should(Equal("10.10.1.1:8765"))
 
+		By("Test list the cloud shell sessions")
+		sessions, err := cloudShellService.ListCloudShellSessions(ctx)
+		Expect(err).Should(BeNil())
+		Expect(len(sessions.Sessions)).Should(Equal(1))
+		Expect(sessions.Sessions[0].User).Should(Equal("test"))
+
+		By("Test the limit of the cloud shells")
+		cloudShellService.Config.MaxSessions = 1
+		Expect(cloudShellService.checkCloudShellLimit(ctx)).Should(Equal(bcode.ErrCloudShellLimitExceeded))
+		cloudShellService.Config.MaxSessions = 0
+
 		By("Test destroy cloud shell")
 		Expect(cloudShellService.Destroy(ctx)).Should(BeNil())
+		var cm corev1.ConfigMap
+		err = k8sClient.Get(ctx, types.NamespacedName{Namespace: kubevelatypes.DefaultKubeVelaNS, Name: makeUserConfigName("test")}, &cm)
+		Expect(apierrors.IsNotFound(err)).Should(BeTrue())
+	})
+})
+
+var _ = Describe("Test the expiration of the cloud shell", func() {
+	It("Test calculating the expire time", func() {
+		created := time.Now().Add(-time.Minute * 40)
+		cs := &v1alpha1.CloudShell{}
+		cs.CreationTimestamp = metav1.NewTime(created)
+		Expect(cloudShellLastActivity(cs).Equal(created)).Should(BeTrue())
+		Expect(cloudShellExpireTime(cs, config.CloudShellConfig{})).Should(BeNil())
+
+		cfg := config.CloudShellConfig{IdleTimeout: time.Minute * 30, MaxLifetime: time.Hour}
+		Expect(cloudShellExpireTime(cs, cfg).Equal(created.Add(time.Minute * 30))).Should(BeTrue())
+
+		lastActivity := time.Now().Add(-time.Minute).UTC().Truncate(time.Second)
+		cs.Annotations = map[string]string{AnnotationCloudShellLastActivity: lastActivity.Format(time.RFC3339)}
+		Expect(cloudShellLastActivity(cs).Equal(lastActivity)).Should(BeTrue())
+		Expect(cloudShellExpireTime(cs, cfg).Equal(created.Add(time.Hour))).Should(BeTrue())
+		Expect(cloudShellExpireTime(cs, config.CloudShellConfig{IdleTimeout: time.Minute * 30}).Equal(lastActivity.Add(time.Minute * 30))).Should(BeTrue())
+	})
+
+	It("Test the expiration of the kubeconfig", func() {
+		options := &auth.KubeConfigGenerateOptions{X509: &auth.KubeConfigGenerateX509Options{ExpireTime: auth.DefaultExpireTime}}
+		kubeConfigExpireTimeOption(time.Hour).ApplyToOptions(options)
+		Expect(options.X509.ExpireTime).Should(Equal(time.Hour))
+		kubeConfigExpireTimeOption(time.Minute).ApplyToOptions(options)
+		Expect(options.X509.ExpireTime).Should(Equal(minKubeConfigExpireTime))
+	})
+})
+
+var _ = Describe("Test the kubeconfig issued to the users", func() {
+	It("Test the expiration of the issued kubeconfig", func() {
+		cfg := config.CloudShellConfig{KubeConfigExpiration: time.Hour, KubeConfigMaxExpiration: time.Hour * 24}
+		Expect(kubeConfigExpiration(0, cfg)).Should(Equal(time.Hour))
+		Expect(kubeConfigExpiration(7200, cfg)).Should(Equal(time.Hour * 2))
+		_, err := kubeConfigExpiration(60, cfg)
+		Expect(err).ShouldNot(BeNil())
+		_, err = kubeConfigExpiration(3600*48, cfg)
+		Expect(err).ShouldNot(BeNil())
+	})
+
+	It("Test the identity of the issued kubeconfig", func() {
+		identity := issuedKubeConfigIdentity(&model.IssuedKubeConfig{ID: "abcd1234", Username: "admin", Group: KubeConfigGroupPrefix + "abcd1234"})
+		Expect(identity.User).Should(Equal("velaux:kubeconfig:abcd1234"))
+		Expect(identity.Groups).Should(Equal([]string{"velaux:kubeconfig:abcd1234"}))
+	})
+
+	It("Test converting the privileges", func() {
+		privileges := convertKubePrivilegesModel([]auth.PrivilegeDescription{
+			&auth.ScopedPrivilege{Cluster: "local"},
+			&auth.ScopedPrivilege{Cluster: "cluster-a", Namespace: "default", ReadOnly: true},
+			&auth.ApplicationPrivilege{Cluster: "local", Namespace: "project-a"},
+			&auth.ApplicationPrivilege{Cluster: "local", Namespace: "project-a"},
+		})
+		Expect(privileges).Should(Equal([]model.KubeConfigPrivilege{
+			{Type: model.KubeConfigPrivilegeScoped, Cluster: "local"},
+			{Type: model.KubeConfigPrivilegeScoped, Cluster: "cluster-a", Namespace: "default", ReadOnly: true},
+			{Type: model.KubeConfigPrivilegeApplication, Cluster: "local", Namespace: "project-a"},
+		}))
+		Expect(convertKubePrivileges(privileges)).Should(Equal([]auth.PrivilegeDescription{
+			&auth.ScopedPrivilege{Cluster: "local"},
+			&auth.ScopedPrivilege{Cluster: "cluster-a", Namespace: "default", ReadOnly: true},
+			&auth.ApplicationPrivilege{Cluster: "local", Namespace: "project-a"},
+		}))
 	})
 })
@@ -42,6 +42,7 @@ import (
 	"github.com/oam-dev/kubevela/pkg/utils"
 	"github.com/oam-dev/kubevela/pkg/utils/util"
 
+	"github.com/kubevela/velaux/pkg/server/config"
 	"github.com/kubevela/velaux/pkg/server/domain/model"
 	"github.com/kubevela/velaux/pkg/server/infrastructure/clients"
 	"github.com/kubevela/velaux/pkg/server/infrastructure/datastore"
@@ -66,19 +67,31 @@ type ClusterService interface {
 	GetCloudClusterCreationStatus(context.Context, string, string) (*apis.CreateCloudClusterResponse, error)
 	ListCloudClusterCreation(context.Context, string) (*apis.ListCloudClusterCreationResponse, error)
 	DeleteCloudClusterCreation(context.Context, string, string) (*apis.CreateCloudClusterResponse, error)
+
+	ProbeClusters(ctx context.Context) error
+	GetClusterHealthHistory(ctx context.Context, clusterName string, since time.Time) (*apis.ClusterHealthHistoryResponse, error)
+
+	ListClusterGroups(ctx context.Context) (*apis.ListClusterGroupResponse, error)
+	CreateClusterGroup(ctx context.Context, req apis.CreateClusterGroupRequest) (*apis.DetailClusterGroupResponse, error)
+	GetClusterGroup(ctx context.Context, groupName string) (*apis.DetailClusterGroupResponse, error)
+	UpdateClusterGroup(ctx context.Context, groupName string, req apis.UpdateClusterGroupRequest) (*apis.DetailClusterGroupResponse, error)
+	DeleteClusterGroup(ctx context.Context, groupName string) error
 	Init(ctx context.Context) error
 }
 
 type clusterServiceImpl struct {
-	Store      datastore.DataStore `inject:"datastore"`
-	K8sClient  client.Client       `inject:"kubeClient"`
-	KubeConfig *rest.Config        `inject:"kubeConfig"`
-	caches     *utils2.MemoryCacheStore
+	Store             datastore.DataStore `inject:"datastore"`
+	K8sClient         client.Client       `inject:"kubeClient"`
+	KubeConfig        *rest.Config        `inject:"kubeConfig"`
+	PluginHookService PluginHookService   `inject:""`
+	HealthConfig      config.ClusterHealthConfig
+	caches            *utils2.MemoryCacheStore
+	lastPurgeTime     time.Time
 }
 
 // NewClusterService new cluster service
-func NewClusterService() ClusterService {
-	c := &clusterServiceImpl{caches: utils2.NewMemoryCacheStore(context.Background())}
+func NewClusterService(healthConfig config.ClusterHealthConfig) ClusterService {
+	c := &clusterServiceImpl{caches: utils2.NewMemoryCacheStore(context.Background()), HealthConfig: healthConfig}
 	return c
 }
 
@@ -179,6 +192,7 @@ func (c *clusterServiceImpl) ListKubeClusters(ctx context.Context, query string,
 		var clusterModel *model.Cluster
 		if clusterInfo, exists := clustersInfoMap[cluster.Name]; exists {
 			clusterModel = clusterInfo
+			c.syncClusterLabels(ctx, cluster.DeepCopy(), clusterModel)
 		} else {
 			clusterModel = newClusterModelFromPrismCluster(cluster.DeepCopy())
 		}
@@ -274,6 +288,9 @@ func (c *clusterServiceImpl) createKubeCluster(ctx context.Context, req apis.Cre
 			}
 			return nil, err
 		}
+		if err = c.writeClusterLabels(ctx, cluster.Name, cluster.Labels); err != nil {
+			klog.Errorf("failed to write the labels of the cluster %s: %s", cluster.Name, err.Error())
+		}
 		return newClusterBaseFromCluster(cluster), nil
 	}
 	if req.KubeConfigSecret != "" {
@@ -378,6 +395,9 @@ func (c *clusterServiceImpl) ModifyKubeCluster(ctx context.Context, req apis.Cre
 			return nil, errors.Wrapf(err, "failed to update cluster %s", newCluster.Name)
 		}
 	}
+	if err = c.writeClusterLabels(ctx, newCluster.Name, newCluster.Labels); err != nil {
+		return nil, errors.Wrapf(err, "failed to write the labels of the cluster %s", newCluster.Name)
+	}
 	return newClusterBaseFromCluster(newCluster), nil
 }
 
@@ -461,6 +481,15 @@ func (c *clusterServiceImpl) getClusterResourceInfoFromK8s(ctx context.Context,
 	if cache := c.caches.Get(cacheKey); cache != nil {
 		return cache.(apis.ClusterResourceInfo), nil
 	}
+	clusterResourceInfo, err := c.loadClusterResourceInfo(ctx, clusterName)
+	if err != nil {
+		return apis.ClusterResourceInfo{}, err
+	}
+	c.caches.Put(cacheKey, clusterResourceInfo, time.Minute)
+	return clusterResourceInfo, nil
+}
+
+func (c *clusterServiceImpl) loadClusterResourceInfo(ctx context.Context, clusterName string) (apis.ClusterResourceInfo, error) {
 	clusterInfo, err := multicluster.GetClusterInfo(ctx, c.K8sClient, clusterName)
 	if err != nil {
 		return apis.ClusterResourceInfo{}, err
@@ -474,22 +503,63 @@ func (c *clusterServiceImpl) getClusterResourceInfoFromK8s(ctx context.Context,
 		used.Sub(alloc)
 		return &used
 	}
-	// TODO add support for gpu capacity
-	clusterResourceInfo := apis.ClusterResourceInfo{
+	var gpuCapacity, gpuUsed int64
+	for _, node := range clusterInfo.Nodes.Items {
+		gpuCapacity += sumGPUResources(node.Status.Capacity)
+	}
+	// Listing the pods is expensive, skip it if there is no GPU in the cluster
+	if gpuCapacity > 0 {
+		if gpuUsed, err = c.getGPUUsed(ctx, clusterName); err != nil {
+			klog.Warningf("failed to get the GPU usage of the cluster %s: %s", clusterName, err.Error())
+		}
+	}
+	return apis.ClusterResourceInfo{
 		WorkerNumber:     clusterInfo.WorkerNumber,
 		MasterNumber:     clusterInfo.MasterNumber,
 		MemoryCapacity:   clusterInfo.MemoryCapacity.Value(),
 		CPUCapacity:      clusterInfo.CPUCapacity.Value(),
-		GPUCapacity:      0,
+		GPUCapacity:      gpuCapacity,
 		PodCapacity:      clusterInfo.PodCapacity.Value(),
 		MemoryUsed:       getUsed(clusterInfo.MemoryCapacity, clusterInfo.MemoryAllocatable).Value(),
 		CPUUsed:          getUsed(clusterInfo.CPUCapacity, clusterInfo.CPUAllocatable).Value(),
-		GPUUsed:          0,
+		GPUUsed:          gpuUsed,
 		PodUsed:          getUsed(clusterInfo.PodCapacity, clusterInfo.PodAllocatable).Value(),
 		StorageClassList: storageClassList,
+	}, nil
+}
+
+// getGPUUsed sum the GPU requests of the pods which are scheduled and not terminated
+func (c *clusterServiceImpl) getGPUUsed(ctx context.Context, clusterName string) (int64, error) {
+	pods := &v12.PodList{}
+	if err := c.K8sClient.List(multicluster.ContextWithClusterName(ctx, clusterName), pods); err != nil {
+		return 0, err
 	}
-	c.caches.Put(cacheKey, clusterResourceInfo, time.Minute)
-	return clusterResourceInfo, nil
+	var used int64
+	for _, pod := range pods.Items {
+		if pod.Spec.NodeName == "" || pod.Status.Phase == v12.PodSucceeded || pod.Status.Phase == v12.PodFailed {
+			continue
+		}
+		for _, container := range pod.Spec.Containers {
+			// The requests of the extended resources default to the limits
+			if requested := sumGPUResources(container.Resources.Requests); requested > 0 {
+				used += requested
+			} else {
+				used += sumGPUResources(container.Resources.Limits)
+			}
+		}
+	}
+	return used, nil
+}
+
+// sumGPUResources sum the GPU extended resources, such as nvidia.com/gpu and amd.com/gpu
+func sumGPUResources(resources v12.ResourceList) int64 {
+	var sum int64
+	for name, quantity := range resources {
+		if strings.HasSuffix(string(name), "/gpu") {
+			sum += quantity.Value()
+		}
+	}
+	return sum
 }
 
 func (c *clusterServiceImpl) ListCloudClusters(ctx context.Context, provider string, req apis.AccessKeyRequest, pageNumber int, pageSize int) (*apis.ListCloudClusterResponse, error) {
@@ -0,0 +1,208 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"context"
+	"reflect"
+	"strings"
+
+	"github.com/pkg/errors"
+	"k8s.io/klog/v2"
+
+	prismclusterv1alpha1 "github.com/kubevela/prism/pkg/apis/cluster/v1alpha1"
+
+	"github.com/oam-dev/kubevela/pkg/multicluster"
+
+	"github.com/kubevela/velaux/pkg/server/domain/model"
+	"github.com/kubevela/velaux/pkg/server/domain/repository"
+	"github.com/kubevela/velaux/pkg/server/infrastructure/datastore"
+	apis "github.com/kubevela/velaux/pkg/server/interfaces/api/dto/v1"
+	"github.com/kubevela/velaux/pkg/server/utils/bcode"
+)
+
+// clusterGatewayLabelPrefix the prefix of the labels managed by the cluster gateway, they are not the labels of the users
+const clusterGatewayLabelPrefix = "cluster.core.oam.dev/"
+
+// clusterLabelsFromPrism returns the labels of the users in the prism cluster
+func clusterLabelsFromPrism(cluster *prismclusterv1alpha1.Cluster) map[string]string {
+	labels := map[string]string{}
+	for key, value := range cluster.Labels {
+		if !strings.HasPrefix(key, clusterGatewayLabelPrefix) {
+			labels[key] = value
+		}
+	}
+	return labels
+}
+
+// syncClusterLabels keep the labels of the cluster in the datastore same as the prism cluster
+func (c *clusterServiceImpl) syncClusterLabels(ctx context.Context, cluster *prismclusterv1alpha1.Cluster, clusterModel *model.Cluster) {
+	// the local cluster has no cluster secret, the labels are only stored in the datastore
+	if cluster.Name == multicluster.ClusterLocalName {
+		return
+	}
+	labels := clusterLabelsFromPrism(cluster)
+	if len(labels) == 0 && len(clusterModel.Labels) == 0 || reflect.DeepEqual(labels, clusterModel.Labels) {
+		return
+	}
+	clusterModel.Labels = labels
+	if err := c.Store.Put(ctx, clusterModel); err != nil {
+		klog.Errorf("failed to sync the labels of the cluster %s: %s", cluster.Name, err.Error())
+	}
+}
+
+// writeClusterLabels write the labels to the cluster secret, so the clusters could be selected by the labels
+func (c *clusterServiceImpl) writeClusterLabels(ctx context.Context, clusterName string, labels map[string]string) error {
+	if clusterName == multicluster.ClusterLocalName {
+		return nil
+	}
+	vc, err := multicluster.GetVirtualCluster(ctx, c.K8sClient, clusterName)
+	if err != nil {
+		return err
+	}
+	newLabels := map[string]string{}
+	for key, value := range vc.Object.GetLabels() {
+		if strings.HasPrefix(key, clusterGatewayLabelPrefix) {
+			newLabels[key] = value
+		}
+	}
+	for key, value := range labels {
+		if !strings.HasPrefix(key, clusterGatewayLabelPrefix) {
+			newLabels[key] = value
+		}
+	}
+	if reflect.DeepEqual(newLabels, vc.Object.GetLabels()) {
+		return nil
+	}
+	vc.Object.SetLabels(newLabels)
+	return c.K8sClient.Update(ctx, vc.Object)
+}
+
+// ListClusterGroups list all cluster groups
+func (c *clusterServiceImpl) ListClusterGroups(ctx context.Context) (*apis.ListClusterGroupResponse, error) {
+	entities, err := c.Store.List(ctx, &model.ClusterGroup{}, &datastore.ListOptions{
+		SortBy: []datastore.SortOption{{Key: "createTime", Order: datastore.SortOrderDescending}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	resp := &apis.ListClusterGroupResponse{ClusterGroups: []apis.ClusterGroupBase{}}
+	for _, entity := range entities {
+		resp.ClusterGroups = append(resp.ClusterGroups, *convertClusterGroupModel(entity.(*model.ClusterGroup)))
+	}
+	return resp, nil
+}
+
+// CreateClusterGroup create a cluster group
+func (c *clusterServiceImpl) CreateClusterGroup(ctx context.Context, req apis.CreateClusterGroupRequest) (*apis.DetailClusterGroupResponse, error) {
+	if len(req.Clusters) == 0 && len(req.LabelSelector) == 0 {
+		return nil, bcode.ErrClusterGroupEmptySelector
+	}
+	group := &model.ClusterGroup{
+		Name:          req.Name,
+		Alias:         req.Alias,
+		Description:   req.Description,
+		Clusters:      req.Clusters,
+		LabelSelector: req.LabelSelector,
+	}
+	if err := c.Store.Add(ctx, group); err != nil {
+		if errors.Is(err, datastore.ErrRecordExist) {
+			return nil, bcode.ErrClusterGroupExist
+		}
+		return nil, err
+	}
+	return c.detailClusterGroup(ctx, group)
+}
+
+// GetClusterGroup get the cluster group and resolve the clusters in it
+func (c *clusterServiceImpl) GetClusterGroup(ctx context.Context, groupName string) (*apis.DetailClusterGroupResponse, error) {
+	group, err := repository.GetClusterGroup(ctx, c.Store, groupName)
+	if err != nil {
+		return nil, err
+	}
+	return c.detailClusterGroup(ctx, group)
+}
+
+// UpdateClusterGroup update the cluster group, the targets selecting the group resolve the new clusters when rendering the applications
+func (c *clusterServiceImpl) UpdateClusterGroup(ctx context.Context, groupName string, req apis.UpdateClusterGroupRequest) (*apis.DetailClusterGroupResponse, error) {
+	if len(req.Clusters) == 0 && len(req.LabelSelector) == 0 {
+		return nil, bcode.ErrClusterGroupEmptySelector
+	}
+	group, err := repository.GetClusterGroup(ctx, c.Store, groupName)
+	if err != nil {
+		return nil, err
+	}
+	group.Alias = req.Alias
+	group.Description = req.Description
+	group.Clusters = req.Clusters
+	group.LabelSelector = req.LabelSelector
+	if err := c.Store.Put(ctx, group); err != nil {
+		return nil, err
+	}
+	return c.detailClusterGroup(ctx, group)
+}
+
+// DeleteClusterGroup delete the cluster group, the group used by the targets can't be deleted
+func (c *clusterServiceImpl) DeleteClusterGroup(ctx context.Context, groupName string) error {
+	group, err := repository.GetClusterGroup(ctx, c.Store, groupName)
+	if err != nil {
+		return err
+	}
+	targets, err := c.Store.List(ctx, &model.Target{}, nil)
+	if err != nil {
+		return err
+	}
+	for _, entity := range targets {
+		target := entity.(*model.Target)
+		if target.Cluster != nil && target.Cluster.ClusterGroup == groupName {
+			return bcode.ErrClusterGroupInUse
+		}
+	}
+	if err := c.Store.Delete(ctx, group); err != nil {
+		if errors.Is(err, datastore.ErrRecordNotExist) {
+			return bcode.ErrClusterGroupNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+func (c *clusterServiceImpl) detailClusterGroup(ctx context.Context, group *model.ClusterGroup) (*apis.DetailClusterGroupResponse, error) {
+	clusters, err := repository.ResolveClusterGroup(ctx, c.K8sClient, group)
+	if err != nil {
+		return nil, err
+	}
+	if clusters == nil {
+		clusters = []string{}
+	}
+	return &apis.DetailClusterGroupResponse{
+		ClusterGroupBase: *convertClusterGroupModel(group),
+		ResolvedClusters: clusters,
+	}, nil
+}
+
+func convertClusterGroupModel(group *model.ClusterGroup) *apis.ClusterGroupBase {
+	return &apis.ClusterGroupBase{
+		Name:          group.Name,
+		Alias:         group.Alias,
+		Description:   group.Description,
+		Clusters:      group.Clusters,
+		LabelSelector: group.LabelSelector,
+		CreateTime:    group.CreateTime,
+		UpdateTime:    group.UpdateTime,
+	}
+}
@@ -0,0 +1,94 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	prismclusterv1alpha1 "github.com/kubevela/prism/pkg/apis/cluster/v1alpha1"
+
+	"github.com/kubevela/pkg/util/rand"
+
+	"github.com/kubevela/velaux/pkg/server/domain/model"
+	apisv1 "github.com/kubevela/velaux/pkg/server/interfaces/api/dto/v1"
+	"github.com/kubevela/velaux/pkg/server/utils"
+	"github.com/kubevela/velaux/pkg/server/utils/bcode"
+)
+
+var _ = Describe("Test cluster group functions", func() {
+	var service *clusterServiceImpl
+
+	BeforeEach(func() {
+		InitTestEnv("cluster-group-test-" + rand.RandomString(4))
+		service = &clusterServiceImpl{
+			Store:     ds,
+			caches:    utils.NewMemoryCacheStore(context.Background()),
+			K8sClient: k8sClient,
+		}
+	})
+
+	It("Test managing the cluster groups", func() {
+		_, err := service.CreateClusterGroup(context.TODO(), apisv1.CreateClusterGroupRequest{Name: "empty"})
+		Expect(err).Should(Equal(bcode.ErrClusterGroupEmptySelector))
+
+		group, err := service.CreateClusterGroup(context.TODO(), apisv1.CreateClusterGroupRequest{Name: "edge", Clusters: []string{"local", "not-exist"}})
+		Expect(err).Should(BeNil())
+		Expect(group.ResolvedClusters).Should(Equal([]string{"local"}))
+		_, err = service.CreateClusterGroup(context.TODO(), apisv1.CreateClusterGroupRequest{Name: "edge", Clusters: []string{"local"}})
+		Expect(err).Should(Equal(bcode.ErrClusterGroupExist))
+
+		group, err = service.UpdateClusterGroup(context.TODO(), "edge", apisv1.UpdateClusterGroupRequest{LabelSelector: map[string]string{"region": "edge"}})
+		Expect(err).Should(BeNil())
+		Expect(group.ResolvedClusters).Should(BeEmpty())
+
+		groups, err := service.ListClusterGroups(context.TODO())
+		Expect(err).Should(BeNil())
+		Expect(len(groups.ClusterGroups)).Should(Equal(1))
+
+		target := &model.Target{Name: "edge-target", Project: "default", Cluster: &model.ClusterTarget{ClusterGroup: "edge", Namespace: "edge"}}
+		Expect(ds.Add(context.TODO(), target)).Should(Succeed())
+		Expect(service.DeleteClusterGroup(context.TODO(), "edge")).Should(Equal(bcode.ErrClusterGroupInUse))
+		Expect(ds.Delete(context.TODO(), target)).Should(Succeed())
+		Expect(service.DeleteClusterGroup(context.TODO(), "edge")).Should(Succeed())
+		_, err = service.GetClusterGroup(context.TODO(), "edge")
+		Expect(err).Should(Equal(bcode.ErrClusterGroupNotFound))
+	})
+
+	It("Test syncing the labels of the clusters", func() {
+		clusterModel := &model.Cluster{Name: "edge-1", Labels: map[string]string{"region": "center"}}
+		Expect(ds.Add(context.TODO(), clusterModel)).Should(Succeed())
+		cluster := &prismclusterv1alpha1.Cluster{}
+		cluster.Name = "edge-1"
+		cluster.Labels = map[string]string{"region": "edge", prismclusterv1alpha1.LabelClusterControlPlane: "false"}
+		service.syncClusterLabels(context.TODO(), cluster, clusterModel)
+
+		synced, err := service.getClusterFromDataStore(context.TODO(), "edge-1")
+		Expect(err).Should(BeNil())
+		Expect(synced.Labels).Should(Equal(map[string]string{"region": "edge"}))
+	})
+
+	It("Test validating the cluster selector of the target", func() {
+		Expect(validateClusterTarget(nil)).Should(BeNil())
+		Expect(validateClusterTarget(&apisv1.ClusterTarget{ClusterName: "local"})).Should(BeNil())
+		Expect(validateClusterTarget(&apisv1.ClusterTarget{LabelSelector: map[string]string{"region": "edge"}})).Should(BeNil())
+		Expect(validateClusterTarget(&apisv1.ClusterTarget{})).Should(Equal(bcode.ErrTargetInvalidClusterSelector))
+		Expect(validateClusterTarget(&apisv1.ClusterTarget{ClusterName: "local", ClusterGroup: "edge"})).Should(Equal(bcode.ErrTargetInvalidClusterSelector))
+	})
+})
@@ -0,0 +1,258 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+	"k8s.io/klog/v2"
+
+	prismclusterv1alpha1 "github.com/kubevela/prism/pkg/apis/cluster/v1alpha1"
+
+	"github.com/oam-dev/kubevela/pkg/multicluster"
+
+	pluginTypes "github.com/kubevela/velaux/pkg/plugin/types"
+	"github.com/kubevela/velaux/pkg/server/domain/model"
+	"github.com/kubevela/velaux/pkg/server/infrastructure/datastore"
+	apis "github.com/kubevela/velaux/pkg/server/interfaces/api/dto/v1"
+)
+
+const (
+	// clusterHealthPurgeInterval the min interval of purging the health history of the removed clusters
+	clusterHealthPurgeInterval = time.Hour
+	// maxClusterHealthSnapshots the max number of the snapshots kept in the health history of a cluster
+	maxClusterHealthSnapshots = 1024
+)
+
+// ProbeClusters probe the reachability and the resource info of all clusters, and record the snapshots
+func (c *clusterServiceImpl) ProbeClusters(ctx context.Context) error {
+	clusters, err := prismclusterv1alpha1.NewClusterClient(c.K8sClient).List(ctx)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get clusters with ClusterClient")
+	}
+	for _, cluster := range clusters.Items {
+		if err := c.probeCluster(ctx, cluster.Name); err != nil {
+			klog.Errorf("failed to probe the cluster %s: %s", cluster.Name, err.Error())
+		}
+		if clusterModel, err := c.getClusterFromDataStore(ctx, cluster.Name); err == nil {
+			c.syncClusterLabels(ctx, cluster.DeepCopy(), clusterModel)
+		}
+	}
+	if time.Since(c.lastPurgeTime) > clusterHealthPurgeInterval {
+		if err := c.purgeClusterHealthHistories(ctx); err != nil {
+			return err
+		}
+		c.lastPurgeTime = time.Now()
+	}
+	return nil
+}
+
+func (c *clusterServiceImpl) probeCluster(ctx context.Context, clusterName string) error {
+	snapshot := model.NewClusterHealthSnapshot(clusterName, time.Now())
+	info, err := c.loadClusterResourceInfo(ctx, clusterName)
+	if err != nil {
+		snapshot.Reason = fmt.Sprintf("Failed to get cluster resource info: %s", err.Error())
+	} else {
+		c.caches.Put(c.getClusterResourceInfoCacheKey(clusterName), info, time.Minute)
+		snapshot.Reachable = true
+		snapshot.WorkerNumber, snapshot.MasterNumber = info.WorkerNumber, info.MasterNumber
+		snapshot.CPUCapacity, snapshot.CPUUsed = info.CPUCapacity, info.CPUUsed
+		snapshot.MemoryCapacity, snapshot.MemoryUsed = info.MemoryCapacity, info.MemoryUsed
+		snapshot.GPUCapacity, snapshot.GPUUsed = info.GPUCapacity, info.GPUUsed
+		snapshot.PodCapacity, snapshot.PodUsed = info.PodCapacity, info.PodUsed
+		snapshot.CPUPercent = percentage(info.CPUUsed, info.CPUCapacity)
+		snapshot.MemoryPercent = percentage(info.MemoryUsed, info.MemoryCapacity)
+		// The actual usage is more accurate than the reserved resources if the metrics server is installed
+		if metrics, err := multicluster.GetClusterMetricsFromMetricsAPI(ctx, c.K8sClient, clusterName); err == nil {
+			snapshot.CPUPercent = percentage(metrics.CPUUsage.MilliValue(), info.CPUCapacity*1000)
+			snapshot.MemoryPercent = percentage(metrics.MemoryUsage.Value(), info.MemoryCapacity)
+		}
+	}
+
+	history, err := c.getClusterHealthHistory(ctx, clusterName)
+	if err != nil {
+		return err
+	}
+	var previous *model.ClusterHealthSnapshot
+	if latest := history.Latest(); latest != nil {
+		copied := *latest
+		previous = &copied
+	}
+	history.Record(*snapshot, c.HealthConfig.Retention, maxClusterHealthSnapshots)
+	if err := c.Store.Add(ctx, history); err != nil {
+		if !errors.Is(err, datastore.ErrRecordExist) {
+			return err
+		}
+		if err := c.Store.Put(ctx, history); err != nil {
+			return err
+		}
+	}
+	c.updateClusterStatus(ctx, snapshot)
+	c.notifyClusterHealth(ctx, previous, snapshot)
+	return nil
+}
+
+func percentage(used, capacity int64) int {
+	if capacity <= 0 {
+		return 0
+	}
+	return int(used * 100 / capacity)
+}
+
+// getClusterHealthHistory get the health history of the cluster, it is empty if the cluster has not been probed
+func (c *clusterServiceImpl) getClusterHealthHistory(ctx context.Context, clusterName string) (*model.ClusterHealthHistory, error) {
+	history := &model.ClusterHealthHistory{Cluster: clusterName}
+	if err := c.Store.Get(ctx, history); err != nil {
+		if errors.Is(err, datastore.ErrRecordNotExist) {
+			return history, nil
+		}
+		return nil, err
+	}
+	return history, nil
+}
+
+// updateClusterStatus keep the status of the cluster up to date, the cluster not in the datastore is skipped
+func (c *clusterServiceImpl) updateClusterStatus(ctx context.Context, snapshot *model.ClusterHealthSnapshot) {
+	cluster, err := c.getClusterFromDataStore(ctx, snapshot.Cluster)
+	if err != nil {
+		if !errors.Is(err, datastore.ErrRecordNotExist) {
+			klog.Errorf("failed to get the cluster %s: %s", snapshot.Cluster, err.Error())
+		}
+		return
+	}
+	status := model.ClusterStatusHealthy
+	if !snapshot.Reachable {
+		status = model.ClusterStatusUnhealthy
+	}
+	if cluster.Status == status && cluster.Reason == snapshot.Reason {
+		return
+	}
+	cluster.Status, cluster.Reason = status, snapshot.Reason
+	if err := c.Store.Put(ctx, cluster); err != nil {
+		klog.Errorf("failed to update the status of the cluster %s: %s", snapshot.Cluster, err.Error())
+	}
+}
+
+func (c *clusterServiceImpl) overloaded(snapshot *model.ClusterHealthSnapshot) bool {
+	if !snapshot.Reachable {
+		return false
+	}
+	return (c.HealthConfig.CPUThreshold > 0 && snapshot.CPUPercent >= c.HealthConfig.CPUThreshold) ||
+		(c.HealthConfig.MemoryThreshold > 0 && snapshot.MemoryPercent >= c.HealthConfig.MemoryThreshold)
+}
+
+// notifyClusterHealth notify the plugin hooks when the reachability changes or the cluster becomes overloaded
+func (c *clusterServiceImpl) notifyClusterHealth(ctx context.Context, previous, current *model.ClusterHealthSnapshot) {
+	if c.PluginHookService == nil {
+		return
+	}
+	var events []pluginTypes.HookEvent
+	switch {
+	case !current.Reachable && (previous == nil || previous.Reachable):
+		events = append(events, pluginTypes.HookEventClusterUnreachable)
+	case current.Reachable && previous != nil && !previous.Reachable:
+		events = append(events, pluginTypes.HookEventClusterRecovered)
+	}
+	if c.overloaded(current) && (previous == nil || !c.overloaded(previous)) {
+		events = append(events, pluginTypes.HookEventClusterOverloaded)
+	}
+	for _, event := range events {
+		payload := NewPluginHookPayload(ctx, event)
+		payload.ClusterHealth = convertClusterHealthSnapshot(current)
+		c.PluginHookService.Notify(ctx, event, payload)
+	}
+}
+
+// purgeClusterHealthHistories delete the health history of the clusters which are not probed in the retention,
+// the history of the probed clusters is bounded when recording the snapshots.
+func (c *clusterServiceImpl) purgeClusterHealthHistories(ctx context.Context) error {
+	if c.HealthConfig.Retention <= 0 {
+		return nil
+	}
+	entities, err := c.Store.List(ctx, &model.ClusterHealthHistory{}, nil)
+	if err != nil {
+		return err
+	}
+	for _, entity := range entities {
+		history := entity.(*model.ClusterHealthHistory)
+		if latest := history.Latest(); latest != nil && time.Since(latest.ProbeTime) <= c.HealthConfig.Retention {
+			continue
+		}
+		if err := c.Store.Delete(ctx, history); err != nil && !errors.Is(err, datastore.ErrRecordNotExist) {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetClusterHealthHistory list the snapshots of the cluster health since the time, and summarize the outages
+func (c *clusterServiceImpl) GetClusterHealthHistory(ctx context.Context, clusterName string, since time.Time) (*apis.ClusterHealthHistoryResponse, error) {
+	history, err := c.getClusterHealthHistory(ctx, clusterName)
+	if err != nil {
+		return nil, err
+	}
+	// The snapshots are ordered by the probe time, skip the ones before the time
+	start := sort.Search(len(history.Snapshots), func(i int) bool {
+		return !history.Snapshots[i].ProbeTime.Before(since)
+	})
+	resp := &apis.ClusterHealthHistoryResponse{Snapshots: []apis.ClusterHealthSnapshot{}, Outages: []apis.ClusterOutage{}}
+	var outage *apis.ClusterOutage
+	for i := range history.Snapshots[start:] {
+		snapshot := &history.Snapshots[start+i]
+		resp.Snapshots = append(resp.Snapshots, *convertClusterHealthSnapshot(snapshot))
+		switch {
+		case !snapshot.Reachable && outage == nil:
+			outage = &apis.ClusterOutage{StartTime: snapshot.ProbeTime, Reason: snapshot.Reason}
+		case snapshot.Reachable && outage != nil:
+			endTime := snapshot.ProbeTime
+			outage.EndTime = &endTime
+			resp.Outages = append(resp.Outages, *outage)
+			outage = nil
+		}
+	}
+	if outage != nil {
+		resp.Outages = append(resp.Outages, *outage)
+	}
+	return resp, nil
+}
+
+func convertClusterHealthSnapshot(snapshot *model.ClusterHealthSnapshot) *apis.ClusterHealthSnapshot {
+	return &apis.ClusterHealthSnapshot{
+		Cluster:   snapshot.Cluster,
+		Reachable: snapshot.Reachable,
+		Reason:    snapshot.Reason,
+		ResourceInfo: apis.ClusterResourceInfo{
+			WorkerNumber:   snapshot.WorkerNumber,
+			MasterNumber:   snapshot.MasterNumber,
+			MemoryCapacity: snapshot.MemoryCapacity,
+			CPUCapacity:    snapshot.CPUCapacity,
+			GPUCapacity:    snapshot.GPUCapacity,
+			PodCapacity:    snapshot.PodCapacity,
+			MemoryUsed:     snapshot.MemoryUsed,
+			CPUUsed:        snapshot.CPUUsed,
+			GPUUsed:        snapshot.GPUUsed,
+			PodUsed:        snapshot.PodUsed,
+		},
+		CPUPercent:    snapshot.CPUPercent,
+		MemoryPercent: snapshot.MemoryPercent,
+		ProbeTime:     snapshot.ProbeTime,
+	}
+}
@@ -0,0 +1,117 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	"github.com/kubevela/pkg/util/rand"
+
+	"github.com/kubevela/velaux/pkg/server/config"
+	"github.com/kubevela/velaux/pkg/server/domain/model"
+	"github.com/kubevela/velaux/pkg/server/utils"
+)
+
+var _ = Describe("Test cluster health functions", func() {
+	var service *clusterServiceImpl
+
+	BeforeEach(func() {
+		InitTestEnv("cluster-health-test-" + rand.RandomString(4))
+		service = &clusterServiceImpl{
+			Store:        ds,
+			caches:       utils.NewMemoryCacheStore(context.Background()),
+			K8sClient:    k8sClient,
+			HealthConfig: config.ClusterHealthConfig{Retention: time.Hour, CPUThreshold: 90, MemoryThreshold: 90},
+		}
+	})
+
+	It("Test probing the clusters and listing the history", func() {
+		Expect(ds.Add(context.TODO(), &model.Cluster{Name: "local"})).Should(Succeed())
+		expired := model.NewClusterHealthSnapshot("local", time.Now().Add(-time.Hour*2))
+		expired.Reachable = true
+		outage := model.NewClusterHealthSnapshot("local", time.Now().Add(-time.Minute*10))
+		outage.Reason = "connection refused"
+		Expect(ds.Add(context.TODO(), &model.ClusterHealthHistory{
+			Cluster:   "local",
+			Snapshots: []model.ClusterHealthSnapshot{*expired, *outage},
+		})).Should(Succeed())
+
+		Expect(service.ProbeClusters(context.TODO())).Should(Succeed())
+		cluster, err := service.getClusterFromDataStore(context.TODO(), "local")
+		Expect(err).Should(BeNil())
+		Expect(cluster.Status).Should(Equal(model.ClusterStatusHealthy))
+
+		history, err := service.GetClusterHealthHistory(context.TODO(), "local", time.Now().Add(-time.Hour*24))
+		Expect(err).Should(BeNil())
+		Expect(len(history.Snapshots)).Should(Equal(2))
+		Expect(history.Snapshots[0].Reachable).Should(BeFalse())
+		Expect(history.Snapshots[1].Reachable).Should(BeTrue())
+		Expect(len(history.Outages)).Should(Equal(1))
+		Expect(history.Outages[0].Reason).Should(Equal("connection refused"))
+		Expect(history.Outages[0].EndTime).ShouldNot(BeNil())
+
+		history, err = service.GetClusterHealthHistory(context.TODO(), "local", time.Now().Add(-time.Minute))
+		Expect(err).Should(BeNil())
+		Expect(len(history.Snapshots)).Should(Equal(1))
+		Expect(len(history.Outages)).Should(Equal(0))
+	})
+
+	It("Test bounding the health history", func() {
+		history := &model.ClusterHealthHistory{Cluster: "local"}
+		now := time.Now()
+		for i := 5; i >= 0; i-- {
+			history.Record(*model.NewClusterHealthSnapshot("local", now.Add(-time.Minute*time.Duration(i))), time.Minute*10, 4)
+		}
+		Expect(len(history.Snapshots)).Should(Equal(4))
+		Expect(history.Latest().ProbeTime).Should(Equal(now))
+		history.Record(*model.NewClusterHealthSnapshot("local", now.Add(time.Second*570)), time.Minute*10, 4)
+		Expect(len(history.Snapshots)).Should(Equal(2))
+		Expect(history.Snapshots[0].ProbeTime).Should(Equal(now))
+
+		Expect(ds.Add(context.TODO(), &model.ClusterHealthHistory{
+			Cluster:   "removed",
+			Snapshots: []model.ClusterHealthSnapshot{*model.NewClusterHealthSnapshot("removed", now.Add(-time.Hour*2))},
+		})).Should(Succeed())
+		Expect(ds.Add(context.TODO(), history)).Should(Succeed())
+		Expect(service.purgeClusterHealthHistories(context.TODO())).Should(Succeed())
+		histories, err := ds.List(context.TODO(), &model.ClusterHealthHistory{}, nil)
+		Expect(err).Should(BeNil())
+		Expect(len(histories)).Should(Equal(1))
+	})
+
+	It("Test detecting the overloaded cluster", func() {
+		Expect(service.overloaded(&model.ClusterHealthSnapshot{Reachable: true, CPUPercent: 95})).Should(BeTrue())
+		Expect(service.overloaded(&model.ClusterHealthSnapshot{Reachable: true, MemoryPercent: 90})).Should(BeTrue())
+		Expect(service.overloaded(&model.ClusterHealthSnapshot{Reachable: true, CPUPercent: 50, MemoryPercent: 50})).Should(BeFalse())
+		Expect(service.overloaded(&model.ClusterHealthSnapshot{CPUPercent: 95})).Should(BeFalse())
+	})
+
+	It("Test summing the GPU resources", func() {
+		Expect(sumGPUResources(corev1.ResourceList{
+			"nvidia.com/gpu":     resource.MustParse("4"),
+			"amd.com/gpu":        resource.MustParse("2"),
+			corev1.ResourceCPU:   resource.MustParse("8"),
+			"example.com/device": resource.MustParse("1"),
+		})).Should(Equal(int64(6)))
+	})
+})
@@ -29,6 +29,9 @@ import (
 	"github.com/oam-dev/kubevela/pkg/config"
 	"github.com/oam-dev/kubevela/pkg/utils/apply"
 
+	"github.com/kubevela/velaux/pkg/server/domain/model"
+	"github.com/kubevela/velaux/pkg/server/infrastructure/datastore"
+	"github.com/kubevela/velaux/pkg/server/infrastructure/secretstore"
 	apis "github.com/kubevela/velaux/pkg/server/interfaces/api/dto/v1"
 	"github.com/kubevela/velaux/pkg/server/utils"
 	"github.com/kubevela/velaux/pkg/server/utils/bcode"
@@ -46,18 +49,29 @@ type ConfigService interface {
 	CreateConfigDistribution(ctx context.Context, project string, req apis.CreateConfigDistributionRequest) error
 	DeleteConfigDistribution(ctx context.Context, project, name string) error
 	ListConfigDistributions(ctx context.Context, project string) ([]*config.Distribution, error)
+	ListConfigRevisions(ctx context.Context, project, name string) (*apis.ListConfigRevisionResponse, error)
+	DiffConfigRevisions(ctx context.Context, project, name string, base, target int64) (*apis.ConfigRevisionDiffResponse, error)
+	RollbackConfig(ctx context.Context, project, name string, revision int64) (*apis.Config, error)
+	CheckConfigDistributions(ctx context.Context) error
+	GetConfigDistributionStatus(ctx context.Context, project, name string, refresh bool) (*apis.ConfigDistributionStatusResponse, error)
+	ResyncConfigDistribution(ctx context.Context, project, name string) (*apis.ConfigDistributionStatusResponse, error)
 }
 
 // NewConfigService returns a config use case
-func NewConfigService() ConfigService {
-	return &configServiceImpl{}
+func NewConfigService(revisionLimit int, secretStore secretstore.SecretStore) ConfigService {
+	return &configServiceImpl{RevisionLimit: revisionLimit, SecretStore: secretStore}
 }
 
 type configServiceImpl struct {
-	KubeClient     client.Client    `inject:"kubeClient"`
-	ProjectService ProjectService   `inject:""`
-	Factory        config.Factory   `inject:"configFactory"`
-	Apply          apply.Applicator `inject:"apply"`
+	Store          datastore.DataStore `inject:"datastore"`
+	KubeClient     client.Client       `inject:"kubeClient"`
+	ProjectService ProjectService      `inject:""`
+	Factory        config.Factory      `inject:"configFactory"`
+	Apply          apply.Applicator    `inject:"apply"`
+	// RevisionLimit the max number of the revisions kept for every config
+	RevisionLimit int
+	// SecretStore keeps the sensitive properties of the configs, nil means they are kept in the config secrets
+	SecretStore secretstore.SecretStore
 }
 
 // ListTemplates list the config templates
@@ -134,6 +148,9 @@ func (u *configServiceImpl) CreateConfig(ctx context.Context, project string, re
 		if err != nil {
 			return nil, err
 		}
+		if err := ensureProjectWritable(ctx, u.Store, pro.Name); err != nil {
+			return nil, err
+		}
 		ns = pro.GetNamespace()
 	}
 	exist, err := u.Factory.IsExist(ctx, ns, req.Name)
@@ -151,6 +168,11 @@ func (u *configServiceImpl) CreateConfig(ctx context.Context, project string, re
 	if req.Template.Namespace == "" {
 		req.Template.Namespace = types.DefaultKubeVelaNS
 	}
+	if err := u.storeSensitiveProperties(ctx, config.NamespacedName(req.Template), ns, req.Name, properties); err != nil {
+		return nil, err
+	}
+	// the stored values are removed if the config is failed to create
+	defer u.pruneSensitiveProperties(ctx, ns, req.Name)
 	configItem, err := u.Factory.ParseConfig(ctx, config.NamespacedName(req.Template), config.Metadata{
 		NamespacedName: config.NamespacedName{Name: req.Name, Namespace: ns},
 		Properties:     properties,
@@ -162,9 +184,13 @@ func (u *configServiceImpl) CreateConfig(ctx context.Context, project string, re
 		}
 		return nil, err
 	}
+	markConfigSecretStore(configItem, u.SecretStore)
 	if err := u.Factory.CreateOrUpdateConfig(ctx, configItem, ns); err != nil {
 		return nil, err
 	}
+	if err := u.recordConfigRevision(ctx, nil, configItem, 0); err != nil {
+		klog.Errorf("failed to record the revision of the config %s/%s: %s", ns, req.Name, err.Error())
+	}
 	return convertConfig(project, *configItem), nil
 }
 
@@ -175,6 +201,9 @@ func (u *configServiceImpl) UpdateConfig(ctx context.Context, project string, na
 		if err != nil {
 			return nil, err
 		}
+		if err := ensureProjectWritable(ctx, u.Store, pro.Name); err != nil {
+			return nil, err
+		}
 		ns = pro.GetNamespace()
 	}
 
@@ -193,12 +222,18 @@ func (u *configServiceImpl) UpdateConfig(ctx context.Context, project string, na
 	if err := json.Unmarshal([]byte(req.Properties), &properties); err != nil {
 		return nil, err
 	}
+	if err := u.storeSensitiveProperties(ctx, it.Template.NamespacedName, ns, it.Name, properties); err != nil {
+		return nil, err
+	}
+	// the new values are removed if the config is failed to update, and the values out of the revisions are removed after the update
+	defer u.pruneSensitiveProperties(ctx, ns, it.Name)
 	configItem, err := u.Factory.ParseConfig(ctx,
 		it.Template.NamespacedName,
 		config.Metadata{NamespacedName: config.NamespacedName{Name: it.Name, Namespace: ns}, Alias: req.Alias, Description: req.Description, Properties: properties})
 	if err != nil {
 		return nil, err
 	}
+	markConfigSecretStore(configItem, u.SecretStore)
 	if err := u.Factory.CreateOrUpdateConfig(ctx, configItem, ns); err != nil {
 		if errors.Is(err, config.ErrChangeTemplate) {
 			return nil, bcode.ErrChangeTemplate
@@ -208,6 +243,15 @@ func (u *configServiceImpl) UpdateConfig(ctx context.Context, project string, na
 		}
 		return nil, err
 	}
+	if err := u.recordConfigRevision(ctx, it, configItem, 0); err != nil {
+		klog.Errorf("failed to record the revision of the config %s/%s: %s", ns, name, err.Error())
+	}
+	// the configs referring to the secret store are delivered by the apiserver, deliver the changes to the targets
+	if configItem.Secret.Annotations[AnnotationConfigSecretStore] != "" {
+		if err := u.redistributeConfig(ctx, project, ns, name); err != nil {
+			klog.Errorf("failed to deliver the config %s/%s to the targets: %s", ns, name, err.Error())
+		}
+	}
 	return convertConfig(project, *configItem), nil
 }
 
@@ -271,16 +315,37 @@ func (u *configServiceImpl) CreateConfigDistribution(ctx context.Context, projec
 		}
 	}
 
-	var configs []*config.NamespacedName
+	// the configs referring to the secret store are delivered by the apiserver, the others are delivered by the application of the distribution
+	var configs, externalConfigs []*config.NamespacedName
 	for _, t := range req.Configs {
-		if t.Name != "" {
+		if t.Name == "" {
+			continue
+		}
+		namespace := t.Namespace
+		if namespace == "" {
+			namespace = pro.GetNamespace()
+		}
+		external, err := u.isExternalConfig(ctx, namespace, t.Name)
+		if err != nil {
+			return err
+		}
+		if external {
+			externalConfigs = append(externalConfigs, &config.NamespacedName{Namespace: t.Namespace, Name: t.Name})
+		} else {
 			configs = append(configs, &config.NamespacedName{Namespace: t.Namespace, Name: t.Name})
 		}
 	}
-	return u.Factory.CreateOrUpdateDistribution(ctx, pro.GetNamespace(), req.Name, &config.CreateDistributionSpec{
-		Configs: configs,
-		Targets: targets,
-	})
+	if len(configs) != 0 || len(externalConfigs) == 0 {
+		if err := u.Factory.CreateOrUpdateDistribution(ctx, pro.GetNamespace(), req.Name, &config.CreateDistributionSpec{
+			Configs: configs,
+			Targets: targets,
+		}); err != nil {
+			return err
+		}
+	} else if err := u.Factory.DeleteDistribution(ctx, pro.GetNamespace(), req.Name); err != nil && !errors.Is(err, config.ErrNotFoundDistribution) {
+		return err
+	}
+	return u.distributeExternalConfigs(ctx, project, pro.GetNamespace(), req.Name, externalConfigs, targets)
 }
 
 // ListDistributeConfigs list the all distributions
@@ -289,7 +354,19 @@ func (u *configServiceImpl) ListConfigDistributions(ctx context.Context, project
 	if err != nil {
 		return nil, err
 	}
-	return u.Factory.ListDistributions(ctx, pro.GetNamespace())
+	distributions, err := u.Factory.ListDistributions(ctx, pro.GetNamespace())
+	if err != nil {
+		return nil, err
+	}
+	entities, err := u.Store.List(ctx, &model.ExternalConfigDistribution{Project: project}, nil)
+	if err != nil {
+		return nil, err
+	}
+	var records []*model.ExternalConfigDistribution
+	for _, entity := range entities {
+		records = append(records, entity.(*model.ExternalConfigDistribution))
+	}
+	return mergeExternalConfigDistributions(distributions, records), nil
 }
 
 // DeleteConfigDistribution delete a distribution
@@ -298,13 +375,19 @@ func (u *configServiceImpl) DeleteConfigDistribution(ctx context.Context, projec
 	if err != nil {
 		return err
 	}
+	external, err := u.deleteExternalConfigDistribution(ctx, project, name)
+	if err != nil {
+		return err
+	}
 	if err := u.Factory.DeleteDistribution(ctx, pro.GetNamespace(), name); err != nil {
-		if errors.Is(err, config.ErrNotFoundDistribution) {
+		if !errors.Is(err, config.ErrNotFoundDistribution) {
+			return err
+		}
+		if !external {
 			return bcode.ErrNotFoundDistribution
 		}
-		return err
 	}
-	return nil
+	return u.deleteConfigDistributionStatus(ctx, project, name)
 }
 
 func convertConfig(project string, config config.Config) *apis.Config {
@@ -356,5 +439,13 @@ func (u *configServiceImpl) DeleteConfig(ctx context.Context, project, name stri
 		}
 		ns = pro.GetNamespace()
 	}
-	return u.Factory.DeleteConfig(ctx, ns, name)
+	if err := u.Factory.DeleteConfig(ctx, ns, name); err != nil {
+		return err
+	}
+	if u.SecretStore != nil {
+		if err := u.SecretStore.Delete(ctx, configSecretKey(ns, name)); err != nil {
+			return err
+		}
+	}
+	return u.deleteConfigRevisions(ctx, ns, name)
 }
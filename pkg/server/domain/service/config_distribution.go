@@ -0,0 +1,229 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/oam-dev/kubevela/pkg/config"
+	"github.com/oam-dev/kubevela/pkg/multicluster"
+
+	"github.com/kubevela/velaux/pkg/server/domain/model"
+	"github.com/kubevela/velaux/pkg/server/infrastructure/datastore"
+	apis "github.com/kubevela/velaux/pkg/server/interfaces/api/dto/v1"
+	"github.com/kubevela/velaux/pkg/server/utils/bcode"
+)
+
+// CheckConfigDistributions check the configs delivered by the distributions of all projects and record the status
+func (u *configServiceImpl) CheckConfigDistributions(ctx context.Context) error {
+	projects, err := u.ProjectService.ListProjects(ctx, 0, 0)
+	if err != nil {
+		return err
+	}
+	for _, project := range projects.Projects {
+		distributions, err := u.ListConfigDistributions(ctx, project.Name)
+		if err != nil {
+			klog.Errorf("failed to list the distributions of the project %s: %s", project.Name, err.Error())
+			continue
+		}
+		for _, d := range distributions {
+			if _, err := u.checkConfigDistribution(ctx, project.Name, d); err != nil {
+				klog.Errorf("failed to check the distribution %s/%s: %s", project.Name, d.Name, err.Error())
+			}
+		}
+	}
+	return nil
+}
+
+// GetConfigDistributionStatus returns the delivery status of the distribution recorded by the last check,
+// the distribution is checked now if it is required or it is never checked.
+func (u *configServiceImpl) GetConfigDistributionStatus(ctx context.Context, project, name string, refresh bool) (*apis.ConfigDistributionStatusResponse, error) {
+	if !refresh {
+		status := &model.ConfigDistributionStatus{Project: project, Distribution: name}
+		if err := u.Store.Get(ctx, status); err == nil {
+			return convertConfigDistributionStatus(status), nil
+		} else if !errors.Is(err, datastore.ErrRecordNotExist) {
+			return nil, err
+		}
+	}
+	distribution, err := u.getConfigDistribution(ctx, project, name)
+	if err != nil {
+		return nil, err
+	}
+	status, err := u.checkConfigDistribution(ctx, project, distribution)
+	if err != nil {
+		return nil, err
+	}
+	return convertConfigDistributionStatus(status), nil
+}
+
+// ResyncConfigDistribution deliver the configs to the failed or the drifted targets again, the synced targets are not touched.
+// The configs are written to the targets directly with the labels of the distribution, so the status is checked again right after.
+func (u *configServiceImpl) ResyncConfigDistribution(ctx context.Context, project, name string) (*apis.ConfigDistributionStatusResponse, error) {
+	distribution, err := u.getConfigDistribution(ctx, project, name)
+	if err != nil {
+		return nil, err
+	}
+	status, err := u.checkConfigDistribution(ctx, project, distribution)
+	if err != nil {
+		return nil, err
+	}
+	var resynced bool
+	for _, target := range status.Targets {
+		if target.Status == model.ConfigTargetSynced {
+			continue
+		}
+		resynced = true
+		if err := u.resyncConfigTarget(ctx, distribution, target); err != nil {
+			klog.Errorf("failed to resync the config %s to the namespace %s of the cluster %s: %s", target.Config, target.Namespace, target.ClusterName, err.Error())
+		}
+	}
+	if resynced {
+		if status, err = u.checkConfigDistribution(ctx, project, distribution); err != nil {
+			return nil, err
+		}
+	}
+	return convertConfigDistributionStatus(status), nil
+}
+
+func (u *configServiceImpl) getConfigDistribution(ctx context.Context, project, name string) (*config.Distribution, error) {
+	distributions, err := u.ListConfigDistributions(ctx, project)
+	if err != nil {
+		return nil, err
+	}
+	for _, d := range distributions {
+		if d.Name == name {
+			return d, nil
+		}
+	}
+	return nil, bcode.ErrNotFoundDistribution
+}
+
+// checkConfigDistribution compare the content hash of the configs in the targets with the source configs, and record the status
+func (u *configServiceImpl) checkConfigDistribution(ctx context.Context, project string, distribution *config.Distribution) (*model.ConfigDistributionStatus, error) {
+	status := &model.ConfigDistributionStatus{
+		Project:       project,
+		Distribution:  distribution.Name,
+		Targets:       []model.ConfigTargetStatus{},
+		LastCheckTime: time.Now(),
+	}
+	for _, c := range distribution.Configs {
+		namespace := c.Namespace
+		if namespace == "" {
+			namespace = distribution.Namespace
+		}
+		source, sourceErr := u.getConfigSource(ctx, namespace, c.Name)
+		for _, t := range distribution.Targets {
+			target := model.ConfigTargetStatus{Config: c.Name, ClusterName: t.ClusterName, Namespace: t.Namespace}
+			if sourceErr != nil {
+				target.Status = model.ConfigTargetFailed
+				target.Reason = fmt.Sprintf("failed to get the source config: %s", sourceErr.Error())
+				status.Targets = append(status.Targets, target)
+				continue
+			}
+			target.SourceHash = configContentHash(source)
+			var delivered corev1.Secret
+			err := u.KubeClient.Get(multicluster.ContextWithClusterName(ctx, t.ClusterName), client.ObjectKey{Namespace: t.Namespace, Name: c.Name}, &delivered)
+			switch {
+			case apierrors.IsNotFound(err):
+				target.Status = model.ConfigTargetFailed
+				target.Reason = fmt.Sprintf("the config is not found in the target, the phase of the distribution is %s", distribution.Status.Phase)
+			case err != nil:
+				target.Status = model.ConfigTargetFailed
+				target.Reason = err.Error()
+			default:
+				target.TargetHash = configContentHash(&delivered)
+				target.Status = model.ConfigTargetSynced
+				if target.TargetHash != target.SourceHash {
+					target.Status = model.ConfigTargetDrifted
+					target.Reason = "the content of the config is modified in the target"
+				}
+			}
+			status.Targets = append(status.Targets, target)
+		}
+	}
+	if err := u.Store.Add(ctx, status); err != nil {
+		if !errors.Is(err, datastore.ErrRecordExist) {
+			return nil, err
+		}
+		if err := u.Store.Put(ctx, status); err != nil {
+			return nil, err
+		}
+	}
+	return status, nil
+}
+
+// resyncConfigTarget write the content of the source config to the target, the values kept in the secret store are resolved
+func (u *configServiceImpl) resyncConfigTarget(ctx context.Context, distribution *config.Distribution, target model.ConfigTargetStatus) error {
+	var namespace string
+	for _, c := range distribution.Configs {
+		if c.Name == target.Config {
+			namespace = c.Namespace
+		}
+	}
+	if namespace == "" {
+		namespace = distribution.Namespace
+	}
+	source, err := u.getConfigSource(ctx, namespace, target.Config)
+	if err != nil {
+		return err
+	}
+	return u.deliverConfigSecret(ctx, distribution.Name, distribution.Namespace, source, target.ClusterName, target.Namespace)
+}
+
+func (u *configServiceImpl) deleteConfigDistributionStatus(ctx context.Context, project, name string) error {
+	if err := u.Store.Delete(ctx, &model.ConfigDistributionStatus{Project: project, Distribution: name}); err != nil && !errors.Is(err, datastore.ErrRecordNotExist) {
+		return err
+	}
+	return nil
+}
+
+// configContentHash returns the hash of the data of the config secret, the metadata is changed by the distribution so it is ignored
+func configContentHash(secret *corev1.Secret) string {
+	keys := make([]string, 0, len(secret.Data))
+	for key := range secret.Data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	hash := sha256.New()
+	for _, key := range keys {
+		hash.Write([]byte(key))
+		hash.Write([]byte{0})
+		hash.Write(secret.Data[key])
+		hash.Write([]byte{0})
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+func convertConfigDistributionStatus(status *model.ConfigDistributionStatus) *apis.ConfigDistributionStatusResponse {
+	return &apis.ConfigDistributionStatusResponse{
+		Name:          status.Distribution,
+		Targets:       status.Targets,
+		LastCheckTime: status.LastCheckTime,
+	}
+}
@@ -0,0 +1,466 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/oam-dev/kubevela/apis/types"
+	"github.com/oam-dev/kubevela/pkg/config"
+
+	apis "github.com/kubevela/velaux/pkg/server/interfaces/api/dto/v1"
+	"github.com/kubevela/velaux/pkg/server/utils/bcode"
+)
+
+const (
+	// LabelConfigRevisionOf the label marks the secret is a revision of the config
+	LabelConfigRevisionOf = "ux.oam.dev/config-revision-of"
+	// LabelConfigRevision the label records the revision number of the config revision
+	LabelConfigRevision = "ux.oam.dev/config-revision"
+
+	configRevisionDataKey = "revision"
+	configRedactedValue   = "******"
+)
+
+// configSensitiveKeys the properties whose path contains these words are redacted in the diff
+var configSensitiveKeys = []string{"password", "passwd", "token", "secret", "key", "credential", "auth", "cert"}
+
+// configRevision the snapshot of the config, it is stored in a secret in the namespace of the config,
+// because the properties may include the credentials.
+type configRevision struct {
+	Revision     int64                       `json:"revision"`
+	Template     config.NamespacedName       `json:"template"`
+	Alias        string                      `json:"alias,omitempty"`
+	Description  string                      `json:"description,omitempty"`
+	Properties   map[string]interface{}      `json:"properties"`
+	Operator     string                      `json:"operator,omitempty"`
+	CreateTime   time.Time                   `json:"createTime"`
+	RollbackFrom int64                       `json:"rollbackFrom,omitempty"`
+	Changes      []apis.ConfigPropertyChange `json:"changes"`
+}
+
+// ListConfigRevisions list the revisions of the config, the latest one first
+func (u *configServiceImpl) ListConfigRevisions(ctx context.Context, project, name string) (*apis.ListConfigRevisionResponse, error) {
+	ns, err := u.configNamespace(ctx, project)
+	if err != nil {
+		return nil, err
+	}
+	revisions, err := u.listConfigRevisions(ctx, ns, name)
+	if err != nil {
+		return nil, err
+	}
+	res := &apis.ListConfigRevisionResponse{Revisions: []*apis.ConfigRevision{}}
+	for i := len(revisions) - 1; i >= 0; i-- {
+		r := revisions[i]
+		res.Revisions = append(res.Revisions, &apis.ConfigRevision{
+			Revision:     r.Revision,
+			Alias:        r.Alias,
+			Description:  r.Description,
+			Operator:     r.Operator,
+			CreateTime:   r.CreateTime,
+			RollbackFrom: r.RollbackFrom,
+			Changes:      r.Changes,
+		})
+	}
+	return res, nil
+}
+
+// DiffConfigRevisions compare the properties of two revisions of the config
+func (u *configServiceImpl) DiffConfigRevisions(ctx context.Context, project, name string, base, target int64) (*apis.ConfigRevisionDiffResponse, error) {
+	ns, err := u.configNamespace(ctx, project)
+	if err != nil {
+		return nil, err
+	}
+	baseRevision, err := u.getConfigRevision(ctx, ns, name, base)
+	if err != nil {
+		return nil, err
+	}
+	targetRevision, err := u.getConfigRevision(ctx, ns, name, target)
+	if err != nil {
+		return nil, err
+	}
+	schema, err := u.loadConfigSchema(ctx, targetRevision.Template)
+	if err != nil {
+		return nil, err
+	}
+	return &apis.ConfigRevisionDiffResponse{
+		Base:    base,
+		Target:  target,
+		Changes: diffConfigProperties(schema, baseRevision.Properties, targetRevision.Properties),
+	}, nil
+}
+
+// RollbackConfig restore the properties of the config to a revision, the distributions of the config are
+// applied again so the targets get the restored value.
+func (u *configServiceImpl) RollbackConfig(ctx context.Context, project, name string, revision int64) (*apis.Config, error) {
+	ns, err := u.configNamespace(ctx, project)
+	if err != nil {
+		return nil, err
+	}
+	it, err := u.Factory.GetConfig(ctx, ns, name, false)
+	if err != nil {
+		if errors.Is(err, config.ErrSensitiveConfig) {
+			return nil, bcode.ErrSensitiveConfig
+		}
+		if errors.Is(err, config.ErrConfigNotFound) {
+			return nil, bcode.ErrConfigNotFound
+		}
+		return nil, err
+	}
+	target, err := u.getConfigRevision(ctx, ns, name, revision)
+	if err != nil {
+		return nil, err
+	}
+	configItem, err := u.Factory.ParseConfig(ctx, target.Template, config.Metadata{
+		NamespacedName: config.NamespacedName{Name: name, Namespace: ns},
+		Alias:          target.Alias,
+		Description:    target.Description,
+		Properties:     target.Properties,
+	})
+	if err != nil {
+		if errors.Is(err, config.ErrTemplateNotFound) {
+			return nil, bcode.ErrTemplateNotFound
+		}
+		return nil, err
+	}
+	markConfigSecretStore(configItem, u.SecretStore)
+	if err := u.Factory.CreateOrUpdateConfig(ctx, configItem, ns); err != nil {
+		if errors.Is(err, config.ErrChangeTemplate) {
+			return nil, bcode.ErrChangeTemplate
+		}
+		if errors.Is(err, config.ErrChangeSecretType) {
+			return nil, bcode.ErrChangeSecretType
+		}
+		return nil, err
+	}
+	if err := u.recordConfigRevision(ctx, it, configItem, revision); err != nil {
+		klog.Errorf("failed to record the revision of the config %s/%s: %s", ns, name, err.Error())
+	}
+	// the sensitive properties refer to the values stored with the revision, the values out of the revisions are removed
+	u.pruneSensitiveProperties(ctx, ns, name)
+	if err := u.redistributeConfig(ctx, project, ns, name); err != nil {
+		return nil, err
+	}
+	return convertConfig(project, *configItem), nil
+}
+
+// recordConfigRevision record the new revision of the config and remove the revisions out of the limit.
+// The previous config is recorded as the first revision if the config has no history.
+func (u *configServiceImpl) recordConfigRevision(ctx context.Context, previous, current *config.Config, rollbackFrom int64) error {
+	if u.RevisionLimit <= 0 || current.Template.Sensitive {
+		return nil
+	}
+	revisions, err := u.listConfigRevisions(ctx, current.Namespace, current.Name)
+	if err != nil {
+		return err
+	}
+	var last *configRevision
+	if len(revisions) > 0 {
+		last = revisions[len(revisions)-1]
+	} else if previous != nil {
+		last = &configRevision{
+			Revision:    1,
+			Template:    previous.Template.NamespacedName,
+			Alias:       previous.Alias,
+			Description: previous.Description,
+			Properties:  previous.Properties,
+			CreateTime:  previous.CreateTime,
+			Changes:     diffConfigProperties(current.Template.Schema, nil, previous.Properties),
+		}
+		if err := u.createConfigRevision(ctx, current.Namespace, current.Name, last); err != nil {
+			return err
+		}
+		revisions = append(revisions, last)
+	}
+	var operator string
+	if user := ctx.Value(&apis.CtxKeyUser); user != nil {
+		if username, ok := user.(string); ok {
+			operator = username
+		}
+	}
+	revision := &configRevision{
+		Revision:     1,
+		Template:     current.Template.NamespacedName,
+		Alias:        current.Alias,
+		Description:  current.Description,
+		Properties:   current.Properties,
+		Operator:     operator,
+		CreateTime:   time.Now(),
+		RollbackFrom: rollbackFrom,
+	}
+	if last != nil {
+		revision.Revision = last.Revision + 1
+		revision.Changes = diffConfigProperties(current.Template.Schema, last.Properties, current.Properties)
+	} else {
+		revision.Changes = diffConfigProperties(current.Template.Schema, nil, current.Properties)
+	}
+	if err := u.createConfigRevision(ctx, current.Namespace, current.Name, revision); err != nil {
+		return err
+	}
+	revisions = append(revisions, revision)
+	for i := 0; i < len(revisions)-u.RevisionLimit; i++ {
+		var secret corev1.Secret
+		secret.Name = configRevisionName(current.Name, revisions[i].Revision)
+		secret.Namespace = current.Namespace
+		if err := u.KubeClient.Delete(ctx, &secret); err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+func (u *configServiceImpl) createConfigRevision(ctx context.Context, ns, name string, revision *configRevision) error {
+	data, err := json.Marshal(revision)
+	if err != nil {
+		return err
+	}
+	var secret corev1.Secret
+	secret.Name = configRevisionName(name, revision.Revision)
+	secret.Namespace = ns
+	secret.Labels = map[string]string{
+		LabelConfigRevisionOf: name,
+		LabelConfigRevision:   strconv.FormatInt(revision.Revision, 10),
+	}
+	secret.Type = corev1.SecretTypeOpaque
+	secret.Data = map[string][]byte{configRevisionDataKey: data}
+	return u.KubeClient.Create(ctx, &secret)
+}
+
+// listConfigRevisions list the revisions of the config, sorted by the revision number
+func (u *configServiceImpl) listConfigRevisions(ctx context.Context, ns, name string) ([]*configRevision, error) {
+	var secrets corev1.SecretList
+	if err := u.KubeClient.List(ctx, &secrets, client.InNamespace(ns), client.MatchingLabels{LabelConfigRevisionOf: name}); err != nil {
+		return nil, err
+	}
+	var revisions []*configRevision
+	for i := range secrets.Items {
+		revision, err := convertSecret2ConfigRevision(&secrets.Items[i])
+		if err != nil {
+			klog.Warningf("failed to parse the config revision %s: %s", secrets.Items[i].Name, err.Error())
+			continue
+		}
+		revisions = append(revisions, revision)
+	}
+	sort.Slice(revisions, func(i, j int) bool {
+		return revisions[i].Revision < revisions[j].Revision
+	})
+	return revisions, nil
+}
+
+func (u *configServiceImpl) getConfigRevision(ctx context.Context, ns, name string, revision int64) (*configRevision, error) {
+	var secret corev1.Secret
+	if err := u.KubeClient.Get(ctx, client.ObjectKey{Namespace: ns, Name: configRevisionName(name, revision)}, &secret); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, bcode.ErrConfigRevisionNotExist
+		}
+		return nil, err
+	}
+	if secret.Labels[LabelConfigRevisionOf] != name {
+		return nil, bcode.ErrConfigRevisionNotExist
+	}
+	return convertSecret2ConfigRevision(&secret)
+}
+
+// deleteConfigRevisions delete all revisions of the config
+func (u *configServiceImpl) deleteConfigRevisions(ctx context.Context, ns, name string) error {
+	return u.KubeClient.DeleteAllOf(ctx, &corev1.Secret{}, client.InNamespace(ns), client.MatchingLabels{LabelConfigRevisionOf: name})
+}
+
+// redistributeConfig apply the distributions including the config again, the distributions of all projects
+// may include the config of the system scope.
+func (u *configServiceImpl) redistributeConfig(ctx context.Context, project, ns, name string) error {
+	projects := []string{project}
+	if project == "" {
+		projects = nil
+		list, err := u.ProjectService.ListProjects(ctx, 0, 0)
+		if err != nil {
+			return err
+		}
+		for _, p := range list.Projects {
+			projects = append(projects, p.Name)
+		}
+	}
+	for _, p := range projects {
+		distributions, err := u.ListConfigDistributions(ctx, p)
+		if err != nil {
+			return err
+		}
+		for _, d := range distributions {
+			var included bool
+			req := apis.CreateConfigDistributionRequest{Name: d.Name}
+			for _, c := range d.Configs {
+				included = included || (c.Name == name && c.Namespace == ns)
+				req.Configs = append(req.Configs, &apis.NamespacedName{Name: c.Name, Namespace: c.Namespace})
+			}
+			if !included {
+				continue
+			}
+			for _, t := range d.Targets {
+				req.Targets = append(req.Targets, &apis.ClusterTarget{ClusterName: t.ClusterName, Namespace: t.Namespace})
+			}
+			if err := u.CreateConfigDistribution(ctx, p, req); err != nil {
+				return fmt.Errorf("failed to apply the distribution %s again: %w", d.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+func (u *configServiceImpl) configNamespace(ctx context.Context, project string) (string, error) {
+	if project == "" {
+		return types.DefaultKubeVelaNS, nil
+	}
+	pro, err := u.ProjectService.GetProject(ctx, project)
+	if err != nil {
+		return "", err
+	}
+	return pro.GetNamespace(), nil
+}
+
+func convertSecret2ConfigRevision(secret *corev1.Secret) (*configRevision, error) {
+	var revision configRevision
+	if err := json.Unmarshal(secret.Data[configRevisionDataKey], &revision); err != nil {
+		return nil, err
+	}
+	return &revision, nil
+}
+
+func configRevisionName(name string, revision int64) string {
+	return fmt.Sprintf("%s.revision-%d", name, revision)
+}
+
+// loadConfigSchema returns the schema of the template to find the sensitive properties, nil if the template is removed
+func (u *configServiceImpl) loadConfigSchema(ctx context.Context, template config.NamespacedName) (*openapi3.Schema, error) {
+	if template.Name == "" {
+		return nil, nil
+	}
+	if template.Namespace == "" {
+		template.Namespace = types.DefaultKubeVelaNS
+	}
+	tem, err := u.Factory.LoadTemplate(ctx, template.Name, template.Namespace)
+	if err != nil {
+		if errors.Is(err, config.ErrTemplateNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return tem.Schema, nil
+}
+
+// diffConfigProperties compare the properties by the path of the leaf values. The values of the properties marked as
+// sensitive in the schema, the properties nested in them and the properties named like the credentials are redacted.
+func diffConfigProperties(schema *openapi3.Schema, base, target map[string]interface{}) []apis.ConfigPropertyChange {
+	baseValues, redacted := map[string]string{}, map[string]bool{}
+	flattenConfigProperties("", base, schema, false, baseValues, redacted)
+	targetValues := map[string]string{}
+	flattenConfigProperties("", target, schema, false, targetValues, redacted)
+	redact := func(key, value string) string {
+		if redacted[key] {
+			return configRedactedValue
+		}
+		return redactConfigValue(key, value)
+	}
+	changes := []apis.ConfigPropertyChange{}
+	for key, before := range baseValues {
+		after, ok := targetValues[key]
+		switch {
+		case !ok:
+			changes = append(changes, apis.ConfigPropertyChange{Key: key, Change: apis.ConfigChangeRemoved, Before: redact(key, before)})
+		case before != after:
+			changes = append(changes, apis.ConfigPropertyChange{Key: key, Change: apis.ConfigChangeModified, Before: redact(key, before), After: redact(key, after)})
+		}
+	}
+	for key, after := range targetValues {
+		if _, ok := baseValues[key]; !ok {
+			changes = append(changes, apis.ConfigPropertyChange{Key: key, Change: apis.ConfigChangeAdded, After: redact(key, after)})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Key < changes[j].Key
+	})
+	return changes
+}
+
+// flattenConfigProperties flatten the nested properties and the items of the arrays, such as "auths[0].password",
+// the paths of the values under the sensitive schemas are recorded in the redacted.
+func flattenConfigProperties(path string, value interface{}, schema *openapi3.Schema, sensitive bool, values map[string]string, redacted map[string]bool) {
+	sensitive = sensitive || isSensitiveSchema(schema)
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if len(v) > 0 || path == "" {
+			for key, item := range v {
+				var property *openapi3.Schema
+				if schema != nil {
+					if ref := schema.Properties[key]; ref != nil {
+						property = ref.Value
+					} else if schema.AdditionalProperties != nil {
+						property = schema.AdditionalProperties.Value
+					}
+				}
+				child := key
+				if path != "" {
+					child = path + "." + key
+				}
+				flattenConfigProperties(child, item, property, sensitive, values, redacted)
+			}
+			return
+		}
+	case []interface{}:
+		if len(v) > 0 {
+			var items *openapi3.Schema
+			if schema != nil && schema.Items != nil {
+				items = schema.Items.Value
+			}
+			for i, item := range v {
+				flattenConfigProperties(fmt.Sprintf("%s[%d]", path, i), item, items, sensitive, values, redacted)
+			}
+			return
+		}
+	}
+	if sensitive {
+		redacted[path] = true
+	}
+	bs, err := json.Marshal(value)
+	if err != nil {
+		values[path] = fmt.Sprintf("%v", value)
+		return
+	}
+	values[path] = string(bs)
+}
+
+func redactConfigValue(key, value string) string {
+	lowerKey := strings.ToLower(key)
+	for _, sensitive := range configSensitiveKeys {
+		if strings.Contains(lowerKey, sensitive) {
+			return configRedactedValue
+		}
+	}
+	return value
+}
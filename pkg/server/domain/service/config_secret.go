@@ -0,0 +1,511 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/rand"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/oam-dev/kubevela/apis/types"
+	"github.com/oam-dev/kubevela/pkg/config"
+	"github.com/oam-dev/kubevela/pkg/multicluster"
+	"github.com/oam-dev/kubevela/pkg/oam"
+
+	"github.com/kubevela/velaux/pkg/server/domain/model"
+	"github.com/kubevela/velaux/pkg/server/infrastructure/datastore"
+	"github.com/kubevela/velaux/pkg/server/infrastructure/secretstore"
+	"github.com/kubevela/velaux/pkg/server/utils/bcode"
+)
+
+const (
+	// AnnotationConfigSecretStore the type of the secret store that keeps the sensitive properties of the config
+	AnnotationConfigSecretStore = "ux.oam.dev/secret-store"
+	// secretStoreReferencePrefix the sensitive properties are replaced by the references with this prefix
+	secretStoreReferencePrefix = "secretstore://"
+	// schemaExtensionSensitive the extension of the template schema to mark a property as sensitive
+	schemaExtensionSensitive = "x-sensitive"
+)
+
+// sensitiveConfigProperties returns the string properties marked as sensitive in the schema of the template,
+// a property is sensitive if it is write only, its format is password or its x-sensitive extension is true.
+func sensitiveConfigProperties(schema *openapi3.Schema) []string {
+	if schema == nil {
+		return nil
+	}
+	var names []string
+	for name, ref := range schema.Properties {
+		if ref == nil || ref.Value == nil || ref.Value.Type != openapi3.TypeString {
+			continue
+		}
+		if isSensitiveSchema(ref.Value) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// isSensitiveSchema check whether the property is write only, its format is password or its x-sensitive extension is true
+func isSensitiveSchema(property *openapi3.Schema) bool {
+	if property == nil {
+		return false
+	}
+	sensitive := property.WriteOnly || property.Format == "password"
+	switch extension := property.Extensions[schemaExtensionSensitive].(type) {
+	case bool:
+		sensitive = sensitive || extension
+	case json.RawMessage:
+		sensitive = sensitive || string(extension) == "true"
+	}
+	return sensitive
+}
+
+func configSecretKey(namespace, name string) string {
+	return namespace + "/" + name
+}
+
+// secretStoreReference returns the reference of the value in the secret store, the fragment is the field of the value in the
+// secret of the config, such as "password@x7k2m9qd". Every change of a sensitive property is stored as a new version so
+// the revisions of the config refer to the values they were created with.
+func secretStoreReference(key, fragment string) string {
+	return fmt.Sprintf("%s%s#%s", secretStoreReferencePrefix, key, fragment)
+}
+
+func newSecretStoreFragment(property string) string {
+	return property + "@" + rand.String(8)
+}
+
+func isSecretStoreReference(value interface{}) bool {
+	reference, ok := value.(string)
+	return ok && strings.HasPrefix(reference, secretStoreReferencePrefix)
+}
+
+// ownSecretStoreFragment returns the fragment of the reference if the value refers to a version of the property in the secret of the config
+func ownSecretStoreFragment(key, property string, value interface{}) (string, bool) {
+	reference, ok := value.(string)
+	if !ok {
+		return "", false
+	}
+	fragment := strings.TrimPrefix(reference, secretStoreReferencePrefix+key+"#")
+	if fragment == reference || (fragment != property && !strings.HasPrefix(fragment, property+"@")) {
+		return "", false
+	}
+	return fragment, true
+}
+
+// loadSensitiveProperties returns the sensitive properties of the template
+func (u *configServiceImpl) loadSensitiveProperties(ctx context.Context, template config.NamespacedName) ([]string, error) {
+	if template.Namespace == "" {
+		template.Namespace = types.DefaultKubeVelaNS
+	}
+	tem, err := u.Factory.LoadTemplate(ctx, template.Name, template.Namespace)
+	if err != nil {
+		if errors.Is(err, config.ErrTemplateNotFound) {
+			return nil, bcode.ErrTemplateNotFound
+		}
+		return nil, err
+	}
+	return sensitiveConfigProperties(tem.Schema), nil
+}
+
+// storeSensitiveProperties move the values of the sensitive properties to the secret store and replace them with the references,
+// so the config secret never holds the values. The property whose value is its reference keeps the stored value,
+// a property can not refer to any other value in the secret store. The new values are added as the new versions, the
+// caller prunes the versions no longer referenced by pruneSensitiveProperties after the config is saved or failed to save.
+func (u *configServiceImpl) storeSensitiveProperties(ctx context.Context, template config.NamespacedName, namespace, name string, properties map[string]interface{}) error {
+	if u.SecretStore == nil {
+		return nil
+	}
+	sensitive, err := u.loadSensitiveProperties(ctx, template)
+	if err != nil {
+		return err
+	}
+	for property, value := range properties {
+		if isSecretStoreReference(value) && !containsString(sensitive, property) {
+			return bcode.ErrInvalidSecretStoreReference.SetMessage(fmt.Sprintf("the property %s is not sensitive, it can not refer to the secret store", property))
+		}
+	}
+	if len(sensitive) == 0 {
+		return nil
+	}
+	key := configSecretKey(namespace, name)
+	previous, err := u.SecretStore.Get(ctx, key)
+	if err != nil && !errors.Is(err, secretstore.ErrSecretNotExist) {
+		return err
+	}
+	data := map[string]string{}
+	for fragment, value := range previous {
+		data[fragment] = value
+	}
+	var changed bool
+	for _, property := range sensitive {
+		value, ok := properties[property].(string)
+		if !ok {
+			continue
+		}
+		if isSecretStoreReference(value) {
+			fragment, own := ownSecretStoreFragment(key, property, value)
+			if _, exist := previous[fragment]; !own || !exist {
+				return bcode.ErrInvalidSecretStoreReference.SetMessage(fmt.Sprintf("the property %s can only refer to its own value in the secret store", property))
+			}
+			continue
+		}
+		fragment := newSecretStoreFragment(property)
+		data[fragment] = value
+		properties[property] = secretStoreReference(key, fragment)
+		changed = true
+	}
+	if !changed {
+		return nil
+	}
+	return u.SecretStore.Put(ctx, key, data)
+}
+
+// pruneSensitiveProperties remove the values in the secret store that neither the config nor its revisions refer to.
+// The failures are logged, the values are pruned again by the next change of the config.
+func (u *configServiceImpl) pruneSensitiveProperties(ctx context.Context, namespace, name string) {
+	if u.SecretStore == nil {
+		return
+	}
+	key := configSecretKey(namespace, name)
+	data, err := u.SecretStore.Get(ctx, key)
+	if err != nil {
+		if !errors.Is(err, secretstore.ErrSecretNotExist) {
+			klog.Errorf("failed to get the sensitive properties of the config %s/%s: %s", namespace, name, err.Error())
+		}
+		return
+	}
+	var referenced []map[string]interface{}
+	it, err := u.Factory.GetConfig(ctx, namespace, name, false)
+	switch {
+	case err == nil:
+		referenced = append(referenced, it.Properties)
+	case !errors.Is(err, config.ErrConfigNotFound):
+		klog.Errorf("failed to get the config %s/%s: %s", namespace, name, err.Error())
+		return
+	}
+	revisions, err := u.listConfigRevisions(ctx, namespace, name)
+	if err != nil {
+		klog.Errorf("failed to list the revisions of the config %s/%s: %s", namespace, name, err.Error())
+		return
+	}
+	for _, revision := range revisions {
+		referenced = append(referenced, revision.Properties)
+	}
+	kept := map[string]string{}
+	for _, properties := range referenced {
+		for property, value := range properties {
+			if fragment, own := ownSecretStoreFragment(key, property, value); own {
+				if stored, exist := data[fragment]; exist {
+					kept[fragment] = stored
+				}
+			}
+		}
+	}
+	if len(kept) == len(data) {
+		return
+	}
+	if len(kept) == 0 {
+		err = u.SecretStore.Delete(ctx, key)
+	} else {
+		err = u.SecretStore.Put(ctx, key, kept)
+	}
+	if err != nil {
+		klog.Errorf("failed to prune the sensitive properties of the config %s/%s: %s", namespace, name, err.Error())
+	}
+}
+
+// markConfigSecretStore annotate the config secret if its sensitive properties refer to the secret store
+func markConfigSecretStore(item *config.Config, store secretstore.SecretStore) {
+	if store == nil || item.Secret == nil {
+		return
+	}
+	key := configSecretKey(item.Namespace, item.Name)
+	for _, property := range sensitiveConfigProperties(item.Template.Schema) {
+		if _, own := ownSecretStoreFragment(key, property, item.Properties[property]); own {
+			if item.Secret.Annotations == nil {
+				item.Secret.Annotations = map[string]string{}
+			}
+			item.Secret.Annotations[AnnotationConfigSecretStore] = store.Type()
+			return
+		}
+	}
+}
+
+// resolveSensitiveProperties replace the references of the sensitive properties with the values in the secret store,
+// a property only resolves its own value of the config.
+func (u *configServiceImpl) resolveSensitiveProperties(ctx context.Context, key string, sensitive []string, properties map[string]interface{}) error {
+	var data map[string]string
+	for _, name := range sensitive {
+		reference, ok := properties[name].(string)
+		if !ok || !strings.HasPrefix(reference, secretStoreReferencePrefix) {
+			continue
+		}
+		if u.SecretStore == nil {
+			return bcode.ErrSecretStoreNotConfigured
+		}
+		fragment, own := ownSecretStoreFragment(key, name, reference)
+		if !own {
+			return bcode.ErrInvalidSecretStoreReference.SetMessage(fmt.Sprintf("the property %s can only refer to its own value in the secret store", name))
+		}
+		if data == nil {
+			var err error
+			if data, err = u.SecretStore.Get(ctx, key); err != nil {
+				return fmt.Errorf("failed to get the property %s from the secret store: %w", name, err)
+			}
+		}
+		resolved, ok := data[fragment]
+		if !ok {
+			return fmt.Errorf("the property %s is not found in the secret store", name)
+		}
+		properties[name] = resolved
+	}
+	return nil
+}
+
+// resolveConfigSecret returns the content of the config to deliver. The config that refers to the secret store is
+// rendered again in memory with the resolved properties, so the resolved values are only written to the targets.
+func (u *configServiceImpl) resolveConfigSecret(ctx context.Context, source *corev1.Secret) (*corev1.Secret, error) {
+	if source.Annotations[AnnotationConfigSecretStore] == "" {
+		return source, nil
+	}
+	properties := map[string]interface{}{}
+	if err := json.Unmarshal(source.Data[config.SaveInputPropertiesKey], &properties); err != nil {
+		return nil, fmt.Errorf("failed to parse the properties of the config %s: %w", source.Name, err)
+	}
+	template := config.NamespacedName{
+		Name:      source.Labels[types.LabelConfigType],
+		Namespace: source.Annotations[types.AnnotationConfigTemplateNamespace],
+	}
+	sensitive, err := u.loadSensitiveProperties(ctx, template)
+	if err != nil {
+		return nil, err
+	}
+	if err := u.resolveSensitiveProperties(ctx, configSecretKey(source.Namespace, source.Name), sensitive, properties); err != nil {
+		return nil, err
+	}
+	item, err := u.Factory.ParseConfig(ctx, template, config.Metadata{
+		NamespacedName: config.NamespacedName{Name: source.Name, Namespace: source.Namespace},
+		Alias:          source.Annotations[types.AnnotationConfigAlias],
+		Description:    source.Annotations[types.AnnotationConfigDescription],
+		Properties:     properties,
+	})
+	if err != nil {
+		return nil, err
+	}
+	resolved := item.Secret.DeepCopy()
+	data := map[string][]byte{}
+	for key, value := range resolved.Data {
+		data[key] = value
+	}
+	for key, value := range resolved.StringData {
+		data[key] = []byte(value)
+	}
+	// the input properties hold the resolved values, they are not required by the targets
+	delete(data, config.SaveInputPropertiesKey)
+	resolved.Data, resolved.StringData = data, nil
+	resolved.Annotations[AnnotationConfigSecretStore] = source.Annotations[AnnotationConfigSecretStore]
+	return resolved, nil
+}
+
+// getConfigSource returns the resolved content of the source config of the distribution
+func (u *configServiceImpl) getConfigSource(ctx context.Context, namespace, name string) (*corev1.Secret, error) {
+	var source corev1.Secret
+	if err := u.KubeClient.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, &source); err != nil {
+		return nil, err
+	}
+	return u.resolveConfigSecret(ctx, &source)
+}
+
+// isExternalConfig check whether the config refers to the secret store, such configs are delivered by the apiserver
+func (u *configServiceImpl) isExternalConfig(ctx context.Context, namespace, name string) (bool, error) {
+	var source corev1.Secret
+	if err := u.KubeClient.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, &source); err != nil {
+		if apierrors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return source.Annotations[AnnotationConfigSecretStore] != "", nil
+}
+
+// distributeExternalConfigs deliver the configs that refer to the secret store to the targets, and remove the configs
+// delivered by the previous version of the distribution but not included now.
+func (u *configServiceImpl) distributeExternalConfigs(ctx context.Context, project, namespace, name string, configs []*config.NamespacedName, targets []*config.ClusterTarget) error {
+	record := &model.ExternalConfigDistribution{Project: project, Distribution: name}
+	var previous []model.ConfigReference
+	var previousTargets []model.ConfigDistributionTarget
+	err := u.Store.Get(ctx, record)
+	if err != nil && !errors.Is(err, datastore.ErrRecordNotExist) {
+		return err
+	}
+	exist := err == nil
+	if exist {
+		previous, previousTargets = record.Configs, record.Targets
+	}
+	record.Namespace, record.Configs, record.Targets = namespace, nil, nil
+	for _, c := range configs {
+		ns := c.Namespace
+		if ns == "" {
+			ns = namespace
+		}
+		record.Configs = append(record.Configs, model.ConfigReference{Name: c.Name, Namespace: ns})
+	}
+	for _, t := range targets {
+		record.Targets = append(record.Targets, model.ConfigDistributionTarget{ClusterName: t.ClusterName, Namespace: t.Namespace})
+	}
+
+	switch {
+	case len(record.Configs) == 0 && exist:
+		if err := u.Store.Delete(ctx, record); err != nil && !errors.Is(err, datastore.ErrRecordNotExist) {
+			return err
+		}
+	case len(record.Configs) != 0 && exist:
+		if err := u.Store.Put(ctx, record); err != nil {
+			return err
+		}
+	case len(record.Configs) != 0:
+		if err := u.Store.Add(ctx, record); err != nil {
+			return err
+		}
+	}
+
+	delivered := map[string]bool{}
+	for _, c := range record.Configs {
+		source, err := u.getConfigSource(ctx, c.Namespace, c.Name)
+		for _, t := range record.Targets {
+			delivered[c.Name+"/"+t.ClusterName+"/"+t.Namespace] = true
+			if err == nil {
+				err = u.deliverConfigSecret(ctx, name, namespace, source, t.ClusterName, t.Namespace)
+			}
+			if err != nil {
+				klog.Errorf("failed to deliver the config %s to the namespace %s of the cluster %s: %s", c.Name, t.Namespace, t.ClusterName, err.Error())
+			}
+		}
+	}
+	for _, c := range previous {
+		for _, t := range previousTargets {
+			if delivered[c.Name+"/"+t.ClusterName+"/"+t.Namespace] {
+				continue
+			}
+			if err := u.deleteDeliveredConfig(ctx, name, namespace, c.Name, t); err != nil {
+				klog.Errorf("failed to delete the config %s from the namespace %s of the cluster %s: %s", c.Name, t.Namespace, t.ClusterName, err.Error())
+			}
+		}
+	}
+	return nil
+}
+
+// deliverConfigSecret write the content of the source config to the target, the config created in the target
+// is labeled as the distributed one.
+func (u *configServiceImpl) deliverConfigSecret(ctx context.Context, distributionName, distributionNamespace string, source *corev1.Secret, clusterName, namespace string) error {
+	ctx = multicluster.ContextWithClusterName(ctx, clusterName)
+	var delivered corev1.Secret
+	if err := u.KubeClient.Get(ctx, client.ObjectKey{Namespace: namespace, Name: source.Name}, &delivered); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return err
+		}
+		delivered = corev1.Secret{
+			Type: source.Type,
+			Data: source.Data,
+		}
+		delivered.Name = source.Name
+		delivered.Namespace = namespace
+		delivered.Labels = map[string]string{}
+		for k, v := range source.Labels {
+			delivered.Labels[k] = v
+		}
+		delivered.Labels[types.LabelConfigCatalog] = types.CatalogConfigDistribution
+		delivered.Labels[oam.LabelAppName] = distributionName
+		delivered.Labels[oam.LabelAppNamespace] = distributionNamespace
+		delivered.Annotations = source.Annotations
+		return u.KubeClient.Create(ctx, &delivered)
+	}
+	delivered.Data = source.Data
+	return u.KubeClient.Update(ctx, &delivered)
+}
+
+// deleteDeliveredConfig delete the config delivered by the distribution from the target
+func (u *configServiceImpl) deleteDeliveredConfig(ctx context.Context, distributionName, distributionNamespace, name string, target model.ConfigDistributionTarget) error {
+	ctx = multicluster.ContextWithClusterName(ctx, target.ClusterName)
+	var delivered corev1.Secret
+	if err := u.KubeClient.Get(ctx, client.ObjectKey{Namespace: target.Namespace, Name: name}, &delivered); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+	if delivered.Labels[types.LabelConfigCatalog] != types.CatalogConfigDistribution ||
+		delivered.Labels[oam.LabelAppName] != distributionName || delivered.Labels[oam.LabelAppNamespace] != distributionNamespace {
+		return nil
+	}
+	return client.IgnoreNotFound(u.KubeClient.Delete(ctx, &delivered))
+}
+
+// deleteExternalConfigDistribution delete the configs delivered by the apiserver and the record of the distribution
+func (u *configServiceImpl) deleteExternalConfigDistribution(ctx context.Context, project, name string) (bool, error) {
+	record := &model.ExternalConfigDistribution{Project: project, Distribution: name}
+	if err := u.Store.Get(ctx, record); err != nil {
+		if errors.Is(err, datastore.ErrRecordNotExist) {
+			return false, nil
+		}
+		return false, err
+	}
+	for _, c := range record.Configs {
+		for _, t := range record.Targets {
+			if err := u.deleteDeliveredConfig(ctx, name, record.Namespace, c.Name, t); err != nil {
+				return true, err
+			}
+		}
+	}
+	if err := u.Store.Delete(ctx, record); err != nil && !errors.Is(err, datastore.ErrRecordNotExist) {
+		return true, err
+	}
+	return true, nil
+}
+
+// mergeExternalConfigDistributions add the configs delivered by the apiserver to the distributions
+func mergeExternalConfigDistributions(distributions []*config.Distribution, records []*model.ExternalConfigDistribution) []*config.Distribution {
+	for _, record := range records {
+		var distribution *config.Distribution
+		for _, d := range distributions {
+			if d.Name == record.Distribution {
+				distribution = d
+				break
+			}
+		}
+		if distribution == nil {
+			distribution = &config.Distribution{Name: record.Distribution, Namespace: record.Namespace, CreatedTime: record.CreateTime}
+			for _, t := range record.Targets {
+				distribution.Targets = append(distribution.Targets, &config.ClusterTarget{ClusterName: t.ClusterName, Namespace: t.Namespace})
+			}
+			distributions = append(distributions, distribution)
+		}
+		for _, c := range record.Configs {
+			distribution.Configs = append(distribution.Configs, &config.NamespacedName{Name: c.Name, Namespace: c.Namespace})
+		}
+	}
+	return distributions
+}
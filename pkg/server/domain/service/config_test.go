@@ -19,16 +19,28 @@ package service
 import (
 	"context"
 	"errors"
+	"os"
+	"path/filepath"
+	"strings"
 
+	"github.com/getkin/kin-openapi/openapi3"
 	terraformapi "github.com/oam-dev/terraform-controller/api/v1beta1"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	apitypes "k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/yaml"
 
+	"github.com/oam-dev/kubevela/apis/core.oam.dev/v1beta1"
 	"github.com/oam-dev/kubevela/apis/types"
 	"github.com/oam-dev/kubevela/pkg/config"
 	"github.com/oam-dev/kubevela/pkg/cue/script"
+	"github.com/oam-dev/kubevela/pkg/oam"
 
+	"github.com/kubevela/velaux/pkg/server/domain/model"
+	"github.com/kubevela/velaux/pkg/server/infrastructure/secretstore"
 	v1 "github.com/kubevela/velaux/pkg/server/interfaces/api/dto/v1"
 	"github.com/kubevela/velaux/pkg/server/utils/bcode"
 )
@@ -192,3 +204,328 @@ var _ = Describe("Test config service", func() {
 		Expect(len(list.Items)).To(Equal(0))
 	})
 })
+
+var _ = Describe("Test the revisions of the config", func() {
+	It("Test diffing the properties", func() {
+		base := map[string]interface{}{
+			"url":     "https://registry.example.com",
+			"auth":    map[string]interface{}{"username": "admin", "password": "123456"},
+			"mirrors": []interface{}{"a"},
+		}
+		target := map[string]interface{}{
+			"url":      "https://registry.example.com",
+			"auth":     map[string]interface{}{"username": "admin", "password": "654321"},
+			"mirrors":  []interface{}{"a", "b"},
+			"insecure": true,
+		}
+		Expect(diffConfigProperties(nil, base, target)).Should(Equal([]v1.ConfigPropertyChange{
+			{Key: "auth.password", Change: v1.ConfigChangeModified, Before: configRedactedValue, After: configRedactedValue},
+			{Key: "insecure", Change: v1.ConfigChangeAdded, After: "true"},
+			{Key: "mirrors[1]", Change: v1.ConfigChangeAdded, After: `"b"`},
+		}))
+		Expect(diffConfigProperties(nil, target, nil)).Should(HaveLen(6))
+		Expect(diffConfigProperties(nil, base, base)).Should(BeEmpty())
+
+		By("redacting the properties marked as sensitive in the schema")
+		schema := openapi3.NewObjectSchema().
+			WithProperty("servers", openapi3.NewArraySchema().WithItems(openapi3.NewObjectSchema().
+				WithProperty("host", openapi3.NewStringSchema()).
+				WithProperty("pin", openapi3.NewStringSchema().WithFormat("password")))).
+			WithProperty("login", &openapi3.Schema{Type: openapi3.TypeObject, ExtensionProps: openapi3.ExtensionProps{Extensions: map[string]interface{}{schemaExtensionSensitive: true}}})
+		base = map[string]interface{}{
+			"servers": []interface{}{map[string]interface{}{"host": "a", "pin": "1234"}},
+			"login":   map[string]interface{}{"user": "admin"},
+		}
+		target = map[string]interface{}{
+			"servers": []interface{}{map[string]interface{}{"host": "b", "pin": "4321"}},
+			"login":   map[string]interface{}{"user": "root"},
+		}
+		Expect(diffConfigProperties(schema, base, target)).Should(Equal([]v1.ConfigPropertyChange{
+			{Key: "login.user", Change: v1.ConfigChangeModified, Before: configRedactedValue, After: configRedactedValue},
+			{Key: "servers[0].host", Change: v1.ConfigChangeModified, Before: `"a"`, After: `"b"`},
+			{Key: "servers[0].pin", Change: v1.ConfigChangeModified, Before: configRedactedValue, After: configRedactedValue},
+		}))
+	})
+
+	It("Test recording the revisions", func() {
+		configService := &configServiceImpl{KubeClient: k8sClient, RevisionLimit: 2}
+		previous := &config.Config{
+			Metadata: config.Metadata{NamespacedName: config.NamespacedName{Name: "registry", Namespace: types.DefaultKubeVelaNS}, Properties: map[string]interface{}{"url": "a"}},
+		}
+		current := &config.Config{
+			Metadata: config.Metadata{NamespacedName: config.NamespacedName{Name: "registry", Namespace: types.DefaultKubeVelaNS}, Properties: map[string]interface{}{"url": "b"}},
+		}
+		Expect(configService.recordConfigRevision(context.TODO(), previous, current, 0)).Should(Succeed())
+		revisions, err := configService.ListConfigRevisions(context.TODO(), "", "registry")
+		Expect(err).Should(BeNil())
+		Expect(len(revisions.Revisions)).Should(Equal(2))
+		Expect(revisions.Revisions[0].Revision).Should(Equal(int64(2)))
+		Expect(revisions.Revisions[0].Changes).Should(Equal([]v1.ConfigPropertyChange{{Key: "url", Change: v1.ConfigChangeModified, Before: `"a"`, After: `"b"`}}))
+
+		Expect(configService.recordConfigRevision(context.TODO(), current, previous, 1)).Should(Succeed())
+		revisions, err = configService.ListConfigRevisions(context.TODO(), "", "registry")
+		Expect(err).Should(BeNil())
+		Expect(len(revisions.Revisions)).Should(Equal(2))
+		Expect(revisions.Revisions[0].RollbackFrom).Should(Equal(int64(1)))
+		_, err = configService.DiffConfigRevisions(context.TODO(), "", "registry", 1, 3)
+		Expect(err).Should(Equal(bcode.ErrConfigRevisionNotExist))
+		diff, err := configService.DiffConfigRevisions(context.TODO(), "", "registry", 2, 3)
+		Expect(err).Should(BeNil())
+		Expect(diff.Changes).Should(Equal([]v1.ConfigPropertyChange{{Key: "url", Change: v1.ConfigChangeModified, Before: `"b"`, After: `"a"`}}))
+
+		Expect(configService.deleteConfigRevisions(context.TODO(), types.DefaultKubeVelaNS, "registry")).Should(Succeed())
+		revisions, err = configService.ListConfigRevisions(context.TODO(), "", "registry")
+		Expect(err).Should(BeNil())
+		Expect(revisions.Revisions).Should(BeEmpty())
+	})
+})
+
+var _ = Describe("Test the status of the config distributions", func() {
+	It("Test checking and resyncing the distribution", func() {
+		configService := &configServiceImpl{KubeClient: k8sClient, Store: ds}
+		source := &corev1.Secret{Data: map[string][]byte{"url": []byte("https://registry.example.com")}}
+		source.Name = "distributed-registry"
+		source.Namespace = types.DefaultKubeVelaNS
+		Expect(k8sClient.Create(context.TODO(), source)).Should(Succeed())
+		distribution := &config.Distribution{
+			Name:      "registry",
+			Namespace: types.DefaultKubeVelaNS,
+			Configs:   []*config.NamespacedName{{Name: "distributed-registry", Namespace: types.DefaultKubeVelaNS}},
+			Targets:   []*config.ClusterTarget{{ClusterName: types.ClusterLocalName, Namespace: "default"}},
+		}
+
+		status, err := configService.checkConfigDistribution(context.TODO(), "default", distribution)
+		Expect(err).Should(BeNil())
+		Expect(len(status.Targets)).Should(Equal(1))
+		Expect(status.Targets[0].Status).Should(Equal(model.ConfigTargetFailed))
+
+		Expect(configService.resyncConfigTarget(context.TODO(), distribution, status.Targets[0])).Should(Succeed())
+		status, err = configService.checkConfigDistribution(context.TODO(), "default", distribution)
+		Expect(err).Should(BeNil())
+		Expect(status.Targets[0].Status).Should(Equal(model.ConfigTargetSynced))
+		Expect(status.Targets[0].TargetHash).Should(Equal(configContentHash(source)))
+
+		var delivered corev1.Secret
+		Expect(k8sClient.Get(context.TODO(), apitypes.NamespacedName{Namespace: "default", Name: "distributed-registry"}, &delivered)).Should(Succeed())
+		Expect(delivered.Labels[types.LabelConfigCatalog]).Should(Equal(types.CatalogConfigDistribution))
+		delivered.Data["url"] = []byte("https://mirror.example.com")
+		Expect(k8sClient.Update(context.TODO(), &delivered)).Should(Succeed())
+		status, err = configService.checkConfigDistribution(context.TODO(), "default", distribution)
+		Expect(err).Should(BeNil())
+		Expect(status.Targets[0].Status).Should(Equal(model.ConfigTargetDrifted))
+
+		Expect(configService.resyncConfigTarget(context.TODO(), distribution, status.Targets[0])).Should(Succeed())
+		status, err = configService.checkConfigDistribution(context.TODO(), "default", distribution)
+		Expect(err).Should(BeNil())
+		Expect(status.Targets[0].Status).Should(Equal(model.ConfigTargetSynced))
+
+		Expect(configService.deleteConfigDistributionStatus(context.TODO(), "default", "registry")).Should(Succeed())
+	})
+
+	It("Test resyncing the configs delivered by the application of the distribution", func() {
+		configService := &configServiceImpl{KubeClient: k8sClient, Store: ds, ProjectService: projectService, Factory: config.NewConfigFactory(k8sClient)}
+		_, err := projectService.CreateProject(context.TODO(), v1.CreateProjectRequest{Name: "resync-project"})
+		Expect(err).Should(BeNil())
+		project, err := projectService.GetProject(context.TODO(), "resync-project")
+		Expect(err).Should(BeNil())
+		source := &corev1.Secret{Data: map[string][]byte{"url": []byte("https://registry.example.com")}}
+		source.Name = "resync-registry"
+		source.Namespace = project.GetNamespace()
+		Expect(k8sClient.Create(context.TODO(), source)).Should(Succeed())
+		Expect(configService.Factory.CreateOrUpdateDistribution(context.TODO(), project.GetNamespace(), "resync", &config.CreateDistributionSpec{
+			Configs: []*config.NamespacedName{{Name: "resync-registry", Namespace: project.GetNamespace()}},
+			Targets: []*config.ClusterTarget{{ClusterName: types.ClusterLocalName, Namespace: "resync-target"}, {ClusterName: types.ClusterLocalName, Namespace: "synced-target"}},
+		})).Should(Succeed())
+		var app v1beta1.Application
+		Expect(k8sClient.Get(context.TODO(), apitypes.NamespacedName{Namespace: project.GetNamespace(), Name: "resync"}, &app)).Should(Succeed())
+		publishVersion := app.Annotations[oam.AnnotationPublishVersion]
+		synced := source.DeepCopy()
+		synced.ObjectMeta = metav1.ObjectMeta{Name: "resync-registry", Namespace: "synced-target"}
+		Expect(k8sClient.Create(context.TODO(), synced)).Should(Succeed())
+
+		status, err := configService.ResyncConfigDistribution(context.TODO(), "resync-project", "resync")
+		Expect(err).Should(BeNil())
+		Expect(len(status.Targets)).Should(Equal(2))
+		for _, target := range status.Targets {
+			Expect(target.Status).Should(Equal(model.ConfigTargetSynced))
+		}
+		// only the failed target is delivered, the application of the distribution is not applied again
+		Expect(k8sClient.Get(context.TODO(), apitypes.NamespacedName{Namespace: project.GetNamespace(), Name: "resync"}, &app)).Should(Succeed())
+		Expect(app.Annotations[oam.AnnotationPublishVersion]).Should(Equal(publishVersion))
+		var delivered corev1.Secret
+		Expect(k8sClient.Get(context.TODO(), apitypes.NamespacedName{Namespace: "resync-target", Name: "resync-registry"}, &delivered)).Should(Succeed())
+		Expect(delivered.Labels[oam.LabelAppName]).Should(Equal("resync"))
+		Expect(delivered.Labels[oam.LabelAppNamespace]).Should(Equal(project.GetNamespace()))
+		var unchanged corev1.Secret
+		Expect(k8sClient.Get(context.TODO(), apitypes.NamespacedName{Namespace: "synced-target", Name: "resync-registry"}, &unchanged)).Should(Succeed())
+		Expect(unchanged.ResourceVersion).Should(Equal(synced.ResourceVersion))
+	})
+})
+
+var imageRegistryTemplate = `
+import "encoding/json"
+
+metadata: {
+	name:  "external-image-registry"
+	alias: "Image Registry"
+	scope: "project"
+}
+template: {
+	output: {
+		apiVersion: "v1"
+		kind:       "Secret"
+		metadata: {
+			name:      context.name
+			namespace: context.namespace
+		}
+		type: "kubernetes.io/dockerconfigjson"
+		stringData: ".dockerconfigjson": json.Marshal({auths: "\(parameter.registry)": {username: parameter.username, password: parameter.password}})
+	}
+	parameter: {
+		registry: string
+		username: string
+		password: string
+	}
+}
+`
+
+var _ = Describe("Test the configs referring to the secret store", func() {
+	It("Test storing and delivering the sensitive properties", func() {
+		dir, err := os.MkdirTemp("", "config-secret-store")
+		Expect(err).Should(BeNil())
+		defer func() {
+			_ = os.RemoveAll(dir)
+		}()
+		Expect(os.WriteFile(filepath.Join(dir, "key"), []byte("encryption-key"), 0600)).Should(Succeed())
+		store := secretstore.NewFileStore(secretstore.Config{FilePath: filepath.Join(dir, "secrets"), FileKeyPath: filepath.Join(dir, "key")})
+		factory := config.NewConfigFactory(k8sClient)
+		configService := &configServiceImpl{KubeClient: k8sClient, Store: ds, Factory: factory, SecretStore: store}
+
+		By("mark the password as sensitive in the schema of the template")
+		tem, err := factory.ParseTemplate("", []byte(imageRegistryTemplate))
+		Expect(err).Should(BeNil())
+		tem.Schema.Properties["password"].Value.Extensions = map[string]interface{}{schemaExtensionSensitive: true}
+		tem.Schema.Properties["username"].Value.Format = "password"
+		schema, err := yaml.Marshal(tem.Schema)
+		Expect(err).Should(BeNil())
+		tem.ConfigMap.Data[config.SaveSchemaKey] = string(schema)
+		Expect(factory.CreateOrUpdateConfigTemplate(context.TODO(), types.DefaultKubeVelaNS, tem)).Should(Succeed())
+		loaded, err := factory.LoadTemplate(context.TODO(), "external-image-registry", types.DefaultKubeVelaNS)
+		Expect(err).Should(BeNil())
+		Expect(sensitiveConfigProperties(loaded.Schema)).Should(Equal([]string{"password", "username"}))
+
+		By("the config secret only holds the references")
+		properties := map[string]interface{}{"registry": "registry.example.com", "username": "admin", "password": "p@ssw0rd"}
+		template := config.NamespacedName{Name: "external-image-registry"}
+		Expect(configService.storeSensitiveProperties(context.TODO(), template, types.DefaultKubeVelaNS, "external-registry", properties)).Should(Succeed())
+		Expect(properties["password"]).Should(HavePrefix("secretstore://vela-system/external-registry#password@"))
+		usernameFragment := strings.TrimPrefix(properties["username"].(string), "secretstore://vela-system/external-registry#")
+		passwordFragment := strings.TrimPrefix(properties["password"].(string), "secretstore://vela-system/external-registry#")
+		item, err := factory.ParseConfig(context.TODO(), config.NamespacedName{Name: template.Name, Namespace: types.DefaultKubeVelaNS}, config.Metadata{
+			NamespacedName: config.NamespacedName{Name: "external-registry", Namespace: types.DefaultKubeVelaNS},
+			Properties:     properties,
+		})
+		Expect(err).Should(BeNil())
+		markConfigSecretStore(item, store)
+		Expect(factory.CreateOrUpdateConfig(context.TODO(), item, types.DefaultKubeVelaNS)).Should(Succeed())
+		var source corev1.Secret
+		Expect(k8sClient.Get(context.TODO(), apitypes.NamespacedName{Namespace: types.DefaultKubeVelaNS, Name: "external-registry"}, &source)).Should(Succeed())
+		Expect(source.Annotations[AnnotationConfigSecretStore]).Should(Equal(secretstore.TypeFile))
+		for _, value := range source.Data {
+			Expect(string(value)).ShouldNot(ContainSubstring("p@ssw0rd"))
+		}
+
+		By("the references keep the stored values")
+		properties["registry"] = "mirror.example.com"
+		Expect(configService.storeSensitiveProperties(context.TODO(), template, types.DefaultKubeVelaNS, "external-registry", properties)).Should(Succeed())
+		data, err := store.Get(context.TODO(), "vela-system/external-registry")
+		Expect(err).Should(BeNil())
+		Expect(data).Should(Equal(map[string]string{usernameFragment: "admin", passwordFragment: "p@ssw0rd"}))
+
+		By("a property can only refer to its own value in the secret store")
+		for property, reference := range map[string]string{
+			"registry": "secretstore://vela-system/external-registry#password",
+			"password": "secretstore://vela-system/another-registry#password",
+		} {
+			invalid := map[string]interface{}{"registry": "registry.example.com", "username": "admin", "password": "p@ssw0rd"}
+			invalid[property] = reference
+			err = configService.storeSensitiveProperties(context.TODO(), template, types.DefaultKubeVelaNS, "external-registry", invalid)
+			Expect(err.(*bcode.Bcode).BusinessCode).Should(Equal(bcode.ErrInvalidSecretStoreReference.BusinessCode))
+		}
+		foreign := map[string]interface{}{"registry": "secretstore://vela-system/external-registry#password", "password": "secretstore://vela-system/another-registry#password"}
+		err = configService.resolveSensitiveProperties(context.TODO(), "vela-system/external-registry", []string{"password"}, foreign)
+		Expect(err.(*bcode.Bcode).BusinessCode).Should(Equal(bcode.ErrInvalidSecretStoreReference.BusinessCode))
+		delete(foreign, "password")
+		Expect(configService.resolveSensitiveProperties(context.TODO(), "vela-system/external-registry", []string{"password"}, foreign)).Should(Succeed())
+		Expect(foreign["registry"]).Should(Equal("secretstore://vela-system/external-registry#password"))
+
+		By("the resolved config is delivered to the targets")
+		Expect(configService.distributeExternalConfigs(context.TODO(), "default", types.DefaultKubeVelaNS, "external", []*config.NamespacedName{{Name: "external-registry"}},
+			[]*config.ClusterTarget{{ClusterName: types.ClusterLocalName, Namespace: "default"}})).Should(Succeed())
+		var delivered corev1.Secret
+		Expect(k8sClient.Get(context.TODO(), apitypes.NamespacedName{Namespace: "default", Name: "external-registry"}, &delivered)).Should(Succeed())
+		Expect(string(delivered.Data[".dockerconfigjson"])).Should(ContainSubstring("p@ssw0rd"))
+		Expect(delivered.Data).ShouldNot(HaveKey(config.SaveInputPropertiesKey))
+
+		entities, err := ds.List(context.TODO(), &model.ExternalConfigDistribution{Project: "default"}, nil)
+		Expect(err).Should(BeNil())
+		Expect(len(entities)).Should(Equal(1))
+		distributions := mergeExternalConfigDistributions(nil, []*model.ExternalConfigDistribution{entities[0].(*model.ExternalConfigDistribution)})
+		Expect(len(distributions)).Should(Equal(1))
+		status, err := configService.checkConfigDistribution(context.TODO(), "default", distributions[0])
+		Expect(err).Should(BeNil())
+		Expect(status.Targets[0].Status).Should(Equal(model.ConfigTargetSynced))
+
+		By("the rotated value in the secret store drifts the targets")
+		Expect(store.Put(context.TODO(), "vela-system/external-registry", map[string]string{usernameFragment: "admin", passwordFragment: "rotated"})).Should(Succeed())
+		status, err = configService.checkConfigDistribution(context.TODO(), "default", distributions[0])
+		Expect(err).Should(BeNil())
+		Expect(status.Targets[0].Status).Should(Equal(model.ConfigTargetDrifted))
+		Expect(configService.resyncConfigTarget(context.TODO(), distributions[0], status.Targets[0])).Should(Succeed())
+		Expect(k8sClient.Get(context.TODO(), apitypes.NamespacedName{Namespace: "default", Name: "external-registry"}, &delivered)).Should(Succeed())
+		Expect(string(delivered.Data[".dockerconfigjson"])).Should(ContainSubstring("rotated"))
+
+		By("delete the distribution")
+		external, err := configService.deleteExternalConfigDistribution(context.TODO(), "default", "external")
+		Expect(err).Should(BeNil())
+		Expect(external).Should(BeTrue())
+		err = k8sClient.Get(context.TODO(), apitypes.NamespacedName{Namespace: "default", Name: "external-registry"}, &delivered)
+		Expect(apierrors.IsNotFound(err)).Should(BeTrue())
+		Expect(configService.deleteConfigDistributionStatus(context.TODO(), "default", "external")).Should(Succeed())
+
+		By("the failed update keeps the stored values and the rollback restores the sensitive values")
+		configService.ProjectService = projectService
+		configService.RevisionLimit = 5
+		_, err = configService.CreateConfig(context.TODO(), "", v1.CreateConfigRequest{
+			Name:       "versioned-registry",
+			Template:   v1.NamespacedName{Name: "external-image-registry"},
+			Properties: `{"registry":"registry.example.com","username":"admin","password":"first"}`,
+		})
+		Expect(err).Should(BeNil())
+		stored, err := store.Get(context.TODO(), "vela-system/versioned-registry")
+		Expect(err).Should(BeNil())
+		Expect(len(stored)).Should(Equal(2))
+		_, err = configService.UpdateConfig(context.TODO(), "", "versioned-registry", v1.UpdateConfigRequest{
+			Properties: `{"registry":1,"username":"admin","password":"invalid"}`,
+		})
+		Expect(err).ShouldNot(BeNil())
+		data, err = store.Get(context.TODO(), "vela-system/versioned-registry")
+		Expect(err).Should(BeNil())
+		Expect(data).Should(Equal(stored))
+		_, err = configService.UpdateConfig(context.TODO(), "", "versioned-registry", v1.UpdateConfigRequest{
+			Properties: `{"registry":"registry.example.com","username":"admin","password":"second"}`,
+		})
+		Expect(err).Should(BeNil())
+		resolved, err := configService.getConfigSource(context.TODO(), types.DefaultKubeVelaNS, "versioned-registry")
+		Expect(err).Should(BeNil())
+		Expect(string(resolved.Data[".dockerconfigjson"])).Should(ContainSubstring("second"))
+		_, err = configService.RollbackConfig(context.TODO(), "", "versioned-registry", 1)
+		Expect(err).Should(BeNil())
+		resolved, err = configService.getConfigSource(context.TODO(), types.DefaultKubeVelaNS, "versioned-registry")
+		Expect(err).Should(BeNil())
+		Expect(string(resolved.Data[".dockerconfigjson"])).Should(ContainSubstring("first"))
+		Expect(configService.DeleteConfig(context.TODO(), "", "versioned-registry")).Should(Succeed())
+		_, err = store.Get(context.TODO(), "vela-system/versioned-registry")
+		Expect(err).Should(Equal(secretstore.ErrSecretNotExist))
+	})
+})
@@ -22,12 +22,14 @@ import (
 	"fmt"
 	"sort"
 	"strings"
+	"sync"
 
 	"github.com/oam-dev/kubevela/pkg/utils/addon"
 	"github.com/oam-dev/kubevela/pkg/utils/filters"
 	"github.com/oam-dev/kubevela/pkg/utils/schema"
 
 	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/kubevela/workflow/pkg/cue/packages"
 	"github.com/pkg/errors"
 	v1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
@@ -35,6 +37,7 @@ import (
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	k8stypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/rest"
 	"k8s.io/klog/v2"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
@@ -42,6 +45,7 @@ import (
 	"github.com/oam-dev/kubevela/apis/types"
 	"github.com/oam-dev/kubevela/pkg/utils"
 
+	"github.com/kubevela/velaux/pkg/server/infrastructure/datastore"
 	apisv1 "github.com/kubevela/velaux/pkg/server/interfaces/api/dto/v1"
 	"github.com/kubevela/velaux/pkg/server/utils/bcode"
 )
@@ -56,13 +60,35 @@ type DefinitionService interface {
 	AddDefinitionUISchema(ctx context.Context, name, defType string, schema []*schema.UIParameter) ([]*schema.UIParameter, error)
 	// UpdateDefinitionStatus update the status of definition
 	UpdateDefinitionStatus(ctx context.Context, name string, status apisv1.UpdateDefinitionStatusRequest) (*apisv1.DetailDefinitionResponse, error)
+	// CreateDefinition create a definition from the CUE source
+	CreateDefinition(ctx context.Context, req apisv1.CreateDefinitionRequest) (*apisv1.DetailDefinitionResponse, error)
+	// UpdateDefinition update the definition with the CUE source
+	UpdateDefinition(ctx context.Context, name string, req apisv1.UpdateDefinitionRequest) (*apisv1.DetailDefinitionResponse, error)
+	// DeleteDefinition delete the definition
+	DeleteDefinition(ctx context.Context, name, defType string) error
+	// GetDefinitionCUE get the CUE source of the definition
+	GetDefinitionCUE(ctx context.Context, name, defType string) (*apisv1.DefinitionCUEResponse, error)
+	// ListDefinitionRevisions list the revisions of the definition applied by VelaUX
+	ListDefinitionRevisions(ctx context.Context, name, defType string) (*apisv1.ListDefinitionRevisionResponse, error)
+	// RollbackDefinition restore the definition to a revision
+	RollbackDefinition(ctx context.Context, name, defType string, revision int64) (*apisv1.DetailDefinitionResponse, error)
+	// PreviewDefinition render the template of the definition with the sample properties
+	PreviewDefinition(ctx context.Context, req apisv1.PreviewDefinitionRequest) (*apisv1.PreviewDefinitionResponse, error)
 }
 
 // DefinitionHidden means the definition can not be used in VelaUX
 const DefinitionHidden = "true"
 
 type definitionServiceImpl struct {
-	KubeClient client.Client `inject:"kubeClient"`
+	KubeClient client.Client       `inject:"kubeClient"`
+	KubeConfig *rest.Config        `inject:"kubeConfig"`
+	Store      datastore.DataStore `inject:"datastore"`
+	// RevisionLimit the max number of the revisions kept for every definition applied by VelaUX
+	RevisionLimit int
+
+	pdOnce sync.Once
+	pd     *packages.PackageDiscover
+	pdErr  error
 }
 
 // DefinitionQueryOption define a set of query options
@@ -88,8 +114,8 @@ const (
 )
 
 // NewDefinitionService new definition service
-func NewDefinitionService() DefinitionService {
-	return &definitionServiceImpl{}
+func NewDefinitionService(revisionLimit int) DefinitionService {
+	return &definitionServiceImpl{RevisionLimit: revisionLimit}
 }
 
 func (d *definitionServiceImpl) ListDefinitions(ctx context.Context, ops DefinitionQueryOption) ([]*apisv1.DefinitionBase, error) {
@@ -373,6 +399,9 @@ func (d *definitionServiceImpl) UpdateDefinitionStatus(ctx context.Context, name
 	}
 	if !exist && update.HiddenInUI {
 		labels := def.GetLabels()
+		if labels == nil {
+			labels = map[string]string{}
+		}
 		labels[types.LabelDefinitionHidden] = DefinitionHidden
 		def.SetLabels(labels)
 		if err := d.KubeClient.Update(ctx, def); err != nil {
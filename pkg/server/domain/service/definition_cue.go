@@ -0,0 +1,407 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/kubevela/workflow/pkg/cue/model/value"
+	"github.com/kubevela/workflow/pkg/cue/packages"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+
+	"github.com/oam-dev/kubevela/apis/types"
+	velacue "github.com/oam-dev/kubevela/pkg/cue"
+	"github.com/oam-dev/kubevela/pkg/cue/script"
+	"github.com/oam-dev/kubevela/pkg/definition"
+	"github.com/oam-dev/kubevela/pkg/utils/common"
+
+	"github.com/kubevela/velaux/pkg/server/domain/model"
+	"github.com/kubevela/velaux/pkg/server/infrastructure/datastore"
+	apisv1 "github.com/kubevela/velaux/pkg/server/interfaces/api/dto/v1"
+	"github.com/kubevela/velaux/pkg/server/utils/bcode"
+)
+
+// definitionTypeOfKind returns the definition type used by VelaUX of the kind
+func definitionTypeOfKind(kind string) (string, error) {
+	switch kind {
+	case kindComponentDefinition:
+		return "component", nil
+	case kindTraitDefinition:
+		return "trait", nil
+	case kindWorkflowStepDefinition:
+		return "workflowstep", nil
+	case kindPolicyDefinition:
+		return "policy", nil
+	default:
+		return "", bcode.ErrDefinitionTypeNotSupport
+	}
+}
+
+// CreateDefinition create a definition from the CUE source
+func (d *definitionServiceImpl) CreateDefinition(ctx context.Context, req apisv1.CreateDefinitionRequest) (*apisv1.DetailDefinitionResponse, error) {
+	def, template, err := d.parseDefinitionCUE(req.CUE)
+	if err != nil {
+		return nil, err
+	}
+	defType, err := definitionTypeOfKind(def.GetKind())
+	if err != nil {
+		return nil, err
+	}
+	schema, err := d.renderDefinitionSchema(template)
+	if err != nil {
+		return nil, err
+	}
+	def.SetNamespace(types.DefaultKubeVelaNS)
+	if err := d.KubeClient.Create(ctx, &def.Unstructured); err != nil {
+		if apierrors.IsAlreadyExists(err) {
+			return nil, bcode.ErrDefinitionExist
+		}
+		return nil, err
+	}
+	if err := d.recordDefinitionRevision(ctx, def.GetName(), defType, "", req.CUE, 0); err != nil {
+		klog.Errorf("failed to record the revision of the definition %s: %s", def.GetName(), err.Error())
+	}
+	return d.convertDefinitionDetail(ctx, def.Unstructured, defType, schema)
+}
+
+// UpdateDefinition update the definition with the CUE source
+func (d *definitionServiceImpl) UpdateDefinition(ctx context.Context, name string, req apisv1.UpdateDefinitionRequest) (*apisv1.DetailDefinitionResponse, error) {
+	return d.applyDefinitionCUE(ctx, name, req.DefinitionType, req.CUE, 0)
+}
+
+// DeleteDefinition delete the definition, its custom UI schema and its revisions
+func (d *definitionServiceImpl) DeleteDefinition(ctx context.Context, name, defType string) error {
+	existing, err := d.getDefinition(ctx, name, defType)
+	if err != nil {
+		return err
+	}
+	if err := d.KubeClient.Delete(ctx, existing); err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	var cm v1.ConfigMap
+	cm.Namespace = types.DefaultKubeVelaNS
+	cm.Name = fmt.Sprintf("%s-uischema-%s", defType, name)
+	if err := d.KubeClient.Delete(ctx, &cm); err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	revisions, err := d.listDefinitionRevisions(ctx, name, defType)
+	if err != nil {
+		return err
+	}
+	for _, revision := range revisions {
+		if err := d.Store.Delete(ctx, revision); err != nil && !errors.Is(err, datastore.ErrRecordNotExist) {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetDefinitionCUE returns the CUE source of the definition, it is generated from the definition in the cluster
+func (d *definitionServiceImpl) GetDefinitionCUE(ctx context.Context, name, defType string) (*apisv1.DefinitionCUEResponse, error) {
+	existing, err := d.getDefinition(ctx, name, defType)
+	if err != nil {
+		return nil, err
+	}
+	def := definition.Definition{Unstructured: *existing}
+	cueString, err := def.ToCUEString()
+	if err != nil {
+		return nil, err
+	}
+	return &apisv1.DefinitionCUEResponse{Name: name, DefinitionType: defType, CUE: cueString}, nil
+}
+
+// ListDefinitionRevisions list the revisions of the definition applied by VelaUX, the latest one first
+func (d *definitionServiceImpl) ListDefinitionRevisions(ctx context.Context, name, defType string) (*apisv1.ListDefinitionRevisionResponse, error) {
+	if _, _, err := getKindAndVersion(defType); err != nil {
+		return nil, err
+	}
+	revisions, err := d.listDefinitionRevisions(ctx, name, defType)
+	if err != nil {
+		return nil, err
+	}
+	res := &apisv1.ListDefinitionRevisionResponse{Revisions: []*apisv1.DefinitionRevision{}}
+	for i := len(revisions) - 1; i >= 0; i-- {
+		r := revisions[i]
+		res.Revisions = append(res.Revisions, &apisv1.DefinitionRevision{
+			Revision:     r.Revision,
+			CUE:          r.CUE,
+			Operator:     r.Operator,
+			CreateTime:   r.CreateTime,
+			RollbackFrom: r.RollbackFrom,
+		})
+	}
+	return res, nil
+}
+
+// RollbackDefinition apply the CUE source of the revision to the definition
+func (d *definitionServiceImpl) RollbackDefinition(ctx context.Context, name, defType string, revision int64) (*apisv1.DetailDefinitionResponse, error) {
+	target := &model.DefinitionRevision{Name: name, Type: defType, Revision: revision}
+	if err := d.Store.Get(ctx, target); err != nil {
+		if errors.Is(err, datastore.ErrRecordNotExist) {
+			return nil, bcode.ErrDefinitionRevisionNotExist
+		}
+		return nil, err
+	}
+	return d.applyDefinitionCUE(ctx, name, defType, target.CUE, revision)
+}
+
+// PreviewDefinition evaluate the template of the definition with the sample properties, nothing is applied to the cluster
+func (d *definitionServiceImpl) PreviewDefinition(ctx context.Context, req apisv1.PreviewDefinitionRequest) (*apisv1.PreviewDefinitionResponse, error) {
+	def, template, err := d.parseDefinitionCUE(req.CUE)
+	if err != nil {
+		return nil, err
+	}
+	defType, err := definitionTypeOfKind(def.GetKind())
+	if err != nil {
+		return nil, err
+	}
+	schema, err := d.renderDefinitionSchema(template)
+	if err != nil {
+		return nil, err
+	}
+	// the context of an application named preview in the default namespace
+	renderContext := map[string]interface{}{
+		"name":           def.GetName(),
+		"appName":        "preview",
+		"namespace":      "default",
+		"appRevision":    "preview-v1",
+		"appRevisionNum": 1,
+		"cluster":        "local",
+	}
+	for k, v := range req.Context {
+		renderContext[k] = v
+	}
+	properties := req.Properties
+	if properties == nil {
+		properties = map[string]interface{}{}
+	}
+	parameterByte, err := json.Marshal(properties)
+	if err != nil {
+		return nil, bcode.ErrDefinitionRenderFailed.SetMessage(err.Error())
+	}
+	contextByte, err := json.Marshal(renderContext)
+	if err != nil {
+		return nil, bcode.ErrDefinitionRenderFailed.SetMessage(err.Error())
+	}
+	pd, err := d.packageDiscover()
+	if err != nil {
+		return nil, err
+	}
+	render, err := value.NewValue(fmt.Sprintf("%s\n%s\nparameter: %s\ncontext: %s\n", template, velacue.BaseTemplate, parameterByte, contextByte), pd, "")
+	if err != nil {
+		return nil, bcode.ErrDefinitionRenderFailed.SetMessage(err.Error())
+	}
+	if err := render.CueValue().Validate(); err != nil {
+		return nil, bcode.ErrDefinitionRenderFailed.SetMessage(err.Error())
+	}
+	res := &apisv1.PreviewDefinitionResponse{
+		APISchema: schema,
+		UISchema:  renderDefaultUISchema(schema),
+	}
+	for field, target := range map[string]*map[string]interface{}{"output": &res.Output, "outputs": &res.Outputs} {
+		v, err := render.LookupValue(field)
+		if err != nil {
+			continue
+		}
+		if err := v.UnmarshalTo(target); err != nil {
+			return nil, bcode.ErrDefinitionRenderFailed.SetMessage(fmt.Sprintf("failed to render the %s of the %s definition: %s", field, defType, err.Error()))
+		}
+	}
+	return res, nil
+}
+
+// applyDefinitionCUE replace the existing definition with the CUE source, the status of the definition in VelaUX is kept
+func (d *definitionServiceImpl) applyDefinitionCUE(ctx context.Context, name, defType, cueString string, rollbackFrom int64) (*apisv1.DetailDefinitionResponse, error) {
+	existing, err := d.getDefinition(ctx, name, defType)
+	if err != nil {
+		return nil, err
+	}
+	def, template, err := d.parseDefinitionCUE(cueString)
+	if err != nil {
+		return nil, err
+	}
+	if def.GetName() != name || def.GetKind() != existing.GetKind() {
+		return nil, bcode.ErrDefinitionMismatch
+	}
+	schema, err := d.renderDefinitionSchema(template)
+	if err != nil {
+		return nil, err
+	}
+	def.SetNamespace(existing.GetNamespace())
+	def.SetResourceVersion(existing.GetResourceVersion())
+	def.SetOwnerReferences(existing.GetOwnerReferences())
+	if hidden, exist := existing.GetLabels()[types.LabelDefinitionHidden]; exist {
+		labels := def.GetLabels()
+		if labels == nil {
+			labels = map[string]string{}
+		}
+		labels[types.LabelDefinitionHidden] = hidden
+		def.SetLabels(labels)
+	}
+	// the definition created out of VelaUX is recorded as the first revision
+	previous, err := (&definition.Definition{Unstructured: *existing}).ToCUEString()
+	if err != nil {
+		klog.Warningf("failed to generate the CUE source of the definition %s: %s", name, err.Error())
+	}
+	if err := d.KubeClient.Update(ctx, &def.Unstructured); err != nil {
+		return nil, err
+	}
+	if err := d.recordDefinitionRevision(ctx, name, defType, previous, cueString, rollbackFrom); err != nil {
+		klog.Errorf("failed to record the revision of the definition %s: %s", name, err.Error())
+	}
+	return d.convertDefinitionDetail(ctx, def.Unstructured, defType, schema)
+}
+
+func (d *definitionServiceImpl) getDefinition(ctx context.Context, name, defType string) (*unstructured.Unstructured, error) {
+	version, kind, err := getKindAndVersion(defType)
+	if err != nil {
+		return nil, err
+	}
+	def := &unstructured.Unstructured{}
+	def.SetAPIVersion(version)
+	def.SetKind(kind)
+	if err := d.KubeClient.Get(ctx, k8stypes.NamespacedName{Namespace: types.DefaultKubeVelaNS, Name: name}, def); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, bcode.ErrDefinitionNotFound
+		}
+		return nil, err
+	}
+	return def, nil
+}
+
+// parseDefinitionCUE validate the CUE source and convert it to the definition, the template of the definition is returned
+func (d *definitionServiceImpl) parseDefinitionCUE(cueString string) (*definition.Definition, string, error) {
+	def := &definition.Definition{Unstructured: unstructured.Unstructured{Object: map[string]interface{}{}}}
+	if err := def.FromCUEString(cueString, d.KubeConfig); err != nil {
+		return nil, "", bcode.ErrInvalidDefinitionCUE.SetMessage(err.Error())
+	}
+	template, _, err := unstructured.NestedString(def.Object, definition.DefinitionTemplateKeys...)
+	if err != nil {
+		return nil, "", bcode.ErrInvalidDefinitionCUE.SetMessage(err.Error())
+	}
+	return def, template, nil
+}
+
+// renderDefinitionSchema generate the OpenAPI schema from the parameter of the template
+func (d *definitionServiceImpl) renderDefinitionSchema(template string) (*openapi3.Schema, error) {
+	pd, err := d.packageDiscover()
+	if err != nil {
+		return nil, err
+	}
+	val, err := value.NewValue(template+"\n"+velacue.BaseTemplate, pd, "")
+	if err != nil {
+		return nil, bcode.ErrInvalidDefinitionCUE.SetMessage(err.Error())
+	}
+	data, err := common.GenOpenAPI(val)
+	if err != nil {
+		return nil, bcode.ErrInvalidDefinitionCUE.SetMessage(err.Error())
+	}
+	schema, err := script.ConvertOpenAPISchema2SwaggerObject(data)
+	if err != nil {
+		return nil, bcode.ErrInvalidDefinitionCUE.SetMessage(err.Error())
+	}
+	script.FixOpenAPISchema("", schema)
+	return schema, nil
+}
+
+// packageDiscover returns the discover of the kubernetes packages imported by the templates, nil means they are not supported
+func (d *definitionServiceImpl) packageDiscover() (*packages.PackageDiscover, error) {
+	if d.KubeConfig == nil {
+		return nil, nil
+	}
+	d.pdOnce.Do(func() {
+		d.pd, d.pdErr = packages.NewPackageDiscover(d.KubeConfig)
+	})
+	return d.pd, d.pdErr
+}
+
+func (d *definitionServiceImpl) convertDefinitionDetail(ctx context.Context, def unstructured.Unstructured, defType string, schema *openapi3.Schema) (*apisv1.DetailDefinitionResponse, error) {
+	base, err := convertDefinitionBase(def, def.GetKind())
+	if err != nil {
+		return nil, err
+	}
+	return &apisv1.DetailDefinitionResponse{
+		DefinitionBase: *base,
+		APISchema:      schema,
+		UISchema:       renderCustomUISchema(ctx, d.KubeClient, def.GetName(), defType, renderDefaultUISchema(schema)),
+	}, nil
+}
+
+// listDefinitionRevisions returns the revisions of the definition, the earliest one first
+func (d *definitionServiceImpl) listDefinitionRevisions(ctx context.Context, name, defType string) ([]*model.DefinitionRevision, error) {
+	entities, err := d.Store.List(ctx, &model.DefinitionRevision{Name: name, Type: defType}, nil)
+	if err != nil {
+		return nil, err
+	}
+	var revisions []*model.DefinitionRevision
+	for _, entity := range entities {
+		revisions = append(revisions, entity.(*model.DefinitionRevision))
+	}
+	sort.Slice(revisions, func(i, j int) bool {
+		return revisions[i].Revision < revisions[j].Revision
+	})
+	return revisions, nil
+}
+
+// recordDefinitionRevision record the new revision of the definition and remove the revisions out of the limit.
+// The previous CUE source is recorded as the first revision if the definition has no history.
+func (d *definitionServiceImpl) recordDefinitionRevision(ctx context.Context, name, defType, previous, current string, rollbackFrom int64) error {
+	if d.RevisionLimit <= 0 {
+		return nil
+	}
+	revisions, err := d.listDefinitionRevisions(ctx, name, defType)
+	if err != nil {
+		return err
+	}
+	if len(revisions) == 0 && previous != "" {
+		baseline := &model.DefinitionRevision{Name: name, Type: defType, Revision: 1, CUE: previous}
+		if err := d.Store.Add(ctx, baseline); err != nil {
+			return err
+		}
+		revisions = append(revisions, baseline)
+	}
+	revision := &model.DefinitionRevision{Name: name, Type: defType, Revision: 1, CUE: current, RollbackFrom: rollbackFrom}
+	if len(revisions) > 0 {
+		revision.Revision = revisions[len(revisions)-1].Revision + 1
+	}
+	if user := ctx.Value(&apisv1.CtxKeyUser); user != nil {
+		if username, ok := user.(string); ok {
+			revision.Operator = username
+		}
+	}
+	revision.SetCreateTime(time.Now())
+	if err := d.Store.Add(ctx, revision); err != nil {
+		return err
+	}
+	revisions = append(revisions, revision)
+	for i := 0; i < len(revisions)-d.RevisionLimit; i++ {
+		if err := d.Store.Delete(ctx, revisions[i]); err != nil && !errors.Is(err, datastore.ErrRecordNotExist) {
+			return err
+		}
+	}
+	return nil
+}
@@ -19,6 +19,7 @@ package service
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"os"
 	"testing"
 
@@ -37,6 +38,7 @@ import (
 	"github.com/oam-dev/kubevela/pkg/utils/schema"
 
 	v1 "github.com/kubevela/velaux/pkg/server/interfaces/api/dto/v1"
+	"github.com/kubevela/velaux/pkg/server/utils/bcode"
 )
 
 var _ = Describe("Test namespace service functions", func() {
@@ -267,6 +269,104 @@ var _ = Describe("Test namespace service functions", func() {
 		Expect(detail.Status).Should(Equal("enable"))
 	})
 
+	It("Test authoring the definition with the CUE source", func() {
+		du := &definitionServiceImpl{
+			KubeClient:    k8sClient,
+			Store:         ds,
+			RevisionLimit: 2,
+		}
+		ctx := context.WithValue(context.TODO(), &v1.CtxKeyUser, "admin")
+		source := func(replicas int) string {
+			return fmt.Sprintf(`"authoring-worker": {
+	type: "component"
+	attributes: workload: definition: {
+		apiVersion: "apps/v1"
+		kind:       "Deployment"
+	}
+	description: "authoring worker"
+}
+template: {
+	output: {
+		apiVersion: "apps/v1"
+		kind:       "Deployment"
+		metadata: name: context.name
+		spec: replicas: %d
+		spec: template: spec: containers: [{name: context.name, image: parameter.image}]
+	}
+	parameter: {
+		// +usage=the image of the worker
+		image: string
+	}
+}
+`, replicas)
+		}
+
+		By("Test creating the definition")
+		_, err := du.CreateDefinition(ctx, v1.CreateDefinitionRequest{CUE: "invalid: {"})
+		Expect(err.(*bcode.Bcode).BusinessCode).Should(Equal(bcode.ErrInvalidDefinitionCUE.BusinessCode))
+		detail, err := du.CreateDefinition(ctx, v1.CreateDefinitionRequest{CUE: source(1)})
+		Expect(err).Should(Succeed())
+		Expect(detail.Name).Should(Equal("authoring-worker"))
+		Expect(detail.APISchema.Properties["image"]).ShouldNot(BeNil())
+		_, err = du.CreateDefinition(ctx, v1.CreateDefinitionRequest{CUE: source(1)})
+		Expect(err).Should(Equal(bcode.ErrDefinitionExist))
+
+		By("Test updating the definition")
+		_, err = du.UpdateDefinition(ctx, "other-worker", v1.UpdateDefinitionRequest{DefinitionType: "component", CUE: source(2)})
+		Expect(err).Should(Equal(bcode.ErrDefinitionNotFound))
+		_, err = du.UpdateDefinitionStatus(ctx, "authoring-worker", v1.UpdateDefinitionStatusRequest{DefinitionType: "component", HiddenInUI: true})
+		Expect(err).Should(Succeed())
+		detail, err = du.UpdateDefinition(ctx, "authoring-worker", v1.UpdateDefinitionRequest{DefinitionType: "component", CUE: source(2)})
+		Expect(err).Should(Succeed())
+		Expect(detail.Status).Should(Equal("disable"))
+		cue, err := du.GetDefinitionCUE(ctx, "authoring-worker", "component")
+		Expect(err).Should(Succeed())
+		Expect(cue.CUE).Should(ContainSubstring("replicas: 2"))
+
+		By("Test the revisions of the definition")
+		_, err = du.UpdateDefinition(ctx, "authoring-worker", v1.UpdateDefinitionRequest{DefinitionType: "component", CUE: source(3)})
+		Expect(err).Should(Succeed())
+		revisions, err := du.ListDefinitionRevisions(ctx, "authoring-worker", "component")
+		Expect(err).Should(Succeed())
+		Expect(len(revisions.Revisions)).Should(Equal(2))
+		Expect(revisions.Revisions[0].Revision).Should(Equal(int64(3)))
+		Expect(revisions.Revisions[0].Operator).Should(Equal("admin"))
+
+		_, err = du.RollbackDefinition(ctx, "authoring-worker", "component", 1)
+		Expect(err).Should(Equal(bcode.ErrDefinitionRevisionNotExist))
+		_, err = du.RollbackDefinition(ctx, "authoring-worker", "component", 2)
+		Expect(err).Should(Succeed())
+		cue, err = du.GetDefinitionCUE(ctx, "authoring-worker", "component")
+		Expect(err).Should(Succeed())
+		Expect(cue.CUE).Should(ContainSubstring("replicas: 2"))
+		revisions, err = du.ListDefinitionRevisions(ctx, "authoring-worker", "component")
+		Expect(err).Should(Succeed())
+		Expect(revisions.Revisions[0].Revision).Should(Equal(int64(4)))
+		Expect(revisions.Revisions[0].RollbackFrom).Should(Equal(int64(2)))
+
+		By("Test previewing the definition")
+		preview, err := du.PreviewDefinition(ctx, v1.PreviewDefinitionRequest{
+			CUE:        source(1),
+			Properties: map[string]interface{}{"image": "nginx"},
+			Context:    map[string]interface{}{"name": "web"},
+		})
+		Expect(err).Should(Succeed())
+		Expect(cmp.Diff(preview.Output["metadata"], map[string]interface{}{"name": "web"})).Should(BeEmpty())
+		Expect(preview.UISchema).ShouldNot(BeEmpty())
+		_, err = du.PreviewDefinition(ctx, v1.PreviewDefinitionRequest{
+			CUE:        source(1),
+			Properties: map[string]interface{}{"image": 1},
+		})
+		Expect(err.(*bcode.Bcode).BusinessCode).Should(Equal(bcode.ErrDefinitionRenderFailed.BusinessCode))
+
+		By("Test deleting the definition")
+		Expect(du.DeleteDefinition(ctx, "authoring-worker", "component")).Should(Succeed())
+		_, err = du.GetDefinitionCUE(ctx, "authoring-worker", "component")
+		Expect(err).Should(Equal(bcode.ErrDefinitionNotFound))
+		revisions, err = du.ListDefinitionRevisions(ctx, "authoring-worker", "component")
+		Expect(err).Should(Succeed())
+		Expect(len(revisions.Revisions)).Should(Equal(0))
+	})
 })
 
 func testSortDefaultUISchema() {
@@ -195,6 +195,9 @@ func (p *envServiceImpl) UpdateEnv(ctx context.Context, name string, req apisv1.
 		klog.Errorf("check if env name exists failure %s", err.Error())
 		return nil, bcode.ErrEnvNotExisted
 	}
+	if err := ensureProjectWritable(ctx, p.Store, env.Project); err != nil {
+		return nil, err
+	}
 	if req.Alias != "" {
 		env.Alias = req.Alias
 	}
@@ -269,6 +272,13 @@ func (p *envServiceImpl) CreateEnv(ctx context.Context, req apisv1.CreateEnvRequ
 		Targets:     req.Targets,
 	}
 
+	if err := ensureProjectWritable(ctx, p.Store, req.Project); err != nil {
+		return nil, err
+	}
+	if err := checkProjectQuota(ctx, p.Store, req.Project, quotaEnvs, &model.Env{Project: req.Project}); err != nil {
+		return nil, err
+	}
+
 	if !req.AllowTargetConflict {
 		pass, err := p.checkEnvTarget(ctx, req.Project, req.Name, req.Targets)
 		if err != nil || !pass {
@@ -340,6 +350,7 @@ func convertEnvModel2Base(env *model.Env, targets []*model.Target) *apisv1.Env {
 		Namespace:   env.Namespace,
 		CreateTime:  env.CreateTime,
 		UpdateTime:  env.UpdateTime,
+		Health:      env.Health,
 	}
 	for _, dt := range env.Targets {
 		var t *model.Target
@@ -0,0 +1,385 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/oam-dev/kubevela/apis/types"
+	"github.com/oam-dev/kubevela/pkg/auth"
+	"github.com/oam-dev/kubevela/pkg/multicluster"
+	"github.com/oam-dev/kubevela/pkg/oam"
+
+	"github.com/kubevela/velaux/pkg/server/domain/model"
+	"github.com/kubevela/velaux/pkg/server/domain/repository"
+	"github.com/kubevela/velaux/pkg/server/infrastructure/datastore"
+	apisv1 "github.com/kubevela/velaux/pkg/server/interfaces/api/dto/v1"
+	"github.com/kubevela/velaux/pkg/server/utils"
+)
+
+// NamespaceReconcileService verify the namespaces and the privileges of the targets and the envs
+type NamespaceReconcileService interface {
+	// ReconcileNamespaces check all targets and envs, the drifts are repaired if the repair mode is enabled
+	ReconcileNamespaces(ctx context.Context) error
+	ReconcileTarget(ctx context.Context, target *model.Target, repair bool) (*model.NamespaceHealth, error)
+	ReconcileEnv(ctx context.Context, env *model.Env, repair bool) (*model.NamespaceHealth, error)
+	ListNamespaceHealth(ctx context.Context, projectName string) (*apisv1.ListNamespaceHealthResponse, error)
+}
+
+type namespaceReconcileServiceImpl struct {
+	Store      datastore.DataStore `inject:"datastore"`
+	KubeClient client.Client       `inject:"kubeClient"`
+	// Repair repair the drifts found by the periodic reconciliation
+	Repair bool
+}
+
+// NewNamespaceReconcileService new namespace reconcile service
+func NewNamespaceReconcileService(repair bool) NamespaceReconcileService {
+	return &namespaceReconcileServiceImpl{Repair: repair}
+}
+
+// ReconcileNamespaces check all targets and envs
+func (n *namespaceReconcileServiceImpl) ReconcileNamespaces(ctx context.Context) error {
+	targets, err := n.Store.List(ctx, &model.Target{}, nil)
+	if err != nil {
+		return err
+	}
+	for _, entity := range targets {
+		target := entity.(*model.Target)
+		if _, err := n.ReconcileTarget(ctx, target, n.Repair); err != nil {
+			klog.Errorf("failed to reconcile the namespace of the target %s: %s", target.Name, err.Error())
+		}
+	}
+	envs, err := n.Store.List(ctx, &model.Env{}, nil)
+	if err != nil {
+		return err
+	}
+	for _, entity := range envs {
+		env := entity.(*model.Env)
+		if _, err := n.ReconcileEnv(ctx, env, n.Repair); err != nil {
+			klog.Errorf("failed to reconcile the namespace of the env %s: %s", env.Name, err.Error())
+		}
+	}
+	return nil
+}
+
+// ReconcileTarget check the namespace and the privileges of the target and record the health status
+func (n *namespaceReconcileServiceImpl) ReconcileTarget(ctx context.Context, target *model.Target, repair bool) (*model.NamespaceHealth, error) {
+	if target.Cluster == nil {
+		return nil, nil
+	}
+	expectLabels := map[string]string{
+		oam.LabelRuntimeNamespaceUsage: oam.VelaNamespaceUsageTarget,
+		oam.LabelNamespaceOfTargetName: target.Name,
+	}
+	clusters, err := repository.ResolveTargetClusters(ctx, n.Store, n.KubeClient, target)
+	if err != nil {
+		return nil, err
+	}
+	health := &model.NamespaceHealth{Status: model.NamespaceHealthy, LastCheckTime: time.Now()}
+	for _, cluster := range clusters {
+		cluster := cluster
+		privilege := &auth.ScopedPrivilege{Cluster: cluster, Namespace: target.Cluster.Namespace}
+		clusterHealth := n.reconcile(ctx, cluster, target.Cluster.Namespace, expectLabels, privilege, target.Project, repair, func() error {
+			if err := repository.CreateTargetNamespace(ctx, n.KubeClient, cluster, target.Cluster.Namespace, target.Name); err != nil {
+				return err
+			}
+			return managePrivilegesForTarget(ctx, n.KubeClient, target, []string{cluster}, false)
+		})
+		mergeNamespaceHealth(health, clusterHealth)
+	}
+	if repair {
+		kept, err := releaseStaleClusters(ctx, n.Store, n.KubeClient, target, clusters)
+		if err != nil {
+			return nil, err
+		}
+		if err := recordProvisionedClusters(ctx, n.Store, target, kept); err != nil {
+			return nil, err
+		}
+	}
+	if err := n.recordTargetHealth(ctx, target, health); err != nil {
+		return nil, err
+	}
+	return health, nil
+}
+
+// recordTargetHealth write the health status into the latest record of the target, so the changes made to the target
+// during the reconciliation are not overridden. The target deleted meanwhile is not recorded.
+func (n *namespaceReconcileServiceImpl) recordTargetHealth(ctx context.Context, target *model.Target, health *model.NamespaceHealth) error {
+	latest := &model.Target{Name: target.Name}
+	if err := n.Store.Get(ctx, latest); err != nil {
+		if errors.Is(err, datastore.ErrRecordNotExist) {
+			return nil
+		}
+		return err
+	}
+	latest.Health = health
+	if err := n.Store.Put(ctx, latest); err != nil {
+		return err
+	}
+	target.Health = health
+	return nil
+}
+
+// ReconcileEnv check the namespace and the privileges of the env and record the health status
+func (n *namespaceReconcileServiceImpl) ReconcileEnv(ctx context.Context, env *model.Env, repair bool) (*model.NamespaceHealth, error) {
+	expectLabels := map[string]string{
+		oam.LabelControlPlaneNamespaceUsage: oam.VelaNamespaceUsageEnv,
+		oam.LabelNamespaceOfEnvName:         env.Name,
+	}
+	privilege := &auth.ApplicationPrivilege{Cluster: types.ClusterLocalName, Namespace: env.Namespace}
+	health := n.reconcile(ctx, types.ClusterLocalName, env.Namespace, expectLabels, privilege, env.Project, repair, func() error {
+		if err := repository.CreateEnvNamespace(ctx, n.KubeClient, env); err != nil {
+			return err
+		}
+		return managePrivilegesForEnvironment(ctx, n.KubeClient, env, false)
+	})
+	if err := n.recordEnvHealth(ctx, env, health); err != nil {
+		return nil, err
+	}
+	return health, nil
+}
+
+// recordEnvHealth write the health status into the latest record of the env, the env deleted meanwhile is not recorded
+func (n *namespaceReconcileServiceImpl) recordEnvHealth(ctx context.Context, env *model.Env, health *model.NamespaceHealth) error {
+	latest := &model.Env{Name: env.Name}
+	if err := n.Store.Get(ctx, latest); err != nil {
+		if errors.Is(err, datastore.ErrRecordNotExist) {
+			return nil
+		}
+		return err
+	}
+	latest.Health = health
+	if err := n.Store.Put(ctx, latest); err != nil {
+		return err
+	}
+	env.Health = health
+	return nil
+}
+
+func (n *namespaceReconcileServiceImpl) reconcile(ctx context.Context, cluster, namespace string, expectLabels map[string]string,
+	privilege auth.PrivilegeDescription, project string, repair bool, repairFunc func() error) *model.NamespaceHealth {
+	subjects := (&auth.Identity{Groups: []string{utils.KubeVelaProjectGroupPrefix + project}}).Subjects()
+	health := &model.NamespaceHealth{LastCheckTime: time.Now()}
+	findings, err := n.checkNamespace(ctx, cluster, namespace, expectLabels, privilege, subjects)
+	if err != nil {
+		health.Status = model.NamespaceUnreachable
+		health.Findings = []string{err.Error()}
+		return health
+	}
+	if repair && len(findings) > 0 {
+		if err := repairFunc(); err != nil {
+			findings = append(findings, fmt.Sprintf("failed to repair: %s", err.Error()))
+		} else {
+			health.Repaired = findings
+			findings, err = n.checkNamespace(ctx, cluster, namespace, expectLabels, privilege, subjects)
+			if err != nil {
+				health.Status = model.NamespaceUnreachable
+				health.Findings = []string{err.Error()}
+				return health
+			}
+		}
+	}
+	health.Status = model.NamespaceHealthy
+	if len(findings) > 0 {
+		health.Status = model.NamespaceDrifted
+	}
+	health.Findings = findings
+	return health
+}
+
+// mergeNamespaceHealth merge the health of the namespace in one of the clusters, the worst status wins
+func mergeNamespaceHealth(health, clusterHealth *model.NamespaceHealth) {
+	health.Findings = append(health.Findings, clusterHealth.Findings...)
+	health.Repaired = append(health.Repaired, clusterHealth.Repaired...)
+	switch {
+	case clusterHealth.Status == model.NamespaceUnreachable:
+		health.Status = model.NamespaceUnreachable
+	case clusterHealth.Status == model.NamespaceDrifted && health.Status == model.NamespaceHealthy:
+		health.Status = model.NamespaceDrifted
+	}
+}
+
+// checkNamespace returns the drifts of the namespace, the error means the cluster could not be reached
+func (n *namespaceReconcileServiceImpl) checkNamespace(ctx context.Context, cluster, namespace string, expectLabels map[string]string,
+	privilege auth.PrivilegeDescription, subjects []rbacv1.Subject) ([]string, error) {
+	ctx = multicluster.ContextWithClusterName(ctx, cluster)
+	var findings []string
+	var ns corev1.Namespace
+	if err := n.KubeClient.Get(ctx, client.ObjectKey{Name: namespace}, &ns); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return nil, fmt.Errorf("failed to get the namespace %s in the cluster %s: %w", namespace, cluster, err)
+		}
+		findings = append(findings, fmt.Sprintf("the namespace %s is not found in the cluster %s", namespace, cluster))
+	} else {
+		var keys []string
+		for key := range expectLabels {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			if ns.Labels[key] != expectLabels[key] {
+				findings = append(findings, fmt.Sprintf("the label %s of the namespace %s is %q, expected %q", key, namespace, ns.Labels[key], expectLabels[key]))
+			}
+		}
+	}
+
+	for _, role := range privilege.GetRoles() {
+		finding, err := n.checkRole(ctx, role)
+		if err != nil {
+			return nil, err
+		}
+		if finding != "" {
+			findings = append(findings, finding)
+		}
+	}
+	finding, err := n.checkRoleBinding(ctx, privilege.GetRoleBinding(subjects))
+	if err != nil {
+		return nil, err
+	}
+	if finding != "" {
+		findings = append(findings, finding)
+	}
+	return findings, nil
+}
+
+func (n *namespaceReconcileServiceImpl) checkRole(ctx context.Context, expected client.Object) (string, error) {
+	var kind string
+	var existing client.Object
+	var rulesOf func(obj client.Object) []rbacv1.PolicyRule
+	switch expected.(type) {
+	case *rbacv1.ClusterRole:
+		kind, existing = "ClusterRole", &rbacv1.ClusterRole{}
+		rulesOf = func(obj client.Object) []rbacv1.PolicyRule { return obj.(*rbacv1.ClusterRole).Rules }
+	case *rbacv1.Role:
+		kind, existing = "Role", &rbacv1.Role{}
+		rulesOf = func(obj client.Object) []rbacv1.PolicyRule { return obj.(*rbacv1.Role).Rules }
+	default:
+		return "", nil
+	}
+	if err := n.KubeClient.Get(ctx, client.ObjectKeyFromObject(expected), existing); err != nil {
+		if apierrors.IsNotFound(err) {
+			return fmt.Sprintf("the %s %s is not found", kind, expected.GetName()), nil
+		}
+		return "", err
+	}
+	if !equality.Semantic.DeepEqual(rulesOf(existing), rulesOf(expected)) {
+		return fmt.Sprintf("the rules of the %s %s are modified", kind, expected.GetName()), nil
+	}
+	return "", nil
+}
+
+func (n *namespaceReconcileServiceImpl) checkRoleBinding(ctx context.Context, expected client.Object) (string, error) {
+	var kind string
+	var existing client.Object
+	var specOf func(obj client.Object) (rbacv1.RoleRef, []rbacv1.Subject)
+	switch expected.(type) {
+	case *rbacv1.RoleBinding:
+		kind, existing = "RoleBinding", &rbacv1.RoleBinding{}
+		specOf = func(obj client.Object) (rbacv1.RoleRef, []rbacv1.Subject) {
+			binding := obj.(*rbacv1.RoleBinding)
+			return binding.RoleRef, binding.Subjects
+		}
+	case *rbacv1.ClusterRoleBinding:
+		kind, existing = "ClusterRoleBinding", &rbacv1.ClusterRoleBinding{}
+		specOf = func(obj client.Object) (rbacv1.RoleRef, []rbacv1.Subject) {
+			binding := obj.(*rbacv1.ClusterRoleBinding)
+			return binding.RoleRef, binding.Subjects
+		}
+	default:
+		return "", nil
+	}
+	if err := n.KubeClient.Get(ctx, client.ObjectKeyFromObject(expected), existing); err != nil {
+		if apierrors.IsNotFound(err) {
+			return fmt.Sprintf("the %s %s is not found", kind, expected.GetName()), nil
+		}
+		return "", err
+	}
+	existingRef, existingSubjects := specOf(existing)
+	expectedRef, expectedSubjects := specOf(expected)
+	if existingRef != expectedRef {
+		return fmt.Sprintf("the role of the %s %s is changed to %s", kind, expected.GetName(), existingRef.Name), nil
+	}
+	for _, subject := range expectedSubjects {
+		if !containsSubject(existingSubjects, subject) {
+			return fmt.Sprintf("the subject %s is removed from the %s %s", subject.Name, kind, expected.GetName()), nil
+		}
+	}
+	return "", nil
+}
+
+func containsSubject(subjects []rbacv1.Subject, subject rbacv1.Subject) bool {
+	for _, s := range subjects {
+		if s.Kind == subject.Kind && s.Name == subject.Name && s.Namespace == subject.Namespace {
+			return true
+		}
+	}
+	return false
+}
+
+// ListNamespaceHealth list the namespace health of the targets and the envs in the project
+func (n *namespaceReconcileServiceImpl) ListNamespaceHealth(ctx context.Context, projectName string) (*apisv1.ListNamespaceHealthResponse, error) {
+	resp := &apisv1.ListNamespaceHealthResponse{Items: []apisv1.NamespaceHealthItem{}}
+	targets, err := repository.ListTarget(ctx, n.Store, projectName, nil)
+	if err != nil {
+		return nil, err
+	}
+	for _, target := range targets {
+		if target.Cluster == nil {
+			continue
+		}
+		resp.Items = append(resp.Items, apisv1.NamespaceHealthItem{
+			Kind:      "target",
+			Name:      target.Name,
+			Cluster:   target.Cluster.ClusterName,
+			Namespace: target.Cluster.Namespace,
+			Health:    target.Health,
+		})
+	}
+	envs, err := repository.ListEnvs(ctx, n.Store, &datastore.ListOptions{
+		FilterOptions: datastore.FilterOptions{In: []datastore.InQueryOption{{Key: "project", Values: []string{projectName}}}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, env := range envs {
+		resp.Items = append(resp.Items, apisv1.NamespaceHealthItem{
+			Kind:      "env",
+			Name:      env.Name,
+			Cluster:   types.ClusterLocalName,
+			Namespace: env.Namespace,
+			Health:    env.Health,
+		})
+	}
+	return resp, nil
+}
+
+// NewTestNamespaceReconcileService create the namespace reconcile service instance for testing
+func NewTestNamespaceReconcileService(ds datastore.DataStore, c client.Client) NamespaceReconcileService {
+	return &namespaceReconcileServiceImpl{Store: ds, KubeClient: c}
+}
@@ -0,0 +1,127 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/oam-dev/kubevela/pkg/oam"
+
+	"github.com/kubevela/velaux/pkg/server/domain/model"
+	apisv1 "github.com/kubevela/velaux/pkg/server/interfaces/api/dto/v1"
+	"github.com/kubevela/velaux/pkg/server/utils"
+)
+
+var _ = Describe("Test namespace reconcile service functions", func() {
+	var reconcileService *namespaceReconcileServiceImpl
+
+	BeforeEach(func() {
+		InitTestEnv("namespace-reconcile-test-" + strconv.FormatInt(time.Now().UnixNano(), 10))
+		reconcileService = NewTestNamespaceReconcileService(ds, k8sClient).(*namespaceReconcileServiceImpl)
+		_, err := projectService.CreateProject(context.TODO(), apisv1.CreateProjectRequest{Name: "reconcile", Owner: "admin"})
+		Expect(err).Should(BeNil())
+		_, err = targetService.CreateTarget(context.TODO(), apisv1.CreateTargetRequest{
+			Name: "reconcile-dev", Project: "reconcile", Cluster: &apisv1.ClusterTarget{ClusterName: "local", Namespace: "reconcile-dev"},
+		})
+		Expect(err).Should(BeNil())
+		_, err = envService.CreateEnv(context.TODO(), apisv1.CreateEnvRequest{Name: "reconcile-env", Namespace: "reconcile-env", Project: "reconcile"})
+		Expect(err).Should(BeNil())
+	})
+
+	It("Test detecting and repairing the drifted target", func() {
+		target, err := targetService.GetTarget(context.TODO(), "reconcile-dev")
+		Expect(err).Should(BeNil())
+		health, err := reconcileService.ReconcileTarget(context.TODO(), target, false)
+		Expect(err).Should(BeNil())
+		Expect(health.Status).Should(Equal(model.NamespaceHealthy))
+
+		var ns corev1.Namespace
+		Expect(k8sClient.Get(context.TODO(), client.ObjectKey{Name: "reconcile-dev"}, &ns)).Should(BeNil())
+		delete(ns.Labels, oam.LabelNamespaceOfTargetName)
+		Expect(k8sClient.Update(context.TODO(), &ns)).Should(BeNil())
+		var binding rbacv1.RoleBinding
+		Expect(k8sClient.Get(context.TODO(), client.ObjectKey{Namespace: "reconcile-dev", Name: "kubevela:writer:binding"}, &binding)).Should(BeNil())
+		binding.Subjects = []rbacv1.Subject{{Kind: rbacv1.UserKind, APIGroup: rbacv1.GroupName, Name: "someone"}}
+		Expect(k8sClient.Update(context.TODO(), &binding)).Should(BeNil())
+
+		health, err = reconcileService.ReconcileTarget(context.TODO(), target, false)
+		Expect(err).Should(BeNil())
+		Expect(health.Status).Should(Equal(model.NamespaceDrifted))
+		Expect(len(health.Findings)).Should(Equal(2))
+		target, err = targetService.GetTarget(context.TODO(), "reconcile-dev")
+		Expect(err).Should(BeNil())
+		Expect(target.Health.Status).Should(Equal(model.NamespaceDrifted))
+
+		health, err = reconcileService.ReconcileTarget(context.TODO(), target, true)
+		Expect(err).Should(BeNil())
+		Expect(health.Status).Should(Equal(model.NamespaceHealthy))
+		Expect(len(health.Repaired)).Should(Equal(2))
+		Expect(k8sClient.Get(context.TODO(), client.ObjectKey{Namespace: "reconcile-dev", Name: "kubevela:writer:binding"}, &binding)).Should(BeNil())
+		Expect(containsSubject(binding.Subjects, rbacv1.Subject{Kind: rbacv1.GroupKind, Name: utils.KubeVelaProjectGroupPrefix + "reconcile"})).Should(BeTrue())
+	})
+
+	It("Test recording the health keeps the changes made during the reconciliation", func() {
+		stale, err := targetService.GetTarget(context.TODO(), "reconcile-dev")
+		Expect(err).Should(BeNil())
+		updated, err := targetService.GetTarget(context.TODO(), "reconcile-dev")
+		Expect(err).Should(BeNil())
+		_, err = targetService.UpdateTarget(context.TODO(), updated, apisv1.UpdateTargetRequest{Alias: "renamed"})
+		Expect(err).Should(BeNil())
+		_, err = reconcileService.ReconcileTarget(context.TODO(), stale, false)
+		Expect(err).Should(BeNil())
+		target, err := targetService.GetTarget(context.TODO(), "reconcile-dev")
+		Expect(err).Should(BeNil())
+		Expect(target.Alias).Should(Equal("renamed"))
+		Expect(target.Health).ShouldNot(BeNil())
+
+		staleEnv := &model.Env{Name: "reconcile-env"}
+		Expect(ds.Get(context.TODO(), staleEnv)).Should(Succeed())
+		_, err = envService.UpdateEnv(context.TODO(), "reconcile-env", apisv1.UpdateEnvRequest{Alias: "renamed"})
+		Expect(err).Should(BeNil())
+		_, err = reconcileService.ReconcileEnv(context.TODO(), staleEnv, false)
+		Expect(err).Should(BeNil())
+		env := &model.Env{Name: "reconcile-env"}
+		Expect(ds.Get(context.TODO(), env)).Should(Succeed())
+		Expect(env.Alias).Should(Equal("renamed"))
+		Expect(env.Health).ShouldNot(BeNil())
+	})
+
+	It("Test reconciling all namespaces and listing the health", func() {
+		var ns corev1.Namespace
+		Expect(k8sClient.Get(context.TODO(), client.ObjectKey{Name: "reconcile-env"}, &ns)).Should(BeNil())
+		Expect(k8sClient.Delete(context.TODO(), &ns)).Should(BeNil())
+
+		Expect(reconcileService.ReconcileNamespaces(context.TODO())).Should(BeNil())
+		resp, err := reconcileService.ListNamespaceHealth(context.TODO(), "reconcile")
+		Expect(err).Should(BeNil())
+		Expect(len(resp.Items)).Should(Equal(2))
+		for _, item := range resp.Items {
+			Expect(item.Health).ShouldNot(BeNil())
+			if item.Kind == "target" {
+				Expect(item.Health.Status).Should(Equal(model.NamespaceHealthy))
+			}
+		}
+	})
+})
@@ -759,6 +759,9 @@ func (p pipelineServiceImpl) RunPipeline(ctx context.Context, pipeline apis.Pipe
 		return nil, err
 	}
 	project := ctx.Value(&apis.CtxKeyProject).(*model.Project)
+	if err := checkPipelineRunQuota(ctx, p.KubeClient, project); err != nil {
+		return nil, err
+	}
 	run := v1alpha1.WorkflowRun{}
 	version := utils.GenerateVersion("")
 	name := fmt.Sprintf("%s-%s", pipeline.Name, version)
@@ -18,7 +18,14 @@ package service
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
 
 	"github.com/kubevela/velaux/pkg/server/domain/model"
 	"github.com/kubevela/velaux/pkg/server/infrastructure/datastore"
@@ -29,8 +36,10 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 
+	pkgmulticluster "github.com/kubevela/pkg/multicluster"
 	"github.com/oam-dev/kubevela/pkg/utils"
 
+	"github.com/kubevela/velaux/pkg/plugin/limiter"
 	"github.com/kubevela/velaux/pkg/plugin/loader"
 	"github.com/kubevela/velaux/pkg/plugin/registry"
 	"github.com/kubevela/velaux/pkg/plugin/types"
@@ -68,18 +77,41 @@ type PluginService interface {
 	GetPlugin(ctx context.Context, pluginID string) (*types.Plugin, error)
 	GetPluginSetting(ctx context.Context, pluginID string) (*model.PluginSetting, error)
 	InitPluginRole(ctx context.Context, plugin *types.Plugin) error
+	// InitPluginRoleInCluster grant the kube permissions of the plugin in the managed cluster, it is only done once for each plugin and cluster.
+	InitPluginRoleInCluster(ctx context.Context, plugin *types.Plugin, cluster string) error
 	Init(ctx context.Context) error
+
+	// For the plugin development mode
+	Reload(ctx context.Context) error
+	ListPluginEvents(ctx context.Context, since int64) *v1.ListPluginEventsResponse
+	AddChangeListener(listener func(event v1.PluginEvent))
 }
 
+// maxPluginEvents the max number of the plugin events saved in memory
+const maxPluginEvents = 100
+
 type pluginImpl struct {
 	loader       *loader.Loader
 	registry     registry.Pool
 	pluginConfig config.PluginConfig
 	Store        datastore.DataStore `inject:"datastore"`
 	KubeClient   client.Client       `inject:"kubeClient"`
+
+	// reloadLock make sure only one reloading at the same time, and protects the fields below.
+	reloadLock   sync.Mutex
+	fingerprints map[string]string
+	events       []v1.PluginEvent
+	revision     int64
+	listeners    []func(event v1.PluginEvent)
+
+	// clusterRoles records the plugins whose role has been granted in the managed clusters, the key is {pluginID}/{cluster}
+	clusterRoles     map[string]*types.Plugin
+	clusterRolesLock sync.Mutex
 }
 
 func (p *pluginImpl) Init(ctx context.Context) error {
+	p.reloadLock.Lock()
+	defer p.reloadLock.Unlock()
 	for _, s := range pluginSources(p.pluginConfig) {
 		plugins, err := p.loader.Load(s.Class, s.Paths, nil)
 		if err != nil {
@@ -97,11 +129,146 @@ func (p *pluginImpl) Init(ctx context.Context) error {
 			if err := p.registry.Add(ctx, plugin); err != nil {
 				return err
 			}
+			p.setFingerprint(plugin)
+		}
+	}
+	if p.pluginConfig.DevMode {
+		var paths []string
+		for _, s := range pluginSources(p.pluginConfig) {
+			paths = append(paths, s.Paths...)
+		}
+		watcher, err := loader.NewWatcher(paths, func() {
+			if err := p.Reload(ctx); err != nil {
+				klog.Errorf("failed to reload the plugins: %s", err.Error())
+			}
+		})
+		if err != nil {
+			return fmt.Errorf("failed to watch the plugin paths %w", err)
 		}
+		go watcher.Start(ctx)
 	}
 	return nil
 }
 
+// Reload load the plugins from the plugin paths again, and update the registry with the changed plugins.
+// The plugin failed to load is kept with the last loaded version, it is only removed after its directory is deleted.
+func (p *pluginImpl) Reload(ctx context.Context) error {
+	p.reloadLock.Lock()
+	defer p.reloadLock.Unlock()
+	loaded := make(map[string]struct{})
+	for _, s := range pluginSources(p.pluginConfig) {
+		plugins, err := p.loader.Load(s.Class, s.Paths, nil)
+		if err != nil {
+			return err
+		}
+		for _, plugin := range plugins {
+			loaded[plugin.PluginID()] = struct{}{}
+			_, exist := p.registry.Plugin(ctx, plugin.PluginID())
+			if exist && p.fingerprints[plugin.PluginID()] == pluginFingerprint(plugin) {
+				continue
+			}
+			if plugin.BackendType == types.KubeAPI && len(plugin.KubePermissions) > 0 {
+				if err := p.InitPluginRole(ctx, plugin); err != nil {
+					klog.Errorf("failed to init the cluster role for the plugin %s err: %s", plugin.PluginID(), err.Error())
+					continue
+				}
+			}
+			eventType := v1.PluginEventAdded
+			if exist {
+				eventType = v1.PluginEventUpdated
+				if err := p.registry.Remove(ctx, plugin.PluginID()); err != nil {
+					return err
+				}
+			}
+			if err := p.registry.Add(ctx, plugin); err != nil {
+				return err
+			}
+			RegisterPluginRoutesResource(plugin)
+			p.setFingerprint(plugin)
+			klog.Infof("The plugin %s is reloaded, %s", plugin.PluginID(), eventType)
+			p.emitEvent(eventType, plugin.PluginID())
+		}
+	}
+	for _, plugin := range p.registry.Plugins(ctx) {
+		if _, exist := loaded[plugin.PluginID()]; exist {
+			continue
+		}
+		// The plugin.json may be invalid while it is being written, keep the last good version until it is fixed
+		if _, err := os.Stat(plugin.PluginDir); err == nil {
+			klog.Warningf("failed to reload the plugin %s, keep the last loaded version", plugin.PluginID())
+			continue
+		}
+		if err := p.registry.Remove(ctx, plugin.PluginID()); err != nil {
+			return err
+		}
+		delete(p.fingerprints, plugin.PluginID())
+		klog.Infof("The plugin %s is removed", plugin.PluginID())
+		p.emitEvent(v1.PluginEventRemoved, plugin.PluginID())
+	}
+	return nil
+}
+
+func (p *pluginImpl) setFingerprint(plugin *types.Plugin) {
+	if p.fingerprints == nil {
+		p.fingerprints = make(map[string]string)
+	}
+	p.fingerprints[plugin.PluginID()] = pluginFingerprint(plugin)
+}
+
+// pluginFingerprint generate the fingerprint with the plugin.json and the module file.
+// The plugin should be reloaded if the fingerprint changed.
+func pluginFingerprint(plugin *types.Plugin) string {
+	data, err := json.Marshal(plugin.JSONData)
+	if err != nil {
+		klog.Warningf("failed to marshal the plugin %s: %s", plugin.PluginID(), err.Error())
+	}
+	hash := sha256.New()
+	hash.Write(data)
+	hash.Write([]byte(plugin.PluginDir))
+	if info, err := os.Stat(filepath.Join(plugin.PluginDir, "module.js")); err == nil {
+		hash.Write([]byte(fmt.Sprintf("%d-%d", info.ModTime().UnixNano(), info.Size())))
+	}
+	return fmt.Sprintf("%x", hash.Sum(nil))
+}
+
+// emitEvent must be called with the reload lock held.
+func (p *pluginImpl) emitEvent(eventType v1.PluginEventType, pluginID string) {
+	p.revision++
+	event := v1.PluginEvent{
+		Revision: p.revision,
+		Type:     eventType,
+		PluginID: pluginID,
+		Time:     time.Now(),
+	}
+	p.events = append(p.events, event)
+	if len(p.events) > maxPluginEvents {
+		p.events = p.events[len(p.events)-maxPluginEvents:]
+	}
+	for _, listener := range p.listeners {
+		listener(event)
+	}
+}
+
+// AddChangeListener the listener will be called after a plugin is reloaded or removed.
+func (p *pluginImpl) AddChangeListener(listener func(event v1.PluginEvent)) {
+	p.reloadLock.Lock()
+	defer p.reloadLock.Unlock()
+	p.listeners = append(p.listeners, listener)
+}
+
+// ListPluginEvents list the plugin events after the since revision
+func (p *pluginImpl) ListPluginEvents(ctx context.Context, since int64) *v1.ListPluginEventsResponse {
+	p.reloadLock.Lock()
+	defer p.reloadLock.Unlock()
+	res := &v1.ListPluginEventsResponse{Revision: p.revision, Events: []v1.PluginEvent{}}
+	for _, event := range p.events {
+		if event.Revision > since {
+			res.Events = append(res.Events, event)
+		}
+	}
+	return res
+}
+
 // GeneratePluginRoleName generate the plugin role name.
 func GeneratePluginRoleName(plugin *types.Plugin) string {
 	return pluginRolePrefix + plugin.ID
@@ -112,13 +279,24 @@ func GeneratePluginSubjectName(plugin *types.Plugin) string {
 	return pluginRolePrefix + plugin.PluginID()
 }
 
+// clusterGatewayProxyRule allows the plugin to request the managed clusters through the cluster-gateway.
+var clusterGatewayProxyRule = rbacv1.PolicyRule{
+	APIGroups: []string{"cluster.core.oam.dev"},
+	Resources: []string{"clustergateways/proxy"},
+	Verbs:     []string{"get", "list", "watch", "create", "update", "patch", "delete"},
+}
+
 func (p *pluginImpl) InitPluginRole(ctx context.Context, plugin *types.Plugin) error {
+	rules := append([]rbacv1.PolicyRule{}, plugin.KubePermissions...)
+	if cluster, _ := pkgmulticluster.ClusterFrom(ctx); pkgmulticluster.IsLocal(cluster) {
+		rules = append(rules, clusterGatewayProxyRule)
+	}
 	role := &rbacv1.ClusterRole{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:   GeneratePluginRoleName(plugin),
 			Labels: map[string]string{},
 		},
-		Rules: plugin.KubePermissions,
+		Rules: rules,
 	}
 	option, err := utils.CreateOrUpdate(ctx, p.KubeClient, role)
 	if err != nil {
@@ -162,6 +340,27 @@ func (p *pluginImpl) InitPluginRole(ctx context.Context, plugin *types.Plugin) e
 	return nil
 }
 
+func (p *pluginImpl) InitPluginRoleInCluster(ctx context.Context, plugin *types.Plugin, cluster string) error {
+	if pkgmulticluster.IsLocal(cluster) || len(plugin.KubePermissions) == 0 {
+		return nil
+	}
+	key := plugin.PluginID() + "/" + cluster
+	p.clusterRolesLock.Lock()
+	defer p.clusterRolesLock.Unlock()
+	// The plugin instance changes after reloading, the role should be updated.
+	if p.clusterRoles[key] == plugin {
+		return nil
+	}
+	if err := p.InitPluginRole(pkgmulticluster.WithCluster(ctx, cluster), plugin); err != nil {
+		return err
+	}
+	if p.clusterRoles == nil {
+		p.clusterRoles = map[string]*types.Plugin{}
+	}
+	p.clusterRoles[key] = plugin
+	return nil
+}
+
 func (p *pluginImpl) ListInstalledPlugins(ctx context.Context) []v1.ManagedPluginDTO {
 	plugins := p.registry.Plugins(ctx)
 	var pluginDTOs []v1.ManagedPluginDTO
@@ -191,6 +390,7 @@ func (p *pluginImpl) DetailInstalledPlugin(ctx context.Context, pluginID string)
 		return nil, err
 	}
 	dto := assembler.PluginToManagedDTO(*plugin, setting)
+	dto.RateLimits = limiter.GetState(pluginID)
 	return &dto, nil
 }
 
@@ -239,6 +439,9 @@ func (p *pluginImpl) EnablePlugin(ctx context.Context, pluginID string, params v
 	if setting.Enabled {
 		return nil, bcode.ErrPluginAlreadyEnabled
 	}
+	if err := checkPluginHookSecret(plugin, params.SecureJSONData); err != nil {
+		return nil, err
+	}
 	setting.Enabled = true
 	setting.JSONData = params.JSONData
 	setting.SecureJSONData = params.SecureJSONData
@@ -296,6 +499,9 @@ func (p *pluginImpl) SetPlugin(ctx context.Context, pluginID string, params v1.P
 	if !setting.Enabled {
 		return nil, bcode.ErrPluginAlreadyDisabled
 	}
+	if err := checkPluginHookSecret(plugin, params.SecureJSONData); err != nil {
+		return nil, err
+	}
 	setting.JSONData = params.JSONData
 	setting.SecureJSONData = params.SecureJSONData
 	err = p.Store.Put(ctx, &setting)
@@ -0,0 +1,266 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apitypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/rand"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	kubevelatypes "github.com/oam-dev/kubevela/apis/types"
+
+	"github.com/kubevela/velaux/pkg/plugin/types"
+	apisv1 "github.com/kubevela/velaux/pkg/server/interfaces/api/dto/v1"
+	"github.com/kubevela/velaux/pkg/server/utils/bcode"
+)
+
+const (
+	// PluginHookSecretKey the key in the secure json data of the plugin setting, it is used to sign the payload.
+	PluginHookSecretKey = "hookSecret"
+	// PluginHookSignatureHeader the header of the payload signature, the value format is sha256={hex(hmac-sha256(secret, body))}
+	PluginHookSignatureHeader = "X-VelaUX-Signature-256"
+	// PluginHookEventHeader the header of the event name
+	PluginHookEventHeader = "X-VelaUX-Event"
+	// PluginHookDeliveryHeader the header of the payload UID
+	PluginHookDeliveryHeader = "X-VelaUX-Delivery"
+)
+
+// maxHookResponseBytes the max bytes to read from the response of the hook
+const maxHookResponseBytes = 64 << 10
+
+// PluginHookService calls the webhooks declared by the enabled plugins when the lifecycle events happen.
+type PluginHookService interface {
+	// Validate calls the validating hooks before the action, returns an error if any hook refuses it.
+	Validate(ctx context.Context, event types.HookEvent, payload *apisv1.PluginHookPayload) error
+	// Notify calls the notifying hooks asynchronously after the action is done.
+	Notify(ctx context.Context, event types.HookEvent, payload *apisv1.PluginHookPayload)
+}
+
+type pluginHookServiceImpl struct {
+	PluginService PluginService `inject:""`
+	KubeClient    client.Client `inject:"kubeClient"`
+	httpClient    *http.Client
+}
+
+// NewPluginHookService create a plugin hook service instance
+func NewPluginHookService() PluginHookService {
+	return &pluginHookServiceImpl{httpClient: &http.Client{}}
+}
+
+// NewPluginHookPayload create the payload with the common fields
+func NewPluginHookPayload(ctx context.Context, event types.HookEvent) *apisv1.PluginHookPayload {
+	userName, _ := ctx.Value(&apisv1.CtxKeyUser).(string)
+	return &apisv1.PluginHookPayload{
+		UID:   rand.String(16),
+		Event: event,
+		Time:  time.Now(),
+		User:  userName,
+	}
+}
+
+type pluginHook struct {
+	plugin *types.Plugin
+	hook   *types.Hook
+	secret string
+}
+
+// listHooks list the hooks of the enabled plugins that subscribe the event
+func (p *pluginHookServiceImpl) listHooks(ctx context.Context, event types.HookEvent, hookType types.HookType) []pluginHook {
+	var hooks []pluginHook
+	for _, installed := range p.PluginService.ListInstalledPlugins(ctx) {
+		if !installed.Enabled {
+			continue
+		}
+		plugin, err := p.PluginService.GetPlugin(ctx, installed.ID)
+		if err != nil || len(plugin.Hooks) == 0 {
+			continue
+		}
+		setting, err := p.PluginService.GetPluginSetting(ctx, installed.ID)
+		if err != nil {
+			klog.Errorf("failed to get the setting of the plugin %s: %s", installed.ID, err.Error())
+			continue
+		}
+		secret := pluginHookSecret(setting.SecureJSONData)
+		for _, hook := range plugin.Hooks {
+			if hook.GetType() == hookType && hook.Subscribe(event) {
+				hooks = append(hooks, pluginHook{plugin: plugin, hook: hook, secret: secret})
+			}
+		}
+	}
+	return hooks
+}
+
+func (p *pluginHookServiceImpl) Validate(ctx context.Context, event types.HookEvent, payload *apisv1.PluginHookPayload) error {
+	payload.Event = event
+	payload.Type = types.HookTypeValidating
+	for _, h := range p.listHooks(ctx, event, types.HookTypeValidating) {
+		res, err := p.call(ctx, h, payload)
+		if err != nil {
+			klog.Errorf("failed to call the validating hook %s of the plugin %s: %s", h.hook.Name, h.plugin.PluginID(), err.Error())
+			if h.hook.GetFailurePolicy() == types.HookFailurePolicyIgnore {
+				continue
+			}
+			return bcode.ErrPluginHookFailed.SetMessage(fmt.Sprintf("failed to call the hook %s of the plugin %s", h.hook.Name, h.plugin.PluginID()))
+		}
+		if !res.Allowed {
+			message := res.Message
+			if message == "" {
+				message = "no reason"
+			}
+			return bcode.ErrPluginHookRejected.SetMessage(fmt.Sprintf("refused by the plugin %s: %s", h.plugin.PluginID(), message))
+		}
+	}
+	return nil
+}
+
+func (p *pluginHookServiceImpl) Notify(ctx context.Context, event types.HookEvent, payload *apisv1.PluginHookPayload) {
+	payload.Event = event
+	payload.Type = types.HookTypeNotifying
+	hooks := p.listHooks(ctx, event, types.HookTypeNotifying)
+	if len(hooks) == 0 {
+		return
+	}
+	// The request context will be canceled after the response is written.
+	go func() {
+		for _, h := range hooks {
+			if _, err := p.call(context.Background(), h, payload); err != nil {
+				klog.Errorf("failed to call the notifying hook %s of the plugin %s: %s", h.hook.Name, h.plugin.PluginID(), err.Error())
+			}
+		}
+	}()
+}
+
+func (p *pluginHookServiceImpl) call(ctx context.Context, h pluginHook, payload *apisv1.PluginHookPayload) (*apisv1.PluginHookResponse, error) {
+	// Never call a hook unsigned, the plugin could not tell the request is sent by VelaUX.
+	if h.secret == "" {
+		return nil, fmt.Errorf("the %s of the plugin %s is not set", PluginHookSecretKey, h.plugin.PluginID())
+	}
+	endpoint, err := p.resolveURL(ctx, h.plugin, h.hook)
+	if err != nil {
+		return nil, err
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(h.hook.GetTimeoutSeconds())*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(PluginHookEventHeader, string(payload.Event))
+	req.Header.Set(PluginHookDeliveryHeader, payload.UID)
+	req.Header.Set(PluginHookSignatureHeader, SignPluginHookPayload(h.secret, body))
+	res, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = res.Body.Close()
+	}()
+	resBody, err := io.ReadAll(io.LimitReader(res.Body, maxHookResponseBytes))
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return nil, fmt.Errorf("the hook responds the status code %d", res.StatusCode)
+	}
+	var hookRes apisv1.PluginHookResponse
+	if payload.Type == types.HookTypeValidating {
+		if err := json.Unmarshal(resBody, &hookRes); err != nil {
+			return nil, fmt.Errorf("failed to decode the response of the hook: %w", err)
+		}
+	}
+	return &hookRes, nil
+}
+
+// resolveURL the relative path is served by the backend service of the plugin
+func (p *pluginHookServiceImpl) resolveURL(ctx context.Context, plugin *types.Plugin, hook *types.Hook) (string, error) {
+	u, err := url.Parse(hook.URL)
+	if err != nil {
+		return "", err
+	}
+	if u.IsAbs() {
+		return hook.URL, nil
+	}
+	if plugin.BackendType != types.KubeService || plugin.BackendService == nil {
+		return "", fmt.Errorf("the plugin does not have the backend service to serve the path %s", hook.URL)
+	}
+	namespace := plugin.BackendService.Namespace
+	if namespace == "" {
+		namespace = kubevelatypes.DefaultKubeVelaNS
+	}
+	var service corev1.Service
+	if err := p.KubeClient.Get(ctx, apitypes.NamespacedName{Namespace: namespace, Name: plugin.BackendService.Name}, &service); err != nil {
+		return "", fmt.Errorf("failed to discover the backend service %s/%s: %w", namespace, plugin.BackendService.Name, err)
+	}
+	if len(service.Spec.Ports) == 0 || service.Spec.ClusterIP == "" {
+		return "", fmt.Errorf("the backend service %s/%s is not available", namespace, plugin.BackendService.Name)
+	}
+	port := service.Spec.Ports[0].Port
+	if plugin.BackendService.Port != 0 {
+		port = plugin.BackendService.Port
+	}
+	return fmt.Sprintf("http://%s:%d/%s", service.Spec.ClusterIP, port, strings.TrimPrefix(hook.URL, "/")), nil
+}
+
+// pluginHookSecret returns the secret to sign the hook payloads from the secure json data of the plugin setting
+func pluginHookSecret(secureJSONData map[string]interface{}) string {
+	secret, _ := secureJSONData[PluginHookSecretKey].(string)
+	return secret
+}
+
+// checkPluginHookSecret the plugin declaring hooks must set the secret, the hooks are never called unsigned
+func checkPluginHookSecret(plugin *types.Plugin, secureJSONData map[string]interface{}) error {
+	if len(plugin.Hooks) > 0 && pluginHookSecret(secureJSONData) == "" {
+		return bcode.ErrPluginHookSecretRequired
+	}
+	return nil
+}
+
+// SignPluginHookPayload sign the payload body with the secret, the plugin could verify the request with the same algorithm.
+func SignPluginHookPayload(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// NewTestPluginHookService only used by testing
+func NewTestPluginHookService(pluginService PluginService, kubeClient client.Client) PluginHookService {
+	return &pluginHookServiceImpl{
+		PluginService: pluginService,
+		KubeClient:    kubeClient,
+		httpClient:    &http.Client{},
+	}
+}
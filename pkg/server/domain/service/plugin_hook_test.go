@@ -0,0 +1,135 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/kubevela/velaux/pkg/plugin/registry"
+	"github.com/kubevela/velaux/pkg/plugin/types"
+	"github.com/kubevela/velaux/pkg/server/domain/model"
+	v1 "github.com/kubevela/velaux/pkg/server/interfaces/api/dto/v1"
+	"github.com/kubevela/velaux/pkg/server/utils/bcode"
+)
+
+var _ = Describe("Test plugin hook service", func() {
+	var hookService PluginHookService
+	var server *httptest.Server
+	var lock sync.Mutex
+	var received []v1.PluginHookPayload
+	var signatures []string
+
+	BeforeEach(func() {
+		InitTestEnv("test-plugin-hook")
+		received = nil
+		signatures = nil
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			var payload v1.PluginHookPayload
+			Expect(json.Unmarshal(body, &payload)).Should(BeNil())
+			lock.Lock()
+			received = append(received, payload)
+			signatures = append(signatures, r.Header.Get(PluginHookSignatureHeader))
+			lock.Unlock()
+			if r.URL.Path == "/validate" {
+				res := v1.PluginHookResponse{Allowed: payload.Application.Name != "forbidden", Message: "the name is forbidden"}
+				Expect(json.NewEncoder(w).Encode(res)).Should(BeNil())
+			}
+		}))
+
+		pluginService := &pluginImpl{registry: registry.NewInMemory(), Store: ds}
+		Expect(pluginService.registry.Add(ctx, &types.Plugin{JSONData: types.JSONData{
+			ID: "policy-plugin",
+			Hooks: []*types.Hook{
+				{Name: "validate", Type: types.HookTypeValidating, URL: server.URL + "/validate", Events: []types.HookEvent{types.HookEventApplicationCreated}},
+				{Name: "notify", URL: server.URL + "/notify", Events: []types.HookEvent{types.HookEventApplicationCreated, types.HookEventProjectCreated}},
+			},
+		}})).Should(BeNil())
+		Expect(pluginService.registry.Add(ctx, &types.Plugin{JSONData: types.JSONData{
+			ID: "disabled-plugin",
+			Hooks: []*types.Hook{
+				{Name: "validate", Type: types.HookTypeValidating, URL: "http://127.0.0.1:1/validate", Events: []types.HookEvent{types.HookEventApplicationCreated}},
+			},
+		}})).Should(BeNil())
+		Expect(ds.Add(ctx, &model.PluginSetting{ID: "policy-plugin", Enabled: true, SecureJSONData: map[string]interface{}{PluginHookSecretKey: "secret"}})).Should(BeNil())
+		hookService = NewTestPluginHookService(pluginService, k8sClient)
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("Test the validating hooks", func() {
+		payload := NewPluginHookPayload(ctx, types.HookEventApplicationCreated)
+		payload.Application = &v1.ApplicationBase{Name: "forbidden"}
+		err := hookService.Validate(ctx, types.HookEventApplicationCreated, payload)
+		Expect(err).ShouldNot(BeNil())
+		Expect(err.(*bcode.Bcode).BusinessCode).Should(Equal(bcode.ErrPluginHookRejected.BusinessCode))
+		Expect(err.(*bcode.Bcode).Message).Should(ContainSubstring("the name is forbidden"))
+
+		payload.Application = &v1.ApplicationBase{Name: "allowed"}
+		Expect(hookService.Validate(ctx, types.HookEventApplicationCreated, payload)).Should(BeNil())
+
+		lock.Lock()
+		defer lock.Unlock()
+		Expect(len(received)).Should(Equal(2))
+		Expect(received[0].Type).Should(Equal(types.HookTypeValidating))
+		body, err := json.Marshal(payload)
+		Expect(err).Should(BeNil())
+		Expect(signatures[1]).Should(Equal(SignPluginHookPayload("secret", body)))
+	})
+
+	It("Test never calling the hooks unsigned", func() {
+		plugin := &types.Plugin{JSONData: types.JSONData{ID: "policy-plugin", Hooks: []*types.Hook{{Name: "notify"}}}}
+		Expect(checkPluginHookSecret(plugin, map[string]interface{}{})).Should(Equal(bcode.ErrPluginHookSecretRequired))
+		Expect(checkPluginHookSecret(plugin, map[string]interface{}{PluginHookSecretKey: "secret"})).Should(BeNil())
+		Expect(checkPluginHookSecret(&types.Plugin{}, nil)).Should(BeNil())
+
+		Expect(ds.Put(ctx, &model.PluginSetting{ID: "policy-plugin", Enabled: true})).Should(BeNil())
+		payload := NewPluginHookPayload(ctx, types.HookEventApplicationCreated)
+		payload.Application = &v1.ApplicationBase{Name: "allowed"}
+		err := hookService.Validate(ctx, types.HookEventApplicationCreated, payload)
+		Expect(err).ShouldNot(BeNil())
+		Expect(err.(*bcode.Bcode).BusinessCode).Should(Equal(bcode.ErrPluginHookFailed.BusinessCode))
+		lock.Lock()
+		defer lock.Unlock()
+		Expect(len(received)).Should(Equal(0))
+	})
+
+	It("Test the notifying hooks", func() {
+		payload := NewPluginHookPayload(ctx, types.HookEventProjectCreated)
+		payload.Project = &v1.ProjectBase{Name: "default"}
+		hookService.Notify(ctx, types.HookEventProjectCreated, payload)
+		Eventually(func() int {
+			lock.Lock()
+			defer lock.Unlock()
+			return len(received)
+		}).Should(Equal(1))
+		lock.Lock()
+		defer lock.Unlock()
+		Expect(received[0].Event).Should(Equal(types.HookEventProjectCreated))
+		Expect(received[0].Type).Should(Equal(types.HookTypeNotifying))
+		Expect(received[0].Project.Name).Should(Equal("default"))
+	})
+})
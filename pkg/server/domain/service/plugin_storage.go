@@ -0,0 +1,271 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/kubevela/velaux/pkg/plugin/types"
+	"github.com/kubevela/velaux/pkg/server/domain/model"
+	"github.com/kubevela/velaux/pkg/server/infrastructure/datastore"
+	assembler "github.com/kubevela/velaux/pkg/server/interfaces/api/assembler/v1"
+	apisv1 "github.com/kubevela/velaux/pkg/server/interfaces/api/dto/v1"
+	"github.com/kubevela/velaux/pkg/server/utils/bcode"
+)
+
+var storageKeyRegexp = regexp.MustCompile(`^[a-z0-9]([-a-z0-9.]*[a-z0-9])?$`)
+
+// PluginStorageService the plugin storage service provides the persistent storage for the plugins
+type PluginStorageService interface {
+	// GetStoragePlugin return the plugin if it is enabled and declares the storage
+	GetStoragePlugin(ctx context.Context, pluginID string) (*types.Plugin, error)
+	// ResolveScope checking the scope requested by the user and return the scope with the name
+	ResolveScope(ctx context.Context, plugin *types.Plugin, scope types.StorageScope, projectName string) (*apisv1.PluginStorageScope, error)
+	ListItems(ctx context.Context, plugin *types.Plugin, scope apisv1.PluginStorageScope, prefix string, page, pageSize int) (*apisv1.ListPluginStorageItemsResponse, error)
+	GetItem(ctx context.Context, plugin *types.Plugin, scope apisv1.PluginStorageScope, key string) (*apisv1.PluginStorageItem, error)
+	PutItem(ctx context.Context, plugin *types.Plugin, scope apisv1.PluginStorageScope, key string, req apisv1.PutPluginStorageItemRequest) (*apisv1.PluginStorageItem, error)
+	DeleteItem(ctx context.Context, plugin *types.Plugin, scope apisv1.PluginStorageScope, key string) error
+}
+
+type pluginStorageServiceImpl struct {
+	Store         datastore.DataStore `inject:"datastore"`
+	PluginService PluginService       `inject:""`
+}
+
+// NewPluginStorageService create a plugin storage service instance
+func NewPluginStorageService() PluginStorageService {
+	return &pluginStorageServiceImpl{}
+}
+
+func (p *pluginStorageServiceImpl) GetStoragePlugin(ctx context.Context, pluginID string) (*types.Plugin, error) {
+	plugin, err := p.PluginService.GetPlugin(ctx, pluginID)
+	if err != nil {
+		return nil, err
+	}
+	setting, err := p.PluginService.GetPluginSetting(ctx, pluginID)
+	if err != nil {
+		return nil, err
+	}
+	if !setting.Enabled {
+		return nil, bcode.ErrPluginNotEnabled
+	}
+	if plugin.Storage == nil {
+		return nil, bcode.ErrPluginStorageNotDeclared
+	}
+	return plugin, nil
+}
+
+func (p *pluginStorageServiceImpl) ResolveScope(ctx context.Context, plugin *types.Plugin, scope types.StorageScope, projectName string) (*apisv1.PluginStorageScope, error) {
+	if plugin.Storage == nil {
+		return nil, bcode.ErrPluginStorageNotDeclared
+	}
+	if scope == "" {
+		scope = types.StorageScopePlatform
+		if projectName != "" {
+			scope = types.StorageScopeProject
+		}
+	}
+	if !plugin.Storage.AllowScope(scope) {
+		return nil, bcode.ErrPluginStorageScopeNotAllowed
+	}
+	switch scope {
+	case types.StorageScopePlatform:
+		return &apisv1.PluginStorageScope{Scope: scope}, nil
+	case types.StorageScopeProject:
+		if projectName == "" {
+			return nil, bcode.ErrProjectIsNotExist
+		}
+		if err := p.Store.Get(ctx, &model.Project{Name: projectName}); err != nil {
+			if errors.Is(err, datastore.ErrRecordNotExist) {
+				return nil, bcode.ErrProjectIsNotExist
+			}
+			return nil, err
+		}
+		return &apisv1.PluginStorageScope{Scope: scope, Name: projectName}, nil
+	case types.StorageScopeUser:
+		userName, ok := ctx.Value(&apisv1.CtxKeyUser).(string)
+		if !ok || userName == "" {
+			return nil, bcode.ErrUnauthorized
+		}
+		return &apisv1.PluginStorageScope{Scope: scope, Name: userName}, nil
+	default:
+		return nil, bcode.ErrPluginStorageScopeNotAllowed
+	}
+}
+
+func (p *pluginStorageServiceImpl) listScopeItems(ctx context.Context, plugin *types.Plugin, scope apisv1.PluginStorageScope) ([]*model.PluginStorage, error) {
+	entities, err := p.Store.List(ctx, &model.PluginStorage{
+		PluginID:  plugin.PluginID(),
+		Scope:     string(scope.Scope),
+		ScopeName: scope.Name,
+	}, nil)
+	if err != nil {
+		return nil, err
+	}
+	var items []*model.PluginStorage
+	for _, entity := range entities {
+		items = append(items, entity.(*model.PluginStorage))
+	}
+	return items, nil
+}
+
+func (p *pluginStorageServiceImpl) ListItems(ctx context.Context, plugin *types.Plugin, scope apisv1.PluginStorageScope, prefix string, page, pageSize int) (*apisv1.ListPluginStorageItemsResponse, error) {
+	items, err := p.listScopeItems(ctx, plugin, scope)
+	if err != nil {
+		return nil, err
+	}
+	res := &apisv1.ListPluginStorageItemsResponse{
+		Items: []*apisv1.PluginStorageItem{},
+		Quota: plugin.Storage.GetQuota(),
+	}
+	var matched []*model.PluginStorage
+	for _, item := range items {
+		res.Usage += item.Size
+		if strings.HasPrefix(item.Key, prefix) {
+			matched = append(matched, item)
+		}
+	}
+	sort.Slice(matched, func(i, j int) bool {
+		return matched[i].Key < matched[j].Key
+	})
+	res.Total = int64(len(matched))
+	if page > 0 && pageSize > 0 {
+		start := (page - 1) * pageSize
+		if start >= len(matched) {
+			matched = nil
+		} else {
+			end := start + pageSize
+			if end > len(matched) {
+				end = len(matched)
+			}
+			matched = matched[start:end]
+		}
+	}
+	for _, item := range matched {
+		res.Items = append(res.Items, assembler.PluginStorageToDTO(item))
+	}
+	return res, nil
+}
+
+func (p *pluginStorageServiceImpl) GetItem(ctx context.Context, plugin *types.Plugin, scope apisv1.PluginStorageScope, key string) (*apisv1.PluginStorageItem, error) {
+	if err := checkStorageKey(key); err != nil {
+		return nil, err
+	}
+	item := &model.PluginStorage{
+		PluginID:  plugin.PluginID(),
+		Scope:     string(scope.Scope),
+		ScopeName: scope.Name,
+		Key:       key,
+	}
+	if err := p.Store.Get(ctx, item); err != nil {
+		if errors.Is(err, datastore.ErrRecordNotExist) {
+			return nil, bcode.ErrPluginStorageItemNotExist
+		}
+		return nil, err
+	}
+	return assembler.PluginStorageToDTO(item), nil
+}
+
+func (p *pluginStorageServiceImpl) PutItem(ctx context.Context, plugin *types.Plugin, scope apisv1.PluginStorageScope, key string, req apisv1.PutPluginStorageItemRequest) (*apisv1.PluginStorageItem, error) {
+	if err := checkStorageKey(key); err != nil {
+		return nil, err
+	}
+	value, err := json.Marshal(req.Value)
+	if err != nil {
+		return nil, bcode.ErrInvalidProperties
+	}
+	size := int64(len(value))
+	if size > plugin.Storage.GetMaxValueBytes() {
+		return nil, bcode.ErrPluginStorageValueTooLarge
+	}
+	items, err := p.listScopeItems(ctx, plugin, scope)
+	if err != nil {
+		return nil, err
+	}
+	var usage int64
+	var exist *model.PluginStorage
+	for _, item := range items {
+		if item.Key == key {
+			exist = item
+			continue
+		}
+		usage += item.Size
+	}
+	if usage+size > plugin.Storage.GetQuota() {
+		return nil, bcode.ErrPluginStorageQuotaExceeded
+	}
+	if exist != nil {
+		exist.Value = req.Value
+		exist.Size = size
+		if err := p.Store.Put(ctx, exist); err != nil {
+			return nil, err
+		}
+		return assembler.PluginStorageToDTO(exist), nil
+	}
+	userName, _ := ctx.Value(&apisv1.CtxKeyUser).(string)
+	item := &model.PluginStorage{
+		PluginID:  plugin.PluginID(),
+		Scope:     string(scope.Scope),
+		ScopeName: scope.Name,
+		Key:       key,
+		Value:     req.Value,
+		Size:      size,
+		Creator:   userName,
+	}
+	if err := p.Store.Add(ctx, item); err != nil {
+		return nil, err
+	}
+	return assembler.PluginStorageToDTO(item), nil
+}
+
+func (p *pluginStorageServiceImpl) DeleteItem(ctx context.Context, plugin *types.Plugin, scope apisv1.PluginStorageScope, key string) error {
+	if err := checkStorageKey(key); err != nil {
+		return err
+	}
+	if err := p.Store.Delete(ctx, &model.PluginStorage{
+		PluginID:  plugin.PluginID(),
+		Scope:     string(scope.Scope),
+		ScopeName: scope.Name,
+		Key:       key,
+	}); err != nil {
+		if errors.Is(err, datastore.ErrRecordNotExist) {
+			return bcode.ErrPluginStorageItemNotExist
+		}
+		return err
+	}
+	return nil
+}
+
+func checkStorageKey(key string) error {
+	if len(key) > 63 || !storageKeyRegexp.MatchString(key) {
+		return bcode.ErrPluginStorageKeyInvalid
+	}
+	return nil
+}
+
+// NewTestPluginStorageService only used by testing
+func NewTestPluginStorageService(ds datastore.DataStore, pluginService PluginService) PluginStorageService {
+	return &pluginStorageServiceImpl{
+		Store:         ds,
+		PluginService: pluginService,
+	}
+}
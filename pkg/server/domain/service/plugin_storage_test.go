@@ -0,0 +1,133 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/kubevela/velaux/pkg/plugin/types"
+	"github.com/kubevela/velaux/pkg/server/domain/model"
+	v1 "github.com/kubevela/velaux/pkg/server/interfaces/api/dto/v1"
+	"github.com/kubevela/velaux/pkg/server/utils/bcode"
+)
+
+var _ = Describe("Test plugin storage service", func() {
+	var storageService PluginStorageService
+	var plugin *types.Plugin
+
+	BeforeEach(func() {
+		InitTestEnv("test-plugin-storage")
+		storageService = NewTestPluginStorageService(ds, nil)
+		plugin = &types.Plugin{JSONData: types.JSONData{
+			ID: "storage-plugin",
+			Storage: &types.Storage{
+				Scopes:        []types.StorageScope{types.StorageScopePlatform, types.StorageScopeUser},
+				Quota:         64,
+				MaxValueBytes: 32,
+			},
+		}}
+	})
+
+	It("Test resolving the scope", func() {
+		scope, err := storageService.ResolveScope(ctx, plugin, "", "")
+		Expect(err).Should(BeNil())
+		Expect(scope.Scope).Should(Equal(types.StorageScopePlatform))
+
+		_, err = storageService.ResolveScope(ctx, plugin, types.StorageScopeProject, "default")
+		Expect(err).Should(Equal(bcode.ErrPluginStorageScopeNotAllowed))
+
+		_, err = storageService.ResolveScope(ctx, plugin, types.StorageScopeUser, "")
+		Expect(err).Should(Equal(bcode.ErrUnauthorized))
+
+		scope, err = storageService.ResolveScope(context.WithValue(ctx, &v1.CtxKeyUser, "alice"), plugin, types.StorageScopeUser, "")
+		Expect(err).Should(BeNil())
+		Expect(scope.Name).Should(Equal("alice"))
+	})
+
+	It("Test saving, listing and deleting the items", func() {
+		platform := v1.PluginStorageScope{Scope: types.StorageScopePlatform}
+		user := v1.PluginStorageScope{Scope: types.StorageScopeUser, Name: "alice"}
+
+		_, err := storageService.PutItem(ctx, plugin, platform, "Invalid_Key", v1.PutPluginStorageItemRequest{Value: &model.JSONStruct{"a": "b"}})
+		Expect(err).Should(Equal(bcode.ErrPluginStorageKeyInvalid))
+
+		item, err := storageService.PutItem(ctx, plugin, platform, "dashboard.a", v1.PutPluginStorageItemRequest{Value: &model.JSONStruct{"a": "b"}})
+		Expect(err).Should(BeNil())
+		Expect(item.Size).Should(Equal(int64(9)))
+
+		_, err = storageService.PutItem(ctx, plugin, platform, "dashboard.b", v1.PutPluginStorageItemRequest{Value: &model.JSONStruct{"b": "c"}})
+		Expect(err).Should(BeNil())
+		_, err = storageService.PutItem(ctx, plugin, platform, "other", v1.PutPluginStorageItemRequest{Value: &model.JSONStruct{"c": "d"}})
+		Expect(err).Should(BeNil())
+		_, err = storageService.PutItem(ctx, plugin, user, "dashboard.c", v1.PutPluginStorageItemRequest{Value: &model.JSONStruct{"c": "d"}})
+		Expect(err).Should(BeNil())
+
+		By("Test the value size and the quota")
+		_, err = storageService.PutItem(ctx, plugin, platform, "large", v1.PutPluginStorageItemRequest{Value: &model.JSONStruct{"large": "0123456789012345678901234567890123456789"}})
+		Expect(err).Should(Equal(bcode.ErrPluginStorageValueTooLarge))
+		for _, key := range []string{"q1", "q2", "q3"} {
+			_, err = storageService.PutItem(ctx, plugin, platform, key, v1.PutPluginStorageItemRequest{Value: &model.JSONStruct{"q": "012345"}})
+			if err != nil {
+				break
+			}
+		}
+		Expect(err).Should(Equal(bcode.ErrPluginStorageQuotaExceeded))
+
+		By("Test updating the item")
+		item, err = storageService.PutItem(ctx, plugin, platform, "dashboard.a", v1.PutPluginStorageItemRequest{Value: &model.JSONStruct{"a": "bb"}})
+		Expect(err).Should(BeNil())
+		Expect(item.Size).Should(Equal(int64(10)))
+		item, err = storageService.GetItem(ctx, plugin, platform, "dashboard.a")
+		Expect(err).Should(BeNil())
+		Expect((*item.Value)["a"]).Should(Equal("bb"))
+
+		By("Test listing the items with the prefix")
+		list, err := storageService.ListItems(ctx, plugin, platform, "dashboard.", 0, 0)
+		Expect(err).Should(BeNil())
+		Expect(list.Total).Should(Equal(int64(2)))
+		Expect(list.Items[0].Key).Should(Equal("dashboard.a"))
+		Expect(list.Quota).Should(Equal(int64(64)))
+		list, err = storageService.ListItems(ctx, plugin, user, "", 0, 0)
+		Expect(err).Should(BeNil())
+		Expect(list.Total).Should(Equal(int64(1)))
+
+		By("Test deleting the item")
+		Expect(storageService.DeleteItem(ctx, plugin, platform, "dashboard.a")).Should(BeNil())
+		_, err = storageService.GetItem(ctx, plugin, platform, "dashboard.a")
+		Expect(err).Should(Equal(bcode.ErrPluginStorageItemNotExist))
+		Expect(storageService.DeleteItem(ctx, plugin, platform, "dashboard.a")).Should(Equal(bcode.ErrPluginStorageItemNotExist))
+	})
+
+	It("Test the items of the different scopes never collide", func() {
+		first := v1.PluginStorageScope{Scope: types.StorageScopeUser, Name: "a-b"}
+		second := v1.PluginStorageScope{Scope: types.StorageScopeUser, Name: "a"}
+		_, err := storageService.PutItem(ctx, plugin, first, "c", v1.PutPluginStorageItemRequest{Value: &model.JSONStruct{"v": "1"}})
+		Expect(err).Should(BeNil())
+		_, err = storageService.PutItem(ctx, plugin, second, "b-c", v1.PutPluginStorageItemRequest{Value: &model.JSONStruct{"v": "2"}})
+		Expect(err).Should(BeNil())
+
+		item, err := storageService.GetItem(ctx, plugin, first, "c")
+		Expect(err).Should(BeNil())
+		Expect((*item.Value)["v"]).Should(Equal("1"))
+		item, err = storageService.GetItem(ctx, plugin, second, "b-c")
+		Expect(err).Should(BeNil())
+		Expect((*item.Value)["v"]).Should(Equal("2"))
+	})
+})
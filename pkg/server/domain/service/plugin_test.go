@@ -18,6 +18,8 @@ package service
 
 import (
 	"context"
+	"os"
+	"path/filepath"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -102,4 +104,57 @@ var _ = Describe("Test plugin service", func() {
 		Expect(err).Should(BeNil())
 
 	})
+
+	It("Test reloading the plugins", func() {
+		dir, err := os.MkdirTemp("", "plugins")
+		Expect(err).Should(BeNil())
+		defer func() { _ = os.RemoveAll(dir) }()
+		copyPlugin := func(name string) {
+			Expect(os.MkdirAll(filepath.Join(dir, name), 0750)).Should(BeNil())
+			for _, file := range []string{"plugin.json", "module.js"} {
+				content, err := os.ReadFile(filepath.Join("./testdata/plugins", name, file))
+				Expect(err).Should(BeNil())
+				Expect(os.WriteFile(filepath.Join(dir, name, file), content, 0600)).Should(BeNil())
+			}
+		}
+		copyPlugin("frontend")
+		devPluginService := NewTestPluginService(config.PluginConfig{
+			CustomPluginPath: []string{dir},
+		}, k8sClient, ds)
+		var changed []string
+		devPluginService.AddChangeListener(func(event v1.PluginEvent) {
+			changed = append(changed, event.PluginID)
+		})
+		Expect(devPluginService.Init(ctx)).Should(BeNil())
+		Expect(len(devPluginService.ListInstalledPlugins(ctx))).Should(Equal(1))
+
+		By("Test reloading without any changes")
+		Expect(devPluginService.Reload(ctx)).Should(BeNil())
+		Expect(len(devPluginService.ListPluginEvents(ctx, 0).Events)).Should(Equal(0))
+
+		By("Test adding a plugin")
+		copyPlugin("backend-kube-service")
+		Expect(devPluginService.Reload(ctx)).Should(BeNil())
+		events := devPluginService.ListPluginEvents(ctx, 0)
+		Expect(len(events.Events)).Should(Equal(1))
+		Expect(events.Events[0].Type).Should(Equal(v1.PluginEventAdded))
+		Expect(events.Events[0].PluginID).Should(Equal("backend-kube-service"))
+
+		By("Test keeping the plugin failed to reload")
+		Expect(os.WriteFile(filepath.Join(dir, "backend-kube-service", "plugin.json"), []byte(`{"id": "backend-kube`), 0600)).Should(BeNil())
+		Expect(devPluginService.Reload(ctx)).Should(BeNil())
+		Expect(len(devPluginService.ListPluginEvents(ctx, events.Revision).Events)).Should(Equal(0))
+		_, err = devPluginService.GetPlugin(ctx, "backend-kube-service")
+		Expect(err).Should(BeNil())
+
+		By("Test removing a plugin")
+		Expect(os.RemoveAll(filepath.Join(dir, "backend-kube-service"))).Should(BeNil())
+		Expect(devPluginService.Reload(ctx)).Should(BeNil())
+		events = devPluginService.ListPluginEvents(ctx, events.Revision)
+		Expect(len(events.Events)).Should(Equal(1))
+		Expect(events.Events[0].Type).Should(Equal(v1.PluginEventRemoved))
+		_, err = devPluginService.GetPlugin(ctx, "backend-kube-service")
+		Expect(err).Should(Equal(bcode.ErrPluginNotfound))
+		Expect(changed).Should(Equal([]string{"backend-kube-service", "backend-kube-service"}))
+	})
 })
@@ -20,6 +20,8 @@ import (
 	"bytes"
 	"context"
 	"errors"
+	"sync"
+	"time"
 
 	terraformapi "github.com/oam-dev/terraform-controller/api/v1beta1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
@@ -31,6 +33,7 @@ import (
 	"github.com/oam-dev/kubevela/pkg/auth"
 	"github.com/oam-dev/kubevela/pkg/utils"
 
+	pluginTypes "github.com/kubevela/velaux/pkg/plugin/types"
 	"github.com/kubevela/velaux/pkg/server/domain/model"
 	"github.com/kubevela/velaux/pkg/server/infrastructure/datastore"
 	apisv1 "github.com/kubevela/velaux/pkg/server/interfaces/api/dto/v1"
@@ -52,20 +55,33 @@ type ProjectService interface {
 	DeleteProjectUser(ctx context.Context, projectName string, userName string) error
 	UpdateProjectUser(ctx context.Context, projectName string, userName string, req apisv1.UpdateProjectUserRequest) (*apisv1.ProjectUserBase, error)
 	ListTerraformProviders(ctx context.Context, projectName string) ([]*apisv1.TerraformProvider, error)
+	ArchiveProject(ctx context.Context, projectName string) (*apisv1.ProjectBase, error)
+	RestoreProject(ctx context.Context, projectName string) (*apisv1.ProjectBase, error)
+	DeleteProjectCascade(ctx context.Context, projectName string) (*apisv1.ProjectBase, error)
+	PurgeArchivedProjects(ctx context.Context) error
+	GetProjectQuota(ctx context.Context, projectName string) (*apisv1.ProjectQuotaResponse, error)
+	UpdateProjectQuota(ctx context.Context, projectName string, req apisv1.UpdateProjectQuotaRequest) (*apisv1.ProjectQuotaResponse, error)
 }
 
 type projectServiceImpl struct {
-	Store         datastore.DataStore `inject:"datastore"`
-	K8sClient     client.Client       `inject:"kubeClient"`
-	RbacService   RBACService         `inject:""`
-	TargetService TargetService       `inject:""`
-	UserService   UserService         `inject:""`
-	EnvService    EnvService          `inject:""`
+	Store                  datastore.DataStore    `inject:"datastore"`
+	K8sClient              client.Client          `inject:"kubeClient"`
+	RbacService            RBACService            `inject:""`
+	TargetService          TargetService          `inject:""`
+	UserService            UserService            `inject:""`
+	EnvService             EnvService             `inject:""`
+	PluginHookService      PluginHookService      `inject:""`
+	ProjectTemplateService ProjectTemplateService `inject:""`
+	ApplicationService     ApplicationService     `inject:""`
+	EnvBindingService      EnvBindingService      `inject:""`
+	// ArchiveRetention the archived projects could be restored in this period
+	ArchiveRetention time.Duration
+	deleting         sync.Map
 }
 
 // NewProjectService new project service
-func NewProjectService() ProjectService {
-	return &projectServiceImpl{}
+func NewProjectService(archiveRetention time.Duration) ProjectService {
+	return &projectServiceImpl{ArchiveRetention: archiveRetention}
 }
 
 // GetProject get project
@@ -149,7 +165,12 @@ func (p *projectServiceImpl) ListUserProjects(ctx context.Context, userName stri
 	}
 	var projectBases []*apisv1.ProjectBase
 	for _, entity := range projectEntities {
-		projectBases = append(projectBases, ConvertProjectModel2Base(entity.(*model.Project), nil))
+		project := entity.(*model.Project)
+		// the archived projects are hidden from the members
+		if project.Archived {
+			continue
+		}
+		projectBases = append(projectBases, ConvertProjectModel2Base(project, nil))
 	}
 	return projectBases, nil
 }
@@ -251,10 +272,6 @@ func (p *projectServiceImpl) CreateProject(ctx context.Context, req apisv1.Creat
 	if namespace == "" {
 		namespace = req.Name
 	}
-	createCtx := apiutils.WithProject(ctx, "")
-	if err := utils.CreateNamespace(createCtx, p.K8sClient, namespace); err != nil && !apierrors.IsAlreadyExists(err) {
-		return nil, bcode.ErrProjectNamespaceFail
-	}
 	newProject := &model.Project{
 		Name:        req.Name,
 		Description: req.Description,
@@ -262,6 +279,21 @@ func (p *projectServiceImpl) CreateProject(ctx context.Context, req apisv1.Creat
 		Owner:       owner,
 		Namespace:   namespace,
 	}
+	// Render the template before creating anything, so the invalid variables and the conflicts are reported early
+	var templateSpec *model.ProjectTemplateSpec
+	if req.Template != "" {
+		if p.ProjectTemplateService == nil {
+			return nil, bcode.ErrProjectTemplateNotExist
+		}
+		templateSpec, err = p.ProjectTemplateService.RenderTemplate(ctx, req.Template, newProject, req.Variables)
+		if err != nil {
+			return nil, err
+		}
+	}
+	createCtx := apiutils.WithProject(ctx, "")
+	if err := utils.CreateNamespace(createCtx, p.K8sClient, namespace); err != nil && !apierrors.IsAlreadyExists(err) {
+		return nil, bcode.ErrProjectNamespaceFail
+	}
 
 	if err := p.Store.Add(ctx, newProject); err != nil {
 		return nil, err
@@ -275,7 +307,22 @@ func (p *projectServiceImpl) CreateProject(ctx context.Context, req apisv1.Creat
 		klog.Errorf("fail to sync the default role and users for the project: %s", err.Error())
 	}
 
-	return ConvertProjectModel2Base(newProject, user), nil
+	if templateSpec != nil {
+		if err := p.ProjectTemplateService.ApplyTemplate(ctx, newProject, templateSpec); err != nil {
+			if err := p.DeleteProject(ctx, newProject.Name); err != nil {
+				klog.Errorf("failed to delete the project %s after failing to apply the template: %s", newProject.Name, err.Error())
+			}
+			return nil, err
+		}
+	}
+
+	base := ConvertProjectModel2Base(newProject, user)
+	if p.PluginHookService != nil {
+		payload := NewPluginHookPayload(ctx, pluginTypes.HookEventProjectCreated)
+		payload.Project = base
+		p.PluginHookService.Notify(ctx, pluginTypes.HookEventProjectCreated, payload)
+	}
+	return base, nil
 }
 
 // managePrivilegesForProject grant or revoke privileges for project
@@ -484,13 +531,16 @@ func (p *projectServiceImpl) ListTerraformProviders(ctx context.Context, project
 // ConvertProjectModel2Base convert project model to base struct
 func ConvertProjectModel2Base(project *model.Project, owner *model.User) *apisv1.ProjectBase {
 	base := &apisv1.ProjectBase{
-		Name:        project.Name,
-		Description: project.Description,
-		Alias:       project.Alias,
-		CreateTime:  project.CreateTime,
-		UpdateTime:  project.UpdateTime,
-		Owner:       apisv1.NameAlias{Name: project.Owner},
-		Namespace:   project.GetNamespace(),
+		Name:         project.Name,
+		Description:  project.Description,
+		Alias:        project.Alias,
+		CreateTime:   project.CreateTime,
+		UpdateTime:   project.UpdateTime,
+		Owner:        apisv1.NameAlias{Name: project.Owner},
+		Namespace:    project.GetNamespace(),
+		Archived:     project.Archived,
+		ArchivedTime: project.ArchivedTime,
+		Deletion:     project.Deletion,
 	}
 	if owner != nil && owner.Name == project.Owner {
 		base.Owner = apisv1.NameAlias{Name: owner.Name, Alias: owner.Alias}
@@ -0,0 +1,262 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/klog/v2"
+
+	"github.com/kubevela/velaux/pkg/server/domain/model"
+	"github.com/kubevela/velaux/pkg/server/infrastructure/datastore"
+	apisv1 "github.com/kubevela/velaux/pkg/server/interfaces/api/dto/v1"
+	"github.com/kubevela/velaux/pkg/server/utils/bcode"
+)
+
+var (
+	// applicationRecycleTimeout the max duration of waiting for the application CRs are removed from the envs
+	applicationRecycleTimeout = time.Minute * 2
+	// projectDeletionResumeAfter the interrupted deletions, such as the server restarted, are resumed after this duration
+	projectDeletionResumeAfter = time.Minute * 30
+)
+
+// ArchiveProject archive the project, the archived project is read-only and hidden from the members
+func (p *projectServiceImpl) ArchiveProject(ctx context.Context, projectName string) (*apisv1.ProjectBase, error) {
+	project, err := p.GetProject(ctx, projectName)
+	if err != nil {
+		return nil, err
+	}
+	if project.Deletion != nil {
+		return nil, bcode.ErrProjectDeleting
+	}
+	if project.Archived {
+		return ConvertProjectModel2Base(project, nil), nil
+	}
+	now := time.Now()
+	project.Archived = true
+	project.ArchivedTime = &now
+	if err := p.Store.Put(ctx, project); err != nil {
+		return nil, err
+	}
+	return ConvertProjectModel2Base(project, nil), nil
+}
+
+// RestoreProject restore the archived project in the retention period
+func (p *projectServiceImpl) RestoreProject(ctx context.Context, projectName string) (*apisv1.ProjectBase, error) {
+	project, err := p.GetProject(ctx, projectName)
+	if err != nil {
+		return nil, err
+	}
+	if !project.Archived {
+		return nil, bcode.ErrProjectNotArchived
+	}
+	if project.Deletion != nil || p.retentionExpired(project) {
+		return nil, bcode.ErrProjectRetentionExpired
+	}
+	project.Archived = false
+	project.ArchivedTime = nil
+	if err := p.Store.Put(ctx, project); err != nil {
+		return nil, err
+	}
+	return ConvertProjectModel2Base(project, nil), nil
+}
+
+// ensureProjectWritable reject writing the resources into the archived project or the project being deleted,
+// the project not exist is left to the callers.
+func ensureProjectWritable(ctx context.Context, ds datastore.DataStore, projectName string) error {
+	if projectName == "" {
+		return nil
+	}
+	project := &model.Project{Name: projectName}
+	if err := ds.Get(ctx, project); err != nil {
+		if errors.Is(err, datastore.ErrRecordNotExist) {
+			return nil
+		}
+		return err
+	}
+	if project.Deletion != nil && project.Deletion.Phase != model.ProjectDeletionPhaseFailed {
+		return bcode.ErrProjectDeleting
+	}
+	if project.Archived {
+		return bcode.ErrProjectArchived
+	}
+	return nil
+}
+
+// DeleteProjectCascade delete the project with all applications, envs and targets in the background,
+// the progress is recorded in the deletion field of the project.
+func (p *projectServiceImpl) DeleteProjectCascade(ctx context.Context, projectName string) (*apisv1.ProjectBase, error) {
+	project, err := p.GetProject(ctx, projectName)
+	if err != nil {
+		return nil, err
+	}
+	if _, running := p.deleting.Load(projectName); running {
+		return nil, bcode.ErrProjectDeleting
+	}
+	if project.Deletion != nil && project.Deletion.Phase != model.ProjectDeletionPhaseFailed && time.Since(project.Deletion.StartTime) < projectDeletionResumeAfter {
+		return nil, bcode.ErrProjectDeleting
+	}
+	project.Deletion = &model.ProjectDeletion{Phase: model.ProjectDeletionPhaseApplications, StartTime: time.Now()}
+	if err := p.Store.Put(ctx, project); err != nil {
+		return nil, err
+	}
+	p.deleting.Store(projectName, true)
+	go func() {
+		defer p.deleting.Delete(projectName)
+		// the deletion outlives the request, so it does not inherit the request context
+		if err := p.cascadeDelete(context.Background(), projectName); err != nil {
+			klog.Errorf("failed to delete the project %s: %s", projectName, err.Error())
+		}
+	}()
+	return ConvertProjectModel2Base(project, nil), nil
+}
+
+// PurgeArchivedProjects delete the archived projects whose retention period is expired, and resume the interrupted deletions
+func (p *projectServiceImpl) PurgeArchivedProjects(ctx context.Context) error {
+	entities, err := p.Store.List(ctx, &model.Project{}, nil)
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, entity := range entities {
+		project := entity.(*model.Project)
+		purge := project.Archived && p.retentionExpired(project)
+		resume := project.Deletion != nil && project.Deletion.Phase != model.ProjectDeletionPhaseFailed &&
+			time.Since(project.Deletion.StartTime) > projectDeletionResumeAfter
+		if !purge && !resume {
+			continue
+		}
+		if _, running := p.deleting.LoadOrStore(project.Name, true); running {
+			continue
+		}
+		klog.Infof("purging the project %s", project.Name)
+		err := p.cascadeDelete(ctx, project.Name)
+		p.deleting.Delete(project.Name)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to purge the project %s: %w", project.Name, err))
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+func (p *projectServiceImpl) retentionExpired(project *model.Project) bool {
+	return project.ArchivedTime != nil && time.Since(*project.ArchivedTime) > p.ArchiveRetention
+}
+
+// cascadeDelete delete the applications, the envs, the targets and the project in order,
+// the applications must be removed from the envs before deleting the envs and the targets.
+func (p *projectServiceImpl) cascadeDelete(ctx context.Context, projectName string) error {
+	progress := &model.ProjectDeletion{StartTime: time.Now(), Deleted: map[string]int{}, Remaining: map[string]int{}}
+	record := func(phase string, stepErr error) error {
+		progress.Phase = phase
+		if stepErr != nil {
+			progress.Phase = model.ProjectDeletionPhaseFailed
+			progress.Message = stepErr.Error()
+		}
+		project := &model.Project{Name: projectName}
+		if err := p.Store.Get(ctx, project); err != nil {
+			return err
+		}
+		project.Deletion = progress
+		if err := p.Store.Put(ctx, project); err != nil {
+			return err
+		}
+		return stepErr
+	}
+
+	apps, err := p.Store.List(ctx, &model.Application{Project: projectName}, nil)
+	if err != nil {
+		return record(model.ProjectDeletionPhaseApplications, err)
+	}
+	envs, err := p.Store.List(ctx, &model.Env{Project: projectName}, nil)
+	if err != nil {
+		return record(model.ProjectDeletionPhaseApplications, err)
+	}
+	targets, err := p.Store.List(ctx, &model.Target{Project: projectName}, nil)
+	if err != nil {
+		return record(model.ProjectDeletionPhaseApplications, err)
+	}
+	progress.Remaining[quotaApplications] = len(apps)
+	progress.Remaining[quotaEnvs] = len(envs)
+	progress.Remaining[quotaTargets] = len(targets)
+
+	for _, entity := range apps {
+		if err := record(model.ProjectDeletionPhaseApplications, p.deleteApplication(ctx, entity.(*model.Application))); err != nil {
+			return err
+		}
+		progress.Deleted[quotaApplications]++
+		progress.Remaining[quotaApplications]--
+	}
+	for _, entity := range envs {
+		if err := record(model.ProjectDeletionPhaseEnvs, p.EnvService.DeleteEnv(ctx, entity.(*model.Env).Name)); err != nil {
+			return err
+		}
+		progress.Deleted[quotaEnvs]++
+		progress.Remaining[quotaEnvs]--
+	}
+	for _, entity := range targets {
+		if err := record(model.ProjectDeletionPhaseTargets, p.TargetService.DeleteTarget(ctx, entity.(*model.Target).Name)); err != nil {
+			return err
+		}
+		progress.Deleted[quotaTargets]++
+		progress.Remaining[quotaTargets]--
+	}
+	if err := record(model.ProjectDeletionPhaseProject, nil); err != nil {
+		return err
+	}
+	if err := p.DeleteProject(ctx, projectName); err != nil {
+		return record(model.ProjectDeletionPhaseProject, err)
+	}
+	klog.Infof("the project %s is deleted", projectName)
+	return nil
+}
+
+// deleteApplication recycle the application from all envs, then delete it
+func (p *projectServiceImpl) deleteApplication(ctx context.Context, app *model.Application) error {
+	if p.ApplicationService == nil || p.EnvBindingService == nil {
+		return bcode.ErrProjectDenyDeleteByApplication
+	}
+	bindings, err := p.Store.List(ctx, &model.EnvBinding{AppPrimaryKey: app.PrimaryKey()}, nil)
+	if err != nil {
+		return err
+	}
+	for _, entity := range bindings {
+		if err := p.EnvBindingService.ApplicationEnvRecycle(ctx, app, entity.(*model.EnvBinding)); err != nil && !errors.Is(err, bcode.ErrEnvNotExisted) {
+			return err
+		}
+	}
+	// the application CRs are removed asynchronously by the controller, the deletion is refused until they are removed
+	err = wait.PollImmediate(time.Second*2, applicationRecycleTimeout, func() (bool, error) {
+		err := p.ApplicationService.DeleteApplication(ctx, app)
+		if errors.Is(err, bcode.ErrApplicationRefusedDelete) {
+			return false, nil
+		}
+		if err != nil && !errors.Is(err, bcode.ErrApplicationNotExist) {
+			return false, err
+		}
+		return true, nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete the application %s: %w", app.Name, err)
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/kubevela/velaux/pkg/server/domain/model"
+	"github.com/kubevela/velaux/pkg/server/infrastructure/datastore"
+	apisv1 "github.com/kubevela/velaux/pkg/server/interfaces/api/dto/v1"
+	"github.com/kubevela/velaux/pkg/server/utils/bcode"
+)
+
+var _ = Describe("Test project archive functions", func() {
+
+	BeforeEach(func() {
+		InitTestEnv("project-archive-test-" + strconv.FormatInt(time.Now().UnixNano(), 10))
+		projectService.ArchiveRetention = time.Hour
+		projectService.ApplicationService = appService
+		projectService.EnvBindingService = envBindingService
+		_, err := userService.CreateUser(context.TODO(), apisv1.CreateUserRequest{Name: "archive-owner", Email: "archive@example.com", Password: "password1"})
+		Expect(err).Should(BeNil())
+		_, err = projectService.CreateProject(context.TODO(), apisv1.CreateProjectRequest{Name: "archive", Owner: "archive-owner"})
+		Expect(err).Should(BeNil())
+	})
+
+	It("Test archiving and restoring the project", func() {
+		_, err := projectService.RestoreProject(context.TODO(), "archive")
+		Expect(err).Should(Equal(bcode.ErrProjectNotArchived))
+
+		base, err := projectService.ArchiveProject(context.TODO(), "archive")
+		Expect(err).Should(BeNil())
+		Expect(base.Archived).Should(BeTrue())
+		projects, err := projectService.ListUserProjects(context.TODO(), "archive-owner")
+		Expect(err).Should(BeNil())
+		Expect(len(projects)).Should(Equal(0))
+
+		// the project named in the request body is checked by the services, not only the project in the path
+		_, err = appService.CreateApplication(context.TODO(), apisv1.CreateApplicationRequest{Name: "archive-app", Project: "archive"})
+		Expect(err).Should(Equal(bcode.ErrProjectArchived))
+		_, err = envService.CreateEnv(context.TODO(), apisv1.CreateEnvRequest{Name: "archive-env", Namespace: "archive-env", Project: "archive"})
+		Expect(err).Should(Equal(bcode.ErrProjectArchived))
+		_, err = targetService.CreateTarget(context.TODO(), apisv1.CreateTargetRequest{
+			Name: "archive-target", Project: "archive", Cluster: &apisv1.ClusterTarget{ClusterName: "local", Namespace: "archive-target"},
+		})
+		Expect(err).Should(Equal(bcode.ErrProjectArchived))
+
+		base, err = projectService.RestoreProject(context.TODO(), "archive")
+		Expect(err).Should(BeNil())
+		Expect(base.Archived).Should(BeFalse())
+		projects, err = projectService.ListUserProjects(context.TODO(), "archive-owner")
+		Expect(err).Should(BeNil())
+		Expect(len(projects)).Should(Equal(1))
+	})
+
+	It("Test purging the expired project", func() {
+		_, err := targetService.CreateTarget(context.TODO(), apisv1.CreateTargetRequest{
+			Name: "archive-dev", Project: "archive", Cluster: &apisv1.ClusterTarget{ClusterName: "local", Namespace: "archive-dev"},
+		})
+		Expect(err).Should(BeNil())
+		_, err = envService.CreateEnv(context.TODO(), apisv1.CreateEnvRequest{Name: "archive-dev", Namespace: "archive-dev", Project: "archive", Targets: []string{"archive-dev"}})
+		Expect(err).Should(BeNil())
+		_, err = projectService.ArchiveProject(context.TODO(), "archive")
+		Expect(err).Should(BeNil())
+
+		// not expired
+		Expect(projectService.PurgeArchivedProjects(context.TODO())).Should(BeNil())
+		Expect(ds.Get(context.TODO(), &model.Project{Name: "archive"})).Should(BeNil())
+
+		project := &model.Project{Name: "archive"}
+		Expect(ds.Get(context.TODO(), project)).Should(BeNil())
+		expired := time.Now().Add(-2 * time.Hour)
+		project.ArchivedTime = &expired
+		Expect(ds.Put(context.TODO(), project)).Should(BeNil())
+		_, err = projectService.RestoreProject(context.TODO(), "archive")
+		Expect(err).Should(Equal(bcode.ErrProjectRetentionExpired))
+
+		Expect(projectService.PurgeArchivedProjects(context.TODO())).Should(BeNil())
+		for _, entity := range []datastore.Entity{&model.Project{Name: "archive"}, &model.Env{Name: "archive-dev"}, &model.Target{Name: "archive-dev"}} {
+			exist, err := ds.IsExist(context.TODO(), entity)
+			Expect(err).Should(BeNil())
+			Expect(exist).Should(BeFalse())
+		}
+	})
+
+	It("Test deleting the project cascading", func() {
+		_, err := envService.CreateEnv(context.TODO(), apisv1.CreateEnvRequest{Name: "cascade-dev", Namespace: "cascade-dev", Project: "archive"})
+		Expect(err).Should(BeNil())
+		base, err := projectService.DeleteProjectCascade(context.TODO(), "archive")
+		Expect(err).Should(BeNil())
+		Expect(base.Deletion).ShouldNot(BeNil())
+		Eventually(func() bool {
+			exist, err := ds.IsExist(context.TODO(), &model.Project{Name: "archive"})
+			return err == nil && !exist
+		}, time.Second*30, time.Second).Should(BeTrue())
+	})
+})
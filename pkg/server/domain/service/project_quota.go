@@ -0,0 +1,189 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/kubevela/workflow/api/v1alpha1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	velatypes "github.com/oam-dev/kubevela/apis/types"
+
+	"github.com/kubevela/velaux/pkg/server/domain/model"
+	"github.com/kubevela/velaux/pkg/server/domain/repository"
+	"github.com/kubevela/velaux/pkg/server/infrastructure/datastore"
+	apisv1 "github.com/kubevela/velaux/pkg/server/interfaces/api/dto/v1"
+	"github.com/kubevela/velaux/pkg/server/utils/bcode"
+)
+
+const (
+	quotaApplications = "applications"
+	quotaEnvs         = "envs"
+	quotaTargets      = "targets"
+	quotaComponents   = "components"
+)
+
+// GetProjectQuota get the quota and the current usage of the project
+func (p *projectServiceImpl) GetProjectQuota(ctx context.Context, projectName string) (*apisv1.ProjectQuotaResponse, error) {
+	project, err := p.GetProject(ctx, projectName)
+	if err != nil {
+		return nil, err
+	}
+	return p.projectQuotaResponse(ctx, project)
+}
+
+// UpdateProjectQuota update the quota of the project and provision the resource limits into the namespaces of the targets
+func (p *projectServiceImpl) UpdateProjectQuota(ctx context.Context, projectName string, req apisv1.UpdateProjectQuotaRequest) (*apisv1.ProjectQuotaResponse, error) {
+	project, err := p.GetProject(ctx, projectName)
+	if err != nil {
+		return nil, err
+	}
+	quota := &model.ProjectQuota{
+		MaxApplications:           req.MaxApplications,
+		MaxEnvs:                   req.MaxEnvs,
+		MaxTargets:                req.MaxTargets,
+		MaxComponentsPerApp:       req.MaxComponentsPerApp,
+		MaxConcurrentPipelineRuns: req.MaxConcurrentPipelineRuns,
+		ResourceQuota:             req.ResourceQuota,
+		DefaultLimits:             req.DefaultLimits,
+		DefaultRequests:           req.DefaultRequests,
+	}
+	for _, limit := range []int{quota.MaxApplications, quota.MaxEnvs, quota.MaxTargets, quota.MaxComponentsPerApp, quota.MaxConcurrentPipelineRuns} {
+		if limit < 0 {
+			return nil, bcode.ErrProjectQuotaInvalid.SetMessage("the limits of the project quota must not be negative")
+		}
+	}
+	for _, resources := range []map[string]string{quota.ResourceQuota, quota.DefaultLimits, quota.DefaultRequests} {
+		if _, err := repository.ParseResourceList(resources); err != nil {
+			return nil, err
+		}
+	}
+	project.Quota = quota
+	if err := p.Store.Put(ctx, project); err != nil {
+		return nil, err
+	}
+	if err := p.TargetService.SyncTargetQuota(ctx, project); err != nil {
+		return nil, err
+	}
+	return p.projectQuotaResponse(ctx, project)
+}
+
+func (p *projectServiceImpl) projectQuotaResponse(ctx context.Context, project *model.Project) (*apisv1.ProjectQuotaResponse, error) {
+	var res apisv1.ProjectQuotaResponse
+	if project.Quota != nil {
+		res.Quota = *project.Quota
+	}
+	var err error
+	if res.Usage.Applications, err = p.Store.Count(ctx, &model.Application{Project: project.Name}, nil); err != nil {
+		return nil, err
+	}
+	if res.Usage.Envs, err = p.Store.Count(ctx, &model.Env{Project: project.Name}, nil); err != nil {
+		return nil, err
+	}
+	if res.Usage.Targets, err = p.Store.Count(ctx, &model.Target{Project: project.Name}, nil); err != nil {
+		return nil, err
+	}
+	apps, err := p.Store.List(ctx, &model.Application{Project: project.Name}, nil)
+	if err != nil {
+		return nil, err
+	}
+	for _, entity := range apps {
+		count, err := p.Store.Count(ctx, &model.ApplicationComponent{AppPrimaryKey: entity.PrimaryKey()}, nil)
+		if err != nil {
+			return nil, err
+		}
+		if count > res.Usage.MaxComponentsPerApp {
+			res.Usage.MaxComponentsPerApp = count
+		}
+	}
+	if res.Usage.ConcurrentPipelineRuns, err = countRunningPipelineRuns(ctx, p.K8sClient, project); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// checkProjectQuota return the ErrProjectQuotaExceeded if creating one more resource exceeds the quota of the project,
+// the entity is used to count the existing resources.
+func checkProjectQuota(ctx context.Context, ds datastore.DataStore, projectName string, resource string, entity datastore.Entity) error {
+	project := &model.Project{Name: projectName}
+	if err := ds.Get(ctx, project); err != nil {
+		if errors.Is(err, datastore.ErrRecordNotExist) {
+			return nil
+		}
+		return err
+	}
+	if project.Quota == nil {
+		return nil
+	}
+	var limit int
+	switch resource {
+	case quotaApplications:
+		limit = project.Quota.MaxApplications
+	case quotaEnvs:
+		limit = project.Quota.MaxEnvs
+	case quotaTargets:
+		limit = project.Quota.MaxTargets
+	case quotaComponents:
+		limit = project.Quota.MaxComponentsPerApp
+	}
+	if limit <= 0 {
+		return nil
+	}
+	count, err := ds.Count(ctx, entity, nil)
+	if err != nil {
+		return err
+	}
+	if count >= int64(limit) {
+		return bcode.ErrProjectQuotaExceeded.SetMessage(fmt.Sprintf("the quota of the %s in the project %s is exceeded (%d/%d)", resource, projectName, count, limit))
+	}
+	return nil
+}
+
+// checkPipelineRunQuota return the ErrProjectQuotaExceeded if the concurrent pipeline runs reach the quota of the project
+func checkPipelineRunQuota(ctx context.Context, cli client.Client, project *model.Project) error {
+	if project.Quota == nil || project.Quota.MaxConcurrentPipelineRuns <= 0 {
+		return nil
+	}
+	count, err := countRunningPipelineRuns(ctx, cli, project)
+	if err != nil {
+		return err
+	}
+	if count >= int64(project.Quota.MaxConcurrentPipelineRuns) {
+		return bcode.ErrProjectQuotaExceeded.SetMessage(fmt.Sprintf("the quota of the concurrent pipeline runs in the project %s is exceeded (%d/%d)", project.Name, count, project.Quota.MaxConcurrentPipelineRuns))
+	}
+	return nil
+}
+
+func countRunningPipelineRuns(ctx context.Context, cli client.Client, project *model.Project) (int64, error) {
+	if cli == nil {
+		return 0, nil
+	}
+	var runs v1alpha1.WorkflowRunList
+	if err := cli.List(ctx, &runs, client.InNamespace(project.GetNamespace()), client.MatchingLabels{velatypes.LabelSourceOfTruth: velatypes.FromUX}, client.HasLabels{labelPipeline}); err != nil {
+		return 0, err
+	}
+	var count int64
+	for _, run := range runs.Items {
+		if !run.Status.Finished {
+			count++
+		}
+	}
+	return count, nil
+}
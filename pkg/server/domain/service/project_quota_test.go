@@ -0,0 +1,103 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/kubevela/velaux/pkg/server/domain/model"
+	"github.com/kubevela/velaux/pkg/server/domain/repository"
+	apisv1 "github.com/kubevela/velaux/pkg/server/interfaces/api/dto/v1"
+	"github.com/kubevela/velaux/pkg/server/utils/bcode"
+)
+
+var _ = Describe("Test project quota functions", func() {
+
+	BeforeEach(func() {
+		InitTestEnv("project-quota-test-" + strconv.FormatInt(time.Now().UnixNano(), 10))
+		_, err := projectService.CreateProject(context.TODO(), apisv1.CreateProjectRequest{Name: "quota"})
+		Expect(err).Should(BeNil())
+	})
+
+	It("Test updating the quota", func() {
+		_, err := projectService.UpdateProjectQuota(context.TODO(), "quota", apisv1.UpdateProjectQuotaRequest{
+			ResourceQuota: map[string]string{"requests.cpu": "invalid"},
+		})
+		Expect(err.(*bcode.Bcode).BusinessCode).Should(Equal(bcode.ErrProjectQuotaInvalid.BusinessCode))
+		_, err = projectService.UpdateProjectQuota(context.TODO(), "quota", apisv1.UpdateProjectQuotaRequest{MaxApplications: -1})
+		Expect(err.(*bcode.Bcode).BusinessCode).Should(Equal(bcode.ErrProjectQuotaInvalid.BusinessCode))
+		_, err = projectService.UpdateProjectQuota(context.TODO(), "quota", apisv1.UpdateProjectQuotaRequest{
+			ResourceQuota: map[string]string{"requests.cpu": "-2"},
+		})
+		Expect(err.(*bcode.Bcode).BusinessCode).Should(Equal(bcode.ErrProjectQuotaInvalid.BusinessCode))
+
+		res, err := projectService.UpdateProjectQuota(context.TODO(), "quota", apisv1.UpdateProjectQuotaRequest{
+			MaxEnvs:       1,
+			MaxTargets:    1,
+			ResourceQuota: map[string]string{"requests.cpu": "2"},
+			DefaultLimits: map[string]string{"memory": "512Mi"},
+		})
+		Expect(err).Should(BeNil())
+		Expect(res.Quota.MaxEnvs).Should(Equal(1))
+		Expect(res.Usage.Envs).Should(Equal(int64(0)))
+	})
+
+	It("Test enforcing the quota", func() {
+		_, err := projectService.UpdateProjectQuota(context.TODO(), "quota", apisv1.UpdateProjectQuotaRequest{
+			MaxEnvs:       1,
+			MaxTargets:    1,
+			ResourceQuota: map[string]string{"requests.cpu": "2"},
+		})
+		Expect(err).Should(BeNil())
+		_, err = targetService.CreateTarget(context.TODO(), apisv1.CreateTargetRequest{
+			Name: "quota-dev", Project: "quota", Cluster: &apisv1.ClusterTarget{ClusterName: "local", Namespace: "quota-dev"},
+		})
+		Expect(err).Should(BeNil())
+		var resourceQuota corev1.ResourceQuota
+		Expect(k8sClient.Get(context.TODO(), types.NamespacedName{Namespace: "quota-dev", Name: repository.TargetResourceQuotaName}, &resourceQuota)).Should(BeNil())
+		Expect(resourceQuota.Spec.Hard.Cpu().String()).Should(Equal("2"))
+
+		_, err = targetService.CreateTarget(context.TODO(), apisv1.CreateTargetRequest{
+			Name: "quota-prod", Project: "quota", Cluster: &apisv1.ClusterTarget{ClusterName: "local", Namespace: "quota-prod"},
+		})
+		Expect(err.(*bcode.Bcode).BusinessCode).Should(Equal(bcode.ErrProjectQuotaExceeded.BusinessCode))
+
+		_, err = envService.CreateEnv(context.TODO(), apisv1.CreateEnvRequest{Name: "quota-dev", Namespace: "quota-dev", Project: "quota", Targets: []string{"quota-dev"}})
+		Expect(err).Should(BeNil())
+		_, err = envService.CreateEnv(context.TODO(), apisv1.CreateEnvRequest{Name: "quota-prod", Namespace: "quota-prod", Project: "quota"})
+		Expect(err.(*bcode.Bcode).BusinessCode).Should(Equal(bcode.ErrProjectQuotaExceeded.BusinessCode))
+
+		res, err := projectService.GetProjectQuota(context.TODO(), "quota")
+		Expect(err).Should(BeNil())
+		Expect(res.Usage.Envs).Should(Equal(int64(1)))
+		Expect(res.Usage.Targets).Should(Equal(int64(1)))
+
+		// removing the resource quota cleans up the namespaces of the targets
+		_, err = projectService.UpdateProjectQuota(context.TODO(), "quota", apisv1.UpdateProjectQuotaRequest{})
+		Expect(err).Should(BeNil())
+		err = k8sClient.Get(context.TODO(), types.NamespacedName{Namespace: "quota-dev", Name: repository.TargetResourceQuotaName}, &resourceQuota)
+		Expect(err).ShouldNot(BeNil())
+		Expect(checkProjectQuota(context.TODO(), ds, "quota", quotaEnvs, &model.Env{Project: "quota"})).Should(BeNil())
+	})
+})
@@ -0,0 +1,386 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+
+	"k8s.io/klog/v2"
+
+	"github.com/kubevela/velaux/pkg/server/domain/model"
+	"github.com/kubevela/velaux/pkg/server/infrastructure/datastore"
+	apisv1 "github.com/kubevela/velaux/pkg/server/interfaces/api/dto/v1"
+	"github.com/kubevela/velaux/pkg/server/utils/bcode"
+)
+
+const (
+	// TemplateVariableProject the built-in variable of the project name
+	TemplateVariableProject = "project"
+	// TemplateVariableNamespace the built-in variable of the project namespace
+	TemplateVariableNamespace = "namespace"
+	// TemplateVariableOwner the built-in variable of the project owner
+	TemplateVariableOwner = "owner"
+)
+
+var (
+	templatePlaceholder  = regexp.MustCompile(`\$\{([^}]*)\}`)
+	templateVariableName = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+)
+
+// ProjectTemplateService manages the project templates and bootstraps the projects by them
+type ProjectTemplateService interface {
+	ListTemplates(ctx context.Context) (*apisv1.ListProjectTemplateResponse, error)
+	GetTemplate(ctx context.Context, name string) (*model.ProjectTemplate, error)
+	CreateTemplate(ctx context.Context, req apisv1.CreateProjectTemplateRequest) (*apisv1.ProjectTemplateBase, error)
+	UpdateTemplate(ctx context.Context, template *model.ProjectTemplate, req apisv1.UpdateProjectTemplateRequest) (*apisv1.ProjectTemplateBase, error)
+	DeleteTemplate(ctx context.Context, name string) error
+	// RenderTemplate replace the placeholders with the variables and check the declared resources are not exist,
+	// the project is not created yet.
+	RenderTemplate(ctx context.Context, name string, project *model.Project, variables map[string]string) (*model.ProjectTemplateSpec, error)
+	// ApplyTemplate create the resources of the rendered template in the project, the created resources are deleted if it fails.
+	ApplyTemplate(ctx context.Context, project *model.Project, spec *model.ProjectTemplateSpec) error
+}
+
+type projectTemplateServiceImpl struct {
+	Store           datastore.DataStore `inject:"datastore"`
+	ProjectService  ProjectService      `inject:""`
+	TargetService   TargetService       `inject:""`
+	EnvService      EnvService          `inject:""`
+	RbacService     RBACService         `inject:""`
+	ConfigService   ConfigService       `inject:""`
+	PipelineService PipelineService     `inject:""`
+}
+
+// NewProjectTemplateService new project template service
+func NewProjectTemplateService() ProjectTemplateService {
+	return &projectTemplateServiceImpl{}
+}
+
+func (p *projectTemplateServiceImpl) ListTemplates(ctx context.Context) (*apisv1.ListProjectTemplateResponse, error) {
+	entities, err := p.Store.List(ctx, &model.ProjectTemplate{}, &datastore.ListOptions{
+		SortBy: []datastore.SortOption{{Key: "createTime", Order: datastore.SortOrderAscending}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	res := &apisv1.ListProjectTemplateResponse{Templates: []*apisv1.ProjectTemplateBase{}}
+	for _, entity := range entities {
+		res.Templates = append(res.Templates, convertProjectTemplateBase(entity.(*model.ProjectTemplate)))
+	}
+	return res, nil
+}
+
+func (p *projectTemplateServiceImpl) GetTemplate(ctx context.Context, name string) (*model.ProjectTemplate, error) {
+	template := &model.ProjectTemplate{Name: name}
+	if err := p.Store.Get(ctx, template); err != nil {
+		if errors.Is(err, datastore.ErrRecordNotExist) {
+			return nil, bcode.ErrProjectTemplateNotExist
+		}
+		return nil, err
+	}
+	return template, nil
+}
+
+func (p *projectTemplateServiceImpl) CreateTemplate(ctx context.Context, req apisv1.CreateProjectTemplateRequest) (*apisv1.ProjectTemplateBase, error) {
+	template := &model.ProjectTemplate{
+		Name:        req.Name,
+		Alias:       req.Alias,
+		Description: req.Description,
+		Variables:   req.Variables,
+		Spec:        req.Spec,
+	}
+	if err := validateProjectTemplate(template); err != nil {
+		return nil, err
+	}
+	if err := p.Store.Add(ctx, template); err != nil {
+		if errors.Is(err, datastore.ErrRecordExist) {
+			return nil, bcode.ErrProjectTemplateExist
+		}
+		return nil, err
+	}
+	return convertProjectTemplateBase(template), nil
+}
+
+func (p *projectTemplateServiceImpl) UpdateTemplate(ctx context.Context, template *model.ProjectTemplate, req apisv1.UpdateProjectTemplateRequest) (*apisv1.ProjectTemplateBase, error) {
+	template.Alias = req.Alias
+	template.Description = req.Description
+	template.Variables = req.Variables
+	template.Spec = req.Spec
+	if err := validateProjectTemplate(template); err != nil {
+		return nil, err
+	}
+	if err := p.Store.Put(ctx, template); err != nil {
+		return nil, err
+	}
+	return convertProjectTemplateBase(template), nil
+}
+
+func (p *projectTemplateServiceImpl) DeleteTemplate(ctx context.Context, name string) error {
+	if err := p.Store.Delete(ctx, &model.ProjectTemplate{Name: name}); err != nil {
+		if errors.Is(err, datastore.ErrRecordNotExist) {
+			return bcode.ErrProjectTemplateNotExist
+		}
+		return err
+	}
+	return nil
+}
+
+// validateProjectTemplate check the variables are declared once and all placeholders refer the declared or the built-in variables
+func validateProjectTemplate(template *model.ProjectTemplate) error {
+	declared := map[string]bool{TemplateVariableProject: true, TemplateVariableNamespace: true, TemplateVariableOwner: true}
+	for _, v := range template.Variables {
+		if !templateVariableName.MatchString(v.Name) {
+			return bcode.ErrProjectTemplateInvalid.SetMessage(fmt.Sprintf("the variable name %q is invalid", v.Name))
+		}
+		if declared[v.Name] {
+			return bcode.ErrProjectTemplateInvalid.SetMessage(fmt.Sprintf("the variable %q is declared repeatedly or built-in", v.Name))
+		}
+		declared[v.Name] = true
+	}
+	raw, err := json.Marshal(template.Spec)
+	if err != nil {
+		return err
+	}
+	for _, match := range templatePlaceholder.FindAllStringSubmatch(string(raw), -1) {
+		if !declared[match[1]] {
+			return bcode.ErrProjectTemplateInvalid.SetMessage(fmt.Sprintf("the variable %q is not declared", match[1]))
+		}
+	}
+	return nil
+}
+
+func (p *projectTemplateServiceImpl) RenderTemplate(ctx context.Context, name string, project *model.Project, variables map[string]string) (*model.ProjectTemplateSpec, error) {
+	template, err := p.GetTemplate(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	values := map[string]string{
+		TemplateVariableProject:   project.Name,
+		TemplateVariableNamespace: project.GetNamespace(),
+		TemplateVariableOwner:     project.Owner,
+	}
+	declared := map[string]bool{}
+	for _, v := range template.Variables {
+		declared[v.Name] = true
+		value, ok := variables[v.Name]
+		if !ok || value == "" {
+			if v.Required {
+				return nil, bcode.ErrProjectTemplateVariable.SetMessage(fmt.Sprintf("the variable %q is required", v.Name))
+			}
+			value = v.Default
+		}
+		values[v.Name] = value
+	}
+	for key := range variables {
+		if !declared[key] {
+			return nil, bcode.ErrProjectTemplateVariable.SetMessage(fmt.Sprintf("the variable %q is not declared by the template", key))
+		}
+	}
+	spec, err := renderProjectTemplateSpec(template.Spec, values)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.checkConflicts(ctx, spec); err != nil {
+		return nil, err
+	}
+	return spec, nil
+}
+
+// renderProjectTemplateSpec replace the placeholders in the string values of the spec, the values are escaped as the JSON strings
+func renderProjectTemplateSpec(spec model.ProjectTemplateSpec, values map[string]string) (*model.ProjectTemplateSpec, error) {
+	raw, err := json.Marshal(spec)
+	if err != nil {
+		return nil, err
+	}
+	var renderErr error
+	rendered := templatePlaceholder.ReplaceAllStringFunc(string(raw), func(placeholder string) string {
+		name := placeholder[2 : len(placeholder)-1]
+		value, ok := values[name]
+		if !ok {
+			renderErr = bcode.ErrProjectTemplateVariable.SetMessage(fmt.Sprintf("the variable %q is not declared", name))
+			return placeholder
+		}
+		escaped, _ := json.Marshal(value)
+		return string(escaped[1 : len(escaped)-1])
+	})
+	if renderErr != nil {
+		return nil, renderErr
+	}
+	var res model.ProjectTemplateSpec
+	if err := json.Unmarshal([]byte(rendered), &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// checkConflicts the names of the targets and the envs are unique in the platform, check them before creating the project
+func (p *projectTemplateServiceImpl) checkConflicts(ctx context.Context, spec *model.ProjectTemplateSpec) error {
+	for _, target := range spec.Targets {
+		exist, err := p.Store.IsExist(ctx, &model.Target{Name: target.Name})
+		if err != nil {
+			return err
+		}
+		if exist {
+			return bcode.ErrProjectTemplateConflict.SetMessage(fmt.Sprintf("the target %q already exists", target.Name))
+		}
+	}
+	for _, env := range spec.Envs {
+		exist, err := p.Store.IsExist(ctx, &model.Env{Name: env.Name})
+		if err != nil {
+			return err
+		}
+		if exist {
+			return bcode.ErrProjectTemplateConflict.SetMessage(fmt.Sprintf("the environment %q already exists", env.Name))
+		}
+	}
+	return nil
+}
+
+func (p *projectTemplateServiceImpl) ApplyTemplate(ctx context.Context, project *model.Project, spec *model.ProjectTemplateSpec) (err error) {
+	var rollbacks []func() error
+	defer func() {
+		if err == nil {
+			return
+		}
+		klog.Warningf("failed to apply the template to the project %s, rolling back: %s", project.Name, err.Error())
+		for i := len(rollbacks) - 1; i >= 0; i-- {
+			if rollbackErr := rollbacks[i](); rollbackErr != nil {
+				klog.Errorf("failed to roll back the resource of the project %s: %s", project.Name, rollbackErr.Error())
+			}
+		}
+	}()
+
+	for _, target := range spec.Targets {
+		cluster := target.Cluster
+		if _, err := p.TargetService.CreateTarget(ctx, apisv1.CreateTargetRequest{
+			Name:        target.Name,
+			Alias:       target.Alias,
+			Description: target.Description,
+			Project:     project.Name,
+			Cluster:     &apisv1.ClusterTarget{ClusterName: cluster.ClusterName, Namespace: cluster.Namespace},
+		}); err != nil {
+			return wrapTemplateError(err, "create the target "+target.Name)
+		}
+		name := target.Name
+		rollbacks = append(rollbacks, func() error { return p.TargetService.DeleteTarget(ctx, name) })
+	}
+	for _, env := range spec.Envs {
+		if _, err := p.EnvService.CreateEnv(ctx, apisv1.CreateEnvRequest{
+			Name:        env.Name,
+			Alias:       env.Alias,
+			Description: env.Description,
+			Project:     project.Name,
+			Namespace:   env.Namespace,
+			Targets:     env.Targets,
+		}); err != nil {
+			return wrapTemplateError(err, "create the environment "+env.Name)
+		}
+		name := env.Name
+		rollbacks = append(rollbacks, func() error { return p.EnvService.DeleteEnv(ctx, name) })
+	}
+	for _, perm := range spec.Permissions {
+		if _, err := p.RbacService.CreatePermission(ctx, project.Name, apisv1.CreatePermissionRequest{
+			Name:      perm.Name,
+			Alias:     perm.Alias,
+			Resources: perm.Resources,
+			Actions:   perm.Actions,
+			Effect:    perm.Effect,
+		}); err != nil {
+			return wrapTemplateError(err, "create the permission "+perm.Name)
+		}
+		name := perm.Name
+		rollbacks = append(rollbacks, func() error { return p.RbacService.DeletePermission(ctx, project.Name, name) })
+	}
+	for _, role := range spec.Roles {
+		if _, err := p.RbacService.CreateRole(ctx, project.Name, apisv1.CreateRoleRequest{
+			Name:        role.Name,
+			Alias:       role.Alias,
+			Permissions: role.Permissions,
+		}); err != nil {
+			return wrapTemplateError(err, "create the role "+role.Name)
+		}
+		name := role.Name
+		rollbacks = append(rollbacks, func() error { return p.RbacService.DeleteRole(ctx, project.Name, name) })
+	}
+	for _, member := range spec.Members {
+		if member.User == project.Owner {
+			// The owner is added as the project admin while creating the project
+			if _, err := p.ProjectService.UpdateProjectUser(ctx, project.Name, member.User, apisv1.UpdateProjectUserRequest{UserRoles: member.Roles}); err != nil {
+				return wrapTemplateError(err, "update the roles of the member "+member.User)
+			}
+			continue
+		}
+		if _, err := p.ProjectService.AddProjectUser(ctx, project.Name, apisv1.AddProjectUserRequest{UserName: member.User, UserRoles: member.Roles}); err != nil {
+			return wrapTemplateError(err, "add the member "+member.User)
+		}
+		name := member.User
+		rollbacks = append(rollbacks, func() error { return p.ProjectService.DeleteProjectUser(ctx, project.Name, name) })
+	}
+	for _, distribution := range spec.ConfigDistributions {
+		req := apisv1.CreateConfigDistributionRequest{Name: distribution.Name}
+		for _, c := range distribution.Configs {
+			req.Configs = append(req.Configs, &apisv1.NamespacedName{Name: c.Name, Namespace: c.Namespace})
+		}
+		for _, t := range distribution.Targets {
+			req.Targets = append(req.Targets, &apisv1.ClusterTarget{ClusterName: t.ClusterName, Namespace: t.Namespace})
+		}
+		if err := p.ConfigService.CreateConfigDistribution(ctx, project.Name, req); err != nil {
+			return wrapTemplateError(err, "create the config distribution "+distribution.Name)
+		}
+		name := distribution.Name
+		rollbacks = append(rollbacks, func() error { return p.ConfigService.DeleteConfigDistribution(ctx, project.Name, name) })
+	}
+	pipelineCtx := context.WithValue(ctx, &apisv1.CtxKeyProject, project)
+	for _, pipeline := range spec.Pipelines {
+		if _, err := p.PipelineService.CreatePipeline(pipelineCtx, apisv1.CreatePipelineRequest{
+			Name:        pipeline.Name,
+			Alias:       pipeline.Alias,
+			Description: pipeline.Description,
+			Spec:        pipeline.Spec,
+		}); err != nil {
+			return wrapTemplateError(err, "create the pipeline "+pipeline.Name)
+		}
+		base := apisv1.PipelineBase{PipelineMeta: apisv1.PipelineMeta{Name: pipeline.Name, Project: apisv1.NameAlias{Name: project.Name}}}
+		rollbacks = append(rollbacks, func() error { return p.PipelineService.DeletePipeline(pipelineCtx, base) })
+	}
+	return nil
+}
+
+func convertProjectTemplateBase(template *model.ProjectTemplate) *apisv1.ProjectTemplateBase {
+	return &apisv1.ProjectTemplateBase{
+		Name:        template.Name,
+		Alias:       template.Alias,
+		Description: template.Description,
+		Variables:   template.Variables,
+		Spec:        template.Spec,
+		CreateTime:  template.CreateTime,
+		UpdateTime:  template.UpdateTime,
+	}
+}
+
+// wrapTemplateError add the failed action to the message, the bcode is kept so the client gets the reason of the failure
+func wrapTemplateError(err error, action string) error {
+	var b *bcode.Bcode
+	if errors.As(err, &b) {
+		return b.SetMessage(action + ": " + b.Message)
+	}
+	return fmt.Errorf("%s: %w", action, err)
+}
@@ -0,0 +1,129 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/kubevela/velaux/pkg/server/domain/model"
+	apisv1 "github.com/kubevela/velaux/pkg/server/interfaces/api/dto/v1"
+	"github.com/kubevela/velaux/pkg/server/utils/bcode"
+)
+
+var _ = Describe("Test project template service functions", func() {
+	var templateService *projectTemplateServiceImpl
+
+	BeforeEach(func() {
+		InitTestEnv("project-template-test-" + strconv.FormatInt(time.Now().UnixNano(), 10))
+		templateService = &projectTemplateServiceImpl{
+			Store:          ds,
+			ProjectService: projectService,
+			TargetService:  targetService,
+			EnvService:     envService,
+			RbacService:    rbacService,
+		}
+		projectService.ProjectTemplateService = templateService
+		_, err := userService.CreateUser(context.TODO(), apisv1.CreateUserRequest{Name: "template-owner", Email: "owner@example.com", Password: "password1"})
+		Expect(err).Should(BeNil())
+		_, err = templateService.CreateTemplate(context.TODO(), apisv1.CreateProjectTemplateRequest{
+			Name:      "team",
+			Variables: []model.ProjectTemplateVariable{{Name: "cluster", Default: "local"}, {Name: "member", Required: true}},
+			Spec: model.ProjectTemplateSpec{
+				Targets: []model.ProjectTemplateTarget{{Name: "${project}-dev", Cluster: model.ClusterTarget{ClusterName: "${cluster}", Namespace: "${project}-dev"}}},
+				Envs:    []model.ProjectTemplateEnv{{Name: "${project}-dev", Namespace: "${project}-dev", Targets: []string{"${project}-dev"}}},
+				Permissions: []model.ProjectTemplatePermission{{
+					Name: "app-view", Resources: []string{"project:${project}/application:*"}, Actions: []string{"detail", "list"}, Effect: "Allow",
+				}},
+				Roles:   []model.ProjectTemplateRole{{Name: "viewer", Permissions: []string{"app-view"}}},
+				Members: []model.ProjectTemplateMember{{User: "${member}", Roles: []string{"viewer"}}},
+			},
+		})
+		Expect(err).Should(BeNil())
+	})
+
+	It("Test validating the template", func() {
+		_, err := templateService.CreateTemplate(context.TODO(), apisv1.CreateProjectTemplateRequest{
+			Name: "invalid",
+			Spec: model.ProjectTemplateSpec{Envs: []model.ProjectTemplateEnv{{Name: "${unknown}"}}},
+		})
+		Expect(err.(*bcode.Bcode).BusinessCode).Should(Equal(bcode.ErrProjectTemplateInvalid.BusinessCode))
+		_, err = templateService.CreateTemplate(context.TODO(), apisv1.CreateProjectTemplateRequest{
+			Name:      "invalid",
+			Variables: []model.ProjectTemplateVariable{{Name: "project"}},
+		})
+		Expect(err.(*bcode.Bcode).BusinessCode).Should(Equal(bcode.ErrProjectTemplateInvalid.BusinessCode))
+		_, err = templateService.CreateTemplate(context.TODO(), apisv1.CreateProjectTemplateRequest{Name: "team"})
+		Expect(err).Should(Equal(bcode.ErrProjectTemplateExist))
+	})
+
+	It("Test rendering the template", func() {
+		project := &model.Project{Name: "render", Owner: "template-owner"}
+		_, err := templateService.RenderTemplate(context.TODO(), "team", project, nil)
+		Expect(err.(*bcode.Bcode).BusinessCode).Should(Equal(bcode.ErrProjectTemplateVariable.BusinessCode))
+		_, err = templateService.RenderTemplate(context.TODO(), "team", project, map[string]string{"member": "a", "other": "b"})
+		Expect(err.(*bcode.Bcode).BusinessCode).Should(Equal(bcode.ErrProjectTemplateVariable.BusinessCode))
+
+		spec, err := templateService.RenderTemplate(context.TODO(), "team", project, map[string]string{"member": `a"b`})
+		Expect(err).Should(BeNil())
+		Expect(spec.Targets[0].Name).Should(Equal("render-dev"))
+		Expect(spec.Targets[0].Cluster.ClusterName).Should(Equal("local"))
+		Expect(spec.Envs[0].Targets).Should(Equal([]string{"render-dev"}))
+		Expect(spec.Permissions[0].Resources).Should(Equal([]string{"project:render/application:*"}))
+		Expect(spec.Members[0].User).Should(Equal(`a"b`))
+	})
+
+	It("Test creating the project by the template", func() {
+		_, err := projectService.CreateProject(context.TODO(), apisv1.CreateProjectRequest{
+			Name: "bootstrap", Owner: "template-owner", Template: "team", Variables: map[string]string{"member": "template-owner"},
+		})
+		Expect(err).Should(BeNil())
+		Expect(ds.Get(context.TODO(), &model.Target{Name: "bootstrap-dev"})).Should(BeNil())
+		Expect(ds.Get(context.TODO(), &model.Env{Name: "bootstrap-dev"})).Should(BeNil())
+		Expect(ds.Get(context.TODO(), &model.Role{Name: "viewer", Project: "bootstrap"})).Should(BeNil())
+		member := &model.ProjectUser{ProjectName: "bootstrap", Username: "template-owner"}
+		Expect(ds.Get(context.TODO(), member)).Should(BeNil())
+		Expect(member.UserRoles).Should(Equal([]string{"viewer"}))
+
+		// the conflicts are reported before creating the project
+		_, err = templateService.RenderTemplate(context.TODO(), "team", &model.Project{Name: "bootstrap"}, map[string]string{"member": "x"})
+		Expect(err.(*bcode.Bcode).BusinessCode).Should(Equal(bcode.ErrProjectTemplateConflict.BusinessCode))
+	})
+
+	It("Test rolling back the project if the template fails", func() {
+		_, err := projectService.CreateProject(context.TODO(), apisv1.CreateProjectRequest{
+			Name: "rollback", Owner: "template-owner", Template: "team", Variables: map[string]string{"member": "not-exist"},
+		})
+		Expect(err).ShouldNot(BeNil())
+		exist, err := ds.IsExist(context.TODO(), &model.Project{Name: "rollback"})
+		Expect(err).Should(BeNil())
+		Expect(exist).Should(BeFalse())
+		exist, err = ds.IsExist(context.TODO(), &model.Target{Name: "rollback-dev"})
+		Expect(err).Should(BeNil())
+		Expect(exist).Should(BeFalse())
+		exist, err = ds.IsExist(context.TODO(), &model.Env{Name: "rollback-dev"})
+		Expect(err).Should(BeNil())
+		Expect(exist).Should(BeFalse())
+		exist, err = ds.IsExist(context.TODO(), &model.Role{Name: "viewer", Project: "rollback"})
+		Expect(err).Should(BeNil())
+		Expect(exist).Should(BeFalse())
+	})
+})
@@ -131,7 +131,7 @@ var defaultPlatformPermission = []*model.PermissionTemplate{
 	{
 		Name:      "cluster-management",
 		Alias:     "Cluster Management",
-		Resources: []string{"cluster:*/*"},
+		Resources: []string{"cluster:*/*", "clusterGroup:*"},
 		Actions:   []string{"*"},
 		Effect:    "Allow",
 		Scope:     "platform",
@@ -280,6 +280,9 @@ var ResourceMaps = map[string]resourceMetadata{
 			"namespace": {},
 		},
 	},
+	"clusterGroup": {
+		pathName: "clusterGroupName",
+	},
 	"addon": {
 		pathName: "addonName",
 	},
@@ -297,6 +300,9 @@ var ResourceMaps = map[string]resourceMetadata{
 		pathName: "permissionName",
 	},
 	"systemSetting": {},
+	"projectTemplate": {
+		pathName: "templateName",
+	},
 	"definition": {
 		pathName: "definitionName",
 	},
@@ -308,11 +314,12 @@ var ResourceMaps = map[string]resourceMetadata{
 			},
 		},
 	},
-	"cloudshell":     {},
-	"config":         {},
-	"configTemplate": {},
-	"plugin":         {},
-	"managePlugin":   {},
+	"cloudshell":       {},
+	"manageCloudshell": {},
+	"config":           {},
+	"configTemplate":   {},
+	"plugin":           {},
+	"managePlugin":     {},
 }
 
 var existResourcePaths = convertSources(ResourceMaps)
@@ -431,6 +438,23 @@ func RegisterPluginResource(resource string, resourceNameMap map[string]string)
 	existResourcePaths = convertSources(ResourceMaps)
 }
 
+// RegisterPluginRoutesResource register the resources declared by the plugin, includes the routes and the storage.
+func RegisterPluginRoutesResource(plugin *plugintypes.Plugin) {
+	for _, route := range plugin.Routes {
+		if route.Permission == nil {
+			continue
+		}
+		resourceMap := map[string]string{defaultPluginResource: router.DefaultPluginResourceKey}
+		for k, v := range route.ResourceMap {
+			resourceMap[k] = v
+		}
+		RegisterPluginResource(path.Join(defaultPluginResource, route.Permission.Resource), resourceMap)
+	}
+	if plugin.Storage != nil {
+		RegisterPluginResource(path.Join(defaultPluginResource, plugin.Storage.GetResource()), map[string]string{defaultPluginResource: router.DefaultPluginResourceKey})
+	}
+}
+
 // registerResourceAction register resource actions
 func registerResourceAction(resource string, actions ...string) {
 	lock.Lock()
@@ -463,6 +487,7 @@ type rbacServiceImpl struct {
 type RBACService interface {
 	CheckPerm(resource string, actions ...string) func(req *restful.Request, res *restful.Response, chain *restful.FilterChain)
 	CheckPluginRequestPerm(httpParams httprouter.Params, r2 *plugintypes.Route) func(req *http.Request, res http.ResponseWriter) bool
+	CheckClusterRequestPerm(clusterName string) func(req *http.Request, res http.ResponseWriter) bool
 	GetUserPermissions(ctx context.Context, user *model.User, projectName string, withPlatform bool) ([]*model.Permission, error)
 	CreateRole(ctx context.Context, projectName string, req apisv1.CreateRoleRequest) (*apisv1.RoleBase, error)
 	DeleteRole(ctx context.Context, projectName, roleName string) error
@@ -709,6 +734,10 @@ func (p *rbacServiceImpl) CheckPerm(resource string, actions ...string) func(req
 			bcode.ReturnError(req, res, bcode.ErrForbidden)
 			return
 		}
+		if err := p.checkProjectWritable(req, resource, projectName, actions); err != nil {
+			bcode.ReturnError(req, res, err)
+			return
+		}
 
 		apiserverutils.SetUsernameAndProjectInRequestContext(req.Request, userName, projectName, user.UserRoles)
 		chain.ProcessFilter(req, res)
@@ -716,6 +745,21 @@ func (p *rbacServiceImpl) CheckPerm(resource string, actions ...string) func(req
 	return f
 }
 
+// checkProjectWritable reject the writing requests to the archived projects and the projects being deleted,
+// only restoring and deleting the project itself are allowed.
+func (p *rbacServiceImpl) checkProjectWritable(req *restful.Request, resource, projectName string, actions []string) error {
+	if projectName == "" || req.Request.Method == http.MethodGet {
+		return nil
+	}
+	if resource == "project" && (utils.StringsContain(actions, "restore") || utils.StringsContain(actions, "delete")) {
+		return nil
+	}
+	if err := ensureProjectWritable(req.Request.Context(), p.Store, projectName); errors.Is(err, bcode.ErrProjectDeleting) || errors.Is(err, bcode.ErrProjectArchived) {
+		return err
+	}
+	return nil
+}
+
 // CheckPluginRequestPerm handle RBAC checking for the http request to plugin backend
 // pathFormat: eg. nodes/{node}/status
 func (p *rbacServiceImpl) CheckPluginRequestPerm(httpParams httprouter.Params, r2 *plugintypes.Route) func(req *http.Request, res http.ResponseWriter) bool {
@@ -807,6 +851,53 @@ func (p *rbacServiceImpl) CheckPluginRequestPerm(httpParams httprouter.Params, r
 	return f
 }
 
+// CheckClusterRequestPerm handle RBAC checking for the plugin request to the cluster, the resource is cluster:{clusterName}.
+// The read-only request requires the detail action, the others require the update action.
+func (p *rbacServiceImpl) CheckClusterRequestPerm(clusterName string) func(req *http.Request, res http.ResponseWriter) bool {
+	return func(req *http.Request, res http.ResponseWriter) bool {
+		userName, ok := req.Context().Value(&apisv1.CtxKeyUser).(string)
+		if !ok {
+			bcode.ReturnHTTPError(req, res, bcode.ErrUnauthorized)
+			return false
+		}
+		user := &model.User{Name: userName}
+		if err := p.Store.Get(req.Context(), user); err != nil {
+			bcode.ReturnHTTPError(req, res, bcode.ErrUnauthorized)
+			return false
+		}
+		path, err := checkResourcePath("cluster")
+		if err != nil {
+			klog.Errorf("check resource path failure %s", err.Error())
+			bcode.ReturnHTTPError(req, res, bcode.ErrForbidden)
+			return false
+		}
+		action := "update"
+		if req.Method == http.MethodGet || req.Method == http.MethodHead || req.Method == http.MethodOptions {
+			action = "detail"
+		}
+		ra := &RequestResourceAction{}
+		ra.SetResourceWithName(path, func(name string) string {
+			if name == ResourceMaps["cluster"].pathName {
+				return clusterName
+			}
+			return ""
+		})
+		ra.SetActions([]string{action})
+
+		permissions, err := p.GetUserPermissions(req.Context(), user, "", true)
+		if err != nil {
+			klog.Errorf("get user's perm policies failure %s, user is %s", err.Error(), user.Name)
+			bcode.ReturnHTTPError(req, res, bcode.ErrForbidden)
+			return false
+		}
+		if !ra.Match(permissions) {
+			bcode.ReturnHTTPError(req, res, bcode.ErrForbidden)
+			return false
+		}
+		return true
+	}
+}
+
 func (p *rbacServiceImpl) CreateRole(ctx context.Context, projectName string, req apisv1.CreateRoleRequest) (*apisv1.RoleBase, error) {
 	if projectName != "" {
 		var project = model.Project{
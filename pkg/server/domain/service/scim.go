@@ -0,0 +1,1084 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/rand"
+	"k8s.io/klog/v2"
+
+	pkgUtils "github.com/oam-dev/kubevela/pkg/utils"
+
+	"github.com/kubevela/velaux/pkg/server/domain/model"
+	"github.com/kubevela/velaux/pkg/server/infrastructure/datastore"
+	apisv1 "github.com/kubevela/velaux/pkg/server/interfaces/api/dto/v1"
+	"github.com/kubevela/velaux/pkg/server/utils/bcode"
+)
+
+// SCIMBasePath the path prefix of the SCIM endpoint
+const SCIMBasePath = "/scim/v2"
+
+var invalidUserNameChars = regexp.MustCompile(`[^a-z0-9.-]+`)
+
+// SCIMService provisions the users and the groups from the identity providers by the SCIM 2.0 protocol (RFC 7643, RFC 7644).
+// The members of the groups are mapped onto the project users by the group rules, the memberships of the projects
+// referenced by the rules are managed by the SCIM groups.
+type SCIMService interface {
+	GetConfig(ctx context.Context) (*apisv1.SCIMConfig, error)
+	UpdateConfig(ctx context.Context, req apisv1.UpdateSCIMConfigRequest) (*apisv1.SCIMConfig, error)
+	// GenerateToken generate a new bearer token, the previous one is invalid after it
+	GenerateToken(ctx context.Context) (*apisv1.SCIMTokenResponse, error)
+	CheckToken(ctx context.Context, token string) error
+
+	ListUsers(ctx context.Context, filter string, startIndex, count int) (*apisv1.SCIMListResponse, error)
+	GetUser(ctx context.Context, id string) (*apisv1.SCIMUser, error)
+	CreateUser(ctx context.Context, req apisv1.SCIMUser) (*apisv1.SCIMUser, error)
+	ReplaceUser(ctx context.Context, id string, req apisv1.SCIMUser) (*apisv1.SCIMUser, error)
+	PatchUser(ctx context.Context, id string, req apisv1.SCIMPatchRequest) (*apisv1.SCIMUser, error)
+	DeleteUser(ctx context.Context, id string) error
+
+	ListGroups(ctx context.Context, filter string, startIndex, count int) (*apisv1.SCIMListResponse, error)
+	GetGroup(ctx context.Context, id string) (*apisv1.SCIMGroup, error)
+	CreateGroup(ctx context.Context, req apisv1.SCIMGroup) (*apisv1.SCIMGroup, error)
+	ReplaceGroup(ctx context.Context, id string, req apisv1.SCIMGroup) (*apisv1.SCIMGroup, error)
+	PatchGroup(ctx context.Context, id string, req apisv1.SCIMPatchRequest) (*apisv1.SCIMGroup, error)
+	DeleteGroup(ctx context.Context, id string) error
+}
+
+type scimServiceImpl struct {
+	Store          datastore.DataStore `inject:"datastore"`
+	UserService    UserService         `inject:""`
+	ProjectService ProjectService      `inject:""`
+}
+
+// NewSCIMService new SCIM service
+func NewSCIMService() SCIMService {
+	return &scimServiceImpl{}
+}
+
+func (s *scimServiceImpl) getConfig(ctx context.Context) (*model.SCIMConfig, error) {
+	config := &model.SCIMConfig{Name: model.DefaultSCIMConfigName}
+	if err := s.Store.Get(ctx, config); err != nil {
+		if errors.Is(err, datastore.ErrRecordNotExist) {
+			return config, nil
+		}
+		return nil, err
+	}
+	return config, nil
+}
+
+func (s *scimServiceImpl) saveConfig(ctx context.Context, config *model.SCIMConfig) error {
+	exist, err := s.Store.IsExist(ctx, &model.SCIMConfig{Name: config.Name})
+	if err != nil {
+		return err
+	}
+	if exist {
+		return s.Store.Put(ctx, config)
+	}
+	return s.Store.Add(ctx, config)
+}
+
+func (s *scimServiceImpl) GetConfig(ctx context.Context) (*apisv1.SCIMConfig, error) {
+	config, err := s.getConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return convertSCIMConfig(config), nil
+}
+
+func (s *scimServiceImpl) UpdateConfig(ctx context.Context, req apisv1.UpdateSCIMConfigRequest) (*apisv1.SCIMConfig, error) {
+	config, err := s.getConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var rules []model.SCIMGroupRule
+	for _, rule := range req.GroupRules {
+		if _, err := path.Match(rule.Group, ""); err != nil {
+			return nil, bcode.ErrSCIMInvalidGroupRule.SetMessage(fmt.Sprintf("the group pattern %q is invalid", rule.Group))
+		}
+		if _, err := s.ProjectService.GetProject(ctx, rule.Project); err != nil {
+			return nil, bcode.ErrSCIMInvalidGroupRule.SetMessage(fmt.Sprintf("the project %q is not found", rule.Project))
+		}
+		for _, role := range rule.Roles {
+			if err := s.Store.Get(ctx, &model.Role{Name: role, Project: rule.Project}); err != nil {
+				return nil, bcode.ErrSCIMInvalidGroupRule.SetMessage(fmt.Sprintf("the role %q is not found in the project %q", role, rule.Project))
+			}
+		}
+		rules = append(rules, model.SCIMGroupRule{Group: rule.Group, Project: rule.Project, Roles: rule.Roles})
+	}
+	config.Enabled = req.Enabled
+	config.GroupRules = rules
+	if err := s.saveConfig(ctx, config); err != nil {
+		return nil, err
+	}
+	groups, err := s.listGroups(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var members []string
+	for _, group := range groups {
+		members = append(members, group.Members...)
+	}
+	s.reconcileProjectUsers(ctx, members)
+	return convertSCIMConfig(config), nil
+}
+
+func (s *scimServiceImpl) GenerateToken(ctx context.Context) (*apisv1.SCIMTokenResponse, error) {
+	config, err := s.getConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+	token := rand.String(48)
+	config.TokenHash = hashSCIMToken(token)
+	if err := s.saveConfig(ctx, config); err != nil {
+		return nil, err
+	}
+	return &apisv1.SCIMTokenResponse{Token: token}, nil
+}
+
+func (s *scimServiceImpl) CheckToken(ctx context.Context, token string) error {
+	config, err := s.getConfig(ctx)
+	if err != nil {
+		return err
+	}
+	if !config.Enabled {
+		return bcode.ErrSCIMDisabled
+	}
+	if config.TokenHash == "" || token == "" ||
+		subtle.ConstantTimeCompare([]byte(config.TokenHash), []byte(hashSCIMToken(token))) != 1 {
+		return bcode.ErrSCIMUnauthorized
+	}
+	return nil
+}
+
+func hashSCIMToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// getUser get the user provisioned by SCIM, the local users and the administrators are invisible to the SCIM client
+func (s *scimServiceImpl) getUser(ctx context.Context, id string) (*model.User, error) {
+	user := &model.User{Name: id}
+	if err := s.Store.Get(ctx, user); err != nil {
+		if errors.Is(err, datastore.ErrRecordNotExist) {
+			return nil, bcode.ErrSCIMUserNotFound
+		}
+		return nil, err
+	}
+	if !isSCIMManagedUser(user) {
+		return nil, bcode.ErrSCIMUserNotFound
+	}
+	return user, nil
+}
+
+func isSCIMManagedUser(user *model.User) bool {
+	return user.IsSCIMProvisioned() && !user.IsAdmin()
+}
+
+func (s *scimServiceImpl) ListUsers(ctx context.Context, filter string, startIndex, count int) (*apisv1.SCIMListResponse, error) {
+	conditions, err := parseSCIMFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+	entities, err := s.Store.List(ctx, &model.User{}, &datastore.ListOptions{
+		SortBy: []datastore.SortOption{{Key: "createTime", Order: datastore.SortOrderAscending}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	groups, err := s.listGroups(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var resources []interface{}
+	for _, entity := range entities {
+		if !isSCIMManagedUser(entity.(*model.User)) {
+			continue
+		}
+		user := convertSCIMUser(entity.(*model.User), groups)
+		if conditions.match(scimUserAttribute(user)) {
+			resources = append(resources, user)
+		}
+	}
+	return newSCIMListResponse(resources, startIndex, count), nil
+}
+
+func (s *scimServiceImpl) GetUser(ctx context.Context, id string) (*apisv1.SCIMUser, error) {
+	user, err := s.getUser(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	groups, err := s.listGroups(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return convertSCIMUser(user, groups), nil
+}
+
+func (s *scimServiceImpl) CreateUser(ctx context.Context, req apisv1.SCIMUser) (*apisv1.SCIMUser, error) {
+	name := scimUserNameToName(req.UserName)
+	if name == "" {
+		return nil, bcode.ErrSCIMInvalidResource.SetMessage("the userName is required")
+	}
+	user := &model.User{Name: name}
+	exist, err := s.Store.IsExist(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+	if exist {
+		return nil, bcode.ErrSCIMUserExist
+	}
+	if err := s.applySCIMUser(user, req); err != nil {
+		return nil, err
+	}
+	if req.Active != nil {
+		user.Disabled = !*req.Active
+	}
+	if err := s.Store.Add(ctx, user); err != nil {
+		if errors.Is(err, datastore.ErrRecordExist) {
+			return nil, bcode.ErrSCIMUserExist
+		}
+		return nil, err
+	}
+	klog.Infof("the user %s is provisioned by SCIM", pkgUtils.Sanitize(user.Name))
+	return convertSCIMUser(user, nil), nil
+}
+
+func (s *scimServiceImpl) ReplaceUser(ctx context.Context, id string, req apisv1.SCIMUser) (*apisv1.SCIMUser, error) {
+	user, err := s.getUser(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return s.updateUser(ctx, user, req)
+}
+
+func (s *scimServiceImpl) PatchUser(ctx context.Context, id string, req apisv1.SCIMPatchRequest) (*apisv1.SCIMUser, error) {
+	user, err := s.getUser(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	target := *convertSCIMUser(user, nil)
+	for _, op := range req.Operations {
+		if err := patchSCIMUser(&target, op); err != nil {
+			return nil, err
+		}
+	}
+	return s.updateUser(ctx, user, target)
+}
+
+// updateUser replace the attributes of the user, the user is disabled or enabled by the active attribute
+func (s *scimServiceImpl) updateUser(ctx context.Context, user *model.User, req apisv1.SCIMUser) (*apisv1.SCIMUser, error) {
+	if req.UserName == "" {
+		return nil, bcode.ErrSCIMInvalidResource.SetMessage("the userName is required")
+	}
+	if err := s.applySCIMUser(user, req); err != nil {
+		return nil, err
+	}
+	if err := s.Store.Put(ctx, user); err != nil {
+		return nil, err
+	}
+	if req.Active != nil && *req.Active == user.Disabled {
+		if *req.Active {
+			if err := s.UserService.EnableUser(ctx, user); err != nil {
+				return nil, err
+			}
+		} else {
+			if err := s.UserService.DisableUser(ctx, user); err != nil {
+				return nil, err
+			}
+			klog.Infof("the user %s is deactivated by SCIM", pkgUtils.Sanitize(user.Name))
+		}
+	}
+	return s.GetUser(ctx, user.Name)
+}
+
+func (s *scimServiceImpl) DeleteUser(ctx context.Context, id string) error {
+	if _, err := s.getUser(ctx, id); err != nil {
+		return err
+	}
+	groups, err := s.listGroups(ctx)
+	if err != nil {
+		return err
+	}
+	for _, group := range groups {
+		if group.HasMember(id) {
+			group.Members = removeSCIMMembers(group.Members, []string{id})
+			if err := s.Store.Put(ctx, group); err != nil {
+				return err
+			}
+		}
+	}
+	if err := s.UserService.DeleteUser(ctx, id); err != nil {
+		return err
+	}
+	klog.Infof("the user %s is deleted by SCIM", pkgUtils.Sanitize(id))
+	return nil
+}
+
+func (s *scimServiceImpl) listGroups(ctx context.Context) ([]*model.SCIMGroup, error) {
+	entities, err := s.Store.List(ctx, &model.SCIMGroup{}, &datastore.ListOptions{
+		SortBy: []datastore.SortOption{{Key: "createTime", Order: datastore.SortOrderAscending}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	var groups []*model.SCIMGroup
+	for _, entity := range entities {
+		groups = append(groups, entity.(*model.SCIMGroup))
+	}
+	return groups, nil
+}
+
+func (s *scimServiceImpl) getGroup(ctx context.Context, id string) (*model.SCIMGroup, error) {
+	group := &model.SCIMGroup{ID: id}
+	if err := s.Store.Get(ctx, group); err != nil {
+		if errors.Is(err, datastore.ErrRecordNotExist) {
+			return nil, bcode.ErrSCIMGroupNotFound
+		}
+		return nil, err
+	}
+	return group, nil
+}
+
+func (s *scimServiceImpl) ListGroups(ctx context.Context, filter string, startIndex, count int) (*apisv1.SCIMListResponse, error) {
+	conditions, err := parseSCIMFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+	groups, err := s.listGroups(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var resources []interface{}
+	for _, group := range groups {
+		res := convertSCIMGroup(group)
+		if conditions.match(scimGroupAttribute(res)) {
+			resources = append(resources, res)
+		}
+	}
+	return newSCIMListResponse(resources, startIndex, count), nil
+}
+
+func (s *scimServiceImpl) GetGroup(ctx context.Context, id string) (*apisv1.SCIMGroup, error) {
+	group, err := s.getGroup(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return convertSCIMGroup(group), nil
+}
+
+func (s *scimServiceImpl) CreateGroup(ctx context.Context, req apisv1.SCIMGroup) (*apisv1.SCIMGroup, error) {
+	if req.DisplayName == "" {
+		return nil, bcode.ErrSCIMInvalidResource.SetMessage("the displayName is required")
+	}
+	if err := s.checkGroupName(ctx, "", req.DisplayName); err != nil {
+		return nil, err
+	}
+	members, err := s.checkMembers(ctx, req.Members)
+	if err != nil {
+		return nil, err
+	}
+	group := &model.SCIMGroup{
+		ID:          rand.String(16),
+		DisplayName: req.DisplayName,
+		ExternalID:  req.ExternalID,
+		Members:     members,
+	}
+	if err := s.Store.Add(ctx, group); err != nil {
+		if errors.Is(err, datastore.ErrRecordExist) {
+			return nil, bcode.ErrSCIMGroupExist
+		}
+		return nil, err
+	}
+	s.reconcileProjectUsers(ctx, group.Members)
+	return convertSCIMGroup(group), nil
+}
+
+func (s *scimServiceImpl) ReplaceGroup(ctx context.Context, id string, req apisv1.SCIMGroup) (*apisv1.SCIMGroup, error) {
+	group, err := s.getGroup(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return s.updateGroup(ctx, group, req)
+}
+
+func (s *scimServiceImpl) PatchGroup(ctx context.Context, id string, req apisv1.SCIMPatchRequest) (*apisv1.SCIMGroup, error) {
+	group, err := s.getGroup(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	target := *convertSCIMGroup(group)
+	for _, op := range req.Operations {
+		if err := patchSCIMGroup(&target, op); err != nil {
+			return nil, err
+		}
+	}
+	return s.updateGroup(ctx, group, target)
+}
+
+// updateGroup replace the attributes and the members of the group, the project users of the previous and the current members are reconciled.
+func (s *scimServiceImpl) updateGroup(ctx context.Context, group *model.SCIMGroup, req apisv1.SCIMGroup) (*apisv1.SCIMGroup, error) {
+	if req.DisplayName == "" {
+		return nil, bcode.ErrSCIMInvalidResource.SetMessage("the displayName is required")
+	}
+	if err := s.checkGroupName(ctx, group.ID, req.DisplayName); err != nil {
+		return nil, err
+	}
+	members, err := s.checkMembers(ctx, req.Members)
+	if err != nil {
+		return nil, err
+	}
+	affected := append(append([]string{}, group.Members...), members...)
+	group.DisplayName = req.DisplayName
+	group.ExternalID = req.ExternalID
+	group.Members = members
+	if err := s.Store.Put(ctx, group); err != nil {
+		return nil, err
+	}
+	s.reconcileProjectUsers(ctx, affected)
+	return convertSCIMGroup(group), nil
+}
+
+func (s *scimServiceImpl) DeleteGroup(ctx context.Context, id string) error {
+	group, err := s.getGroup(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := s.Store.Delete(ctx, group); err != nil {
+		return err
+	}
+	s.reconcileProjectUsers(ctx, group.Members)
+	return nil
+}
+
+// checkGroupName the display name of the group must be unique, the group rules match it
+func (s *scimServiceImpl) checkGroupName(ctx context.Context, id, displayName string) error {
+	groups, err := s.listGroups(ctx)
+	if err != nil {
+		return err
+	}
+	for _, group := range groups {
+		if group.ID != id && group.DisplayName == displayName {
+			return bcode.ErrSCIMGroupExist
+		}
+	}
+	return nil
+}
+
+// checkMembers check the members exist and return the deduplicated user names
+func (s *scimServiceImpl) checkMembers(ctx context.Context, refs []apisv1.SCIMMemberRef) ([]string, error) {
+	var members []string
+	for _, ref := range refs {
+		if containsString(members, ref.Value) {
+			continue
+		}
+		if _, err := s.getUser(ctx, ref.Value); err != nil {
+			if errors.Is(err, bcode.ErrSCIMUserNotFound) {
+				return nil, bcode.ErrSCIMInvalidResource.SetMessage(fmt.Sprintf("the member %q is not found", ref.Value))
+			}
+			return nil, err
+		}
+		members = append(members, ref.Value)
+	}
+	return members, nil
+}
+
+// reconcileProjectUsers add, update or remove the users of the projects referenced by the group rules,
+// the roles of a user in a project are the union of the roles of all rules matching the groups of the user.
+// The failures are logged, they are retried by the next change of the groups.
+func (s *scimServiceImpl) reconcileProjectUsers(ctx context.Context, usernames []string) {
+	config, err := s.getConfig(ctx)
+	if err != nil {
+		klog.Errorf("failed to get the SCIM config: %s", err.Error())
+		return
+	}
+	if len(config.GroupRules) == 0 || len(usernames) == 0 {
+		return
+	}
+	groups, err := s.listGroups(ctx)
+	if err != nil {
+		klog.Errorf("failed to list the SCIM groups: %s", err.Error())
+		return
+	}
+	var reconciled []string
+	for _, username := range usernames {
+		if containsString(reconciled, username) {
+			continue
+		}
+		reconciled = append(reconciled, username)
+		desired := map[string][]string{}
+		for _, rule := range config.GroupRules {
+			if _, ok := desired[rule.Project]; !ok {
+				desired[rule.Project] = nil
+			}
+			for _, group := range groups {
+				if group.HasMember(username) && rule.Match(group.DisplayName) {
+					for _, role := range rule.Roles {
+						if !containsString(desired[rule.Project], role) {
+							desired[rule.Project] = append(desired[rule.Project], role)
+						}
+					}
+				}
+			}
+		}
+		for project, roles := range desired {
+			if err := s.reconcileProjectUser(ctx, project, username, roles); err != nil {
+				klog.Errorf("failed to reconcile the user %s of the project %s: %s", pkgUtils.Sanitize(username), project, err.Error())
+			}
+		}
+	}
+}
+
+func (s *scimServiceImpl) reconcileProjectUser(ctx context.Context, project, username string, roles []string) error {
+	projectUser := &model.ProjectUser{ProjectName: project, Username: username}
+	err := s.Store.Get(ctx, projectUser)
+	if err != nil && !errors.Is(err, datastore.ErrRecordNotExist) {
+		return err
+	}
+	exist := err == nil
+	switch {
+	case len(roles) == 0 && exist:
+		return s.ProjectService.DeleteProjectUser(ctx, project, username)
+	case len(roles) == 0:
+		return nil
+	case !exist:
+		_, err := s.ProjectService.AddProjectUser(ctx, project, apisv1.AddProjectUserRequest{UserName: username, UserRoles: roles})
+		return err
+	}
+	current := append([]string{}, projectUser.UserRoles...)
+	sort.Strings(current)
+	sort.Strings(roles)
+	if strings.Join(current, ",") == strings.Join(roles, ",") {
+		return nil
+	}
+	_, err = s.ProjectService.UpdateProjectUser(ctx, project, username, apisv1.UpdateProjectUserRequest{UserRoles: roles})
+	return err
+}
+
+func containsString(items []string, item string) bool {
+	for _, i := range items {
+		if i == item {
+			return true
+		}
+	}
+	return false
+}
+
+func removeSCIMMembers(members []string, removed []string) []string {
+	var res []string
+	for _, m := range members {
+		if !containsString(removed, m) {
+			res = append(res, m)
+		}
+	}
+	return res
+}
+
+// scimUserNameToName convert the SCIM user name to a valid user name, such as "Alice@Example.com" to "alice-example.com"
+func scimUserNameToName(userName string) string {
+	name := invalidUserNameChars.ReplaceAllString(strings.ToLower(userName), "-")
+	return strings.Trim(name, "-.")
+}
+
+// applySCIMUser set the attributes of the SCIM user to the user, the active attribute is handled by the caller
+func (s *scimServiceImpl) applySCIMUser(user *model.User, req apisv1.SCIMUser) error {
+	user.SCIMUserName = req.UserName
+	user.ExternalID = req.ExternalID
+	user.Alias = req.DisplayName
+	if user.Alias == "" && req.Name != nil {
+		user.Alias = req.Name.Formatted
+		if user.Alias == "" {
+			user.Alias = strings.TrimSpace(req.Name.GivenName + " " + req.Name.FamilyName)
+		}
+	}
+	user.Email = ""
+	for i, email := range req.Emails {
+		if i == 0 || email.Primary {
+			user.Email = email.Value
+		}
+		if email.Primary {
+			break
+		}
+	}
+	// The password is only used by the local login, it follows the same policy as the local users.
+	if req.Password != "" {
+		if err := s.UserService.SetPassword(user, req.Password); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func convertSCIMConfig(config *model.SCIMConfig) *apisv1.SCIMConfig {
+	res := &apisv1.SCIMConfig{
+		Enabled:        config.Enabled,
+		TokenGenerated: config.TokenHash != "",
+		GroupRules:     []apisv1.SCIMGroupRule{},
+		UpdateTime:     config.UpdateTime,
+	}
+	for _, rule := range config.GroupRules {
+		res.GroupRules = append(res.GroupRules, apisv1.SCIMGroupRule{Group: rule.Group, Project: rule.Project, Roles: rule.Roles})
+	}
+	return res
+}
+
+func convertSCIMUser(user *model.User, groups []*model.SCIMGroup) *apisv1.SCIMUser {
+	active := !user.Disabled
+	userName := user.SCIMUserName
+	if userName == "" {
+		userName = user.Name
+	}
+	res := &apisv1.SCIMUser{
+		Schemas:     []string{apisv1.SCIMSchemaUser},
+		ID:          user.Name,
+		ExternalID:  user.ExternalID,
+		UserName:    userName,
+		DisplayName: user.Alias,
+		Active:      &active,
+		Meta: &apisv1.SCIMMeta{
+			ResourceType: "User",
+			Created:      user.CreateTime,
+			LastModified: user.UpdateTime,
+			Location:     SCIMBasePath + "/Users/" + user.Name,
+		},
+	}
+	if user.Email != "" {
+		res.Emails = []apisv1.SCIMEmail{{Value: user.Email, Primary: true}}
+	}
+	for _, group := range groups {
+		if group.HasMember(user.Name) {
+			res.Groups = append(res.Groups, apisv1.SCIMMemberRef{
+				Value:   group.ID,
+				Display: group.DisplayName,
+				Ref:     SCIMBasePath + "/Groups/" + group.ID,
+			})
+		}
+	}
+	return res
+}
+
+func convertSCIMGroup(group *model.SCIMGroup) *apisv1.SCIMGroup {
+	res := &apisv1.SCIMGroup{
+		Schemas:     []string{apisv1.SCIMSchemaGroup},
+		ID:          group.ID,
+		ExternalID:  group.ExternalID,
+		DisplayName: group.DisplayName,
+		Meta: &apisv1.SCIMMeta{
+			ResourceType: "Group",
+			Created:      group.CreateTime,
+			LastModified: group.UpdateTime,
+			Location:     SCIMBasePath + "/Groups/" + group.ID,
+		},
+	}
+	for _, member := range group.Members {
+		res.Members = append(res.Members, apisv1.SCIMMemberRef{
+			Value: member,
+			Ref:   SCIMBasePath + "/Users/" + member,
+		})
+	}
+	return res
+}
+
+// newSCIMListResponse paginate the resources, the start index is 1-based and the negative count means no limit
+func newSCIMListResponse(resources []interface{}, startIndex, count int) *apisv1.SCIMListResponse {
+	if startIndex < 1 {
+		startIndex = 1
+	}
+	res := &apisv1.SCIMListResponse{
+		Schemas:      []string{apisv1.SCIMSchemaListResponse},
+		TotalResults: len(resources),
+		StartIndex:   startIndex,
+		Resources:    []interface{}{},
+	}
+	if startIndex > len(resources) {
+		return res
+	}
+	page := resources[startIndex-1:]
+	if count >= 0 && count < len(page) {
+		page = page[:count]
+	}
+	res.Resources = append(res.Resources, page...)
+	res.ItemsPerPage = len(res.Resources)
+	return res
+}
+
+func scimUserAttribute(user *apisv1.SCIMUser) func(attr string) []string {
+	return func(attr string) []string {
+		switch attr {
+		case "id":
+			return []string{user.ID}
+		case "username":
+			return []string{user.UserName}
+		case "externalid":
+			return []string{user.ExternalID}
+		case "displayname":
+			return []string{user.DisplayName}
+		case "active":
+			return []string{strconv.FormatBool(user.Active != nil && *user.Active)}
+		case "emails", "emails.value":
+			var values []string
+			for _, email := range user.Emails {
+				values = append(values, email.Value)
+			}
+			return values
+		}
+		return nil
+	}
+}
+
+func scimGroupAttribute(group *apisv1.SCIMGroup) func(attr string) []string {
+	return func(attr string) []string {
+		switch attr {
+		case "id":
+			return []string{group.ID}
+		case "displayname":
+			return []string{group.DisplayName}
+		case "externalid":
+			return []string{group.ExternalID}
+		case "members", "members.value":
+			var values []string
+			for _, member := range group.Members {
+				values = append(values, member.Value)
+			}
+			return values
+		}
+		return nil
+	}
+}
+
+// scimCondition one comparison of the filter, such as `userName eq "alice"`
+type scimCondition struct {
+	attr  string
+	op    string
+	value string
+}
+
+// scimFilter the conditions joined by "and", the "or", "not" and the grouping are not supported
+type scimFilter []scimCondition
+
+// parseSCIMFilter parse the filter (RFC 7644 section 3.4.2.2), the operators eq, ne, co, sw, ew and pr are supported
+func parseSCIMFilter(filter string) (scimFilter, error) {
+	tokens, err := tokenizeSCIMFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+	var conditions scimFilter
+	for i := 0; i < len(tokens); {
+		if len(conditions) > 0 {
+			if !strings.EqualFold(tokens[i], "and") {
+				return nil, bcode.ErrSCIMInvalidFilter
+			}
+			i++
+		}
+		if i+1 >= len(tokens) {
+			return nil, bcode.ErrSCIMInvalidFilter
+		}
+		condition := scimCondition{attr: strings.ToLower(tokens[i]), op: strings.ToLower(tokens[i+1])}
+		i += 2
+		switch condition.op {
+		case "pr":
+		case "eq", "ne", "co", "sw", "ew":
+			if i >= len(tokens) {
+				return nil, bcode.ErrSCIMInvalidFilter
+			}
+			condition.value = tokens[i]
+			i++
+		default:
+			return nil, bcode.ErrSCIMInvalidFilter
+		}
+		conditions = append(conditions, condition)
+	}
+	return conditions, nil
+}
+
+// tokenizeSCIMFilter split the filter by the spaces, the quoted string is one token without the quotes
+func tokenizeSCIMFilter(filter string) ([]string, error) {
+	var tokens []string
+	var current strings.Builder
+	inToken, quoted, escaped := false, false, false
+	for _, c := range filter {
+		switch {
+		case escaped:
+			current.WriteRune(c)
+			escaped = false
+		case quoted && c == '\\':
+			escaped = true
+		case c == '"':
+			quoted = !quoted
+			inToken = true
+		case !quoted && (c == '(' || c == ')' || c == '[' || c == ']'):
+			return nil, bcode.ErrSCIMInvalidFilter
+		case !quoted && c == ' ':
+			if inToken {
+				tokens = append(tokens, current.String())
+				current.Reset()
+				inToken = false
+			}
+		default:
+			current.WriteRune(c)
+			inToken = true
+		}
+	}
+	if quoted || escaped {
+		return nil, bcode.ErrSCIMInvalidFilter
+	}
+	if inToken {
+		tokens = append(tokens, current.String())
+	}
+	return tokens, nil
+}
+
+// match return whether the resource matches all conditions, the string comparison is case-insensitive
+func (f scimFilter) match(attribute func(attr string) []string) bool {
+	for _, condition := range f {
+		values := attribute(condition.attr)
+		if !condition.match(values) {
+			return false
+		}
+	}
+	return true
+}
+
+func (c scimCondition) match(values []string) bool {
+	expected := strings.ToLower(c.value)
+	if c.op == "ne" {
+		for _, value := range values {
+			if strings.ToLower(value) == expected {
+				return false
+			}
+		}
+		return true
+	}
+	for _, value := range values {
+		value = strings.ToLower(value)
+		switch c.op {
+		case "pr":
+			if value != "" {
+				return true
+			}
+		case "eq":
+			if value == expected {
+				return true
+			}
+		case "co":
+			if strings.Contains(value, expected) {
+				return true
+			}
+		case "sw":
+			if strings.HasPrefix(value, expected) {
+				return true
+			}
+		case "ew":
+			if strings.HasSuffix(value, expected) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// patchSCIMUser apply the patch operation to the user, the unknown attributes such as the extension attributes are ignored
+func patchSCIMUser(user *apisv1.SCIMUser, op apisv1.SCIMPatchOperation) error {
+	switch strings.ToLower(op.Op) {
+	case "add", "replace":
+		if op.Path == "" {
+			var values map[string]json.RawMessage
+			if err := json.Unmarshal(op.Value, &values); err != nil {
+				return bcode.ErrSCIMInvalidPatch
+			}
+			for attr, value := range values {
+				if err := setSCIMUserAttribute(user, attr, value); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		return setSCIMUserAttribute(user, op.Path, op.Value)
+	case "remove":
+		switch attr := strings.ToLower(op.Path); {
+		case attr == "externalid":
+			user.ExternalID = ""
+		case attr == "displayname":
+			user.DisplayName = ""
+		case attr == "name":
+			user.Name = nil
+		case attr == "emails" || strings.HasPrefix(attr, "emails["):
+			user.Emails = nil
+		case attr == "":
+			return bcode.ErrSCIMInvalidPatch
+		}
+		return nil
+	}
+	return bcode.ErrSCIMInvalidPatch
+}
+
+func setSCIMUserAttribute(user *apisv1.SCIMUser, attr string, value json.RawMessage) error {
+	var err error
+	switch attr = strings.ToLower(attr); {
+	case attr == "username":
+		err = json.Unmarshal(value, &user.UserName)
+	case attr == "externalid":
+		err = json.Unmarshal(value, &user.ExternalID)
+	case attr == "displayname":
+		err = json.Unmarshal(value, &user.DisplayName)
+	case attr == "password":
+		err = json.Unmarshal(value, &user.Password)
+	case attr == "active":
+		var active bool
+		active, err = parseSCIMBool(value)
+		user.Active = &active
+	case attr == "name":
+		err = json.Unmarshal(value, &user.Name)
+	case strings.HasPrefix(attr, "name."):
+		if user.Name == nil {
+			user.Name = &apisv1.SCIMName{}
+		}
+		switch attr {
+		case "name.formatted":
+			err = json.Unmarshal(value, &user.Name.Formatted)
+		case "name.givenname":
+			err = json.Unmarshal(value, &user.Name.GivenName)
+		case "name.familyname":
+			err = json.Unmarshal(value, &user.Name.FamilyName)
+		}
+	case attr == "emails":
+		err = json.Unmarshal(value, &user.Emails)
+	case strings.HasPrefix(attr, "emails"):
+		// such as emails.value and emails[type eq "work"].value, only one email is stored
+		var email string
+		err = json.Unmarshal(value, &email)
+		user.Emails = []apisv1.SCIMEmail{{Value: email, Primary: true}}
+	}
+	if err != nil {
+		return bcode.ErrSCIMInvalidPatch.SetMessage(fmt.Sprintf("the value of %s is invalid", attr))
+	}
+	return nil
+}
+
+// parseSCIMBool parse the boolean value, some identity providers send the boolean as a string, such as "False"
+func parseSCIMBool(value json.RawMessage) (bool, error) {
+	var b bool
+	if err := json.Unmarshal(value, &b); err == nil {
+		return b, nil
+	}
+	var s string
+	if err := json.Unmarshal(value, &s); err != nil {
+		return false, err
+	}
+	return strconv.ParseBool(strings.ToLower(s))
+}
+
+var scimMemberPath = regexp.MustCompile(`(?i)^members\[value eq "([^"]*)"\]$`)
+
+// patchSCIMGroup apply the patch operation to the group
+func patchSCIMGroup(group *apisv1.SCIMGroup, op apisv1.SCIMPatchOperation) error {
+	opName := strings.ToLower(op.Op)
+	if opName != "add" && opName != "replace" && opName != "remove" {
+		return bcode.ErrSCIMInvalidPatch
+	}
+	if op.Path == "" {
+		if opName == "remove" {
+			return bcode.ErrSCIMInvalidPatch
+		}
+		var values map[string]json.RawMessage
+		if err := json.Unmarshal(op.Value, &values); err != nil {
+			return bcode.ErrSCIMInvalidPatch
+		}
+		for attr, value := range values {
+			if err := patchSCIMGroup(group, apisv1.SCIMPatchOperation{Op: opName, Path: attr, Value: value}); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if match := scimMemberPath.FindStringSubmatch(op.Path); match != nil {
+		if opName != "remove" {
+			return bcode.ErrSCIMInvalidPatch
+		}
+		group.Members = removeSCIMMemberRefs(group.Members, []apisv1.SCIMMemberRef{{Value: match[1]}})
+		return nil
+	}
+	var err error
+	switch strings.ToLower(op.Path) {
+	case "displayname":
+		if opName == "remove" {
+			return bcode.ErrSCIMInvalidPatch
+		}
+		err = json.Unmarshal(op.Value, &group.DisplayName)
+	case "externalid":
+		group.ExternalID = ""
+		if opName != "remove" {
+			err = json.Unmarshal(op.Value, &group.ExternalID)
+		}
+	case "members":
+		var members []apisv1.SCIMMemberRef
+		if len(op.Value) > 0 {
+			err = json.Unmarshal(op.Value, &members)
+		}
+		switch {
+		case err != nil:
+		case opName == "add":
+			group.Members = append(group.Members, members...)
+		case opName == "replace":
+			group.Members = members
+		case len(members) == 0:
+			group.Members = nil
+		default:
+			group.Members = removeSCIMMemberRefs(group.Members, members)
+		}
+	default:
+		return bcode.ErrSCIMInvalidPatch.SetMessage(fmt.Sprintf("the path %s is not supported", op.Path))
+	}
+	if err != nil {
+		return bcode.ErrSCIMInvalidPatch.SetMessage(fmt.Sprintf("the value of %s is invalid", op.Path))
+	}
+	return nil
+}
+
+func removeSCIMMemberRefs(members []apisv1.SCIMMemberRef, removed []apisv1.SCIMMemberRef) []apisv1.SCIMMemberRef {
+	var res []apisv1.SCIMMemberRef
+	for _, member := range members {
+		found := false
+		for _, r := range removed {
+			if r.Value == member.Value {
+				found = true
+				break
+			}
+		}
+		if !found {
+			res = append(res, member)
+		}
+	}
+	return res
+}
+
+// NewTestSCIMService only used by testing
+func NewTestSCIMService(ds datastore.DataStore, userService UserService, projectService ProjectService) SCIMService {
+	return &scimServiceImpl{Store: ds, UserService: userService, ProjectService: projectService}
+}
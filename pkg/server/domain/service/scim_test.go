@@ -0,0 +1,186 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/kubevela/velaux/pkg/server/config"
+	"github.com/kubevela/velaux/pkg/server/domain/model"
+	apisv1 "github.com/kubevela/velaux/pkg/server/interfaces/api/dto/v1"
+	"github.com/kubevela/velaux/pkg/server/utils/bcode"
+)
+
+var _ = Describe("Test SCIM service functions", func() {
+	var scimService SCIMService
+
+	BeforeEach(func() {
+		InitTestEnv("scim-test-" + strconv.FormatInt(time.Now().UnixNano(), 10))
+		userService.SessionService = NewTestSessionService(ds)
+		scimService = NewTestSCIMService(ds, userService, projectService)
+	})
+
+	It("Test parsing the filter", func() {
+		filter, err := parseSCIMFilter(`userName eq "Alice@example.com" and emails.value co "example"`)
+		Expect(err).Should(BeNil())
+		Expect(len(filter)).Should(Equal(2))
+		user := &apisv1.SCIMUser{UserName: "alice@example.com", Emails: []apisv1.SCIMEmail{{Value: "alice@example.com"}}}
+		Expect(filter.match(scimUserAttribute(user))).Should(BeTrue())
+		user.Emails = nil
+		Expect(filter.match(scimUserAttribute(user))).Should(BeFalse())
+
+		filter, err = parseSCIMFilter(`externalId pr`)
+		Expect(err).Should(BeNil())
+		Expect(filter.match(scimUserAttribute(&apisv1.SCIMUser{ExternalID: "1"}))).Should(BeTrue())
+		Expect(filter.match(scimUserAttribute(&apisv1.SCIMUser{}))).Should(BeFalse())
+
+		for _, invalid := range []string{`userName eq`, `userName gt "a"`, `userName eq "a" or userName eq "b"`, `(userName eq "a")`, `userName eq "a`} {
+			_, err = parseSCIMFilter(invalid)
+			Expect(err).Should(Equal(bcode.ErrSCIMInvalidFilter))
+		}
+	})
+
+	It("Test the token", func() {
+		Expect(scimService.CheckToken(context.TODO(), "")).Should(Equal(bcode.ErrSCIMDisabled))
+		_, err := scimService.UpdateConfig(context.TODO(), apisv1.UpdateSCIMConfigRequest{Enabled: true})
+		Expect(err).Should(BeNil())
+		Expect(scimService.CheckToken(context.TODO(), "")).Should(Equal(bcode.ErrSCIMUnauthorized))
+		token, err := scimService.GenerateToken(context.TODO())
+		Expect(err).Should(BeNil())
+		Expect(scimService.CheckToken(context.TODO(), token.Token)).Should(BeNil())
+		Expect(scimService.CheckToken(context.TODO(), "invalid")).Should(Equal(bcode.ErrSCIMUnauthorized))
+		config, err := scimService.GetConfig(context.TODO())
+		Expect(err).Should(BeNil())
+		Expect(config.TokenGenerated).Should(BeTrue())
+	})
+
+	It("Test provisioning the users", func() {
+		user, err := scimService.CreateUser(context.TODO(), apisv1.SCIMUser{
+			UserName:   "Alice@Example.com",
+			ExternalID: "00u1",
+			Name:       &apisv1.SCIMName{GivenName: "Alice", FamilyName: "Smith"},
+			Emails:     []apisv1.SCIMEmail{{Value: "alice@example.com", Primary: true}},
+		})
+		Expect(err).Should(BeNil())
+		Expect(user.ID).Should(Equal("alice-example.com"))
+		Expect(user.UserName).Should(Equal("Alice@Example.com"))
+		Expect(user.DisplayName).Should(Equal("Alice Smith"))
+		Expect(*user.Active).Should(BeTrue())
+		_, err = scimService.CreateUser(context.TODO(), apisv1.SCIMUser{UserName: "alice@example.com"})
+		Expect(err).Should(Equal(bcode.ErrSCIMUserExist))
+
+		list, err := scimService.ListUsers(context.TODO(), `userName eq "alice@example.com"`, 1, -1)
+		Expect(err).Should(BeNil())
+		Expect(list.TotalResults).Should(Equal(1))
+
+		// deactivate the user
+		patched, err := scimService.PatchUser(context.TODO(), user.ID, apisv1.SCIMPatchRequest{
+			Operations: []apisv1.SCIMPatchOperation{{Op: "Replace", Path: "active", Value: json.RawMessage(`"False"`)}},
+		})
+		Expect(err).Should(BeNil())
+		Expect(*patched.Active).Should(BeFalse())
+		stored, err := userService.GetUser(context.TODO(), user.ID)
+		Expect(err).Should(BeNil())
+		Expect(stored.Disabled).Should(BeTrue())
+
+		patched, err = scimService.PatchUser(context.TODO(), user.ID, apisv1.SCIMPatchRequest{
+			Operations: []apisv1.SCIMPatchOperation{{Op: "replace", Value: json.RawMessage(`{"active":true,"displayName":"Alice"}`)}},
+		})
+		Expect(err).Should(BeNil())
+		Expect(*patched.Active).Should(BeTrue())
+		Expect(patched.DisplayName).Should(Equal("Alice"))
+
+		Expect(scimService.DeleteUser(context.TODO(), user.ID)).Should(BeNil())
+		_, err = scimService.GetUser(context.TODO(), user.ID)
+		Expect(err).Should(Equal(bcode.ErrSCIMUserNotFound))
+	})
+
+	It("Test the local users are not managed by SCIM", func() {
+		Expect(ds.Add(context.TODO(), &model.User{Name: "scim-local"})).Should(BeNil())
+		Expect(ds.Add(context.TODO(), &model.User{Name: "scim-admin", SCIMUserName: "scim-admin", UserRoles: []string{model.RoleAdmin}})).Should(BeNil())
+		for _, name := range []string{"scim-local", "scim-admin"} {
+			_, err := scimService.GetUser(context.TODO(), name)
+			Expect(err).Should(Equal(bcode.ErrSCIMUserNotFound))
+			_, err = scimService.ReplaceUser(context.TODO(), name, apisv1.SCIMUser{UserName: name, Password: "Passw0rd!Passw0rd"})
+			Expect(err).Should(Equal(bcode.ErrSCIMUserNotFound))
+			_, err = scimService.PatchUser(context.TODO(), name, apisv1.SCIMPatchRequest{
+				Operations: []apisv1.SCIMPatchOperation{{Op: "replace", Path: "active", Value: json.RawMessage(`false`)}},
+			})
+			Expect(err).Should(Equal(bcode.ErrSCIMUserNotFound))
+			Expect(scimService.DeleteUser(context.TODO(), name)).Should(Equal(bcode.ErrSCIMUserNotFound))
+		}
+		list, err := scimService.ListUsers(context.TODO(), "", 1, -1)
+		Expect(err).Should(BeNil())
+		Expect(list.TotalResults).Should(Equal(0))
+
+		By("the password follows the policy of the local users")
+		userService.securityConfig = config.AccountSecurityConfig{PasswordMinLength: 10}
+		_, err = scimService.CreateUser(context.TODO(), apisv1.SCIMUser{UserName: "bob@example.com", Password: "short"})
+		Expect(err.(*bcode.Bcode).BusinessCode).Should(Equal(bcode.ErrUserPasswordPolicy.BusinessCode))
+		_, err = scimService.CreateUser(context.TODO(), apisv1.SCIMUser{UserName: "bob@example.com", Password: "long-enough-password"})
+		Expect(err).Should(BeNil())
+	})
+
+	It("Test mapping the groups onto the project users", func() {
+		_, err := projectService.CreateProject(context.TODO(), apisv1.CreateProjectRequest{Name: "scim-project"})
+		Expect(err).Should(BeNil())
+		_, err = scimService.UpdateConfig(context.TODO(), apisv1.UpdateSCIMConfigRequest{
+			Enabled:    true,
+			GroupRules: []apisv1.SCIMGroupRule{{Group: "team-*", Project: "scim-project", Roles: []string{"app-developer"}}},
+		})
+		Expect(err).Should(BeNil())
+		_, err = scimService.UpdateConfig(context.TODO(), apisv1.UpdateSCIMConfigRequest{
+			GroupRules: []apisv1.SCIMGroupRule{{Group: "team-*", Project: "scim-project", Roles: []string{"not-exist"}}},
+		})
+		Expect(err.(*bcode.Bcode).BusinessCode).Should(Equal(bcode.ErrSCIMInvalidGroupRule.BusinessCode))
+
+		user, err := scimService.CreateUser(context.TODO(), apisv1.SCIMUser{UserName: "bob"})
+		Expect(err).Should(BeNil())
+		group, err := scimService.CreateGroup(context.TODO(), apisv1.SCIMGroup{DisplayName: "team-a"})
+		Expect(err).Should(BeNil())
+		_, err = scimService.CreateGroup(context.TODO(), apisv1.SCIMGroup{DisplayName: "team-a"})
+		Expect(err).Should(Equal(bcode.ErrSCIMGroupExist))
+
+		_, err = scimService.PatchGroup(context.TODO(), group.ID, apisv1.SCIMPatchRequest{
+			Operations: []apisv1.SCIMPatchOperation{{Op: "add", Path: "members", Value: json.RawMessage(`[{"value":"bob"}]`)}},
+		})
+		Expect(err).Should(BeNil())
+		projectUser := &model.ProjectUser{ProjectName: "scim-project", Username: user.ID}
+		Expect(ds.Get(context.TODO(), projectUser)).Should(BeNil())
+		Expect(projectUser.UserRoles).Should(Equal([]string{"app-developer"}))
+		detail, err := scimService.GetUser(context.TODO(), user.ID)
+		Expect(err).Should(BeNil())
+		Expect(len(detail.Groups)).Should(Equal(1))
+
+		_, err = scimService.PatchGroup(context.TODO(), group.ID, apisv1.SCIMPatchRequest{
+			Operations: []apisv1.SCIMPatchOperation{{Op: "remove", Path: `members[value eq "bob"]`}},
+		})
+		Expect(err).Should(BeNil())
+		Expect(ds.Get(context.TODO(), &model.ProjectUser{ProjectName: "scim-project", Username: user.ID})).ShouldNot(BeNil())
+
+		_, err = scimService.PatchGroup(context.TODO(), group.ID, apisv1.SCIMPatchRequest{
+			Operations: []apisv1.SCIMPatchOperation{{Op: "add", Path: "members", Value: json.RawMessage(`[{"value":"not-exist"}]`)}},
+		})
+		Expect(err.(*bcode.Bcode).BusinessCode).Should(Equal(bcode.ErrSCIMInvalidResource.BusinessCode))
+	})
+})
@@ -21,6 +21,7 @@ import (
 	"fmt"
 
 	"github.com/kubevela/velaux/pkg/server/config"
+	"github.com/kubevela/velaux/pkg/server/infrastructure/secretstore"
 )
 
 // needInitData register the service that need to init data
@@ -28,22 +29,24 @@ var needInitData []DataInit
 
 // InitServiceBean init all service instance
 func InitServiceBean(c config.Config) []interface{} {
-	clusterService := NewClusterService()
+	clusterService := NewClusterService(c.ClusterHealth)
 	rbacService := NewRBACService()
-	projectService := NewProjectService()
+	projectService := NewProjectService(c.ProjectArchiveRetention)
 	envService := NewEnvService()
 	targetService := NewTargetService()
 	workflowService := NewWorkflowService()
 	oamApplicationService := NewOAMApplicationService()
 	velaQLService := NewVelaQLService()
-	definitionService := NewDefinitionService()
-	addonService := NewAddonService(c.AddonCacheTime)
+	definitionService := NewDefinitionService(c.DefinitionRevisionLimit)
+	offlineAddon := c.OfflineAddon
+	offlineAddon.ServerURL = c.OfflineAddonServerURL()
+	addonService := NewAddonService(c.AddonCacheTime, offlineAddon)
 	envBindingService := NewEnvBindingService()
 	systemInfoService := NewSystemInfoService()
 	helmService := NewHelmService()
-	userService := NewUserService()
+	userService := NewUserService(c.AccountSecurity)
 	authenticationService := NewAuthenticationService()
-	configService := NewConfigService()
+	configService := NewConfigService(c.ConfigRevisionLimit, secretstore.New(c.SecretStore))
 	applicationService := NewApplicationService()
 	webhookService := NewWebhookService()
 	pipelineService := NewPipelineService(c.WorkflowVersion)
@@ -55,7 +58,8 @@ func InitServiceBean(c config.Config) []interface{} {
 		clusterService, rbacService, projectService, envService, targetService, workflowService, oamApplicationService,
 		velaQLService, definitionService, addonService, envBindingService, systemInfoService, helmService, userService,
 		authenticationService, configService, applicationService, webhookService, pipelineService, pipelineRunService,
-		contextService, NewImageService(), NewCloudShellService(), pluginService,
+		contextService, NewImageService(), NewCloudShellService(c.CloudShell), pluginService, NewPluginStorageService(), NewPluginHookService(),
+		NewSessionService(), NewSCIMService(), NewProjectTemplateService(), NewNamespaceReconcileService(c.NamespaceRepair),
 	}
 }
 
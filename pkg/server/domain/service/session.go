@@ -0,0 +1,329 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/rand"
+	"k8s.io/klog/v2"
+
+	pkgUtils "github.com/oam-dev/kubevela/pkg/utils"
+
+	"github.com/kubevela/velaux/pkg/server/domain/model"
+	"github.com/kubevela/velaux/pkg/server/infrastructure/datastore"
+	apisv1 "github.com/kubevela/velaux/pkg/server/interfaces/api/dto/v1"
+	"github.com/kubevela/velaux/pkg/server/utils"
+	"github.com/kubevela/velaux/pkg/server/utils/bcode"
+)
+
+const (
+	// sessionCacheDuration the session is loaded from the datastore at most once in this duration,
+	// so the revocation from another replica takes effect after it.
+	sessionCacheDuration = time.Second * 10
+	// sessionLastSeenInterval the min interval to update the last seen time of the session
+	sessionLastSeenInterval = time.Minute
+	// maxUserAgentLength the longer user agent is truncated
+	maxUserAgentLength = 256
+	// maxCachedSessions the expired cache items are cleaned up after the cache grows to this size
+	maxCachedSessions = 1024
+)
+
+// SessionService manages the server-side login sessions, the tokens are invalid after the session is revoked.
+type SessionService interface {
+	CreateSession(ctx context.Context, username string, expiration time.Duration) (*model.Session, error)
+	// CheckSession check the session of the token is active and record the last seen time
+	CheckSession(ctx context.Context, claims *model.CustomClaims) error
+	// RenewSession extend the expiration of the session and rotate the refresh token, the refresh token of the claims is invalid after renewed
+	RenewSession(ctx context.Context, claims *model.CustomClaims, expiration time.Duration) (*model.Session, error)
+	ListSessions(ctx context.Context, username string) (*apisv1.ListSessionResponse, error)
+	RevokeSession(ctx context.Context, username, sessionID string) error
+	RevokeUserSessions(ctx context.Context, username string) error
+}
+
+type cachedSession struct {
+	session   model.Session
+	loadTime  time.Time
+	touchTime time.Time
+}
+
+type sessionServiceImpl struct {
+	Store datastore.DataStore `inject:"datastore"`
+	lock  sync.Mutex
+	cache map[string]*cachedSession
+}
+
+// NewSessionService create a session service instance
+func NewSessionService() SessionService {
+	return &sessionServiceImpl{cache: map[string]*cachedSession{}}
+}
+
+func clientInfo(ctx context.Context) utils.ClientInfo {
+	info, _ := utils.ClientInfoFrom(ctx)
+	if len(info.UserAgent) > maxUserAgentLength {
+		info.UserAgent = info.UserAgent[:maxUserAgentLength]
+	}
+	return info
+}
+
+func (s *sessionServiceImpl) CreateSession(ctx context.Context, username string, expiration time.Duration) (*model.Session, error) {
+	s.cleanupExpiredSessions(ctx, username)
+	now := time.Now()
+	client := clientInfo(ctx)
+	session := &model.Session{
+		ID:             rand.String(32),
+		Username:       username,
+		RefreshTokenID: rand.String(32),
+		UserAgent:      client.UserAgent,
+		IP:             client.IP,
+		LastSeenTime:   now,
+		ExpireTime:     now.Add(expiration),
+	}
+	if err := s.Store.Add(ctx, session); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// getSession get the session from the cache or the datastore
+func (s *sessionServiceImpl) getSession(ctx context.Context, id string) (*cachedSession, error) {
+	s.lock.Lock()
+	cached, ok := s.cache[id]
+	s.lock.Unlock()
+	if ok && time.Since(cached.loadTime) < sessionCacheDuration {
+		return cached, nil
+	}
+	session := &model.Session{ID: id}
+	if err := s.Store.Get(ctx, session); err != nil {
+		if errors.Is(err, datastore.ErrRecordNotExist) {
+			s.forget(id)
+			return nil, bcode.ErrSessionNotFound
+		}
+		return nil, err
+	}
+	now := time.Now()
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if len(s.cache) >= maxCachedSessions {
+		for key, item := range s.cache {
+			if now.Sub(item.loadTime) >= sessionCacheDuration {
+				delete(s.cache, key)
+			}
+		}
+	}
+	cached = &cachedSession{session: *session, loadTime: now, touchTime: session.LastSeenTime}
+	s.cache[id] = cached
+	return cached, nil
+}
+
+func (s *sessionServiceImpl) forget(id string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	delete(s.cache, id)
+}
+
+// activeSession get the session of the claims and check whether it is active
+func (s *sessionServiceImpl) activeSession(ctx context.Context, claims *model.CustomClaims) (*cachedSession, error) {
+	// The tokens issued before the sessions are introduced have no session
+	if claims.SessionID == "" {
+		return nil, bcode.ErrSessionNotFound
+	}
+	cached, err := s.getSession(ctx, claims.SessionID)
+	if err != nil {
+		return nil, err
+	}
+	if cached.session.Username != claims.Username {
+		return nil, bcode.ErrSessionNotFound
+	}
+	if time.Now().After(cached.session.ExpireTime) {
+		if err := s.Store.Delete(ctx, &model.Session{ID: claims.SessionID}); err != nil && !errors.Is(err, datastore.ErrRecordNotExist) {
+			klog.Errorf("failed to delete the expired session: %s", err.Error())
+		}
+		s.forget(claims.SessionID)
+		return nil, bcode.ErrSessionNotFound
+	}
+	return cached, nil
+}
+
+func (s *sessionServiceImpl) CheckSession(ctx context.Context, claims *model.CustomClaims) error {
+	// The access tokens issued before the sessions are introduced are accepted until they expire unless the user is disabled
+	if claims.SessionID == "" {
+		return s.checkSessionlessUser(ctx, claims.Username)
+	}
+	cached, err := s.activeSession(ctx, claims)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	s.lock.Lock()
+	if now.Sub(cached.touchTime) < sessionLastSeenInterval {
+		s.lock.Unlock()
+		return nil
+	}
+	cached.touchTime = now
+	session := cached.session
+	s.lock.Unlock()
+
+	session.LastSeenTime = now
+	if client := clientInfo(ctx); client.IP != "" {
+		session.IP = client.IP
+	}
+	if err := s.Store.Put(ctx, &session); err != nil {
+		klog.Errorf("failed to update the last seen time of the session: %s", err.Error())
+	}
+	return nil
+}
+
+// checkSessionlessUser check the user of the token without the session, the disabling could not revoke the token.
+func (s *sessionServiceImpl) checkSessionlessUser(ctx context.Context, username string) error {
+	user := &model.User{Name: username}
+	if err := s.Store.Get(ctx, user); err != nil {
+		if errors.Is(err, datastore.ErrRecordNotExist) {
+			return bcode.ErrSessionNotFound
+		}
+		return err
+	}
+	if user.Disabled {
+		return bcode.ErrUserDisabled
+	}
+	return nil
+}
+
+func (s *sessionServiceImpl) RenewSession(ctx context.Context, claims *model.CustomClaims, expiration time.Duration) (*model.Session, error) {
+	// Load the latest session, the refresh token may be rotated by another replica
+	s.forget(claims.SessionID)
+	cached, err := s.activeSession(ctx, claims)
+	if err != nil {
+		return nil, err
+	}
+	session := cached.session
+	if session.RefreshTokenID != claims.Id {
+		return nil, bcode.ErrRefreshTokenUsed
+	}
+	now := time.Now()
+	session.LastSeenTime = now
+	session.ExpireTime = now.Add(expiration)
+	session.RefreshTokenID = rand.String(32)
+	if client := clientInfo(ctx); client.IP != "" {
+		session.IP = client.IP
+	}
+	if err := s.Store.Put(ctx, &session); err != nil {
+		return nil, err
+	}
+	s.forget(session.ID)
+	return &session, nil
+}
+
+func (s *sessionServiceImpl) listUserSessions(ctx context.Context, username string) ([]*model.Session, error) {
+	entities, err := s.Store.List(ctx, &model.Session{Username: username}, &datastore.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	var sessions []*model.Session
+	for _, entity := range entities {
+		sessions = append(sessions, entity.(*model.Session))
+	}
+	return sessions, nil
+}
+
+// cleanupExpiredSessions delete the expired sessions of the user, they are never used again.
+func (s *sessionServiceImpl) cleanupExpiredSessions(ctx context.Context, username string) {
+	sessions, err := s.listUserSessions(ctx, username)
+	if err != nil {
+		klog.Errorf("failed to list the sessions of the user %s: %s", pkgUtils.Sanitize(username), err.Error())
+		return
+	}
+	now := time.Now()
+	for _, session := range sessions {
+		if now.After(session.ExpireTime) {
+			if err := s.Store.Delete(ctx, session); err != nil && !errors.Is(err, datastore.ErrRecordNotExist) {
+				klog.Errorf("failed to delete the expired session: %s", err.Error())
+			}
+		}
+	}
+}
+
+func (s *sessionServiceImpl) ListSessions(ctx context.Context, username string) (*apisv1.ListSessionResponse, error) {
+	sessions, err := s.listUserSessions(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	currentID, _ := ctx.Value(&apisv1.CtxKeySession).(string)
+	now := time.Now()
+	res := &apisv1.ListSessionResponse{Sessions: []*apisv1.SessionBase{}}
+	for _, session := range sessions {
+		if now.After(session.ExpireTime) {
+			continue
+		}
+		res.Sessions = append(res.Sessions, &apisv1.SessionBase{
+			ID:           session.ID,
+			Username:     session.Username,
+			UserAgent:    session.UserAgent,
+			IP:           session.IP,
+			CreateTime:   session.CreateTime,
+			LastSeenTime: session.LastSeenTime,
+			ExpireTime:   session.ExpireTime,
+			Current:      session.ID == currentID,
+		})
+	}
+	sort.Slice(res.Sessions, func(i, j int) bool {
+		return res.Sessions[i].LastSeenTime.After(res.Sessions[j].LastSeenTime)
+	})
+	return res, nil
+}
+
+func (s *sessionServiceImpl) RevokeSession(ctx context.Context, username, sessionID string) error {
+	session := &model.Session{ID: sessionID}
+	if err := s.Store.Get(ctx, session); err != nil {
+		if errors.Is(err, datastore.ErrRecordNotExist) {
+			return bcode.ErrSessionNotExist
+		}
+		return err
+	}
+	if session.Username != username {
+		return bcode.ErrSessionNotExist
+	}
+	if err := s.Store.Delete(ctx, session); err != nil && !errors.Is(err, datastore.ErrRecordNotExist) {
+		return err
+	}
+	s.forget(sessionID)
+	return nil
+}
+
+func (s *sessionServiceImpl) RevokeUserSessions(ctx context.Context, username string) error {
+	sessions, err := s.listUserSessions(ctx, username)
+	if err != nil {
+		return err
+	}
+	for _, session := range sessions {
+		if err := s.Store.Delete(ctx, session); err != nil && !errors.Is(err, datastore.ErrRecordNotExist) {
+			return err
+		}
+		s.forget(session.ID)
+	}
+	klog.Infof("revoked %d sessions of the user %s", len(sessions), pkgUtils.Sanitize(username))
+	return nil
+}
+
+// NewTestSessionService only used by testing
+func NewTestSessionService(ds datastore.DataStore) SessionService {
+	return &sessionServiceImpl{Store: ds, cache: map[string]*cachedSession{}}
+}
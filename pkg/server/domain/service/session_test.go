@@ -0,0 +1,176 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/form3tech-oss/jwt-go"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/kubevela/velaux/pkg/server/domain/model"
+	apisv1 "github.com/kubevela/velaux/pkg/server/interfaces/api/dto/v1"
+	"github.com/kubevela/velaux/pkg/server/utils"
+	"github.com/kubevela/velaux/pkg/server/utils/bcode"
+)
+
+var _ = Describe("Test session service functions", func() {
+	var sessionService SessionService
+
+	BeforeEach(func() {
+		InitTestEnv("session-test-" + strconv.FormatInt(time.Now().UnixNano(), 10))
+		sessionService = NewTestSessionService(ds)
+		authService.SessionService = sessionService
+		userService.SessionService = sessionService
+		Expect(sysService.Init(context.TODO())).Should(BeNil())
+		_, err := userService.CreateUser(context.TODO(), apisv1.CreateUserRequest{Name: "session-user", Email: "session@example.com", Password: "password1"})
+		Expect(err).Should(BeNil())
+	})
+
+	It("Test the session of the tokens", func() {
+		loginCtx := utils.WithClientInfo(context.TODO(), utils.ClientInfo{IP: "10.0.0.1", UserAgent: "test-agent"})
+		resp, err := authService.Login(loginCtx, apisv1.LoginRequest{Username: "session-user", Password: "password1"})
+		Expect(err).Should(BeNil())
+		claims, err := ParseToken(resp.AccessToken)
+		Expect(err).Should(BeNil())
+		Expect(claims.SessionID).ShouldNot(BeEmpty())
+		Expect(sessionService.CheckSession(context.TODO(), claims)).Should(BeNil())
+
+		sessions, err := sessionService.ListSessions(context.WithValue(context.TODO(), &apisv1.CtxKeySession, claims.SessionID), "session-user")
+		Expect(err).Should(BeNil())
+		Expect(len(sessions.Sessions)).Should(Equal(1))
+		Expect(sessions.Sessions[0].IP).Should(Equal("10.0.0.1"))
+		Expect(sessions.Sessions[0].UserAgent).Should(Equal("test-agent"))
+		Expect(sessions.Sessions[0].Current).Should(BeTrue())
+
+		refreshed, err := authService.RefreshToken(context.TODO(), resp.RefreshToken)
+		Expect(err).Should(BeNil())
+		refreshedClaims, err := ParseToken(refreshed.AccessToken)
+		Expect(err).Should(BeNil())
+		Expect(refreshedClaims.SessionID).Should(Equal(claims.SessionID))
+		// the rotated refresh token can not be used again
+		_, err = authService.RefreshToken(context.TODO(), resp.RefreshToken)
+		Expect(err).Should(Equal(bcode.ErrRefreshTokenUsed))
+		_, err = authService.RefreshToken(context.TODO(), refreshed.RefreshToken)
+		Expect(err).Should(BeNil())
+
+		// the session of another user can not be revoked
+		Expect(sessionService.RevokeSession(context.TODO(), "other", claims.SessionID)).Should(Equal(bcode.ErrSessionNotExist))
+		Expect(sessionService.RevokeSession(context.TODO(), "session-user", claims.SessionID)).Should(BeNil())
+		Expect(sessionService.CheckSession(context.TODO(), claims)).Should(Equal(bcode.ErrSessionNotFound))
+		_, err = authService.RefreshToken(context.TODO(), refreshed.RefreshToken)
+		Expect(err).Should(Equal(bcode.ErrSessionNotFound))
+	})
+
+	It("Test the tokens issued before the sessions are introduced", func() {
+		// the access tokens without the session are accepted until they expire
+		Expect(sessionService.CheckSession(context.TODO(), &model.CustomClaims{Username: "session-user"})).Should(BeNil())
+		Expect(sessionService.CheckSession(context.TODO(), &model.CustomClaims{Username: "not-exist"})).Should(Equal(bcode.ErrSessionNotFound))
+
+		// the refresh tokens without the session can not be rotated
+		legacyRefreshToken, err := authService.generateJWTToken("session-user", GrantTypeRefresh, "", "", refreshTokenExpiration)
+		Expect(err).Should(BeNil())
+		_, err = authService.RefreshToken(context.TODO(), legacyRefreshToken)
+		Expect(err).Should(Equal(bcode.ErrSessionNotFound))
+
+		user, err := userService.GetUser(context.TODO(), "session-user")
+		Expect(err).Should(BeNil())
+		Expect(userService.DisableUser(context.TODO(), user)).Should(BeNil())
+		Expect(sessionService.CheckSession(context.TODO(), &model.CustomClaims{Username: "session-user"})).Should(Equal(bcode.ErrUserDisabled))
+	})
+
+	It("Test revoking the sessions after disabling the user", func() {
+		var allClaims []*model.CustomClaims
+		var refreshToken string
+		for i := 0; i < 2; i++ {
+			resp, err := authService.Login(context.TODO(), apisv1.LoginRequest{Username: "session-user", Password: "password1"})
+			Expect(err).Should(BeNil())
+			refreshToken = resp.RefreshToken
+			claims, err := ParseToken(resp.AccessToken)
+			Expect(err).Should(BeNil())
+			allClaims = append(allClaims, claims)
+		}
+		user, err := userService.GetUser(context.TODO(), "session-user")
+		Expect(err).Should(BeNil())
+		// the refresh token of the disabled user is rejected even if the session is not revoked
+		user.Disabled = true
+		Expect(ds.Put(context.TODO(), user)).Should(BeNil())
+		_, err = authService.RefreshToken(context.TODO(), refreshToken)
+		Expect(err).Should(Equal(bcode.ErrUserDisabled))
+		user.Disabled = false
+		Expect(userService.DisableUser(context.TODO(), user)).Should(BeNil())
+		for _, claims := range allClaims {
+			Expect(sessionService.CheckSession(context.TODO(), claims)).Should(Equal(bcode.ErrSessionNotFound))
+		}
+		sessions, err := sessionService.ListSessions(context.TODO(), "session-user")
+		Expect(err).Should(BeNil())
+		Expect(len(sessions.Sessions)).Should(Equal(0))
+		_, err = authService.Login(context.TODO(), apisv1.LoginRequest{Username: "session-user", Password: "password1"})
+		Expect(err).Should(Equal(bcode.ErrUserAlreadyDisabled))
+	})
+
+	It("Test rotating the signing key", func() {
+		resp, err := authService.Login(context.TODO(), apisv1.LoginRequest{Username: "session-user", Password: "password1"})
+		Expect(err).Should(BeNil())
+
+		rotated, err := sysService.RotateSigningKey(context.TODO())
+		Expect(err).Should(BeNil())
+		Expect(rotated.RetainedKeys).Should(Equal(1))
+
+		// the token signed by the retired key is still valid
+		_, err = ParseToken(resp.AccessToken)
+		Expect(err).Should(BeNil())
+		newResp, err := authService.Login(context.TODO(), apisv1.LoginRequest{Username: "session-user", Password: "password1"})
+		Expect(err).Should(BeNil())
+		_, err = ParseToken(newResp.AccessToken)
+		Expect(err).Should(BeNil())
+
+		info, err := sysService.Get(context.TODO())
+		Expect(err).Should(BeNil())
+		Expect(info.SigningKeys[0].ID).Should(Equal(rotated.KeyID))
+
+		// the retired key is removed after the issued tokens expire
+		info.SigningKeys[1].RetireTime = time.Now().Add(-refreshTokenExpiration)
+		Expect(ds.Put(context.TODO(), info)).Should(BeNil())
+		_, err = sysService.RotateSigningKey(context.TODO())
+		Expect(err).Should(BeNil())
+		_, err = ParseToken(resp.AccessToken)
+		Expect(err).Should(Equal(bcode.ErrTokenInvalid))
+		_, err = ParseToken(newResp.AccessToken)
+		Expect(err).Should(BeNil())
+
+		By("Test signing the new tokens with the key rotated by another replica")
+		info, err = sysService.Get(context.TODO())
+		Expect(err).Should(BeNil())
+		anotherKey := model.SigningKey{ID: "another", Key: "another-replica-key", CreateTime: time.Now()}
+		info.SigningKeys = append([]model.SigningKey{anotherKey}, info.SigningKeys...)
+		Expect(ds.Put(context.TODO(), info)).Should(BeNil())
+		signingKeys.Lock()
+		signingKeys.loadTime = time.Now().Add(-signingKeysRefreshInterval)
+		signingKeys.Unlock()
+		token, err := authService.generateJWTToken("session-user", GrantTypeAccess, "", "", accessTokenExpiration)
+		Expect(err).Should(BeNil())
+		parsed, _, err := new(jwt.Parser).ParseUnverified(token, &model.CustomClaims{})
+		Expect(err).Should(BeNil())
+		Expect(parsed.Header["kid"]).Should(Equal("another"))
+		_, err = ParseToken(token)
+		Expect(err).Should(BeNil())
+	})
+})
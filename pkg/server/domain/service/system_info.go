@@ -22,6 +22,7 @@ import (
 	"time"
 
 	"k8s.io/apimachinery/pkg/util/rand"
+	"k8s.io/klog/v2"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	"github.com/oam-dev/kubevela/version"
@@ -38,6 +39,8 @@ type SystemInfoService interface {
 	Get(ctx context.Context) (*model.SystemInfo, error)
 	GetSystemInfo(ctx context.Context) (*v1.SystemInfoResponse, error)
 	UpdateSystemInfo(ctx context.Context, sysInfo v1.SystemInfoRequest) (*v1.SystemInfoResponse, error)
+	// RotateSigningKey generate a new key to sign the tokens, the issued tokens are valid until they expire.
+	RotateSigningKey(ctx context.Context) (*v1.RotateSigningKeyResponse, error)
 	Init(ctx context.Context) error
 }
 
@@ -119,6 +122,8 @@ func (u systemInfoServiceImpl) UpdateSystemInfo(ctx context.Context, sysInfo v1.
 		StatisticInfo:               info.StatisticInfo,
 		DexUserDefaultProjects:      sysInfo.DexUserDefaultProjects,
 		DexUserDefaultPlatformRoles: info.DexUserDefaultPlatformRoles,
+		SignedKey:                   info.SignedKey,
+		SigningKeys:                 info.SigningKeys,
 	}
 
 	if sysInfo.LoginType == model.LoginTypeDex {
@@ -152,12 +157,43 @@ func (u systemInfoServiceImpl) UpdateSystemInfo(ctx context.Context, sysInfo v1.
 	}, nil
 }
 
+func (u systemInfoServiceImpl) RotateSigningKey(ctx context.Context) (*v1.RotateSigningKeyResponse, error) {
+	info, err := u.Get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	keys := info.SigningKeys
+	if len(keys) == 0 {
+		keys = []model.SigningKey{{Key: info.SignedKey, CreateTime: info.CreateTime}}
+	}
+	keys[0].RetireTime = now
+	newKey := model.SigningKey{ID: rand.String(8), Key: rand.String(32), CreateTime: now}
+	retained := []model.SigningKey{newKey}
+	for _, key := range keys {
+		// The tokens signed by the key expire after the refresh token expiration at most.
+		if now.Sub(key.RetireTime) < refreshTokenExpiration {
+			retained = append(retained, key)
+		}
+	}
+	info.SigningKeys = retained
+	// The legacy field is kept for the downgrade
+	info.SignedKey = newKey.Key
+	if err := u.Store.Put(ctx, info); err != nil {
+		return nil, err
+	}
+	setSigningKeys(info)
+	klog.Infof("the signing key is rotated, %d keys are retained to verify the issued tokens", len(retained)-1)
+	return &v1.RotateSigningKeyResponse{KeyID: newKey.ID, RetainedKeys: len(retained) - 1}, nil
+}
+
 func (u systemInfoServiceImpl) Init(ctx context.Context) error {
 	info, err := u.Get(ctx)
 	if err != nil {
 		return err
 	}
-	signedKey = info.SignedKey
+	setSigningKeys(info)
+	signingKeysLoader = u.Get
 	_, err = initDexConfig(ctx, u.KubeClient, "http://velaux.com")
 	return err
 }
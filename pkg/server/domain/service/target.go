@@ -27,6 +27,7 @@ import (
 
 	"github.com/oam-dev/kubevela/pkg/auth"
 	"github.com/oam-dev/kubevela/pkg/multicluster"
+	pkgUtils "github.com/oam-dev/kubevela/pkg/utils"
 
 	"github.com/kubevela/velaux/pkg/server/domain/model"
 	"github.com/kubevela/velaux/pkg/server/domain/repository"
@@ -45,6 +46,7 @@ type TargetService interface {
 	UpdateTarget(ctx context.Context, Target *model.Target, req apisv1.UpdateTargetRequest) (*apisv1.DetailTargetResponse, error)
 	ListTargets(ctx context.Context, page, pageSize int, projectName string) (*apisv1.ListTargetResponse, error)
 	ListTargetCount(ctx context.Context, projectName string) (int64, error)
+	SyncTargetQuota(ctx context.Context, project *model.Project) error
 	Init(ctx context.Context) error
 }
 
@@ -75,7 +77,12 @@ func (dt *targetServiceImpl) Init(ctx context.Context) error {
 		if err := dt.Store.Put(ctx, t); err != nil {
 			return err
 		}
-		if err := managePrivilegesForTarget(ctx, dt.K8sClient, t, false); err != nil {
+		clusters, err := repository.ResolveTargetClusters(ctx, dt.Store, dt.K8sClient, t)
+		if err != nil {
+			klog.Errorf("failed to resolve the clusters of the target %s: %s", t.Name, err.Error())
+			continue
+		}
+		if err := managePrivilegesForTarget(ctx, dt.K8sClient, t, clusters, false); err != nil {
 			return err
 		}
 	}
@@ -121,10 +128,17 @@ func (dt *targetServiceImpl) DeleteTarget(ctx context.Context, targetName string
 	if err != nil {
 		return err
 	}
-	if err = repository.DeleteTargetNamespace(ctx, dt.K8sClient, ddt.Cluster.ClusterName, ddt.Cluster.Namespace, targetName); err != nil {
+	clusters, err := repository.ResolveTargetClusters(ctx, dt.Store, dt.K8sClient, ddt)
+	if err != nil {
 		return err
 	}
-	if err = managePrivilegesForTarget(ctx, dt.K8sClient, ddt, true); err != nil {
+	// the clusters left the cluster group after provisioning are released too
+	for _, cluster := range ddt.ProvisionedClusters {
+		if !pkgUtils.StringsContain(clusters, cluster) {
+			clusters = append(clusters, cluster)
+		}
+	}
+	if err = releaseTarget(ctx, dt.Store, dt.K8sClient, ddt, clusters); err != nil {
 		return err
 	}
 	if err = dt.Store.Delete(ctx, target); err != nil {
@@ -145,37 +159,98 @@ func (dt *targetServiceImpl) CreateTarget(ctx context.Context, req apisv1.Create
 	if err := dt.Store.Get(ctx, &project); err != nil {
 		return nil, bcode.ErrProjectIsNotExist
 	}
+	if err := ensureProjectWritable(ctx, dt.Store, project.Name); err != nil {
+		return nil, err
+	}
+	if err := checkProjectQuota(ctx, dt.Store, project.Name, quotaTargets, &model.Target{Project: project.Name}); err != nil {
+		return nil, err
+	}
+	if err := validateClusterTarget(req.Cluster); err != nil {
+		return nil, err
+	}
+	if (*model.ClusterTarget)(req.Cluster).IsDynamic() && req.Cluster.Namespace == "" {
+		req.Cluster.Namespace = req.Name
+	}
 	target := convertCreateReqToTargetModel(req)
 	if req.Cluster == nil {
 		req.Cluster = &apisv1.ClusterTarget{ClusterName: multicluster.ClusterLocalName, Namespace: req.Name}
 	}
 	createTargetCtx := utils.WithProject(ctx, "")
-	if err := repository.CreateTargetNamespace(createTargetCtx, dt.K8sClient, req.Cluster.ClusterName, req.Cluster.Namespace, req.Name); err != nil {
+	provisioned := &model.Target{Name: target.Name, Project: target.Project, Cluster: (*model.ClusterTarget)(req.Cluster)}
+	clusters, err := repository.ResolveTargetClusters(createTargetCtx, dt.Store, dt.K8sClient, provisioned)
+	if err != nil {
 		return nil, err
 	}
-	if err := managePrivilegesForTarget(createTargetCtx, dt.K8sClient, &target, false); err != nil {
+	if err := provisionTarget(createTargetCtx, dt.K8sClient, provisioned, clusters, project.Quota); err != nil {
 		return nil, err
 	}
-	err := repository.CreateTarget(ctx, dt.Store, &target)
-	if err != nil {
+	target.ProvisionedClusters = clusters
+	if err := repository.CreateTarget(ctx, dt.Store, &target); err != nil {
 		return nil, err
 	}
 	return dt.DetailTarget(ctx, &target)
 }
 
 func (dt *targetServiceImpl) UpdateTarget(ctx context.Context, target *model.Target, req apisv1.UpdateTargetRequest) (*apisv1.DetailTargetResponse, error) {
+	if err := ensureProjectWritable(ctx, dt.Store, target.Project); err != nil {
+		return nil, err
+	}
 	targetModel := convertUpdateReqToTargetModel(target, req)
 	if err := dt.Store.Put(ctx, targetModel); err != nil {
 		return nil, err
 	}
 	// Compatible with historical data, if the existing Target has not been authorized, perform an update action.
 	updateCtx := utils.WithProject(ctx, "")
-	if err := managePrivilegesForTarget(updateCtx, dt.K8sClient, targetModel, false); err != nil {
+	clusters, err := repository.ResolveTargetClusters(updateCtx, dt.Store, dt.K8sClient, targetModel)
+	if err != nil {
+		return nil, err
+	}
+	if err := managePrivilegesForTarget(updateCtx, dt.K8sClient, targetModel, clusters, false); err != nil {
+		return nil, err
+	}
+	kept, err := releaseStaleClusters(updateCtx, dt.Store, dt.K8sClient, targetModel, clusters)
+	if err != nil {
+		return nil, err
+	}
+	if err := recordProvisionedClusters(ctx, dt.Store, targetModel, kept); err != nil {
 		return nil, err
 	}
 	return dt.DetailTarget(ctx, targetModel)
 }
 
+// SyncTargetQuota provision the quota of the project into the namespaces of all targets in the project
+func (dt *targetServiceImpl) SyncTargetQuota(ctx context.Context, project *model.Project) error {
+	entities, err := dt.Store.List(ctx, &model.Target{Project: project.Name}, nil)
+	if err != nil {
+		return err
+	}
+	ctx = utils.WithProject(ctx, "")
+	for _, entity := range entities {
+		target := entity.(*model.Target)
+		if target.Cluster == nil {
+			continue
+		}
+		clusters, err := repository.ResolveTargetClusters(ctx, dt.Store, dt.K8sClient, target)
+		if err != nil {
+			return err
+		}
+		for _, cluster := range clusters {
+			if err := repository.ApplyTargetQuota(ctx, dt.K8sClient, cluster, target.Cluster.Namespace, project.Quota); err != nil {
+				klog.Errorf("failed to provision the quota into the target %s: %s", target.Name, err.Error())
+				return err
+			}
+		}
+		kept, err := releaseStaleClusters(ctx, dt.Store, dt.K8sClient, target, clusters)
+		if err != nil {
+			return err
+		}
+		if err := recordProvisionedClusters(ctx, dt.Store, target, kept); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // DetailTarget detail Target
 func (dt *targetServiceImpl) DetailTarget(ctx context.Context, target *model.Target) (*apisv1.DetailTargetResponse, error) {
 	return &apisv1.DetailTargetResponse{
@@ -225,6 +300,7 @@ func (dt *targetServiceImpl) convertFromTargetModel(ctx context.Context, target
 		CreateTime:  target.CreateTime,
 		UpdateTime:  target.UpdateTime,
 		AppNum:      appNum,
+		Health:      target.Health,
 	}
 	if target.Project != "" {
 		var project = model.Project{
@@ -235,6 +311,13 @@ func (dt *targetServiceImpl) convertFromTargetModel(ctx context.Context, target
 		}
 		targetBase.Project = apisv1.NameAlias{Name: project.Name, Alias: project.Alias}
 	}
+	if target.Cluster.IsDynamic() {
+		clusters, err := repository.ResolveTargetClusters(ctx, dt.Store, dt.K8sClient, target)
+		if err != nil {
+			klog.Errorf("failed to resolve the clusters of the target %s: %s", target.Name, err.Error())
+		}
+		targetBase.ResolvedClusters = clusters
+	}
 	if targetBase.Cluster != nil && targetBase.Cluster.ClusterName != "" {
 		cluster, err := _getClusterFromDataStore(ctx, dt.Store, target.Cluster.ClusterName)
 		if err != nil {
@@ -247,19 +330,148 @@ func (dt *targetServiceImpl) convertFromTargetModel(ctx context.Context, target
 	return targetBase
 }
 
-// managePrivilegesForTarget grant or revoke privileges for target
-func managePrivilegesForTarget(ctx context.Context, cli client.Client, target *model.Target, revoke bool) error {
+// validateClusterTarget the target must select the clusters by exactly one of the cluster name, the labels and the cluster group
+func validateClusterTarget(cluster *apisv1.ClusterTarget) error {
+	if cluster == nil {
+		return nil
+	}
+	var selectors int
+	if cluster.ClusterName != "" {
+		selectors++
+	}
+	if len(cluster.LabelSelector) > 0 {
+		selectors++
+	}
+	if cluster.ClusterGroup != "" {
+		selectors++
+	}
+	if selectors != 1 {
+		return bcode.ErrTargetInvalidClusterSelector
+	}
+	return nil
+}
+
+// provisionTarget create the namespace, provision the quota and grant the privileges of the target in the clusters
+func provisionTarget(ctx context.Context, cli client.Client, target *model.Target, clusters []string, quota *model.ProjectQuota) error {
+	for _, cluster := range clusters {
+		if err := repository.CreateTargetNamespace(ctx, cli, cluster, target.Cluster.Namespace, target.Name); err != nil {
+			return err
+		}
+		if err := repository.ApplyTargetQuota(ctx, cli, cluster, target.Cluster.Namespace, quota); err != nil {
+			return err
+		}
+	}
+	return managePrivilegesForTarget(ctx, cli, target, clusters, false)
+}
+
+// releaseTarget remove the labels and the quota from the namespace and revoke the privileges of the target in the clusters.
+// The quota and the privileges are kept in the clusters where another target of the project uses the same namespace.
+func releaseTarget(ctx context.Context, store datastore.DataStore, cli client.Client, target *model.Target, clusters []string) error {
 	if target.Cluster == nil {
 		return nil
 	}
-	p := &auth.ScopedPrivilege{Cluster: target.Cluster.ClusterName, Namespace: target.Cluster.Namespace}
+	shared, err := sharedTargetClusters(ctx, store, target)
+	if err != nil {
+		return err
+	}
+	var released []string
+	for _, cluster := range clusters {
+		if err := repository.DeleteTargetNamespace(ctx, cli, cluster, target.Cluster.Namespace, target.Name); err != nil {
+			return err
+		}
+		if pkgUtils.StringsContain(shared, cluster) {
+			continue
+		}
+		if err := repository.ApplyTargetQuota(ctx, cli, cluster, target.Cluster.Namespace, nil); err != nil {
+			klog.Warningf("failed to remove the quota of the target %s: %s", target.Name, err.Error())
+		}
+		released = append(released, cluster)
+	}
+	return managePrivilegesForTarget(ctx, cli, target, released, true)
+}
+
+// sharedTargetClusters returns the clusters where the other targets of the project resolve to the same namespace as the target
+func sharedTargetClusters(ctx context.Context, store datastore.DataStore, target *model.Target) ([]string, error) {
+	entities, err := store.List(ctx, &model.Target{Project: target.Project}, nil)
+	if err != nil {
+		return nil, err
+	}
+	var shared []string
+	for _, entity := range entities {
+		other := entity.(*model.Target)
+		if other.Name == target.Name || other.Cluster == nil || other.Cluster.Namespace != target.Cluster.Namespace {
+			continue
+		}
+		shared = append(shared, other.ProvisionedClusters...)
+		if other.Cluster.ClusterName != "" {
+			shared = append(shared, other.Cluster.ClusterName)
+		}
+	}
+	return shared, nil
+}
+
+// releaseStaleClusters release the target from the provisioned clusters not matched by it anymore,
+// return the provisioned clusters still matched
+func releaseStaleClusters(ctx context.Context, store datastore.DataStore, cli client.Client, target *model.Target, matched []string) ([]string, error) {
+	var stale, kept []string
+	for _, cluster := range target.ProvisionedClusters {
+		if pkgUtils.StringsContain(matched, cluster) {
+			kept = append(kept, cluster)
+		} else {
+			stale = append(stale, cluster)
+		}
+	}
+	if len(stale) == 0 {
+		return kept, nil
+	}
+	if err := releaseTarget(ctx, store, cli, target, stale); err != nil {
+		return nil, err
+	}
+	return kept, nil
+}
+
+// recordProvisionedClusters save the clusters the target is provisioned in, only the field is written to the latest record
+func recordProvisionedClusters(ctx context.Context, store datastore.DataStore, target *model.Target, clusters []string) error {
+	if len(clusters) == len(target.ProvisionedClusters) {
+		changed := false
+		for _, cluster := range clusters {
+			if !pkgUtils.StringsContain(target.ProvisionedClusters, cluster) {
+				changed = true
+				break
+			}
+		}
+		if !changed {
+			return nil
+		}
+	}
+	latest := &model.Target{Name: target.Name}
+	if err := store.Get(ctx, latest); err != nil {
+		return err
+	}
+	latest.ProvisionedClusters = clusters
+	if err := store.Put(ctx, latest); err != nil {
+		return err
+	}
+	target.ProvisionedClusters = clusters
+	return nil
+}
+
+// managePrivilegesForTarget grant or revoke privileges for target in the resolved clusters
+func managePrivilegesForTarget(ctx context.Context, cli client.Client, target *model.Target, clusters []string, revoke bool) error {
+	if target.Cluster == nil || len(clusters) == 0 {
+		return nil
+	}
+	var pds []auth.PrivilegeDescription
+	for _, cluster := range clusters {
+		pds = append(pds, &auth.ScopedPrivilege{Cluster: cluster, Namespace: target.Cluster.Namespace})
+	}
 	identity := &auth.Identity{Groups: []string{utils.KubeVelaProjectGroupPrefix + target.Project}}
 	writer := &bytes.Buffer{}
 	f, msg := auth.GrantPrivileges, "GrantPrivileges"
 	if revoke {
 		f, msg = auth.RevokePrivileges, "RevokePrivileges"
 	}
-	if err := f(ctx, cli, []auth.PrivilegeDescription{p}, identity, writer); err != nil {
+	if err := f(ctx, cli, pds, identity, writer); err != nil {
 		klog.Warningf("error encountered for %s: %s", msg, err.Error())
 		// for some cluster, authn/authz is not supported, ignore errors
 		return client.IgnoreNotFound(err)
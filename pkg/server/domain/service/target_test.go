@@ -22,8 +22,14 @@ import (
 	"github.com/google/go-cmp/cmp"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/oam-dev/kubevela/pkg/oam"
 
 	"github.com/kubevela/velaux/pkg/server/domain/model"
+	"github.com/kubevela/velaux/pkg/server/domain/repository"
 	"github.com/kubevela/velaux/pkg/server/infrastructure/datastore"
 	apisv1 "github.com/kubevela/velaux/pkg/server/interfaces/api/dto/v1"
 )
@@ -83,4 +89,66 @@ var _ = Describe("Test target service functions", func() {
 		err = targetService.DeleteTarget(context.TODO(), "test--target")
 		Expect(err).Should(BeNil())
 	})
+
+	It("Test releasing the clusters left the cluster group", func() {
+		project, err := projectService.CreateProject(context.TODO(), apisv1.CreateProjectRequest{Name: "group-target-project"})
+		Expect(err).Should(BeNil())
+		group := &model.ClusterGroup{Name: "release-group", Clusters: []string{"local"}}
+		Expect(targetService.Store.Add(context.TODO(), group)).Should(Succeed())
+		_, err = targetService.CreateTarget(context.TODO(), apisv1.CreateTargetRequest{
+			Name:    "group-target",
+			Project: project.Name,
+			Cluster: &apisv1.ClusterTarget{ClusterGroup: "release-group", Namespace: "group-target"},
+		})
+		Expect(err).Should(BeNil())
+		target, err := targetService.GetTarget(context.TODO(), "group-target")
+		Expect(err).Should(BeNil())
+		Expect(target.ProvisionedClusters).Should(Equal([]string{"local"}))
+		var namespace corev1.Namespace
+		Expect(k8sClient.Get(context.TODO(), types.NamespacedName{Name: "group-target"}, &namespace)).Should(Succeed())
+		Expect(namespace.Labels[oam.LabelNamespaceOfTargetName]).Should(Equal("group-target"))
+
+		group.Clusters = []string{"not-exist"}
+		Expect(targetService.Store.Put(context.TODO(), group)).Should(Succeed())
+		Expect(targetService.SyncTargetQuota(context.TODO(), &model.Project{Name: project.Name})).Should(Succeed())
+		target, err = targetService.GetTarget(context.TODO(), "group-target")
+		Expect(err).Should(BeNil())
+		Expect(target.ProvisionedClusters).Should(BeEmpty())
+		Expect(k8sClient.Get(context.TODO(), types.NamespacedName{Name: "group-target"}, &namespace)).Should(Succeed())
+		Expect(namespace.Labels[oam.LabelNamespaceOfTargetName]).Should(BeEmpty())
+
+		Expect(targetService.DeleteTarget(context.TODO(), "group-target")).Should(Succeed())
+	})
+
+	It("Test keeping the quota of the namespace shared by another target", func() {
+		project, err := projectService.CreateProject(context.TODO(), apisv1.CreateProjectRequest{Name: "shared-target-project"})
+		Expect(err).Should(BeNil())
+		projectModel := &model.Project{Name: project.Name}
+		Expect(targetService.Store.Get(context.TODO(), projectModel)).Should(Succeed())
+		projectModel.Quota = &model.ProjectQuota{ResourceQuota: map[string]string{"requests.cpu": "2"}}
+		Expect(targetService.Store.Put(context.TODO(), projectModel)).Should(Succeed())
+		_, err = targetService.CreateTarget(context.TODO(), apisv1.CreateTargetRequest{
+			Name:    "shared-target-a",
+			Project: project.Name,
+			Cluster: &apisv1.ClusterTarget{ClusterName: "local", Namespace: "shared-target"},
+		})
+		Expect(err).Should(BeNil())
+		// the target resolving to the same namespace by the cluster group
+		Expect(targetService.Store.Add(context.TODO(), &model.Target{
+			Name:                "shared-target-b",
+			Project:             project.Name,
+			Cluster:             &model.ClusterTarget{ClusterGroup: "shared-group", Namespace: "shared-target"},
+			ProvisionedClusters: []string{"local"},
+		})).Should(Succeed())
+		quotaKey := types.NamespacedName{Namespace: "shared-target", Name: repository.TargetResourceQuotaName}
+		var quota corev1.ResourceQuota
+		Expect(k8sClient.Get(context.TODO(), quotaKey, &quota)).Should(Succeed())
+
+		Expect(targetService.DeleteTarget(context.TODO(), "shared-target-a")).Should(Succeed())
+		Expect(k8sClient.Get(context.TODO(), quotaKey, &quota)).Should(Succeed())
+		target := &model.Target{Name: "shared-target-b"}
+		Expect(targetService.Store.Get(context.TODO(), target)).Should(Succeed())
+		Expect(releaseTarget(context.TODO(), targetService.Store, k8sClient, target, []string{"local"})).Should(Succeed())
+		Expect(apierrors.IsNotFound(k8sClient.Get(context.TODO(), quotaKey, &quota))).Should(BeTrue())
+	})
 })
@@ -18,24 +18,40 @@ package service
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"strings"
+	"time"
+	"unicode"
 
 	"github.com/oam-dev/kubevela/pkg/multicluster"
 
 	"golang.org/x/crypto/bcrypt"
-	"helm.sh/helm/v3/pkg/time"
 	"k8s.io/klog/v2"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	pkgUtils "github.com/oam-dev/kubevela/pkg/utils"
 
+	"github.com/kubevela/velaux/pkg/server/config"
 	"github.com/kubevela/velaux/pkg/server/domain/model"
 	"github.com/kubevela/velaux/pkg/server/infrastructure/datastore"
 	apisv1 "github.com/kubevela/velaux/pkg/server/interfaces/api/dto/v1"
+	"github.com/kubevela/velaux/pkg/server/utils"
 	"github.com/kubevela/velaux/pkg/server/utils/bcode"
 )
 
+const (
+	// TOTPIssuer the issuer shown in the authenticator apps
+	TOTPIssuer = "KubeVela"
+	// recoveryCodeCount the number of the recovery codes generated once
+	recoveryCodeCount = 10
+)
+
 // UserService User manage api
 type UserService interface {
 	AdminConfigured(ctx context.Context) (apisv1.AdminConfiguredResponse, error)
@@ -51,6 +67,18 @@ type UserService interface {
 	EnableUser(ctx context.Context, user *model.User) error
 	DetailLoginUserInfo(ctx context.Context) (*apisv1.LoginUserInfoResponse, error)
 	UpdateUserLoginTime(ctx context.Context, user *model.User) error
+	// VerifyPassword verify the password of the local user, the user is locked after too many failed attempts.
+	// It returns ErrUserPasswordExpired if the password is correct but expired.
+	VerifyPassword(ctx context.Context, user *model.User, password string) error
+	// VerifyTOTP verify the TOTP code or one of the recovery codes, the used recovery code is removed.
+	VerifyTOTP(ctx context.Context, user *model.User, code string) error
+	UnlockUser(ctx context.Context, user *model.User) error
+	EnrollTOTP(ctx context.Context, user *model.User) (*apisv1.TOTPEnrollResponse, error)
+	ActivateTOTP(ctx context.Context, user *model.User, code string) (*apisv1.TOTPRecoveryCodesResponse, error)
+	GenerateRecoveryCodes(ctx context.Context, user *model.User) (*apisv1.TOTPRecoveryCodesResponse, error)
+	DisableTOTP(ctx context.Context, user *model.User) error
+	// SetPassword check the password with the policy and replace the password of the user, the caller saves the user
+	SetPassword(user *model.User, password string) error
 }
 
 type userServiceImpl struct {
@@ -61,11 +89,13 @@ type userServiceImpl struct {
 	SysService     SystemInfoService   `inject:""`
 	TargetService  TargetService       `inject:""`
 	EnvService     EnvService          `inject:""`
+	SessionService SessionService      `inject:""`
+	securityConfig config.AccountSecurityConfig
 }
 
 // NewUserService new User service
-func NewUserService() UserService {
-	return &userServiceImpl{}
+func NewUserService(c config.AccountSecurityConfig) UserService {
+	return &userServiceImpl{securityConfig: c}
 }
 
 // AdminConfigured check if admin user is initialized
@@ -250,6 +280,11 @@ func (u *userServiceImpl) DeleteUser(ctx context.Context, username string) error
 		klog.Errorf("failed to delete user %s %v", pkgUtils.Sanitize(username), err.Error())
 		return err
 	}
+	if u.SessionService != nil {
+		if err := u.SessionService.RevokeUserSessions(ctx, username); err != nil {
+			klog.Errorf("failed to revoke the sessions of the user %s: %s", pkgUtils.Sanitize(username), err.Error())
+		}
+	}
 	return nil
 }
 
@@ -262,10 +297,6 @@ func (u *userServiceImpl) CreateUser(ctx context.Context, req apisv1.CreateUserR
 	if sysInfo.LoginType == model.LoginTypeDex {
 		return nil, bcode.ErrUserCannotModified
 	}
-	hash, err := GeneratePasswordHash(req.Password)
-	if err != nil {
-		return nil, err
-	}
 
 	// TODO: validate the roles, they must be platform roles
 	user := &model.User{
@@ -273,9 +304,11 @@ func (u *userServiceImpl) CreateUser(ctx context.Context, req apisv1.CreateUserR
 		Alias:     req.Alias,
 		Email:     req.Email,
 		UserRoles: req.Roles,
-		Password:  hash,
 		Disabled:  false,
 	}
+	if err := u.setPassword(user, req.Password); err != nil {
+		return nil, err
+	}
 	if err := u.Store.Add(ctx, user); err != nil {
 		return nil, err
 	}
@@ -293,11 +326,9 @@ func (u *userServiceImpl) UpdateUser(ctx context.Context, user *model.User, req
 	}
 	if sysInfo.LoginType != model.LoginTypeDex {
 		if req.Password != "" {
-			hash, err := GeneratePasswordHash(req.Password)
-			if err != nil {
+			if err := u.setPassword(user, req.Password); err != nil {
 				return nil, err
 			}
-			user.Password = hash
 		}
 	}
 	if req.Email != "" {
@@ -382,7 +413,13 @@ func (u *userServiceImpl) DisableUser(ctx context.Context, user *model.User) err
 		return bcode.ErrUserAlreadyDisabled
 	}
 	user.Disabled = true
-	return u.Store.Put(ctx, user)
+	if err := u.Store.Put(ctx, user); err != nil {
+		return err
+	}
+	if u.SessionService != nil {
+		return u.SessionService.RevokeUserSessions(ctx, user.Name)
+	}
+	return nil
 }
 
 // EnableUser disable user
@@ -396,10 +433,284 @@ func (u *userServiceImpl) EnableUser(ctx context.Context, user *model.User) erro
 
 // UpdateUserLoginTime update user login time
 func (u *userServiceImpl) UpdateUserLoginTime(ctx context.Context, user *model.User) error {
-	user.LastLoginTime = time.Now().Time
+	user.LastLoginTime = time.Now()
+	return u.Store.Put(ctx, user)
+}
+
+// SetPassword check the password with the policy and replace the password of the user, the caller saves the user
+func (u *userServiceImpl) SetPassword(user *model.User, password string) error {
+	return u.setPassword(user, password)
+}
+
+// setPassword check the password with the policy and replace the password hash of the user
+func (u *userServiceImpl) setPassword(user *model.User, password string) error {
+	if err := u.checkPasswordPolicy(user, password); err != nil {
+		return err
+	}
+	hash, err := GeneratePasswordHash(password)
+	if err != nil {
+		return err
+	}
+	if history := u.securityConfig.PasswordHistory; history > 0 && user.Password != "" {
+		user.PasswordHistory = append([]string{user.Password}, user.PasswordHistory...)
+		if len(user.PasswordHistory) > history {
+			user.PasswordHistory = user.PasswordHistory[:history]
+		}
+	} else if history <= 0 {
+		user.PasswordHistory = nil
+	}
+	user.Password = hash
+	user.PasswordUpdateTime = time.Now()
+	return nil
+}
+
+// checkPasswordPolicy check the length and the character classes of the password, and whether it is used recently
+func (u *userServiceImpl) checkPasswordPolicy(user *model.User, password string) error {
+	policy := u.securityConfig
+	if policy.PasswordMinLength > 0 && len(password) < policy.PasswordMinLength {
+		return bcode.ErrUserPasswordPolicy.SetMessage(fmt.Sprintf("the password must contain at least %d characters", policy.PasswordMinLength))
+	}
+	if policy.PasswordMinClasses > 0 && countCharacterClasses(password) < policy.PasswordMinClasses {
+		return bcode.ErrUserPasswordPolicy.SetMessage(fmt.Sprintf("the password must contain at least %d of the character classes: lowercase, uppercase, digit and symbol", policy.PasswordMinClasses))
+	}
+	if policy.PasswordHistory > 0 && user.Password != "" {
+		for _, hash := range append([]string{user.Password}, user.PasswordHistory...) {
+			if compareHashWithPassword(hash, password) == nil {
+				return bcode.ErrUserPasswordReused
+			}
+		}
+	}
+	return nil
+}
+
+func countCharacterClasses(password string) int {
+	var lower, upper, digit, symbol int
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			lower = 1
+		case unicode.IsUpper(r):
+			upper = 1
+		case unicode.IsDigit(r):
+			digit = 1
+		default:
+			symbol = 1
+		}
+	}
+	return lower + upper + digit + symbol
+}
+
+func (u *userServiceImpl) passwordExpired(user *model.User) bool {
+	// The users created before the policy enabled have no update time, they are not forced to change the password.
+	if u.securityConfig.PasswordMaxAge <= 0 || user.PasswordUpdateTime.IsZero() {
+		return false
+	}
+	return time.Now().After(user.PasswordUpdateTime.Add(u.securityConfig.PasswordMaxAge))
+}
+
+// VerifyPassword verify the password of the local user
+func (u *userServiceImpl) VerifyPassword(ctx context.Context, user *model.User, password string) error {
+	if err := u.verifyCredential(ctx, user, func() error {
+		return compareHashWithPassword(user.Password, password)
+	}); err != nil {
+		return err
+	}
+	if u.passwordExpired(user) {
+		return bcode.ErrUserPasswordExpired
+	}
+	return nil
+}
+
+// VerifyTOTP verify the TOTP code or the recovery code of the user
+func (u *userServiceImpl) VerifyTOTP(ctx context.Context, user *model.User, code string) error {
+	if !user.TOTPEnabled {
+		return bcode.ErrTOTPNotEnabled
+	}
+	var totpCodeUsed, recoveryCodeUsed bool
+	if err := u.verifyCredential(ctx, user, func() error {
+		if step, ok := utils.ValidateTOTPCode(user.TOTPSecret, code, time.Now()); ok && step > user.TOTPLastStep {
+			user.TOTPLastStep = step
+			totpCodeUsed = true
+			return nil
+		}
+		hash := hashRecoveryCode(code)
+		for i, c := range user.RecoveryCodes {
+			if subtle.ConstantTimeCompare([]byte(c), []byte(hash)) == 1 {
+				user.RecoveryCodes = append(user.RecoveryCodes[:i], user.RecoveryCodes[i+1:]...)
+				recoveryCodeUsed = true
+				return nil
+			}
+		}
+		return bcode.ErrInvalidTOTPCode
+	}); err != nil {
+		return err
+	}
+	if recoveryCodeUsed {
+		klog.Infof("the user %s logged in with a recovery code, %d codes left", pkgUtils.Sanitize(user.Name), len(user.RecoveryCodes))
+	}
+	if totpCodeUsed || recoveryCodeUsed {
+		return u.Store.Put(ctx, user)
+	}
+	return nil
+}
+
+// verifyCredential record the failed attempts and lock the user if the attempts reach the threshold
+func (u *userServiceImpl) verifyCredential(ctx context.Context, user *model.User, verify func() error) error {
+	now := time.Now()
+	if user.IsLocked(now) {
+		return lockedError(user)
+	}
+	err := verify()
+	if err == nil {
+		if user.FailedLoginAttempts > 0 || user.LockReason != "" {
+			resetLock(user)
+			return u.Store.Put(ctx, user)
+		}
+		return nil
+	}
+	if !errors.Is(err, bcode.ErrUserInconsistentPassword) && !errors.Is(err, bcode.ErrInvalidTOTPCode) {
+		return err
+	}
+	if user.LockReason != "" {
+		// the previous lock is expired, count the attempts again
+		resetLock(user)
+	}
+	user.FailedLoginAttempts++
+	threshold := u.securityConfig.LockoutThreshold
+	if threshold > 0 && user.FailedLoginAttempts >= threshold {
+		user.LockReason = model.LockReasonTooManyFailedAttempts
+		user.LockTime = now
+		if u.securityConfig.LockoutDuration > 0 {
+			user.LockedUntil = now.Add(u.securityConfig.LockoutDuration)
+		}
+		klog.Warningf("the user %s is locked after %d failed login attempts", pkgUtils.Sanitize(user.Name), user.FailedLoginAttempts)
+	}
+	if putErr := u.Store.Put(ctx, user); putErr != nil {
+		klog.Errorf("failed to record the failed login attempt of the user %s: %s", pkgUtils.Sanitize(user.Name), putErr.Error())
+	}
+	if user.IsLocked(now) {
+		return lockedError(user)
+	}
+	return err
+}
+
+func lockedError(user *model.User) error {
+	if user.LockedUntil.IsZero() {
+		return bcode.ErrUserLocked.SetMessage(fmt.Sprintf("the user is locked because of %s, please contact the administrator", user.LockReason))
+	}
+	return bcode.ErrUserLocked.SetMessage(fmt.Sprintf("the user is locked because of %s, please retry after %s", user.LockReason, user.LockedUntil.Format(time.RFC3339)))
+}
+
+func resetLock(user *model.User) {
+	user.FailedLoginAttempts = 0
+	user.LockReason = ""
+	user.LockTime = time.Time{}
+	user.LockedUntil = time.Time{}
+}
+
+// UnlockUser unlock the user and reset the failed attempts
+func (u *userServiceImpl) UnlockUser(ctx context.Context, user *model.User) error {
+	if !user.IsLocked(time.Now()) && user.FailedLoginAttempts == 0 {
+		return bcode.ErrUserNotLocked
+	}
+	resetLock(user)
+	return u.Store.Put(ctx, user)
+}
+
+// EnrollTOTP generate a new TOTP secret for the user, it takes effect after activating with a valid code
+func (u *userServiceImpl) EnrollTOTP(ctx context.Context, user *model.User) (*apisv1.TOTPEnrollResponse, error) {
+	sysInfo, err := u.SysService.Get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if sysInfo.LoginType == model.LoginTypeDex {
+		return nil, bcode.ErrUserCannotModified
+	}
+	if user.TOTPEnabled {
+		return nil, bcode.ErrTOTPAlreadyEnabled
+	}
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+	user.TOTPSecret = secret
+	if err := u.Store.Put(ctx, user); err != nil {
+		return nil, err
+	}
+	return &apisv1.TOTPEnrollResponse{
+		Secret: secret,
+		URI:    utils.TOTPKeyURI(TOTPIssuer, user.Name, secret),
+	}, nil
+}
+
+// ActivateTOTP enable the enrolled TOTP authenticator after verifying the first code
+func (u *userServiceImpl) ActivateTOTP(ctx context.Context, user *model.User, code string) (*apisv1.TOTPRecoveryCodesResponse, error) {
+	if user.TOTPEnabled {
+		return nil, bcode.ErrTOTPAlreadyEnabled
+	}
+	if user.TOTPSecret == "" {
+		return nil, bcode.ErrTOTPNotEnrolled
+	}
+	step, ok := utils.ValidateTOTPCode(user.TOTPSecret, code, time.Now())
+	if !ok {
+		return nil, bcode.ErrInvalidTOTPCode
+	}
+	user.TOTPEnabled = true
+	user.TOTPLastStep = step
+	return u.GenerateRecoveryCodes(ctx, user)
+}
+
+// GenerateRecoveryCodes replace the recovery codes of the user, only the hashes are saved
+func (u *userServiceImpl) GenerateRecoveryCodes(ctx context.Context, user *model.User) (*apisv1.TOTPRecoveryCodesResponse, error) {
+	if !user.TOTPEnabled {
+		return nil, bcode.ErrTOTPNotEnabled
+	}
+	codes := make([]string, 0, recoveryCodeCount)
+	hashes := make([]string, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		code, err := generateRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+		codes = append(codes, code)
+		hashes = append(hashes, hashRecoveryCode(code))
+	}
+	user.RecoveryCodes = hashes
+	if err := u.Store.Put(ctx, user); err != nil {
+		return nil, err
+	}
+	return &apisv1.TOTPRecoveryCodesResponse{RecoveryCodes: codes}, nil
+}
+
+// DisableTOTP remove the TOTP authenticator and the recovery codes of the user
+func (u *userServiceImpl) DisableTOTP(ctx context.Context, user *model.User) error {
+	if !user.TOTPEnabled && user.TOTPSecret == "" {
+		return bcode.ErrTOTPNotEnabled
+	}
+	user.TOTPEnabled = false
+	user.TOTPSecret = ""
+	user.TOTPLastStep = 0
+	user.RecoveryCodes = nil
 	return u.Store.Put(ctx, user)
 }
 
+// generateRecoveryCode generate a code like "abcde-fghij"
+func generateRecoveryCode() (string, error) {
+	b := make([]byte, 10)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	code := strings.ToLower(base32.StdEncoding.EncodeToString(b))
+	return code[:5] + "-" + code[5:10], nil
+}
+
+// hashRecoveryCode the recovery codes are random enough, so a fast hash is used to compare them
+func hashRecoveryCode(code string) string {
+	code = strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), " ", ""))
+	sum := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(sum[:])
+}
+
 // DetailLoginUserInfo get projects and permission policies of login user
 func (u *userServiceImpl) DetailLoginUserInfo(ctx context.Context) (*apisv1.LoginUserInfoResponse, error) {
 	userName, ok := ctx.Value(&apisv1.CtxKeyUser).(string)
@@ -482,14 +793,20 @@ func convertUserModel(user *model.User, roles *apisv1.ListRolesResponse) *apisv1
 }
 
 func convertUserBase(user *model.User) *apisv1.UserBase {
-	return &apisv1.UserBase{
+	base := &apisv1.UserBase{
 		Name:          user.Name,
 		Alias:         user.Alias,
 		Email:         user.Email,
 		CreateTime:    user.CreateTime,
 		LastLoginTime: user.LastLoginTime,
 		Disabled:      user.Disabled,
+		TOTPEnabled:   user.TOTPEnabled,
+	}
+	if user.IsLocked(time.Now()) {
+		base.Locked = true
+		base.LockReason = user.LockReason
 	}
+	return base
 }
 
 // GeneratePasswordHash generate password hash
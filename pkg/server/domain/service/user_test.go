@@ -20,6 +20,7 @@ import (
 	"context"
 	"fmt"
 	"strconv"
+	"strings"
 	"time"
 
 	. "github.com/onsi/ginkgo"
@@ -31,9 +32,11 @@ import (
 
 	"github.com/oam-dev/kubevela/pkg/oam/util"
 
+	"github.com/kubevela/velaux/pkg/server/config"
 	"github.com/kubevela/velaux/pkg/server/domain/model"
 	"github.com/kubevela/velaux/pkg/server/infrastructure/datastore"
 	apisv1 "github.com/kubevela/velaux/pkg/server/interfaces/api/dto/v1"
+	"github.com/kubevela/velaux/pkg/server/utils"
 	"github.com/kubevela/velaux/pkg/server/utils/bcode"
 )
 
@@ -251,4 +254,123 @@ var _ = Describe("Test authentication service functions", func() {
 		Expect(err).Should(BeNil())
 		Expect(newUser.Disabled).Should(Equal(false))
 	})
+
+	It("Test password policy", func() {
+		ctx := context.Background()
+		userService.securityConfig = config.AccountSecurityConfig{PasswordMinLength: 10, PasswordMinClasses: 3, PasswordHistory: 2}
+		_, err := userService.CreateUser(ctx, apisv1.CreateUserRequest{Name: "policy", Email: "policy@example.com", Password: "Short1"})
+		Expect(err.(*bcode.Bcode).BusinessCode).Should(Equal(bcode.ErrUserPasswordPolicy.BusinessCode))
+		_, err = userService.CreateUser(ctx, apisv1.CreateUserRequest{Name: "policy", Email: "policy@example.com", Password: "lowercase123"})
+		Expect(err.(*bcode.Bcode).BusinessCode).Should(Equal(bcode.ErrUserPasswordPolicy.BusinessCode))
+		_, err = userService.CreateUser(ctx, apisv1.CreateUserRequest{Name: "policy", Email: "policy@example.com", Password: "Password-0"})
+		Expect(err).Should(BeNil())
+
+		user, err := userService.GetUser(ctx, "policy")
+		Expect(err).Should(BeNil())
+		Expect(user.PasswordUpdateTime.IsZero()).Should(BeFalse())
+		_, err = userService.UpdateUser(ctx, user, apisv1.UpdateUserRequest{Password: "Password-0"})
+		Expect(err).Should(Equal(bcode.ErrUserPasswordReused))
+		_, err = userService.UpdateUser(ctx, user, apisv1.UpdateUserRequest{Password: "Password-1"})
+		Expect(err).Should(BeNil())
+		_, err = userService.UpdateUser(ctx, user, apisv1.UpdateUserRequest{Password: "Password-2"})
+		Expect(err).Should(BeNil())
+		_, err = userService.UpdateUser(ctx, user, apisv1.UpdateUserRequest{Password: "Password-0"})
+		Expect(err).Should(Equal(bcode.ErrUserPasswordReused))
+		_, err = userService.UpdateUser(ctx, user, apisv1.UpdateUserRequest{Password: "Password-3"})
+		Expect(err).Should(BeNil())
+		// only the last 2 passwords are kept
+		Expect(len(user.PasswordHistory)).Should(Equal(2))
+		_, err = userService.UpdateUser(ctx, user, apisv1.UpdateUserRequest{Password: "Password-0"})
+		Expect(err).Should(BeNil())
+
+		userService.securityConfig.PasswordMaxAge = time.Hour
+		user.PasswordUpdateTime = time.Now().Add(-2 * time.Hour)
+		Expect(userService.VerifyPassword(ctx, user, "Password-0")).Should(Equal(bcode.ErrUserPasswordExpired))
+	})
+
+	It("Test lock user", func() {
+		ctx := context.Background()
+		userService.securityConfig = config.AccountSecurityConfig{LockoutThreshold: 3, LockoutDuration: time.Hour}
+		_, err := userService.CreateUser(ctx, apisv1.CreateUserRequest{Name: "lock", Email: "lock@example.com", Password: "password1"})
+		Expect(err).Should(BeNil())
+		user, err := userService.GetUser(ctx, "lock")
+		Expect(err).Should(BeNil())
+
+		Expect(userService.VerifyPassword(ctx, user, "wrong")).Should(Equal(bcode.ErrUserInconsistentPassword))
+		Expect(userService.VerifyPassword(ctx, user, "password1")).Should(BeNil())
+		Expect(user.FailedLoginAttempts).Should(Equal(0))
+		for i := 0; i < 2; i++ {
+			Expect(userService.VerifyPassword(ctx, user, "wrong")).Should(Equal(bcode.ErrUserInconsistentPassword))
+		}
+		err = userService.VerifyPassword(ctx, user, "wrong")
+		Expect(err.(*bcode.Bcode).BusinessCode).Should(Equal(bcode.ErrUserLocked.BusinessCode))
+		// the correct password is refused while the user is locked
+		err = userService.VerifyPassword(ctx, user, "password1")
+		Expect(err.(*bcode.Bcode).BusinessCode).Should(Equal(bcode.ErrUserLocked.BusinessCode))
+
+		user, err = userService.GetUser(ctx, "lock")
+		Expect(err).Should(BeNil())
+		Expect(user.LockReason).Should(Equal(model.LockReasonTooManyFailedAttempts))
+		Expect(convertUserBase(user).Locked).Should(BeTrue())
+
+		Expect(userService.UnlockUser(ctx, user)).Should(BeNil())
+		Expect(userService.UnlockUser(ctx, user)).Should(Equal(bcode.ErrUserNotLocked))
+		Expect(userService.VerifyPassword(ctx, user, "password1")).Should(BeNil())
+
+		// the lock is expired
+		user.LockReason = model.LockReasonTooManyFailedAttempts
+		user.LockedUntil = time.Now().Add(-time.Minute)
+		user.FailedLoginAttempts = 3
+		Expect(userService.VerifyPassword(ctx, user, "wrong")).Should(Equal(bcode.ErrUserInconsistentPassword))
+		Expect(user.FailedLoginAttempts).Should(Equal(1))
+		Expect(user.LockReason).Should(BeEmpty())
+	})
+
+	It("Test TOTP", func() {
+		ctx := context.Background()
+		userService.securityConfig = config.AccountSecurityConfig{LockoutThreshold: 5}
+		_, err := userService.CreateUser(ctx, apisv1.CreateUserRequest{Name: "totp", Email: "totp@example.com", Password: "password1"})
+		Expect(err).Should(BeNil())
+		user, err := userService.GetUser(ctx, "totp")
+		Expect(err).Should(BeNil())
+
+		_, err = userService.ActivateTOTP(ctx, user, "123456")
+		Expect(err).Should(Equal(bcode.ErrTOTPNotEnrolled))
+		enroll, err := userService.EnrollTOTP(ctx, user)
+		Expect(err).Should(BeNil())
+		Expect(enroll.URI).Should(ContainSubstring("secret=" + enroll.Secret))
+		Expect(userService.VerifyTOTP(ctx, user, "123456")).Should(Equal(bcode.ErrTOTPNotEnabled))
+
+		code, err := utils.GenerateTOTPCode(enroll.Secret, time.Now())
+		Expect(err).Should(BeNil())
+		codes, err := userService.ActivateTOTP(ctx, user, code)
+		Expect(err).Should(BeNil())
+		Expect(len(codes.RecoveryCodes)).Should(Equal(recoveryCodeCount))
+		_, err = userService.EnrollTOTP(ctx, user)
+		Expect(err).Should(Equal(bcode.ErrTOTPAlreadyEnabled))
+
+		// the code accepted by the activation can not be used again
+		Expect(userService.VerifyTOTP(ctx, user, code)).Should(Equal(bcode.ErrInvalidTOTPCode))
+		next := time.Now().Add(utils.TOTPPeriod)
+		nextCode, err := utils.GenerateTOTPCode(enroll.Secret, next)
+		Expect(err).Should(BeNil())
+		Expect(userService.VerifyTOTP(ctx, user, nextCode)).Should(BeNil())
+		Expect(userService.VerifyTOTP(ctx, user, nextCode)).Should(Equal(bcode.ErrInvalidTOTPCode))
+		Expect(user.FailedLoginAttempts).Should(Equal(1))
+		Expect(userService.VerifyTOTP(ctx, user, "000000x")).Should(Equal(bcode.ErrInvalidTOTPCode))
+		Expect(user.FailedLoginAttempts).Should(Equal(2))
+
+		// the recovery code can be used only once
+		Expect(userService.VerifyTOTP(ctx, user, strings.ToUpper(codes.RecoveryCodes[0]))).Should(BeNil())
+		Expect(user.FailedLoginAttempts).Should(Equal(0))
+		Expect(userService.VerifyTOTP(ctx, user, codes.RecoveryCodes[0])).Should(Equal(bcode.ErrInvalidTOTPCode))
+		user, err = userService.GetUser(ctx, "totp")
+		Expect(err).Should(BeNil())
+		Expect(len(user.RecoveryCodes)).Should(Equal(recoveryCodeCount - 1))
+		Expect(user.TOTPLastStep).Should(Equal(utils.TOTPStep(next)))
+
+		Expect(userService.DisableTOTP(ctx, user)).Should(BeNil())
+		Expect(user.TOTPEnabled).Should(BeFalse())
+		Expect(userService.DisableTOTP(ctx, user)).Should(Equal(bcode.ErrTOTPNotEnabled))
+	})
 })
@@ -46,6 +46,7 @@ import (
 	"github.com/oam-dev/kubevela/pkg/utils/apply"
 	"github.com/oam-dev/kubevela/pkg/workflow/operation"
 
+	pluginTypes "github.com/kubevela/velaux/pkg/plugin/types"
 	"github.com/kubevela/velaux/pkg/server/domain/model"
 	"github.com/kubevela/velaux/pkg/server/domain/repository"
 	"github.com/kubevela/velaux/pkg/server/event/sync/convert"
@@ -101,6 +102,7 @@ type workflowServiceImpl struct {
 	Apply             apply.Applicator    `inject:"apply"`
 	EnvService        EnvService          `inject:""`
 	EnvBindingService EnvBindingService   `inject:""`
+	PluginHookService PluginHookService   `inject:""`
 }
 
 // DeleteWorkflow delete application workflow
@@ -485,6 +487,7 @@ func (w *workflowServiceImpl) SyncWorkflowRecord(ctx context.Context, appPrimary
 		}
 	}
 
+	wasFinished := record.Finished == "true"
 	record.Finished = strconv.FormatBool(status.Finished)
 	record.EndTime = status.EndTime.Time
 	if err := w.Store.Put(ctx, record); err != nil {
@@ -501,6 +504,9 @@ func (w *workflowServiceImpl) SyncWorkflowRecord(ctx context.Context, appPrimary
 
 	if record.Finished == "true" {
 		klog.InfoS("successfully sync workflow status", "oam app name", app.Name, "workflow name", record.WorkflowName, "record name", record.Name, "status", record.Status, "sync source", app.Name)
+		if !wasFinished {
+			w.notifyRecordFinished(ctx, record)
+		}
 	}
 
 	unfinished := &model.WorkflowRecord{
@@ -1087,6 +1093,21 @@ func convertWorkflowStep(stepStatus model.StepStatus) workflowv1alpha1.StepStatu
 	}
 }
 
+// notifyRecordFinished call the plugin hooks after the workflow record is finished
+func (w *workflowServiceImpl) notifyRecordFinished(ctx context.Context, record *model.WorkflowRecord) {
+	if w.PluginHookService == nil {
+		return
+	}
+	payload := NewPluginHookPayload(ctx, pluginTypes.HookEventWorkflowRecordFinished)
+	appModel := &model.Application{Name: record.AppPrimaryKey}
+	if err := w.Store.Get(ctx, appModel); err == nil {
+		payload.Project = &apisv1.ProjectBase{Name: appModel.Project}
+		payload.Application = assembler.ConvertAppModelToBase(appModel, nil)
+	}
+	payload.WorkflowRecord = &assembler.ConvertFromRecordModel(record).WorkflowRecordBase
+	w.PluginHookService.Notify(ctx, pluginTypes.HookEventWorkflowRecordFinished, payload)
+}
+
 // NewTestWorkflowService create the workflow service instance for testing
 func NewTestWorkflowService(ds datastore.DataStore, c client.Client) WorkflowService {
 	return &workflowServiceImpl{
@@ -23,6 +23,9 @@ import (
 
 	"github.com/kubevela/velaux/pkg/server/config"
 	"github.com/kubevela/velaux/pkg/server/event/collect"
+	"github.com/kubevela/velaux/pkg/server/event/monitor"
+	"github.com/kubevela/velaux/pkg/server/event/purge"
+	"github.com/kubevela/velaux/pkg/server/event/reconcile"
 	"github.com/kubevela/velaux/pkg/server/event/sync"
 )
 
@@ -39,8 +42,13 @@ func InitEvent(cfg config.Config) []interface{} {
 		Queue: workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter()),
 	}
 	collect := &collect.InfoCalculateCronJob{}
-	workers = append(workers, application, collect)
-	return []interface{}{application, collect}
+	projectPurge := &purge.ProjectPurgeWorker{}
+	namespaceReconcile := &reconcile.NamespaceReconcileWorker{Interval: cfg.NamespaceReconcileInterval}
+	clusterProbe := &monitor.ClusterProbeWorker{Interval: cfg.ClusterHealth.ProbeInterval}
+	cloudShellReap := &purge.CloudShellReapWorker{Interval: cfg.CloudShell.ReapInterval}
+	configDistributionCheck := &reconcile.ConfigDistributionCheckWorker{Interval: cfg.ConfigDistributionCheckInterval}
+	workers = append(workers, application, collect, projectPurge, namespaceReconcile, clusterProbe, cloudShellReap, configDistributionCheck)
+	return []interface{}{application, collect, projectPurge, namespaceReconcile, clusterProbe, cloudShellReap, configDistributionCheck}
 }
 
 // StartEventWorker start all event worker
@@ -0,0 +1,46 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package monitor
+
+import (
+	"context"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/klog/v2"
+
+	"github.com/kubevela/velaux/pkg/server/domain/service"
+)
+
+// ClusterProbeWorker probe the health of the clusters periodically and record the history
+type ClusterProbeWorker struct {
+	ClusterService service.ClusterService `inject:""`
+	Interval       time.Duration
+}
+
+// Start start the worker
+func (c *ClusterProbeWorker) Start(ctx context.Context, errChan chan error) {
+	if c.Interval <= 0 {
+		klog.Infof("the cluster prober is disabled")
+		return
+	}
+	wait.UntilWithContext(ctx, func(ctx context.Context) {
+		if err := c.ClusterService.ProbeClusters(ctx); err != nil {
+			klog.Errorf("failed to probe the clusters: %s", err.Error())
+		}
+	}, c.Interval)
+}
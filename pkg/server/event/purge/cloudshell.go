@@ -0,0 +1,50 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package purge
+
+import (
+	"context"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/klog/v2"
+
+	"github.com/kubevela/velaux/pkg/server/domain/service"
+)
+
+// CloudShellReapWorker delete the idle and the expired cloud shells and revoke their kubeconfigs,
+// and revoke the privileges of the expired kubeconfigs issued to the users
+type CloudShellReapWorker struct {
+	CloudShellService service.CloudShellService `inject:""`
+	Interval          time.Duration
+}
+
+// Start start the worker
+func (c *CloudShellReapWorker) Start(ctx context.Context, errChan chan error) {
+	if c.Interval <= 0 {
+		klog.Infof("the cloud shell reaper is disabled")
+		return
+	}
+	wait.UntilWithContext(ctx, func(ctx context.Context) {
+		if err := c.CloudShellService.ReapExpiredCloudShells(ctx); err != nil {
+			klog.Errorf("failed to reap the expired cloud shells: %s", err.Error())
+		}
+		if err := c.CloudShellService.ReapExpiredKubeConfigs(ctx); err != nil {
+			klog.Errorf("failed to revoke the privileges of the expired kubeconfigs: %s", err.Error())
+		}
+	}, c.Interval)
+}
@@ -0,0 +1,44 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package purge
+
+import (
+	"context"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/klog/v2"
+
+	"github.com/kubevela/velaux/pkg/server/domain/service"
+)
+
+// ProjectPurgeInterval the interval of checking the archived projects
+var ProjectPurgeInterval = time.Hour
+
+// ProjectPurgeWorker purge the archived projects after the retention period, and resume the interrupted deletions
+type ProjectPurgeWorker struct {
+	ProjectService service.ProjectService `inject:""`
+}
+
+// Start start the worker
+func (p *ProjectPurgeWorker) Start(ctx context.Context, errChan chan error) {
+	wait.UntilWithContext(ctx, func(ctx context.Context) {
+		if err := p.ProjectService.PurgeArchivedProjects(ctx); err != nil {
+			klog.Errorf("failed to purge the archived projects: %s", err.Error())
+		}
+	}, ProjectPurgeInterval)
+}
@@ -0,0 +1,47 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reconcile
+
+import (
+	"context"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/klog/v2"
+
+	"github.com/kubevela/velaux/pkg/server/domain/service"
+)
+
+// ConfigDistributionCheckWorker check the configs delivered by the distributions periodically, the failed and the drifted
+// targets are recorded, they are not repaired until the distribution is resynced.
+type ConfigDistributionCheckWorker struct {
+	ConfigService service.ConfigService `inject:""`
+	Interval      time.Duration
+}
+
+// Start start the worker
+func (c *ConfigDistributionCheckWorker) Start(ctx context.Context, errChan chan error) {
+	if c.Interval <= 0 {
+		klog.Infof("the config distribution checker is disabled")
+		return
+	}
+	wait.UntilWithContext(ctx, func(ctx context.Context) {
+		if err := c.ConfigService.CheckConfigDistributions(ctx); err != nil {
+			klog.Errorf("failed to check the config distributions: %s", err.Error())
+		}
+	}, c.Interval)
+}
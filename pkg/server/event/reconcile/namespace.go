@@ -0,0 +1,46 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reconcile
+
+import (
+	"context"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/klog/v2"
+
+	"github.com/kubevela/velaux/pkg/server/domain/service"
+)
+
+// NamespaceReconcileWorker verify the namespaces and the privileges of the targets and the envs periodically
+type NamespaceReconcileWorker struct {
+	NamespaceReconcileService service.NamespaceReconcileService `inject:""`
+	Interval                  time.Duration
+}
+
+// Start start the worker
+func (n *NamespaceReconcileWorker) Start(ctx context.Context, errChan chan error) {
+	if n.Interval <= 0 {
+		klog.Infof("the namespace reconciliation is disabled")
+		return
+	}
+	wait.UntilWithContext(ctx, func(ctx context.Context) {
+		if err := n.NamespaceReconcileService.ReconcileNamespaces(ctx); err != nil {
+			klog.Errorf("failed to reconcile the namespaces: %s", err.Error())
+		}
+	}, n.Interval)
+}
@@ -0,0 +1,166 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package secretstore
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// fileStore stores all secrets in a file encrypted by AES-256-GCM, the key is derived from the content of the key file.
+// The file is local to the apiserver, so it only fits the deployment with one replica or a shared volume.
+type fileStore struct {
+	filePath string
+	keyPath  string
+	lock     sync.Mutex
+}
+
+// NewFileStore returns the store backed by an encrypted file
+func NewFileStore(c Config) SecretStore {
+	return &fileStore{filePath: c.FilePath, keyPath: c.FileKeyPath}
+}
+
+func (f *fileStore) Type() string {
+	return TypeFile
+}
+
+func (f *fileStore) Put(_ context.Context, key string, data map[string]string) error {
+	if err := CheckKey(key); err != nil {
+		return err
+	}
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	secrets, err := f.load()
+	if err != nil {
+		return err
+	}
+	secrets[key] = data
+	return f.save(secrets)
+}
+
+func (f *fileStore) Get(_ context.Context, key string) (map[string]string, error) {
+	if err := CheckKey(key); err != nil {
+		return nil, err
+	}
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	secrets, err := f.load()
+	if err != nil {
+		return nil, err
+	}
+	data, ok := secrets[key]
+	if !ok {
+		return nil, ErrSecretNotExist
+	}
+	return data, nil
+}
+
+func (f *fileStore) Delete(_ context.Context, key string) error {
+	if err := CheckKey(key); err != nil {
+		return err
+	}
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	secrets, err := f.load()
+	if err != nil {
+		return err
+	}
+	if _, ok := secrets[key]; !ok {
+		return nil
+	}
+	delete(secrets, key)
+	return f.save(secrets)
+}
+
+func (f *fileStore) newCipher() (cipher.AEAD, error) {
+	content, err := os.ReadFile(f.keyPath)
+	if err != nil {
+		return nil, fmt.Errorf("fail to read the key of the secret store: %w", err)
+	}
+	key := strings.TrimSpace(string(content))
+	if key == "" {
+		return nil, fmt.Errorf("the key of the secret store is empty")
+	}
+	sum := sha256.Sum256([]byte(key))
+	block, err := aes.NewCipher(sum[:])
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func (f *fileStore) load() (map[string]map[string]string, error) {
+	secrets := map[string]map[string]string{}
+	content, err := os.ReadFile(f.filePath)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return secrets, nil
+		}
+		return nil, err
+	}
+	aead, err := f.newCipher()
+	if err != nil {
+		return nil, err
+	}
+	if len(content) < aead.NonceSize() {
+		return nil, fmt.Errorf("the secret store file is invalid")
+	}
+	plain, err := aead.Open(nil, content[:aead.NonceSize()], content[aead.NonceSize():], nil)
+	if err != nil {
+		return nil, fmt.Errorf("fail to decrypt the secret store file: %w", err)
+	}
+	if err := json.Unmarshal(plain, &secrets); err != nil {
+		return nil, fmt.Errorf("fail to parse the secret store file: %w", err)
+	}
+	return secrets, nil
+}
+
+func (f *fileStore) save(secrets map[string]map[string]string) error {
+	aead, err := f.newCipher()
+	if err != nil {
+		return err
+	}
+	plain, err := json.Marshal(secrets)
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return err
+	}
+	content := aead.Seal(nonce, nonce, plain, nil)
+	// write to a temporary file and rename it, the file is never left half written
+	if err := os.MkdirAll(filepath.Dir(f.filePath), 0700); err != nil {
+		return err
+	}
+	tmp := f.filePath + ".tmp"
+	if err := os.WriteFile(tmp, content, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, f.filePath)
+}
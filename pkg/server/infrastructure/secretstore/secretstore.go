@@ -0,0 +1,111 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package secretstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+)
+
+const (
+	// TypeVault the secrets are stored in the KV version 2 secrets engine of a vault server
+	TypeVault = "vault"
+	// TypeFile the secrets are stored in a local file encrypted by AES-GCM
+	TypeFile = "file"
+)
+
+// ErrSecretNotExist the secret is not found in the store
+var ErrSecretNotExist = errors.New("the secret does not exist")
+
+// ErrInvalidKey the key of the secret is not a clean relative path, it could escape the path of the store
+var ErrInvalidKey = errors.New("the key of the secret is invalid")
+
+// CheckKey check the key is a clean relative path without the parent references
+func CheckKey(key string) error {
+	if key == "" || strings.Contains(key, "..") || strings.HasPrefix(key, "/") || path.Clean(key) != key {
+		return fmt.Errorf("%w: %q", ErrInvalidKey, key)
+	}
+	return nil
+}
+
+// Config the config of the external secret store
+type Config struct {
+	// Type the type of the store, support vault and file, empty means the sensitive properties are kept in the config secrets
+	Type string
+	// VaultAddress the address of the vault server
+	VaultAddress string
+	// VaultToken the token to access the vault server, the VAULT_TOKEN environment variable is used if it is empty
+	VaultToken string
+	// VaultMount the mount path of the KV version 2 secrets engine
+	VaultMount string
+	// VaultPathPrefix the secrets are stored under this path of the secrets engine
+	VaultPathPrefix string
+	// FilePath the path of the encrypted file
+	FilePath string
+	// FileKeyPath the path of the file that contains the encryption key
+	FileKeyPath string
+}
+
+// Validate check the required fields of the store type
+func (c Config) Validate() error {
+	switch c.Type {
+	case "":
+		return nil
+	case TypeVault:
+		if c.VaultAddress == "" {
+			return fmt.Errorf("the address of the vault server is required by the vault secret store")
+		}
+		if c.VaultMount == "" {
+			return fmt.Errorf("the mount path of the secrets engine is required by the vault secret store")
+		}
+		return nil
+	case TypeFile:
+		if c.FilePath == "" || c.FileKeyPath == "" {
+			return fmt.Errorf("the path of the file and the key file are required by the file secret store")
+		}
+		return nil
+	default:
+		return fmt.Errorf("not support secret store type %s", c.Type)
+	}
+}
+
+// SecretStore stores the sensitive properties of the configs out of the cluster, every secret is a set of key-value pairs
+type SecretStore interface {
+	// Type returns the type of the store
+	Type() string
+	// Put create or replace the secret
+	Put(ctx context.Context, key string, data map[string]string) error
+	// Get returns the secret, ErrSecretNotExist is returned if it is not found
+	Get(ctx context.Context, key string) (map[string]string, error)
+	// Delete remove the secret, it is not an error if the secret is not found
+	Delete(ctx context.Context, key string) error
+}
+
+// New returns the secret store of the config, nil means no external store is configured
+func New(c Config) SecretStore {
+	switch c.Type {
+	case TypeVault:
+		return NewVaultStore(c)
+	case TypeFile:
+		return NewFileStore(c)
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,150 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package secretstore
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// newVaultServer returns a stand-in server of the KV version 2 secrets engine mounted on the secret path
+func newVaultServer(token string) *httptest.Server {
+	var lock sync.Mutex
+	secrets := map[string]map[string]string{}
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		defer lock.Unlock()
+		if r.Header.Get("X-Vault-Token") != token {
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"errors":["permission denied"]}`))
+			return
+		}
+		switch {
+		case strings.HasPrefix(r.URL.Path, "/v1/secret/data/"):
+			key := strings.TrimPrefix(r.URL.Path, "/v1/secret/data/")
+			switch r.Method {
+			case http.MethodPost:
+				var body struct {
+					Data map[string]string `json:"data"`
+				}
+				if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+					w.WriteHeader(http.StatusBadRequest)
+					return
+				}
+				secrets[key] = body.Data
+				_, _ = w.Write([]byte(`{"data":{"version":1}}`))
+			case http.MethodGet:
+				data, ok := secrets[key]
+				if !ok {
+					w.WriteHeader(http.StatusNotFound)
+					_, _ = w.Write([]byte(`{"errors":[]}`))
+					return
+				}
+				_ = json.NewEncoder(w).Encode(map[string]interface{}{"data": map[string]interface{}{"data": data}})
+			}
+		case strings.HasPrefix(r.URL.Path, "/v1/secret/metadata/") && r.Method == http.MethodDelete:
+			delete(secrets, strings.TrimPrefix(r.URL.Path, "/v1/secret/metadata/"))
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func testSecretStore(store SecretStore) {
+	_, err := store.Get(context.TODO(), "vela-system/registry")
+	Expect(err).Should(Equal(ErrSecretNotExist))
+
+	Expect(store.Put(context.TODO(), "vela-system/registry", map[string]string{"password": "p@ss"})).Should(Succeed())
+	Expect(store.Put(context.TODO(), "project-a/registry", map[string]string{"token": "t0ken"})).Should(Succeed())
+	data, err := store.Get(context.TODO(), "vela-system/registry")
+	Expect(err).Should(BeNil())
+	Expect(data).Should(Equal(map[string]string{"password": "p@ss"}))
+
+	Expect(store.Put(context.TODO(), "vela-system/registry", map[string]string{"password": "changed"})).Should(Succeed())
+	data, err = store.Get(context.TODO(), "vela-system/registry")
+	Expect(err).Should(BeNil())
+	Expect(data).Should(Equal(map[string]string{"password": "changed"}))
+
+	Expect(store.Delete(context.TODO(), "vela-system/registry")).Should(Succeed())
+	Expect(store.Delete(context.TODO(), "vela-system/registry")).Should(Succeed())
+	_, err = store.Get(context.TODO(), "vela-system/registry")
+	Expect(err).Should(Equal(ErrSecretNotExist))
+	data, err = store.Get(context.TODO(), "project-a/registry")
+	Expect(err).Should(BeNil())
+	Expect(data).Should(Equal(map[string]string{"token": "t0ken"}))
+
+	for _, key := range []string{"../../other/registry", "vela-system/../registry", "/vela-system/registry", ""} {
+		Expect(errors.Is(store.Put(context.TODO(), key, map[string]string{"token": "t0ken"}), ErrInvalidKey)).Should(BeTrue())
+		_, err = store.Get(context.TODO(), key)
+		Expect(errors.Is(err, ErrInvalidKey)).Should(BeTrue())
+		Expect(errors.Is(store.Delete(context.TODO(), key), ErrInvalidKey)).Should(BeTrue())
+	}
+}
+
+var _ = Describe("Test the secret stores", func() {
+	It("Test validating the config", func() {
+		Expect(Config{}.Validate()).Should(BeNil())
+		Expect(Config{Type: "unknown"}.Validate()).ShouldNot(BeNil())
+		Expect(Config{Type: TypeVault, VaultMount: "secret"}.Validate()).ShouldNot(BeNil())
+		Expect(Config{Type: TypeVault, VaultAddress: "http://127.0.0.1:8200", VaultMount: "secret"}.Validate()).Should(BeNil())
+		Expect(Config{Type: TypeFile, FilePath: "secrets"}.Validate()).ShouldNot(BeNil())
+		Expect(New(Config{})).Should(BeNil())
+		Expect(New(Config{Type: TypeFile}).Type()).Should(Equal(TypeFile))
+	})
+
+	It("Test the vault store", func() {
+		server := newVaultServer("root")
+		defer server.Close()
+		testSecretStore(NewVaultStore(Config{VaultAddress: server.URL + "/", VaultToken: "root", VaultMount: "secret", VaultPathPrefix: "velaux"}))
+
+		store := NewVaultStore(Config{VaultAddress: server.URL, VaultToken: "invalid", VaultMount: "secret"})
+		_, err := store.Get(context.TODO(), "vela-system/registry")
+		Expect(err).ShouldNot(BeNil())
+		Expect(err.Error()).Should(ContainSubstring("permission denied"))
+	})
+
+	It("Test the file store", func() {
+		dir, err := os.MkdirTemp("", "secret-store")
+		Expect(err).Should(BeNil())
+		defer func() {
+			_ = os.RemoveAll(dir)
+		}()
+		keyPath := filepath.Join(dir, "key")
+		Expect(os.WriteFile(keyPath, []byte("encryption-key\n"), 0600)).Should(Succeed())
+		filePath := filepath.Join(dir, "store", "secrets")
+		testSecretStore(NewFileStore(Config{FilePath: filePath, FileKeyPath: keyPath}))
+
+		content, err := os.ReadFile(filePath)
+		Expect(err).Should(BeNil())
+		Expect(string(content)).ShouldNot(ContainSubstring("t0ken"))
+
+		Expect(os.WriteFile(keyPath, []byte("another-key"), 0600)).Should(Succeed())
+		_, err = NewFileStore(Config{FilePath: filePath, FileKeyPath: keyPath}).Get(context.TODO(), "project-a/registry")
+		Expect(err).ShouldNot(BeNil())
+	})
+})
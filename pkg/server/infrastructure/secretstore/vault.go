@@ -0,0 +1,156 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package secretstore
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+	"time"
+)
+
+type vaultStore struct {
+	address    string
+	token      string
+	mount      string
+	pathPrefix string
+	httpClient *http.Client
+}
+
+// NewVaultStore returns the store backed by the KV version 2 secrets engine of a vault server
+func NewVaultStore(c Config) SecretStore {
+	token := c.VaultToken
+	if token == "" {
+		token = os.Getenv("VAULT_TOKEN")
+	}
+	return &vaultStore{
+		address:    strings.TrimSuffix(c.VaultAddress, "/"),
+		token:      token,
+		mount:      strings.Trim(c.VaultMount, "/"),
+		pathPrefix: strings.Trim(c.VaultPathPrefix, "/"),
+		httpClient: &http.Client{Timeout: time.Second * 10},
+	}
+}
+
+type vaultSecret struct {
+	Data *vaultSecretData `json:"data"`
+}
+
+type vaultSecretData struct {
+	Data map[string]string `json:"data"`
+}
+
+type vaultErrors struct {
+	Errors []string `json:"errors"`
+}
+
+func (v *vaultStore) Type() string {
+	return TypeVault
+}
+
+func (v *vaultStore) Put(ctx context.Context, key string, data map[string]string) error {
+	body, err := json.Marshal(map[string]interface{}{"data": data})
+	if err != nil {
+		return err
+	}
+	address, err := v.secretURL("data", key)
+	if err != nil {
+		return err
+	}
+	_, err = v.request(ctx, http.MethodPost, address, body)
+	return err
+}
+
+func (v *vaultStore) Get(ctx context.Context, key string) (map[string]string, error) {
+	address, err := v.secretURL("data", key)
+	if err != nil {
+		return nil, err
+	}
+	content, err := v.request(ctx, http.MethodGet, address, nil)
+	if err != nil {
+		return nil, err
+	}
+	var secret vaultSecret
+	if err := json.Unmarshal(content, &secret); err != nil {
+		return nil, fmt.Errorf("fail to parse the secret from the vault server: %w", err)
+	}
+	// the data of the deleted version is null
+	if secret.Data == nil || secret.Data.Data == nil {
+		return nil, ErrSecretNotExist
+	}
+	return secret.Data.Data, nil
+}
+
+func (v *vaultStore) Delete(ctx context.Context, key string) error {
+	address, err := v.secretURL("metadata", key)
+	if err != nil {
+		return err
+	}
+	// delete the metadata to remove all versions of the secret
+	if _, err := v.request(ctx, http.MethodDelete, address, nil); err != nil && !errors.Is(err, ErrSecretNotExist) {
+		return err
+	}
+	return nil
+}
+
+// secretURL returns the address of the secret, the key is checked so the path never escapes the mount and the prefix
+func (v *vaultStore) secretURL(api, key string) (string, error) {
+	if err := CheckKey(key); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s/v1/%s", v.address, path.Join(v.mount, api, v.pathPrefix, key)), nil
+}
+
+func (v *vaultStore) request(ctx context.Context, method, address string, body []byte) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, method, address, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Vault-Token", v.token)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	res, err := v.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fail to request the vault server: %w", err)
+	}
+	defer func() {
+		_ = res.Body.Close()
+	}()
+	content, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case res.StatusCode == http.StatusNotFound:
+		return nil, ErrSecretNotExist
+	case res.StatusCode >= http.StatusBadRequest:
+		var vaultErr vaultErrors
+		if err := json.Unmarshal(content, &vaultErr); err == nil && len(vaultErr.Errors) > 0 {
+			return nil, fmt.Errorf("the vault server responds %d: %s", res.StatusCode, strings.Join(vaultErr.Errors, "; "))
+		}
+		return nil, fmt.Errorf("the vault server responds %d", res.StatusCode)
+	}
+	return content, nil
+}
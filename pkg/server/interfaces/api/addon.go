@@ -17,18 +17,27 @@ limitations under the License.
 package api
 
 import (
+	"encoding/json"
+	"fmt"
+	"net/http"
 	"strconv"
+	"time"
 
 	restfulspec "github.com/emicklei/go-restful-openapi/v2"
 	"github.com/emicklei/go-restful/v3"
 
 	"github.com/oam-dev/kubevela/apis/types"
 
+	"github.com/kubevela/velaux/pkg/server/domain/model"
 	"github.com/kubevela/velaux/pkg/server/domain/service"
 	apis "github.com/kubevela/velaux/pkg/server/interfaces/api/dto/v1"
+	"github.com/kubevela/velaux/pkg/server/utils"
 	"github.com/kubevela/velaux/pkg/server/utils/bcode"
 )
 
+// addonOperationStreamInterval the interval of pushing the progress of the addon operation
+const addonOperationStreamInterval = time.Second
+
 // NewAddon returns addon web service
 func NewAddon() Interface {
 	return &addon{}
@@ -43,6 +52,7 @@ type addon struct {
 	RbacService    service.RBACService    `inject:""`
 	AddonService   service.AddonService   `inject:""`
 	ClusterService service.ClusterService `inject:""`
+	SessionService service.SessionService `inject:""`
 }
 
 func (s *addon) GetWebServiceRoute() *restful.WebService {
@@ -94,34 +104,86 @@ func (s *addon) GetWebServiceRoute() *restful.WebService {
 		Metadata(restfulspec.KeyOpenAPITags, tags).
 		Reads(apis.EnableAddonRequest{}).
 		Filter(s.RbacService.CheckPerm("addon", "enable")).
-		Returns(200, "OK", apis.AddonStatusResponse{}).
+		Returns(200, "OK", apis.AddonOperationBase{}).
 		Returns(400, "Bad Request", bcode.Bcode{}).
 		Param(ws.PathParameter("addonName", "addon name to enable").DataType("string").Required(true)).
-		Writes(apis.AddonStatusResponse{}))
+		Writes(apis.AddonOperationBase{}))
 
 	// disable addon
 	ws.Route(ws.POST("/{addonName}/disable").To(s.disableAddon).
 		Doc("disable an addon").
 		Metadata(restfulspec.KeyOpenAPITags, tags).
-		Returns(200, "OK", apis.AddonStatusResponse{}).
+		Returns(200, "OK", apis.AddonOperationBase{}).
 		Filter(s.RbacService.CheckPerm("addon", "disable")).
 		Returns(400, "Bad Request", bcode.Bcode{}).
 		Param(ws.PathParameter("addonName", "addon name to enable").DataType("string").Required(true)).
 		Param(ws.QueryParameter("force", "force disable an addon").DataType("boolean").Required(false)).
-		Writes(apis.AddonStatusResponse{}))
+		Writes(apis.AddonOperationBase{}))
 
 	// update addon
 	ws.Route(ws.PUT("/{addonName}/update").To(s.updateAddon).
 		Doc("update an addon").
 		Metadata(restfulspec.KeyOpenAPITags, tags).
 		Reads(apis.EnableAddonRequest{}).
-		Returns(200, "OK", apis.AddonStatusResponse{}).
+		Returns(200, "OK", apis.AddonOperationBase{}).
 		Filter(s.RbacService.CheckPerm("addon", "update")).
 		Returns(400, "Bad Request", bcode.Bcode{}).
 		Param(ws.PathParameter("addonName", "addon name to update").DataType("string").Required(true)).
-		Writes(apis.AddonStatusResponse{}))
+		Writes(apis.AddonOperationBase{}))
+
+	// promote the staged rollout of addon
+	ws.Route(ws.POST("/{addonName}/promote").To(s.promoteAddonRollout).
+		Doc("promote the staged rollout of an addon to all target clusters").
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Filter(s.RbacService.CheckPerm("addon", "update")).
+		Param(ws.PathParameter("addonName", "addon name to promote").DataType("string").Required(true)).
+		Returns(200, "OK", apis.AddonOperationBase{}).
+		Returns(400, "Bad Request", bcode.Bcode{}).
+		Writes(apis.AddonOperationBase{}))
+
+	// preview upgrading addon
+	ws.Route(ws.POST("/{addonName}/preview_upgrade").To(s.previewAddonUpgrade).
+		Doc("render the target version of an enabled addon and diff it against the installed addon without applying").
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Reads(apis.EnableAddonRequest{}).
+		Filter(s.RbacService.CheckPerm("addon", "update")).
+		Param(ws.PathParameter("addonName", "addon name to upgrade").DataType("string").Required(true)).
+		Returns(200, "OK", apis.AddonUpgradePreviewResponse{}).
+		Returns(400, "Bad Request", bcode.Bcode{}).
+		Writes(apis.AddonUpgradePreviewResponse{}))
+
+	ws.Route(ws.GET("/{addonName}/operations").To(s.listAddonOperations).
+		Doc("list the operations of an addon, the latest first").
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Filter(s.RbacService.CheckPerm("addon", "detail")).
+		Param(ws.PathParameter("addonName", "addon name to query the operations").DataType("string").Required(true)).
+		Param(ws.QueryParameter("page", "Page for paging").DataType("integer")).
+		Param(ws.QueryParameter("pageSize", "PageSize for paging").DataType("integer")).
+		Returns(200, "OK", apis.ListAddonOperationResponse{}).
+		Returns(400, "Bad Request", bcode.Bcode{}).
+		Writes(apis.ListAddonOperationResponse{}))
+
+	ws.Route(ws.GET("/{addonName}/operations/{operationID}").To(s.detailAddonOperation).
+		Doc("show the progress of an addon operation").
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Filter(s.RbacService.CheckPerm("addon", "detail")).
+		Param(ws.PathParameter("addonName", "addon name of the operation").DataType("string").Required(true)).
+		Param(ws.PathParameter("operationID", "identifier of the operation").DataType("string").Required(true)).
+		Returns(200, "OK", apis.AddonOperationBase{}).
+		Returns(400, "Bad Request", bcode.Bcode{}).
+		Writes(apis.AddonOperationBase{}))
+
+	ws.Route(ws.GET("/{addonName}/operations/{operationID}/stream").To(s.streamAddonOperation).
+		Doc("stream the logs of an addon operation as the server-sent events until the operation is finished").
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Filter(s.RbacService.CheckPerm("addon", "detail")).
+		Produces("text/event-stream", restful.MIME_JSON).
+		Param(ws.PathParameter("addonName", "addon name of the operation").DataType("string").Required(true)).
+		Param(ws.PathParameter("operationID", "identifier of the operation").DataType("string").Required(true)).
+		Returns(200, "OK", nil).
+		Returns(400, "Bad Request", bcode.Bcode{}))
 
-	ws.Filter(authCheckFilter)
+	ws.Filter(authCheckFilter(s.SessionService))
 	return ws
 }
 
@@ -188,25 +250,30 @@ func (s *addon) enableAddon(req *restful.Request, res *restful.Response) {
 	}
 
 	name := req.PathParameter("addonName")
-	err = s.AddonService.EnableAddon(req.Request.Context(), name, createReq)
+	op, err := s.AddonService.EnableAddon(req.Request.Context(), name, createReq)
 	if err != nil {
 		bcode.ReturnError(req, res, err)
 		return
 	}
-
-	s.statusAddon(req, res)
+	if err := res.WriteEntity(op); err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
 }
 
 func (s *addon) disableAddon(req *restful.Request, res *restful.Response) {
 	name := req.PathParameter("addonName")
 	forceParam := req.QueryParameter("force")
 	force, _ := strconv.ParseBool(forceParam)
-	err := s.AddonService.DisableAddon(req.Request.Context(), name, force)
+	op, err := s.AddonService.DisableAddon(req.Request.Context(), name, force)
 	if err != nil {
 		bcode.ReturnError(req, res, err)
 		return
 	}
-	s.statusAddon(req, res)
+	if err := res.WriteEntity(op); err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
 }
 
 func (s *addon) statusAddon(req *restful.Request, res *restful.Response) {
@@ -259,18 +326,136 @@ func (s *addon) updateAddon(req *restful.Request, res *restful.Response) {
 	}
 
 	name := req.PathParameter("addonName")
-	err = s.AddonService.UpdateAddon(req.Request.Context(), name, createReq)
+	op, err := s.AddonService.UpdateAddon(req.Request.Context(), name, createReq)
+	if err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+	if err := res.WriteEntity(op); err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+}
+
+func (s *addon) promoteAddonRollout(req *restful.Request, res *restful.Response) {
+	op, err := s.AddonService.PromoteAddonRollout(req.Request.Context(), req.PathParameter("addonName"))
+	if err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+	if err := res.WriteEntity(op); err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+}
+
+func (s *addon) previewAddonUpgrade(req *restful.Request, res *restful.Response) {
+	var previewReq apis.EnableAddonRequest
+	if err := req.ReadEntity(&previewReq); err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+	if err := validate.Struct(&previewReq); err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+	if previewReq.Clusters != nil {
+		if previewReq.Args == nil {
+			previewReq.Args = make(map[string]interface{})
+		}
+		previewReq.Args[types.ClustersArg] = previewReq.Clusters
+	}
+	preview, err := s.AddonService.PreviewAddonUpgrade(req.Request.Context(), req.PathParameter("addonName"), previewReq)
 	if err != nil {
 		bcode.ReturnError(req, res, err)
 		return
 	}
+	if err := res.WriteEntity(preview); err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+}
 
-	s.statusAddon(req, res)
+func (s *addon) listAddonOperations(req *restful.Request, res *restful.Response) {
+	page, pageSize, err := utils.ExtractPagingParams(req, minPageSize, maxPageSize)
+	if err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+	operations, err := s.AddonService.ListAddonOperations(req.Request.Context(), req.PathParameter("addonName"), page, pageSize)
+	if err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+	if err := res.WriteEntity(operations); err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+}
+
+func (s *addon) detailAddonOperation(req *restful.Request, res *restful.Response) {
+	op, err := s.AddonService.GetAddonOperation(req.Request.Context(), req.PathParameter("addonName"), req.PathParameter("operationID"))
+	if err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+	if err := res.WriteEntity(op); err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+}
+
+// streamAddonOperation push the logs of the operation as the server-sent events until the operation is finished
+func (s *addon) streamAddonOperation(req *restful.Request, res *restful.Response) {
+	ctx := req.Request.Context()
+	name, operationID := req.PathParameter("addonName"), req.PathParameter("operationID")
+	op, err := s.AddonService.GetAddonOperation(ctx, name, operationID)
+	if err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+	res.Header().Set("Content-Type", "text/event-stream")
+	res.Header().Set("Cache-Control", "no-cache")
+	res.WriteHeader(http.StatusOK)
+	var sent int
+	for {
+		for ; sent < len(op.Logs); sent++ {
+			if err := writeServerSentEvent(res, "log", op.Logs[sent]); err != nil {
+				return
+			}
+		}
+		if op.Phase == model.AddonOperationSucceeded || op.Phase == model.AddonOperationFailed {
+			_ = writeServerSentEvent(res, "result", op)
+			return
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(addonOperationStreamInterval):
+		}
+		if op, err = s.AddonService.GetAddonOperation(ctx, name, operationID); err != nil {
+			_ = writeServerSentEvent(res, "error", err.Error())
+			return
+		}
+	}
+}
+
+func writeServerSentEvent(res *restful.Response, event string, data interface{}) error {
+	body, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(res, "event: %s\ndata: %s\n\n", event, body); err != nil {
+		return err
+	}
+	res.Flush()
+	return nil
 }
 
 type enabledAddon struct {
-	AddonService service.AddonService `inject:""`
-	RbacService  service.RBACService  `inject:""`
+	AddonService   service.AddonService   `inject:""`
+	RbacService    service.RBACService    `inject:""`
+	SessionService service.SessionService `inject:""`
 }
 
 func (s *enabledAddon) GetWebServiceRoute() *restful.WebService {
@@ -292,7 +477,7 @@ func (s *enabledAddon) GetWebServiceRoute() *restful.WebService {
 		Returns(400, "Bad Request", bcode.Bcode{}).
 		Writes(apis.ListAddonResponse{}))
 
-	ws.Filter(authCheckFilter)
+	ws.Filter(authCheckFilter(s.SessionService))
 	return ws
 }
 
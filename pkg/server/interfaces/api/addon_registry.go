@@ -17,6 +17,8 @@ limitations under the License.
 package api
 
 import (
+	"io"
+
 	restfulspec "github.com/emicklei/go-restful-openapi/v2"
 	"github.com/emicklei/go-restful/v3"
 
@@ -31,8 +33,9 @@ func NewAddonRegistry() Interface {
 }
 
 type addonRegistry struct {
-	AddonService service.AddonService `inject:""`
-	RbacService  service.RBACService  `inject:""`
+	AddonService   service.AddonService   `inject:""`
+	RbacService    service.RBACService    `inject:""`
+	SessionService service.SessionService `inject:""`
 }
 
 func (s *addonRegistry) GetWebServiceRoute() *restful.WebService {
@@ -82,7 +85,47 @@ func (s *addonRegistry) GetWebServiceRoute() *restful.WebService {
 		Returns(400, "Bad Request", bcode.Bcode{}).
 		Writes(apis.AddonRegistry{}))
 
-	ws.Filter(authCheckFilter)
+	ws.Route(ws.POST("/{addonRegName}/addons").To(s.uploadOfflineAddon).
+		Doc("upload a packaged addon(*.tgz) to the offline addon registry").
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Consumes(mimeGzip, restful.MIME_OCTET).
+		Filter(s.RbacService.CheckPerm("addonRegistry", "update")).
+		Param(ws.PathParameter("addonRegName", "identifier of the addon registry").DataType("string")).
+		Param(ws.QueryParameter("sha256", "the expected sha256 checksum of the addon package").DataType("string")).
+		Returns(200, "OK", apis.OfflineAddonVersion{}).
+		Returns(400, "Bad Request", bcode.Bcode{}).
+		Writes(apis.OfflineAddonVersion{}))
+
+	ws.Route(ws.GET("/{addonRegName}/addons").To(s.listOfflineAddons).
+		Doc("list the addon versions stored in the offline addon registry").
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Filter(s.RbacService.CheckPerm("addonRegistry", "list")).
+		Param(ws.PathParameter("addonRegName", "identifier of the addon registry").DataType("string")).
+		Returns(200, "OK", apis.ListOfflineAddonResponse{}).
+		Returns(400, "Bad Request", bcode.Bcode{}).
+		Writes(apis.ListOfflineAddonResponse{}))
+
+	ws.Route(ws.DELETE("/{addonRegName}/addons/{addonName}/versions/{version}").To(s.deleteOfflineAddon).
+		Doc("delete an addon version from the offline addon registry").
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Filter(s.RbacService.CheckPerm("addonRegistry", "update")).
+		Param(ws.PathParameter("addonRegName", "identifier of the addon registry").DataType("string")).
+		Param(ws.PathParameter("addonName", "identifier of the addon").DataType("string")).
+		Param(ws.PathParameter("version", "the version of the addon").DataType("string")).
+		Returns(200, "OK", apis.EmptyResponse{}).
+		Returns(400, "Bad Request", bcode.Bcode{}).
+		Writes(apis.EmptyResponse{}))
+
+	ws.Route(ws.POST("/{addonRegName}/sync").To(s.syncOfflineAddonRegistry).
+		Doc("import the addons from the source directory of the offline addon registry").
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Filter(s.RbacService.CheckPerm("addonRegistry", "update")).
+		Param(ws.PathParameter("addonRegName", "identifier of the addon registry").DataType("string")).
+		Returns(200, "OK", apis.SyncOfflineAddonRegistryResponse{}).
+		Returns(400, "Bad Request", bcode.Bcode{}).
+		Writes(apis.SyncOfflineAddonRegistryResponse{}))
+
+	ws.Filter(authCheckFilter(s.SessionService))
 	return ws
 }
 
@@ -166,3 +209,64 @@ func (s *addonRegistry) updateAddonRegistry(req *restful.Request, res *restful.R
 		return
 	}
 }
+
+func (s *addonRegistry) uploadOfflineAddon(req *restful.Request, res *restful.Response) {
+	if req.Request.ContentLength > maxOfflineAddonSize {
+		bcode.ReturnError(req, res, bcode.ErrOfflineAddonInvalidPackage.SetMessage("the addon package is too large"))
+		return
+	}
+	data, err := io.ReadAll(io.LimitReader(req.Request.Body, maxOfflineAddonSize+1))
+	if err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+	if len(data) > maxOfflineAddonSize {
+		bcode.ReturnError(req, res, bcode.ErrOfflineAddonInvalidPackage.SetMessage("the addon package is too large"))
+		return
+	}
+	version, err := s.AddonService.UploadOfflineAddon(req.Request.Context(), req.PathParameter("addonRegName"), data, req.QueryParameter("sha256"))
+	if err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+	if err := res.WriteEntity(version); err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+}
+
+func (s *addonRegistry) listOfflineAddons(req *restful.Request, res *restful.Response) {
+	addons, err := s.AddonService.ListOfflineAddons(req.Request.Context(), req.PathParameter("addonRegName"))
+	if err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+	if err := res.WriteEntity(addons); err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+}
+
+func (s *addonRegistry) deleteOfflineAddon(req *restful.Request, res *restful.Response) {
+	err := s.AddonService.DeleteOfflineAddon(req.Request.Context(), req.PathParameter("addonRegName"), req.PathParameter("addonName"), req.PathParameter("version"))
+	if err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+	if err := res.WriteEntity(apis.EmptyResponse{}); err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+}
+
+func (s *addonRegistry) syncOfflineAddonRegistry(req *restful.Request, res *restful.Response) {
+	result, err := s.AddonService.SyncOfflineAddonRegistry(req.Request.Context(), req.PathParameter("addonRegName"))
+	if err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+	if err := res.WriteEntity(result); err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+}
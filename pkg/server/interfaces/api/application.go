@@ -39,6 +39,7 @@ type application struct {
 	RbacService        service.RBACService        `inject:""`
 	ApplicationService service.ApplicationService `inject:""`
 	EnvBindingService  service.EnvBindingService  `inject:""`
+	SessionService     service.SessionService     `inject:""`
 }
 
 // NewApplication new application manage
@@ -183,6 +184,28 @@ func (c *application) GetWebServiceRoute() *restful.WebService {
 		Returns(400, "Bad Request", bcode.Bcode{}).
 		Writes(apis.ApplicationDeployResponse{}))
 
+	ws.Route(ws.POST("/{appName}/transfer").To(c.transferApplication).
+		Doc("transfer the application to another project, the application CRs are re-homed into the mapped envs").
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Filter(c.RbacService.CheckPerm("application", "transfer")).
+		Filter(c.appCheckFilter).
+		Param(ws.PathParameter("appName", "identifier of the application ").DataType("string")).
+		Reads(apis.TransferApplicationRequest{}).
+		Returns(200, "OK", apis.ApplicationBase{}).
+		Returns(400, "Bad Request", bcode.Bcode{}).
+		Writes(apis.ApplicationBase{}))
+
+	ws.Route(ws.POST("/{appName}/clone").To(c.cloneApplication).
+		Doc("clone the application with a new name, optionally into another project").
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Filter(c.RbacService.CheckPerm("application", "clone")).
+		Filter(c.appCheckFilter).
+		Param(ws.PathParameter("appName", "identifier of the application ").DataType("string")).
+		Reads(apis.CloneApplicationRequest{}).
+		Returns(200, "OK", apis.ApplicationBase{}).
+		Returns(400, "Bad Request", bcode.Bcode{}).
+		Writes(apis.ApplicationBase{}))
+
 	ws.Route(ws.GET("/{appName}/components").To(c.listApplicationComponents).
 		Doc("gets the list of application components").
 		Filter(c.RbacService.CheckPerm("component", "list")).
@@ -676,7 +699,7 @@ func (c *application) GetWebServiceRoute() *restful.WebService {
 		Returns(400, "Bad Request", bcode.Bcode{}).
 		Writes(apis.AppDryRunResponse{}))
 
-	ws.Filter(authCheckFilter)
+	ws.Filter(authCheckFilter(c.SessionService))
 	return ws
 }
 
@@ -849,6 +872,50 @@ func (c *application) deployApplication(req *restful.Request, res *restful.Respo
 	}
 }
 
+func (c *application) transferApplication(req *restful.Request, res *restful.Response) {
+	app := req.Request.Context().Value(&apis.CtxKeyApplication).(*model.Application)
+	var transferReq apis.TransferApplicationRequest
+	if err := req.ReadEntity(&transferReq); err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+	if err := validate.Struct(&transferReq); err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+	base, err := c.ApplicationService.TransferApplication(req.Request.Context(), app, transferReq)
+	if err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+	if err := res.WriteEntity(base); err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+}
+
+func (c *application) cloneApplication(req *restful.Request, res *restful.Response) {
+	app := req.Request.Context().Value(&apis.CtxKeyApplication).(*model.Application)
+	var cloneReq apis.CloneApplicationRequest
+	if err := req.ReadEntity(&cloneReq); err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+	if err := validate.Struct(&cloneReq); err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+	base, err := c.ApplicationService.CloneApplication(req.Request.Context(), app, cloneReq)
+	if err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+	if err := res.WriteEntity(base); err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+}
+
 func (c *application) deleteApplication(req *restful.Request, res *restful.Response) {
 	app := req.Request.Context().Value(&apis.CtxKeyApplication).(*model.Application)
 	err := c.ApplicationService.DeleteApplication(req.Request.Context(), app)
@@ -39,8 +39,10 @@ func ConvertEnvBindingModelToBase(envBinding *model.EnvBinding, env *model.Env,
 			}
 			if dt.Cluster != nil {
 				ebt.Cluster = &apisv1.ClusterTarget{
-					ClusterName: dt.Cluster.ClusterName,
-					Namespace:   dt.Cluster.Namespace,
+					ClusterName:   dt.Cluster.ClusterName,
+					Namespace:     dt.Cluster.Namespace,
+					LabelSelector: dt.Cluster.LabelSelector,
+					ClusterGroup:  dt.Cluster.ClusterGroup,
 				}
 			}
 			envBindingTargets = append(envBindingTargets, ebt)
@@ -334,3 +336,17 @@ func PluginToManagedDTO(p pluginTypes.Plugin, setting model.PluginSetting) apisv
 		SecureJSONFields: secureJSONFields,
 	}
 }
+
+// PluginStorageToDTO convert the plugin storage item to dto
+func PluginStorageToDTO(item *model.PluginStorage) *apisv1.PluginStorageItem {
+	return &apisv1.PluginStorageItem{
+		Key:        item.Key,
+		Scope:      pluginTypes.StorageScope(item.Scope),
+		ScopeName:  item.ScopeName,
+		Value:      item.Value,
+		Size:       item.Size,
+		Creator:    item.Creator,
+		CreateTime: item.CreateTime,
+		UpdateTime: item.UpdateTime,
+	}
+}
@@ -24,6 +24,7 @@ import (
 	restfulspec "github.com/emicklei/go-restful-openapi/v2"
 	"github.com/emicklei/go-restful/v3"
 
+	"github.com/kubevela/velaux/pkg/server/domain/model"
 	"github.com/kubevela/velaux/pkg/server/domain/service"
 	apis "github.com/kubevela/velaux/pkg/server/interfaces/api/dto/v1"
 	"github.com/kubevela/velaux/pkg/server/utils"
@@ -33,6 +34,7 @@ import (
 type authentication struct {
 	AuthenticationService service.AuthenticationService `inject:""`
 	UserService           service.UserService           `inject:""`
+	SessionService        service.SessionService        `inject:""`
 }
 
 // NewAuthentication is the  of authentication
@@ -57,6 +59,14 @@ func (c *authentication) GetWebServiceRoute() *restful.WebService {
 		Returns(400, "", bcode.Bcode{}).
 		Writes(apis.LoginResponse{}))
 
+	ws.Route(ws.POST("/login/mfa").To(c.loginMFA).
+		Doc("verify the TOTP code or the recovery code after the password is verified").
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Reads(apis.LoginMFARequest{}).
+		Returns(200, "", apis.LoginResponse{}).
+		Returns(400, "", bcode.Bcode{}).
+		Writes(apis.LoginResponse{}))
+
 	ws.Route(ws.GET("/dex_config").To(c.getDexConfig).
 		Doc("get Dex config").
 		Metadata(restfulspec.KeyOpenAPITags, tags).
@@ -80,7 +90,7 @@ func (c *authentication) GetWebServiceRoute() *restful.WebService {
 
 	ws.Route(ws.GET("/user_info").To(c.getLoginUserInfo).
 		Doc("get login user detail info").
-		Filter(authCheckFilter).
+		Filter(authCheckFilter(c.SessionService)).
 		Metadata(restfulspec.KeyOpenAPITags, tags).
 		Returns(200, "", apis.LoginUserInfoResponse{}).
 		Returns(400, "", bcode.Bcode{}).
@@ -101,15 +111,71 @@ func (c *authentication) GetWebServiceRoute() *restful.WebService {
 		Returns(400, "", bcode.Bcode{}).
 		Writes(apis.InitAdminResponse{}))
 
+	ws.Route(ws.POST("/totp/enroll").To(c.enrollTOTP).
+		Doc("enroll a TOTP authenticator for the login user, it takes effect after activating").
+		Filter(authCheckFilter(c.SessionService)).
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Returns(200, "", apis.TOTPEnrollResponse{}).
+		Returns(400, "", bcode.Bcode{}).
+		Writes(apis.TOTPEnrollResponse{}))
+
+	ws.Route(ws.POST("/totp/activate").To(c.activateTOTP).
+		Doc("activate the enrolled TOTP authenticator with a valid code, the recovery codes are returned").
+		Filter(authCheckFilter(c.SessionService)).
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Reads(apis.TOTPVerifyRequest{}).
+		Returns(200, "", apis.TOTPRecoveryCodesResponse{}).
+		Returns(400, "", bcode.Bcode{}).
+		Writes(apis.TOTPRecoveryCodesResponse{}))
+
+	ws.Route(ws.POST("/totp/recovery_codes").To(c.generateRecoveryCodes).
+		Doc("regenerate the recovery codes of the login user, the previous codes are invalidated").
+		Filter(authCheckFilter(c.SessionService)).
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Reads(apis.TOTPVerifyRequest{}).
+		Returns(200, "", apis.TOTPRecoveryCodesResponse{}).
+		Returns(400, "", bcode.Bcode{}).
+		Writes(apis.TOTPRecoveryCodesResponse{}))
+
+	ws.Route(ws.POST("/totp/disable").To(c.disableTOTP).
+		Doc("disable the TOTP authenticator of the login user").
+		Filter(authCheckFilter(c.SessionService)).
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Reads(apis.TOTPVerifyRequest{}).
+		Returns(200, "", apis.EmptyResponse{}).
+		Returns(400, "", bcode.Bcode{}).
+		Writes(apis.EmptyResponse{}))
+
+	ws.Route(ws.GET("/sessions").To(c.listSessions).
+		Doc("list the active sessions of the login user").
+		Filter(authCheckFilter(c.SessionService)).
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Returns(200, "", apis.ListSessionResponse{}).
+		Returns(400, "", bcode.Bcode{}).
+		Writes(apis.ListSessionResponse{}))
+
+	ws.Route(ws.DELETE("/sessions/{sessionID}").To(c.revokeSession).
+		Doc("revoke a session of the login user, the tokens of the session are invalid after revoked").
+		Filter(authCheckFilter(c.SessionService)).
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Param(ws.PathParameter("sessionID", "identifier of the session").DataType("string").Required(true)).
+		Returns(200, "", apis.EmptyResponse{}).
+		Returns(400, "", bcode.Bcode{}).
+		Writes(apis.EmptyResponse{}))
+
 	return ws
 }
 
-func authCheckFilter(req *restful.Request, res *restful.Response, chain *restful.FilterChain) {
-	if authTokenCheck(req.Request, res.ResponseWriter) {
-		chain.ProcessFilter(req, res)
+// authCheckFilter checks the token and its session of the request
+func authCheckFilter(sessionService service.SessionService) restful.FilterFunction {
+	return func(req *restful.Request, res *restful.Response, chain *restful.FilterChain) {
+		if authTokenCheck(sessionService, req.Request, res.ResponseWriter) {
+			chain.ProcessFilter(req, res)
+		}
 	}
 }
-func authTokenCheck(req *http.Request, res http.ResponseWriter) bool {
+
+func authTokenCheck(sessionService service.SessionService, req *http.Request, res http.ResponseWriter) bool {
 	// support getting the token from the cookie
 	var tokenValue string
 	tokenHeader := req.Header.Get("Authorization")
@@ -139,16 +205,30 @@ func authTokenCheck(req *http.Request, res http.ResponseWriter) bool {
 		bcode.ReturnHTTPError(req, res, bcode.ErrNotAccessToken)
 		return false
 	}
+	if sessionService != nil {
+		if err := sessionService.CheckSession(withClientInfo(req), token); err != nil {
+			bcode.ReturnHTTPError(req, res, err)
+			return false
+		}
+	}
 	newReq := req.WithContext(context.WithValue(req.Context(), &apis.CtxKeyUser, token.Username))
 	newReq = newReq.WithContext(context.WithValue(newReq.Context(), &apis.CtxKeyToken, tokenValue))
+	newReq = newReq.WithContext(context.WithValue(newReq.Context(), &apis.CtxKeySession, token.SessionID))
 	*req = *newReq
 	return true
 }
 
+// withClientInfo carry the client info of the request in the context, it is recorded in the session
+func withClientInfo(req *http.Request) context.Context {
+	return utils.WithClientInfo(req.Context(), utils.ClientInfo{IP: utils.ClientIP(req), UserAgent: req.UserAgent()})
+}
+
 // AuthTokenCheck Parse the token from the request
-func AuthTokenCheck(req *http.Request, res http.ResponseWriter, chain *utils.FilterChain) {
-	if authTokenCheck(req, res) {
-		chain.ProcessFilter(req, res)
+func AuthTokenCheck(sessionService service.SessionService) func(req *http.Request, res http.ResponseWriter, chain *utils.FilterChain) {
+	return func(req *http.Request, res http.ResponseWriter, chain *utils.FilterChain) {
+		if authTokenCheck(sessionService, req, res) {
+			chain.ProcessFilter(req, res)
+		}
 	}
 }
 
@@ -177,7 +257,11 @@ func (c *authentication) login(req *restful.Request, res *restful.Response) {
 		bcode.ReturnError(req, res, err)
 		return
 	}
-	base, err := c.AuthenticationService.Login(req.Request.Context(), loginReq)
+	if err := validate.Struct(&loginReq); err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+	base, err := c.AuthenticationService.Login(withClientInfo(req.Request), loginReq)
 	if err != nil {
 		bcode.ReturnError(req, res, err)
 		return
@@ -201,7 +285,7 @@ func (c *authentication) getDexConfig(req *restful.Request, res *restful.Respons
 }
 
 func (c *authentication) refreshToken(req *restful.Request, res *restful.Response) {
-	base, err := c.AuthenticationService.RefreshToken(req.Request.Context(), req.HeaderParameter("RefreshToken"))
+	base, err := c.AuthenticationService.RefreshToken(withClientInfo(req.Request), req.HeaderParameter("RefreshToken"))
 	if err != nil {
 		bcode.ReturnError(req, res, err)
 		return
@@ -269,3 +353,162 @@ func (c *authentication) configureAdmin(req *restful.Request, res *restful.Respo
 		return
 	}
 }
+
+func (c *authentication) loginMFA(req *restful.Request, res *restful.Response) {
+	var loginReq apis.LoginMFARequest
+	if err := req.ReadEntity(&loginReq); err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+	if err := validate.Struct(&loginReq); err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+	base, err := c.AuthenticationService.LoginMFA(withClientInfo(req.Request), loginReq)
+	if err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+	if err := res.WriteEntity(base); err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+}
+
+// loginUser get the model of the login user
+func (c *authentication) loginUser(req *restful.Request) (*model.User, error) {
+	userName, ok := req.Request.Context().Value(&apis.CtxKeyUser).(string)
+	if !ok {
+		return nil, bcode.ErrUnauthorized
+	}
+	return c.UserService.GetUser(req.Request.Context(), userName)
+}
+
+// verifyTOTPCode read the code and verify it with the TOTP authenticator of the login user
+func (c *authentication) verifyTOTPCode(req *restful.Request, user *model.User) error {
+	var verifyReq apis.TOTPVerifyRequest
+	if err := req.ReadEntity(&verifyReq); err != nil {
+		return err
+	}
+	if err := validate.Struct(&verifyReq); err != nil {
+		return err
+	}
+	return c.UserService.VerifyTOTP(req.Request.Context(), user, verifyReq.Code)
+}
+
+func (c *authentication) enrollTOTP(req *restful.Request, res *restful.Response) {
+	user, err := c.loginUser(req)
+	if err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+	resp, err := c.UserService.EnrollTOTP(req.Request.Context(), user)
+	if err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+	if err := res.WriteEntity(resp); err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+}
+
+func (c *authentication) activateTOTP(req *restful.Request, res *restful.Response) {
+	user, err := c.loginUser(req)
+	if err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+	var verifyReq apis.TOTPVerifyRequest
+	if err := req.ReadEntity(&verifyReq); err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+	if err := validate.Struct(&verifyReq); err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+	resp, err := c.UserService.ActivateTOTP(req.Request.Context(), user, verifyReq.Code)
+	if err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+	if err := res.WriteEntity(resp); err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+}
+
+func (c *authentication) generateRecoveryCodes(req *restful.Request, res *restful.Response) {
+	user, err := c.loginUser(req)
+	if err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+	if err := c.verifyTOTPCode(req, user); err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+	resp, err := c.UserService.GenerateRecoveryCodes(req.Request.Context(), user)
+	if err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+	if err := res.WriteEntity(resp); err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+}
+
+func (c *authentication) disableTOTP(req *restful.Request, res *restful.Response) {
+	user, err := c.loginUser(req)
+	if err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+	if err := c.verifyTOTPCode(req, user); err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+	if err := c.UserService.DisableTOTP(req.Request.Context(), user); err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+	if err := res.WriteEntity(apis.EmptyResponse{}); err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+}
+
+func (c *authentication) listSessions(req *restful.Request, res *restful.Response) {
+	userName, ok := req.Request.Context().Value(&apis.CtxKeyUser).(string)
+	if !ok {
+		bcode.ReturnError(req, res, bcode.ErrUnauthorized)
+		return
+	}
+	resp, err := c.SessionService.ListSessions(req.Request.Context(), userName)
+	if err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+	if err := res.WriteEntity(resp); err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+}
+
+func (c *authentication) revokeSession(req *restful.Request, res *restful.Response) {
+	userName, ok := req.Request.Context().Value(&apis.CtxKeyUser).(string)
+	if !ok {
+		bcode.ReturnError(req, res, bcode.ErrUnauthorized)
+		return
+	}
+	if err := c.SessionService.RevokeSession(req.Request.Context(), userName, req.PathParameter("sessionID")); err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+	if err := res.WriteEntity(apis.EmptyResponse{}); err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+}
@@ -17,25 +17,32 @@ limitations under the License.
 package api
 
 import (
+	"context"
 	"net/http"
 	"net/http/httputil"
 	"net/url"
 	"strings"
+	"time"
 
 	restfulspec "github.com/emicklei/go-restful-openapi/v2"
 	"github.com/emicklei/go-restful/v3"
 	"github.com/gorilla/websocket"
 	"github.com/koding/websocketproxy"
+	"k8s.io/klog/v2"
 
 	"github.com/kubevela/velaux/pkg/server/domain/service"
 	apis "github.com/kubevela/velaux/pkg/server/interfaces/api/dto/v1"
 	"github.com/kubevela/velaux/pkg/server/utils/bcode"
 )
 
+// cloudShellKeepAliveInterval the interval of recording the activity of the connected cloud shell
+var cloudShellKeepAliveInterval = time.Minute
+
 // CloudShell provide the API for preparing the cloud shell environment
 type CloudShell struct {
 	RbacService       service.RBACService       `inject:""`
 	CloudShellService service.CloudShellService `inject:""`
+	SessionService    service.SessionService    `inject:""`
 }
 
 // NewCloudShell create the cloudshell api instance
@@ -69,10 +76,121 @@ func (c *CloudShell) GetWebServiceRoute() *restful.WebService {
 		Returns(400, "Bad Request", bcode.Bcode{}).
 		Writes(apis.EmptyResponse{}).Do(returns200, returns500))
 
-	ws.Filter(authCheckFilter)
+	ws.Route(ws.GET("/sessions").To(c.listCloudShellSessions).
+		Doc("list the active cloud shells of all users").
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Filter(c.RbacService.CheckPerm("manageCloudshell", "list")).
+		Returns(200, "OK", apis.ListCloudShellSessionResponse{}).
+		Returns(400, "Bad Request", bcode.Bcode{}).
+		Writes(apis.ListCloudShellSessionResponse{}))
+
+	ws.Route(ws.DELETE("/sessions/{userName}").To(c.terminateCloudShell).
+		Doc("terminate the cloud shell of a user and revoke the kubeconfig").
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Param(ws.PathParameter("userName", "identifier of the user").DataType("string")).
+		Filter(c.RbacService.CheckPerm("manageCloudshell", "delete")).
+		Returns(200, "OK", apis.EmptyResponse{}).
+		Returns(400, "Bad Request", bcode.Bcode{}).
+		Writes(apis.EmptyResponse{}))
+
+	ws.Route(ws.POST("/kubeconfigs").To(c.issueKubeConfig).
+		Doc("issue a short-lived kubeconfig to the current user").
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Filter(c.RbacService.CheckPerm("cloudshell", "create")).
+		Reads(apis.IssueKubeConfigRequest{}).
+		Returns(200, "OK", apis.IssueKubeConfigResponse{}).
+		Returns(400, "Bad Request", bcode.Bcode{}).
+		Writes(apis.IssueKubeConfigResponse{}))
+
+	ws.Route(ws.GET("/kubeconfigs").To(c.listIssuedKubeConfigs).
+		Doc("list the kubeconfigs issued to the users").
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Param(ws.QueryParameter("user", "list the kubeconfigs issued to the user").DataType("string")).
+		Filter(c.RbacService.CheckPerm("manageCloudshell", "list")).
+		Returns(200, "OK", apis.ListIssuedKubeConfigResponse{}).
+		Returns(400, "Bad Request", bcode.Bcode{}).
+		Writes(apis.ListIssuedKubeConfigResponse{}))
+
+	ws.Route(ws.DELETE("/kubeconfigs/{id}").To(c.revokeKubeConfig).
+		Doc("revoke the privileges of an issued kubeconfig").
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Param(ws.PathParameter("id", "identifier of the issued kubeconfig").DataType("string")).
+		Filter(c.RbacService.CheckPerm("manageCloudshell", "delete")).
+		Returns(200, "OK", apis.EmptyResponse{}).
+		Returns(400, "Bad Request", bcode.Bcode{}).
+		Writes(apis.EmptyResponse{}))
+
+	ws.Filter(authCheckFilter(c.SessionService))
 	return ws
 }
 
+func (c *CloudShell) issueKubeConfig(req *restful.Request, res *restful.Response) {
+	var issueReq apis.IssueKubeConfigRequest
+	if err := req.ReadEntity(&issueReq); err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+	if err := validate.Struct(&issueReq); err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+	kubeConfig, err := c.CloudShellService.IssueKubeConfig(req.Request.Context(), issueReq)
+	if err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+	if err := res.WriteEntity(kubeConfig); err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+}
+
+func (c *CloudShell) listIssuedKubeConfigs(req *restful.Request, res *restful.Response) {
+	kubeConfigs, err := c.CloudShellService.ListIssuedKubeConfigs(req.Request.Context(), req.QueryParameter("user"))
+	if err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+	if err := res.WriteEntity(kubeConfigs); err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+}
+
+func (c *CloudShell) revokeKubeConfig(req *restful.Request, res *restful.Response) {
+	if err := c.CloudShellService.RevokeKubeConfig(req.Request.Context(), req.PathParameter("id")); err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+	if err := res.WriteEntity(apis.EmptyResponse{}); err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+}
+
+func (c *CloudShell) listCloudShellSessions(req *restful.Request, res *restful.Response) {
+	sessions, err := c.CloudShellService.ListCloudShellSessions(req.Request.Context())
+	if err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+	if err := res.WriteEntity(sessions); err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+}
+
+func (c *CloudShell) terminateCloudShell(req *restful.Request, res *restful.Response) {
+	if err := c.CloudShellService.TerminateCloudShell(req.Request.Context(), req.PathParameter("userName")); err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+	if err := res.WriteEntity(apis.EmptyResponse{}); err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+}
+
 func (c *CloudShell) prepareCloudShell(req *restful.Request, res *restful.Response) {
 	prepare, err := c.CloudShellService.Prepare(req.Request.Context())
 	// Write back response data
@@ -107,6 +225,7 @@ func (c *CloudShell) destroyCloudShell(req *restful.Request, res *restful.Respon
 type CloudShellView struct {
 	RbacService       service.RBACService       `inject:""`
 	CloudShellService service.CloudShellService `inject:""`
+	SessionService    service.SessionService    `inject:""`
 }
 
 // NewCloudShellView new cloud share
@@ -142,7 +261,7 @@ func (c *CloudShellView) GetWebServiceRoute() *restful.WebService {
 		Returns(400, "Bad Request", bcode.Bcode{}).
 		Writes(apis.EmptyResponse{}).Do(returns200, returns500))
 
-	ws.Filter(authCheckFilter)
+	ws.Filter(authCheckFilter(c.SessionService))
 	return ws
 }
 
@@ -168,6 +287,10 @@ func (c *CloudShellView) proxy(req *restful.Request, res *restful.Response) {
 				return true
 			},
 		}
+		// the cloud shell is not idle while the terminal is connected
+		stop := make(chan struct{})
+		defer close(stop)
+		go c.keepAlive(req.Request.Context(), stop)
 		proxy.ServeHTTP(res.ResponseWriter, req.Request)
 		return
 	}
@@ -179,6 +302,22 @@ func (c *CloudShellView) proxy(req *restful.Request, res *restful.Response) {
 	NewReverseProxy(u).ServeHTTP(res.ResponseWriter, req.Request)
 }
 
+// keepAlive record the activity of the cloud shell periodically until the connection is closed
+func (c *CloudShellView) keepAlive(ctx context.Context, stop chan struct{}) {
+	ticker := time.NewTicker(cloudShellKeepAliveInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			if err := c.CloudShellService.KeepAlive(ctx); err != nil {
+				klog.Errorf("failed to keep the cloud shell alive: %s", err.Error())
+			}
+		}
+	}
+}
+
 // NewReverseProxy proxy for requests of the cloud shell
 func NewReverseProxy(target *url.URL) *httputil.ReverseProxy {
 	director := func(req *http.Request) {
@@ -17,6 +17,8 @@ limitations under the License.
 package api
 
 import (
+	"time"
+
 	restfulspec "github.com/emicklei/go-restful-openapi/v2"
 	restful "github.com/emicklei/go-restful/v3"
 
@@ -30,6 +32,7 @@ import (
 type Cluster struct {
 	ClusterService service.ClusterService `inject:""`
 	RbacService    service.RBACService    `inject:""`
+	SessionService service.SessionService `inject:""`
 }
 
 // NewCluster new cluster
@@ -76,6 +79,16 @@ func (c *Cluster) GetWebServiceRoute() *restful.WebService {
 		Returns(400, "Bad Request", bcode.Bcode{}).
 		Writes(apis.DetailClusterResponse{}))
 
+	ws.Route(ws.GET("/{clusterName}/health_history").To(c.getClusterHealthHistory).
+		Doc("list the health history of the cluster, includes the capacity, the usage and the outages").
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Filter(c.RbacService.CheckPerm("cluster", "detail")).
+		Param(ws.PathParameter("clusterName", "identifier of the cluster").DataType("string")).
+		Param(ws.QueryParameter("since", "list the history since the time in RFC3339 format, default is 24 hours ago").DataType("string")).
+		Returns(200, "OK", apis.ClusterHealthHistoryResponse{}).
+		Returns(400, "Bad Request", bcode.Bcode{}).
+		Writes(apis.ClusterHealthHistoryResponse{}))
+
 	ws.Route(ws.PUT("/{clusterName}").To(c.modifyKubeCluster).
 		Doc("modify cluster").
 		Metadata(restfulspec.KeyOpenAPITags, tags).
@@ -166,7 +179,7 @@ func (c *Cluster) GetWebServiceRoute() *restful.WebService {
 		Returns(400, "Bad Request", bcode.Bcode{}).
 		Writes(apis.CreateCloudClusterResponse{}))
 
-	ws.Filter(authCheckFilter)
+	ws.Filter(authCheckFilter(c.SessionService))
 	return ws
 }
 
@@ -217,6 +230,26 @@ func (c *Cluster) createKubeCluster(req *restful.Request, res *restful.Response)
 	}
 }
 
+func (c *Cluster) getClusterHealthHistory(req *restful.Request, res *restful.Response) {
+	since := time.Now().Add(-24 * time.Hour)
+	if sinceStr := req.QueryParameter("since"); sinceStr != "" {
+		var err error
+		if since, err = time.Parse(time.RFC3339, sinceStr); err != nil {
+			bcode.ReturnError(req, res, bcode.ErrClusterHealthHistoryInvalidTime)
+			return
+		}
+	}
+	history, err := c.ClusterService.GetClusterHealthHistory(req.Request.Context(), req.PathParameter("clusterName"), since)
+	if err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+	if err := res.WriteEntity(history); err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+}
+
 func (c *Cluster) getKubeCluster(req *restful.Request, res *restful.Response) {
 	clusterName := req.PathParameter("clusterName")
 
@@ -0,0 +1,174 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	restfulspec "github.com/emicklei/go-restful-openapi/v2"
+	restful "github.com/emicklei/go-restful/v3"
+
+	"github.com/kubevela/velaux/pkg/server/domain/service"
+	apis "github.com/kubevela/velaux/pkg/server/interfaces/api/dto/v1"
+	"github.com/kubevela/velaux/pkg/server/utils/bcode"
+)
+
+// ClusterGroup the cluster group manage
+type ClusterGroup struct {
+	ClusterService service.ClusterService `inject:""`
+	RbacService    service.RBACService    `inject:""`
+	SessionService service.SessionService `inject:""`
+}
+
+// NewClusterGroup new cluster group manage
+func NewClusterGroup() *ClusterGroup {
+	return &ClusterGroup{}
+}
+
+// GetWebServiceRoute -
+func (c *ClusterGroup) GetWebServiceRoute() *restful.WebService {
+	ws := new(restful.WebService)
+	ws.Path(versionPrefix+"/cluster_groups").
+		Consumes(restful.MIME_XML, restful.MIME_JSON).
+		Produces(restful.MIME_JSON, restful.MIME_XML).
+		Doc("api for cluster group manage")
+
+	tags := []string{"cluster"}
+
+	ws.Route(ws.GET("/").To(c.listClusterGroups).
+		Doc("list all cluster groups").
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Filter(c.RbacService.CheckPerm("clusterGroup", "list")).
+		Returns(200, "OK", apis.ListClusterGroupResponse{}).
+		Returns(400, "Bad Request", bcode.Bcode{}).
+		Writes(apis.ListClusterGroupResponse{}))
+
+	ws.Route(ws.POST("/").To(c.createClusterGroup).
+		Doc("create a cluster group").
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Reads(apis.CreateClusterGroupRequest{}).
+		Filter(c.RbacService.CheckPerm("clusterGroup", "create")).
+		Returns(200, "OK", apis.DetailClusterGroupResponse{}).
+		Returns(400, "Bad Request", bcode.Bcode{}).
+		Writes(apis.DetailClusterGroupResponse{}))
+
+	ws.Route(ws.GET("/{clusterGroupName}").To(c.detailClusterGroup).
+		Doc("detail the cluster group, includes the clusters in the group at present").
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Filter(c.RbacService.CheckPerm("clusterGroup", "detail")).
+		Param(ws.PathParameter("clusterGroupName", "identifier of the cluster group").DataType("string")).
+		Returns(200, "OK", apis.DetailClusterGroupResponse{}).
+		Returns(400, "Bad Request", bcode.Bcode{}).
+		Writes(apis.DetailClusterGroupResponse{}))
+
+	ws.Route(ws.PUT("/{clusterGroupName}").To(c.updateClusterGroup).
+		Doc("update the cluster group").
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Filter(c.RbacService.CheckPerm("clusterGroup", "update")).
+		Param(ws.PathParameter("clusterGroupName", "identifier of the cluster group").DataType("string")).
+		Reads(apis.UpdateClusterGroupRequest{}).
+		Returns(200, "OK", apis.DetailClusterGroupResponse{}).
+		Returns(400, "Bad Request", bcode.Bcode{}).
+		Writes(apis.DetailClusterGroupResponse{}))
+
+	ws.Route(ws.DELETE("/{clusterGroupName}").To(c.deleteClusterGroup).
+		Doc("delete the cluster group").
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Filter(c.RbacService.CheckPerm("clusterGroup", "delete")).
+		Param(ws.PathParameter("clusterGroupName", "identifier of the cluster group").DataType("string")).
+		Returns(200, "OK", apis.EmptyResponse{}).
+		Returns(400, "Bad Request", bcode.Bcode{}).
+		Writes(apis.EmptyResponse{}))
+
+	ws.Filter(authCheckFilter(c.SessionService))
+	return ws
+}
+
+func (c *ClusterGroup) listClusterGroups(req *restful.Request, res *restful.Response) {
+	groups, err := c.ClusterService.ListClusterGroups(req.Request.Context())
+	if err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+	if err := res.WriteEntity(groups); err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+}
+
+func (c *ClusterGroup) createClusterGroup(req *restful.Request, res *restful.Response) {
+	var createReq apis.CreateClusterGroupRequest
+	if err := req.ReadEntity(&createReq); err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+	if err := validate.Struct(&createReq); err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+	group, err := c.ClusterService.CreateClusterGroup(req.Request.Context(), createReq)
+	if err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+	if err := res.WriteEntity(group); err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+}
+
+func (c *ClusterGroup) detailClusterGroup(req *restful.Request, res *restful.Response) {
+	group, err := c.ClusterService.GetClusterGroup(req.Request.Context(), req.PathParameter("clusterGroupName"))
+	if err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+	if err := res.WriteEntity(group); err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+}
+
+func (c *ClusterGroup) updateClusterGroup(req *restful.Request, res *restful.Response) {
+	var updateReq apis.UpdateClusterGroupRequest
+	if err := req.ReadEntity(&updateReq); err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+	if err := validate.Struct(&updateReq); err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+	group, err := c.ClusterService.UpdateClusterGroup(req.Request.Context(), req.PathParameter("clusterGroupName"), updateReq)
+	if err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+	if err := res.WriteEntity(group); err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+}
+
+func (c *ClusterGroup) deleteClusterGroup(req *restful.Request, res *restful.Response) {
+	if err := c.ClusterService.DeleteClusterGroup(req.Request.Context(), req.PathParameter("clusterGroupName")); err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+	if err := res.WriteEntity(apis.EmptyResponse{}); err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+}
@@ -17,6 +17,8 @@ limitations under the License.
 package api
 
 import (
+	"strconv"
+
 	restfulspec "github.com/emicklei/go-restful-openapi/v2"
 	"github.com/emicklei/go-restful/v3"
 
@@ -33,8 +35,9 @@ func Config() Interface {
 }
 
 type config struct {
-	ConfigService service.ConfigService `inject:""`
-	RbacService   service.RBACService   `inject:""`
+	ConfigService  service.ConfigService  `inject:""`
+	RbacService    service.RBACService    `inject:""`
+	SessionService service.SessionService `inject:""`
 }
 
 func (s *config) GetWebServiceRoute() *restful.WebService {
@@ -93,7 +96,37 @@ func (s *config) GetWebServiceRoute() *restful.WebService {
 		Returns(404, "Not Found", bcode.Bcode{}).
 		Writes(apis.EmptyResponse{}))
 
-	ws.Filter(authCheckFilter)
+	ws.Route(ws.GET("/{configName}/revisions").To(s.listConfigRevisions).
+		Doc("list the revisions of a config").
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Filter(s.RbacService.CheckPerm("config", "get")).
+		Param(ws.PathParameter("configName", "identifier of the config").DataType("string")).
+		Returns(200, "OK", apis.ListConfigRevisionResponse{}).
+		Returns(400, "Bad Request", bcode.Bcode{}).
+		Writes(apis.ListConfigRevisionResponse{}))
+
+	ws.Route(ws.GET("/{configName}/revisions/diff").To(s.diffConfigRevisions).
+		Doc("compare the properties of two revisions of a config").
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Filter(s.RbacService.CheckPerm("config", "get")).
+		Param(ws.PathParameter("configName", "identifier of the config").DataType("string")).
+		Param(ws.QueryParameter("base", "the base revision").DataType("integer").Required(true)).
+		Param(ws.QueryParameter("target", "the target revision").DataType("integer").Required(true)).
+		Returns(200, "OK", apis.ConfigRevisionDiffResponse{}).
+		Returns(400, "Bad Request", bcode.Bcode{}).
+		Writes(apis.ConfigRevisionDiffResponse{}))
+
+	ws.Route(ws.POST("/{configName}/revisions/{revision}/rollback").To(s.rollbackConfig).
+		Doc("restore a config to a revision and apply its distributions again").
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Filter(s.RbacService.CheckPerm("config", "update")).
+		Param(ws.PathParameter("configName", "identifier of the config").DataType("string")).
+		Param(ws.PathParameter("revision", "the revision to restore").DataType("integer")).
+		Returns(200, "OK", apis.Config{}).
+		Returns(400, "Bad Request", bcode.Bcode{}).
+		Writes(apis.Config{}))
+
+	ws.Filter(authCheckFilter(s.SessionService))
 	return ws
 }
 
@@ -103,8 +136,9 @@ func ConfigTemplate() Interface {
 }
 
 type configTemplate struct {
-	ConfigService service.ConfigService `inject:""`
-	RbacService   service.RBACService   `inject:""`
+	ConfigService  service.ConfigService  `inject:""`
+	RbacService    service.RBACService    `inject:""`
+	SessionService service.SessionService `inject:""`
 }
 
 func (s *configTemplate) GetWebServiceRoute() *restful.WebService {
@@ -134,7 +168,7 @@ func (s *configTemplate) GetWebServiceRoute() *restful.WebService {
 		Returns(400, "Bad Request", bcode.Bcode{}).
 		Writes(apis.ConfigTemplateDetail{}))
 
-	ws.Filter(authCheckFilter)
+	ws.Filter(authCheckFilter(s.SessionService))
 	return ws
 }
 
@@ -250,3 +284,63 @@ func (s *config) deleteConfig(req *restful.Request, res *restful.Response) {
 		return
 	}
 }
+
+func (s *config) listConfigRevisions(req *restful.Request, res *restful.Response) {
+	revisions, err := s.ConfigService.ListConfigRevisions(req.Request.Context(), "", req.PathParameter("configName"))
+	if err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+	if err := res.WriteEntity(revisions); err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+}
+
+func (s *config) diffConfigRevisions(req *restful.Request, res *restful.Response) {
+	base, err := parseConfigRevision(req.QueryParameter("base"))
+	if err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+	target, err := parseConfigRevision(req.QueryParameter("target"))
+	if err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+	diff, err := s.ConfigService.DiffConfigRevisions(req.Request.Context(), "", req.PathParameter("configName"), base, target)
+	if err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+	if err := res.WriteEntity(diff); err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+}
+
+func (s *config) rollbackConfig(req *restful.Request, res *restful.Response) {
+	revision, err := parseConfigRevision(req.PathParameter("revision"))
+	if err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+	config, err := s.ConfigService.RollbackConfig(req.Request.Context(), "", req.PathParameter("configName"), revision)
+	if err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+	if err := res.WriteEntity(config); err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+}
+
+// parseConfigRevision parse the revision number of the config
+func parseConfigRevision(value string) (int64, error) {
+	revision, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || revision <= 0 {
+		return 0, bcode.ErrInvalidConfigRevision
+	}
+	return revision, nil
+}
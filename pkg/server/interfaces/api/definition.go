@@ -32,6 +32,7 @@ import (
 type definition struct {
 	DefinitionService service.DefinitionService `inject:""`
 	RbacService       service.RBACService       `inject:""`
+	SessionService    service.SessionService    `inject:""`
 }
 
 func (d *definition) GetWebServiceRoute() *restful.WebService {
@@ -83,7 +84,76 @@ func (d *definition) GetWebServiceRoute() *restful.WebService {
 		Returns(200, "update successfully", schema.UISchema{}).
 		Writes(apis.DetailDefinitionResponse{}).Do(returns200, returns500))
 
-	ws.Filter(authCheckFilter)
+	ws.Route(ws.POST("/").To(d.createDefinition).
+		Doc("Create a definition from the CUE source").
+		Filter(d.RbacService.CheckPerm("definition", "create")).
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Reads(apis.CreateDefinitionRequest{}).
+		Returns(200, "create successfully", apis.DetailDefinitionResponse{}).
+		Returns(400, "Bad Request", bcode.Bcode{}).
+		Writes(apis.DetailDefinitionResponse{}).Do(returns200, returns500))
+
+	ws.Route(ws.POST("/preview").To(d.previewDefinition).
+		Doc("Render the template of a definition with the sample properties, nothing is applied").
+		Filter(d.RbacService.CheckPerm("definition", "create")).
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Reads(apis.PreviewDefinitionRequest{}).
+		Returns(200, "OK", apis.PreviewDefinitionResponse{}).
+		Returns(400, "Bad Request", bcode.Bcode{}).
+		Writes(apis.PreviewDefinitionResponse{}).Do(returns200, returns500))
+
+	ws.Route(ws.PUT("/{definitionName}").To(d.updateDefinition).
+		Doc("Update a definition with the CUE source").
+		Filter(d.RbacService.CheckPerm("definition", "update")).
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Param(ws.PathParameter("definitionName", "identifier of the definition").DataType("string").Required(true)).
+		Reads(apis.UpdateDefinitionRequest{}).
+		Returns(200, "update successfully", apis.DetailDefinitionResponse{}).
+		Returns(400, "Bad Request", bcode.Bcode{}).
+		Writes(apis.DetailDefinitionResponse{}).Do(returns200, returns500))
+
+	ws.Route(ws.DELETE("/{definitionName}").To(d.deleteDefinition).
+		Doc("Delete a definition").
+		Filter(d.RbacService.CheckPerm("definition", "delete")).
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Param(ws.PathParameter("definitionName", "identifier of the definition").DataType("string").Required(true)).
+		Param(ws.QueryParameter("type", "the definition type").DataType("string").Required(true).PossibleValues([]string{"component", "trait", "workflowstep", "policy"})).
+		Returns(200, "delete successfully", apis.EmptyResponse{}).
+		Returns(400, "Bad Request", bcode.Bcode{}).
+		Writes(apis.EmptyResponse{}).Do(returns200, returns500))
+
+	ws.Route(ws.GET("/{definitionName}/cue").To(d.getDefinitionCUE).
+		Doc("Get the CUE source of a definition").
+		Filter(d.RbacService.CheckPerm("definition", "detail")).
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Param(ws.PathParameter("definitionName", "identifier of the definition").DataType("string").Required(true)).
+		Param(ws.QueryParameter("type", "the definition type").DataType("string").Required(true).PossibleValues([]string{"component", "trait", "workflowstep", "policy"})).
+		Returns(200, "OK", apis.DefinitionCUEResponse{}).
+		Returns(400, "Bad Request", bcode.Bcode{}).
+		Writes(apis.DefinitionCUEResponse{}).Do(returns200, returns500))
+
+	ws.Route(ws.GET("/{definitionName}/revisions").To(d.listDefinitionRevisions).
+		Doc("List the revisions of a definition").
+		Filter(d.RbacService.CheckPerm("definition", "detail")).
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Param(ws.PathParameter("definitionName", "identifier of the definition").DataType("string").Required(true)).
+		Param(ws.QueryParameter("type", "the definition type").DataType("string").Required(true).PossibleValues([]string{"component", "trait", "workflowstep", "policy"})).
+		Returns(200, "OK", apis.ListDefinitionRevisionResponse{}).
+		Returns(400, "Bad Request", bcode.Bcode{}).
+		Writes(apis.ListDefinitionRevisionResponse{}).Do(returns200, returns500))
+
+	ws.Route(ws.POST("/{definitionName}/revisions/{revision}/rollback").To(d.rollbackDefinition).
+		Doc("Rollback a definition to the revision").
+		Filter(d.RbacService.CheckPerm("definition", "update")).
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Param(ws.PathParameter("definitionName", "identifier of the definition").DataType("string").Required(true)).
+		Param(ws.PathParameter("revision", "the revision number").DataType("integer").Required(true)).
+		Param(ws.QueryParameter("type", "the definition type").DataType("string").Required(true).PossibleValues([]string{"component", "trait", "workflowstep", "policy"})).
+		Returns(200, "rollback successfully", apis.DetailDefinitionResponse{}).
+		Returns(400, "Bad Request", bcode.Bcode{}).
+		Writes(apis.DetailDefinitionResponse{}).Do(returns200, returns500))
+
+	ws.Filter(authCheckFilter(d.SessionService))
 	return ws
 }
 
@@ -173,3 +243,121 @@ func (d *definition) updateDefinitionStatus(req *restful.Request, res *restful.R
 		return
 	}
 }
+
+func (d *definition) createDefinition(req *restful.Request, res *restful.Response) {
+	// Verify the validity of parameters
+	var createReq apis.CreateDefinitionRequest
+	if err := req.ReadEntity(&createReq); err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+	if err := validate.Struct(&createReq); err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+	definition, err := d.DefinitionService.CreateDefinition(req.Request.Context(), createReq)
+	if err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+	if err := res.WriteEntity(definition); err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+}
+
+func (d *definition) updateDefinition(req *restful.Request, res *restful.Response) {
+	// Verify the validity of parameters
+	var updateReq apis.UpdateDefinitionRequest
+	if err := req.ReadEntity(&updateReq); err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+	if err := validate.Struct(&updateReq); err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+	definition, err := d.DefinitionService.UpdateDefinition(req.Request.Context(), req.PathParameter("definitionName"), updateReq)
+	if err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+	if err := res.WriteEntity(definition); err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+}
+
+func (d *definition) deleteDefinition(req *restful.Request, res *restful.Response) {
+	if err := d.DefinitionService.DeleteDefinition(req.Request.Context(), req.PathParameter("definitionName"), req.QueryParameter("type")); err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+	if err := res.WriteEntity(apis.EmptyResponse{}); err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+}
+
+func (d *definition) getDefinitionCUE(req *restful.Request, res *restful.Response) {
+	cue, err := d.DefinitionService.GetDefinitionCUE(req.Request.Context(), req.PathParameter("definitionName"), req.QueryParameter("type"))
+	if err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+	if err := res.WriteEntity(cue); err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+}
+
+func (d *definition) listDefinitionRevisions(req *restful.Request, res *restful.Response) {
+	revisions, err := d.DefinitionService.ListDefinitionRevisions(req.Request.Context(), req.PathParameter("definitionName"), req.QueryParameter("type"))
+	if err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+	if err := res.WriteEntity(revisions); err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+}
+
+func (d *definition) rollbackDefinition(req *restful.Request, res *restful.Response) {
+	revision, err := strconv.ParseInt(req.PathParameter("revision"), 10, 64)
+	if err != nil || revision <= 0 {
+		bcode.ReturnError(req, res, bcode.ErrInvalidDefinitionRevision)
+		return
+	}
+	definition, err := d.DefinitionService.RollbackDefinition(req.Request.Context(), req.PathParameter("definitionName"), req.QueryParameter("type"), revision)
+	if err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+	if err := res.WriteEntity(definition); err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+}
+
+func (d *definition) previewDefinition(req *restful.Request, res *restful.Response) {
+	// Verify the validity of parameters
+	var previewReq apis.PreviewDefinitionRequest
+	if err := req.ReadEntity(&previewReq); err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+	if err := validate.Struct(&previewReq); err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+	preview, err := d.DefinitionService.PreviewDefinition(req.Request.Context(), previewReq)
+	if err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+	if err := res.WriteEntity(preview); err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+}
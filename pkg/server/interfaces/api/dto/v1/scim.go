@@ -0,0 +1,149 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	// SCIMSchemaUser the schema of the user resource
+	SCIMSchemaUser = "urn:ietf:params:scim:schemas:core:2.0:User"
+	// SCIMSchemaGroup the schema of the group resource
+	SCIMSchemaGroup = "urn:ietf:params:scim:schemas:core:2.0:Group"
+	// SCIMSchemaListResponse the schema of the list response
+	SCIMSchemaListResponse = "urn:ietf:params:scim:api:messages:2.0:ListResponse"
+	// SCIMSchemaPatchOp the schema of the patch request
+	SCIMSchemaPatchOp = "urn:ietf:params:scim:api:messages:2.0:PatchOp"
+	// SCIMSchemaError the schema of the error response
+	SCIMSchemaError = "urn:ietf:params:scim:api:messages:2.0:Error"
+)
+
+// SCIMMeta the meta attributes of the SCIM resource
+type SCIMMeta struct {
+	ResourceType string    `json:"resourceType"`
+	Created      time.Time `json:"created"`
+	LastModified time.Time `json:"lastModified"`
+	Location     string    `json:"location,omitempty"`
+}
+
+// SCIMName the name of the SCIM user
+type SCIMName struct {
+	Formatted  string `json:"formatted,omitempty"`
+	FamilyName string `json:"familyName,omitempty"`
+	GivenName  string `json:"givenName,omitempty"`
+}
+
+// SCIMEmail the email of the SCIM user
+type SCIMEmail struct {
+	Value   string `json:"value"`
+	Type    string `json:"type,omitempty"`
+	Primary bool   `json:"primary,omitempty"`
+}
+
+// SCIMMemberRef the reference of the group member or the group of the user
+type SCIMMemberRef struct {
+	Value   string `json:"value"`
+	Display string `json:"display,omitempty"`
+	Ref     string `json:"$ref,omitempty"`
+}
+
+// SCIMUser the SCIM user resource
+type SCIMUser struct {
+	Schemas     []string    `json:"schemas"`
+	ID          string      `json:"id,omitempty"`
+	ExternalID  string      `json:"externalId,omitempty"`
+	UserName    string      `json:"userName"`
+	Name        *SCIMName   `json:"name,omitempty"`
+	DisplayName string      `json:"displayName,omitempty"`
+	Emails      []SCIMEmail `json:"emails,omitempty"`
+	Active      *bool       `json:"active,omitempty"`
+	// Password is write only, it is only used by the local login
+	Password string          `json:"password,omitempty"`
+	Groups   []SCIMMemberRef `json:"groups,omitempty"`
+	Meta     *SCIMMeta       `json:"meta,omitempty"`
+}
+
+// SCIMGroup the SCIM group resource
+type SCIMGroup struct {
+	Schemas     []string        `json:"schemas"`
+	ID          string          `json:"id,omitempty"`
+	ExternalID  string          `json:"externalId,omitempty"`
+	DisplayName string          `json:"displayName"`
+	Members     []SCIMMemberRef `json:"members,omitempty"`
+	Meta        *SCIMMeta       `json:"meta,omitempty"`
+}
+
+// SCIMListResponse the response of the list requests
+type SCIMListResponse struct {
+	Schemas      []string      `json:"schemas"`
+	TotalResults int           `json:"totalResults"`
+	StartIndex   int           `json:"startIndex"`
+	ItemsPerPage int           `json:"itemsPerPage"`
+	Resources    []interface{} `json:"Resources"`
+}
+
+// SCIMPatchRequest the request of the patch requests
+type SCIMPatchRequest struct {
+	Schemas    []string             `json:"schemas"`
+	Operations []SCIMPatchOperation `json:"Operations"`
+}
+
+// SCIMPatchOperation one patch operation
+type SCIMPatchOperation struct {
+	// Op the options: add, remove and replace
+	Op    string          `json:"op"`
+	Path  string          `json:"path,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// SCIMError the error response
+type SCIMError struct {
+	Schemas  []string `json:"schemas"`
+	Status   string   `json:"status"`
+	ScimType string   `json:"scimType,omitempty"`
+	Detail   string   `json:"detail,omitempty"`
+}
+
+// SCIMGroupRule maps the members of the SCIM groups onto the project users
+type SCIMGroupRule struct {
+	// Group the display name of the group, the shell pattern is supported, such as "team-*"
+	Group   string   `json:"group" validate:"required"`
+	Project string   `json:"project" validate:"checkname"`
+	Roles   []string `json:"roles" validate:"required"`
+}
+
+// SCIMConfig the config of the SCIM provisioning endpoint
+type SCIMConfig struct {
+	Enabled bool `json:"enabled"`
+	// TokenGenerated means the bearer token is generated
+	TokenGenerated bool            `json:"tokenGenerated"`
+	GroupRules     []SCIMGroupRule `json:"groupRules"`
+	UpdateTime     time.Time       `json:"updateTime"`
+}
+
+// UpdateSCIMConfigRequest the request to update the SCIM config
+type UpdateSCIMConfigRequest struct {
+	Enabled    bool            `json:"enabled"`
+	GroupRules []SCIMGroupRule `json:"groupRules" validate:"dive"`
+}
+
+// SCIMTokenResponse the generated bearer token, it is only shown once
+type SCIMTokenResponse struct {
+	Token string `json:"token"`
+}
@@ -36,6 +36,7 @@ import (
 	"github.com/oam-dev/kubevela/pkg/config"
 	"github.com/oam-dev/kubevela/pkg/utils/schema"
 
+	"github.com/kubevela/velaux/pkg/plugin/limiter"
 	pluginTypes "github.com/kubevela/velaux/pkg/plugin/types"
 	"github.com/kubevela/velaux/pkg/server/domain/model"
 )
@@ -61,12 +62,20 @@ var (
 	CtxKeyProject = "project"
 	// CtxKeyToken request context key of request token
 	CtxKeyToken = "token"
+	// CtxKeySession request context key of the login session ID
+	CtxKeySession = "session"
 	// CtxKeyPipeline request context key of pipeline
 	CtxKeyPipeline = "pipeline"
 	// CtxKeyPipelineContext request context key of pipeline context
 	CtxKeyPipelineContext = "pipeline-context"
 	// CtxKeyPipelineRun request context key of pipeline run
 	CtxKeyPipelineRun = "pipeline-run"
+	// CtxKeyPlugin request context key of plugin
+	CtxKeyPlugin = "plugin"
+	// CtxKeyPluginStorageScope request context key of the plugin storage scope
+	CtxKeyPluginStorageScope = "plugin-storage-scope"
+	// CtxKeyProjectTemplate request context key of project template
+	CtxKeyProjectTemplate = "project-template"
 )
 
 // AddonPhase defines the phase of an addon
@@ -83,6 +92,8 @@ const (
 	AddonPhaseDisabling AddonPhase = "disabling"
 	// AddonPhaseSuspend indicates the addon is suspend
 	AddonPhaseSuspend AddonPhase = "suspend"
+	// AddonPhasePendingPromotion indicates the addon is waiting for the promotion of the staged rollout on the cluster
+	AddonPhasePendingPromotion AddonPhase = "pendingPromotion"
 )
 
 // EmptyResponse empty response, it will used for delete api
@@ -102,25 +113,29 @@ type CreateAddonRegistryRequest struct {
 	Oss    *addon.OSSAddonSource    `json:"oss,omitempty"`
 	Gitee  *addon.GiteeAddonSource  `json:"gitee,omitempty" `
 	Gitlab *addon.GitlabAddonSource `json:"gitlab,omitempty" `
+	// Offline the registry is served by VelaUX from the local storage
+	Offline *OfflineAddonSource `json:"offline,omitempty"`
 }
 
 // UpdateAddonRegistryRequest defines the format for addon registry update request
 type UpdateAddonRegistryRequest struct {
-	Helm   *addon.HelmSource        `json:"helm,omitempty"`
-	Git    *addon.GitAddonSource    `json:"git,omitempty"`
-	Oss    *addon.OSSAddonSource    `json:"oss,omitempty"`
-	Gitee  *addon.GiteeAddonSource  `json:"gitee,omitempty" `
-	Gitlab *addon.GitlabAddonSource `json:"gitlab,omitempty" `
+	Helm    *addon.HelmSource        `json:"helm,omitempty"`
+	Git     *addon.GitAddonSource    `json:"git,omitempty"`
+	Oss     *addon.OSSAddonSource    `json:"oss,omitempty"`
+	Gitee   *addon.GiteeAddonSource  `json:"gitee,omitempty" `
+	Gitlab  *addon.GitlabAddonSource `json:"gitlab,omitempty" `
+	Offline *OfflineAddonSource      `json:"offline,omitempty"`
 }
 
 // AddonRegistry defines the format for a single addon registry
 type AddonRegistry struct {
-	Name   string                   `json:"name" validate:"required"`
-	Helm   *addon.HelmSource        `json:"helm,omitempty"`
-	Git    *addon.GitAddonSource    `json:"git,omitempty"`
-	OSS    *addon.OSSAddonSource    `json:"oss,omitempty"`
-	Gitee  *addon.GiteeAddonSource  `json:"gitee,omitempty" `
-	Gitlab *addon.GitlabAddonSource `json:"gitlab,omitempty" `
+	Name    string                   `json:"name" validate:"required"`
+	Helm    *addon.HelmSource        `json:"helm,omitempty"`
+	Git     *addon.GitAddonSource    `json:"git,omitempty"`
+	OSS     *addon.OSSAddonSource    `json:"oss,omitempty"`
+	Gitee   *addon.GiteeAddonSource  `json:"gitee,omitempty" `
+	Gitlab  *addon.GitlabAddonSource `json:"gitlab,omitempty" `
+	Offline *OfflineAddonSource      `json:"offline,omitempty"`
 }
 
 // ListAddonRegistryResponse list addon registry
@@ -128,6 +143,41 @@ type ListAddonRegistryResponse struct {
 	Registries []*AddonRegistry `json:"registries"`
 }
 
+// OfflineAddonSource the source of the offline addon registry
+type OfflineAddonSource struct {
+	// Dir the server-local directory to import the addons from, it could contain the addon directories and the packaged addons(*.tgz).
+	// It is optional, the addons could be uploaded through the API.
+	Dir string `json:"dir,omitempty"`
+}
+
+// OfflineAddonVersion the addon version stored in the offline addon registry
+type OfflineAddonVersion struct {
+	Name        string `json:"name"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+	// Digest the sha256 checksum of the addon package
+	Digest  string    `json:"digest"`
+	Created time.Time `json:"created"`
+}
+
+// ListOfflineAddonResponse the addon versions stored in the offline addon registry
+type ListOfflineAddonResponse struct {
+	Addons []*OfflineAddonVersion `json:"addons"`
+}
+
+// OfflineAddonImportFailure the addon package failed to import
+type OfflineAddonImportFailure struct {
+	Source string `json:"source"`
+	Reason string `json:"reason"`
+}
+
+// SyncOfflineAddonRegistryResponse the result of importing the addons from the directory of the offline addon registry
+type SyncOfflineAddonRegistryResponse struct {
+	Imported []*OfflineAddonVersion      `json:"imported"`
+	Skipped  []*OfflineAddonVersion      `json:"skipped"`
+	Failed   []OfflineAddonImportFailure `json:"failed"`
+}
+
 // EnableAddonRequest defines the format for enable addon request
 type EnableAddonRequest struct {
 	// Args is the key-value environment variables, e.g. AK/SK credentials.
@@ -138,6 +188,9 @@ type EnableAddonRequest struct {
 	Version string `json:"version,omitempty"`
 	// RegistryName specify the registry name
 	RegistryName string `json:"registryName,omitempty"`
+	// RolloutClusters the clusters to enable the addon first, the addon is promoted to the other target clusters later.
+	// It is only supported when enabling the addon, the enabled addon can't be upgraded in stages.
+	RolloutClusters []string `json:"rolloutClusters,omitempty"`
 }
 
 // ListAddonResponse defines the format for addon list response
@@ -198,6 +251,40 @@ type AddonStatusResponse struct {
 	// the status of multiple clusters
 	Clusters    map[string]map[string]interface{} `json:"clusters,omitempty"`
 	AllClusters []NameAlias                       `json:"allClusters,omitempty"`
+	// ClusterStatus the status of the addon on each cluster
+	ClusterStatus []AddonClusterStatus `json:"clusterStatus,omitempty"`
+	// Rollout the staged rollout of the addon
+	Rollout *AddonRolloutBase `json:"rollout,omitempty"`
+}
+
+// AddonClusterStatus the status of the addon on a cluster
+type AddonClusterStatus struct {
+	Cluster    string                 `json:"cluster"`
+	Phase      AddonPhase             `json:"phase"`
+	Version    string                 `json:"version,omitempty"`
+	Healthy    bool                   `json:"healthy"`
+	Components []AddonComponentHealth `json:"components,omitempty"`
+}
+
+// AddonComponentHealth the health of a component of the addon on a cluster
+type AddonComponentHealth struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace,omitempty"`
+	Healthy   bool   `json:"healthy"`
+	Message   string `json:"message,omitempty"`
+}
+
+// AddonRolloutBase the staged rollout of the addon
+type AddonRolloutBase struct {
+	Addon          string     `json:"addon"`
+	Version        string     `json:"version,omitempty"`
+	RegistryName   string     `json:"registryName,omitempty"`
+	Clusters       []string   `json:"clusters,omitempty"`
+	StagedClusters []string   `json:"stagedClusters"`
+	Phase          string     `json:"phase"`
+	Operator       string     `json:"operator,omitempty"`
+	CreateTime     time.Time  `json:"createTime"`
+	PromoteTime    *time.Time `json:"promoteTime,omitempty"`
 }
 
 // EnablingProgress defines the progress of enabling an addon
@@ -206,6 +293,66 @@ type EnablingProgress struct {
 	TotalComponents   int `json:"total_components"`
 }
 
+// AddonOperationBase the operation of enabling, updating or disabling the addon, the operation runs in the background
+type AddonOperationBase struct {
+	ID           string                    `json:"id"`
+	Addon        string                    `json:"addon"`
+	Type         string                    `json:"type"`
+	Operator     string                    `json:"operator,omitempty"`
+	Args         map[string]interface{}    `json:"args,omitempty"`
+	Version      string                    `json:"version,omitempty"`
+	RegistryName string                    `json:"registryName,omitempty"`
+	Force        bool                      `json:"force,omitempty"`
+	Phase        string                    `json:"phase"`
+	Logs         []model.AddonOperationLog `json:"logs,omitempty"`
+	Warnings     []string                  `json:"warnings,omitempty"`
+	Error        string                    `json:"error,omitempty"`
+	CreateTime   time.Time                 `json:"createTime"`
+	StartTime    *time.Time                `json:"startTime,omitempty"`
+	EndTime      *time.Time                `json:"endTime,omitempty"`
+}
+
+// ListAddonOperationResponse the history of the operations of the addon
+type ListAddonOperationResponse struct {
+	Operations []AddonOperationBase `json:"operations"`
+	Total      int64                `json:"total"`
+}
+
+// AddonUpgradePreviewResponse the changes of upgrading the enabled addon, nothing is applied
+type AddonUpgradePreviewResponse struct {
+	Name             string `json:"name"`
+	RegistryName     string `json:"registryName"`
+	InstalledVersion string `json:"installedVersion"`
+	TargetVersion    string `json:"targetVersion"`
+	// Args the args merged with the args of the enabled addon, which are used to render the target version
+	Args map[string]interface{} `json:"args,omitempty"`
+	// AppDiff the diff of the rendered application against the installed application
+	AppDiff      AppCompareResponse `json:"appDiff"`
+	Definitions  []AddonChangeItem  `json:"definitions"`
+	Parameters   []AddonChangeItem  `json:"parameters"`
+	Dependencies []AddonChangeItem  `json:"dependencies"`
+	Warnings     []string           `json:"warnings,omitempty"`
+}
+
+const (
+	// AddonChangeAdded the item only exists in the target version
+	AddonChangeAdded = "Added"
+	// AddonChangeRemoved the item only exists in the installed version
+	AddonChangeRemoved = "Removed"
+	// AddonChangeModified the item exists in both versions but is changed
+	AddonChangeModified = "Modified"
+)
+
+// AddonChangeItem a changed definition, parameter or dependency of the addon
+type AddonChangeItem struct {
+	Name string `json:"name"`
+	// Kind the kind of the definition, or the type of the parameter
+	Kind   string `json:"kind,omitempty"`
+	Change string `json:"change"`
+	Before string `json:"before,omitempty"`
+	After  string `json:"after,omitempty"`
+}
+
 // AddonArgsResponse defines the response of addon args
 type AddonArgsResponse struct {
 	Args map[string]string `json:"args"`
@@ -266,6 +413,49 @@ type ListConfigResponse struct {
 	Configs []*Config `json:"configs"`
 }
 
+const (
+	// ConfigChangeAdded the property only exists in the target revision
+	ConfigChangeAdded = "Added"
+	// ConfigChangeRemoved the property only exists in the base revision
+	ConfigChangeRemoved = "Removed"
+	// ConfigChangeModified the property exists in both revisions but is changed
+	ConfigChangeModified = "Modified"
+)
+
+// ConfigPropertyChange a changed property of the config, the values of the sensitive properties are redacted
+type ConfigPropertyChange struct {
+	// Key the path of the property, the keys of the nested properties are joined with dots
+	Key    string `json:"key"`
+	Change string `json:"change"`
+	Before string `json:"before,omitempty"`
+	After  string `json:"after,omitempty"`
+}
+
+// ConfigRevision a revision of the config
+type ConfigRevision struct {
+	Revision    int64     `json:"revision"`
+	Alias       string    `json:"alias,omitempty"`
+	Description string    `json:"description,omitempty"`
+	Operator    string    `json:"operator,omitempty"`
+	CreateTime  time.Time `json:"createTime"`
+	// RollbackFrom the revision restored by this revision, it is zero if the revision is not created by a rollback
+	RollbackFrom int64 `json:"rollbackFrom,omitempty"`
+	// Changes the changes from the previous revision
+	Changes []ConfigPropertyChange `json:"changes"`
+}
+
+// ListConfigRevisionResponse the revisions of the config, the latest one first
+type ListConfigRevisionResponse struct {
+	Revisions []*ConfigRevision `json:"revisions"`
+}
+
+// ConfigRevisionDiffResponse the changes of the properties from the base revision to the target revision
+type ConfigRevisionDiffResponse struct {
+	Base    int64                  `json:"base"`
+	Target  int64                  `json:"target"`
+	Changes []ConfigPropertyChange `json:"changes"`
+}
+
 // ListConfigTemplateResponse is the response body for listing the config templates
 type ListConfigTemplateResponse struct {
 	Templates []*ConfigTemplate `json:"templates"`
@@ -333,6 +523,71 @@ type ClusterResourceInfo struct {
 	StorageClassList []string `json:"storageClassList,omitempty"`
 }
 
+// ClusterHealthSnapshot the reachability and the resource info of the cluster probed at a time
+type ClusterHealthSnapshot struct {
+	Cluster       string              `json:"cluster"`
+	Reachable     bool                `json:"reachable"`
+	Reason        string              `json:"reason,omitempty"`
+	ResourceInfo  ClusterResourceInfo `json:"resourceInfo"`
+	CPUPercent    int                 `json:"cpuPercent"`
+	MemoryPercent int                 `json:"memoryPercent"`
+	ProbeTime     time.Time           `json:"probeTime"`
+}
+
+// ClusterOutage the period that the cluster is unreachable, the end time is nil if the cluster is still unreachable
+type ClusterOutage struct {
+	StartTime time.Time  `json:"startTime"`
+	EndTime   *time.Time `json:"endTime,omitempty"`
+	Reason    string     `json:"reason,omitempty"`
+}
+
+// ClusterHealthHistoryResponse the health history of the cluster
+type ClusterHealthHistoryResponse struct {
+	Snapshots []ClusterHealthSnapshot `json:"snapshots"`
+	Outages   []ClusterOutage         `json:"outages"`
+}
+
+// CreateClusterGroupRequest request parameters to create a cluster group
+type CreateClusterGroupRequest struct {
+	Name        string   `json:"name" validate:"checkname"`
+	Alias       string   `json:"alias,omitempty" validate:"checkalias" optional:"true"`
+	Description string   `json:"description,omitempty" optional:"true"`
+	Clusters    []string `json:"clusters,omitempty" optional:"true"`
+	// LabelSelector the clusters matching the labels are in the group, includes the clusters joined later
+	LabelSelector map[string]string `json:"labelSelector,omitempty" optional:"true"`
+}
+
+// UpdateClusterGroupRequest request parameters to update a cluster group
+type UpdateClusterGroupRequest struct {
+	Alias         string            `json:"alias,omitempty" validate:"checkalias" optional:"true"`
+	Description   string            `json:"description,omitempty" optional:"true"`
+	Clusters      []string          `json:"clusters,omitempty" optional:"true"`
+	LabelSelector map[string]string `json:"labelSelector,omitempty" optional:"true"`
+}
+
+// ClusterGroupBase cluster group base model
+type ClusterGroupBase struct {
+	Name          string            `json:"name"`
+	Alias         string            `json:"alias,omitempty"`
+	Description   string            `json:"description,omitempty"`
+	Clusters      []string          `json:"clusters,omitempty"`
+	LabelSelector map[string]string `json:"labelSelector,omitempty"`
+	CreateTime    time.Time         `json:"createTime"`
+	UpdateTime    time.Time         `json:"updateTime"`
+}
+
+// DetailClusterGroupResponse the detail of the cluster group
+type DetailClusterGroupResponse struct {
+	ClusterGroupBase
+	// ResolvedClusters the existing clusters in the group at present
+	ResolvedClusters []string `json:"resolvedClusters"`
+}
+
+// ListClusterGroupResponse list the cluster groups
+type ListClusterGroupResponse struct {
+	ClusterGroups []ClusterGroupBase `json:"clusterGroups"`
+}
+
 // CreateClusterNamespaceRequest request parameter to create namespace in cluster
 type CreateClusterNamespaceRequest struct {
 	Namespace string `json:"namespace"`
@@ -502,6 +757,26 @@ type CreateApplicationRequest struct {
 	Component   *CreateComponentRequest `json:"component"`
 }
 
+// TransferApplicationRequest transfer the application to another project
+type TransferApplicationRequest struct {
+	Project string `json:"project" validate:"checkname"`
+	// EnvMapping maps the envs bound by the application to the envs of the destination project,
+	// the destination env must contain all targets of the source env.
+	EnvMapping map[string]string `json:"envMapping,omitempty" optional:"true"`
+}
+
+// CloneApplicationRequest clone the application with a new name
+type CloneApplicationRequest struct {
+	Name        string `json:"name" validate:"checkname"`
+	Alias       string `json:"alias" validate:"checkalias" optional:"true"`
+	Description string `json:"description" optional:"true"`
+	// Project the project of the new application, default is the project of the source application
+	Project string `json:"project" optional:"true"`
+	// EnvMapping maps the envs bound by the application to the envs of the destination project,
+	// the bindings of the unmapped envs are not cloned into another project.
+	EnvMapping map[string]string `json:"envMapping,omitempty" optional:"true"`
+}
+
 // UpdateApplicationRequest update application base config
 type UpdateApplicationRequest struct {
 	Alias       string            `json:"alias" validate:"checkalias" optional:"true"`
@@ -822,6 +1097,10 @@ type ProjectBase struct {
 	UpdateTime  time.Time `json:"updateTime"`
 	Owner       NameAlias `json:"owner,omitempty"`
 	Namespace   string    `json:"namespace"`
+	Archived    bool      `json:"archived,omitempty"`
+	// ArchivedTime the project could be restored until the retention period after this time
+	ArchivedTime *time.Time             `json:"archivedTime,omitempty"`
+	Deletion     *model.ProjectDeletion `json:"deletion,omitempty"`
 }
 
 // CreateProjectRequest create project request body
@@ -832,6 +1111,43 @@ type CreateProjectRequest struct {
 	Owner       string `json:"owner" optional:"true"`
 	// the namespace to save the pipelines belong to this project.
 	Namespace string `json:"namespace" optional:"true"`
+	// Template the name of the project template, the resources declared by it are created with the project
+	Template string `json:"template,omitempty" optional:"true"`
+	// Variables the values of the template variables
+	Variables map[string]string `json:"variables,omitempty" optional:"true"`
+}
+
+// ProjectTemplateBase project template base model
+type ProjectTemplateBase struct {
+	Name        string                          `json:"name"`
+	Alias       string                          `json:"alias"`
+	Description string                          `json:"description"`
+	Variables   []model.ProjectTemplateVariable `json:"variables"`
+	Spec        model.ProjectTemplateSpec       `json:"spec"`
+	CreateTime  time.Time                       `json:"createTime"`
+	UpdateTime  time.Time                       `json:"updateTime"`
+}
+
+// ListProjectTemplateResponse list project template response body
+type ListProjectTemplateResponse struct {
+	Templates []*ProjectTemplateBase `json:"templates"`
+}
+
+// CreateProjectTemplateRequest create project template request body
+type CreateProjectTemplateRequest struct {
+	Name        string                          `json:"name" validate:"checkname"`
+	Alias       string                          `json:"alias" validate:"checkalias" optional:"true"`
+	Description string                          `json:"description" optional:"true"`
+	Variables   []model.ProjectTemplateVariable `json:"variables" optional:"true"`
+	Spec        model.ProjectTemplateSpec       `json:"spec"`
+}
+
+// UpdateProjectTemplateRequest update project template request body
+type UpdateProjectTemplateRequest struct {
+	Alias       string                          `json:"alias" validate:"checkalias" optional:"true"`
+	Description string                          `json:"description" optional:"true"`
+	Variables   []model.ProjectTemplateVariable `json:"variables" optional:"true"`
+	Spec        model.ProjectTemplateSpec       `json:"spec"`
 }
 
 // UpdateProjectRequest update a project request body
@@ -841,6 +1157,34 @@ type UpdateProjectRequest struct {
 	Owner       string `json:"owner" optional:"true"`
 }
 
+// UpdateProjectQuotaRequest update the quota of the project, the zero value of the limits means unlimited
+type UpdateProjectQuotaRequest struct {
+	MaxApplications           int               `json:"maxApplications" validate:"min=0" optional:"true"`
+	MaxEnvs                   int               `json:"maxEnvs" validate:"min=0" optional:"true"`
+	MaxTargets                int               `json:"maxTargets" validate:"min=0" optional:"true"`
+	MaxComponentsPerApp       int               `json:"maxComponentsPerApp" validate:"min=0" optional:"true"`
+	MaxConcurrentPipelineRuns int               `json:"maxConcurrentPipelineRuns" validate:"min=0" optional:"true"`
+	ResourceQuota             map[string]string `json:"resourceQuota" optional:"true"`
+	DefaultLimits             map[string]string `json:"defaultLimits" optional:"true"`
+	DefaultRequests           map[string]string `json:"defaultRequests" optional:"true"`
+}
+
+// ProjectQuotaResponse the quota and the current usage of the project
+type ProjectQuotaResponse struct {
+	Quota model.ProjectQuota `json:"quota"`
+	Usage ProjectQuotaUsage  `json:"usage"`
+}
+
+// ProjectQuotaUsage the current usage of the project
+type ProjectQuotaUsage struct {
+	Applications int64 `json:"applications"`
+	Envs         int64 `json:"envs"`
+	Targets      int64 `json:"targets"`
+	// MaxComponentsPerApp the component count of the largest application in the project
+	MaxComponentsPerApp    int64 `json:"maxComponentsPerApp"`
+	ConcurrentPipelineRuns int64 `json:"concurrentPipelineRuns"`
+}
+
 // Env models the data of env in API
 type Env struct {
 	Name        string `json:"name"`
@@ -858,6 +1202,24 @@ type Env struct {
 
 	CreateTime time.Time `json:"createTime"`
 	UpdateTime time.Time `json:"updateTime"`
+
+	// Health the result of the latest namespace reconciliation
+	Health *model.NamespaceHealth `json:"health,omitempty" optional:"true"`
+}
+
+// NamespaceHealthItem the namespace health of one target or env
+type NamespaceHealthItem struct {
+	// Kind the kind of the owner, target or env
+	Kind      string                 `json:"kind"`
+	Name      string                 `json:"name"`
+	Cluster   string                 `json:"cluster"`
+	Namespace string                 `json:"namespace"`
+	Health    *model.NamespaceHealth `json:"health,omitempty"`
+}
+
+// ListNamespaceHealthResponse the namespace health of the targets and the envs in the project
+type ListNamespaceHealthResponse struct {
+	Items []NamespaceHealthItem `json:"items"`
 }
 
 // ListEnvOptions list envs by query options
@@ -924,6 +1286,55 @@ type UpdateDefinitionStatusRequest struct {
 	HiddenInUI     bool   `json:"hiddenInUI"`
 }
 
+// CreateDefinitionRequest the request body to create a definition from the CUE source, the type is read from the source
+type CreateDefinitionRequest struct {
+	CUE string `json:"cue" validate:"required"`
+}
+
+// UpdateDefinitionRequest the request body to update a definition with the CUE source
+type UpdateDefinitionRequest struct {
+	DefinitionType string `json:"type" validate:"required"`
+	CUE            string `json:"cue" validate:"required"`
+}
+
+// DefinitionCUEResponse the CUE source of the definition
+type DefinitionCUEResponse struct {
+	Name           string `json:"name"`
+	DefinitionType string `json:"type"`
+	CUE            string `json:"cue"`
+}
+
+// DefinitionRevision a revision of the CUE source of the definition applied by VelaUX
+type DefinitionRevision struct {
+	Revision   int64     `json:"revision"`
+	CUE        string    `json:"cue"`
+	Operator   string    `json:"operator,omitempty"`
+	CreateTime time.Time `json:"createTime"`
+	// RollbackFrom the revision restored by this revision, it is zero if the revision is not created by a rollback
+	RollbackFrom int64 `json:"rollbackFrom,omitempty"`
+}
+
+// ListDefinitionRevisionResponse the revisions of the definition, the latest one first
+type ListDefinitionRevisionResponse struct {
+	Revisions []*DefinitionRevision `json:"revisions"`
+}
+
+// PreviewDefinitionRequest the request body to render the template of the definition with the sample properties
+type PreviewDefinitionRequest struct {
+	CUE        string                 `json:"cue" validate:"required"`
+	Properties map[string]interface{} `json:"properties,omitempty"`
+	// Context the context variables referenced by the template, such as name, appName and namespace
+	Context map[string]interface{} `json:"context,omitempty"`
+}
+
+// PreviewDefinitionResponse the resources rendered by the template of the definition
+type PreviewDefinitionResponse struct {
+	Output    map[string]interface{} `json:"output,omitempty"`
+	Outputs   map[string]interface{} `json:"outputs,omitempty"`
+	APISchema *openapi3.Schema       `json:"schema"`
+	UISchema  schema.UISchema        `json:"uiSchema"`
+}
+
 // DefinitionBase is the definition base model
 type DefinitionBase struct {
 	Name        string            `json:"name"`
@@ -1250,8 +1661,12 @@ type UpdateTargetRequest struct {
 
 // ClusterTarget kubernetes delivery target
 type ClusterTarget struct {
-	ClusterName string `json:"clusterName" validate:"checkname"`
+	ClusterName string `json:"clusterName,omitempty" validate:"omitempty,checkname" optional:"true"`
 	Namespace   string `json:"namespace" optional:"true"`
+	// LabelSelector selects all clusters matching the labels, includes the clusters joined later
+	LabelSelector map[string]string `json:"labelSelector,omitempty" optional:"true"`
+	// ClusterGroup selects all clusters in the cluster group
+	ClusterGroup string `json:"clusterGroup,omitempty" optional:"true"`
 }
 
 // DetailTargetResponse detail Target response
@@ -1277,6 +1692,9 @@ type TargetBase struct {
 	UpdateTime   time.Time              `json:"updateTime"`
 	AppNum       int64                  `json:"appNum,omitempty"`
 	Project      NameAlias              `json:"project"`
+	Health       *model.NamespaceHealth `json:"health,omitempty"`
+	// ResolvedClusters the clusters selected by the labels or the cluster group at present
+	ResolvedClusters []string `json:"resolvedClusters,omitempty"`
 }
 
 // ApplicationRevisionBase application revision base spec
@@ -1368,6 +1786,8 @@ type LoginRequest struct {
 	Code     string `json:"code,omitempty" optional:"true"`
 	Username string `json:"username,omitempty" optional:"true"`
 	Password string `json:"password,omitempty" optional:"true"`
+	// NewPassword replaces the expired password while logging in
+	NewPassword string `json:"newPassword,omitempty" validate:"checkpassword" optional:"true"`
 }
 
 // LoginResponse is the response of login request
@@ -1375,6 +1795,34 @@ type LoginResponse struct {
 	User         *UserBase `json:"user"`
 	AccessToken  string    `json:"accessToken"`
 	RefreshToken string    `json:"refreshToken"`
+	// MFARequired means the user should verify the TOTP code with the MFA token to get the tokens
+	MFARequired bool   `json:"mfaRequired,omitempty"`
+	MFAToken    string `json:"mfaToken,omitempty"`
+}
+
+// LoginMFARequest is the request body for the second login step
+type LoginMFARequest struct {
+	MFAToken string `json:"mfaToken" validate:"required"`
+	// Code the TOTP code or one of the recovery codes
+	Code string `json:"code" validate:"required"`
+}
+
+// TOTPEnrollResponse is the response of enrolling the TOTP authenticator
+type TOTPEnrollResponse struct {
+	Secret string `json:"secret"`
+	// URI the otpauth URI, it could be rendered as a QR code
+	URI string `json:"uri"`
+}
+
+// TOTPVerifyRequest is the request body for verifying the TOTP code
+type TOTPVerifyRequest struct {
+	// Code the TOTP code or one of the recovery codes
+	Code string `json:"code" validate:"required"`
+}
+
+// TOTPRecoveryCodesResponse is the response of generating the recovery codes, they are shown only once
+type TOTPRecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recoveryCodes"`
 }
 
 // RefreshTokenResponse is the response of refresh token request
@@ -1383,6 +1831,31 @@ type RefreshTokenResponse struct {
 	RefreshToken string `json:"refreshToken"`
 }
 
+// SessionBase is the login session of the user
+type SessionBase struct {
+	ID           string    `json:"id"`
+	Username     string    `json:"username"`
+	UserAgent    string    `json:"userAgent,omitempty"`
+	IP           string    `json:"ip,omitempty"`
+	CreateTime   time.Time `json:"createTime"`
+	LastSeenTime time.Time `json:"lastSeenTime"`
+	ExpireTime   time.Time `json:"expireTime"`
+	// Current means the session of the request
+	Current bool `json:"current,omitempty"`
+}
+
+// ListSessionResponse is the response of listing the sessions
+type ListSessionResponse struct {
+	Sessions []*SessionBase `json:"sessions"`
+}
+
+// RotateSigningKeyResponse is the response of rotating the signing key
+type RotateSigningKeyResponse struct {
+	KeyID string `json:"keyID"`
+	// RetainedKeys the number of the retired keys kept to verify the issued tokens
+	RetainedKeys int `json:"retainedKeys"`
+}
+
 // DexConfigResponse is the response of dex config
 type DexConfigResponse struct {
 	ClientID     string `json:"clientID"`
@@ -1444,6 +1917,9 @@ type UserBase struct {
 	Email         string    `json:"email"`
 	Alias         string    `json:"alias,omitempty"`
 	Disabled      bool      `json:"disabled"`
+	Locked        bool      `json:"locked,omitempty"`
+	LockReason    string    `json:"lockReason,omitempty"`
+	TOTPEnabled   bool      `json:"totpEnabled,omitempty"`
 }
 
 // ListUserOptions list user options
@@ -1602,6 +2078,59 @@ type CloudShellPrepareResponse struct {
 	Message string `json:"message"`
 }
 
+// CloudShellSession the active cloud shell of a user
+type CloudShellSession struct {
+	User             string    `json:"user"`
+	Phase            string    `json:"phase"`
+	CreateTime       time.Time `json:"createTime"`
+	LastActivityTime time.Time `json:"lastActivityTime"`
+	// ExpireTime the cloud shell is deleted after this time if it is not accessed, empty means never
+	ExpireTime *time.Time `json:"expireTime,omitempty"`
+}
+
+// ListCloudShellSessionResponse the active cloud shells of the platform
+type ListCloudShellSessionResponse struct {
+	Sessions []*CloudShellSession `json:"sessions"`
+}
+
+// IssueKubeConfigRequest the request for issuing a kubeconfig to the current user
+type IssueKubeConfigRequest struct {
+	// ExpirationSeconds the kubeconfig expires after this period, the default expiration is used if it is zero
+	ExpirationSeconds int64 `json:"expirationSeconds,omitempty" validate:"min=0" optional:"true"`
+}
+
+// KubeConfigPrivilege the kubernetes privilege granted to the issued kubeconfig
+type KubeConfigPrivilege struct {
+	Type      string `json:"type"`
+	Cluster   string `json:"cluster"`
+	Namespace string `json:"namespace,omitempty"`
+	ReadOnly  bool   `json:"readOnly"`
+}
+
+// IssuedKubeConfigBase the record of the kubeconfig issued to a user
+type IssuedKubeConfigBase struct {
+	ID         string                `json:"id"`
+	Username   string                `json:"username"`
+	Group      string                `json:"group"`
+	Privileges []KubeConfigPrivilege `json:"privileges"`
+	CreateTime time.Time             `json:"createTime"`
+	ExpireTime time.Time             `json:"expireTime"`
+	Revoked    bool                  `json:"revoked"`
+	RevokeTime *time.Time            `json:"revokeTime,omitempty"`
+	Revoker    string                `json:"revoker,omitempty"`
+}
+
+// IssueKubeConfigResponse the kubeconfig issued to the current user
+type IssueKubeConfigResponse struct {
+	IssuedKubeConfigBase
+	KubeConfig string `json:"kubeconfig"`
+}
+
+// ListIssuedKubeConfigResponse the records of the issued kubeconfigs
+type ListIssuedKubeConfigResponse struct {
+	KubeConfigs []*IssuedKubeConfigBase `json:"kubeconfigs"`
+}
+
 // ConfigType define the format for listing configuration types
 type ConfigType struct {
 	Definitions []string `json:"definitions"`
@@ -1641,6 +2170,13 @@ type ListConfigDistributionResponse struct {
 	Distributions []*config.Distribution `json:"distributions"`
 }
 
+// ConfigDistributionStatusResponse the delivery status of the configs in the targets of the distribution
+type ConfigDistributionStatusResponse struct {
+	Name          string                     `json:"name"`
+	Targets       []model.ConfigTargetStatus `json:"targets"`
+	LastCheckTime time.Time                  `json:"lastCheckTime"`
+}
+
 /********************/
 /* Pipeline Structs */
 /********************/
@@ -1874,6 +2410,8 @@ type ManagedPluginDTO struct {
 	Enabled          bool                   `json:"enabled"`
 	JSONSetting      map[string]interface{} `json:"jsonSetting"`
 	SecureJSONFields map[string]bool        `json:"secureJsonFields"`
+	// RateLimits the current state of the proxy rate limiters, only for the detail API.
+	RateLimits []limiter.State `json:"rateLimits,omitempty"`
 }
 
 // PluginDTO the model for the common user.
@@ -1916,3 +2454,87 @@ type PluginSetRequest struct {
 
 // PluginEnableRequest plugin enable request model
 type PluginEnableRequest PluginSetRequest
+
+// PluginStorageScope the scope of the plugin storage request
+type PluginStorageScope struct {
+	Scope pluginTypes.StorageScope `json:"scope"`
+	// Name is the project name for the project scope and the username for the user scope.
+	Name string `json:"name,omitempty"`
+}
+
+// PluginStorageItem the item saved in the plugin storage
+type PluginStorageItem struct {
+	Key        string                   `json:"key"`
+	Scope      pluginTypes.StorageScope `json:"scope"`
+	ScopeName  string                   `json:"scopeName,omitempty"`
+	Value      *model.JSONStruct        `json:"value,omitempty"`
+	Size       int64                    `json:"size"`
+	Creator    string                   `json:"creator,omitempty"`
+	CreateTime time.Time                `json:"createTime"`
+	UpdateTime time.Time                `json:"updateTime"`
+}
+
+// PutPluginStorageItemRequest the request body to save an item to the plugin storage
+type PutPluginStorageItemRequest struct {
+	Value *model.JSONStruct `json:"value" validate:"required"`
+}
+
+// ListPluginStorageItemsResponse the response body of listing the plugin storage items
+type ListPluginStorageItemsResponse struct {
+	Items []*PluginStorageItem `json:"items"`
+	Total int64                `json:"total"`
+	// Usage the bytes of all values in the scope
+	Usage int64 `json:"usage"`
+	// Quota the max bytes of all values in the scope
+	Quota int64 `json:"quota"`
+}
+
+// PluginEventType the type of the plugin change event
+type PluginEventType string
+
+const (
+	// PluginEventAdded means a new plugin is loaded
+	PluginEventAdded PluginEventType = "added"
+	// PluginEventUpdated means the plugin is reloaded because the files changed
+	PluginEventUpdated PluginEventType = "updated"
+	// PluginEventRemoved means the plugin is removed
+	PluginEventRemoved PluginEventType = "removed"
+)
+
+// PluginEvent the event generated after the plugins reloaded, the UI could reload the plugin modules.
+type PluginEvent struct {
+	Revision int64           `json:"revision"`
+	Type     PluginEventType `json:"type"`
+	PluginID string          `json:"pluginID"`
+	Time     time.Time       `json:"time"`
+}
+
+// ListPluginEventsResponse the response body of listing the plugin events
+type ListPluginEventsResponse struct {
+	// Revision the latest revision, the client should use it as the since parameter in the next request.
+	Revision int64         `json:"revision"`
+	Events   []PluginEvent `json:"events"`
+}
+
+// PluginHookPayload the signed JSON payload sent to the plugin hooks
+type PluginHookPayload struct {
+	// UID the unique ID of this delivery, it is the same for all hooks that receive the same event.
+	UID   string                `json:"uid"`
+	Event pluginTypes.HookEvent `json:"event"`
+	// Type is validating means the action has not happened, the hook could refuse it.
+	Type           pluginTypes.HookType      `json:"type"`
+	Time           time.Time                 `json:"time"`
+	User           string                    `json:"user,omitempty"`
+	Project        *ProjectBase              `json:"project,omitempty"`
+	Application    *ApplicationBase          `json:"application,omitempty"`
+	DeployRequest  *ApplicationDeployRequest `json:"deployRequest,omitempty"`
+	WorkflowRecord *WorkflowRecordBase       `json:"workflowRecord,omitempty"`
+	ClusterHealth  *ClusterHealthSnapshot    `json:"clusterHealth,omitempty"`
+}
+
+// PluginHookResponse the response body of the validating hooks
+type PluginHookResponse struct {
+	Allowed bool `json:"allowed"`
+	// Message the reason why the action is refused, it will be shown to the user.
+	Message string `json:"message,omitempty"`
+}
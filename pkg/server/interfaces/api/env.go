@@ -21,6 +21,7 @@ import (
 	"github.com/emicklei/go-restful/v3"
 	"k8s.io/klog/v2"
 
+	"github.com/kubevela/velaux/pkg/server/domain/model"
 	"github.com/kubevela/velaux/pkg/server/domain/service"
 	apis "github.com/kubevela/velaux/pkg/server/interfaces/api/dto/v1"
 	"github.com/kubevela/velaux/pkg/server/utils"
@@ -31,6 +32,9 @@ type env struct {
 	EnvService         service.EnvService         `inject:""`
 	ApplicationService service.ApplicationService `inject:""`
 	RBACService        service.RBACService        `inject:""`
+
+	NamespaceReconcileService service.NamespaceReconcileService `inject:""`
+	SessionService            service.SessionService            `inject:""`
 }
 
 // NewEnv new env
@@ -85,7 +89,18 @@ func (n *env) GetWebServiceRoute() *restful.WebService {
 		Returns(400, "Bad Request", bcode.Bcode{}).
 		Writes(apis.EmptyResponse{}))
 
-	ws.Filter(authCheckFilter)
+	ws.Route(ws.POST("/{envName}/reconcile").To(n.reconcile).
+		Operation("envreconcile").
+		Doc("check the namespace and the privileges of the env, the drifts could be repaired").
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Filter(n.RBACService.CheckPerm("environment", "update")).
+		Param(ws.PathParameter("envName", "identifier of the environment").DataType("string")).
+		Param(ws.QueryParameter("repair", "repair the drifts").DataType("boolean")).
+		Returns(200, "OK", model.NamespaceHealth{}).
+		Returns(400, "Bad Request", bcode.Bcode{}).
+		Writes(model.NamespaceHealth{}))
+
+	ws.Filter(authCheckFilter(n.SessionService))
 	return ws
 }
 
@@ -184,3 +199,21 @@ func (n *env) update(req *restful.Request, res *restful.Response) {
 		return
 	}
 }
+
+func (n *env) reconcile(req *restful.Request, res *restful.Response) {
+	ctx := req.Request.Context()
+	env, err := n.EnvService.GetEnv(ctx, req.PathParameter("envName"))
+	if err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+	health, err := n.NamespaceReconcileService.ReconcileEnv(ctx, env, req.QueryParameter("repair") == "true")
+	if err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+	if err := res.WriteEntity(health); err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+}
@@ -19,6 +19,7 @@ package api
 import (
 	"net/http"
 
+	"github.com/kubevela/velaux/pkg/server/domain/service"
 	apisv1 "github.com/kubevela/velaux/pkg/server/interfaces/api/dto/v1"
 
 	"github.com/emicklei/go-restful/v3"
@@ -29,7 +30,7 @@ var versionPrefix = "/api/v1"
 
 // GetAPIPrefix return the prefix of the api route path
 func GetAPIPrefix() []string {
-	return []string{versionPrefix, viewPrefix, "/v1"}
+	return []string{versionPrefix, viewPrefix, "/v1", service.SCIMBasePath}
 }
 
 // viewPrefix the path prefix for view page
@@ -66,6 +67,7 @@ func InitAPIBean() []interface{} {
 	// Application
 	RegisterAPI(NewApplication())
 	RegisterAPI(NewProject())
+	RegisterAPI(NewProjectTemplate())
 	RegisterAPI(NewEnv())
 	RegisterAPI(NewPipeline())
 
@@ -74,6 +76,7 @@ func InitAPIBean() []interface{} {
 	RegisterAPI(NewAddon())
 	RegisterAPI(NewEnabledAddon())
 	RegisterAPI(NewAddonRegistry())
+	RegisterAPI(NewOfflineAddonRegistry())
 	RegisterAPI(NewPlugin())
 	RegisterAPI(NewManagePlugin())
 
@@ -83,6 +86,7 @@ func InitAPIBean() []interface{} {
 
 	// Resources
 	RegisterAPI(NewCluster())
+	RegisterAPI(NewClusterGroup())
 	RegisterAPI(NewOAMApplication())
 	RegisterAPI(NewPayloadTypes())
 	RegisterAPI(NewTarget())
@@ -96,6 +100,8 @@ func InitAPIBean() []interface{} {
 	RegisterAPI(NewUser())
 	RegisterAPI(NewSystemInfo())
 	RegisterAPI(NewCloudShellView())
+	RegisterAPI(NewSCIM())
+	RegisterAPI(NewSCIMConfig())
 
 	// RBAC
 	RegisterAPI(NewRBAC())
@@ -29,6 +29,7 @@ import (
 type oamApplication struct {
 	OamApplicationService service.OAMApplicationService `inject:""`
 	RbacService           service.RBACService           `inject:""`
+	SessionService        service.SessionService        `inject:""`
 }
 
 // NewOAMApplication new oam application
@@ -73,7 +74,7 @@ func (c *oamApplication) GetWebServiceRoute() *restful.WebService {
 		Param(ws.PathParameter("namespace", "identifier of the namespace").DataType("string")).
 		Param(ws.PathParameter("appname", "identifier of the oam application").DataType("string")))
 
-	ws.Filter(authCheckFilter)
+	ws.Filter(authCheckFilter(c.SessionService))
 	return ws
 }
 
@@ -0,0 +1,99 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"net/http"
+	"strings"
+
+	restfulspec "github.com/emicklei/go-restful-openapi/v2"
+	"github.com/emicklei/go-restful/v3"
+	"k8s.io/klog/v2"
+
+	"github.com/kubevela/velaux/pkg/server/domain/service"
+	"github.com/kubevela/velaux/pkg/server/utils/bcode"
+)
+
+// mimeGzip the media type of the packaged addon
+const mimeGzip = "application/gzip"
+
+// maxOfflineAddonSize the max size of the addon package uploaded to the offline addon registry
+const maxOfflineAddonSize = 50 << 20
+
+type offlineAddonRegistry struct {
+	AddonService service.AddonService `inject:""`
+}
+
+// NewOfflineAddonRegistry serves the offline addon registries as the helm repositories, the KubeVela addon client
+// pulls the addons from them by the basic auth of the registries
+func NewOfflineAddonRegistry() Interface {
+	return &offlineAddonRegistry{}
+}
+
+func (s *offlineAddonRegistry) GetWebServiceRoute() *restful.WebService {
+	ws := new(restful.WebService)
+	ws.Path(service.OfflineAddonRegistryBasePath).
+		Produces(restful.MIME_JSON, "application/x-yaml", mimeGzip, restful.MIME_OCTET).
+		Doc("api for pulling the addons from the offline addon registries")
+
+	tags := []string{"addon_registry"}
+
+	ws.Route(ws.GET("/{addonRegName}/{file}").To(s.getFile).
+		Doc("get the index(index.yaml) or an addon package of the offline addon registry").
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Param(ws.PathParameter("addonRegName", "identifier of the addon registry").DataType("string")).
+		Param(ws.PathParameter("file", "index.yaml or the file name of the addon package").DataType("string")).
+		Returns(200, "OK", nil).
+		Returns(401, "Unauthorized", bcode.Bcode{}).
+		Returns(404, "Not Found", bcode.Bcode{}))
+
+	ws.Filter(s.basicAuthCheck)
+	return ws
+}
+
+// basicAuthCheck check the basic auth of the offline addon registry
+func (s *offlineAddonRegistry) basicAuthCheck(req *restful.Request, res *restful.Response, chain *restful.FilterChain) {
+	username, password, ok := req.Request.BasicAuth()
+	if !ok {
+		res.Header().Set("WWW-Authenticate", `Basic realm="offline addon registry"`)
+		bcode.ReturnError(req, res, bcode.ErrOfflineAddonRegistryUnauthorized)
+		return
+	}
+	if err := s.AddonService.CheckOfflineAddonRegistryToken(req.Request.Context(), req.PathParameter("addonRegName"), username, password); err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+	chain.ProcessFilter(req, res)
+}
+
+func (s *offlineAddonRegistry) getFile(req *restful.Request, res *restful.Response) {
+	file := req.PathParameter("file")
+	data, err := s.AddonService.GetOfflineAddonRegistryFile(req.Request.Context(), req.PathParameter("addonRegName"), file)
+	if err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+	contentType := mimeGzip
+	if !strings.HasSuffix(file, ".tgz") {
+		contentType = "application/x-yaml"
+	}
+	res.Header().Set("Content-Type", contentType)
+	res.WriteHeader(http.StatusOK)
+	if _, err := res.Write(data); err != nil {
+		klog.Errorf("failed to write the file %s of the offline addon registry: %s", file, err.Error())
+	}
+}
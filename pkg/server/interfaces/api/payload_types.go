@@ -25,6 +25,7 @@ import (
 )
 
 type payloadTypes struct {
+	SessionService service.SessionService `inject:""`
 }
 
 // NewPayloadTypes new -
@@ -48,7 +49,7 @@ func (c *payloadTypes) GetWebServiceRoute() *restful.WebService {
 		Returns(400, "Bad Request", bcode.Bcode{}).
 		Writes([]string{}))
 
-	ws.Filter(authCheckFilter)
+	ws.Filter(authCheckFilter(c.SessionService))
 	return ws
 }
 
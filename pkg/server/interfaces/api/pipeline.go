@@ -214,7 +214,7 @@ func initPipelineRoutes(ws *restful.WebService, n *project) {
 		Returns(400, "Bad Request", bcode.Bcode{}).
 		Writes(apis.EmptyResponse{}).Do(meta, projParam, pipelineParam, runParam))
 
-	ws.Filter(authCheckFilter)
+	ws.Filter(authCheckFilter(n.SessionService))
 }
 
 // GetWebServiceRoute is the implementation of pipeline Interface
@@ -240,12 +240,13 @@ func (n *pipeline) GetWebServiceRoute() *restful.WebService {
 		Returns(400, "Bad Request", bcode.Bcode{}).
 		Writes(apis.ListPipelineResponse{}).Do(meta))
 
-	ws.Filter(authCheckFilter)
+	ws.Filter(authCheckFilter(n.SessionService))
 	return ws
 }
 
 type pipeline struct {
 	PipelineService service.PipelineService `inject:""`
+	SessionService  service.SessionService  `inject:""`
 }
 
 // NewPipeline new pipeline manage
@@ -17,11 +17,18 @@ limitations under the License.
 package api
 
 import (
+	"context"
+	"strconv"
+
 	restfulspec "github.com/emicklei/go-restful-openapi/v2"
 	"github.com/emicklei/go-restful/v3"
+	"github.com/julienschmidt/httprouter"
 
+	"github.com/kubevela/velaux/pkg/plugin/router"
+	"github.com/kubevela/velaux/pkg/plugin/types"
 	"github.com/kubevela/velaux/pkg/server/domain/service"
 	apis "github.com/kubevela/velaux/pkg/server/interfaces/api/dto/v1"
+	"github.com/kubevela/velaux/pkg/server/utils"
 	"github.com/kubevela/velaux/pkg/server/utils/bcode"
 )
 
@@ -37,14 +44,17 @@ func NewManagePlugin() Interface {
 
 // Plugin web service
 type Plugin struct {
-	RBACService   service.RBACService   `inject:""`
-	PluginService service.PluginService `inject:""`
+	RBACService          service.RBACService          `inject:""`
+	PluginService        service.PluginService        `inject:""`
+	PluginStorageService service.PluginStorageService `inject:""`
+	SessionService       service.SessionService       `inject:""`
 }
 
 // ManagePlugin the web service to manage the plugin
 type ManagePlugin struct {
-	RBACService   service.RBACService   `inject:""`
-	PluginService service.PluginService `inject:""`
+	RBACService    service.RBACService    `inject:""`
+	PluginService  service.PluginService  `inject:""`
+	SessionService service.SessionService `inject:""`
 }
 
 // GetWebServiceRoute get web service
@@ -63,13 +73,72 @@ func (p *Plugin) GetWebServiceRoute() *restful.WebService {
 		Returns(200, "OK", apis.ListPluginResponse{}).
 		Writes(apis.ListPluginResponse{}).Do(returns200, returns500))
 
+	ws.Route(ws.GET("/events").To(p.listPluginEvents).
+		Doc("List the plugin change events, the events only are generated in the plugin development mode").
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Param(ws.QueryParameter("since", "only list the events after this revision").DataType("integer")).
+		Returns(200, "OK", apis.ListPluginEventsResponse{}).
+		Returns(400, "Bad Request", bcode.Bcode{}).
+		Writes(apis.ListPluginEventsResponse{}))
+
 	ws.Route(ws.GET("/{pluginId}").To(p.detailPlugin).
 		Doc("Detail an installed plugin").
 		Metadata(restfulspec.KeyOpenAPITags, tags).
 		Returns(200, "OK", apis.PluginDTO{}).
 		Writes(apis.PluginDTO{}).Do(returns200, returns500))
 
-	ws.Filter(authCheckFilter)
+	ws.Route(ws.GET("/{pluginId}/storage").To(p.listStorageItems).
+		Doc("List the items in the plugin storage").
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Filter(p.storageCheckFilter("list")).
+		Param(ws.PathParameter("pluginId", "identifier of the plugin").DataType("string")).
+		Param(ws.QueryParameter("scope", "the storage scope, includes: platform, project and user").DataType("string")).
+		Param(ws.QueryParameter("project", "the project name, it is required by the project scope").DataType("string")).
+		Param(ws.QueryParameter("prefix", "only list the items that the key has this prefix").DataType("string")).
+		Param(ws.QueryParameter("page", "query the page number").DataType("integer")).
+		Param(ws.QueryParameter("pageSize", "query the page size number").DataType("integer")).
+		Returns(200, "OK", apis.ListPluginStorageItemsResponse{}).
+		Returns(400, "Bad Request", bcode.Bcode{}).
+		Writes(apis.ListPluginStorageItemsResponse{}))
+
+	ws.Route(ws.GET("/{pluginId}/storage/{key}").To(p.getStorageItem).
+		Doc("Get an item from the plugin storage").
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Filter(p.storageCheckFilter("detail")).
+		Param(ws.PathParameter("pluginId", "identifier of the plugin").DataType("string")).
+		Param(ws.PathParameter("key", "the key of the item").DataType("string")).
+		Param(ws.QueryParameter("scope", "the storage scope, includes: platform, project and user").DataType("string")).
+		Param(ws.QueryParameter("project", "the project name, it is required by the project scope").DataType("string")).
+		Returns(200, "OK", apis.PluginStorageItem{}).
+		Returns(400, "Bad Request", bcode.Bcode{}).
+		Writes(apis.PluginStorageItem{}))
+
+	ws.Route(ws.PUT("/{pluginId}/storage/{key}").To(p.putStorageItem).
+		Doc("Create or update an item in the plugin storage").
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Filter(p.storageCheckFilter("update")).
+		Param(ws.PathParameter("pluginId", "identifier of the plugin").DataType("string")).
+		Param(ws.PathParameter("key", "the key of the item").DataType("string")).
+		Param(ws.QueryParameter("scope", "the storage scope, includes: platform, project and user").DataType("string")).
+		Param(ws.QueryParameter("project", "the project name, it is required by the project scope").DataType("string")).
+		Reads(apis.PutPluginStorageItemRequest{}).
+		Returns(200, "OK", apis.PluginStorageItem{}).
+		Returns(400, "Bad Request", bcode.Bcode{}).
+		Writes(apis.PluginStorageItem{}))
+
+	ws.Route(ws.DELETE("/{pluginId}/storage/{key}").To(p.deleteStorageItem).
+		Doc("Delete an item from the plugin storage").
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Filter(p.storageCheckFilter("delete")).
+		Param(ws.PathParameter("pluginId", "identifier of the plugin").DataType("string")).
+		Param(ws.PathParameter("key", "the key of the item").DataType("string")).
+		Param(ws.QueryParameter("scope", "the storage scope, includes: platform, project and user").DataType("string")).
+		Param(ws.QueryParameter("project", "the project name, it is required by the project scope").DataType("string")).
+		Returns(200, "OK", apis.EmptyResponse{}).
+		Returns(400, "Bad Request", bcode.Bcode{}).
+		Writes(apis.EmptyResponse{}))
+
+	ws.Filter(authCheckFilter(p.SessionService))
 	return ws
 }
 
@@ -119,7 +188,7 @@ func (p *ManagePlugin) GetWebServiceRoute() *restful.WebService {
 		Returns(200, "OK", apis.ManagedPluginDTO{}).
 		Writes(apis.PluginDTO{}).Do(returns200, returns500))
 
-	ws.Filter(authCheckFilter)
+	ws.Filter(authCheckFilter(p.SessionService))
 	return ws
 }
 
@@ -136,6 +205,22 @@ func (p *Plugin) listEnabledPlugins(req *restful.Request, res *restful.Response)
 	}
 }
 
+func (p *Plugin) listPluginEvents(req *restful.Request, res *restful.Response) {
+	var since int64
+	if value := req.QueryParameter("since"); value != "" {
+		s, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			bcode.ReturnError(req, res, bcode.ErrInvalidPluginEventRevision)
+			return
+		}
+		since = s
+	}
+	if err := res.WriteEntity(p.PluginService.ListPluginEvents(req.Request.Context(), since)); err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+}
+
 func (p *Plugin) detailPlugin(req *restful.Request, res *restful.Response) {
 	plugin, err := p.PluginService.DetailPlugin(req.Request.Context(), req.PathParameter("pluginId"))
 	if err != nil {
@@ -149,6 +234,107 @@ func (p *Plugin) detailPlugin(req *restful.Request, res *restful.Response) {
 	}
 }
 
+// storageCheckFilter checking the plugin storage and the permission of the request.
+// The data in the user scope only belongs to the current user, so the RBAC checking is skipped.
+// For the other scopes, the permission is checked with the resource declared by the plugin, such as plugin:{pluginName}/storage:*
+func (p *Plugin) storageCheckFilter(action string) restful.FilterFunction {
+	return func(req *restful.Request, res *restful.Response, chain *restful.FilterChain) {
+		plugin, err := p.PluginStorageService.GetStoragePlugin(req.Request.Context(), req.PathParameter("pluginId"))
+		if err != nil {
+			bcode.ReturnError(req, res, err)
+			return
+		}
+		scope, err := p.PluginStorageService.ResolveScope(req.Request.Context(), plugin, types.StorageScope(req.QueryParameter("scope")), req.QueryParameter("project"))
+		if err != nil {
+			bcode.ReturnError(req, res, err)
+			return
+		}
+		if scope.Scope != types.StorageScopeUser {
+			params := httprouter.Params{{Key: router.DefaultPluginResourceKey, Value: plugin.PluginID()}}
+			if scope.Scope == types.StorageScopeProject {
+				params = append(params, httprouter.Param{Key: "projectName", Value: scope.Name})
+			}
+			route := &types.Route{Permission: &types.Permission{Resource: plugin.Storage.GetResource(), Action: action}}
+			if !p.RBACService.CheckPluginRequestPerm(params, route)(req.Request, res.ResponseWriter) {
+				return
+			}
+		}
+		ctx := context.WithValue(req.Request.Context(), &apis.CtxKeyPlugin, plugin)
+		ctx = context.WithValue(ctx, &apis.CtxKeyPluginStorageScope, scope)
+		req.Request = req.Request.WithContext(ctx)
+		chain.ProcessFilter(req, res)
+	}
+}
+
+func (p *Plugin) listStorageItems(req *restful.Request, res *restful.Response) {
+	page, pageSize, err := utils.ExtractPagingParams(req, minPageSize, maxPageSize)
+	if err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+	plugin := req.Request.Context().Value(&apis.CtxKeyPlugin).(*types.Plugin)
+	scope := req.Request.Context().Value(&apis.CtxKeyPluginStorageScope).(*apis.PluginStorageScope)
+	items, err := p.PluginStorageService.ListItems(req.Request.Context(), plugin, *scope, req.QueryParameter("prefix"), page, pageSize)
+	if err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+	if err := res.WriteEntity(items); err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+}
+
+func (p *Plugin) getStorageItem(req *restful.Request, res *restful.Response) {
+	plugin := req.Request.Context().Value(&apis.CtxKeyPlugin).(*types.Plugin)
+	scope := req.Request.Context().Value(&apis.CtxKeyPluginStorageScope).(*apis.PluginStorageScope)
+	item, err := p.PluginStorageService.GetItem(req.Request.Context(), plugin, *scope, req.PathParameter("key"))
+	if err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+	if err := res.WriteEntity(item); err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+}
+
+func (p *Plugin) putStorageItem(req *restful.Request, res *restful.Response) {
+	var putReq apis.PutPluginStorageItemRequest
+	if err := req.ReadEntity(&putReq); err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+	if err := validate.Struct(&putReq); err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+	plugin := req.Request.Context().Value(&apis.CtxKeyPlugin).(*types.Plugin)
+	scope := req.Request.Context().Value(&apis.CtxKeyPluginStorageScope).(*apis.PluginStorageScope)
+	item, err := p.PluginStorageService.PutItem(req.Request.Context(), plugin, *scope, req.PathParameter("key"), putReq)
+	if err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+	if err := res.WriteEntity(item); err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+}
+
+func (p *Plugin) deleteStorageItem(req *restful.Request, res *restful.Response) {
+	plugin := req.Request.Context().Value(&apis.CtxKeyPlugin).(*types.Plugin)
+	scope := req.Request.Context().Value(&apis.CtxKeyPluginStorageScope).(*apis.PluginStorageScope)
+	if err := p.PluginStorageService.DeleteItem(req.Request.Context(), plugin, *scope, req.PathParameter("key")); err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+	if err := res.WriteEntity(apis.EmptyResponse{}); err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+}
+
 func (p *ManagePlugin) listInstalledPlugins(req *restful.Request, res *restful.Response) {
 	plugins := p.PluginService.ListInstalledPlugins(req.Request.Context())
 	// Write back response data
@@ -17,6 +17,8 @@ limitations under the License.
 package api
 
 import (
+	"strconv"
+
 	restfulspec "github.com/emicklei/go-restful-openapi/v2"
 	"github.com/emicklei/go-restful/v3"
 	"k8s.io/klog/v2"
@@ -38,6 +40,9 @@ type project struct {
 	PipelineRunService service.PipelineRunService `inject:""`
 	ContextService     service.ContextService     `inject:""`
 	RBACService        service.RBACService        `inject:""`
+
+	NamespaceReconcileService service.NamespaceReconcileService `inject:""`
+	SessionService            service.SessionService            `inject:""`
 }
 
 // NewProject new project
@@ -87,13 +92,33 @@ func (n *project) GetWebServiceRoute() *restful.WebService {
 		Writes(apis.ProjectBase{}))
 
 	ws.Route(ws.DELETE("/{projectName}").To(n.deleteProject).
-		Doc("delete a project").
+		Doc("delete a project, the cascading deletion returns the project with the progress of the deletion").
 		Metadata(restfulspec.KeyOpenAPITags, tags).
 		Param(ws.PathParameter("projectName", "identifier of the project").DataType("string")).
+		Param(ws.QueryParameter("cascade", "delete the applications, the envs and the targets of the project in the background").DataType("boolean")).
 		Filter(n.RbacService.CheckPerm("project", "delete")).
 		Returns(200, "OK", apis.EmptyResponse{}).
+		Returns(400, "Bad Request", bcode.Bcode{}).
 		Writes(apis.EmptyResponse{}))
 
+	ws.Route(ws.POST("/{projectName}/archive").To(n.archiveProject).
+		Doc("archive a project, the archived project is read-only and hidden from the members until it is restored").
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Param(ws.PathParameter("projectName", "identifier of the project").DataType("string")).
+		Filter(n.RbacService.CheckPerm("project", "archive")).
+		Returns(200, "OK", apis.ProjectBase{}).
+		Returns(400, "Bad Request", bcode.Bcode{}).
+		Writes(apis.ProjectBase{}))
+
+	ws.Route(ws.POST("/{projectName}/restore").To(n.restoreProject).
+		Doc("restore an archived project in the retention period").
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Param(ws.PathParameter("projectName", "identifier of the project").DataType("string")).
+		Filter(n.RbacService.CheckPerm("project", "restore")).
+		Returns(200, "OK", apis.ProjectBase{}).
+		Returns(400, "Bad Request", bcode.Bcode{}).
+		Writes(apis.ProjectBase{}))
+
 	ws.Route(ws.GET("/{projectName}/targets").To(n.listProjectTargets).
 		Doc("get targets list belong to a project").
 		Metadata(restfulspec.KeyOpenAPITags, tags).
@@ -278,6 +303,39 @@ func (n *project) GetWebServiceRoute() *restful.WebService {
 		Returns(400, "Bad Request", bcode.Bcode{}).
 		Writes(apis.Config{}))
 
+	ws.Route(ws.GET("/{projectName}/configs/{configName}/revisions").To(n.listConfigRevisions).
+		Doc("list the revisions of a config in a project").
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Filter(n.RbacService.CheckPerm("project/config", "list")).
+		Param(ws.PathParameter("projectName", "identifier of the project").DataType("string").Required(true)).
+		Param(ws.PathParameter("configName", "identifier of the config").DataType("string")).
+		Returns(200, "OK", apis.ListConfigRevisionResponse{}).
+		Returns(400, "Bad Request", bcode.Bcode{}).
+		Writes(apis.ListConfigRevisionResponse{}))
+
+	ws.Route(ws.GET("/{projectName}/configs/{configName}/revisions/diff").To(n.diffConfigRevisions).
+		Doc("compare the properties of two revisions of a config in a project").
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Filter(n.RbacService.CheckPerm("project/config", "list")).
+		Param(ws.PathParameter("projectName", "identifier of the project").DataType("string").Required(true)).
+		Param(ws.PathParameter("configName", "identifier of the config").DataType("string")).
+		Param(ws.QueryParameter("base", "the base revision").DataType("integer").Required(true)).
+		Param(ws.QueryParameter("target", "the target revision").DataType("integer").Required(true)).
+		Returns(200, "OK", apis.ConfigRevisionDiffResponse{}).
+		Returns(400, "Bad Request", bcode.Bcode{}).
+		Writes(apis.ConfigRevisionDiffResponse{}))
+
+	ws.Route(ws.POST("/{projectName}/configs/{configName}/revisions/{revision}/rollback").To(n.rollbackConfig).
+		Doc("restore a config in a project to a revision and apply its distributions again").
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Filter(n.RbacService.CheckPerm("project/config", "distribute")).
+		Param(ws.PathParameter("projectName", "identifier of the project").DataType("string").Required(true)).
+		Param(ws.PathParameter("configName", "identifier of the config").DataType("string")).
+		Param(ws.PathParameter("revision", "the revision to restore").DataType("integer")).
+		Returns(200, "OK", apis.Config{}).
+		Returns(400, "Bad Request", bcode.Bcode{}).
+		Writes(apis.Config{}))
+
 	ws.Route(ws.POST("/{projectName}/distributions").To(n.applyDistribution).
 		Doc("apply the distribution job of the config").
 		Metadata(restfulspec.KeyOpenAPITags, tags).
@@ -307,6 +365,27 @@ func (n *project) GetWebServiceRoute() *restful.WebService {
 		Returns(400, "Bad Request", bcode.Bcode{}).
 		Writes(apis.EmptyResponse{}))
 
+	ws.Route(ws.GET("/{projectName}/distributions/{distributionName}/status").To(n.getDistributionStatus).
+		Doc("get the delivery status of the configs in the targets of a distribution").
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Filter(n.RbacService.CheckPerm("project/config", "distribute")).
+		Param(ws.PathParameter("projectName", "identifier of the project").DataType("string").Required(true)).
+		Param(ws.PathParameter("distributionName", "identifier of the distribution").DataType("string").Required(true)).
+		Param(ws.QueryParameter("refresh", "check the targets now instead of returning the last checked status").DataType("boolean")).
+		Returns(200, "OK", apis.ConfigDistributionStatusResponse{}).
+		Returns(400, "Bad Request", bcode.Bcode{}).
+		Writes(apis.ConfigDistributionStatusResponse{}))
+
+	ws.Route(ws.POST("/{projectName}/distributions/{distributionName}/resync").To(n.resyncDistribution).
+		Doc("deliver the configs to the failed or the drifted targets of a distribution again").
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Filter(n.RbacService.CheckPerm("project/config", "distribute")).
+		Param(ws.PathParameter("projectName", "identifier of the project").DataType("string").Required(true)).
+		Param(ws.PathParameter("distributionName", "identifier of the distribution").DataType("string").Required(true)).
+		Returns(200, "OK", apis.ConfigDistributionStatusResponse{}).
+		Returns(400, "Bad Request", bcode.Bcode{}).
+		Writes(apis.ConfigDistributionStatusResponse{}))
+
 	ws.Route(ws.GET("/{projectName}/providers").To(n.getProviders).
 		Doc("get providers which are in a project").
 		Metadata(restfulspec.KeyOpenAPITags, tags).
@@ -316,8 +395,36 @@ func (n *project) GetWebServiceRoute() *restful.WebService {
 		Returns(400, "Bad Request", bcode.Bcode{}).
 		Writes(apis.ListTerraformProviderResponse{}))
 
+	ws.Route(ws.GET("/{projectName}/quota").To(n.getProjectQuota).
+		Doc("get the quota and the current usage of a project").
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Filter(n.RbacService.CheckPerm("project", "detail")).
+		Param(ws.PathParameter("projectName", "identifier of the project").DataType("string").Required(true)).
+		Returns(200, "OK", apis.ProjectQuotaResponse{}).
+		Returns(400, "Bad Request", bcode.Bcode{}).
+		Writes(apis.ProjectQuotaResponse{}))
+
+	ws.Route(ws.PUT("/{projectName}/quota").To(n.updateProjectQuota).
+		Doc("update the quota of a project").
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Filter(n.RbacService.CheckPerm("project", "update")).
+		Param(ws.PathParameter("projectName", "identifier of the project").DataType("string").Required(true)).
+		Reads(apis.UpdateProjectQuotaRequest{}).
+		Returns(200, "OK", apis.ProjectQuotaResponse{}).
+		Returns(400, "Bad Request", bcode.Bcode{}).
+		Writes(apis.ProjectQuotaResponse{}))
+
+	ws.Route(ws.GET("/{projectName}/namespace_health").To(n.listNamespaceHealth).
+		Doc("list the namespace health of the targets and the envs in a project").
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Filter(n.RbacService.CheckPerm("project", "detail")).
+		Param(ws.PathParameter("projectName", "identifier of the project").DataType("string").Required(true)).
+		Returns(200, "OK", apis.ListNamespaceHealthResponse{}).
+		Returns(400, "Bad Request", bcode.Bcode{}).
+		Writes(apis.ListNamespaceHealthResponse{}))
+
 	initPipelineRoutes(ws, n)
-	ws.Filter(authCheckFilter)
+	ws.Filter(authCheckFilter(n.SessionService))
 	return ws
 }
 
@@ -404,6 +511,18 @@ func (n *project) detailProject(req *restful.Request, res *restful.Response) {
 }
 
 func (n *project) deleteProject(req *restful.Request, res *restful.Response) {
+	if cascade, _ := strconv.ParseBool(req.QueryParameter("cascade")); cascade {
+		project, err := n.ProjectService.DeleteProjectCascade(req.Request.Context(), req.PathParameter("projectName"))
+		if err != nil {
+			bcode.ReturnError(req, res, err)
+			return
+		}
+		if err := res.WriteEntity(project); err != nil {
+			bcode.ReturnError(req, res, err)
+			return
+		}
+		return
+	}
 	err := n.ProjectService.DeleteProject(req.Request.Context(), req.PathParameter("projectName"))
 	if err != nil {
 		bcode.ReturnError(req, res, err)
@@ -862,3 +981,148 @@ func (n *project) deleteDistribution(req *restful.Request, res *restful.Response
 		return
 	}
 }
+
+func (n *project) getDistributionStatus(req *restful.Request, res *restful.Response) {
+	refresh, _ := strconv.ParseBool(req.QueryParameter("refresh"))
+	status, err := n.ConfigService.GetConfigDistributionStatus(req.Request.Context(), req.PathParameter("projectName"), req.PathParameter("distributionName"), refresh)
+	if err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+	if err := res.WriteEntity(status); err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+}
+
+func (n *project) resyncDistribution(req *restful.Request, res *restful.Response) {
+	status, err := n.ConfigService.ResyncConfigDistribution(req.Request.Context(), req.PathParameter("projectName"), req.PathParameter("distributionName"))
+	if err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+	if err := res.WriteEntity(status); err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+}
+
+func (n *project) getProjectQuota(req *restful.Request, res *restful.Response) {
+	quota, err := n.ProjectService.GetProjectQuota(req.Request.Context(), req.PathParameter("projectName"))
+	if err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+	if err := res.WriteEntity(quota); err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+}
+
+func (n *project) updateProjectQuota(req *restful.Request, res *restful.Response) {
+	var updateReq apis.UpdateProjectQuotaRequest
+	if err := req.ReadEntity(&updateReq); err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+	if err := validate.Struct(&updateReq); err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+	quota, err := n.ProjectService.UpdateProjectQuota(req.Request.Context(), req.PathParameter("projectName"), updateReq)
+	if err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+	if err := res.WriteEntity(quota); err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+}
+
+func (n *project) listNamespaceHealth(req *restful.Request, res *restful.Response) {
+	health, err := n.NamespaceReconcileService.ListNamespaceHealth(req.Request.Context(), req.PathParameter("projectName"))
+	if err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+	if err := res.WriteEntity(health); err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+}
+
+func (n *project) archiveProject(req *restful.Request, res *restful.Response) {
+	project, err := n.ProjectService.ArchiveProject(req.Request.Context(), req.PathParameter("projectName"))
+	if err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+	if err := res.WriteEntity(project); err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+}
+
+func (n *project) restoreProject(req *restful.Request, res *restful.Response) {
+	project, err := n.ProjectService.RestoreProject(req.Request.Context(), req.PathParameter("projectName"))
+	if err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+	if err := res.WriteEntity(project); err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+}
+
+func (n *project) listConfigRevisions(req *restful.Request, res *restful.Response) {
+	revisions, err := n.ConfigService.ListConfigRevisions(req.Request.Context(), req.PathParameter("projectName"), req.PathParameter("configName"))
+	if err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+	if err := res.WriteEntity(revisions); err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+}
+
+func (n *project) diffConfigRevisions(req *restful.Request, res *restful.Response) {
+	base, err := parseConfigRevision(req.QueryParameter("base"))
+	if err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+	target, err := parseConfigRevision(req.QueryParameter("target"))
+	if err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+	diff, err := n.ConfigService.DiffConfigRevisions(req.Request.Context(), req.PathParameter("projectName"), req.PathParameter("configName"), base, target)
+	if err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+	if err := res.WriteEntity(diff); err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+}
+
+func (n *project) rollbackConfig(req *restful.Request, res *restful.Response) {
+	revision, err := parseConfigRevision(req.PathParameter("revision"))
+	if err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+	config, err := n.ConfigService.RollbackConfig(req.Request.Context(), req.PathParameter("projectName"), req.PathParameter("configName"), revision)
+	if err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+	if err := res.WriteEntity(config); err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+}
@@ -0,0 +1,191 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"context"
+
+	restfulspec "github.com/emicklei/go-restful-openapi/v2"
+	"github.com/emicklei/go-restful/v3"
+
+	"github.com/kubevela/velaux/pkg/server/domain/model"
+	"github.com/kubevela/velaux/pkg/server/domain/service"
+	apis "github.com/kubevela/velaux/pkg/server/interfaces/api/dto/v1"
+	"github.com/kubevela/velaux/pkg/server/utils/bcode"
+)
+
+type projectTemplate struct {
+	RbacService            service.RBACService            `inject:""`
+	ProjectTemplateService service.ProjectTemplateService `inject:""`
+	SessionService         service.SessionService         `inject:""`
+}
+
+// NewProjectTemplate new project template
+func NewProjectTemplate() Interface {
+	return &projectTemplate{}
+}
+
+func (n *projectTemplate) GetWebServiceRoute() *restful.WebService {
+	ws := new(restful.WebService)
+	ws.Path(versionPrefix+"/project_templates").
+		Consumes(restful.MIME_XML, restful.MIME_JSON).
+		Produces(restful.MIME_JSON, restful.MIME_XML).
+		Doc("api for project template manage")
+
+	tags := []string{"projectTemplate"}
+
+	ws.Route(ws.GET("/").To(n.listTemplates).
+		Doc("list all project templates").
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Filter(n.RbacService.CheckPerm("projectTemplate", "list")).
+		Returns(200, "OK", apis.ListProjectTemplateResponse{}).
+		Writes(apis.ListProjectTemplateResponse{}))
+
+	ws.Route(ws.POST("/").To(n.createTemplate).
+		Doc("create a project template").
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Filter(n.RbacService.CheckPerm("projectTemplate", "create")).
+		Reads(apis.CreateProjectTemplateRequest{}).
+		Returns(200, "OK", apis.ProjectTemplateBase{}).
+		Returns(400, "Bad Request", bcode.Bcode{}).
+		Writes(apis.ProjectTemplateBase{}))
+
+	ws.Route(ws.GET("/{templateName}").To(n.detailTemplate).
+		Doc("detail a project template").
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Param(ws.PathParameter("templateName", "identifier of the project template").DataType("string")).
+		Filter(n.RbacService.CheckPerm("projectTemplate", "detail")).
+		Filter(n.templateCheckFilter).
+		Returns(200, "OK", apis.ProjectTemplateBase{}).
+		Returns(400, "Bad Request", bcode.Bcode{}).
+		Writes(apis.ProjectTemplateBase{}))
+
+	ws.Route(ws.PUT("/{templateName}").To(n.updateTemplate).
+		Doc("update a project template, the created projects are not changed").
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Param(ws.PathParameter("templateName", "identifier of the project template").DataType("string")).
+		Filter(n.RbacService.CheckPerm("projectTemplate", "update")).
+		Filter(n.templateCheckFilter).
+		Reads(apis.UpdateProjectTemplateRequest{}).
+		Returns(200, "OK", apis.ProjectTemplateBase{}).
+		Returns(400, "Bad Request", bcode.Bcode{}).
+		Writes(apis.ProjectTemplateBase{}))
+
+	ws.Route(ws.DELETE("/{templateName}").To(n.deleteTemplate).
+		Doc("delete a project template").
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Param(ws.PathParameter("templateName", "identifier of the project template").DataType("string")).
+		Filter(n.RbacService.CheckPerm("projectTemplate", "delete")).
+		Returns(200, "OK", apis.EmptyResponse{}).
+		Returns(400, "Bad Request", bcode.Bcode{}).
+		Writes(apis.EmptyResponse{}))
+
+	ws.Filter(authCheckFilter(n.SessionService))
+	return ws
+}
+
+func (n *projectTemplate) templateCheckFilter(req *restful.Request, res *restful.Response, chain *restful.FilterChain) {
+	template, err := n.ProjectTemplateService.GetTemplate(req.Request.Context(), req.PathParameter("templateName"))
+	if err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+	req.Request = req.Request.WithContext(context.WithValue(req.Request.Context(), &apis.CtxKeyProjectTemplate, template))
+	chain.ProcessFilter(req, res)
+}
+
+func (n *projectTemplate) listTemplates(req *restful.Request, res *restful.Response) {
+	templates, err := n.ProjectTemplateService.ListTemplates(req.Request.Context())
+	if err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+	if err := res.WriteEntity(templates); err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+}
+
+func (n *projectTemplate) createTemplate(req *restful.Request, res *restful.Response) {
+	var createReq apis.CreateProjectTemplateRequest
+	if err := req.ReadEntity(&createReq); err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+	if err := validate.Struct(&createReq); err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+	template, err := n.ProjectTemplateService.CreateTemplate(req.Request.Context(), createReq)
+	if err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+	if err := res.WriteEntity(template); err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+}
+
+func (n *projectTemplate) detailTemplate(req *restful.Request, res *restful.Response) {
+	template := req.Request.Context().Value(&apis.CtxKeyProjectTemplate).(*model.ProjectTemplate)
+	if err := res.WriteEntity(apis.ProjectTemplateBase{
+		Name:        template.Name,
+		Alias:       template.Alias,
+		Description: template.Description,
+		Variables:   template.Variables,
+		Spec:        template.Spec,
+		CreateTime:  template.CreateTime,
+		UpdateTime:  template.UpdateTime,
+	}); err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+}
+
+func (n *projectTemplate) updateTemplate(req *restful.Request, res *restful.Response) {
+	template := req.Request.Context().Value(&apis.CtxKeyProjectTemplate).(*model.ProjectTemplate)
+	var updateReq apis.UpdateProjectTemplateRequest
+	if err := req.ReadEntity(&updateReq); err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+	if err := validate.Struct(&updateReq); err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+	base, err := n.ProjectTemplateService.UpdateTemplate(req.Request.Context(), template, updateReq)
+	if err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+	if err := res.WriteEntity(base); err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+}
+
+func (n *projectTemplate) deleteTemplate(req *restful.Request, res *restful.Response) {
+	if err := n.ProjectTemplateService.DeleteTemplate(req.Request.Context(), req.PathParameter("templateName")); err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+	if err := res.WriteEntity(apis.EmptyResponse{}); err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+}
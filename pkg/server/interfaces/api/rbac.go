@@ -28,7 +28,8 @@ import (
 )
 
 type rbac struct {
-	RbacService service.RBACService `inject:""`
+	RbacService    service.RBACService    `inject:""`
+	SessionService service.SessionService `inject:""`
 }
 
 // NewRBAC new rbac
@@ -100,7 +101,7 @@ func (r *rbac) GetWebServiceRoute() *restful.WebService {
 		Returns(200, "OK", apis.EmptyResponse{}).
 		Writes(apis.EmptyResponse{}))
 
-	ws.Filter(authCheckFilter)
+	ws.Filter(authCheckFilter(r.SessionService))
 	return ws
 }
 
@@ -30,9 +30,10 @@ import (
 )
 
 type repository struct {
-	HelmService  service.HelmService  `inject:""`
-	ImageService service.ImageService `inject:""`
-	RbacService  service.RBACService  `inject:""`
+	HelmService    service.HelmService    `inject:""`
+	ImageService   service.ImageService   `inject:""`
+	RbacService    service.RBACService    `inject:""`
+	SessionService service.SessionService `inject:""`
 }
 
 // NewRepository will return the repository
@@ -139,7 +140,7 @@ func (h repository) GetWebServiceRoute() *restful.WebService {
 		Returns(400, "Bad Request", bcode.Bcode{}).
 		Writes([]string{}))
 
-	ws.Filter(authCheckFilter)
+	ws.Filter(authCheckFilter(h.SessionService))
 	return ws
 }
 
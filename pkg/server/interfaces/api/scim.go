@@ -0,0 +1,456 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	restfulspec "github.com/emicklei/go-restful-openapi/v2"
+	"github.com/emicklei/go-restful/v3"
+	"k8s.io/klog/v2"
+
+	"github.com/kubevela/velaux/pkg/server/domain/service"
+	apis "github.com/kubevela/velaux/pkg/server/interfaces/api/dto/v1"
+	"github.com/kubevela/velaux/pkg/server/utils/bcode"
+)
+
+// mimeSCIM the media type of the SCIM protocol
+const mimeSCIM = "application/scim+json"
+
+func init() {
+	restful.RegisterEntityAccessor(mimeSCIM, restful.NewEntityAccessorJSON(mimeSCIM))
+}
+
+// scimTypes the scimType of the errors, see RFC 7644 section 3.12
+var scimTypes = map[int32]string{
+	bcode.ErrSCIMInvalidFilter.BusinessCode:   "invalidFilter",
+	bcode.ErrSCIMUserExist.BusinessCode:       "uniqueness",
+	bcode.ErrSCIMGroupExist.BusinessCode:      "uniqueness",
+	bcode.ErrSCIMInvalidPatch.BusinessCode:    "invalidValue",
+	bcode.ErrSCIMInvalidResource.BusinessCode: "invalidValue",
+}
+
+type scim struct {
+	SCIMService service.SCIMService `inject:""`
+}
+
+// NewSCIM new the SCIM 2.0 provisioning endpoint, it is authenticated by the dedicated bearer token
+func NewSCIM() Interface {
+	return &scim{}
+}
+
+func (s *scim) GetWebServiceRoute() *restful.WebService {
+	ws := new(restful.WebService)
+	ws.Path(service.SCIMBasePath).
+		Consumes(restful.MIME_JSON, mimeSCIM).
+		Produces(mimeSCIM, restful.MIME_JSON).
+		Doc("api for provisioning the users and the groups by SCIM 2.0")
+
+	tags := []string{"scim"}
+
+	ws.Route(ws.GET("/Users").To(s.listUsers).
+		Doc("list the users").
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Param(ws.QueryParameter("filter", "filter the users, such as userName eq \"alice\"").DataType("string")).
+		Param(ws.QueryParameter("startIndex", "the 1-based index of the first result").DataType("integer")).
+		Param(ws.QueryParameter("count", "the max number of the results").DataType("integer")).
+		Returns(200, "OK", apis.SCIMListResponse{}).
+		Returns(400, "Bad Request", apis.SCIMError{}).
+		Writes(apis.SCIMListResponse{}))
+
+	ws.Route(ws.POST("/Users").To(s.createUser).
+		Doc("create a user").
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Reads(apis.SCIMUser{}).
+		Returns(201, "Created", apis.SCIMUser{}).
+		Returns(400, "Bad Request", apis.SCIMError{}).
+		Writes(apis.SCIMUser{}))
+
+	ws.Route(ws.GET("/Users/{id}").To(s.getUser).
+		Doc("get a user").
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Param(ws.PathParameter("id", "identifier of the user").DataType("string")).
+		Returns(200, "OK", apis.SCIMUser{}).
+		Returns(404, "Not Found", apis.SCIMError{}).
+		Writes(apis.SCIMUser{}))
+
+	ws.Route(ws.PUT("/Users/{id}").To(s.replaceUser).
+		Doc("replace a user").
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Param(ws.PathParameter("id", "identifier of the user").DataType("string")).
+		Reads(apis.SCIMUser{}).
+		Returns(200, "OK", apis.SCIMUser{}).
+		Returns(400, "Bad Request", apis.SCIMError{}).
+		Writes(apis.SCIMUser{}))
+
+	ws.Route(ws.PATCH("/Users/{id}").To(s.patchUser).
+		Doc("patch a user, the user is disabled if the active attribute is false").
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Param(ws.PathParameter("id", "identifier of the user").DataType("string")).
+		Reads(apis.SCIMPatchRequest{}).
+		Returns(200, "OK", apis.SCIMUser{}).
+		Returns(400, "Bad Request", apis.SCIMError{}).
+		Writes(apis.SCIMUser{}))
+
+	ws.Route(ws.DELETE("/Users/{id}").To(s.deleteUser).
+		Doc("delete a user").
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Param(ws.PathParameter("id", "identifier of the user").DataType("string")).
+		Returns(204, "No Content", nil).
+		Returns(404, "Not Found", apis.SCIMError{}))
+
+	ws.Route(ws.GET("/Groups").To(s.listGroups).
+		Doc("list the groups").
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Param(ws.QueryParameter("filter", "filter the groups, such as displayName eq \"team\"").DataType("string")).
+		Param(ws.QueryParameter("startIndex", "the 1-based index of the first result").DataType("integer")).
+		Param(ws.QueryParameter("count", "the max number of the results").DataType("integer")).
+		Returns(200, "OK", apis.SCIMListResponse{}).
+		Returns(400, "Bad Request", apis.SCIMError{}).
+		Writes(apis.SCIMListResponse{}))
+
+	ws.Route(ws.POST("/Groups").To(s.createGroup).
+		Doc("create a group").
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Reads(apis.SCIMGroup{}).
+		Returns(201, "Created", apis.SCIMGroup{}).
+		Returns(400, "Bad Request", apis.SCIMError{}).
+		Writes(apis.SCIMGroup{}))
+
+	ws.Route(ws.GET("/Groups/{id}").To(s.getGroup).
+		Doc("get a group").
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Param(ws.PathParameter("id", "identifier of the group").DataType("string")).
+		Returns(200, "OK", apis.SCIMGroup{}).
+		Returns(404, "Not Found", apis.SCIMError{}).
+		Writes(apis.SCIMGroup{}))
+
+	ws.Route(ws.PUT("/Groups/{id}").To(s.replaceGroup).
+		Doc("replace a group").
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Param(ws.PathParameter("id", "identifier of the group").DataType("string")).
+		Reads(apis.SCIMGroup{}).
+		Returns(200, "OK", apis.SCIMGroup{}).
+		Returns(400, "Bad Request", apis.SCIMError{}).
+		Writes(apis.SCIMGroup{}))
+
+	ws.Route(ws.PATCH("/Groups/{id}").To(s.patchGroup).
+		Doc("patch a group").
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Param(ws.PathParameter("id", "identifier of the group").DataType("string")).
+		Reads(apis.SCIMPatchRequest{}).
+		Returns(200, "OK", apis.SCIMGroup{}).
+		Returns(400, "Bad Request", apis.SCIMError{}).
+		Writes(apis.SCIMGroup{}))
+
+	ws.Route(ws.DELETE("/Groups/{id}").To(s.deleteGroup).
+		Doc("delete a group").
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Param(ws.PathParameter("id", "identifier of the group").DataType("string")).
+		Returns(204, "No Content", nil).
+		Returns(404, "Not Found", apis.SCIMError{}))
+
+	ws.Filter(s.scimTokenCheck)
+	return ws
+}
+
+// scimTokenCheck check the dedicated bearer token of the SCIM client
+func (s *scim) scimTokenCheck(req *restful.Request, res *restful.Response, chain *restful.FilterChain) {
+	token := strings.TrimSpace(req.HeaderParameter("Authorization"))
+	if len(token) > 7 && strings.EqualFold(token[:7], "bearer ") {
+		token = strings.TrimSpace(token[7:])
+	} else {
+		token = ""
+	}
+	if err := s.SCIMService.CheckToken(req.Request.Context(), token); err != nil {
+		returnSCIMError(res, err)
+		return
+	}
+	chain.ProcessFilter(req, res)
+}
+
+// returnSCIMError write the error in the SCIM format
+func returnSCIMError(res *restful.Response, err error) {
+	status := http.StatusInternalServerError
+	scimErr := apis.SCIMError{Schemas: []string{apis.SCIMSchemaError}, Detail: err.Error()}
+	var b *bcode.Bcode
+	if errors.As(err, &b) {
+		status = int(b.HTTPCode)
+		scimErr.Detail = b.Message
+		scimErr.ScimType = scimTypes[b.BusinessCode]
+	} else {
+		klog.Errorf("failed to handle the SCIM request: %s", err.Error())
+	}
+	scimErr.Status = strconv.Itoa(status)
+	if err := res.WriteHeaderAndJson(status, scimErr, mimeSCIM); err != nil {
+		klog.Errorf("write entity failure %s", err.Error())
+	}
+}
+
+func writeSCIMEntity(res *restful.Response, status int, entity interface{}) {
+	if err := res.WriteHeaderAndJson(status, entity, mimeSCIM); err != nil {
+		klog.Errorf("write entity failure %s", err.Error())
+	}
+}
+
+// scimPage parse the pagination parameters, the negative count means no limit
+func scimPage(req *restful.Request) (int, int) {
+	startIndex, err := strconv.Atoi(req.QueryParameter("startIndex"))
+	if err != nil {
+		startIndex = 1
+	}
+	count, err := strconv.Atoi(req.QueryParameter("count"))
+	if err != nil {
+		count = -1
+	}
+	return startIndex, count
+}
+
+func (s *scim) listUsers(req *restful.Request, res *restful.Response) {
+	startIndex, count := scimPage(req)
+	users, err := s.SCIMService.ListUsers(req.Request.Context(), req.QueryParameter("filter"), startIndex, count)
+	if err != nil {
+		returnSCIMError(res, err)
+		return
+	}
+	writeSCIMEntity(res, http.StatusOK, users)
+}
+
+func (s *scim) createUser(req *restful.Request, res *restful.Response) {
+	var user apis.SCIMUser
+	if err := req.ReadEntity(&user); err != nil {
+		returnSCIMError(res, bcode.ErrSCIMInvalidResource.SetMessage(err.Error()))
+		return
+	}
+	created, err := s.SCIMService.CreateUser(req.Request.Context(), user)
+	if err != nil {
+		returnSCIMError(res, err)
+		return
+	}
+	writeSCIMEntity(res, http.StatusCreated, created)
+}
+
+func (s *scim) getUser(req *restful.Request, res *restful.Response) {
+	user, err := s.SCIMService.GetUser(req.Request.Context(), req.PathParameter("id"))
+	if err != nil {
+		returnSCIMError(res, err)
+		return
+	}
+	writeSCIMEntity(res, http.StatusOK, user)
+}
+
+func (s *scim) replaceUser(req *restful.Request, res *restful.Response) {
+	var user apis.SCIMUser
+	if err := req.ReadEntity(&user); err != nil {
+		returnSCIMError(res, bcode.ErrSCIMInvalidResource.SetMessage(err.Error()))
+		return
+	}
+	updated, err := s.SCIMService.ReplaceUser(req.Request.Context(), req.PathParameter("id"), user)
+	if err != nil {
+		returnSCIMError(res, err)
+		return
+	}
+	writeSCIMEntity(res, http.StatusOK, updated)
+}
+
+func (s *scim) patchUser(req *restful.Request, res *restful.Response) {
+	var patch apis.SCIMPatchRequest
+	if err := req.ReadEntity(&patch); err != nil {
+		returnSCIMError(res, bcode.ErrSCIMInvalidPatch.SetMessage(err.Error()))
+		return
+	}
+	updated, err := s.SCIMService.PatchUser(req.Request.Context(), req.PathParameter("id"), patch)
+	if err != nil {
+		returnSCIMError(res, err)
+		return
+	}
+	writeSCIMEntity(res, http.StatusOK, updated)
+}
+
+func (s *scim) deleteUser(req *restful.Request, res *restful.Response) {
+	if err := s.SCIMService.DeleteUser(req.Request.Context(), req.PathParameter("id")); err != nil {
+		returnSCIMError(res, err)
+		return
+	}
+	res.WriteHeader(http.StatusNoContent)
+}
+
+func (s *scim) listGroups(req *restful.Request, res *restful.Response) {
+	startIndex, count := scimPage(req)
+	groups, err := s.SCIMService.ListGroups(req.Request.Context(), req.QueryParameter("filter"), startIndex, count)
+	if err != nil {
+		returnSCIMError(res, err)
+		return
+	}
+	writeSCIMEntity(res, http.StatusOK, groups)
+}
+
+func (s *scim) createGroup(req *restful.Request, res *restful.Response) {
+	var group apis.SCIMGroup
+	if err := req.ReadEntity(&group); err != nil {
+		returnSCIMError(res, bcode.ErrSCIMInvalidResource.SetMessage(err.Error()))
+		return
+	}
+	created, err := s.SCIMService.CreateGroup(req.Request.Context(), group)
+	if err != nil {
+		returnSCIMError(res, err)
+		return
+	}
+	writeSCIMEntity(res, http.StatusCreated, created)
+}
+
+func (s *scim) getGroup(req *restful.Request, res *restful.Response) {
+	group, err := s.SCIMService.GetGroup(req.Request.Context(), req.PathParameter("id"))
+	if err != nil {
+		returnSCIMError(res, err)
+		return
+	}
+	writeSCIMEntity(res, http.StatusOK, group)
+}
+
+func (s *scim) replaceGroup(req *restful.Request, res *restful.Response) {
+	var group apis.SCIMGroup
+	if err := req.ReadEntity(&group); err != nil {
+		returnSCIMError(res, bcode.ErrSCIMInvalidResource.SetMessage(err.Error()))
+		return
+	}
+	updated, err := s.SCIMService.ReplaceGroup(req.Request.Context(), req.PathParameter("id"), group)
+	if err != nil {
+		returnSCIMError(res, err)
+		return
+	}
+	writeSCIMEntity(res, http.StatusOK, updated)
+}
+
+func (s *scim) patchGroup(req *restful.Request, res *restful.Response) {
+	var patch apis.SCIMPatchRequest
+	if err := req.ReadEntity(&patch); err != nil {
+		returnSCIMError(res, bcode.ErrSCIMInvalidPatch.SetMessage(err.Error()))
+		return
+	}
+	updated, err := s.SCIMService.PatchGroup(req.Request.Context(), req.PathParameter("id"), patch)
+	if err != nil {
+		returnSCIMError(res, err)
+		return
+	}
+	writeSCIMEntity(res, http.StatusOK, updated)
+}
+
+func (s *scim) deleteGroup(req *restful.Request, res *restful.Response) {
+	if err := s.SCIMService.DeleteGroup(req.Request.Context(), req.PathParameter("id")); err != nil {
+		returnSCIMError(res, err)
+		return
+	}
+	res.WriteHeader(http.StatusNoContent)
+}
+
+type scimConfig struct {
+	SCIMService    service.SCIMService    `inject:""`
+	RbacService    service.RBACService    `inject:""`
+	SessionService service.SessionService `inject:""`
+}
+
+// NewSCIMConfig new the api to manage the SCIM provisioning
+func NewSCIMConfig() Interface {
+	return &scimConfig{}
+}
+
+func (s *scimConfig) GetWebServiceRoute() *restful.WebService {
+	ws := new(restful.WebService)
+	ws.Path(versionPrefix+"/scim_config").
+		Consumes(restful.MIME_XML, restful.MIME_JSON).
+		Produces(restful.MIME_JSON, restful.MIME_XML).
+		Doc("api for the SCIM provisioning config")
+
+	tags := []string{"scim"}
+
+	ws.Route(ws.GET("/").To(s.getConfig).
+		Doc("get the SCIM config").
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Filter(s.RbacService.CheckPerm("systemSetting", "detail")).
+		Returns(200, "OK", apis.SCIMConfig{}).
+		Returns(400, "Bad Request", bcode.Bcode{}).
+		Writes(apis.SCIMConfig{}))
+
+	ws.Route(ws.PUT("/").To(s.updateConfig).
+		Doc("update the SCIM config and the group rules").
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Filter(s.RbacService.CheckPerm("systemSetting", "update")).
+		Reads(apis.UpdateSCIMConfigRequest{}).
+		Returns(200, "OK", apis.SCIMConfig{}).
+		Returns(400, "Bad Request", bcode.Bcode{}).
+		Writes(apis.SCIMConfig{}))
+
+	ws.Route(ws.POST("/token").To(s.generateToken).
+		Doc("generate the bearer token of the SCIM client, the previous token is invalid after it").
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Filter(s.RbacService.CheckPerm("systemSetting", "update")).
+		Returns(200, "OK", apis.SCIMTokenResponse{}).
+		Returns(400, "Bad Request", bcode.Bcode{}).
+		Writes(apis.SCIMTokenResponse{}))
+
+	ws.Filter(authCheckFilter(s.SessionService))
+	return ws
+}
+
+func (s *scimConfig) getConfig(req *restful.Request, res *restful.Response) {
+	config, err := s.SCIMService.GetConfig(req.Request.Context())
+	if err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+	if err := res.WriteEntity(config); err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+}
+
+func (s *scimConfig) updateConfig(req *restful.Request, res *restful.Response) {
+	var updateReq apis.UpdateSCIMConfigRequest
+	if err := req.ReadEntity(&updateReq); err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+	if err := validate.Struct(&updateReq); err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+	config, err := s.SCIMService.UpdateConfig(req.Request.Context(), updateReq)
+	if err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+	if err := res.WriteEntity(config); err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+}
+
+func (s *scimConfig) generateToken(req *restful.Request, res *restful.Response) {
+	token, err := s.SCIMService.GenerateToken(req.Request.Context())
+	if err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+	if err := res.WriteEntity(token); err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+}
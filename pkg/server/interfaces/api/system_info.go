@@ -28,6 +28,7 @@ import (
 type systemInfo struct {
 	SystemInfoService service.SystemInfoService `inject:""`
 	RbacService       service.RBACService       `inject:""`
+	SessionService    service.SessionService    `inject:""`
 }
 
 // NewSystemInfo return systemInfo
@@ -60,7 +61,15 @@ func (u systemInfo) GetWebServiceRoute() *restful.WebService {
 		Returns(400, "Bad Request", bcode.Bcode{}).
 		Writes(apis.SystemInfoResponse{}))
 
-	ws.Filter(authCheckFilter)
+	ws.Route(ws.POST("/signing_key/rotate").To(u.rotateSigningKey).
+		Doc("rotate the key to sign the tokens, the issued tokens are valid until they expire").
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Filter(u.RbacService.CheckPerm("systemSetting", "update")).
+		Returns(200, "OK", apis.RotateSigningKeyResponse{}).
+		Returns(400, "Bad Request", bcode.Bcode{}).
+		Writes(apis.RotateSigningKeyResponse{}))
+
+	ws.Filter(authCheckFilter(u.SessionService))
 	return ws
 }
 
@@ -102,3 +111,15 @@ func (u systemInfo) updateSystemInfo(req *restful.Request, res *restful.Response
 		return
 	}
 }
+
+func (u systemInfo) rotateSigningKey(req *restful.Request, res *restful.Response) {
+	resp, err := u.SystemInfoService.RotateSigningKey(req.Request.Context())
+	if err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+	if err := res.WriteEntity(resp); err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+}
@@ -44,6 +44,9 @@ type Target struct {
 	TargetService      service.TargetService      `inject:""`
 	ApplicationService service.ApplicationService `inject:""`
 	RbacService        service.RBACService        `inject:""`
+
+	NamespaceReconcileService service.NamespaceReconcileService `inject:""`
+	SessionService            service.SessionService            `inject:""`
 }
 
 // GetWebServiceRoute get web service
@@ -103,7 +106,18 @@ func (dt *Target) GetWebServiceRoute() *restful.WebService {
 		Returns(200, "OK", apis.EmptyResponse{}).
 		Writes(apis.EmptyResponse{}).Do(returns200, returns500))
 
-	ws.Filter(authCheckFilter)
+	ws.Route(ws.POST("/{targetName}/reconcile").To(dt.reconcileTarget).
+		Doc("check the namespace and the privileges of the Target, the drifts could be repaired").
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Filter(dt.targetCheckFilter).
+		Filter(dt.RbacService.CheckPerm("target", "update")).
+		Param(ws.PathParameter("targetName", "identifier of the Target").DataType("string")).
+		Param(ws.QueryParameter("repair", "repair the drifts").DataType("boolean")).
+		Returns(200, "OK", model.NamespaceHealth{}).
+		Returns(400, "Bad Request", bcode.Bcode{}).
+		Writes(model.NamespaceHealth{}).Do(returns200, returns500))
+
+	ws.Filter(authCheckFilter(dt.SessionService))
 	return ws
 }
 
@@ -218,3 +232,16 @@ func (dt *Target) listTargets(req *restful.Request, res *restful.Response) {
 		return
 	}
 }
+
+func (dt *Target) reconcileTarget(req *restful.Request, res *restful.Response) {
+	target := req.Request.Context().Value(&apis.CtxKeyTarget).(*model.Target)
+	health, err := dt.NamespaceReconcileService.ReconcileTarget(req.Request.Context(), target, req.QueryParameter("repair") == "true")
+	if err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+	if err := res.WriteEntity(health); err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+}
@@ -30,8 +30,9 @@ import (
 )
 
 type user struct {
-	UserService service.UserService `inject:""`
-	RbacService service.RBACService `inject:""`
+	UserService    service.UserService    `inject:""`
+	RbacService    service.RBACService    `inject:""`
+	SessionService service.SessionService `inject:""`
 }
 
 // NewUser is the  of user
@@ -120,7 +121,47 @@ func (c *user) GetWebServiceRoute() *restful.WebService {
 		Returns(400, "Bad Request", bcode.Bcode{}).
 		Writes(apis.EmptyResponse{}))
 
-	ws.Filter(authCheckFilter)
+	ws.Route(ws.PUT("/{username}/unlock").To(c.unlockUser).
+		Doc("unlock a user who is locked because of too many failed login attempts").
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Param(ws.PathParameter("username", "identifier of a user").DataType("string").Required(true)).
+		Filter(c.RbacService.CheckPerm("user", "unlock")).
+		Filter(c.userCheckFilter).
+		Returns(200, "OK", apis.EmptyResponse{}).
+		Returns(400, "Bad Request", bcode.Bcode{}).
+		Writes(apis.EmptyResponse{}))
+
+	ws.Route(ws.DELETE("/{username}/totp").To(c.resetTOTP).
+		Doc("remove the TOTP authenticator of a user who lost it").
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Param(ws.PathParameter("username", "identifier of a user").DataType("string").Required(true)).
+		Filter(c.RbacService.CheckPerm("user", "update")).
+		Filter(c.userCheckFilter).
+		Returns(200, "OK", apis.EmptyResponse{}).
+		Returns(400, "Bad Request", bcode.Bcode{}).
+		Writes(apis.EmptyResponse{}))
+
+	ws.Route(ws.GET("/{username}/sessions").To(c.listUserSessions).
+		Doc("list the active sessions of a user").
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Param(ws.PathParameter("username", "identifier of a user").DataType("string").Required(true)).
+		Filter(c.RbacService.CheckPerm("user", "detail")).
+		Filter(c.userCheckFilter).
+		Returns(200, "OK", apis.ListSessionResponse{}).
+		Returns(400, "Bad Request", bcode.Bcode{}).
+		Writes(apis.ListSessionResponse{}))
+
+	ws.Route(ws.DELETE("/{username}/sessions").To(c.revokeUserSessions).
+		Doc("revoke all sessions of a user").
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Param(ws.PathParameter("username", "identifier of a user").DataType("string").Required(true)).
+		Filter(c.RbacService.CheckPerm("user", "update")).
+		Filter(c.userCheckFilter).
+		Returns(200, "OK", apis.EmptyResponse{}).
+		Returns(400, "Bad Request", bcode.Bcode{}).
+		Writes(apis.EmptyResponse{}))
+
+	ws.Filter(authCheckFilter(c.SessionService))
 	return ws
 }
 
@@ -248,3 +289,54 @@ func (c *user) enableUser(req *restful.Request, res *restful.Response) {
 		return
 	}
 }
+
+func (c *user) unlockUser(req *restful.Request, res *restful.Response) {
+	user := req.Request.Context().Value(&apis.CtxKeyUser).(*model.User)
+	err := c.UserService.UnlockUser(req.Request.Context(), user)
+	if err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+	if err := res.WriteEntity(apis.EmptyResponse{}); err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+}
+
+func (c *user) resetTOTP(req *restful.Request, res *restful.Response) {
+	user := req.Request.Context().Value(&apis.CtxKeyUser).(*model.User)
+	err := c.UserService.DisableTOTP(req.Request.Context(), user)
+	if err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+	if err := res.WriteEntity(apis.EmptyResponse{}); err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+}
+
+func (c *user) listUserSessions(req *restful.Request, res *restful.Response) {
+	user := req.Request.Context().Value(&apis.CtxKeyUser).(*model.User)
+	resp, err := c.SessionService.ListSessions(req.Request.Context(), user.Name)
+	if err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+	if err := res.WriteEntity(resp); err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+}
+
+func (c *user) revokeUserSessions(req *restful.Request, res *restful.Response) {
+	user := req.Request.Context().Value(&apis.CtxKeyUser).(*model.User)
+	if err := c.SessionService.RevokeUserSessions(req.Request.Context(), user.Name); err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+	if err := res.WriteEntity(apis.EmptyResponse{}); err != nil {
+		bcode.ReturnError(req, res, err)
+		return
+	}
+}
@@ -26,8 +26,9 @@ import (
 )
 
 type velaQL struct {
-	VelaQLService service.VelaQLService `inject:""`
-	RbacService   service.RBACService   `inject:""`
+	VelaQLService  service.VelaQLService  `inject:""`
+	RbacService    service.RBACService    `inject:""`
+	SessionService service.SessionService `inject:""`
 }
 
 // NewVelaQL new velaQL
@@ -54,7 +55,7 @@ func (v *velaQL) GetWebServiceRoute() *restful.WebService {
 		Returns(400, "Bad Request", bcode.Bcode{}).
 		Writes(apis.VelaQLViewResponse{}))
 
-	ws.Filter(authCheckFilter)
+	ws.Filter(authCheckFilter(v.SessionService))
 	return ws
 }
 
@@ -19,9 +19,11 @@ package server
 import (
 	"context"
 	"fmt"
+	"math"
 	"net/http"
 	"net/http/httputil"
 	"net/url"
+	"strconv"
 	"time"
 
 	"cuelang.org/go/pkg/strings"
@@ -36,6 +38,7 @@ import (
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
+	"github.com/kubevela/pkg/multicluster"
 	"github.com/oam-dev/kubevela/apis/types"
 	pkgaddon "github.com/oam-dev/kubevela/pkg/addon"
 	pkgconfig "github.com/oam-dev/kubevela/pkg/config"
@@ -43,6 +46,7 @@ import (
 	pkgUtils "github.com/oam-dev/kubevela/pkg/utils"
 	"github.com/oam-dev/kubevela/pkg/utils/apply"
 
+	"github.com/kubevela/velaux/pkg/plugin/limiter"
 	"github.com/kubevela/velaux/pkg/plugin/proxy"
 	"github.com/kubevela/velaux/pkg/plugin/router"
 	plugintypes "github.com/kubevela/velaux/pkg/plugin/types"
@@ -54,6 +58,7 @@ import (
 	"github.com/kubevela/velaux/pkg/server/infrastructure/datastore/kubeapi"
 	"github.com/kubevela/velaux/pkg/server/infrastructure/datastore/mongodb"
 	"github.com/kubevela/velaux/pkg/server/interfaces/api"
+	apisv1 "github.com/kubevela/velaux/pkg/server/interfaces/api/dto/v1"
 	"github.com/kubevela/velaux/pkg/server/utils"
 	"github.com/kubevela/velaux/pkg/server/utils/bcode"
 	"github.com/kubevela/velaux/pkg/server/utils/container"
@@ -87,15 +92,17 @@ type APIServer interface {
 
 // restServer rest server
 type restServer struct {
-	webContainer  *restful.Container
-	beanContainer *container.Container
-	cfg           config.Config
-	dataStore     datastore.DataStore
-	PluginService service.PluginService `inject:""`
-	KubeClient    client.Client         `inject:"kubeClient"`
-	KubeConfig    *rest.Config          `inject:"kubeConfig"`
-	RBACService   service.RBACService   `inject:""`
-	UserService   service.UserService   `inject:""`
+	webContainer   *restful.Container
+	beanContainer  *container.Container
+	cfg            config.Config
+	dataStore      datastore.DataStore
+	PluginService  service.PluginService  `inject:""`
+	KubeClient     client.Client          `inject:"kubeClient"`
+	KubeConfig     *rest.Config           `inject:"kubeConfig"`
+	RBACService    service.RBACService    `inject:""`
+	UserService    service.UserService    `inject:""`
+	SessionService service.SessionService `inject:""`
+	ClusterService service.ClusterService `inject:""`
 }
 
 // New create api server with config data
@@ -200,6 +207,13 @@ func (s *restServer) Run(ctx context.Context, errChan chan error) error {
 		return err
 	}
 
+	// The cached router and proxy of the plugin should be rebuilt after the plugin reloaded.
+	s.PluginService.AddChangeListener(func(event apisv1.PluginEvent) {
+		router.InvalidateCache(event.PluginID)
+		proxy.InvalidateCache(event.PluginID)
+		limiter.InvalidateCache(event.PluginID)
+	})
+
 	// init database
 	if err := service.InitData(ctx); err != nil {
 		return fmt.Errorf("fail to init database %w", err)
@@ -359,7 +373,7 @@ func (s *restServer) ServeHTTP(res http.ResponseWriter, req *http.Request) {
 		utils.NewFilterChain(s.getPluginAssets).ProcessFilter(req, res)
 		return
 	case strings.HasPrefix(req.URL.Path, PluginProxyRoutePath):
-		utils.NewFilterChain(s.proxyPluginBackend, api.AuthTokenCheck, api.AuthUserCheck(s.UserService)).ProcessFilter(req, res)
+		utils.NewFilterChain(s.proxyPluginBackend, api.AuthTokenCheck(s.SessionService), api.AuthUserCheck(s.UserService)).ProcessFilter(req, res)
 		return
 	case strings.HasPrefix(req.URL.Path, DexRoutePath):
 		s.proxyDexService(res, req)
@@ -445,16 +459,61 @@ func (s *restServer) pluginBackendProxyHandler(w http.ResponseWriter, r *http.Re
 	if !s.RBACService.CheckPluginRequestPerm(p, route)(r, w) {
 		return
 	}
+	// Check the limits
+	userName, _ := r.Context().Value(&apisv1.CtxKeyUser).(string)
+	if allowed, retryAfter := limiter.Allow(plugin, route, userName); !allowed {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+		bcode.ReturnHTTPError(r, w, bcode.ErrPluginRateLimited)
+		return
+	}
+	if maxBodyBytes := plugin.GetMaxBodyBytes(route); maxBodyBytes > 0 && r.Body != nil {
+		if r.ContentLength > maxBodyBytes {
+			bcode.ReturnHTTPError(r, w, bcode.ErrPluginRequestBodyTooLarge)
+			return
+		}
+		r.Body = http.MaxBytesReader(w, r.Body, maxBodyBytes)
+	}
 	pro, err := proxy.NewBackendPluginProxy(plugin, s.KubeClient, s.KubeConfig)
 	if err != nil {
 		bcode.ReturnHTTPError(r, w, err)
 		return
 	}
 	r.URL.Path = strings.Replace(r.URL.Path, "/proxy/plugins/"+plugin.PluginID(), "", 1)
+	if plugin.BackendType == plugintypes.KubeAPI {
+		var ok bool
+		if r, ok = s.withRequestCluster(w, r, p, plugin); !ok {
+			return
+		}
+	}
 	r = r.WithContext(context.WithValue(r.Context(), &proxy.RouteCtxKey, route))
 	pro.Handler(r, w)
 }
 
+// withRequestCluster checking the cluster that the kube-api plugin requests, and set it to the request context.
+func (s *restServer) withRequestCluster(w http.ResponseWriter, r *http.Request, p httprouter.Params, plugin *plugintypes.Plugin) (*http.Request, bool) {
+	cluster := proxy.GetRequestCluster(r, p)
+	if cluster == "" {
+		return r, true
+	}
+	if _, err := s.ClusterService.GetKubeCluster(r.Context(), cluster); err != nil {
+		bcode.ReturnHTTPError(r, w, err)
+		return r, false
+	}
+	if !s.RBACService.CheckClusterRequestPerm(cluster)(r, w) {
+		return r, false
+	}
+	if err := s.PluginService.InitPluginRoleInCluster(r.Context(), plugin, cluster); err != nil {
+		klog.Errorf("failed to init the role of the plugin %s in the cluster %s: %s", plugin.PluginID(), cluster, err.Error())
+		bcode.ReturnHTTPError(r, w, bcode.ErrPluginClusterRoleFailed)
+		return r, false
+	}
+	// The route like /clusters/:cluster/api/v1/nodes is proxied to the /api/v1/nodes of the cluster.
+	if p.ByName(proxy.ClusterParamKey) != "" {
+		r.URL.Path = strings.Replace(r.URL.Path, "/clusters/"+cluster, "", 1)
+	}
+	return r.WithContext(multicluster.WithCluster(r.Context(), cluster)), true
+}
+
 func (s *restServer) proxyDexService(res http.ResponseWriter, req *http.Request) {
 	if s.cfg.DexServerURL == "" {
 		bcode.ReturnHTTPError(req, res, bcode.ErrNotFound)
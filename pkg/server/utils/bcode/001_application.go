@@ -102,3 +102,9 @@ var ErrApplicationDryRunFailed = NewBcode(400, 10027, "The application dry run f
 
 // ErrApplicationRevisionConflict -
 var ErrApplicationRevisionConflict = NewBcode(400, 10028, "The current revision of the application is equal to the requested revision")
+
+// ErrApplicationEnvMismatch means the envs of the destination project do not match the envs bound by the application
+var ErrApplicationEnvMismatch = NewBcode(400, 10029, "the envs of the destination project do not match the envs of the application")
+
+// ErrApplicationTransferConflict means the application can not be transferred to the destination
+var ErrApplicationTransferConflict = NewBcode(400, 10030, "the application can not be transferred to the destination")
@@ -45,3 +45,36 @@ var ErrProjectUserExist = NewBcode(400, 30009, "the user is already exist in thi
 
 // ErrProjectOwnerInvalid means the project owner name is invalid
 var ErrProjectOwnerInvalid = NewBcode(400, 30010, "the project owner name is invalid")
+
+// ErrProjectTemplateNotExist means the project template is not exist
+var ErrProjectTemplateNotExist = NewBcode(404, 30011, "the project template is not exist")
+
+// ErrProjectTemplateExist means the project template is already exist
+var ErrProjectTemplateExist = NewBcode(400, 30012, "the project template is already exist")
+
+// ErrProjectTemplateVariable means the variables of the project template are invalid
+var ErrProjectTemplateVariable = NewBcode(400, 30013, "the variables of the project template are invalid")
+
+// ErrProjectTemplateInvalid means the project template is invalid
+var ErrProjectTemplateInvalid = NewBcode(400, 30014, "the project template is invalid")
+
+// ErrProjectTemplateConflict means the resources declared by the project template already exist
+var ErrProjectTemplateConflict = NewBcode(400, 30015, "the resources declared by the project template already exist")
+
+// ErrProjectQuotaExceeded means the quota of the project is exceeded
+var ErrProjectQuotaExceeded = NewBcode(400, 30016, "the quota of the project is exceeded")
+
+// ErrProjectQuotaInvalid means the quota of the project is invalid
+var ErrProjectQuotaInvalid = NewBcode(400, 30017, "the quota of the project is invalid")
+
+// ErrProjectArchived means the project is archived and read-only
+var ErrProjectArchived = NewBcode(400, 30018, "the project is archived and read-only, restore it first")
+
+// ErrProjectNotArchived means the project is not archived
+var ErrProjectNotArchived = NewBcode(400, 30019, "the project is not archived")
+
+// ErrProjectRetentionExpired means the retention period of the archived project is expired
+var ErrProjectRetentionExpired = NewBcode(400, 30020, "the retention period of the archived project is expired, it can not be restored")
+
+// ErrProjectDeleting means the project is being deleted
+var ErrProjectDeleting = NewBcode(400, 30021, "the project is being deleted")
@@ -60,3 +60,18 @@ var ErrInvalidAccessKeyOrSecretKey = NewBcode(400, 40013, "access key or secret
 
 // ErrClusterCreateNamespaceNoPermission cluster create namespace is forbidden
 var ErrClusterCreateNamespaceNoPermission = NewBcode(401, 40014, "no permission to create namespace in cluster")
+
+// ErrClusterHealthHistoryInvalidTime means the time to query the health history is invalid
+var ErrClusterHealthHistoryInvalidTime = NewBcode(400, 40015, "the time must be in RFC3339 format")
+
+// ErrClusterGroupNotFound means the cluster group is not found
+var ErrClusterGroupNotFound = NewBcode(404, 40016, "the cluster group is not found")
+
+// ErrClusterGroupExist means the cluster group is exist
+var ErrClusterGroupExist = NewBcode(400, 40017, "the cluster group is exist")
+
+// ErrClusterGroupInUse means the cluster group is used by the targets
+var ErrClusterGroupInUse = NewBcode(400, 40018, "the cluster group is used by the targets, can't be deleted")
+
+// ErrClusterGroupEmptySelector means neither the clusters nor the labels are specified for the cluster group
+var ErrClusterGroupEmptySelector = NewBcode(400, 40019, "the clusters or the label selector of the cluster group must be specified")
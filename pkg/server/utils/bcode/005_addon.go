@@ -74,8 +74,59 @@ var (
 	// ErrCloudShellNotInit means the cloudshell CR not created
 	ErrCloudShellNotInit = NewBcode(400, 50021, "Closing the console window and retry")
 
+	// ErrCloudShellLimitExceeded means the number of the active cloud shells reaches the limit of the platform
+	ErrCloudShellLimitExceeded = NewBcode(400, 50036, "the number of the active cloud shells reaches the limit, please retry later")
+
+	// ErrKubeConfigNotExist means the issued kubeconfig is not exist
+	ErrKubeConfigNotExist = NewBcode(404, 50037, "the issued kubeconfig is not exist")
+
+	// ErrKubeConfigInvalidExpiration means the expiration of the kubeconfig is out of the allowed range
+	ErrKubeConfigInvalidExpiration = NewBcode(400, 50038, "the expiration of the kubeconfig is out of the allowed range")
+
+	// ErrKubeConfigRevoked means the issued kubeconfig is already revoked
+	ErrKubeConfigRevoked = NewBcode(400, 50039, "the kubeconfig is already revoked")
+
 	// ErrRegistryNotExist means the specified registry not exist
 	ErrRegistryNotExist = NewBcode(400, 50022, "The specified not exist")
+
+	// ErrAddonOperationInProgress means another operation of the addon is running
+	ErrAddonOperationInProgress = NewBcode(400, 50023, "another operation of the addon is in progress, please retry later")
+
+	// ErrAddonOperationNotExist means the addon operation is not exist
+	ErrAddonOperationNotExist = NewBcode(404, 50024, "the addon operation is not exist")
+
+	// ErrAddonNotEnabled means the addon to upgrade is not enabled
+	ErrAddonNotEnabled = NewBcode(400, 50025, "the addon is not enabled")
+
+	// ErrAddonRolloutNotExist means the addon has no staged rollout
+	ErrAddonRolloutNotExist = NewBcode(404, 50026, "the addon has no staged rollout")
+
+	// ErrAddonRolloutInvalidClusters means the staged clusters are not the target clusters of the addon
+	ErrAddonRolloutInvalidClusters = NewBcode(400, 50027, "the staged clusters must be the target clusters of the addon")
+
+	// ErrAddonRolloutEnabled means the enabled addon can't be upgraded in stages, the addon would be removed from the clusters out of the stage
+	ErrAddonRolloutEnabled = NewBcode(400, 50028, "the staged rollout is only supported when enabling the addon, the enabled addon would be removed from the clusters out of the stage")
+
+	// ErrAddonRolloutPromoted means the rollout of the addon is already promoted
+	ErrAddonRolloutPromoted = NewBcode(400, 50029, "the rollout of the addon is already promoted")
+
+	// ErrOfflineAddonRegistryNotExist means the registry is not an offline addon registry
+	ErrOfflineAddonRegistryNotExist = NewBcode(404, 50030, "the offline addon registry does not exist")
+
+	// ErrOfflineAddonInvalidPackage means the uploaded or imported addon package is invalid
+	ErrOfflineAddonInvalidPackage = NewBcode(400, 50031, "the addon package is invalid")
+
+	// ErrOfflineAddonChecksumMismatch means the checksum of the addon package does not match the expected one
+	ErrOfflineAddonChecksumMismatch = NewBcode(400, 50032, "the sha256 checksum of the addon package does not match")
+
+	// ErrOfflineAddonVersionExist means a different package of the same addon version is already stored
+	ErrOfflineAddonVersionExist = NewBcode(400, 50033, "the addon version already exists with a different package")
+
+	// ErrOfflineAddonNotExist means the addon version is not stored in the offline addon registry
+	ErrOfflineAddonNotExist = NewBcode(404, 50034, "the addon version does not exist in the offline addon registry")
+
+	// ErrOfflineAddonRegistryUnauthorized means the request to the offline addon registry is not authorized
+	ErrOfflineAddonRegistryUnauthorized = NewBcode(401, 50035, "the request to the offline addon registry is not authorized")
 )
 
 // isGithubRateLimit check if error is github rate limit
@@ -27,3 +27,21 @@ var ErrDefinitionTypeNotSupport = NewBcode(400, 70003, "definition type not supp
 
 // ErrInvalidDefinitionUISchema invalid custom definition ui schema
 var ErrInvalidDefinitionUISchema = NewBcode(400, 70004, "invalid custom defnition ui schema")
+
+// ErrDefinitionExist the definition already exists
+var ErrDefinitionExist = NewBcode(400, 70005, "the definition already exists")
+
+// ErrInvalidDefinitionCUE the CUE source of the definition is invalid
+var ErrInvalidDefinitionCUE = NewBcode(400, 70006, "the CUE source of the definition is invalid")
+
+// ErrDefinitionMismatch the name or the type in the CUE source is different from the definition
+var ErrDefinitionMismatch = NewBcode(400, 70007, "the name or the type in the CUE source does not match the definition")
+
+// ErrDefinitionRevisionNotExist the revision of the definition does not exist
+var ErrDefinitionRevisionNotExist = NewBcode(404, 70008, "the revision of the definition does not exist")
+
+// ErrInvalidDefinitionRevision the revision number of the definition is invalid
+var ErrInvalidDefinitionRevision = NewBcode(400, 70009, "the revision of the definition must be a positive integer")
+
+// ErrDefinitionRenderFailed the template of the definition can not be rendered with the properties
+var ErrDefinitionRenderFailed = NewBcode(400, 70010, "failed to render the template of the definition")
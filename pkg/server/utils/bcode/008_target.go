@@ -30,3 +30,9 @@ var ErrTargetNamespaceAlreadyBound = NewBcode(400, 80004, "the namespace specifi
 
 // ErrTargetInvalidWithEmptyClusterOrNamespace indicates the namespace/cluster of target is empty
 var ErrTargetInvalidWithEmptyClusterOrNamespace = NewBcode(400, 80005, "the namespace or cluster of target should not be empty")
+
+// ErrTargetInvalidClusterSelector indicates the target does not select the clusters by exactly one of the cluster name, the labels and the cluster group
+var ErrTargetInvalidClusterSelector = NewBcode(400, 80006, "the target must select the clusters by one of the cluster name, the labels and the cluster group")
+
+// ErrTargetNoMatchedCluster indicates no cluster matches the labels or the cluster group of the target
+var ErrTargetNoMatchedCluster = NewBcode(400, 80007, "no cluster matches the labels or the cluster group of the target")
@@ -41,4 +41,14 @@ var (
 	ErrNoDexConnector = NewBcode(400, 12011, "there is no dex connector")
 	// ErrAdminAlreadyConfigured is the error of admin user is already configured
 	ErrAdminAlreadyConfigured = NewBcode(400, 12012, "admin user is already configured")
+	// ErrNotMFAToken is the error of not a multi-factor authentication token
+	ErrNotMFAToken = NewBcode(401, 12013, "the token is not a multi-factor authentication token")
+	// ErrSessionNotFound is the error of the session not found
+	ErrSessionNotFound = NewBcode(401, 12014, "the session is expired or revoked, please login again")
+	// ErrSessionNotExist is the error of the session to revoke not exist
+	ErrSessionNotExist = NewBcode(404, 12015, "the session is not exist")
+	// ErrRefreshTokenUsed is the error of the refresh token already rotated
+	ErrRefreshTokenUsed = NewBcode(401, 12016, "the refresh token is already used, please login again")
+	// ErrUserDisabled is the error of the token belonging to a disabled user
+	ErrUserDisabled = NewBcode(401, 12017, "the user is disabled")
 )
@@ -39,4 +39,22 @@ var (
 	ErrEmptyAdminEmail = NewBcode(400, 14010, "the admin email is empty, please set the admin email before using sso login")
 	// ErrNoAdminUser is the error of no admin user
 	ErrNoAdminUser = NewBcode(400, 14011, "the admin user is not found, please init the platform first")
+	// ErrUserPasswordPolicy is the error of the password does not meet the password policy
+	ErrUserPasswordPolicy = NewBcode(400, 14012, "the password does not meet the password policy")
+	// ErrUserPasswordReused is the error of the password is used recently
+	ErrUserPasswordReused = NewBcode(400, 14013, "the password is used recently, please choose another one")
+	// ErrUserLocked is the error of user locked
+	ErrUserLocked = NewBcode(401, 14014, "the user is locked")
+	// ErrUserPasswordExpired is the error of the password expired
+	ErrUserPasswordExpired = NewBcode(401, 14015, "the password is expired, please set a new password")
+	// ErrUserNotLocked is the error of user not locked
+	ErrUserNotLocked = NewBcode(400, 14016, "the user is not locked")
+	// ErrTOTPAlreadyEnabled is the error of the TOTP already enabled
+	ErrTOTPAlreadyEnabled = NewBcode(400, 14017, "the TOTP authenticator is already enabled")
+	// ErrTOTPNotEnrolled is the error of the TOTP not enrolled
+	ErrTOTPNotEnrolled = NewBcode(400, 14018, "the TOTP authenticator is not enrolled")
+	// ErrTOTPNotEnabled is the error of the TOTP not enabled
+	ErrTOTPNotEnabled = NewBcode(400, 14019, "the TOTP authenticator is not enabled")
+	// ErrInvalidTOTPCode is the error of invalid TOTP code or recovery code
+	ErrInvalidTOTPCode = NewBcode(401, 14020, "the verification code is invalid")
 )
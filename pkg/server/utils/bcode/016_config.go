@@ -40,4 +40,16 @@ var (
 
 	// ErrChangeSecretType the secret type of the config can not be changed
 	ErrChangeSecretType = NewBcode(400, 16008, "the secret type of the config can not be changed")
+
+	// ErrConfigRevisionNotExist means the revision of the config does not exist
+	ErrConfigRevisionNotExist = NewBcode(404, 16009, "the revision of the config does not exist")
+
+	// ErrInvalidConfigRevision means the revision number of the config is invalid
+	ErrInvalidConfigRevision = NewBcode(400, 16010, "the revision of the config must be a positive integer")
+
+	// ErrSecretStoreNotConfigured means the config refers to the secret store but it is not configured
+	ErrSecretStoreNotConfigured = NewBcode(500, 16011, "the secret store of the sensitive properties is not configured")
+
+	// ErrInvalidSecretStoreReference means the property refers to a value in the secret store that it does not own
+	ErrInvalidSecretStoreReference = NewBcode(400, 16012, "the property can only refer to its own value in the secret store")
 )
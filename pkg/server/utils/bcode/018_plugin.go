@@ -33,3 +33,42 @@ var ErrPluginAlreadyDisabled = NewBcode(400, 18005, "the plugin is already disab
 
 // ErrPluginNotEnabled -
 var ErrPluginNotEnabled = NewBcode(400, 18006, "the plugin is not enabled")
+
+// ErrPluginStorageNotDeclared -
+var ErrPluginStorageNotDeclared = NewBcode(400, 18007, "the plugin does not declare the storage")
+
+// ErrPluginStorageScopeNotAllowed -
+var ErrPluginStorageScopeNotAllowed = NewBcode(400, 18008, "the storage scope is not allowed by the plugin")
+
+// ErrPluginStorageKeyInvalid -
+var ErrPluginStorageKeyInvalid = NewBcode(400, 18009, "the storage key is invalid, it must consist of lower case alphanumeric characters, '-' or '.', and the max length is 63")
+
+// ErrPluginStorageItemNotExist -
+var ErrPluginStorageItemNotExist = NewBcode(404, 18010, "the storage item is not exist")
+
+// ErrPluginStorageValueTooLarge -
+var ErrPluginStorageValueTooLarge = NewBcode(400, 18011, "the size of the value exceeds the limit of the plugin")
+
+// ErrPluginStorageQuotaExceeded -
+var ErrPluginStorageQuotaExceeded = NewBcode(400, 18012, "the storage quota of the plugin is exceeded")
+
+// ErrInvalidPluginEventRevision -
+var ErrInvalidPluginEventRevision = NewBcode(400, 18013, "the revision of the plugin event is invalid")
+
+// ErrPluginHookRejected means the action is refused by a validating hook
+var ErrPluginHookRejected = NewBcode(400, 18014, "the action is refused by the plugin")
+
+// ErrPluginHookFailed means failed to call a validating hook whose failure policy is Fail
+var ErrPluginHookFailed = NewBcode(500, 18015, "failed to call the plugin hook")
+
+// ErrPluginRateLimited means the request exceeds the rate limit of the plugin
+var ErrPluginRateLimited = NewBcode(429, 18016, "too many requests, the rate limit of the plugin is exceeded")
+
+// ErrPluginRequestBodyTooLarge means the request body exceeds the limit of the plugin
+var ErrPluginRequestBodyTooLarge = NewBcode(413, 18017, "the request body is too large")
+
+// ErrPluginClusterRoleFailed means failed to grant the kube permissions of the plugin in the managed cluster
+var ErrPluginClusterRoleFailed = NewBcode(500, 18018, "failed to grant the permissions of the plugin in the cluster")
+
+// ErrPluginHookSecretRequired means the plugin declares hooks but the secret to sign the hook payloads is not set
+var ErrPluginHookSecretRequired = NewBcode(400, 18019, "the plugin declares hooks, the hookSecret is required in the secure json data")
@@ -0,0 +1,47 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bcode
+
+// ErrSCIMDisabled means the SCIM provisioning is disabled
+var ErrSCIMDisabled = NewBcode(403, 19001, "the SCIM provisioning is disabled")
+
+// ErrSCIMUnauthorized means the bearer token is invalid
+var ErrSCIMUnauthorized = NewBcode(401, 19002, "the SCIM token is invalid")
+
+// ErrSCIMInvalidFilter means the filter is not supported
+var ErrSCIMInvalidFilter = NewBcode(400, 19003, "the filter is invalid or not supported")
+
+// ErrSCIMUserExist -
+var ErrSCIMUserExist = NewBcode(409, 19004, "the user already exists")
+
+// ErrSCIMUserNotFound -
+var ErrSCIMUserNotFound = NewBcode(404, 19005, "the user is not found")
+
+// ErrSCIMGroupExist -
+var ErrSCIMGroupExist = NewBcode(409, 19006, "the group already exists")
+
+// ErrSCIMGroupNotFound -
+var ErrSCIMGroupNotFound = NewBcode(404, 19007, "the group is not found")
+
+// ErrSCIMInvalidPatch means the patch operation is invalid
+var ErrSCIMInvalidPatch = NewBcode(400, 19008, "the patch operation is invalid")
+
+// ErrSCIMInvalidResource means the required attributes are missing
+var ErrSCIMInvalidResource = NewBcode(400, 19009, "the resource is invalid")
+
+// ErrSCIMInvalidGroupRule means the group rule is invalid
+var ErrSCIMInvalidGroupRule = NewBcode(400, 19010, "the group rule is invalid")
@@ -26,8 +26,15 @@ const (
 	projectKey contextKey = iota
 	usernameKey
 	permissionKey
+	clientKey
 )
 
+// ClientInfo the information of the client that sends the request
+type ClientInfo struct {
+	IP        string
+	UserAgent string
+}
+
 // WithProject carries project in context
 func WithProject(parent context.Context, project string) context.Context {
 	return context.WithValue(parent, projectKey, project)
@@ -60,3 +67,14 @@ func UserRoleFrom(ctx context.Context) ([]string, bool) {
 	roles, ok := ctx.Value(permissionKey).([]string)
 	return roles, ok
 }
+
+// WithClientInfo carries the client info in context
+func WithClientInfo(parent context.Context, info ClientInfo) context.Context {
+	return context.WithValue(parent, clientKey, info)
+}
+
+// ClientInfoFrom extract the client info from context
+func ClientInfoFrom(ctx context.Context) (ClientInfo, bool) {
+	info, ok := ctx.Value(clientKey).(ClientInfo)
+	return info, ok
+}
@@ -0,0 +1,73 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"encoding/base32"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Test TOTP utils", func() {
+	// The test vectors of RFC 6238 with the SHA1 algorithm, truncated to 6 digits
+	secret := base32.StdEncoding.EncodeToString([]byte("12345678901234567890"))
+
+	It("Test generating the TOTP code", func() {
+		vectors := map[int64]string{
+			59:          "287082",
+			1111111109:  "081804",
+			1111111111:  "050471",
+			1234567890:  "005924",
+			2000000000:  "279037",
+			20000000000: "353130",
+		}
+		for ts, expected := range vectors {
+			code, err := GenerateTOTPCode(secret, time.Unix(ts, 0))
+			Expect(err).Should(BeNil())
+			Expect(code).Should(Equal(expected))
+		}
+		_, err := GenerateTOTPCode("not-base32!", time.Now())
+		Expect(err).ShouldNot(BeNil())
+	})
+
+	It("Test validating the TOTP code", func() {
+		s, err := GenerateTOTPSecret()
+		Expect(err).Should(BeNil())
+		now := time.Now()
+		code, err := GenerateTOTPCode(s, now)
+		Expect(err).Should(BeNil())
+		step, ok := ValidateTOTPCode(s, code, now)
+		Expect(ok).Should(BeTrue())
+		Expect(step).Should(Equal(TOTPStep(now)))
+		step, ok = ValidateTOTPCode(s, code, now.Add(TOTPPeriod))
+		Expect(ok).Should(BeTrue())
+		Expect(step).Should(Equal(TOTPStep(now)))
+		_, ok = ValidateTOTPCode(s, code, now.Add(3*TOTPPeriod))
+		Expect(ok).Should(BeFalse())
+		_, ok = ValidateTOTPCode(s, "12345", now)
+		Expect(ok).Should(BeFalse())
+	})
+
+	It("Test building the key URI", func() {
+		uri := TOTPKeyURI("KubeVela", "admin", "ABC")
+		Expect(uri).Should(HavePrefix("otpauth://totp/KubeVela:admin?"))
+		Expect(uri).Should(ContainSubstring("secret=ABC"))
+		Expect(uri).Should(ContainSubstring("issuer=KubeVela"))
+	})
+})